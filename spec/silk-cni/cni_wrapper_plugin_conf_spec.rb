@@ -59,6 +59,9 @@ module Bosh::Template::Test
           'disableCheck' => true,
           'plugins' => [{
             'type' => 'cni-wrapper-plugin',
+            'capabilities' => {
+              'bandwidth' => true,
+            },
             'datastore' => '/var/vcap/data/container-metadata/store.json',
             'datastore_file_owner' => 'vcap',
             'datastore_file_group' => 'vcap',
@@ -87,9 +90,24 @@ module Bosh::Template::Test
               'name' => 'silk',
               'type' => 'silk-cni',
               'daemonPort' => 8080,
-              'dataDir' => '/var/vcap/data/host-local',
               'datastore' => '/var/vcap/data/silk/store.json',
-              'mtu' => 0
+              'mtu' => 0,
+              'bandwidth' => {
+                'ingressRate' => 100 * 1024,
+                'ingressBurst' => 200 * 1024,
+                'egressRate' => 100 * 1024,
+                'egressBurst' => 200 * 1024
+              },
+              'attachments' => [],
+              'routes' => [],
+              'dns' => {
+                'search' => [],
+                'options' => []
+              },
+              'tuning' => {
+                'sysctls' => {},
+                'txQueueLen' => 0
+              }
             },
             'outbound_connections' => {
               'limit' => true,
@@ -98,13 +116,6 @@ module Bosh::Template::Test
               'rate_per_sec' => 100,
               'dry_run' => false,
             }
-          }, {
-            'name' => 'bandwidth-limit',
-            'type' => 'bandwidth',
-            'ingressRate' => 100 * 1024,
-            'ingressBurst' => 200 * 1024,
-            'egressRate' => 100 * 1024,
-            'egressBurst' => 200 * 1024
           }]
         })
       end
@@ -176,6 +187,110 @@ module Bosh::Template::Test
         end
       end
 
+      context 'when only one of rate and burst is set' do
+        it 'raises a descriptive error' do
+          merged_manifest_properties['burst'] = 0
+          expect {
+            template.render(merged_manifest_properties, spec: spec, consumes: links)
+          }.to raise_error /rate and burst must both be set to limit bandwidth/
+        end
+      end
+
+      context 'when attachments are provided' do
+        it 'passes them to silk-cni' do
+          merged_manifest_properties['attachments'] = [{
+            'if_name' => 'eth1',
+            'name' => 'mesh',
+            'network' => '10.250.0.0/16',
+            'subnet_file' => '/var/vcap/data/mesh/subnet.env',
+          }]
+          clientConfig = JSON.parse(template.render(merged_manifest_properties, spec: spec, consumes: links))
+          expect(clientConfig['plugins'][0]['delegate']['attachments']).to eq([{
+            'ifName' => 'eth1',
+            'name' => 'mesh',
+            'network' => '10.250.0.0/16',
+            'daemonPort' => 0,
+            'subnetFile' => '/var/vcap/data/mesh/subnet.env',
+            'mtu' => 0,
+          }])
+        end
+
+        context 'when a network is invalid' do
+          it 'raises a descriptive error' do
+            merged_manifest_properties['attachments'] = [{
+              'if_name' => 'eth1',
+              'name' => 'mesh',
+              'network' => 'invalid-network',
+            }]
+            expect {
+              template.render(merged_manifest_properties, spec: spec, consumes: links)
+            }.to raise_error /Invalid attachments network 'invalid-network'/
+          end
+        end
+      end
+
+      context 'when routes are provided' do
+        it 'passes them to silk-cni' do
+          merged_manifest_properties['routes'] = [
+            { 'destination' => '192.168.0.0/16' },
+            { 'destination' => '172.16.5.0/24', 'if_name' => 'eth1' },
+            { 'destination' => '172.16.6.0/24', 'if_name' => 'eth1', 'gateway' => '10.0.16.1' },
+          ]
+          clientConfig = JSON.parse(template.render(merged_manifest_properties, spec: spec, consumes: links))
+          expect(clientConfig['plugins'][0]['delegate']['routes']).to eq([
+            { 'dst' => '192.168.0.0/16', 'ifName' => '', 'gw' => '' },
+            { 'dst' => '172.16.5.0/24', 'ifName' => 'eth1', 'gw' => '' },
+            { 'dst' => '172.16.6.0/24', 'ifName' => 'eth1', 'gw' => '10.0.16.1' },
+          ])
+        end
+
+        context 'when a destination is invalid' do
+          it 'raises a descriptive error' do
+            merged_manifest_properties['routes'] = [{ 'destination' => 'invalid-destination' }]
+            expect {
+              template.render(merged_manifest_properties, spec: spec, consumes: links)
+            }.to raise_error /Invalid routes destination 'invalid-destination'/
+          end
+        end
+
+        context 'when a gateway is invalid' do
+          it 'raises a descriptive error' do
+            merged_manifest_properties['routes'] = [{ 'destination' => '172.16.5.0/24', 'gateway' => 'invalid-gateway' }]
+            expect {
+              template.render(merged_manifest_properties, spec: spec, consumes: links)
+            }.to raise_error /Invalid routes gateway 'invalid-gateway'/
+          end
+        end
+      end
+
+      context 'when dns search domains and options are provided' do
+        it 'passes them to silk-cni' do
+          merged_manifest_properties['dns_search_domains'] = ['apps.internal']
+          merged_manifest_properties['dns_options'] = ['ndots:2']
+          clientConfig = JSON.parse(template.render(merged_manifest_properties, spec: spec, consumes: links))
+          expect(clientConfig['plugins'][0]['delegate']['dns']).to eq({
+            'search' => ['apps.internal'],
+            'options' => ['ndots:2'],
+          })
+        end
+      end
+
+      context 'when tuning is provided' do
+        it 'passes it to silk-cni' do
+          merged_manifest_properties['tuning'] = {
+            'sysctls' => { 'net.core.somaxconn' => 4096 },
+            'tx_queue_len' => 500,
+            'gso' => false,
+          }
+          clientConfig = JSON.parse(template.render(merged_manifest_properties, spec: spec, consumes: links))
+          expect(clientConfig['plugins'][0]['delegate']['tuning']).to eq({
+            'sysctls' => { 'net.core.somaxconn' => '4096' },
+            'txQueueLen' => 500,
+            'gso' => false,
+          })
+        end
+      end
+
       context 'when deny_networks are provided' do
         context 'when a destination is IPv6' do
           it 'raises a descriptive error' do
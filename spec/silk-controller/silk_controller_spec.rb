@@ -53,6 +53,14 @@ module Bosh::Template::Test
       end
     end
 
+    describe 'admin_ca.crt' do
+      let(:template) {job.template('config/certs/admin_ca.crt')}
+      it 'writes the content of admin_ca_cert' do
+        merged_manifest_properties['admin_ca_cert'] = 'the admin ca cert'
+        expect(template.render(merged_manifest_properties)).to eq("the admin ca cert\n")
+      end
+    end
+
     describe 'silk-controller.json.erb' do
       let(:template) {job.template('config/silk-controller.json')}
 
@@ -61,13 +69,18 @@ module Bosh::Template::Test
         expect(config).to eq({
           'debug_server_port' => 1234,
           'health_check_port' => 2345,
+          'prometheus_port' => 0,
           'listen_host' => '123.123.2.2',
           'listen_port' => 2222,
           'ca_cert_file' => '/var/vcap/jobs/silk-controller/config/certs/ca.crt',
           'server_cert_file' => '/var/vcap/jobs/silk-controller/config/certs/server.crt',
           'server_key_file' => '/var/vcap/jobs/silk-controller/config/certs/server.key',
+          'admin_listen_port' => 0,
+          'admin_ca_cert_file' => '/var/vcap/jobs/silk-controller/config/certs/admin_ca.crt',
           'network' => ['10.255.0.1/12'],
           'subnet_prefix_length' => 30,
+          'subnet_prefix_length_v6' => 64,
+          'allocation_strategy' => 'random',
           'database' => {
             'type' => 'postgres',
             'user' => 'some-database-username',
@@ -84,6 +97,9 @@ module Bosh::Template::Test
           'metron_port' => 2222,
           'staleness_threshold_seconds' => 60*60,
           'metrics_emit_seconds' => 30,
+          'overlay_networks_refresh_seconds' => 30,
+          'lease_watch_refresh_seconds' => 5,
+          'lease_history_retention_seconds' => 60 * 60 * 720,
           'log_prefix' => 'cfnetworking',
           'max_idle_connections' => 10,
           'max_open_connections' => 1,
@@ -91,12 +107,78 @@ module Bosh::Template::Test
         })
       end
 
+      it 'sets the prometheus port' do
+        merged_manifest_properties['prometheus_port'] = 9090
+        config = JSON.parse(template.render(merged_manifest_properties))
+        expect(config['prometheus_port']).to eq(9090)
+      end
+
+      context 'when admin_listen_port is set' do
+        it 'sets the admin listen port' do
+          merged_manifest_properties['admin_listen_port'] = 4104
+          merged_manifest_properties['admin_ca_cert'] = 'the admin ca cert'
+          config = JSON.parse(template.render(merged_manifest_properties))
+          expect(config['admin_listen_port']).to eq(4104)
+        end
+
+        it 'raises an error when admin_ca_cert is not set' do
+          merged_manifest_properties['admin_listen_port'] = 4104
+          expect {
+            template.render(merged_manifest_properties)
+          }.to raise_error('admin_ca_cert must be set when admin_listen_port is set')
+        end
+
+        it 'raises an error when it is the same as listen_port' do
+          merged_manifest_properties['admin_listen_port'] = 2222
+          merged_manifest_properties['admin_ca_cert'] = 'the admin ca cert'
+          expect {
+            template.render(merged_manifest_properties)
+          }.to raise_error('admin_listen_port must be different from listen_port')
+        end
+      end
+
       it 'uses the database link for host when the property is not set' do
         merged_manifest_properties['database'].delete('host')
         config = JSON.parse(template.render(merged_manifest_properties, consumes: [database_link]))
         expect(config['database']['host']).to eq('link.instance.address.com')
       end
 
+      context 'when ipv6 networks are provided' do
+        it 'renders the ipv6 networks and the ipv6 subnet prefix length' do
+          merged_manifest_properties['network'] = ['10.255.0.0/16', 'fd00::/48']
+          merged_manifest_properties['subnet_prefix_length'] = 24
+          merged_manifest_properties['subnet_prefix_length_v6'] = 64
+          config = JSON.parse(template.render(merged_manifest_properties))
+          expect(config['network']).to eq(['10.255.0.0/16', 'fd00::/48'])
+          expect(config['subnet_prefix_length']).to eq(24)
+          expect(config['subnet_prefix_length_v6']).to eq(64)
+        end
+
+        it 'fails when the ipv6 subnet prefix length is not smaller than the ipv6 network' do
+          merged_manifest_properties['network'] = ['10.255.0.0/16', 'fd00::/64']
+          merged_manifest_properties['subnet_prefix_length'] = 24
+          merged_manifest_properties['subnet_prefix_length_v6'] = 64
+          expect {
+            template.render(merged_manifest_properties)
+          }.to raise_error (/subnet_prefix_length_v6 '64' must be smaller than the network 'fd00::\/64'/)
+        end
+      end
+
+      context 'when subnet_allocation_strategy is set' do
+        it 'renders the allocation strategy' do
+          merged_manifest_properties['subnet_allocation_strategy'] = 'consistent-hash'
+          config = JSON.parse(template.render(merged_manifest_properties))
+          expect(config['allocation_strategy']).to eq('consistent-hash')
+        end
+
+        it 'fails when the allocation strategy is unknown' do
+          merged_manifest_properties['subnet_allocation_strategy'] = 'banana'
+          expect {
+            template.render(merged_manifest_properties)
+          }.to raise_error (/subnet_allocation_strategy must be one of random, lowest-free, least-recently-released, consistent-hash/)
+        end
+      end
+
       context 'when ips have leading 0s' do
         it 'network fails with a nice message' do
           merged_manifest_properties['network'] = '10.255.0.01/12'
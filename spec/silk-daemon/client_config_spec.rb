@@ -54,25 +54,35 @@ module Bosh::Template::Test
             expect(clientConfig).to eq({
               'underlay_ip' => '192.168.0.0',
               'subnet_prefix_length' => 24,
+              'subnet_prefix_length_v6' => 64,
               'overlay_network' => ['10.255.0.0/16'],
               'health_check_port' => 12345,
               'vtep_name' => 'silk-vtep',
               'connectivity_server_url' => 'https://some-host:12345',
+              'connectivity_server_urls' => [],
               'ca_cert_file' => '/var/vcap/jobs/silk-daemon/config/certs/ca.crt',
               'client_cert_file' => '/var/vcap/jobs/silk-daemon/config/certs/client.crt',
               'client_key_file' => '/var/vcap/jobs/silk-daemon/config/certs/client.key',
               'vni' => 1,
               'poll_interval' => 30,
+              'poll_jitter_percent' => 10,
+              'poll_max_backoff_seconds' => 300,
+              'lease_watch_seconds' => 0,
               'debug_server_port' => 89,
               'datastore' => '/var/vcap/data/silk/store.json',
               'partition_tolerance_seconds' => 3600,
               'client_timeout_seconds' => 5,
               'metron_port' => 5678,
               'vtep_port' => 6666,
+              'vtep_mtu' => 0,
+              'encapsulation' => 'vxlan-gbp',
               'log_prefix' => 'cfnetworking',
               'log_level' => 'error',
               'vxlan_interface_name' => '',
-              'single_ip_only' => true
+              'single_ip_only' => true,
+              'force_vtep_recreate' => false,
+              'release_lease_on_shutdown' => false,
+              'lease_release_file' => '/var/vcap/data/silk-daemon/lease-released'
             })
           end
 
@@ -104,6 +114,48 @@ module Bosh::Template::Test
             end
           end
 
+          context 'when lease_watch_seconds is set' do
+            let(:merged_manifest_properties) do
+              {
+                'lease_watch_seconds' => 30,
+              }
+            end
+
+            it 'sets lease_watch_seconds' do
+              clientConfig = JSON.parse(template.render(merged_manifest_properties, consumes: links))
+              expect(clientConfig['lease_watch_seconds']).to eq(30)
+            end
+          end
+
+          context 'when silk_controller.hostnames is set' do
+            let(:merged_manifest_properties) do
+              {
+                'silk_controller' => {
+                  'hostnames' => ['silk-controller-0', 'silk-controller-1'],
+                  'listen_port' => 4103,
+                },
+              }
+            end
+
+            it 'sets connectivity_server_urls' do
+              clientConfig = JSON.parse(template.render(merged_manifest_properties, consumes: links))
+              expect(clientConfig['connectivity_server_urls']).to eq(['https://silk-controller-0:4103', 'https://silk-controller-1:4103'])
+            end
+          end
+
+          context 'when release_lease_on_shutdown is set' do
+            let(:merged_manifest_properties) do
+              {
+                'release_lease_on_shutdown' => true,
+              }
+            end
+
+            it 'sets release_lease_on_shutdown' do
+              clientConfig = JSON.parse(template.render(merged_manifest_properties, consumes: links))
+              expect(clientConfig['release_lease_on_shutdown']).to eq(true)
+            end
+          end
+
           context 'when vxlan_network is set' do
             let(:merged_manifest_properties) do
               {
@@ -132,6 +184,28 @@ module Bosh::Template::Test
             end
           end
 
+          context 'when the cf_network link provides ipv6 networks' do
+            let(:links) do
+              [
+                Link.new(
+                  name: 'cf_network',
+                  instances: [LinkInstance.new()],
+                  properties: {
+                    'network' => ['10.255.0.0/16', 'fd00::/48'],
+                    'subnet_prefix_length' => 24,
+                    'subnet_prefix_length_v6' => 60
+                  }
+                )
+              ]
+            end
+
+            it 'renders the ipv6 networks and subnet prefix length' do
+              clientConfig = JSON.parse(template.render(merged_manifest_properties, consumes: links))
+              expect(clientConfig['overlay_network']).to eq(['10.255.0.0/16', 'fd00::/48'])
+              expect(clientConfig['subnet_prefix_length_v6']).to eq(60)
+            end
+          end
+
           context 'when network is a single string instead of an array' do
             let(:links) do
               [
@@ -23,6 +23,18 @@ type Delegator struct {
 		result1 types.Result
 		result2 error
 	}
+	DelegateCheckStub        func(string, []byte) error
+	delegateCheckMutex       sync.RWMutex
+	delegateCheckArgsForCall []struct {
+		arg1 string
+		arg2 []byte
+	}
+	delegateCheckReturns struct {
+		result1 error
+	}
+	delegateCheckReturnsOnCall map[int]struct {
+		result1 error
+	}
 	DelegateDelStub        func(string, []byte) error
 	delegateDelMutex       sync.RWMutex
 	delegateDelArgsForCall []struct {
@@ -109,6 +121,73 @@ func (fake *Delegator) DelegateAddReturnsOnCall(i int, result1 types.Result, res
 	}{result1, result2}
 }
 
+func (fake *Delegator) DelegateCheck(arg1 string, arg2 []byte) error {
+	var arg2Copy []byte
+	if arg2 != nil {
+		arg2Copy = make([]byte, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.delegateCheckMutex.Lock()
+	ret, specificReturn := fake.delegateCheckReturnsOnCall[len(fake.delegateCheckArgsForCall)]
+	fake.delegateCheckArgsForCall = append(fake.delegateCheckArgsForCall, struct {
+		arg1 string
+		arg2 []byte
+	}{arg1, arg2Copy})
+	stub := fake.DelegateCheckStub
+	fakeReturns := fake.delegateCheckReturns
+	fake.recordInvocation("DelegateCheck", []interface{}{arg1, arg2Copy})
+	fake.delegateCheckMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Delegator) DelegateCheckCallCount() int {
+	fake.delegateCheckMutex.RLock()
+	defer fake.delegateCheckMutex.RUnlock()
+	return len(fake.delegateCheckArgsForCall)
+}
+
+func (fake *Delegator) DelegateCheckCalls(stub func(string, []byte) error) {
+	fake.delegateCheckMutex.Lock()
+	defer fake.delegateCheckMutex.Unlock()
+	fake.DelegateCheckStub = stub
+}
+
+func (fake *Delegator) DelegateCheckArgsForCall(i int) (string, []byte) {
+	fake.delegateCheckMutex.RLock()
+	defer fake.delegateCheckMutex.RUnlock()
+	argsForCall := fake.delegateCheckArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *Delegator) DelegateCheckReturns(result1 error) {
+	fake.delegateCheckMutex.Lock()
+	defer fake.delegateCheckMutex.Unlock()
+	fake.DelegateCheckStub = nil
+	fake.delegateCheckReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *Delegator) DelegateCheckReturnsOnCall(i int, result1 error) {
+	fake.delegateCheckMutex.Lock()
+	defer fake.delegateCheckMutex.Unlock()
+	fake.DelegateCheckStub = nil
+	if fake.delegateCheckReturnsOnCall == nil {
+		fake.delegateCheckReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.delegateCheckReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *Delegator) DelegateDel(arg1 string, arg2 []byte) error {
 	var arg2Copy []byte
 	if arg2 != nil {
@@ -181,6 +260,8 @@ func (fake *Delegator) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.delegateAddMutex.RLock()
 	defer fake.delegateAddMutex.RUnlock()
+	fake.delegateCheckMutex.RLock()
+	defer fake.delegateCheckMutex.RUnlock()
 	fake.delegateDelMutex.RLock()
 	defer fake.delegateDelMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
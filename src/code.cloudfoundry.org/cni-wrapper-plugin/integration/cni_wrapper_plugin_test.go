@@ -596,6 +596,50 @@ var _ = Describe("CniWrapperPlugin", func() {
 			})
 		})
 
+		Context("when the delegate returns DNS settings", func() {
+			BeforeEach(func() {
+				debug.ReportResult = `{
+					"cniVersion": "1.0.0",
+					"ips": [{ "interface": -1, "address": "1.2.3.4/32" }],
+					"dns": {"nameservers": ["169.254.0.3"], "search": ["apps.internal"], "options": ["ndots:2"]}
+				}`
+				Expect(debug.WriteDebug(debugFileName)).To(Succeed())
+			})
+
+			It("returns them when no DNS servers are configured", func() {
+				session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(session).Should(gexec.Exit(0))
+
+				Expect(session.Out.Contents()).To(MatchJSON(`{
+						"cniVersion": "1.0.0",
+						"ips": [{ "interface": -1, "address": "1.2.3.4/32" }],
+						"dns": {"nameservers": ["169.254.0.3"], "search": ["apps.internal"], "options": ["ndots:2"]}
+					}`))
+			})
+
+			Context("when DNS servers are configured", func() {
+				BeforeEach(func() {
+					inputStruct.DNSServers = []string{"8.8.4.4"}
+					input = GetInput(inputStruct)
+
+					cmd = cniCommand("ADD", input)
+				})
+
+				It("replaces only the nameservers", func() {
+					session, err := gexec.Start(cmd, GinkgoWriter, GinkgoWriter)
+					Expect(err).NotTo(HaveOccurred())
+					Eventually(session).Should(gexec.Exit(0))
+
+					Expect(session.Out.Contents()).To(MatchJSON(`{
+						"cniVersion": "1.0.0",
+						"ips": [{ "interface": -1, "address": "1.2.3.4/32" }],
+						"dns": {"nameservers": ["8.8.4.4"], "search": ["apps.internal"], "options": ["ndots:2"]}
+					}`))
+				})
+			})
+		})
+
 		Context("when some of the DNS servers are not valid IPs", func() {
 			BeforeEach(func() {
 				inputStruct.DNSServers = []string{"1.2.3.4", "banana"}
@@ -11,6 +11,7 @@ import (
 type Delegator interface {
 	DelegateAdd(delegatePlugin string, netconf []byte) (types.Result, error)
 	DelegateDel(delegatePlugin string, netconf []byte) error
+	DelegateCheck(delegatePlugin string, netconf []byte) error
 }
 
 type delegator struct{}
@@ -23,4 +24,8 @@ func (*delegator) DelegateDel(delegatePlugin string, netconf []byte) error {
 	return invoke.DelegateDel(context.Background(), delegatePlugin, netconf, nil)
 }
 
+func (*delegator) DelegateCheck(delegatePlugin string, netconf []byte) error {
+	return invoke.DelegateCheck(context.Background(), delegatePlugin, netconf, nil)
+}
+
 func NewDelegator() Delegator { return &delegator{} }
@@ -13,8 +13,9 @@ import (
 )
 
 type RuntimeConfig struct {
-	PortMappings []garden.NetIn      `json:"portMappings"`
-	NetOutRules  []garden.NetOutRule `json:"netOutRules"`
+	PortMappings []garden.NetIn         `json:"portMappings"`
+	NetOutRules  []garden.NetOutRule    `json:"netOutRules"`
+	Bandwidth    map[string]interface{} `json:"bandwidth,omitempty"`
 }
 
 type DenyNetworksConfig struct {
@@ -55,6 +56,7 @@ type WrapperConfig struct {
 	RuntimeConfig                   RuntimeConfig          `json:"runtimeConfig,omitempty"`
 	PolicyAgentForcePollAddress     string                 `json:"policy_agent_force_poll_address" validate:"nonzero"`
 	OutConn                         OutConnConfig          `json:"outbound_connections"`
+	RawPrevResult                   map[string]interface{} `json:"prevResult,omitempty"`
 }
 
 func LoadWrapperConfig(bytes []byte) (*WrapperConfig, error) {
@@ -99,6 +101,14 @@ func LoadWrapperConfig(bytes []byte) (*WrapperConfig, error) {
 		n.Delegate["cniVersion"] = "1.0.0"
 	}
 
+	// the delegate limits the bandwidth of the container, so it needs the
+	// limits the runtime passed for it
+	if n.RuntimeConfig.Bandwidth != nil {
+		n.Delegate["runtimeConfig"] = map[string]interface{}{
+			"bandwidth": n.RuntimeConfig.Bandwidth,
+		}
+	}
+
 	if n.OutConn.Burst <= 0 {
 		return nil, fmt.Errorf("invalid outbound connection burst")
 	}
@@ -152,6 +162,23 @@ func (c *PluginController) DelegateDel(netconf map[string]interface{}) error {
 	return c.Delegator.DelegateDel(delegateType, netconfBytes)
 }
 
+// DelegateCheck passes the cached result on to the delegate plugin, as it
+// is required by the CNI spec for CHECK.
+func (c *PluginController) DelegateCheck(netconf map[string]interface{}, prevResult map[string]interface{}) error {
+	checkNetconf := make(map[string]interface{}, len(netconf)+1)
+	for k, v := range netconf {
+		checkNetconf[k] = v
+	}
+	checkNetconf["prevResult"] = prevResult
+
+	delegateType, netconfBytes, err := getDelegateParams(checkNetconf)
+	if err != nil {
+		return err
+	}
+
+	return c.Delegator.DelegateCheck(delegateType, netconfBytes)
+}
+
 func (c *PluginController) AddIPMasq(ip, noMasqueradeCIDRRange, deviceName string) error {
 	rule := rules.NewDefaultEgressRule(ip, noMasqueradeCIDRRange, deviceName)
 
@@ -171,3 +198,17 @@ func (c *PluginController) DelIPMasq(ip, noMasqueradeCIDRRange, deviceName strin
 
 	return nil
 }
+
+func (c *PluginController) CheckIPMasq(ip, noMasqueradeCIDRRange, deviceName string) error {
+	rule := rules.NewDefaultEgressRule(ip, noMasqueradeCIDRRange, deviceName)
+
+	exists, err := c.IPTables.Exists("nat", "POSTROUTING", rule)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("missing ip masquerade rule for %s", ip)
+	}
+
+	return nil
+}
@@ -124,6 +124,40 @@ var _ = Describe("LoadWrapperConfig", func() {
 		})
 	})
 
+	Describe("delegate runtimeConfig", func() {
+		Context("when the runtime passes bandwidth limits", func() {
+			BeforeEach(func() {
+				var inputData map[string]interface{}
+				Expect(json.Unmarshal(input, &inputData)).To(Succeed())
+				inputData["runtimeConfig"] = map[string]interface{}{
+					"bandwidth": map[string]interface{}{
+						"ingressRate":  1024,
+						"ingressBurst": 2048,
+					},
+				}
+				input, _ = json.Marshal(inputData)
+			})
+			It("should pass them on to the delegate", func() {
+				conf, err := lib.LoadWrapperConfig(input)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(conf.Delegate).To(HaveKeyWithValue("runtimeConfig", map[string]interface{}{
+					"bandwidth": map[string]interface{}{
+						"ingressRate":  float64(1024),
+						"ingressBurst": float64(2048),
+					},
+				}))
+			})
+		})
+
+		Context("when the runtime does not pass bandwidth limits", func() {
+			It("should not set it", func() {
+				conf, err := lib.LoadWrapperConfig(input)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(conf.Delegate).NotTo(HaveKey("runtimeConfig"))
+			})
+		})
+	})
+
 	DescribeTable("missing required field", func(field, errMessage string) {
 		var config map[string]interface{}
 		Expect(json.Unmarshal(input, &config)).To(Succeed())
@@ -302,6 +336,66 @@ var _ = Describe("DelegateDel", func() {
 	})
 })
 
+var _ = Describe("DelegateCheck", func() {
+	var (
+		input            map[string]interface{}
+		prevResult       map[string]interface{}
+		pluginController *lib.PluginController
+		fakeDelegator    *fakes.Delegator
+	)
+
+	BeforeEach(func() {
+		fakeDelegator = &fakes.Delegator{}
+		pluginController = &lib.PluginController{
+			Delegator: fakeDelegator,
+		}
+
+		input = map[string]interface{}{
+			"type": "something",
+		}
+		prevResult = map[string]interface{}{
+			"cniVersion": "1.0.0",
+		}
+	})
+
+	It("should call the plugin specified by the type with the prevResult", func() {
+		err := pluginController.DelegateCheck(input, prevResult)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(fakeDelegator.DelegateCheckCallCount()).To(Equal(1))
+		delegatePlugin, netconf := fakeDelegator.DelegateCheckArgsForCall(0)
+		Expect(delegatePlugin).To(Equal("something"))
+		Expect(netconf).To(MatchJSON(`{"type": "something", "prevResult": {"cniVersion": "1.0.0"}}`))
+
+		By("not modifying the delegate config")
+		Expect(input).NotTo(HaveKey("prevResult"))
+	})
+
+	Context("when the delegator returns an error", func() {
+		BeforeEach(func() {
+			fakeDelegator.DelegateCheckReturns(fmt.Errorf("patato"))
+		})
+
+		It("should return a useful error", func() {
+			err := pluginController.DelegateCheck(input, prevResult)
+			Expect(err).To(MatchError("patato"))
+		})
+	})
+
+	Context("when the input type is missing", func() {
+		BeforeEach(func() {
+			input = map[string]interface{}{
+				"notype": "shoudbemissing",
+			}
+		})
+
+		It("should return a useful error", func() {
+			err := pluginController.DelegateCheck(input, prevResult)
+			Expect(err).To(MatchError("delegate config is missing type"))
+		})
+	})
+})
+
 var _ = Describe("AddIPMasq", func() {
 	var (
 		pluginController *lib.PluginController
@@ -351,3 +445,51 @@ var _ = Describe("DelIPMasq", func() {
 		Expect(iptablesRule).To(Equal(rules.NewDefaultEgressRule("10.255.5.5/32", "10.255.0.0/16", "silk-vtep")))
 	})
 })
+
+var _ = Describe("CheckIPMasq", func() {
+	var (
+		pluginController *lib.PluginController
+
+		fakeIPTablesAdapter *lib_fakes.IPTablesAdapter
+	)
+
+	BeforeEach(func() {
+		fakeIPTablesAdapter = &lib_fakes.IPTablesAdapter{}
+		fakeIPTablesAdapter.ExistsReturns(true, nil)
+		pluginController = &lib.PluginController{
+			IPTables: fakeIPTablesAdapter,
+		}
+	})
+
+	It("should check the ip masquerade rule for egress traffic exists", func() {
+		err := pluginController.CheckIPMasq("10.255.5.5/32", "10.255.0.0/16", "silk-vtep")
+		Expect(err).NotTo(HaveOccurred())
+
+		tableName, chainName, iptablesRule := fakeIPTablesAdapter.ExistsArgsForCall(0)
+		Expect(tableName).To(Equal("nat"))
+		Expect(chainName).To(Equal("POSTROUTING"))
+		Expect(iptablesRule).To(Equal(rules.NewDefaultEgressRule("10.255.5.5/32", "10.255.0.0/16", "silk-vtep")))
+	})
+
+	Context("when the rule is missing", func() {
+		BeforeEach(func() {
+			fakeIPTablesAdapter.ExistsReturns(false, nil)
+		})
+
+		It("should return a useful error", func() {
+			err := pluginController.CheckIPMasq("10.255.5.5/32", "10.255.0.0/16", "silk-vtep")
+			Expect(err).To(MatchError("missing ip masquerade rule for 10.255.5.5/32"))
+		})
+	})
+
+	Context("when checking the rule fails", func() {
+		BeforeEach(func() {
+			fakeIPTablesAdapter.ExistsReturns(false, fmt.Errorf("patato"))
+		})
+
+		It("should return the error", func() {
+			err := pluginController.CheckIPMasq("10.255.5.5/32", "10.255.0.0/16", "silk-vtep")
+			Expect(err).To(MatchError("patato"))
+		})
+	})
+})
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -23,6 +24,7 @@ import (
 
 	"code.cloudfoundry.org/filelock"
 	"github.com/containernetworking/cni/pkg/skel"
+	"github.com/containernetworking/cni/pkg/types"
 	current "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/containernetworking/cni/pkg/version"
 	"github.com/coreos/go-iptables/iptables"
@@ -224,7 +226,11 @@ func cmdAdd(args *skel.CmdArgs) error {
 		return fmt.Errorf("error setting up default ip masq rule: %s", err)
 	}
 
-	resultActual.DNS.Nameservers = cfg.DNSServers
+	// the DNS servers of the job take precedence over the nameservers of
+	// the delegate, which keeps its search domains and options either way
+	if len(cfg.DNSServers) > 0 {
+		resultActual.DNS.Nameservers = cfg.DNSServers
+	}
 
 	resultVersioned, err := resultActual.GetAsVersion(cfg.CNIVersion)
 	if err != nil {
@@ -234,7 +240,148 @@ func cmdAdd(args *skel.CmdArgs) error {
 }
 
 func cmdCheck(args *skel.CmdArgs) error {
-	return fmt.Errorf("Meow this isn't implemented yet")
+	cfg, err := lib.LoadWrapperConfig(args.StdinData)
+	if err != nil {
+		return err
+	}
+
+	if cfg.RawPrevResult == nil {
+		return typedError("parse prevResult", errors.New("prevResult is required"))
+	}
+
+	netConf := &types.NetConf{
+		CNIVersion:    cfg.CNIVersion,
+		RawPrevResult: cfg.RawPrevResult,
+	}
+	prevResultRaw := cfg.RawPrevResult
+	if err := version.ParsePrevResult(netConf); err != nil {
+		return typedError("parse prevResult", err)
+	}
+
+	prevResult, err := current.NewResultFromResult(netConf.PrevResult)
+	if err != nil {
+		return typedError("convert prevResult to current CNI version", err)
+	}
+	if len(prevResult.IPs) == 0 {
+		return typedError("parse prevResult", errors.New("no IP address in prevResult"))
+	}
+	containerIP := prevResult.IPs[0].Address.IP
+
+	pluginController, err := newPluginController(cfg)
+	if err != nil {
+		return err
+	}
+
+	if err := pluginController.DelegateCheck(cfg.Delegate, prevResultRaw); err != nil {
+		if _, ok := err.(*types.Error); ok {
+			return err
+		}
+		return typedError("delegate check", err)
+	}
+
+	store := &datastore.Store{
+		Serializer: &serial.Serial{},
+		Locker: &filelock.Locker{
+			FileLocker: filelock.NewLocker(cfg.Datastore + "_lock"),
+			Mutex:      new(sync.Mutex),
+		},
+		DataFilePath:    cfg.Datastore,
+		VersionFilePath: cfg.Datastore + "_version",
+		LockedFilePath:  cfg.Datastore + "_lock",
+		CacheMutex:      new(sync.RWMutex),
+	}
+
+	containers, err := store.ReadAll()
+	if err != nil {
+		return typedError("read datastore", err)
+	}
+	container, ok := containers[args.ContainerID]
+	if !ok {
+		return typedError("check datastore", fmt.Errorf("missing entry for container %s", args.ContainerID))
+	}
+	if container.IP != containerIP.String() {
+		return typedError("check datastore", fmt.Errorf("entry for container %s has ip %s, expected %s", args.ContainerID, container.IP, containerIP))
+	}
+
+	var containerWorkload string
+	if workload, present := container.Metadata["container_workload"]; present {
+		containerWorkload, _ = workload.(string)
+	}
+
+	interfaceNameLookup := interfacelookup.InterfaceNameLookup{
+		NetlinkAdapter: &adapter.NetlinkAdapter{},
+	}
+
+	var interfaceNames []string
+	if len(cfg.TemporaryUnderlayInterfaceNames) > 0 {
+		interfaceNames = cfg.TemporaryUnderlayInterfaceNames
+	} else {
+		interfaceNames, err = interfaceNameLookup.GetNamesFromIPs(cfg.UnderlayIPs)
+		if err != nil {
+			return fmt.Errorf("looking up interface names: %s", err) // not tested
+		}
+	}
+
+	netinProvider := netrules.NetIn{
+		ChainNamer: &netrules.ChainNamer{
+			MaxLength: 28,
+		},
+		IPTables:   pluginController.IPTables,
+		IngressTag: cfg.IngressTag,
+	}
+	if err := netinProvider.Check(args.ContainerID); err != nil {
+		return typedError("check net in", err)
+	}
+
+	chainNamer := &netrules.ChainNamer{
+		MaxLength: 28,
+	}
+	outConn := netrules.OutConn{
+		Limit:   cfg.OutConn.Limit,
+		Logging: cfg.OutConn.Logging,
+		DryRun:  cfg.OutConn.DryRun,
+	}
+
+	netOutProvider := netrules.NetOut{
+		ChainNamer: chainNamer,
+		NetOutChain: &netrules.NetOutChain{
+			ChainNamer: chainNamer,
+			Converter:  &netrules.RuleConverter{LogWriter: os.Stderr},
+			Conn:       outConn,
+		},
+		IPTables:           pluginController.IPTables,
+		IngressTag:         cfg.IngressTag,
+		VTEPName:           cfg.VTEPName,
+		ContainerHandle:    args.ContainerID,
+		ContainerWorkload:  containerWorkload,
+		ContainerIP:        containerIP.String(),
+		HostInterfaceNames: interfaceNames,
+		Conn:               outConn,
+	}
+	if err := netOutProvider.Check(); err != nil {
+		return typedError("check net out", err)
+	}
+
+	masquerader := netrules.Masquerader{
+		PluginController:            pluginController,
+		VTEPName:                    cfg.VTEPName,
+		DaemonPort:                  fmt.Sprintf("%v", cfg.Delegate["daemonPort"]),
+		ContainerIP:                 containerIP.String(),
+		CustomNoMasqueradeCIDRRange: cfg.NoMasqueradeCIDRRange,
+	}
+	if err := masquerader.CheckIPMasq(); err != nil {
+		return typedError("check ip masquerade", err)
+	}
+
+	return nil
+}
+
+func typedError(msg string, err error) *types.Error {
+	return &types.Error{
+		Code:    100,
+		Msg:     msg,
+		Details: err.Error(),
+	}
 }
 
 func getLocalDNSServers(allDNSServers []string) ([]string, error) {
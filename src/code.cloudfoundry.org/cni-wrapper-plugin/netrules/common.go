@@ -66,3 +66,31 @@ func cleanupChains(args []IpTablesFullChain, iptables rules.IPTablesAdapter) err
 	}
 	return result
 }
+
+func checkChains(iptables rules.IPTablesAdapter, fullRules []IpTablesFullChain) error {
+	for _, rule := range fullRules {
+		exists, err := iptables.ChainExists(rule.Table, rule.ChainName)
+		if err != nil {
+			return fmt.Errorf("checking chain: %s", err)
+		}
+		if !exists {
+			return fmt.Errorf("missing chain %s in table %s", rule.ChainName, rule.Table)
+		}
+
+		if rule.ParentChain == "" {
+			continue
+		}
+
+		for _, condition := range rule.JumpConditions {
+			exists, err := iptables.Exists(rule.Table, rule.ParentChain, condition)
+			if err != nil {
+				return fmt.Errorf("checking rule: %s", err)
+			}
+			if !exists {
+				return fmt.Errorf("missing jump to %s from %s in table %s", rule.ChainName, rule.ParentChain, rule.Table)
+			}
+		}
+	}
+
+	return nil
+}
@@ -36,6 +36,14 @@ func (m *Masquerader) DelIPMasq() error {
 	return m.PluginController.DelIPMasq(m.ContainerIP, noMasqCidr, m.VTEPName)
 }
 
+func (m *Masquerader) CheckIPMasq() error {
+	noMasqCidr, err := m.getNoMasqueradeCIDRRange()
+	if err != nil {
+		return err
+	}
+	return m.PluginController.CheckIPMasq(m.ContainerIP, noMasqCidr, m.VTEPName)
+}
+
 func (m *Masquerader) getNoMasqueradeCIDRRange() (string, error) {
 	if m.CustomNoMasqueradeCIDRRange != "" {
 		return m.CustomNoMasqueradeCIDRRange, nil
@@ -326,4 +326,33 @@ var _ = Describe("Masquerader", func() {
 			})
 		})
 	})
+
+	Describe("CheckIPMasq", func() {
+		BeforeEach(func() {
+			masquerader.CustomNoMasqueradeCIDRRange = "10.33.0.0/16"
+			fakeIPTables.ExistsReturns(true, nil)
+		})
+
+		It("checks for the masq rule", func() {
+			expectedRule := rules.IPTablesRule{"--source", containerIP, "!", "-o", vtepName, "!", "--destination", "10.33.0.0/16", "--jump", "MASQUERADE"}
+			err := masquerader.CheckIPMasq()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fakeIPTables.ExistsCallCount()).To(Equal(1))
+			table, chain, rule := fakeIPTables.ExistsArgsForCall(0)
+			Expect(table).To(Equal("nat"))
+			Expect(chain).To(Equal("POSTROUTING"))
+			Expect(rule).To(Equal(expectedRule))
+		})
+
+		Context("when the rule is missing", func() {
+			BeforeEach(func() {
+				fakeIPTables.ExistsReturns(false, nil)
+			})
+
+			It("returns an error", func() {
+				err := masquerader.CheckIPMasq()
+				Expect(err).To(MatchError("missing ip masquerade rule for 10.255.11.11"))
+			})
+		})
+	})
 })
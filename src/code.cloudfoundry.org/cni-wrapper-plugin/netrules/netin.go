@@ -58,6 +58,12 @@ func (m *NetIn) Cleanup(containerHandle string) error {
 	return result
 }
 
+// Check verifies that the chains created by Initialize still exist and are
+// still jumped to from their parent chains.
+func (m *NetIn) Check(containerHandle string) error {
+	return checkChains(m.IPTables, m.defaultNetInRules(containerHandle))
+}
+
 func (m *NetIn) AddRule(containerHandle string, hostPort, containerPort int, hostIP, containerIP string) error {
 	chain := m.ChainNamer.Prefix(prefixNetIn, containerHandle)
 
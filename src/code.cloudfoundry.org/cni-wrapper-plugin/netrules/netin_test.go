@@ -257,4 +257,70 @@ var _ = Describe("Netin", func() {
 			})
 		})
 	})
+
+	Describe("Check", func() {
+		BeforeEach(func() {
+			ipTables.ChainExistsReturns(true, nil)
+			ipTables.ExistsReturns(true, nil)
+		})
+
+		It("checks the chain and jump rule in the nat and mangle tables", func() {
+			err := netIn.Check("some-container-handle")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ipTables.ChainExistsCallCount()).To(Equal(2))
+			table, chain := ipTables.ChainExistsArgsForCall(0)
+			Expect(table).To(Equal("nat"))
+			Expect(chain).To(Equal("some-chain-name"))
+			table, chain = ipTables.ChainExistsArgsForCall(1)
+			Expect(table).To(Equal("mangle"))
+			Expect(chain).To(Equal("some-chain-name"))
+
+			Expect(ipTables.ExistsCallCount()).To(Equal(2))
+			table, chain, rulespec := ipTables.ExistsArgsForCall(0)
+			Expect(table).To(Equal("nat"))
+			Expect(chain).To(Equal("PREROUTING"))
+			Expect(rulespec).To(Equal(rules.IPTablesRule{"--jump", "some-chain-name"}))
+		})
+
+		Context("when the chain is missing", func() {
+			BeforeEach(func() {
+				ipTables.ChainExistsReturns(false, nil)
+			})
+			It("returns an error", func() {
+				err := netIn.Check("some-container-handle")
+				Expect(err).To(MatchError("missing chain some-chain-name in table nat"))
+			})
+		})
+
+		Context("when the jump rule is missing", func() {
+			BeforeEach(func() {
+				ipTables.ExistsReturnsOnCall(1, false, nil)
+			})
+			It("returns an error", func() {
+				err := netIn.Check("some-container-handle")
+				Expect(err).To(MatchError("missing jump to some-chain-name from PREROUTING in table mangle"))
+			})
+		})
+
+		Context("when checking the chain fails", func() {
+			BeforeEach(func() {
+				ipTables.ChainExistsReturns(false, errors.New("blue potato"))
+			})
+			It("returns an error", func() {
+				err := netIn.Check("some-container-handle")
+				Expect(err).To(MatchError("checking chain: blue potato"))
+			})
+		})
+
+		Context("when checking the jump rule fails", func() {
+			BeforeEach(func() {
+				ipTables.ExistsReturns(false, errors.New("blue potato"))
+			})
+			It("returns an error", func() {
+				err := netIn.Check("some-container-handle")
+				Expect(err).To(MatchError("checking rule: blue potato"))
+			})
+		})
+	})
 })
@@ -101,6 +101,17 @@ func (m *NetOut) Cleanup() error {
 	return cleanupChains(args, m.IPTables)
 }
 
+// Check verifies that the input, netout, overlay and log chains created by
+// Initialize still exist and are still jumped to from their parent chains.
+func (m *NetOut) Check() error {
+	args, err := m.defaultNetOutRules()
+	if err != nil {
+		return err
+	}
+
+	return checkChains(m.IPTables, args)
+}
+
 func (m *NetOut) defaultNetOutRules() ([]IpTablesFullChain, error) {
 	inputChainName := m.ChainNamer.Prefix(prefixInput, m.ContainerHandle)
 	forwardChainName := m.ChainNamer.Prefix(prefixNetOut, m.ContainerHandle)
@@ -634,4 +634,78 @@ var _ = Describe("Netout", func() {
 			})
 		})
 	})
+
+	Describe("Check", func() {
+		BeforeEach(func() {
+			ipTables.ChainExistsReturns(true, nil)
+			ipTables.ExistsReturns(true, nil)
+		})
+
+		It("checks the input, netout, overlay and log chains and their jump rules", func() {
+			err := netOut.Check()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(ipTables.ChainExistsCallCount()).To(Equal(4))
+			var chains []string
+			for i := 0; i < 4; i++ {
+				table, chain := ipTables.ChainExistsArgsForCall(i)
+				Expect(table).To(Equal("filter"))
+				chains = append(chains, chain)
+			}
+			Expect(chains).To(Equal([]string{
+				"input-some-container-handle",
+				"netout-some-container-handle",
+				"overlay-some-container-handle",
+				"some-other-chain-name",
+			}))
+
+			Expect(ipTables.ExistsCallCount()).To(Equal(4))
+			table, chain, rulespec := ipTables.ExistsArgsForCall(0)
+			Expect(table).To(Equal("filter"))
+			Expect(chain).To(Equal("INPUT"))
+			Expect(rulespec).To(Equal(rules.IPTablesRule{"-s", "5.6.7.8", "--jump", "input-some-container-handle"}))
+
+			table, chain, rulespec = ipTables.ExistsArgsForCall(2)
+			Expect(table).To(Equal("filter"))
+			Expect(chain).To(Equal("FORWARD"))
+			Expect(rulespec).To(Equal(rules.IPTablesRule{"-s", "5.6.7.8", "-o", "eth0", "--jump", "netout-some-container-handle"}))
+
+			table, chain, rulespec = ipTables.ExistsArgsForCall(3)
+			Expect(table).To(Equal("filter"))
+			Expect(chain).To(Equal("FORWARD"))
+			Expect(rulespec).To(Equal(rules.IPTablesRule{"--jump", "overlay-some-container-handle"}))
+		})
+
+		Context("when the overlay chain is missing", func() {
+			BeforeEach(func() {
+				ipTables.ChainExistsStub = func(table, chain string) (bool, error) {
+					return chain != "overlay-some-container-handle", nil
+				}
+			})
+			It("returns an error", func() {
+				err := netOut.Check()
+				Expect(err).To(MatchError("missing chain overlay-some-container-handle in table filter"))
+			})
+		})
+
+		Context("when a jump to the netout chain is missing", func() {
+			BeforeEach(func() {
+				ipTables.ExistsReturnsOnCall(1, false, nil)
+			})
+			It("returns an error", func() {
+				err := netOut.Check()
+				Expect(err).To(MatchError("missing jump to netout-some-container-handle from FORWARD in table filter"))
+			})
+		})
+
+		Context("when getting the log chain name fails", func() {
+			BeforeEach(func() {
+				chainNamer.PostfixReturns("", errors.New("banana"))
+			})
+			It("returns an error", func() {
+				err := netOut.Check()
+				Expect(err).To(MatchError("getting chain name: banana"))
+			})
+		})
+	})
 })
@@ -33,6 +33,25 @@ func NewMultipleCIDRNetwork(cidrs []string) (MultipleCIDRNetwork, error) {
 	}, nil
 }
 
+// SplitByFamily separates a mixed list of cidrs into IPv4 and IPv6 cidrs,
+// preserving their order.
+func SplitByFamily(cidrs []string) ([]string, []string, error) {
+	var ipv4CIDRs, ipv6CIDRs []string
+	for _, c := range cidrs {
+		ip, _, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ip.To4() != nil {
+			ipv4CIDRs = append(ipv4CIDRs, c)
+		} else {
+			ipv6CIDRs = append(ipv6CIDRs, c)
+		}
+	}
+
+	return ipv4CIDRs, ipv6CIDRs, nil
+}
+
 func (m *MultipleCIDRNetwork) Contains(ip net.IP) bool {
 	for _, n := range m.Networks {
 		if n.Contains(ip) {
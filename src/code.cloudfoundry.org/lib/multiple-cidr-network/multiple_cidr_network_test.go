@@ -66,6 +66,20 @@ var _ = Describe("MultipleCIDRNetwork", func() {
 		)
 	})
 
+	Context("SplitByFamily", func() {
+		It("separates the ipv4 and ipv6 cidrs preserving their order", func() {
+			ipv4CIDRs, ipv6CIDRs, err := SplitByFamily([]string{"fd00::/48", validCIDR1, "fd01::/48", validCIDR2})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(ipv4CIDRs).To(Equal([]string{validCIDR1, validCIDR2}))
+			Expect(ipv6CIDRs).To(Equal([]string{"fd00::/48", "fd01::/48"}))
+		})
+
+		It("returns an error when a cidr is invalid", func() {
+			_, _, err := SplitByFamily([]string{validCIDR1, "meow"})
+			Expect(err).To(MatchError("invalid CIDR address: meow"))
+		})
+	})
+
 	Context("Contains", func() {
 		BeforeEach(func() {
 			var err error
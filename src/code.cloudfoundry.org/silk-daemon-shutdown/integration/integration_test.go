@@ -6,6 +6,7 @@ import (
 
 	"net/http"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -109,6 +110,40 @@ var _ = Describe("Teardown", func() {
 		})
 	})
 
+	Context("when waiting for the silk daemon to release its lease", func() {
+		var leaseReleaseFile string
+
+		BeforeEach(func() {
+			Expect(os.WriteFile(fakeContainerMetadataFile.Name(), []byte(`{}`), 0777)).To(Succeed())
+			leaseReleaseFile = filepath.Join(GinkgoT().TempDir(), "lease-released")
+
+			fakeSilkDaemonServer.AppendHandlers(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				go func() {
+					fakeSilkDaemonServer.Close()
+				}()
+			}))
+		})
+
+		It("continues once the lease release file exists", func() {
+			Expect(os.WriteFile(leaseReleaseFile, []byte(`{}`), 0644)).To(Succeed())
+
+			session := runTeardown(fakeContainerMetadataFile.Name(), fakeSilkDaemonServer.URL(), tempPidFile.Name(), 1,
+				"--leaseReleaseFile", leaseReleaseFile, "--leaseReleaseTimeout", "1")
+			Eventually(session, DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+
+			Expect(session.Out).To(gbytes.Say("silk daemon released its lease"))
+		})
+
+		It("gives up waiting after the timeout and continues", func() {
+			session := runTeardown(fakeContainerMetadataFile.Name(), fakeSilkDaemonServer.URL(), tempPidFile.Name(), 1,
+				"--leaseReleaseFile", leaseReleaseFile, "--leaseReleaseTimeout", "1")
+			Eventually(session, DEFAULT_TIMEOUT).Should(gexec.Exit(0))
+
+			Expect(session.Out).To(gbytes.Say("waiting for the silk daemon to release its lease"))
+			Expect(session.Out).To(gbytes.Say("The lease was not released. Continuing"))
+		})
+	})
+
 	Context("when running in single ip mode", func() {
 		BeforeEach(func() {
 			iptablesSession, err := gexec.Start(exec.Command("iptables", "-N", "istio-ingress"), GinkgoWriter, GinkgoWriter)
@@ -213,15 +248,17 @@ var _ = Describe("Teardown", func() {
 	})
 })
 
-func runTeardown(containerMetadataFile, silkDaemonUrl, silkDaemonPidFile string, fileCheckInterval int) *gexec.Session {
-	startCmd := exec.Command(paths.TeardownBin,
+func runTeardown(containerMetadataFile, silkDaemonUrl, silkDaemonPidFile string, fileCheckInterval int, extraArgs ...string) *gexec.Session {
+	args := []string{
 		"--containerMetadataFile", containerMetadataFile,
 		"--containerMetadataFileCheckInterval", strconv.Itoa(fileCheckInterval),
 		"--containerMetadataFileCheckTimeout", "9",
 		"--silkDaemonUrl", silkDaemonUrl,
 		"--silkDaemonTimeout", "0",
 		"--silkDaemonPidPath", silkDaemonPidFile,
-		"--iptablesLockFile", "/tmp/someLockWhoReallyCares.lock")
+		"--iptablesLockFile", "/tmp/someLockWhoReallyCares.lock",
+	}
+	startCmd := exec.Command(paths.TeardownBin, append(args, extraArgs...)...)
 	session, err := gexec.Start(startCmd, GinkgoWriter, GinkgoWriter)
 	Expect(err).NotTo(HaveOccurred())
 	return session
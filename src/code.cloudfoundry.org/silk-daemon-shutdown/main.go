@@ -51,6 +51,9 @@ func mainWithError() error {
 
 	iptablesLockFile := flag.String("iptablesLockFile", "", "path to iptablesLockFile")
 
+	leaseReleaseFile := flag.String("leaseReleaseFile", "", "file written by silk daemon once it has released its lease. If set, wait for it when there are no containers on the cell.")
+	leaseReleaseTimeout := flag.Int("leaseReleaseTimeout", 10, "timeout (seconds) when waiting for the lease release file")
+
 	flag.Parse()
 
 	fileCheckMaxAttempts := *fileCheckTimeout / *fileCheckInterval
@@ -109,6 +112,14 @@ func mainWithError() error {
 		return fmt.Errorf("Silk Daemon Server did not exit after %d ping attempts", silkDaemonMaxAttempts)
 	}
 
+	if *leaseReleaseFile != "" && isStoreEmpty {
+		if waitForLeaseRelease(*leaseReleaseFile, *leaseReleaseTimeout) {
+			logger.Debug("silk daemon released its lease")
+		} else {
+			logger.Debug(fmt.Sprintf("%s not found after %d seconds. The lease was not released. Continuing", *leaseReleaseFile, *leaseReleaseTimeout))
+		}
+	}
+
 	ipt, err := iptables.New()
 	if err != nil {
 		return err
@@ -207,6 +218,24 @@ func checkIfServerUp(serverName string, url string) bool {
 	return false
 }
 
+func waitForLeaseRelease(leaseReleaseFile string, timeoutInSeconds int) bool {
+	timeout := time.After(time.Duration(timeoutInSeconds) * time.Second)
+
+	for {
+		logger.Debug(fmt.Sprintf("waiting for the silk daemon to release its lease in %s", leaseReleaseFile))
+
+		if _, err := os.Stat(leaseReleaseFile); err == nil {
+			return true
+		}
+
+		select {
+		case <-time.After(500 * time.Millisecond):
+		case <-timeout:
+			return false
+		}
+	}
+}
+
 func waitForStoreToEmpty(store *datastore.Store, pollingTimeInSeconds int, maxAttempts int, timeoutInSeconds int) (bool, error) {
 	currentAttempt := 0
 
@@ -12,16 +12,23 @@ type Config struct {
 	UnderlayIP                string   `json:"underlay_ip" validate:"nonzero"`
 	VxlanInterfaceName        string   `json:"vxlan_interface_name"`
 	SubnetPrefixLength        int      `json:"subnet_prefix_length" validate:"nonzero"`
+	SubnetPrefixLengthV6      int      `json:"subnet_prefix_length_v6" validate:"min=0,max=128"`
 	OverlayNetworks           []string `json:"overlay_network" validate:"nonzero"`
 	HealthCheckPort           uint16   `json:"health_check_port" validate:"nonzero"`
 	VTEPName                  string   `json:"vtep_name" validate:"nonzero"`
 	ConnectivityServerURL     string   `json:"connectivity_server_url" validate:"nonzero"`
+	ConnectivityServerURLs    []string `json:"connectivity_server_urls"`
 	ServerCACertFile          string   `json:"ca_cert_file" validate:"nonzero"`
 	ClientCertFile            string   `json:"client_cert_file" validate:"nonzero"`
 	ClientKeyFile             string   `json:"client_key_file" validate:"nonzero"`
 	VNI                       int      `json:"vni" validate:"nonzero"`
 	VTEPPort                  int      `json:"vtep_port" validate:"min=1"`
+	VTEPMTU                   int      `json:"vtep_mtu" validate:"min=0"`
+	Encapsulation             string   `json:"encapsulation"`
 	PollInterval              int      `json:"poll_interval" validate:"nonzero"`
+	PollJitterPercent         int      `json:"poll_jitter_percent" validate:"min=0,max=50"`
+	PollMaxBackoffSeconds     int      `json:"poll_max_backoff_seconds" validate:"min=0"`
+	LeaseWatchSeconds         int      `json:"lease_watch_seconds" validate:"min=0"`
 	DebugServerPort           int      `json:"debug_server_port" validate:"nonzero"`
 	Datastore                 string   `json:"datastore" validate:"nonzero"`
 	PartitionToleranceSeconds int      `json:"partition_tolerance_seconds" validate:"nonzero"`
@@ -30,6 +37,9 @@ type Config struct {
 	LogPrefix                 string   `json:"log_prefix" validate:"nonzero"`
 	LogLevel                  string   `json:"log_level"`
 	SingleIPOnly              bool     `json:"single_ip_only"`
+	ReleaseLeaseOnShutdown    bool     `json:"release_lease_on_shutdown"`
+	LeaseReleaseFile          string   `json:"lease_release_file"`
+	ForceVTEPRecreate         bool     `json:"force_vtep_recreate"`
 }
 
 func LoadConfig(filePath string) (Config, error) {
@@ -49,3 +59,12 @@ func LoadConfig(filePath string) (Config, error) {
 	}
 	return cfg, nil
 }
+
+// ControllerURLs are the silk-controllers that requests are spread across.
+// When ConnectivityServerURLs is empty there is only ConnectivityServerURL.
+func (c Config) ControllerURLs() []string {
+	if len(c.ConnectivityServerURLs) > 0 {
+		return c.ConnectivityServerURLs
+	}
+	return []string{c.ConnectivityServerURL}
+}
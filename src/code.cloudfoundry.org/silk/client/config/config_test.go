@@ -91,6 +91,142 @@ var _ = Describe("Config.LoadConfig", func() {
 		})
 	})
 
+	Describe("ControllerURLs", func() {
+		It("is the connectivity server url", func() {
+			cfg := config.Config{ConnectivityServerURL: "https://controller:4103"}
+			Expect(cfg.ControllerURLs()).To(Equal([]string{"https://controller:4103"}))
+		})
+
+		Context("when connectivity_server_urls is specified", func() {
+			It("is the connectivity server urls", func() {
+				cfg := cloneMap(requiredFields)
+				cfg["connectivity_server_urls"] = []string{"https://controller-0:4103", "https://controller-1:4103"}
+
+				file, err := os.CreateTemp(os.TempDir(), "config-")
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+				loadedConfig, err := config.LoadConfig(file.Name())
+				Expect(err).NotTo(HaveOccurred())
+				Expect(loadedConfig.ControllerURLs()).To(Equal([]string{"https://controller-0:4103", "https://controller-1:4103"}))
+			})
+		})
+	})
+
+	Context("when release_lease_on_shutdown is specified", func() {
+		It("sets ReleaseLeaseOnShutdown and LeaseReleaseFile", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["release_lease_on_shutdown"] = true
+			cfg["lease_release_file"] = "/some/lease-released"
+
+			file, err := os.CreateTemp(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			loadedConfig, err := config.LoadConfig(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loadedConfig.ReleaseLeaseOnShutdown).To(Equal(true))
+			Expect(loadedConfig.LeaseReleaseFile).To(Equal("/some/lease-released"))
+		})
+	})
+
+	Context("when force_vtep_recreate is specified", func() {
+		It("sets ForceVTEPRecreate", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["force_vtep_recreate"] = true
+
+			file, err := os.CreateTemp(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			loadedConfig, err := config.LoadConfig(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loadedConfig.ForceVTEPRecreate).To(Equal(true))
+		})
+	})
+
+	Context("when vtep_mtu is specified", func() {
+		It("sets VTEPMTU", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["vtep_mtu"] = 1400
+
+			file, err := os.CreateTemp(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			loadedConfig, err := config.LoadConfig(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loadedConfig.VTEPMTU).To(Equal(1400))
+		})
+	})
+
+	Context("when encapsulation is specified", func() {
+		It("sets Encapsulation", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["encapsulation"] = "geneve"
+
+			file, err := os.CreateTemp(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			loadedConfig, err := config.LoadConfig(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loadedConfig.Encapsulation).To(Equal("geneve"))
+		})
+	})
+
+	Context("when the poll jitter and backoff are specified", func() {
+		It("sets PollJitterPercent and PollMaxBackoffSeconds", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["poll_jitter_percent"] = 10
+			cfg["poll_max_backoff_seconds"] = 300
+
+			file, err := os.CreateTemp(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			loadedConfig, err := config.LoadConfig(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loadedConfig.PollJitterPercent).To(Equal(10))
+			Expect(loadedConfig.PollMaxBackoffSeconds).To(Equal(300))
+		})
+
+		It("errors when the poll jitter is more than 50 percent", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["poll_jitter_percent"] = 51
+
+			file, err := os.CreateTemp(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			_, err = config.LoadConfig(file.Name())
+			Expect(err).To(MatchError(HavePrefix("invalid config:")))
+		})
+	})
+
+	Context("when lease_watch_seconds is specified", func() {
+		It("sets LeaseWatchSeconds", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["lease_watch_seconds"] = 30
+
+			file, err := os.CreateTemp(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			loadedConfig, err := config.LoadConfig(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loadedConfig.LeaseWatchSeconds).To(Equal(30))
+		})
+	})
+
 	Context("when vxlan_interface_name is specified", func() {
 		It("sets VxlanInterfaceName", func() {
 			cfg := cloneMap(requiredFields)
@@ -123,4 +259,34 @@ var _ = Describe("Config.LoadConfig", func() {
 			Expect(loadedConfig.OverlayNetworks).To(Equal(overlayNets))
 		})
 	})
+
+	Context("when ipv6 overlay networks are provided", func() {
+		It("sets the ipv6 subnet prefix length", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["overlay_network"] = []string{"10.255.0.0/16", "fd00::/48"}
+			cfg["subnet_prefix_length_v6"] = 64
+
+			file, err := os.CreateTemp(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			loadedConfig, err := config.LoadConfig(file.Name())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(loadedConfig.SubnetPrefixLengthV6).To(Equal(64))
+		})
+
+		It("errors when the ipv6 subnet prefix length is out of range", func() {
+			cfg := cloneMap(requiredFields)
+			cfg["subnet_prefix_length_v6"] = 129
+
+			file, err := os.CreateTemp(os.TempDir(), "config-")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(json.NewEncoder(file).Encode(cfg)).To(Succeed())
+
+			_, err = config.LoadConfig(file.Name())
+			Expect(err).To(MatchError(HavePrefix("invalid config:")))
+		})
+	})
 })
@@ -1,10 +1,11 @@
 package main
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -17,13 +18,13 @@ import (
 
 	"code.cloudfoundry.org/silk/cni/adapter"
 	"code.cloudfoundry.org/silk/cni/config"
+	"code.cloudfoundry.org/silk/cni/ipam"
 	"code.cloudfoundry.org/silk/cni/lib"
 	"code.cloudfoundry.org/silk/cni/netinfo"
 	"code.cloudfoundry.org/silk/daemon"
 	libAdapter "code.cloudfoundry.org/silk/lib/adapter"
 	"code.cloudfoundry.org/silk/lib/datastore"
 	"code.cloudfoundry.org/silk/lib/serial"
-	"github.com/containernetworking/cni/pkg/invoke"
 	"github.com/containernetworking/cni/pkg/skel"
 	"github.com/containernetworking/cni/pkg/types"
 	current "github.com/containernetworking/cni/pkg/types/100"
@@ -33,19 +34,27 @@ import (
 )
 
 type CNIPlugin struct {
-	HostNSPath      string
-	HostNS          ns.NetNS
-	ConfigCreator   *config.ConfigCreator
-	VethPairCreator *lib.VethPairCreator
-	Host            *lib.Host
-	Container       *lib.Container
-	Store           *datastore.Store
-	Logger          lager.Logger
+	HostNSPath        string
+	HostNS            ns.NetNS
+	ConfigCreator     *config.ConfigCreator
+	VethPairCreator   *lib.VethPairCreator
+	Host              *lib.Host
+	Container         *lib.Container
+	TokenBucketFilter *lib.TokenBucketFilter
+	Tuning            *lib.Tuning
+	IPAM              *ipam.Allocator
+	Store             *datastore.Store
+	Logger            lager.Logger
 }
 
 const (
 	jobPrefix = "silk-cni"
 	logPrefix = "cfnetworking"
+
+	// hostLocalDataDir is the dataDir silk-cni ran the host-local plugin
+	// with before it allocated the IPs of containers itself. host-local kept
+	// them in ipam/<network> below it.
+	hostLocalDataDir = "/var/vcap/data/host-local"
 )
 
 // used as a compile-time flag to disable logging during integration tests
@@ -116,6 +125,23 @@ func main() {
 			LinkOperations: linkOperations,
 			Logger:         logger.Session("container-setup"),
 		},
+		TokenBucketFilter: &lib.TokenBucketFilter{
+			NetlinkAdapter: netlinkAdapter,
+			LinkOperations: linkOperations,
+			Logger:         logger.Session("token-bucket-filter"),
+		},
+		Tuning: &lib.Tuning{
+			SysctlAdapter:  &adapter.SysctlAdapter{},
+			NetlinkAdapter: netlinkAdapter,
+			EthtoolAdapter: &adapter.EthtoolAdapter{},
+			Logger:         logger.Session("tuning"),
+		},
+		IPAM: &ipam.Allocator{
+			Serializer:       &serial.Serial{},
+			LockerNew:        filelock.NewLocker,
+			HostLocalDataDir: hostLocalDataDir,
+			Logger:           logger.Session("ipam"),
+		},
 		Logger: logger,
 		Store:  store,
 	}
@@ -130,25 +156,97 @@ func main() {
 
 type NetConf struct {
 	types.NetConf
-	DataDir    string `json:"dataDir"`
 	SubnetFile string `json:"subnetFile"`
 	MTU        int    `json:"mtu" validate:"min=0"`
 	Datastore  string `json:"datastore"`
 	DaemonPort int    `json:"daemonPort"`
+
+	// Bandwidth limits every container, unless the runtime passes its own
+	// limits through the bandwidth capability
+	Bandwidth     config.Bandwidth `json:"bandwidth"`
+	RuntimeConfig struct {
+		Bandwidth *config.Bandwidth `json:"bandwidth"`
+	} `json:"runtimeConfig"`
+
+	// Args requests a specific IP for the container, see CONVENTIONS.md
+	// of the CNI spec
+	Args struct {
+		CNI struct {
+			IPs []string `json:"ips"`
+		} `json:"cni"`
+	} `json:"args"`
+
+	// Attachments are silk interfaces in other overlay networks that are
+	// added to the container next to the one named by CNI_IFNAME
+	Attachments []config.Attachment `json:"attachments"`
+
+	// Routes are added next to the default route of the primary interface
+	// and the network routes of the attachments. The DNS settings of the
+	// embedded types.NetConf are returned as they are for the runtime to
+	// write to the resolv.conf of the container.
+	Routes []config.Route `json:"routes"`
+
+	// Tuning is applied inside the container namespace and to the device of
+	// the primary interface
+	Tuning config.Tuning `json:"tuning"`
+}
+
+func (n NetConf) bandwidthLimits() config.Bandwidth {
+	if n.RuntimeConfig.Bandwidth != nil {
+		return *n.RuntimeConfig.Bandwidth
+	}
+	return n.Bandwidth
+}
+
+// validateAttachments checks that the interfaces, network names and overlay
+// networks of the attachments do not collide with each other or the primary
+// interface, whose overlay network is the subnet of the cell.
+func (n NetConf) validateAttachments(ifName, overlaySubnet string) error {
+	ifNames := map[string]bool{ifName: true}
+	names := map[string]bool{n.Name: true}
+	networks := []string{overlaySubnet}
+	for _, attachment := range n.Attachments {
+		if err := attachment.Validate(); err != nil {
+			return err
+		}
+		if ifNames[attachment.IfName] {
+			return fmt.Errorf("%s: interface is already in use", attachment.IfName)
+		}
+		if names[attachment.Name] {
+			return fmt.Errorf("%s: network name %s is already in use", attachment.IfName, attachment.Name)
+		}
+		for _, network := range networks {
+			if attachment.Overlaps(network) {
+				return fmt.Errorf("%s: network %s overlaps %s", attachment.IfName, attachment.Network, network)
+			}
+		}
+		ifNames[attachment.IfName] = true
+		names[attachment.Name] = true
+		networks = append(networks, attachment.Network)
+	}
+	return nil
 }
 
-type HostLocalIPAM struct {
-	Type    string         `json:"type"`
-	Subnet  string         `json:"subnet"`
-	Gateway string         `json:"gateway"`
-	Routes  []*types.Route `json:"routes"`
-	DataDir string         `json:"dataDir"`
+// routesThrough returns the routes through the named interface. Routes
+// without an interface go through the primary one.
+func (n NetConf) routesThrough(ifName, primaryIfName string) []config.Route {
+	var routes []config.Route
+	for _, r := range n.Routes {
+		if r.IfName == ifName || (r.IfName == "" && ifName == primaryIfName) {
+			routes = append(routes, r)
+		}
+	}
+	return routes
 }
 
-type ConfForHostLocal struct {
-	CNIVersion string        `json:"cniVersion"`
-	Name       string        `json:"name"`
-	IPAM       HostLocalIPAM `json:"ipam"`
+// attachmentNetConf is the config to discover the network info of the
+// overlay network of an attachment
+func attachmentNetConf(attachment config.Attachment) NetConf {
+	return NetConf{
+		SubnetFile: attachment.SubnetFile,
+		DaemonPort: attachment.DaemonPort,
+		MTU:        attachment.MTU,
+	}
 }
 
 func typedError(msg string, err error) *types.Error {
@@ -197,40 +295,101 @@ func (p *CNIPlugin) cmdAdd(args *skel.CmdArgs) error {
 		return typedError("discover network info", err)
 	}
 
-	p.Logger.Debug("generate-ipam-config", lager.Data{"overlaySubnet": networkInfo.OverlaySubnet, "name": netConf.Name, "dataDir": netConf.DataDir})
+	limits := netConf.bandwidthLimits()
+	p.Logger.Debug("validate-bandwidth-limits", lager.Data{"limits": limits, "mtu": networkInfo.MTU})
+	err = limits.Validate(networkInfo.MTU)
+	if err != nil {
+		p.Logger.Error("validate-bandwidth-limits-failed", err)
+		return typedError("validate bandwidth limits", err)
+	}
+
+	p.Logger.Debug("validate-attachments", lager.Data{"attachments": netConf.Attachments})
+	err = netConf.validateAttachments(args.IfName, networkInfo.OverlaySubnet)
+	if err != nil {
+		p.Logger.Error("validate-attachments-failed", err)
+		return typedError("validate attachments", err)
+	}
+
+	p.Logger.Debug("validate-routes", lager.Data{"routes": netConf.Routes})
+	err = config.ValidateRoutes(netConf.Routes, args.IfName, netConf.Attachments)
+	if err != nil {
+		p.Logger.Error("validate-routes-failed", err)
+		return typedError("validate routes", err)
+	}
+
+	p.Logger.Debug("validate-route-tables", lager.Data{"overlaySubnet": networkInfo.OverlaySubnet})
+	err = config.ValidateRouteTables(netConf.Routes, networkInfo.OverlaySubnet)
+	if err != nil {
+		p.Logger.Error("validate-route-tables-failed", err)
+		return typedError("validate routes", err)
+	}
+
+	gateways := config.RouteGateways(netConf.Routes)
+	p.Logger.Debug("check-gateways", lager.Data{"gateways": gateways})
+	err = p.Host.CheckGateways(p.HostNS, gateways)
+	if err != nil {
+		p.Logger.Error("check-gateways-failed", err)
+		return typedError("validate routes", err)
+	}
+
+	p.Logger.Debug("validate-dns", lager.Data{"dns": netConf.DNS})
+	err = config.ValidateDNS(netConf.DNS)
+	if err != nil {
+		p.Logger.Error("validate-dns-failed", err)
+		return typedError("validate dns", err)
+	}
+
+	p.Logger.Debug("validate-tuning", lager.Data{"tuning": netConf.Tuning})
+	err = netConf.Tuning.Validate()
+	if err != nil {
+		p.Logger.Error("validate-tuning-failed", err)
+		return typedError("validate tuning", err)
+	}
+
+	p.Logger.Debug("generate-ipam-config", lager.Data{"overlaySubnet": networkInfo.OverlaySubnet, "name": netConf.Name, "cniArgs": args.Args, "ips": netConf.Args.CNI.IPs})
 	generator := config.IPAMConfigGenerator{}
-	ipamConfig, err := generator.GenerateConfig(networkInfo.OverlaySubnet, netConf.Name, netConf.DataDir)
+	ipamConfig, err := generator.GenerateConfig(networkInfo.OverlaySubnet, netConf.Name, args.Args, netConf.Args.CNI.IPs)
 	if err != nil {
 		p.Logger.Error("generate-ipam-config-failed", err)
 		return typedError("generate ipam config", err)
 	}
-	ipamConfigBytes, _ := json.Marshal(ipamConfig) // untestable
 
-	p.Logger.Debug("host-local-ipam", lager.Data{"action": "add", "ipamConfig": string(ipamConfigBytes)})
-	result, err := invoke.DelegateAdd(context.Background(), "host-local", ipamConfigBytes, nil)
+	p.Logger.Debug("allocate-ip", lager.Data{"datastore": netConf.Datastore, "ipamConfig": ipamConfig})
+	ip, err := p.IPAM.Allocate(netConf.Datastore, ipamConfig, ipam.Allocation{
+		ContainerID: args.ContainerID,
+		IfName:      args.IfName,
+		Netns:       args.Netns,
+	})
 	if err != nil {
-		p.Logger.Error("host-local-ipam-failed", err)
-		return typedError("run ipam plugin", err)
+		p.Logger.Error("allocate-ip-failed", err)
+		return typedError("allocate ip", err)
 	}
 
-	p.Logger.Debug("convert-ipam-result", lager.Data{"result": result})
-	cniResult, err := current.NewResultFromResult(result)
-	if err != nil {
-		p.Logger.Error("convert-ipam-result-failed", err)
-		return fmt.Errorf("convert result to current CNI version: %s", err) // not tested
-	}
+	cniResult := ipamResult(ip, ipamConfig)
 
 	p.Logger.Debug("create-config", lager.Data{"hostNamespace": p.HostNS, "args": args, "result": cniResult, "mtu": networkInfo.MTU})
 	cfg, err := p.ConfigCreator.Create(p.HostNS, args, cniResult, networkInfo.MTU)
 	if err != nil {
 		p.Logger.Error("create-config-failed", err)
+		p.undoAdd(netConf, args, nil, false)
 		return typedError("create config", err)
 	}
+	cfg.Host.RouteTable = config.RouteTable(cfg.Container.Address.IP)
+	cfgs := []*config.Config{cfg}
+
+	p.Logger.Debug("add-routes", lager.Data{"routes": netConf.routesThrough(args.IfName, args.IfName)})
+	err = cfg.AddRoutes(netConf.routesThrough(args.IfName, args.IfName))
+	if err != nil {
+		p.Logger.Error("add-routes-failed", err)
+		p.undoAdd(netConf, args, nil, false)
+		return typedError("add routes", err)
+	}
 
 	p.Logger.Debug("create-veth-pair", lager.Data{"cfg": cfg})
 	err = p.VethPairCreator.Create(cfg)
 	if err != nil {
 		p.Logger.Error("create-veth-pair-failed", err)
+		p.undoAdd(netConf, args, cfgs, false)
 		return typedError("create veth pair", err)
 	}
 
@@ -238,32 +397,183 @@ func (p *CNIPlugin) cmdAdd(args *skel.CmdArgs) error {
 	err = p.Host.Setup(cfg)
 	if err != nil {
 		p.Logger.Error("setup-host-failed", err)
+		p.undoAdd(netConf, args, cfgs, false)
 		return typedError("set up host", err)
 	}
 
+	if limits.IsLimited() {
+		p.Logger.Debug("setup-bandwidth-limits", lager.Data{"cfg": cfg, "limits": limits})
+		err = p.TokenBucketFilter.Setup(cfg, limits)
+		if err != nil {
+			p.Logger.Error("setup-bandwidth-limits-failed", err)
+			p.undoAdd(netConf, args, cfgs, true)
+			return typedError("set up bandwidth limits", err)
+		}
+	}
+
 	p.Logger.Debug("setup-container", lager.Data{"cfg": cfg})
 	err = p.Container.Setup(cfg)
 	if err != nil {
 		p.Logger.Error("setup-container-failed", err)
+		p.undoAdd(netConf, args, cfgs, limits.IsLimited())
 		return typedError("set up container", err)
 	}
 
+	if netConf.Tuning.IsSet() {
+		p.Logger.Debug("setup-tuning", lager.Data{"cfg": cfg, "tuning": netConf.Tuning})
+		err = p.Tuning.Setup(cfg, netConf.Tuning)
+		if err != nil {
+			p.Logger.Error("setup-tuning-failed", err)
+			p.undoAdd(netConf, args, cfgs, limits.IsLimited())
+			return typedError("set up tuning", err)
+		}
+	}
+
+	for _, attachment := range netConf.Attachments {
+		p.Logger.Debug("add-attachment", lager.Data{"attachment": attachment})
+		attachmentCfg, err := p.addAttachment(args, netConf.Datastore, attachment, netConf.routesThrough(attachment.IfName, args.IfName), cfg.Host.RouteTable)
+		if err != nil {
+			p.Logger.Error("add-attachment-failed", err, lager.Data{"ifName": attachment.IfName})
+			p.undoAdd(netConf, args, cfgs, limits.IsLimited())
+			return typedError(fmt.Sprintf("add attachment %s", attachment.IfName), err)
+		}
+		cfgs = append(cfgs, attachmentCfg)
+	}
+
 	// use args.Netns as the 'handle' for now
 	p.Logger.Debug("write-container-metadata", lager.Data{"datastore": netConf.Datastore, "path": filepath.Base(args.Netns), "ip": cfg.Container.Address.IP.String()})
 	err = p.Store.Add(netConf.Datastore, filepath.Base(args.Netns), cfg.Container.Address.IP.String(), nil)
 	if err != nil {
 		p.Logger.Error("write-container-metadata-failed", err)
+		p.undoAdd(netConf, args, cfgs, limits.IsLimited())
 		return typedError("write container metadata", err)
 	}
 
-	p.Logger.Debug("print-cni-result", lager.Data{"cfg": cfg.AsCNIResult(), "cniVersion": netConf.CNIVersion})
-	err = types.PrintResult(cfg.AsCNIResult(), netConf.CNIVersion)
+	result := config.CNIResult(cfgs...)
+	result.DNS = netConf.DNS
+	p.Logger.Debug("print-cni-result", lager.Data{"cfg": result, "cniVersion": netConf.CNIVersion})
+	err = types.PrintResult(result, netConf.CNIVersion)
 	if err != nil {
 		p.Logger.Error("print-cni-result-failed", err)
 	}
 	return err
 }
 
+// addAttachment allocates an IP in the overlay network of an attachment and
+// sets up its veth pair with the given routes. Bandwidth limits and
+// container metadata only apply to the primary interface, and its gateway
+// routes go into the route table of the primary interface. When setting up
+// the veth pair fails it is deleted again, the caller releases the IP.
+func (p *CNIPlugin) addAttachment(args *skel.CmdArgs, datastore string, attachment config.Attachment, routes []config.Route, routeTable int) (*config.Config, error) {
+	networkInfo, err := getNetworkInfo(attachmentNetConf(attachment))
+	if err != nil {
+		return nil, fmt.Errorf("discover network info: %s", err)
+	}
+
+	generator := config.IPAMConfigGenerator{}
+	ipamConfig, err := generator.GenerateConfig(networkInfo.OverlaySubnet, attachment.Name, "", nil)
+	if err != nil {
+		return nil, fmt.Errorf("generate ipam config: %s", err)
+	}
+
+	ip, err := p.IPAM.Allocate(datastore, ipamConfig, ipam.Allocation{
+		ContainerID: args.ContainerID,
+		IfName:      attachment.IfName,
+		Netns:       args.Netns,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("allocate ip: %s", err)
+	}
+
+	cfg, err := p.ConfigCreator.CreateForAttachment(p.HostNS, args, attachment, ipamResult(ip, ipamConfig), networkInfo.MTU)
+	if err != nil {
+		return nil, fmt.Errorf("create config: %s", err)
+	}
+	cfg.Host.RouteTable = routeTable
+
+	if err := cfg.AddRoutes(routes); err != nil {
+		return nil, fmt.Errorf("add routes: %s", err)
+	}
+
+	p.Logger.Debug("create-veth-pair", lager.Data{"cfg": cfg})
+	if err := p.VethPairCreator.Create(cfg); err != nil {
+		p.teardownInterface(cfg)
+		return nil, fmt.Errorf("create veth pair: %s", err)
+	}
+
+	p.Logger.Debug("setup-host", lager.Data{"cfg": cfg})
+	if err := p.Host.Setup(cfg); err != nil {
+		p.teardownInterface(cfg)
+		return nil, fmt.Errorf("set up host: %s", err)
+	}
+
+	p.Logger.Debug("setup-container", lager.Data{"cfg": cfg})
+	if err := p.Container.Setup(cfg); err != nil {
+		p.teardownInterface(cfg)
+		return nil, fmt.Errorf("set up container: %s", err)
+	}
+	return cfg, nil
+}
+
+// undoAdd removes what cmdAdd set up before it failed and releases the IPs
+// of the container, so that the container does not keep them when the
+// runtime gives up on it. cfgs holds the interfaces whose veth pair may have
+// been created, the primary one first, and limited tells whether bandwidth
+// limits may have been set up. It keeps going when a step fails, like
+// cmdDel.
+func (p *CNIPlugin) undoAdd(netConf NetConf, args *skel.CmdArgs, cfgs []*config.Config, limited bool) {
+	if len(cfgs) > 0 {
+		primary := cfgs[0]
+		if limited {
+			p.Logger.Debug("teardown-bandwidth-limits", lager.Data{"ifbDeviceName": primary.Host.IFBDeviceName})
+			if err := p.TokenBucketFilter.Teardown(primary.Host.IFBDeviceName); err != nil {
+				p.Logger.Error("teardown-bandwidth-limits-failed", err)
+			}
+		}
+
+		for _, cfg := range cfgs {
+			p.teardownInterface(cfg)
+		}
+
+		p.Logger.Debug("teardown-gateway-routes", lager.Data{"table": primary.Host.RouteTable})
+		if err := p.Host.TeardownGatewayRoutes(p.HostNS, primary.Host.RouteTable); err != nil {
+			p.Logger.Error("teardown-gateway-routes-failed", err)
+		}
+	}
+
+	p.Logger.Debug("release-ip", lager.Data{"datastore": netConf.Datastore, "name": netConf.Name, "containerID": args.ContainerID, "interface": args.IfName})
+	if err := p.IPAM.Release(netConf.Datastore, netConf.Name, args.ContainerID, args.IfName); err != nil {
+		p.Logger.Error("release-ip-failed", err)
+	}
+	for _, attachment := range netConf.Attachments {
+		p.Logger.Debug("release-ip", lager.Data{"datastore": netConf.Datastore, "name": attachment.Name, "containerID": args.ContainerID, "interface": attachment.IfName})
+		if err := p.IPAM.Release(netConf.Datastore, attachment.Name, args.ContainerID, attachment.IfName); err != nil {
+			p.Logger.Error("release-ip-failed", err)
+		}
+	}
+}
+
+// teardownInterface deletes the container end of the veth pair of an
+// interface, which deletes the host end as well. The container end only
+// gets its final name once the container is set up, so both names are
+// tried.
+func (p *CNIPlugin) teardownInterface(cfg *config.Config) {
+	for _, deviceName := range []string{cfg.Container.TemporaryDeviceName, cfg.Container.DeviceName} {
+		p.Logger.Debug("teardown-container", lager.Data{"namespace": cfg.Container.Namespace, "interface": deviceName})
+		if err := p.Container.Teardown(cfg.Container.Namespace, deviceName); err != nil {
+			p.Logger.Error("teardown-failed", err)
+		}
+	}
+}
+
+func ipamResult(ip net.IP, ipamConfig *config.IPAMConfig) *current.Result {
+	return &current.Result{
+		IPs: []*current.IPConfig{{
+			Address: net.IPNet{IP: ip, Mask: ipamConfig.Subnet.Mask},
+		}},
+	}
+}
+
 func (p *CNIPlugin) cmdDel(args *skel.CmdArgs) error {
 	p.Logger = p.Logger.Session("plugin-del")
 
@@ -275,23 +585,22 @@ func (p *CNIPlugin) cmdDel(args *skel.CmdArgs) error {
 		return err // impossible, skel package asserts JSON is valid
 	}
 
-	p.Logger.Debug("generate-ipam-config", lager.Data{"name": netConf.Name, "dataDir": netConf.DataDir})
-	generator := config.IPAMConfigGenerator{}
-	// use 0.0.0.0/0 for the IPAM subnet during delete so we don't need to discover the subnet.
-	// this way, silk-daemon does not need to be up during deletes, and cleanup that takes place
-	// on startup, after the subnet may have changed, will succeed.
-	ipamConfig, err := generator.GenerateConfig("0.0.0.0/0", netConf.Name, netConf.DataDir)
+	// releasing does not need the subnet, so silk-daemon does not need to be
+	// up during deletes, and cleanup that takes place on startup, after the
+	// subnet may have changed, will succeed.
+	p.Logger.Debug("release-ip", lager.Data{"datastore": netConf.Datastore, "name": netConf.Name, "containerID": args.ContainerID, "interface": args.IfName})
+	err = p.IPAM.Release(netConf.Datastore, netConf.Name, args.ContainerID, args.IfName)
 	if err != nil {
-		p.Logger.Error("generate-ipam-config-failed", err) // untestable
+		p.Logger.Error("release-ip-failed", err)
 		// continue, keep trying to cleanup
 	}
-	ipamConfigBytes, _ := json.Marshal(ipamConfig) // untestable
-
-	p.Logger.Debug("host-local-ipam", lager.Data{"action": "delete", "ipamConfig": string(ipamConfigBytes)})
-	err = invoke.DelegateDel(context.Background(), "host-local", ipamConfigBytes, nil)
-	if err != nil {
-		p.Logger.Error("host-local-ipam-failed", err)
-		// continue, keep trying to cleanup
+	for _, attachment := range netConf.Attachments {
+		p.Logger.Debug("release-ip", lager.Data{"datastore": netConf.Datastore, "name": attachment.Name, "containerID": args.ContainerID, "interface": attachment.IfName})
+		err = p.IPAM.Release(netConf.Datastore, attachment.Name, args.ContainerID, attachment.IfName)
+		if err != nil {
+			p.Logger.Error("release-ip-failed", err)
+			// continue, keep trying to cleanup
+		}
 	}
 
 	p.Logger.Debug("open-netns", lager.Data{"namespace": args.Netns})
@@ -308,15 +617,186 @@ func (p *CNIPlugin) cmdDel(args *skel.CmdArgs) error {
 		return typedError("teardown failed", err)
 	}
 
+	for _, attachment := range netConf.Attachments {
+		p.Logger.Debug("teardown-container", lager.Data{"namespace": containerNS, "interface": attachment.IfName})
+		err = p.Container.Teardown(containerNS, attachment.IfName)
+		if err != nil {
+			p.Logger.Error("teardown-failed", err)
+			return typedError("teardown failed", err)
+		}
+	}
+
 	p.Logger.Debug("delete-from-container-metadata", lager.Data{"datastore": netConf.Datastore, "path": filepath.Base(args.Netns)})
-	_, err = p.Store.Delete(netConf.Datastore, filepath.Base(args.Netns))
+	container, err := p.Store.Delete(netConf.Datastore, filepath.Base(args.Netns))
 	if err != nil {
 		p.Logger.Error("delete-from-container-metadata-failed", err)
 	}
 
+	// the gateway routes are in the table of the container IP, which only
+	// the container metadata still knows
+	if ip := net.ParseIP(container.IP); ip != nil {
+		p.Logger.Debug("teardown-gateway-routes", lager.Data{"ip": container.IP})
+		err = p.Host.TeardownGatewayRoutes(p.HostNS, config.RouteTable(ip))
+		if err != nil {
+			p.Logger.Error("teardown-gateway-routes-failed", err)
+		}
+	}
+
+	// the ifb device is named after the container IP, which only the
+	// container metadata still knows
+	p.Logger.Debug("teardown-bandwidth-limits", lager.Data{"ip": container.IP})
+	ifbDeviceName, err := p.ConfigCreator.DeviceNameGenerator.GenerateForHostIFB(net.ParseIP(container.IP), "")
+	if err != nil {
+		p.Logger.Error("teardown-bandwidth-limits-failed", err)
+		return nil
+	}
+	err = p.TokenBucketFilter.Teardown(ifbDeviceName)
+	if err != nil {
+		p.Logger.Error("teardown-bandwidth-limits-failed", err)
+	}
+
 	return nil
 }
 
 func (p *CNIPlugin) cmdCheck(args *skel.CmdArgs) error {
-	return fmt.Errorf("Meow this isn't implemented yet")
+	p.Logger = p.Logger.Session("plugin-check")
+
+	var netConf NetConf
+	p.Logger.Debug("json-unmarshal-stdin-as-netconf")
+	err := json.Unmarshal(args.StdinData, &netConf)
+	if err != nil {
+		p.Logger.Error("json-unmarshal-stdin-as-netconf-failed", err)
+		return err // impossible, skel package asserts JSON is valid
+	}
+
+	p.Logger.Debug("parse-prev-result")
+	err = version.ParsePrevResult(&netConf.NetConf)
+	if err != nil {
+		p.Logger.Error("parse-prev-result-failed", err)
+		return typedError("parse prevResult", err)
+	}
+	if netConf.PrevResult == nil {
+		err = errors.New("prevResult is required")
+		p.Logger.Error("parse-prev-result-failed", err)
+		return typedError("parse prevResult", err)
+	}
+
+	prevResult, err := current.NewResultFromResult(netConf.PrevResult)
+	if err != nil {
+		p.Logger.Error("convert-prev-result-failed", err)
+		return typedError("convert prevResult to current CNI version", err)
+	}
+
+	p.Logger.Debug("getting-network-info", lager.Data{"netConf": netConf})
+	networkInfo, err := getNetworkInfo(netConf)
+	if err != nil {
+		p.Logger.Error("get-network-info-failed", err)
+		return typedError("discover network info", err)
+	}
+
+	// the device names, hardware addresses and routes are all derived from
+	// the container IP and the network config, so rebuilding the config from
+	// the cached result gives back exactly what ADD configured
+	p.Logger.Debug("create-config", lager.Data{"hostNamespace": p.HostNS, "args": args, "result": prevResult, "mtu": networkInfo.MTU})
+	cfg, err := p.ConfigCreator.Create(p.HostNS, args, prevResult, networkInfo.MTU)
+	if err != nil {
+		p.Logger.Error("create-config-failed", err)
+		return typedError("create config", err)
+	}
+	cfg.Host.RouteTable = config.RouteTable(cfg.Container.Address.IP)
+
+	p.Logger.Debug("add-routes", lager.Data{"routes": netConf.routesThrough(args.IfName, args.IfName)})
+	err = cfg.AddRoutes(netConf.routesThrough(args.IfName, args.IfName))
+	if err != nil {
+		p.Logger.Error("add-routes-failed", err)
+		return typedError("add routes", err)
+	}
+
+	cfgs := []*config.Config{cfg}
+	for _, attachment := range netConf.Attachments {
+		p.Logger.Debug("create-attachment-config", lager.Data{"attachment": attachment})
+		attachmentCfg, err := p.attachmentConfig(args, attachment, prevResult, netConf.routesThrough(attachment.IfName, args.IfName), cfg.Host.RouteTable)
+		if err != nil {
+			p.Logger.Error("create-attachment-config-failed", err, lager.Data{"ifName": attachment.IfName})
+			return typedError(fmt.Sprintf("create config of attachment %s", attachment.IfName), err)
+		}
+		cfgs = append(cfgs, attachmentCfg)
+	}
+
+	p.Logger.Debug("check-prev-result", lager.Data{"cfgs": cfgs})
+	err = checkInterfaces(config.CNIResult(cfgs...), prevResult)
+	if err != nil {
+		p.Logger.Error("check-prev-result-failed", err)
+		return typedError("check prevResult", err)
+	}
+
+	for _, cfg := range cfgs {
+		p.Logger.Debug("check-host", lager.Data{"cfg": cfg})
+		err = p.Host.Check(cfg)
+		if err != nil {
+			p.Logger.Error("check-host-failed", err)
+			return typedError("check host", err)
+		}
+
+		p.Logger.Debug("check-container", lager.Data{"cfg": cfg})
+		err = p.Container.Check(cfg)
+		if err != nil {
+			p.Logger.Error("check-container-failed", err)
+			return typedError("check container", err)
+		}
+	}
+
+	return nil
+}
+
+// attachmentConfig rebuilds the config of an attachment from the IP of its
+// interface in the cached result, the given routes and the route table of
+// the primary interface.
+func (p *CNIPlugin) attachmentConfig(args *skel.CmdArgs, attachment config.Attachment, prevResult *current.Result, routes []config.Route, routeTable int) (*config.Config, error) {
+	var ipConfig *current.IPConfig
+	for _, ip := range prevResult.IPs {
+		if ip.Interface == nil || *ip.Interface < 0 || *ip.Interface >= len(prevResult.Interfaces) {
+			continue
+		}
+		iface := prevResult.Interfaces[*ip.Interface]
+		if iface.Name == attachment.IfName && iface.Sandbox != "" {
+			ipConfig = ip
+			break
+		}
+	}
+	if ipConfig == nil {
+		return nil, fmt.Errorf("missing ip of interface %s", attachment.IfName)
+	}
+
+	networkInfo, err := getNetworkInfo(attachmentNetConf(attachment))
+	if err != nil {
+		return nil, fmt.Errorf("discover network info: %s", err)
+	}
+
+	cfg, err := p.ConfigCreator.CreateForAttachment(p.HostNS, args, attachment, &current.Result{IPs: []*current.IPConfig{ipConfig}}, networkInfo.MTU)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Host.RouteTable = routeTable
+
+	if err := cfg.AddRoutes(routes); err != nil {
+		return nil, fmt.Errorf("add routes: %s", err)
+	}
+	return cfg, nil
+}
+
+func checkInterfaces(expected, cached *current.Result) error {
+	for _, want := range expected.Interfaces {
+		found := false
+		for _, got := range cached.Interfaces {
+			if got.Name == want.Name && got.Mac == want.Mac && got.Sandbox == want.Sandbox {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("missing interface %s with mac %s", want.Name, want.Mac)
+		}
+	}
+	return nil
 }
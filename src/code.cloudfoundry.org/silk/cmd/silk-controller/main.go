@@ -1,6 +1,7 @@
 package main
 
 import (
+	"crypto/tls"
 	"encoding/json"
 	"flag"
 	"fmt"
@@ -36,6 +37,14 @@ var (
 	logPrefix = "cfnetworking"
 )
 
+const (
+	defaultOverlayNetworksRefreshSeconds = 30
+	defaultLeaseWatchRefreshSeconds      = 5
+	defaultLeaseHistoryRetentionSeconds  = 30 * 24 * 60 * 60
+	leaseHistoryPruneInterval            = time.Hour
+	maxLeaseWatchWait                    = 60 * time.Second
+)
+
 func main() {
 	if err := mainWithError(); err != nil {
 		log.Fatalf("%s.silk-controller error: %s", logPrefix, err)
@@ -68,6 +77,20 @@ func mainWithError() error {
 	if err != nil {
 		return fmt.Errorf("mutual tls config: %s", err)
 	}
+	// the admin api trusts its own ca, so that silk-daemon client certs
+	// cannot change reservations
+	var adminTLSConfig *tls.Config
+	if conf.AdminListenPort > 0 {
+		adminTLSConfig, err = mutualtls.NewServerTLSConfig(conf.ServerCertFile, conf.ServerKeyFile, conf.AdminCACertFile)
+		if err != nil {
+			return fmt.Errorf("admin mutual tls config: %s", err)
+		}
+	}
+
+	newAllocationStrategy, err := leaser.AllocationStrategyByName(conf.AllocationStrategy)
+	if err != nil {
+		return fmt.Errorf("allocation strategy: %s", err)
+	}
 
 	connectionPool, err := db.NewConnectionPool(
 		conf.Database,
@@ -83,16 +106,50 @@ func mainWithError() error {
 	}
 
 	databaseHandler := database.NewDatabaseHandler(&database.MigrateAdapter{}, connectionPool)
-	cidrPool := leaser.NewCIDRPool(conf.Network, conf.SubnetPrefixLength)
+
+	overlayNetworksRefreshSeconds := conf.OverlayNetworksRefreshSeconds
+	if overlayNetworksRefreshSeconds == 0 {
+		overlayNetworksRefreshSeconds = defaultOverlayNetworksRefreshSeconds
+	}
+	overlayNetworkPool := &leaser.OverlayNetworkPool{
+		Store:                  databaseHandler,
+		SubnetPrefixLength:     conf.SubnetPrefixLength,
+		SubnetPrefixLengthV6:   conf.SubnetPrefixLengthV6,
+		NewAllocationStrategy:  newAllocationStrategy,
+		LeaseExpirationSeconds: conf.LeaseExpirationSeconds,
+		RefreshInterval:        time.Duration(overlayNetworksRefreshSeconds) * time.Second,
+		Logger:                 logger.Session("overlay-networks"),
+	}
+
 	leaseController := &leaser.LeaseController{
-		DatabaseHandler:            databaseHandler,
-		HardwareAddressGenerator:   &leaser.HardwareAddressGenerator{},
-		LeaseValidator:             &leaser.LeaseValidator{},
-		AcquireSubnetLeaseAttempts: 10,
-		CIDRPool:                   cidrPool,
-		LeaseExpirationSeconds:     conf.LeaseExpirationSeconds,
-		Logger:                     logger,
+		DatabaseHandler:          databaseHandler,
+		HardwareAddressGenerator: &leaser.HardwareAddressGenerator{},
+		LeaseValidator:           &leaser.LeaseValidator{ReservationRepository: databaseHandler},
+		CIDRPool:                 overlayNetworkPool,
+		LeaseExpirationSeconds:   conf.LeaseExpirationSeconds,
+		Logger:                   logger,
+	}
+	leaseWatchRefreshSeconds := conf.LeaseWatchRefreshSeconds
+	if leaseWatchRefreshSeconds == 0 {
+		leaseWatchRefreshSeconds = defaultLeaseWatchRefreshSeconds
+	}
+	leaseWatcher := &leaser.LeaseWatcher{
+		LeaseRepository: leaseController,
+		RefreshInterval: time.Duration(leaseWatchRefreshSeconds) * time.Second,
+		Logger:          logger.Session("lease-watcher"),
+	}
+
+	leaseHistoryRetentionSeconds := conf.LeaseHistoryRetentionSeconds
+	if leaseHistoryRetentionSeconds == 0 {
+		leaseHistoryRetentionSeconds = defaultLeaseHistoryRetentionSeconds
 	}
+	leaseHistoryPruner := &leaser.LeaseHistoryPruner{
+		Store:            databaseHandler,
+		RetentionSeconds: leaseHistoryRetentionSeconds,
+		PruneInterval:    leaseHistoryPruneInterval,
+		Logger:           logger.Session("lease-history-pruner"),
+	}
+
 	migrator := &database.Migrator{
 		DatabaseMigrator:              databaseHandler,
 		MaxMigrationAttempts:          5,
@@ -102,6 +159,25 @@ func mainWithError() error {
 	if err = migrator.TryMigrations(); err != nil {
 		return fmt.Errorf("migrating database: %s", err)
 	}
+	if err = overlayNetworkPool.Init(conf.Network); err != nil {
+		return fmt.Errorf("initializing overlay networks: %s", err)
+	}
+
+	// Metrics sources
+	metricSources := []metrics.MetricSource{
+		metrics.NewUptimeSource(),
+		server_metrics.NewTotalLeasesSource(databaseHandler),
+		server_metrics.NewStaleLeasesSource(databaseHandler, conf.StalenessThresholdSeconds),
+		server_metrics.NewReclaimedLeasesSource(databaseHandler),
+	}
+	metricSources = append(metricSources, server_metrics.NewPoolUsageSources(databaseHandler, overlayNetworkPool, conf.StalenessThresholdSeconds)...)
+	metricSources = append(metricSources, metrics.NewDBMonitorSource(connectionPool, connectionPool.Monitor)...)
+
+	prometheusMetrics, err := server_metrics.NewPrometheusMetrics(logger, metricSources...)
+	if err != nil {
+		return fmt.Errorf("creating prometheus metrics: %s", err)
+	}
+	connectionPool.Monitor = prometheusMetrics.MonitorDB(connectionPool.Monitor)
 
 	metricsSender := &metrics.MetricsSender{
 		Logger: logger.Session("time-metric-emitter"),
@@ -117,6 +193,13 @@ func mainWithError() error {
 		ErrorResponse:   errorResponse,
 	}
 
+	leasesWatch := &handlers.LeasesWatch{
+		Marshaler:     marshal.MarshalFunc(json.Marshal),
+		LeaseWatcher:  leaseWatcher,
+		ErrorResponse: errorResponse,
+		MaxWait:       maxLeaseWatchWait,
+	}
+
 	leasesAcquire := &handlers.LeasesAcquire{
 		Marshaler:     marshal.MarshalFunc(json.Marshal),
 		Unmarshaler:   marshal.UnmarshalFunc(json.Unmarshal),
@@ -137,12 +220,52 @@ func mainWithError() error {
 		ErrorResponse: errorResponse,
 	}
 
+	leasesHistory := &handlers.LeaseHistory{
+		Marshaler:              marshal.MarshalFunc(json.Marshal),
+		LeaseHistoryRepository: leaseController,
+		ErrorResponse:          errorResponse,
+	}
+
+	reservationsIndex := &handlers.ReservationsIndex{
+		Marshaler:             marshal.MarshalFunc(json.Marshal),
+		ReservationRepository: leaseController,
+		ErrorResponse:         errorResponse,
+	}
+
+	reservationsReserve := &handlers.ReserveSubnet{
+		Marshaler:      marshal.MarshalFunc(json.Marshal),
+		Unmarshaler:    marshal.UnmarshalFunc(json.Unmarshal),
+		SubnetReserver: leaseController,
+		ErrorResponse:  errorResponse,
+	}
+
+	reservationsDelete := &handlers.DeleteReservation{
+		Unmarshaler:        marshal.UnmarshalFunc(json.Unmarshal),
+		ReservationDeleter: leaseController,
+		ErrorResponse:      errorResponse,
+	}
+
+	overlayNetworksIndex := &handlers.OverlayNetworksIndex{
+		Marshaler:                marshal.MarshalFunc(json.Marshal),
+		OverlayNetworkRepository: overlayNetworkPool,
+		ErrorResponse:            errorResponse,
+	}
+
+	changeOverlayNetwork := func(action string) *handlers.ChangeOverlayNetwork {
+		return &handlers.ChangeOverlayNetwork{
+			Action:                action,
+			Unmarshaler:           marshal.UnmarshalFunc(json.Unmarshal),
+			OverlayNetworkChanger: overlayNetworkPool,
+			ErrorResponse:         errorResponse,
+		}
+	}
+
 	metricsWrap := func(name string, handle http.Handler) http.Handler {
 		metricsWrapper := middleware.MetricWrapper{
 			Name:          name,
 			MetricsSender: metricsSender,
 		}
-		return metricsWrapper.Wrap(handle)
+		return prometheusMetrics.WrapRoute(name, metricsWrapper.Wrap(handle))
 	}
 
 	type loggableHandler interface {
@@ -158,18 +281,46 @@ func mainWithError() error {
 			{Name: "leases-acquire", Method: "PUT", Path: "/leases/acquire"},
 			{Name: "leases-release", Method: "PUT", Path: "/leases/release"},
 			{Name: "leases-renew", Method: "PUT", Path: "/leases/renew"},
+			{Name: "leases-watch", Method: "GET", Path: "/leases/watch"},
+			{Name: "overlay-networks-index", Method: "GET", Path: "/overlay-networks"},
 		},
 		rata.Handlers{
-			"leases-index":   metricsWrap("LeasesIndex", logWrap(leasesIndex)),
-			"leases-acquire": metricsWrap("LeasesAcquire", logWrap(leasesAcquire)),
-			"leases-release": metricsWrap("LeasesRelease", logWrap(leasesRelease)),
-			"leases-renew":   metricsWrap("LeasesRenew", logWrap(leasesRenew)),
+			"leases-index":           metricsWrap("LeasesIndex", logWrap(leasesIndex)),
+			"leases-acquire":         metricsWrap("LeasesAcquire", logWrap(leasesAcquire)),
+			"leases-release":         metricsWrap("LeasesRelease", logWrap(leasesRelease)),
+			"leases-renew":           metricsWrap("LeasesRenew", logWrap(leasesRenew)),
+			"leases-watch":           metricsWrap(server_metrics.WatchRouteName, logWrap(leasesWatch)),
+			"overlay-networks-index": metricsWrap("OverlayNetworksIndex", logWrap(overlayNetworksIndex)),
 		},
 	)
 	if err != nil {
 		return fmt.Errorf("creating router: %s", err)
 	}
 
+	adminRouter, err := rata.NewRouter(
+		rata.Routes{
+			{Name: "leases-history", Method: "GET", Path: "/leases/history"},
+			{Name: "reservations-index", Method: "GET", Path: "/reservations"},
+			{Name: "reservations-reserve", Method: "PUT", Path: "/reservations/reserve"},
+			{Name: "reservations-delete", Method: "PUT", Path: "/reservations/delete"},
+			{Name: "overlay-networks-add", Method: "PUT", Path: "/overlay-networks/add"},
+			{Name: "overlay-networks-drain", Method: "PUT", Path: "/overlay-networks/drain"},
+			{Name: "overlay-networks-retire", Method: "PUT", Path: "/overlay-networks/retire"},
+		},
+		rata.Handlers{
+			"leases-history":          metricsWrap("LeasesHistory", logWrap(leasesHistory)),
+			"reservations-index":      metricsWrap("ReservationsIndex", logWrap(reservationsIndex)),
+			"reservations-reserve":    metricsWrap("ReservationsReserve", logWrap(reservationsReserve)),
+			"reservations-delete":     metricsWrap("ReservationsDelete", logWrap(reservationsDelete)),
+			"overlay-networks-add":    metricsWrap("OverlayNetworksAdd", logWrap(changeOverlayNetwork(handlers.OverlayNetworkAdd))),
+			"overlay-networks-drain":  metricsWrap("OverlayNetworksDrain", logWrap(changeOverlayNetwork(handlers.OverlayNetworkDrain))),
+			"overlay-networks-retire": metricsWrap("OverlayNetworksRetire", logWrap(changeOverlayNetwork(handlers.OverlayNetworkRetire))),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("creating admin router: %s", err)
+	}
+
 	health := &handlers.Health{
 		DatabaseChecker: databaseHandler,
 		ErrorResponse:   errorResponse,
@@ -197,20 +348,25 @@ func mainWithError() error {
 	httpServer := http_server.NewTLSServer(mainServerAddress, router, tlsConfig)
 	healthServer := http_server.New(healthServerAddress, healthRouter)
 
-	// Metrics sources
-	metricSources := []metrics.MetricSource{
-		metrics.NewUptimeSource(),
-		server_metrics.NewTotalLeasesSource(databaseHandler),
-		server_metrics.NewFreeLeasesSource(databaseHandler, cidrPool),
-		server_metrics.NewStaleLeasesSource(databaseHandler, conf.StalenessThresholdSeconds),
-	}
-	metricSources = append(metricSources, metrics.NewDBMonitorSource(connectionPool, connectionPool.Monitor)...)
 	metricsEmitter := metrics.NewMetricsEmitter(logger, time.Duration(conf.MetricsEmitSeconds)*time.Second, metricSources...)
 	members := grouper.Members{
+		{Name: "lease-watcher", Runner: leaseWatcher},
 		{Name: "http_server", Runner: httpServer},
 		{Name: "health-server", Runner: healthServer},
 		{Name: "debug-server", Runner: debugserver.Runner(debugServerAddress, reconfigurableSink)},
 		{Name: "metrics-emitter", Runner: metricsEmitter},
+		{Name: "overlay-networks-refresher", Runner: overlayNetworkPool},
+		{Name: "lease-history-pruner", Runner: leaseHistoryPruner},
+	}
+	if conf.AdminListenPort > 0 {
+		adminServerAddress := fmt.Sprintf("%s:%d", conf.ListenHost, conf.AdminListenPort)
+		members = append(members, grouper.Member{Name: "admin-server", Runner: http_server.NewTLSServer(adminServerAddress, adminRouter, adminTLSConfig)})
+	}
+	if conf.PrometheusPort > 0 {
+		prometheusServerAddress := fmt.Sprintf("%s:%d", conf.ListenHost, conf.PrometheusPort)
+		prometheusMux := http.NewServeMux()
+		prometheusMux.Handle("/metrics", prometheusMetrics.Handler())
+		members = append(members, grouper.Member{Name: "prometheus-server", Runner: http_server.New(prometheusServerAddress, prometheusMux)})
 	}
 
 	group := grouper.NewOrdered(os.Interrupt, members)
@@ -21,6 +21,8 @@ import (
 	"code.cloudfoundry.org/silk/client/config"
 	"code.cloudfoundry.org/silk/controller"
 	"code.cloudfoundry.org/silk/daemon"
+	"code.cloudfoundry.org/silk/daemon/backoff"
+	"code.cloudfoundry.org/silk/daemon/health"
 	"code.cloudfoundry.org/silk/daemon/planner"
 	"code.cloudfoundry.org/silk/daemon/vtep"
 	"code.cloudfoundry.org/silk/lib/adapter"
@@ -41,6 +43,15 @@ var logPrefix = "cfnetworking"
 
 const (
 	jobPrefix = "silk-daemon"
+
+	// leaseWatchInterval is the pause between two lease watch requests.
+	leaseWatchInterval = time.Second
+
+	// vtepRepairDelay collects the netlink updates of one change to the vtep
+	// before repairing it, and vtepRecreateRetryInterval is the pause before
+	// trying again to recreate a deleted vtep.
+	vtepRepairDelay           = 100 * time.Millisecond
+	vtepRecreateRetryInterval = 5 * time.Second
 )
 
 func main() {
@@ -92,24 +103,38 @@ func mainWithError() error {
 
 	vtepFactory := &vtep.Factory{
 		NetlinkAdapter: &adapter.NetlinkAdapter{},
+		BPFAdapter:     &adapter.BPFAdapter{},
 		Logger:         logger,
 	}
 	vtepConfigCreator := &vtep.ConfigCreator{
 		NetAdapter: &adapter.NetAdapter{},
 	}
 
-	client := controller.NewClient(logger, httpClient, cfg.ConnectivityServerURL)
+	client := controller.NewFailoverClient(logger, httpClient, cfg.ControllerURLs(), metricSender)
 
 	store := &datastore.Store{
 		Serializer: &serial.Serial{},
 		LockerNew:  filelock.NewLocker,
 	}
 
-	overlayNetworks, err := mcn.NewMultipleCIDRNetwork(cfg.OverlayNetworks)
+	ipv4OverlayNetworks, ipv6OverlayNetworks, err := mcn.SplitByFamily(cfg.OverlayNetworks)
+	if err != nil {
+		return fmt.Errorf("parse overlay network CIDR: %s", err)
+	}
+
+	overlayNetworks, err := mcn.NewMultipleCIDRNetwork(ipv4OverlayNetworks)
 	if err != nil {
 		return fmt.Errorf("parse overlay network CIDR: %s", err)
 	}
 
+	var overlayNetworksV6 mcn.MultipleCIDRNetwork
+	if len(ipv6OverlayNetworks) > 0 {
+		overlayNetworksV6, err = mcn.NewMultipleCIDRNetwork(ipv6OverlayNetworks)
+		if err != nil {
+			return fmt.Errorf("parse ipv6 overlay network CIDR: %s", err) // not tested, already parsed
+		}
+	}
+
 	lease, err := discoverLocalLease(cfg, vtepFactory)
 	if err != nil {
 		lease, err = acquireLease(logger, client, vtepConfigCreator, vtepFactory, cfg)
@@ -164,15 +189,20 @@ func mainWithError() error {
 		logger.Info("renewed-lease", lager.Data{"lease": lease})
 	}
 
-	debugServerAddress := fmt.Sprintf("127.0.0.1:%d", cfg.DebugServerPort)
-	networkInfo, err := getNetworkInfo(vtepFactory, cfg, lease)
+	vtepConf, err := vtepConfigCreator.Create(cfg, lease)
 	if err != nil {
-		return fmt.Errorf("get network info: %s", err) // not tested
+		return fmt.Errorf("create vtep config: %s", err) // not tested
 	}
 
-	healthCheckServer, err := buildHealthCheckServer(cfg.HealthCheckPort, networkInfo)
+	vtepDrift, err := recreateDriftedVTEP(cfg, logger, store, vtepFactory, vtepConf)
 	if err != nil {
-		return fmt.Errorf("create health check server: %s", err) // not tested
+		return err
+	}
+
+	debugServerAddress := fmt.Sprintf("127.0.0.1:%d", cfg.DebugServerPort)
+	networkInfo, err := getNetworkInfo(vtepFactory, cfg, lease)
+	if err != nil {
+		return fmt.Errorf("get network info: %s", err) // not tested
 	}
 
 	_, localSubnet, err := net.ParseCIDR(lease.OverlaySubnet)
@@ -180,41 +210,125 @@ func mainWithError() error {
 		return fmt.Errorf("parse local subnet CIDR: %s", err) // not tested
 	}
 
+	var localSubnetV6 *net.IPNet
+	if lease.OverlaySubnetV6 != "" {
+		_, localSubnetV6, err = net.ParseCIDR(lease.OverlaySubnetV6)
+		if err != nil {
+			return fmt.Errorf("parse local ipv6 subnet CIDR: %s", err) // not tested
+		}
+	}
+
 	vxlanIface, err := net.InterfaceByName(cfg.VTEPName)
 	if err != nil || vxlanIface == nil {
 		return fmt.Errorf("find local VTEP: %s", err) // not tested
 	}
 
-	vxlanPoller := &poller.Poller{
+	converger := &vtep.Converger{
+		OverlayNetwork:   overlayNetworks,
+		LocalSubnet:      localSubnet,
+		OverlayNetworkV6: overlayNetworksV6,
+		LocalSubnetV6:    localSubnetV6,
+		LocalVTEP:        *vxlanIface,
+		NetlinkAdapter:   &adapter.NetlinkAdapter{},
+		Logger:           logger,
+		IsSingleIP:       cfg.SingleIPOnly,
+		MetricSender:     metricSender,
+		Encapsulation:    vtepConf.Encapsulation,
+		VNI:              cfg.VNI,
+	}
+
+	errorDetector := planner.NewGracefulDetector(
+		time.Duration(cfg.PartitionToleranceSeconds) * time.Second,
+	)
+	vxlanPlanner := &planner.VXLANPlanner{
+		Logger:           logger,
+		ControllerClient: client,
+		Lease:            lease,
+		Converger:        converger,
+		ErrorDetector:    errorDetector,
+		MetricSender:     metricSender,
+	}
+
+	vxlanPoller := &backoff.Poller{
 		Logger:                 logger,
 		PollInterval:           time.Duration(cfg.PollInterval) * time.Second,
+		JitterPercent:          cfg.PollJitterPercent,
+		MaxBackoff:             time.Duration(cfg.PollMaxBackoffSeconds) * time.Second,
+		Deadline:               errorDetector.Deadline,
 		RunBeforeFirstInterval: true,
-		SingleCycleFunc: (&planner.VXLANPlanner{
-			Logger:           logger,
-			ControllerClient: client,
-			Lease:            lease,
-			Converger: &vtep.Converger{
-				OverlayNetwork: overlayNetworks,
-				LocalSubnet:    localSubnet,
-				LocalVTEP:      *vxlanIface,
-				NetlinkAdapter: &adapter.NetlinkAdapter{},
-				Logger:         logger,
-				IsSingleIP:     cfg.SingleIPOnly,
-			},
-			ErrorDetector: planner.NewGracefulDetector(
-				time.Duration(cfg.PartitionToleranceSeconds) * time.Second,
-			),
-			MetricSender: metricSender,
-		}).DoCycle,
+		SingleCycleFunc:        vxlanPlanner.DoCycle,
+	}
+
+	healthCheckServer, err := buildHealthCheckServer(cfg.HealthCheckPort, networkInfo, &health.Handler{
+		Planner:            vxlanPlanner,
+		Poller:             vxlanPoller,
+		Converger:          converger,
+		NetlinkAdapter:     &adapter.NetlinkAdapter{},
+		VTEPName:           cfg.VTEPName,
+		PartitionTolerance: time.Duration(cfg.PartitionToleranceSeconds) * time.Second,
+		VTEPDrift:          vtepDrift,
+		Started:            time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("create health check server: %s", err) // not tested
 	}
 
 	uptimeSource := metrics.NewUptimeSource()
 	metricsEmitter := metrics.NewMetricsEmitter(logger, 30*time.Second, uptimeSource)
 	members := grouper.Members{
 		{Name: "server", Runner: healthCheckServer},
+	}
+	if cfg.ReleaseLeaseOnShutdown {
+		// members are stopped in reverse order, so the lease is released
+		// after renewing and repairing have stopped, and before the server
+		// that silk-daemon-shutdown waits on goes away
+		members = append(members, grouper.Member{Name: "lease-releaser", Runner: &planner.LeaseReleaser{
+			Logger:           logger,
+			ControllerClient: client,
+			Store:            store,
+			DatastorePath:    cfg.Datastore,
+			VTEPDeleter:      vtepFactory,
+			VTEPName:         cfg.VTEPName,
+			Lease:            lease,
+			ReleaseFile:      cfg.LeaseReleaseFile,
+		}})
+	}
+	members = append(members, grouper.Members{
 		{Name: "vxlan-poller", Runner: vxlanPoller},
+		{Name: "vtep-repairer", Runner: &vtep.Repairer{
+			Converger:      converger,
+			Factory:        vtepFactory,
+			VTEPConfig:     vtepConf,
+			NetlinkAdapter: &adapter.NetlinkAdapter{},
+			Logger:         logger,
+			MetricSender:   metricSender,
+			RepairDelay:    vtepRepairDelay,
+			RetryInterval:  vtepRecreateRetryInterval,
+		}},
 		{Name: "debug-server", Runner: debugserver.Runner(debugServerAddress, reconfigurableSink)},
 		{Name: "metrics-emitter", Runner: metricsEmitter},
+	}...)
+	if cfg.LeaseWatchSeconds > 0 {
+		// watch requests wait for changes, so they get a longer timeout.
+		// Revisions are only valid for one controller, so watches use their
+		// own single connection per host name: a host name that resolves to
+		// several controllers keeps the watch on one of them until the
+		// connection is lost.
+		watchHTTPClient := &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: tlsConfig,
+				MaxConnsPerHost: 1,
+			},
+			Timeout: time.Duration(cfg.ClientTimeoutSeconds+cfg.LeaseWatchSeconds) * time.Second,
+		}
+		vxlanPlanner.LeaseWatcher = controller.NewStickyFailoverClient(logger, watchHTTPClient, cfg.ControllerURLs(), metricSender)
+		vxlanPlanner.WatchSeconds = cfg.LeaseWatchSeconds
+		members = append(members, grouper.Member{Name: "lease-watcher", Runner: &poller.Poller{
+			Logger:                 logger,
+			PollInterval:           leaseWatchInterval,
+			RunBeforeFirstInterval: true,
+			SingleCycleFunc:        vxlanPlanner.WatchCycle,
+		}})
 	}
 	group := grouper.NewOrdered(os.Interrupt, members)
 	monitor := ifrit.Invoke(sigmon.New(group))
@@ -257,19 +371,24 @@ func acquireLease(logger lager.Logger, client *controller.Client, vtepConfigCrea
 	return lease, nil
 }
 
-func buildHealthCheckServer(healthCheckPort uint16, networkInfo daemon.NetworkInfo) (ifrit.Runner, error) {
+func buildHealthCheckServer(healthCheckPort uint16, networkInfo daemon.NetworkInfo, healthHandler http.Handler) (ifrit.Runner, error) {
 	networkBytes, err := json.Marshal(networkInfo)
 	if err != nil {
 		return nil, fmt.Errorf("unmarshaling network info: %s", err) // not possible
 	}
 
+	// silk-cni reads the network info from any path but /health
+	mux := http.NewServeMux()
+	mux.Handle("/health", healthHandler)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		// #nosec G104 - ignore errors when writing HTTP responses so we don't spam our logs during a DoS
+		w.Write(networkBytes)
+	})
+
 	return http_server.New(
 		fmt.Sprintf("127.0.0.1:%d", healthCheckPort),
-		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			w.WriteHeader(http.StatusOK)
-			// #nosec G104 - ignore errors when writing HTTP responses so we don't spam our logs during a DoS
-			w.Write(networkBytes)
-		}),
+		mux,
 	), nil
 }
 
@@ -278,19 +397,31 @@ func discoverLocalLease(clientConfig config.Config, vtepFactory *vtep.Factory) (
 	if err != nil {
 		return controller.Lease{}, fmt.Errorf("get vtep overlay ip: %s", err) // not tested
 	}
-	return leaseFromVTEPState(clientConfig, overlayHwAddr, overlayIP), nil
+	overlayIPv6, err := vtepFactory.GetVTEPIPv6(clientConfig.VTEPName)
+	if err != nil {
+		return controller.Lease{}, fmt.Errorf("get vtep overlay ipv6: %s", err) // not tested
+	}
+	return leaseFromVTEPState(clientConfig, overlayHwAddr, overlayIP, overlayIPv6), nil
 }
 
-func leaseFromVTEPState(clientConfig config.Config, overlayHwAddr net.HardwareAddr, overlayIP net.IP) controller.Lease {
+func leaseFromVTEPState(clientConfig config.Config, overlayHwAddr net.HardwareAddr, overlayIP, overlayIPv6 net.IP) controller.Lease {
 	overlaySubnet := &net.IPNet{
 		IP:   overlayIP,
 		Mask: net.CIDRMask(clientConfig.SubnetPrefixLength, 32),
 	}
-	return controller.Lease{
+	lease := controller.Lease{
 		UnderlayIP:          clientConfig.UnderlayIP,
 		OverlaySubnet:       overlaySubnet.String(),
 		OverlayHardwareAddr: overlayHwAddr.String(),
 	}
+	if overlayIPv6 != nil {
+		overlaySubnetV6 := &net.IPNet{
+			IP:   overlayIPv6,
+			Mask: net.CIDRMask(clientConfig.SubnetPrefixLengthV6, 128),
+		}
+		lease.OverlaySubnetV6 = overlaySubnetV6.String()
+	}
+	return lease
 }
 
 func getNetworkInfo(vtepFactory *vtep.Factory, clientConfig config.Config, lease controller.Lease) (daemon.NetworkInfo, error) {
@@ -300,8 +431,9 @@ func getNetworkInfo(vtepFactory *vtep.Factory, clientConfig config.Config, lease
 	}
 
 	return daemon.NetworkInfo{
-		OverlaySubnet: lease.OverlaySubnet,
-		MTU:           mtu,
+		OverlaySubnet:   lease.OverlaySubnet,
+		MTU:             mtu,
+		OverlaySubnetV6: lease.OverlaySubnetV6,
 	}, nil
 }
 
@@ -313,6 +445,84 @@ func deleteAndAcquire(cfg config.Config, logger lager.Logger, client *controller
 	return acquireLease(logger, client, vtepConfigCreator, vtepFactory, cfg)
 }
 
+// recreateDriftedVTEP recreates the vtep with the same lease when it no
+// longer matches the config, e.g. after the vni, vtep_port or
+// vxlan_interface_name changed. Containers lose connectivity while that
+// happens, so unless it is forced the old vtep is kept when there are any,
+// and the drift that was kept is returned. The datastore stays locked from
+// the check until the vtep is recreated, so that silk-cni cannot add a
+// container in the meantime. A changed mtu is applied in place, see
+// setVTEPMTU.
+func recreateDriftedVTEP(cfg config.Config, logger lager.Logger, store *datastore.Store, vtepFactory *vtep.Factory, vtepConf *vtep.Config) ([]string, error) {
+	var (
+		kept        []string
+		recreateErr error
+	)
+	err := store.ReadAllLocked(cfg.Datastore, func(containers map[string]datastore.Container) error {
+		kept, recreateErr = recreateVTEPIfDrifted(cfg, logger, containers, vtepFactory, vtepConf)
+		if recreateErr != nil {
+			return nil
+		}
+		var mtuDrift []string
+		mtuDrift, recreateErr = setVTEPMTU(cfg, logger, containers, vtepFactory, vtepConf)
+		kept = append(kept, mtuDrift...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("read datastore: %s", err)
+	}
+	if recreateErr != nil {
+		return nil, recreateErr
+	}
+	return kept, nil
+}
+
+func recreateVTEPIfDrifted(cfg config.Config, logger lager.Logger, containers map[string]datastore.Container, vtepFactory *vtep.Factory, vtepConf *vtep.Config) ([]string, error) {
+	drift, err := vtepFactory.Drift(vtepConf)
+	if err != nil {
+		return nil, fmt.Errorf("check vtep: %s", err) // not tested
+	}
+	if len(drift) == 0 {
+		return nil, nil
+	}
+
+	if len(containers) != 0 && !cfg.ForceVTEPRecreate {
+		logger.Error("vtep-drift", fmt.Errorf("vtep does not match config and has containers: %d", len(containers)), lager.Data{"drift": drift})
+		return drift, nil
+	}
+
+	logger.Info("recreating-vtep", lager.Data{"drift": drift, "containers": len(containers)})
+	err = vtepFactory.DeleteVTEP(cfg.VTEPName)
+	if err != nil {
+		return nil, fmt.Errorf("delete vtep: %s", err) // not tested
+	}
+	err = vtepFactory.CreateVTEP(vtepConf)
+	if err != nil {
+		return nil, fmt.Errorf("create vtep: %s", err)
+	}
+	return nil, nil
+}
+
+// setVTEPMTU changes the mtu of the vtep in place. Running containers keep
+// their mtu, so a lower mtu is only applied when there are none or when
+// recreating the vtep is forced. Otherwise the mismatch is returned as drift.
+func setVTEPMTU(cfg config.Config, logger lager.Logger, containers map[string]datastore.Container, vtepFactory *vtep.Factory, vtepConf *vtep.Config) ([]string, error) {
+	lower := len(containers) == 0 || cfg.ForceVTEPRecreate
+	previous, err := vtepFactory.SetMTU(vtepConf, lower)
+	if err != nil {
+		return nil, fmt.Errorf("set vtep mtu: %s", err)
+	}
+	if previous > vtepConf.MTU && !lower {
+		drift := []string{fmt.Sprintf("mtu: %d, want %d", previous, vtepConf.MTU)}
+		logger.Error("vtep-drift", fmt.Errorf("vtep mtu is larger than config and has containers: %d", len(containers)), lager.Data{"drift": drift})
+		return drift, nil
+	}
+	if previous != vtepConf.MTU {
+		logger.Info("vtep-mtu-changed", lager.Data{"previous_mtu": previous, "mtu": vtepConf.MTU})
+	}
+	return nil, nil
+}
+
 func getLagerConfig(level string) lagerflags.LagerConfig {
 	lagerConfig := lagerflags.DefaultLagerConfig()
 	lagerConfig.TimeFormat = lagerflags.FormatRFC3339
@@ -8,6 +8,7 @@ import (
 
 	"github.com/hashicorp/go-multierror"
 
+	"code.cloudfoundry.org/cf-networking-helpers/metrics"
 	"code.cloudfoundry.org/cf-networking-helpers/mutualtls"
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/lager/v3/lagerflags"
@@ -53,7 +54,7 @@ func mainWithError() error {
 			TLSClientConfig: tlsConfig,
 		},
 	}
-	client := controller.NewClient(logger, httpClient, cfg.ConnectivityServerURL)
+	client := controller.NewFailoverClient(logger, httpClient, cfg.ControllerURLs(), &metrics.NoOpMetricsSender{})
 
 	var errList error
 	if err := client.ReleaseSubnetLease(cfg.UnderlayIP); err != nil {
@@ -0,0 +1,27 @@
+package adapter
+
+import (
+	"github.com/safchain/ethtool"
+)
+
+// EthtoolAdapter opens an ethtool socket for every call, since the socket
+// is bound to the network namespace it was opened in.
+type EthtoolAdapter struct{}
+
+func (*EthtoolAdapter) Features(deviceName string) (map[string]bool, error) {
+	e, err := ethtool.NewEthtool()
+	if err != nil {
+		return nil, err
+	}
+	defer e.Close()
+	return e.Features(deviceName)
+}
+
+func (*EthtoolAdapter) Change(deviceName string, features map[string]bool) error {
+	e, err := ethtool.NewEthtool()
+	if err != nil {
+		return err
+	}
+	defer e.Close()
+	return e.Change(deviceName, features)
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Attachment is a silk interface of a container in another overlay network
+// than its primary interface, like a dedicated service mesh network. It gets
+// its own IP from the subnet of the cell in that network, its own veth pair,
+// and only routes that network.
+type Attachment struct {
+	// IfName is the name of the interface in the container
+	IfName string `json:"ifName"`
+	// Name is the network name that IPs are allocated under
+	Name string `json:"name"`
+	// Network is the overlay network, which is routed through the interface
+	Network string `json:"network"`
+
+	// the subnet of the cell in the overlay network comes from a silk-daemon
+	// or a subnet file, like for the primary interface
+	DaemonPort int    `json:"daemonPort"`
+	SubnetFile string `json:"subnetFile"`
+	MTU        int    `json:"mtu"`
+}
+
+// Validate returns an error when the attachment cannot be set up. The
+// interface name is checked when the config is created.
+func (a Attachment) Validate() error {
+	if a.IfName == "" {
+		return errors.New("ifName must be set")
+	}
+	if a.Name == "" {
+		return fmt.Errorf("%s: name must be set", a.IfName)
+	}
+	if _, err := a.network(); err != nil {
+		return fmt.Errorf("%s: %s", a.IfName, err)
+	}
+	if a.DaemonPort == 0 && a.SubnetFile == "" {
+		return fmt.Errorf("%s: daemonPort or subnetFile must be set", a.IfName)
+	}
+	return nil
+}
+
+// Overlaps reports whether the overlay network of the attachment shares
+// addresses with the given network. It is false when either is invalid.
+func (a Attachment) Overlaps(cidr string) bool {
+	network, err := a.network()
+	if err != nil {
+		return false
+	}
+	_, other, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false
+	}
+	return network.Contains(other.IP) || other.Contains(network.IP)
+}
+
+func (a Attachment) network() (*net.IPNet, error) {
+	_, network, err := net.ParseCIDR(a.Network)
+	if err != nil {
+		return nil, fmt.Errorf("invalid network: %s", err)
+	}
+	if network.IP.To4() == nil {
+		return nil, fmt.Errorf("network %s is not ipv4", network)
+	}
+	return network, nil
+}
@@ -0,0 +1,86 @@
+package config_test
+
+import (
+	"code.cloudfoundry.org/silk/cni/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Attachment", func() {
+	var attachment config.Attachment
+
+	BeforeEach(func() {
+		attachment = config.Attachment{
+			IfName:     "eth1",
+			Name:       "mesh",
+			Network:    "10.250.0.0/16",
+			DaemonPort: 8090,
+		}
+	})
+
+	Describe("Validate", func() {
+		It("accepts an attachment with a silk-daemon", func() {
+			Expect(attachment.Validate()).To(Succeed())
+		})
+
+		It("accepts an attachment with a subnet file", func() {
+			attachment.DaemonPort = 0
+			attachment.SubnetFile = "/some/subnet.env"
+			Expect(attachment.Validate()).To(Succeed())
+		})
+
+		Context("when the interface name is missing", func() {
+			It("returns an error", func() {
+				attachment.IfName = ""
+				Expect(attachment.Validate()).To(MatchError("ifName must be set"))
+			})
+		})
+
+		Context("when the name is missing", func() {
+			It("returns an error", func() {
+				attachment.Name = ""
+				Expect(attachment.Validate()).To(MatchError("eth1: name must be set"))
+			})
+		})
+
+		Context("when the network is invalid", func() {
+			It("returns an error", func() {
+				attachment.Network = "10.250.0.0"
+				Expect(attachment.Validate()).To(MatchError("eth1: invalid network: invalid CIDR address: 10.250.0.0"))
+			})
+		})
+
+		Context("when the network is not ipv4", func() {
+			It("returns an error", func() {
+				attachment.Network = "fd00::/64"
+				Expect(attachment.Validate()).To(MatchError("eth1: network fd00::/64 is not ipv4"))
+			})
+		})
+
+		Context("when neither a silk-daemon nor a subnet file is set", func() {
+			It("returns an error", func() {
+				attachment.DaemonPort = 0
+				Expect(attachment.Validate()).To(MatchError("eth1: daemonPort or subnetFile must be set"))
+			})
+		})
+	})
+
+	Describe("Overlaps", func() {
+		It("is true when the networks share addresses", func() {
+			Expect(attachment.Overlaps("10.250.7.0/24")).To(BeTrue())
+			Expect(attachment.Overlaps("10.0.0.0/8")).To(BeTrue())
+			Expect(attachment.Overlaps("10.250.0.0/16")).To(BeTrue())
+		})
+
+		It("is false when the networks are apart", func() {
+			Expect(attachment.Overlaps("10.255.30.0/24")).To(BeFalse())
+			Expect(attachment.Overlaps("10.251.0.0/16")).To(BeFalse())
+		})
+
+		It("is false when a network is invalid", func() {
+			Expect(attachment.Overlaps("10.250.0.0")).To(BeFalse())
+			attachment.Network = "potato"
+			Expect(attachment.Overlaps("10.250.0.0/16")).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"fmt"
+	"math"
+)
+
+// Bandwidth limits the traffic into and out of a container. Rates are in
+// bits per second and bursts in bits, like the bandwidth capability of CNI.
+// A direction with a rate of zero is not limited.
+type Bandwidth struct {
+	IngressRate  uint64 `json:"ingressRate"`
+	IngressBurst uint64 `json:"ingressBurst"`
+	EgressRate   uint64 `json:"egressRate"`
+	EgressBurst  uint64 `json:"egressBurst"`
+}
+
+func (b Bandwidth) IsLimited() bool {
+	return b.IngressRate > 0 || b.EgressRate > 0
+}
+
+// Validate checks that the burst of each direction can hold a packet of the
+// given MTU and that the token bucket filter can represent the time it
+// takes to send the burst at the rate.
+func (b Bandwidth) Validate(mtu int) error {
+	if err := validateLimit("ingress", b.IngressRate, b.IngressBurst, mtu); err != nil {
+		return err
+	}
+	return validateLimit("egress", b.EgressRate, b.EgressBurst, mtu)
+}
+
+func validateLimit(direction string, rate, burst uint64, mtu int) error {
+	if rate == 0 && burst == 0 {
+		return nil
+	}
+	if rate == 0 || burst == 0 {
+		return fmt.Errorf("%s rate and burst must both be set", direction)
+	}
+	if rate < 8 {
+		return fmt.Errorf("%s rate %d must be at least 8 bits per second", direction, rate)
+	}
+	if burst/8 < uint64(mtu) {
+		return fmt.Errorf("%s burst %d bits is smaller than a packet of %d bytes", direction, burst, mtu)
+	}
+	if burst/8 > math.MaxUint32 {
+		return fmt.Errorf("%s burst %d bits is larger than 4GB", direction, burst)
+	}
+	if float64(burst)/float64(rate)*1e6 > math.MaxUint32 {
+		return fmt.Errorf("%s burst %d bits is too large for the rate %d bits per second", direction, burst, rate)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package config_test
+
+import (
+	"code.cloudfoundry.org/silk/cni/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bandwidth", func() {
+	var bandwidth config.Bandwidth
+
+	BeforeEach(func() {
+		bandwidth = config.Bandwidth{
+			IngressRate:  1024 * 1024,
+			IngressBurst: 512 * 1024,
+			EgressRate:   2048 * 1024,
+			EgressBurst:  1024 * 1024,
+		}
+	})
+
+	It("is valid when the bursts can hold a packet", func() {
+		Expect(bandwidth.Validate(1450)).To(Succeed())
+		Expect(bandwidth.IsLimited()).To(BeTrue())
+	})
+
+	It("is valid and not limited when nothing is set", func() {
+		Expect(config.Bandwidth{}.Validate(1450)).To(Succeed())
+		Expect(config.Bandwidth{}.IsLimited()).To(BeFalse())
+	})
+
+	It("allows limiting a single direction", func() {
+		bandwidth.EgressRate = 0
+		bandwidth.EgressBurst = 0
+		Expect(bandwidth.Validate(1450)).To(Succeed())
+		Expect(bandwidth.IsLimited()).To(BeTrue())
+	})
+
+	Context("when only the rate or the burst is set", func() {
+		It("returns an error", func() {
+			bandwidth.IngressBurst = 0
+			Expect(bandwidth.Validate(1450)).To(MatchError("ingress rate and burst must both be set"))
+
+			bandwidth.IngressBurst = 512 * 1024
+			bandwidth.EgressRate = 0
+			Expect(bandwidth.Validate(1450)).To(MatchError("egress rate and burst must both be set"))
+		})
+	})
+
+	Context("when the rate is less than a byte per second", func() {
+		It("returns an error", func() {
+			bandwidth.IngressRate = 7
+			Expect(bandwidth.Validate(1450)).To(MatchError("ingress rate 7 must be at least 8 bits per second"))
+		})
+	})
+
+	Context("when the burst cannot hold a packet", func() {
+		It("returns an error", func() {
+			bandwidth.EgressBurst = 1449 * 8
+			Expect(bandwidth.Validate(1450)).To(MatchError("egress burst 11592 bits is smaller than a packet of 1450 bytes"))
+		})
+	})
+
+	Context("when the burst is larger than 4GB", func() {
+		It("returns an error", func() {
+			bandwidth.IngressRate = 1 << 40
+			bandwidth.IngressBurst = 1 << 40
+			Expect(bandwidth.Validate(1450)).To(MatchError("ingress burst 1099511627776 bits is larger than 4GB"))
+		})
+	})
+
+	Context("when the burst takes too long to send at the rate", func() {
+		It("returns an error", func() {
+			bandwidth.IngressRate = 8
+			bandwidth.IngressBurst = 1 << 30
+			Expect(bandwidth.Validate(1450)).To(MatchError("ingress burst 1073741824 bits is too large for the rate 8 bits per second"))
+		})
+	})
+})
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"encoding/binary"
+	"fmt"
 	"net"
 
 	"github.com/containernetworking/cni/pkg/types"
@@ -28,16 +30,113 @@ type Config struct {
 		Routes              []*types.Route
 	}
 	Host struct {
-		DeviceName string
-		Namespace  netNS
-		Address    DualAddress
+		DeviceName    string
+		IFBDeviceName string
+		Namespace     netNS
+		Address       DualAddress
+		// RouteTable holds the routes of the container via other gateways
+		// on the cell, see RouteTable
+		RouteTable int
 	}
 }
 
+const (
+	// RouteTableBase is the first of the routing tables on the cell that
+	// silk-cni adds the routes of containers via other gateways to. It uses
+	// the RouteTableSize tables from there on, which no other software on
+	// the cell may use.
+	RouteTableBase = 0x10000
+	RouteTableSize = 1 << 16
+
+	// GatewayRulePriority is the priority of the rules that send the
+	// traffic of a container to its routing table. It puts them in front of
+	// the main table at 32766, which would route the traffic by its
+	// destination. silk-cni only deletes the rules of its tables that have
+	// this priority.
+	GatewayRulePriority = 100
+)
+
+// RouteTable returns the routing table on the cell of the container with the
+// given primary IP, which is RouteTableBase plus the low 16 bits of the IP.
+// The routes of all the interfaces of a container are in the table of its
+// primary IP. The low bits differ for every address of a cell subnet of at
+// most RouteTableSize addresses, see ValidateRouteTables, and they give the
+// table back on DEL without the cell subnet.
+func RouteTable(primaryIP net.IP) int {
+	ip := primaryIP.To4()
+	if ip == nil {
+		return 0
+	}
+	return RouteTableBase + int(binary.BigEndian.Uint32(ip)%RouteTableSize)
+}
+
+// ValidateRouteTables returns an error when some routes need a routing table
+// on the cell and the cell subnet has more addresses than there are tables.
+func ValidateRouteTables(routes []Route, cellSubnet string) error {
+	if len(RouteGateways(routes)) == 0 {
+		return nil
+	}
+	_, subnet, err := net.ParseCIDR(cellSubnet)
+	if err != nil {
+		return fmt.Errorf("invalid cell subnet: %s", err)
+	}
+	ones, bits := subnet.Mask.Size()
+	if 1<<(bits-ones) > RouteTableSize {
+		return fmt.Errorf("routes with a gateway need a cell subnet of at most %d addresses, %s is larger", RouteTableSize, cellSubnet)
+	}
+	return nil
+}
+
+// AddRoutes adds routes through the container interface, via their gateway
+// or the host end of its veth pair.
+func (c *Config) AddRoutes(routes []Route) error {
+	for _, r := range routes {
+		dst, err := r.destination()
+		if err != nil {
+			return err
+		}
+		gw := c.Host.Address.IP
+		if r.GW != "" {
+			gw, err = r.gateway()
+			if err != nil {
+				return err
+			}
+		}
+		c.Container.Routes = append(c.Container.Routes, &types.Route{
+			Dst: *dst,
+			GW:  gw,
+		})
+	}
+	return nil
+}
+
+// GatewayRoutes returns the routes of the container via a gateway other than
+// the host end of its veth pair. The cell forwards the traffic of the
+// container to them through its RouteTable.
+func (c *Config) GatewayRoutes() []*types.Route {
+	var routes []*types.Route
+	for _, r := range c.Container.Routes {
+		if !r.GW.Equal(c.Host.Address.IP) {
+			routes = append(routes, r)
+		}
+	}
+	return routes
+}
+
 func (c *Config) AsCNIResult() *current.Result {
-	ipInterface := 1
-	return &current.Result{
-		Interfaces: []*current.Interface{
+	return CNIResult(c)
+}
+
+// CNIResult reports the interfaces of a container in one result. Each config
+// adds its host and container interfaces, in order, and the IP and routes of
+// the container interface.
+func CNIResult(cfgs ...*Config) *current.Result {
+	result := &current.Result{
+		DNS: types.DNS{},
+	}
+	for _, c := range cfgs {
+		ipInterface := len(result.Interfaces) + 1
+		result.Interfaces = append(result.Interfaces,
 			&current.Interface{
 				Name:    c.Host.DeviceName,
 				Mac:     c.Host.Address.Hardware.String(),
@@ -48,18 +147,16 @@ func (c *Config) AsCNIResult() *current.Result {
 				Mac:     c.Container.Address.Hardware.String(),
 				Sandbox: c.Container.Namespace.Path(),
 			},
-		},
-		IPs: []*current.IPConfig{
-			&current.IPConfig{
-				Interface: &ipInterface,
-				Address: net.IPNet{
-					IP:   c.Container.Address.IP,
-					Mask: []byte{255, 255, 255, 255},
-				},
-				Gateway: c.Host.Address.IP,
+		)
+		result.IPs = append(result.IPs, &current.IPConfig{
+			Interface: &ipInterface,
+			Address: net.IPNet{
+				IP:   c.Container.Address.IP,
+				Mask: []byte{255, 255, 255, 255},
 			},
-		},
-		Routes: c.Container.Routes,
-		DNS:    types.DNS{},
+			Gateway: c.Host.Address.IP,
+		})
+		result.Routes = append(result.Routes, c.Container.Routes...)
 	}
+	return result
 }
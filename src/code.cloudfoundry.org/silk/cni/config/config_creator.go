@@ -20,8 +20,9 @@ type hardwareAddressGenerator interface {
 
 //go:generate counterfeiter -o fakes/deviceNameGenerator.go --fake-name DeviceNameGenerator . deviceNameGenerator
 type deviceNameGenerator interface {
-	GenerateForHost(containerIP net.IP) (string, error)
-	GenerateTemporaryForContainer(containerIP net.IP) (string, error)
+	GenerateForHost(containerIP net.IP, attachment string) (string, error)
+	GenerateTemporaryForContainer(containerIP net.IP, attachment string) (string, error)
+	GenerateForHostIFB(containerIP net.IP, attachment string) (string, error)
 }
 
 //go:generate counterfeiter -o fakes/namespaceAdapter.go --fake-name NamespaceAdapter . namespaceAdapter
@@ -37,22 +38,42 @@ type ConfigCreator struct {
 	Logger                   lager.Logger
 }
 
+// Create returns the config of the primary interface of a container, which
+// takes the default route.
 func (c *ConfigCreator) Create(hostNS netNS, addCmdArgs *skel.CmdArgs, ipamResult *current.Result, mtu int) (*Config, error) {
+	defaultRoute := net.IPNet{
+		IP:   net.IPv4zero,
+		Mask: net.CIDRMask(0, 32),
+	}
+	return c.create(hostNS, addCmdArgs.Netns, addCmdArgs.IfName, "", defaultRoute, ipamResult, mtu)
+}
+
+// CreateForAttachment returns the config of an attachment of a container,
+// which only routes the overlay network of the attachment.
+func (c *ConfigCreator) CreateForAttachment(hostNS netNS, addCmdArgs *skel.CmdArgs, attachment Attachment, ipamResult *current.Result, mtu int) (*Config, error) {
+	network, err := attachment.network()
+	if err != nil {
+		return nil, err
+	}
+	return c.create(hostNS, addCmdArgs.Netns, attachment.IfName, attachment.IfName, *network, ipamResult, mtu)
+}
+
+func (c *ConfigCreator) create(hostNS netNS, netns, ifName, attachment string, route net.IPNet, ipamResult *current.Result, mtu int) (*Config, error) {
 	var conf Config
 	var err error
 
 	c.Logger.Debug("start")
 	defer c.Logger.Debug("done")
 
-	if addCmdArgs.IfName == "" {
+	if ifName == "" {
 		return nil, errors.New("IfName cannot be empty")
 	}
-	if len(addCmdArgs.IfName) > 15 {
+	if len(ifName) > 15 {
 		return nil, errors.New("IfName cannot be longer than 15 characters")
 	}
 
-	conf.Container.DeviceName = addCmdArgs.IfName
-	conf.Container.Namespace, err = c.NamespaceAdapter.GetNS(addCmdArgs.Netns)
+	conf.Container.DeviceName = ifName
+	conf.Container.Namespace, err = c.NamespaceAdapter.GetNS(netns)
 	if err != nil {
 		return nil, fmt.Errorf("getting container namespace: %s", err)
 	}
@@ -62,7 +83,7 @@ func (c *ConfigCreator) Create(hostNS netNS, addCmdArgs *skel.CmdArgs, ipamResul
 	}
 	conf.Container.Address.IP = ipamResult.IPs[0].Address.IP
 
-	conf.Container.TemporaryDeviceName, err = c.DeviceNameGenerator.GenerateTemporaryForContainer(conf.Container.Address.IP)
+	conf.Container.TemporaryDeviceName, err = c.DeviceNameGenerator.GenerateTemporaryForContainer(conf.Container.Address.IP, attachment)
 	if err != nil {
 		return nil, fmt.Errorf("generating temporary container device name: %s", err)
 	}
@@ -73,11 +94,16 @@ func (c *ConfigCreator) Create(hostNS netNS, addCmdArgs *skel.CmdArgs, ipamResul
 	}
 
 	conf.Container.MTU = mtu
-	conf.Host.DeviceName, err = c.DeviceNameGenerator.GenerateForHost(conf.Container.Address.IP)
+	conf.Host.DeviceName, err = c.DeviceNameGenerator.GenerateForHost(conf.Container.Address.IP, attachment)
 	if err != nil {
 		return nil, fmt.Errorf("generating host device name: %s", err)
 	}
 
+	conf.Host.IFBDeviceName, err = c.DeviceNameGenerator.GenerateForHostIFB(conf.Container.Address.IP, attachment)
+	if err != nil {
+		return nil, fmt.Errorf("generating host ifb device name: %s", err)
+	}
+
 	conf.Host.Namespace = hostNS
 	conf.Host.Address.IP = net.IP{169, 254, 0, 1}
 	conf.Host.Address.Hardware, err = c.HardwareAddressGenerator.GenerateForHost(conf.Container.Address.IP)
@@ -87,11 +113,8 @@ func (c *ConfigCreator) Create(hostNS netNS, addCmdArgs *skel.CmdArgs, ipamResul
 
 	conf.Container.Routes = []*types.Route{
 		{
-			Dst: net.IPNet{
-				IP:   net.IPv4zero,
-				Mask: net.CIDRMask(0, 32),
-			},
-			GW: []byte{169, 254, 0, 1},
+			Dst: route,
+			GW:  []byte{169, 254, 0, 1},
 		},
 	}
 
@@ -60,6 +60,7 @@ var _ = Describe("ConfigCreator", func() {
 			fakeHardwareAddressGenerator.GenerateForHostReturns(hostMAC, nil)
 			fakeDeviceNameGenerator.GenerateForHostReturns("s-010255030004", nil)
 			fakeDeviceNameGenerator.GenerateTemporaryForContainerReturns("c-010255030004", nil)
+			fakeDeviceNameGenerator.GenerateForHostIFBReturns("i-010255030004", nil)
 			containerNS.PathReturns("/some/container/namespace")
 			configCreator = &config.ConfigCreator{
 				HardwareAddressGenerator: fakeHardwareAddressGenerator,
@@ -97,9 +98,88 @@ var _ = Describe("ConfigCreator", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(conf.Host.DeviceName).To(Equal("s-010255030004"))
+			Expect(conf.Host.IFBDeviceName).To(Equal("i-010255030004"))
 			Expect(conf.Host.Namespace).To(Equal(hostNS))
 			Expect(conf.Host.Address.IP).To(Equal(net.IP{169, 254, 0, 1}))
 			Expect(conf.Host.Address.Hardware).To(Equal(hostMAC))
+
+			By("naming the devices after the primary interface")
+			ip, attachment := fakeDeviceNameGenerator.GenerateForHostArgsForCall(0)
+			Expect(ip).To(Equal(ipamResult.IPs[0].Address.IP))
+			Expect(attachment).To(BeEmpty())
+			_, attachment = fakeDeviceNameGenerator.GenerateTemporaryForContainerArgsForCall(0)
+			Expect(attachment).To(BeEmpty())
+			_, attachment = fakeDeviceNameGenerator.GenerateForHostIFBArgsForCall(0)
+			Expect(attachment).To(BeEmpty())
+		})
+
+		Describe("CreateForAttachment", func() {
+			var attachment config.Attachment
+
+			BeforeEach(func() {
+				attachment = config.Attachment{
+					IfName:     "eth1",
+					Name:       "mesh",
+					Network:    "10.250.0.0/16",
+					DaemonPort: 8090,
+				}
+			})
+
+			It("creates a config for the interface of the attachment", func() {
+				conf, err := configCreator.CreateForAttachment(hostNS, addCmdArgs, attachment, ipamResult, 1400)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(conf.Container.DeviceName).To(Equal("eth1"))
+				Expect(conf.Container.Namespace).To(Equal(containerNS))
+				Expect(conf.Container.Address.IP).To(Equal(ipamResult.IPs[0].Address.IP))
+				Expect(conf.Container.MTU).To(Equal(1400))
+				Expect(fakeNamespaceAdapter.GetNSArgsForCall(0)).To(Equal("/some/container/namespace"))
+			})
+
+			It("names the devices after the attachment", func() {
+				_, err := configCreator.CreateForAttachment(hostNS, addCmdArgs, attachment, ipamResult, 1400)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, name := fakeDeviceNameGenerator.GenerateForHostArgsForCall(0)
+				Expect(name).To(Equal("eth1"))
+				_, name = fakeDeviceNameGenerator.GenerateTemporaryForContainerArgsForCall(0)
+				Expect(name).To(Equal("eth1"))
+				_, name = fakeDeviceNameGenerator.GenerateForHostIFBArgsForCall(0)
+				Expect(name).To(Equal("eth1"))
+			})
+
+			It("only routes the network of the attachment", func() {
+				conf, err := configCreator.CreateForAttachment(hostNS, addCmdArgs, attachment, ipamResult, 1400)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(conf.Container.Routes).To(Equal([]*types.Route{{
+					Dst: net.IPNet{
+						IP:   net.IP{10, 250, 0, 0},
+						Mask: net.CIDRMask(16, 32),
+					},
+					GW: net.IP{169, 254, 0, 1},
+				}}))
+			})
+
+			Context("when the network of the attachment is invalid", func() {
+				BeforeEach(func() {
+					attachment.Network = "banana"
+				})
+				It("returns an error", func() {
+					_, err := configCreator.CreateForAttachment(hostNS, addCmdArgs, attachment, ipamResult, 1400)
+					Expect(err).To(MatchError("invalid network: invalid CIDR address: banana"))
+				})
+			})
+
+			Context("when the interface name of the attachment is longer than 15 characters", func() {
+				BeforeEach(func() {
+					attachment.IfName = "some-very-long-name"
+				})
+				It("returns an error", func() {
+					_, err := configCreator.CreateForAttachment(hostNS, addCmdArgs, attachment, ipamResult, 1400)
+					Expect(err).To(MatchError("IfName cannot be longer than 15 characters"))
+				})
+			})
 		})
 
 		Context("when the args interface name is blank", func() {
@@ -162,6 +242,16 @@ var _ = Describe("ConfigCreator", func() {
 			})
 		})
 
+		Context("when the device name generator fails for the host ifb device", func() {
+			BeforeEach(func() {
+				fakeDeviceNameGenerator.GenerateForHostIFBReturns("", errors.New("potato"))
+			})
+			It("wraps and returns the error", func() {
+				_, err := configCreator.Create(hostNS, addCmdArgs, ipamResult, 1450)
+				Expect(err).To(MatchError("generating host ifb device name: potato"))
+			})
+		})
+
 		Context("when the device name generator fails for the container's temporary name", func() {
 			BeforeEach(func() {
 				fakeDeviceNameGenerator.GenerateTemporaryForContainerReturns("", errors.New("potato"))
@@ -42,6 +42,96 @@ var _ = Describe("Config", func() {
 		cfg.Container.Namespace.Close()
 	})
 
+	Describe("AddRoutes", func() {
+		It("adds the routes via the host end of the veth pair", func() {
+			Expect(cfg.AddRoutes([]config.Route{
+				{Dst: "10.0.0.0/8"},
+				{Dst: "192.168.1.7/32", IfName: "container-device-name"},
+			})).To(Succeed())
+
+			Expect(cfg.Container.Routes).To(HaveLen(3))
+			Expect(cfg.Container.Routes[1].Dst.String()).To(Equal("10.0.0.0/8"))
+			Expect(cfg.Container.Routes[1].GW.String()).To(Equal("169.254.0.1"))
+			Expect(cfg.Container.Routes[2].Dst.String()).To(Equal("192.168.1.7/32"))
+			Expect(cfg.Container.Routes[2].GW.String()).To(Equal("169.254.0.1"))
+		})
+
+		It("adds the routes via their gateway when they have one", func() {
+			Expect(cfg.AddRoutes([]config.Route{
+				{Dst: "10.0.0.0/8", GW: "10.255.30.9"},
+			})).To(Succeed())
+
+			Expect(cfg.Container.Routes).To(HaveLen(2))
+			Expect(cfg.Container.Routes[1].Dst.String()).To(Equal("10.0.0.0/8"))
+			Expect(cfg.Container.Routes[1].GW).To(Equal(net.IP{10, 255, 30, 9}))
+		})
+
+		Context("when a destination is invalid", func() {
+			It("returns an error", func() {
+				err := cfg.AddRoutes([]config.Route{{Dst: "banana"}})
+				Expect(err).To(MatchError("invalid route destination: invalid CIDR address: banana"))
+			})
+		})
+
+		Context("when a gateway is invalid", func() {
+			It("returns an error", func() {
+				err := cfg.AddRoutes([]config.Route{{Dst: "10.0.0.0/8", GW: "banana"}})
+				Expect(err).To(MatchError(`gateway "banana" is not an ipv4 address`))
+			})
+		})
+	})
+
+	Describe("GatewayRoutes", func() {
+		It("returns the routes via another gateway than the host end", func() {
+			cfg.Container.Routes = nil
+			Expect(cfg.AddRoutes([]config.Route{
+				{Dst: "10.0.0.0/8"},
+				{Dst: "172.16.6.0/24", GW: "10.0.16.1"},
+			})).To(Succeed())
+
+			routes := cfg.GatewayRoutes()
+			Expect(routes).To(HaveLen(1))
+			Expect(routes[0].Dst.String()).To(Equal("172.16.6.0/24"))
+			Expect(routes[0].GW).To(Equal(net.IP{10, 0, 16, 1}))
+		})
+	})
+
+	Describe("RouteTable", func() {
+		It("numbers the table by the low bits of the primary IP", func() {
+			Expect(config.RouteTable(net.IP{10, 255, 30, 5})).To(Equal(0x11e05))
+			Expect(config.RouteTable(net.ParseIP("10.255.30.5"))).To(Equal(0x11e05))
+			Expect(config.RouteTable(net.ParseIP("10.254.0.0"))).To(Equal(config.RouteTableBase))
+			Expect(config.RouteTable(net.ParseIP("10.254.255.255"))).To(Equal(config.RouteTableBase + config.RouteTableSize - 1))
+		})
+	})
+
+	Describe("ValidateRouteTables", func() {
+		var routes []config.Route
+		BeforeEach(func() {
+			routes = []config.Route{{Dst: "172.16.6.0/24", GW: "10.0.16.1"}}
+		})
+
+		It("accepts a cell subnet with at most one address per table", func() {
+			Expect(config.ValidateRouteTables(routes, "10.255.30.0/24")).To(Succeed())
+			Expect(config.ValidateRouteTables(routes, "10.255.0.0/16")).To(Succeed())
+		})
+
+		It("rejects a larger cell subnet", func() {
+			err := config.ValidateRouteTables(routes, "10.254.0.0/15")
+			Expect(err).To(MatchError("routes with a gateway need a cell subnet of at most 65536 addresses, 10.254.0.0/15 is larger"))
+		})
+
+		It("accepts any cell subnet when no route has a gateway", func() {
+			routes[0].GW = ""
+			Expect(config.ValidateRouteTables(routes, "10.254.0.0/15")).To(Succeed())
+		})
+
+		It("rejects an invalid cell subnet", func() {
+			err := config.ValidateRouteTables(routes, "banana")
+			Expect(err).To(MatchError("invalid cell subnet: invalid CIDR address: banana"))
+		})
+	})
+
 	Describe("AsCNIResult", func() {
 		It("returns a CNI v0.3.0 result that represents the config", func() {
 			result := cfg.AsCNIResult()
@@ -66,4 +156,53 @@ var _ = Describe("Config", func() {
 			Expect(result.Routes).To(ConsistOf(cfg.Container.Routes))
 		})
 	})
+
+	Describe("CNIResult", func() {
+		var attachmentCfg *config.Config
+
+		BeforeEach(func() {
+			attachmentCfg = &config.Config{}
+			attachmentCfg.Container.DeviceName = "eth1"
+			attachmentCfg.Container.Namespace = cfg.Container.Namespace
+			attachmentCfg.Container.Address.IP = net.IP{10, 250, 7, 2}
+			attachmentCfg.Container.Address.Hardware = net.HardwareAddr{0xee, 0xee, 0x0a, 0xfa, 0x07, 0x02}
+			attachmentCfg.Host.DeviceName = "s-0afa070298b7"
+			attachmentCfg.Host.Address.IP = net.IP{169, 254, 0, 1}
+			attachmentCfg.Host.Address.Hardware = net.HardwareAddr{0xaa, 0xaa, 0x0a, 0xfa, 0x07, 0x02}
+			attachmentCfg.Container.Routes = []*types.Route{{
+				Dst: net.IPNet{
+					IP:   net.IP{10, 250, 0, 0},
+					Mask: net.CIDRMask(16, 32),
+				},
+				GW: net.IP{169, 254, 0, 1},
+			}}
+		})
+
+		It("reports the interfaces, IPs and routes of every config in order", func() {
+			result := config.CNIResult(cfg, attachmentCfg)
+
+			Expect(result.Interfaces).To(HaveLen(4))
+			Expect(result.Interfaces[0].Name).To(Equal("host-device-name"))
+			Expect(result.Interfaces[1].Name).To(Equal("container-device-name"))
+			Expect(result.Interfaces[2]).To(Equal(&current.Interface{
+				Name:    "s-0afa070298b7",
+				Mac:     "aa:aa:0a:fa:07:02",
+				Sandbox: "",
+			}))
+			Expect(result.Interfaces[3]).To(Equal(&current.Interface{
+				Name:    "eth1",
+				Mac:     "ee:ee:0a:fa:07:02",
+				Sandbox: "/some/namespace",
+			}))
+
+			Expect(result.IPs).To(HaveLen(2))
+			Expect(*result.IPs[0].Interface).To(Equal(1))
+			Expect(result.IPs[0].Address.String()).To(Equal("10.255.30.5/32"))
+			Expect(*result.IPs[1].Interface).To(Equal(3))
+			Expect(result.IPs[1].Address.String()).To(Equal("10.250.7.2/32"))
+			Expect(result.IPs[1].Gateway.String()).To(Equal("169.254.0.1"))
+
+			Expect(result.Routes).To(Equal(append(cfg.Container.Routes, attachmentCfg.Container.Routes...)))
+		})
+	})
 })
@@ -3,27 +3,42 @@ package config
 import (
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"net"
 )
 
 type DeviceNameGenerator struct{}
 
-func (g *DeviceNameGenerator) generate(prefix string, containerIP net.IP) (string, error) {
+// generate names a device after the container IP, like s-010255030005.
+// The devices of an attachment are named after the container IP in hex and a
+// hash of the attachment, like s-0aff1e05a41c, since the decimal IP leaves no
+// room for the attachment in the 15 characters of a device name. The overlay
+// networks of a container never overlap, so the IP alone keeps the names
+// unique and the hash tells which attachment a device belongs to.
+func (g *DeviceNameGenerator) generate(prefix string, containerIP net.IP, attachment string) (string, error) {
 	i := containerIP.To4()
 	if i == nil {
 		return "", errors.New("generating device name: expecting valid IPv4 address")
 	}
-	return fmt.Sprintf("%s-%03d%03d%03d%03d", prefix, i[0], i[1], i[2], i[3]), nil
+	if attachment == "" {
+		return fmt.Sprintf("%s-%03d%03d%03d%03d", prefix, i[0], i[1], i[2], i[3]), nil
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(attachment))
+	return fmt.Sprintf("%s-%02x%02x%02x%02x%04x", prefix, i[0], i[1], i[2], i[3], h.Sum32()&0xffff), nil
 }
 
-func (g *DeviceNameGenerator) GenerateForHost(containerIP net.IP) (string, error) {
-	return g.generate("s", containerIP)
+// GenerateForHost names the host side of the veth pair. The attachment is
+// empty for the primary interface of the container.
+func (g *DeviceNameGenerator) GenerateForHost(containerIP net.IP, attachment string) (string, error) {
+	return g.generate("s", containerIP, attachment)
 }
 
-func (g *DeviceNameGenerator) GenerateTemporaryForContainer(containerIP net.IP) (string, error) {
-	return g.generate("c", containerIP)
+func (g *DeviceNameGenerator) GenerateTemporaryForContainer(containerIP net.IP, attachment string) (string, error) {
+	return g.generate("c", containerIP, attachment)
 }
 
-func (g *DeviceNameGenerator) GenerateForHostIFB(containerIP net.IP) (string, error) {
-	return g.generate("i", containerIP)
+func (g *DeviceNameGenerator) GenerateForHostIFB(containerIP net.IP, attachment string) (string, error) {
+	return g.generate("i", containerIP, attachment)
 }
@@ -12,15 +12,28 @@ var _ = Describe("DeviceNameGenerator", func() {
 	Describe("GenerateForHost", func() {
 		It("generates a valid Linux network device name from the given IPv4 address", func() {
 			g := config.DeviceNameGenerator{}
-			name, err := g.GenerateForHost(net.IP{10, 255, 30, 5})
+			name, err := g.GenerateForHost(net.IP{10, 255, 30, 5}, "")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(name).To(Equal("s-010255030005"))
 		})
 
+		Context("when given an attachment", func() {
+			It("generates a distinct device name for each attachment", func() {
+				g := config.DeviceNameGenerator{}
+				name, err := g.GenerateForHost(net.IP{10, 255, 30, 5}, "eth1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(name).To(Equal("s-0aff1e0598b7"))
+
+				name, err = g.GenerateForHost(net.IP{10, 255, 30, 5}, "eth2")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(name).To(Equal("s-0aff1e059a4a"))
+			})
+		})
+
 		Context("when given an IPv6 address", func() {
 			It("returns a meaningful error", func() {
 				g := config.DeviceNameGenerator{}
-				_, err := g.GenerateForHost(net.IPv6linklocalallnodes)
+				_, err := g.GenerateForHost(net.IPv6linklocalallnodes, "")
 				Expect(err).To(MatchError("generating device name: expecting valid IPv4 address"))
 			})
 		})
@@ -29,15 +42,22 @@ var _ = Describe("DeviceNameGenerator", func() {
 	Describe("GenerateTemporaryForContainer", func() {
 		It("generates a device name that is distinct from the host device name", func() {
 			g := config.DeviceNameGenerator{}
-			name, err := g.GenerateTemporaryForContainer(net.IP{10, 255, 30, 5})
+			name, err := g.GenerateTemporaryForContainer(net.IP{10, 255, 30, 5}, "")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(name).To(Equal("c-010255030005"))
 		})
 
+		It("generates a device name for an attachment that is distinct from the host device name", func() {
+			g := config.DeviceNameGenerator{}
+			name, err := g.GenerateTemporaryForContainer(net.IP{10, 255, 30, 5}, "eth1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(name).To(Equal("c-0aff1e0598b7"))
+		})
+
 		Context("when given an IPv6 address", func() {
 			It("returns a meaningful error", func() {
 				g := config.DeviceNameGenerator{}
-				_, err := g.GenerateTemporaryForContainer(net.IPv6linklocalallnodes)
+				_, err := g.GenerateTemporaryForContainer(net.IPv6linklocalallnodes, "")
 				Expect(err).To(MatchError("generating device name: expecting valid IPv4 address"))
 			})
 		})
@@ -46,15 +66,22 @@ var _ = Describe("DeviceNameGenerator", func() {
 	Describe("GenerateForHostIFB", func() {
 		It("generates a device name that is distinct from the host device name", func() {
 			g := config.DeviceNameGenerator{}
-			name, err := g.GenerateForHostIFB(net.IP{10, 255, 30, 5})
+			name, err := g.GenerateForHostIFB(net.IP{10, 255, 30, 5}, "")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(name).To(Equal("i-010255030005"))
 		})
 
+		It("generates a device name for an attachment that is distinct from the host device name", func() {
+			g := config.DeviceNameGenerator{}
+			name, err := g.GenerateForHostIFB(net.IP{10, 255, 30, 5}, "eth1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(name).To(Equal("i-0aff1e0598b7"))
+		})
+
 		Context("when given an IPv6 address", func() {
 			It("returns a meaningful error", func() {
 				g := config.DeviceNameGenerator{}
-				_, err := g.GenerateForHostIFB(net.IPv6linklocalallnodes)
+				_, err := g.GenerateForHostIFB(net.IPv6linklocalallnodes, "")
 				Expect(err).To(MatchError("generating device name: expecting valid IPv4 address"))
 			})
 		})
@@ -0,0 +1,38 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/containernetworking/cni/pkg/types"
+)
+
+// ValidateDNS returns an error when the DNS settings cannot be written to
+// the resolv.conf of a container by the runtime.
+func ValidateDNS(dns types.DNS) error {
+	for _, nameserver := range dns.Nameservers {
+		if net.ParseIP(nameserver) == nil {
+			return fmt.Errorf("invalid nameserver %q", nameserver)
+		}
+	}
+	if dns.Domain != "" && !isResolvConfWord(dns.Domain) {
+		return fmt.Errorf("invalid domain %q", dns.Domain)
+	}
+	for _, search := range dns.Search {
+		if !isResolvConfWord(search) {
+			return fmt.Errorf("invalid search domain %q", search)
+		}
+	}
+	for _, option := range dns.Options {
+		if !isResolvConfWord(option) {
+			return fmt.Errorf("invalid option %q", option)
+		}
+	}
+	return nil
+}
+
+// isResolvConfWord is true when s is a single word of a line in resolv.conf
+func isResolvConfWord(s string) bool {
+	return s != "" && !strings.ContainsAny(s, " \t\r\n#;")
+}
@@ -0,0 +1,57 @@
+package config_test
+
+import (
+	"code.cloudfoundry.org/silk/cni/config"
+	"github.com/containernetworking/cni/pkg/types"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ValidateDNS", func() {
+	var dns types.DNS
+
+	BeforeEach(func() {
+		dns = types.DNS{
+			Nameservers: []string{"169.254.0.2", "fd00::53"},
+			Domain:      "example.com",
+			Search:      []string{"apps.internal", "example.com"},
+			Options:     []string{"ndots:2", "rotate"},
+		}
+	})
+
+	It("accepts nameservers, search domains and options", func() {
+		Expect(config.ValidateDNS(dns)).To(Succeed())
+	})
+
+	It("accepts empty settings", func() {
+		Expect(config.ValidateDNS(types.DNS{})).To(Succeed())
+	})
+
+	Context("when a nameserver is not an IP", func() {
+		It("returns an error", func() {
+			dns.Nameservers[1] = "dns.example.com"
+			Expect(config.ValidateDNS(dns)).To(MatchError(`invalid nameserver "dns.example.com"`))
+		})
+	})
+
+	Context("when the domain is more than one word", func() {
+		It("returns an error", func() {
+			dns.Domain = "example.com apps.internal"
+			Expect(config.ValidateDNS(dns)).To(MatchError(`invalid domain "example.com apps.internal"`))
+		})
+	})
+
+	Context("when a search domain is empty", func() {
+		It("returns an error", func() {
+			dns.Search[0] = ""
+			Expect(config.ValidateDNS(dns)).To(MatchError(`invalid search domain ""`))
+		})
+	})
+
+	Context("when an option would start a comment", func() {
+		It("returns an error", func() {
+			dns.Options[0] = "#ndots:2"
+			Expect(config.ValidateDNS(dns)).To(MatchError(`invalid option "#ndots:2"`))
+		})
+	})
+})
@@ -7,10 +7,11 @@ import (
 )
 
 type DeviceNameGenerator struct {
-	GenerateForHostStub        func(net.IP) (string, error)
+	GenerateForHostStub        func(net.IP, string) (string, error)
 	generateForHostMutex       sync.RWMutex
 	generateForHostArgsForCall []struct {
 		arg1 net.IP
+		arg2 string
 	}
 	generateForHostReturns struct {
 		result1 string
@@ -20,10 +21,25 @@ type DeviceNameGenerator struct {
 		result1 string
 		result2 error
 	}
-	GenerateTemporaryForContainerStub        func(net.IP) (string, error)
+	GenerateForHostIFBStub        func(net.IP, string) (string, error)
+	generateForHostIFBMutex       sync.RWMutex
+	generateForHostIFBArgsForCall []struct {
+		arg1 net.IP
+		arg2 string
+	}
+	generateForHostIFBReturns struct {
+		result1 string
+		result2 error
+	}
+	generateForHostIFBReturnsOnCall map[int]struct {
+		result1 string
+		result2 error
+	}
+	GenerateTemporaryForContainerStub        func(net.IP, string) (string, error)
 	generateTemporaryForContainerMutex       sync.RWMutex
 	generateTemporaryForContainerArgsForCall []struct {
 		arg1 net.IP
+		arg2 string
 	}
 	generateTemporaryForContainerReturns struct {
 		result1 string
@@ -37,18 +53,19 @@ type DeviceNameGenerator struct {
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *DeviceNameGenerator) GenerateForHost(arg1 net.IP) (string, error) {
+func (fake *DeviceNameGenerator) GenerateForHost(arg1 net.IP, arg2 string) (string, error) {
 	fake.generateForHostMutex.Lock()
 	ret, specificReturn := fake.generateForHostReturnsOnCall[len(fake.generateForHostArgsForCall)]
 	fake.generateForHostArgsForCall = append(fake.generateForHostArgsForCall, struct {
 		arg1 net.IP
-	}{arg1})
+		arg2 string
+	}{arg1, arg2})
 	stub := fake.GenerateForHostStub
 	fakeReturns := fake.generateForHostReturns
-	fake.recordInvocation("GenerateForHost", []interface{}{arg1})
+	fake.recordInvocation("GenerateForHost", []interface{}{arg1, arg2})
 	fake.generateForHostMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -62,17 +79,17 @@ func (fake *DeviceNameGenerator) GenerateForHostCallCount() int {
 	return len(fake.generateForHostArgsForCall)
 }
 
-func (fake *DeviceNameGenerator) GenerateForHostCalls(stub func(net.IP) (string, error)) {
+func (fake *DeviceNameGenerator) GenerateForHostCalls(stub func(net.IP, string) (string, error)) {
 	fake.generateForHostMutex.Lock()
 	defer fake.generateForHostMutex.Unlock()
 	fake.GenerateForHostStub = stub
 }
 
-func (fake *DeviceNameGenerator) GenerateForHostArgsForCall(i int) net.IP {
+func (fake *DeviceNameGenerator) GenerateForHostArgsForCall(i int) (net.IP, string) {
 	fake.generateForHostMutex.RLock()
 	defer fake.generateForHostMutex.RUnlock()
 	argsForCall := fake.generateForHostArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *DeviceNameGenerator) GenerateForHostReturns(result1 string, result2 error) {
@@ -101,18 +118,84 @@ func (fake *DeviceNameGenerator) GenerateForHostReturnsOnCall(i int, result1 str
 	}{result1, result2}
 }
 
-func (fake *DeviceNameGenerator) GenerateTemporaryForContainer(arg1 net.IP) (string, error) {
+func (fake *DeviceNameGenerator) GenerateForHostIFB(arg1 net.IP, arg2 string) (string, error) {
+	fake.generateForHostIFBMutex.Lock()
+	ret, specificReturn := fake.generateForHostIFBReturnsOnCall[len(fake.generateForHostIFBArgsForCall)]
+	fake.generateForHostIFBArgsForCall = append(fake.generateForHostIFBArgsForCall, struct {
+		arg1 net.IP
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.GenerateForHostIFBStub
+	fakeReturns := fake.generateForHostIFBReturns
+	fake.recordInvocation("GenerateForHostIFB", []interface{}{arg1, arg2})
+	fake.generateForHostIFBMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *DeviceNameGenerator) GenerateForHostIFBCallCount() int {
+	fake.generateForHostIFBMutex.RLock()
+	defer fake.generateForHostIFBMutex.RUnlock()
+	return len(fake.generateForHostIFBArgsForCall)
+}
+
+func (fake *DeviceNameGenerator) GenerateForHostIFBCalls(stub func(net.IP, string) (string, error)) {
+	fake.generateForHostIFBMutex.Lock()
+	defer fake.generateForHostIFBMutex.Unlock()
+	fake.GenerateForHostIFBStub = stub
+}
+
+func (fake *DeviceNameGenerator) GenerateForHostIFBArgsForCall(i int) (net.IP, string) {
+	fake.generateForHostIFBMutex.RLock()
+	defer fake.generateForHostIFBMutex.RUnlock()
+	argsForCall := fake.generateForHostIFBArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *DeviceNameGenerator) GenerateForHostIFBReturns(result1 string, result2 error) {
+	fake.generateForHostIFBMutex.Lock()
+	defer fake.generateForHostIFBMutex.Unlock()
+	fake.GenerateForHostIFBStub = nil
+	fake.generateForHostIFBReturns = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DeviceNameGenerator) GenerateForHostIFBReturnsOnCall(i int, result1 string, result2 error) {
+	fake.generateForHostIFBMutex.Lock()
+	defer fake.generateForHostIFBMutex.Unlock()
+	fake.GenerateForHostIFBStub = nil
+	if fake.generateForHostIFBReturnsOnCall == nil {
+		fake.generateForHostIFBReturnsOnCall = make(map[int]struct {
+			result1 string
+			result2 error
+		})
+	}
+	fake.generateForHostIFBReturnsOnCall[i] = struct {
+		result1 string
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DeviceNameGenerator) GenerateTemporaryForContainer(arg1 net.IP, arg2 string) (string, error) {
 	fake.generateTemporaryForContainerMutex.Lock()
 	ret, specificReturn := fake.generateTemporaryForContainerReturnsOnCall[len(fake.generateTemporaryForContainerArgsForCall)]
 	fake.generateTemporaryForContainerArgsForCall = append(fake.generateTemporaryForContainerArgsForCall, struct {
 		arg1 net.IP
-	}{arg1})
+		arg2 string
+	}{arg1, arg2})
 	stub := fake.GenerateTemporaryForContainerStub
 	fakeReturns := fake.generateTemporaryForContainerReturns
-	fake.recordInvocation("GenerateTemporaryForContainer", []interface{}{arg1})
+	fake.recordInvocation("GenerateTemporaryForContainer", []interface{}{arg1, arg2})
 	fake.generateTemporaryForContainerMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1, ret.result2
@@ -126,17 +209,17 @@ func (fake *DeviceNameGenerator) GenerateTemporaryForContainerCallCount() int {
 	return len(fake.generateTemporaryForContainerArgsForCall)
 }
 
-func (fake *DeviceNameGenerator) GenerateTemporaryForContainerCalls(stub func(net.IP) (string, error)) {
+func (fake *DeviceNameGenerator) GenerateTemporaryForContainerCalls(stub func(net.IP, string) (string, error)) {
 	fake.generateTemporaryForContainerMutex.Lock()
 	defer fake.generateTemporaryForContainerMutex.Unlock()
 	fake.GenerateTemporaryForContainerStub = stub
 }
 
-func (fake *DeviceNameGenerator) GenerateTemporaryForContainerArgsForCall(i int) net.IP {
+func (fake *DeviceNameGenerator) GenerateTemporaryForContainerArgsForCall(i int) (net.IP, string) {
 	fake.generateTemporaryForContainerMutex.RLock()
 	defer fake.generateTemporaryForContainerMutex.RUnlock()
 	argsForCall := fake.generateTemporaryForContainerArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *DeviceNameGenerator) GenerateTemporaryForContainerReturns(result1 string, result2 error) {
@@ -170,6 +253,8 @@ func (fake *DeviceNameGenerator) Invocations() map[string][][]interface{} {
 	defer fake.invocationsMutex.RUnlock()
 	fake.generateForHostMutex.RLock()
 	defer fake.generateForHostMutex.RUnlock()
+	fake.generateForHostIFBMutex.RLock()
+	defer fake.generateForHostIFBMutex.RUnlock()
 	fake.generateTemporaryForContainerMutex.RLock()
 	defer fake.generateTemporaryForContainerMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
@@ -3,57 +3,61 @@ package config
 import (
 	"fmt"
 	"net"
-	"path/filepath"
+	"strings"
 
 	"github.com/containernetworking/cni/pkg/types"
 )
 
-type RangeSet []Range
-
-type Range struct {
-	RangeStart net.IP      `json:"rangeStart,omitempty"` // The first ip, inclusive
-	RangeEnd   net.IP      `json:"rangeEnd,omitempty"`   // The last ip, inclusive
-	Subnet     types.IPNet `json:"subnet"`
-	Gateway    net.IP      `json:"gateway,omitempty"`
-}
-
 type IPAMConfig struct {
-	*Range
-	Name       string
-	Type       string         `json:"type"`
-	Routes     []*types.Route `json:"routes"`
-	DataDir    string         `json:"dataDir"`
-	ResolvConf string         `json:"resolvConf"`
-	Ranges     []RangeSet     `json:"ranges"`
-	IPArgs     []net.IP       `json:"-"` // Requested IPs from CNI_ARGS and args
+	Name   string
+	Subnet *net.IPNet
+	IPArgs []net.IP // Requested IPs from CNI_ARGS and args
 }
 
-type HostLocalIPAM struct {
-	CNIVersion string     `json:"cniVersion"`
-	Name       string     `json:"name"`
-	IPAM       IPAMConfig `json:"ipam"`
+// ipArgs is the IP key of CNI_ARGS, like "IP=10.255.30.9"
+type ipArgs struct {
+	types.CommonArgs
+	IP net.IP `json:"ip,omitempty"`
 }
 
 type IPAMConfigGenerator struct{}
 
-func (IPAMConfigGenerator) GenerateConfig(subnet, network, dataDirPath string) (*HostLocalIPAM, error) {
-	subnetAsIPNet, err := types.ParseCIDR(subnet)
+// GenerateConfig returns the config to allocate an IP for a container in
+// the given subnet. A specific IP can be requested through the IP key of
+// cniArgs or through argsIPs, which are the "args.cni.ips" of the network
+// config.
+func (IPAMConfigGenerator) GenerateConfig(subnet, network, cniArgs string, argsIPs []string) (*IPAMConfig, error) {
+	_, subnetAsIPNet, err := net.ParseCIDR(subnet)
 	if err != nil {
 		return nil, fmt.Errorf("invalid subnet: %s", err)
 	}
 
-	return &HostLocalIPAM{
-		CNIVersion: "1.0.0",
-		Name:       network,
-		IPAM: IPAMConfig{
-			Type: "host-local",
-			Ranges: []RangeSet{
-				[]Range{{
-					Subnet: types.IPNet(*subnetAsIPNet),
-				}},
-			},
-			Routes:  []*types.Route{},
-			DataDir: filepath.Join(dataDirPath, "ipam"),
-		},
-	}, nil
+	conf := &IPAMConfig{
+		Name:   network,
+		Subnet: subnetAsIPNet,
+	}
+
+	args := ipArgs{}
+	args.IgnoreUnknown = true
+	if err := types.LoadArgs(cniArgs, &args); err != nil {
+		return nil, fmt.Errorf("invalid CNI_ARGS: %s", err)
+	}
+	if args.IP != nil {
+		conf.IPArgs = append(conf.IPArgs, args.IP)
+	}
+
+	for _, argsIP := range argsIPs {
+		// the conventions allow the IP with or without a prefix length
+		ip := net.ParseIP(strings.Split(argsIP, "/")[0])
+		if ip == nil {
+			return nil, fmt.Errorf("invalid ip in args: %q", argsIP)
+		}
+		conf.IPArgs = append(conf.IPArgs, ip)
+	}
+
+	if len(conf.IPArgs) > 1 {
+		return nil, fmt.Errorf("only one ip can be requested, got %d", len(conf.IPArgs))
+	}
+
+	return conf, nil
 }
@@ -1,43 +1,69 @@
 package config_test
 
 import (
+	"net"
+
 	"code.cloudfoundry.org/silk/cni/config"
-	"github.com/containernetworking/cni/pkg/types"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
 
 var _ = Describe("Ipam config generation", func() {
-	It("returns IPAM config object", func() {
+	var generator config.IPAMConfigGenerator
 
-		generator := config.IPAMConfigGenerator{}
-		ipamConfig, err := generator.GenerateConfig("10.255.30.0/24", "some-network-name", "/some/data/dir")
+	It("returns IPAM config object", func() {
+		ipamConfig, err := generator.GenerateConfig("10.255.30.0/24", "some-network-name", "", nil)
 		Expect(err).NotTo(HaveOccurred())
 
-		subnetAsIPNet, err := types.ParseCIDR("10.255.30.0/24")
-		Expect(err).NotTo(HaveOccurred())
+		Expect(ipamConfig).To(Equal(&config.IPAMConfig{
+			Name: "some-network-name",
+			Subnet: &net.IPNet{
+				IP:   net.IP{10, 255, 30, 0},
+				Mask: net.CIDRMask(24, 32),
+			},
+		}))
+	})
+
+	Context("when an IP is requested through CNI_ARGS", func() {
+		It("sets the IPArgs", func() {
+			ipamConfig, err := generator.GenerateConfig("10.255.30.0/24", "some-network-name", "K8S_POD_NAME=foo;IP=10.255.30.9", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ipamConfig.IPArgs).To(Equal([]net.IP{net.ParseIP("10.255.30.9")}))
+		})
+
+		Context("when the IP is invalid", func() {
+			It("returns an error", func() {
+				_, err := generator.GenerateConfig("10.255.30.0/24", "some-network-name", "IP=banana", nil)
+				Expect(err).To(MatchError(HavePrefix("invalid CNI_ARGS: ")))
+			})
+		})
+	})
+
+	Context("when an IP is requested through the args of the network config", func() {
+		It("sets the IPArgs", func() {
+			ipamConfig, err := generator.GenerateConfig("10.255.30.0/24", "some-network-name", "", []string{"10.255.30.9/24"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ipamConfig.IPArgs).To(Equal([]net.IP{net.ParseIP("10.255.30.9")}))
+		})
 
-		Expect(ipamConfig).To(Equal(
-			&config.HostLocalIPAM{
-				CNIVersion: "1.0.0",
-				Name:       "some-network-name",
-				IPAM: config.IPAMConfig{
-					Type: "host-local",
-					Ranges: []config.RangeSet{
-						[]config.Range{
-							{
-								Subnet: types.IPNet(*subnetAsIPNet),
-							},
-						}},
-					Routes:  []*types.Route{},
-					DataDir: "/some/data/dir/ipam",
-				},
-			}))
+		Context("when the IP is invalid", func() {
+			It("returns an error", func() {
+				_, err := generator.GenerateConfig("10.255.30.0/24", "some-network-name", "", []string{"banana"})
+				Expect(err).To(MatchError(`invalid ip in args: "banana"`))
+			})
+		})
 	})
+
+	Context("when more than one IP is requested", func() {
+		It("returns an error", func() {
+			_, err := generator.GenerateConfig("10.255.30.0/24", "some-network-name", "IP=10.255.30.8", []string{"10.255.30.9"})
+			Expect(err).To(MatchError("only one ip can be requested, got 2"))
+		})
+	})
+
 	Context("when the subnet is invalid", func() {
 		It("returns an error", func() {
-			generator := config.IPAMConfigGenerator{}
-			_, err := generator.GenerateConfig("10.255.30.0/33", "some-network-name", "/some/data/dir")
+			_, err := generator.GenerateConfig("10.255.30.0/33", "some-network-name", "", nil)
 			Expect(err).To(MatchError("invalid subnet: invalid CIDR address: 10.255.30.0/33"))
 		})
 	})
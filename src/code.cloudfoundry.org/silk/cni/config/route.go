@@ -0,0 +1,103 @@
+package config
+
+import (
+	"fmt"
+	"net"
+)
+
+// Route sends the traffic to a destination through one of the silk
+// interfaces of a container. The gateway of each silk interface is the host
+// end of its veth pair, so a route through an attachment is a policy route
+// that takes the destination out through the gateway of another overlay
+// network.
+type Route struct {
+	Dst string `json:"dst"`
+	// IfName is the interface that the route goes through, which is the
+	// primary interface when it is empty
+	IfName string `json:"ifName,omitempty"`
+	// GW is the gateway that the cell forwards the traffic of the container
+	// to the destination to, instead of routing it itself. It must be a
+	// neighbor of the cell, see RouteGateways. The route goes via the host
+	// end of the veth pair when it is empty.
+	GW string `json:"gw,omitempty"`
+}
+
+// ValidateRoutes returns an error when a route cannot be added next to the
+// routes that silk adds itself: the default route through the primary
+// interface and the network of each attachment through its interface.
+// Whether the gateways can be reached is only known on the cell, see
+// RouteGateways.
+func ValidateRoutes(routes []Route, primaryIfName string, attachments []Attachment) error {
+	ifNames := map[string]bool{primaryIfName: true}
+	routed := map[string]bool{}
+	for _, attachment := range attachments {
+		ifNames[attachment.IfName] = true
+		if network, err := attachment.network(); err == nil {
+			routed[network.String()] = true
+		}
+	}
+
+	for _, r := range routes {
+		dst, err := r.destination()
+		if err != nil {
+			return err
+		}
+		if r.IfName != "" && !ifNames[r.IfName] {
+			return fmt.Errorf("route to %s: no interface %s", dst, r.IfName)
+		}
+		if r.GW != "" {
+			if _, err := r.gateway(); err != nil {
+				return fmt.Errorf("route to %s: %s", dst, err)
+			}
+		}
+		if ones, _ := dst.Mask.Size(); ones == 0 {
+			return fmt.Errorf("route to %s: the default route always goes through the primary interface", dst)
+		}
+		if routed[dst.String()] {
+			return fmt.Errorf("route to %s: destination is already routed", dst)
+		}
+		routed[dst.String()] = true
+	}
+	return nil
+}
+
+// RouteGateways returns the gateways of the routes, once each. The cell
+// forwards the traffic to them itself, so each one must be a neighbor of the
+// cell: on a network that the cell is attached to or a container on the
+// cell. A container on another cell is not, since that cell would route the
+// traffic by its destination again.
+func RouteGateways(routes []Route) []net.IP {
+	var gateways []net.IP
+	seen := map[string]bool{}
+	for _, r := range routes {
+		if r.GW == "" {
+			continue
+		}
+		gw, err := r.gateway()
+		if err != nil || seen[gw.String()] {
+			continue
+		}
+		seen[gw.String()] = true
+		gateways = append(gateways, gw)
+	}
+	return gateways
+}
+
+func (r Route) gateway() (net.IP, error) {
+	gw := net.ParseIP(r.GW).To4()
+	if gw == nil {
+		return nil, fmt.Errorf("gateway %q is not an ipv4 address", r.GW)
+	}
+	return gw, nil
+}
+
+func (r Route) destination() (*net.IPNet, error) {
+	_, dst, err := net.ParseCIDR(r.Dst)
+	if err != nil {
+		return nil, fmt.Errorf("invalid route destination: %s", err)
+	}
+	if dst.IP.To4() == nil {
+		return nil, fmt.Errorf("route destination %s is not ipv4", dst)
+	}
+	return dst, nil
+}
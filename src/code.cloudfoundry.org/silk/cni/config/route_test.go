@@ -0,0 +1,100 @@
+package config_test
+
+import (
+	"net"
+
+	"code.cloudfoundry.org/silk/cni/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ValidateRoutes", func() {
+	var (
+		routes      []config.Route
+		attachments []config.Attachment
+	)
+
+	BeforeEach(func() {
+		routes = []config.Route{
+			{Dst: "10.0.0.0/8"},
+			{Dst: "192.168.0.0/16", IfName: "eth0"},
+			{Dst: "172.16.5.0/24", IfName: "eth1"},
+			{Dst: "172.16.6.0/24", IfName: "eth1", GW: "10.250.0.9"},
+			{Dst: "172.16.7.0/24", GW: "10.255.30.9"},
+		}
+		attachments = []config.Attachment{{
+			IfName:     "eth1",
+			Name:       "mesh",
+			Network:    "10.250.0.0/16",
+			DaemonPort: 8090,
+		}}
+	})
+
+	It("accepts routes through the primary interface and the attachments", func() {
+		Expect(config.ValidateRoutes(routes, "eth0", attachments)).To(Succeed())
+	})
+
+	Context("when a destination is invalid", func() {
+		It("returns an error", func() {
+			routes[1].Dst = "192.168.0.0/33"
+			Expect(config.ValidateRoutes(routes, "eth0", attachments)).To(MatchError("invalid route destination: invalid CIDR address: 192.168.0.0/33"))
+		})
+	})
+
+	Context("when a destination is not ipv4", func() {
+		It("returns an error", func() {
+			routes[1].Dst = "fd00::/64"
+			Expect(config.ValidateRoutes(routes, "eth0", attachments)).To(MatchError("route destination fd00::/64 is not ipv4"))
+		})
+	})
+
+	Context("when a route goes through an unknown interface", func() {
+		It("returns an error", func() {
+			routes[2].IfName = "eth2"
+			Expect(config.ValidateRoutes(routes, "eth0", attachments)).To(MatchError("route to 172.16.5.0/24: no interface eth2"))
+		})
+	})
+
+	Context("when a route is a default route", func() {
+		It("returns an error", func() {
+			routes[2].Dst = "0.0.0.0/0"
+			Expect(config.ValidateRoutes(routes, "eth0", attachments)).To(MatchError("route to 0.0.0.0/0: the default route always goes through the primary interface"))
+		})
+	})
+
+	Context("when a destination is routed twice", func() {
+		It("returns an error", func() {
+			routes[2].Dst = "10.1.2.3/8"
+			Expect(config.ValidateRoutes(routes, "eth0", attachments)).To(MatchError("route to 10.0.0.0/8: destination is already routed"))
+		})
+	})
+
+	Context("when a gateway is not an ipv4 address", func() {
+		It("returns an error", func() {
+			routes[3].GW = "fd00::1"
+			Expect(config.ValidateRoutes(routes, "eth0", attachments)).To(MatchError(`route to 172.16.6.0/24: gateway "fd00::1" is not an ipv4 address`))
+		})
+	})
+
+	Context("when a destination is the network of an attachment", func() {
+		It("returns an error", func() {
+			routes[0].Dst = "10.250.0.0/16"
+			Expect(config.ValidateRoutes(routes, "eth0", attachments)).To(MatchError("route to 10.250.0.0/16: destination is already routed"))
+		})
+	})
+})
+
+var _ = Describe("RouteGateways", func() {
+	It("returns each gateway once", func() {
+		Expect(config.RouteGateways([]config.Route{
+			{Dst: "10.0.0.0/8"},
+			{Dst: "172.16.6.0/24", IfName: "eth1", GW: "10.250.0.9"},
+			{Dst: "172.16.7.0/24", GW: "10.0.16.1"},
+			{Dst: "172.16.8.0/24", GW: "10.250.0.9"},
+		})).To(Equal([]net.IP{{10, 250, 0, 9}, {10, 0, 16, 1}}))
+	})
+
+	It("returns nothing when no route has a gateway", func() {
+		Expect(config.RouteGateways([]config.Route{{Dst: "10.0.0.0/8"}})).To(BeEmpty())
+	})
+})
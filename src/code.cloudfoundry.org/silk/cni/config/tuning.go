@@ -0,0 +1,100 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const maxTxQueueLen = 100000
+
+// Tuning is applied inside the network namespace of a container after its
+// primary interface is set up. Sysctls are limited to the ones in
+// allowedSysctls, which are all namespaced by the kernel, so they never
+// change the host.
+type Tuning struct {
+	Sysctls map[string]string `json:"sysctls"`
+	// TxQueueLen of the container device, which is left as it is when zero
+	TxQueueLen int `json:"txQueueLen"`
+	// GSO and GRO turn generic segmentation and receive offload of the
+	// container device on or off, which are left as they are when unset
+	GSO *bool `json:"gso"`
+	GRO *bool `json:"gro"`
+}
+
+var allowedSysctls = map[string]func(string) error{
+	"net.core.somaxconn":            intBetween(1, 65535),
+	"net.ipv4.tcp_keepalive_time":   intBetween(1, 32767),
+	"net.ipv4.tcp_keepalive_intvl":  intBetween(1, 32767),
+	"net.ipv4.tcp_keepalive_probes": intBetween(1, 127),
+	"net.ipv4.ip_local_port_range":  portRange,
+}
+
+func (t Tuning) IsSet() bool {
+	return len(t.Sysctls) > 0 || t.TxQueueLen != 0 || t.GSO != nil || t.GRO != nil
+}
+
+// Validate returns an error when a sysctl is not allowed or any setting is
+// out of its safe range.
+func (t Tuning) Validate() error {
+	for _, name := range t.SysctlNames() {
+		validate, ok := allowedSysctls[name]
+		if !ok {
+			return fmt.Errorf("sysctl %s is not allowed", name)
+		}
+		if err := validate(t.Sysctls[name]); err != nil {
+			return fmt.Errorf("invalid value %q of sysctl %s: %s", t.Sysctls[name], name, err)
+		}
+	}
+	if t.TxQueueLen < 0 || t.TxQueueLen > maxTxQueueLen {
+		return fmt.Errorf("txQueueLen %d must be between 1 and %d, or 0 to leave it as it is", t.TxQueueLen, maxTxQueueLen)
+	}
+	return nil
+}
+
+// SysctlNames returns the names of the sysctls in order, so that they are
+// applied the same way on every container.
+func (t Tuning) SysctlNames() []string {
+	names := make([]string, 0, len(t.Sysctls))
+	for name := range t.Sysctls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func intBetween(min, max int) func(string) error {
+	return func(value string) error {
+		i, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return errors.New("not an integer")
+		}
+		if i < min || i > max {
+			return fmt.Errorf("must be between %d and %d", min, max)
+		}
+		return nil
+	}
+}
+
+// portRange is the first and last local port, like "32768 60999". Ports
+// below 1024 are left to privileged services.
+func portRange(value string) error {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return errors.New("must be the first and last port")
+	}
+	first, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return errors.New("first port is not an integer")
+	}
+	last, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return errors.New("last port is not an integer")
+	}
+	if first < 1024 || last > 65535 || first >= last {
+		return errors.New("must be a range of ports between 1024 and 65535")
+	}
+	return nil
+}
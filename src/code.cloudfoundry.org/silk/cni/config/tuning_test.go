@@ -0,0 +1,115 @@
+package config_test
+
+import (
+	"code.cloudfoundry.org/silk/cni/config"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Tuning", func() {
+	var tuning config.Tuning
+
+	BeforeEach(func() {
+		off := false
+		tuning = config.Tuning{
+			Sysctls: map[string]string{
+				"net.core.somaxconn":            "4096",
+				"net.ipv4.tcp_keepalive_time":   "600",
+				"net.ipv4.tcp_keepalive_intvl":  "30",
+				"net.ipv4.tcp_keepalive_probes": "5",
+				"net.ipv4.ip_local_port_range":  "10000 60999",
+			},
+			TxQueueLen: 500,
+			GSO:        &off,
+		}
+	})
+
+	Describe("IsSet", func() {
+		It("is false for the zero value", func() {
+			Expect(config.Tuning{}.IsSet()).To(BeFalse())
+		})
+
+		It("is true when anything is tuned", func() {
+			Expect(tuning.IsSet()).To(BeTrue())
+			Expect(config.Tuning{GSO: tuning.GSO}.IsSet()).To(BeTrue())
+		})
+	})
+
+	Describe("SysctlNames", func() {
+		It("returns the names in order", func() {
+			Expect(tuning.SysctlNames()).To(Equal([]string{
+				"net.core.somaxconn",
+				"net.ipv4.ip_local_port_range",
+				"net.ipv4.tcp_keepalive_intvl",
+				"net.ipv4.tcp_keepalive_probes",
+				"net.ipv4.tcp_keepalive_time",
+			}))
+		})
+	})
+
+	Describe("Validate", func() {
+		It("accepts the allowed sysctls in their safe ranges", func() {
+			Expect(tuning.Validate()).To(Succeed())
+		})
+
+		It("accepts no tuning", func() {
+			Expect(config.Tuning{}.Validate()).To(Succeed())
+		})
+
+		Context("when a sysctl is not allowed", func() {
+			It("returns an error", func() {
+				tuning.Sysctls["net.ipv4.ip_forward"] = "1"
+				Expect(tuning.Validate()).To(MatchError("sysctl net.ipv4.ip_forward is not allowed"))
+			})
+		})
+
+		Context("when a value is not an integer", func() {
+			It("returns an error", func() {
+				tuning.Sysctls["net.core.somaxconn"] = "banana"
+				Expect(tuning.Validate()).To(MatchError(`invalid value "banana" of sysctl net.core.somaxconn: not an integer`))
+			})
+		})
+
+		Context("when a value is out of its range", func() {
+			It("returns an error", func() {
+				tuning.Sysctls["net.ipv4.tcp_keepalive_probes"] = "128"
+				Expect(tuning.Validate()).To(MatchError(`invalid value "128" of sysctl net.ipv4.tcp_keepalive_probes: must be between 1 and 127`))
+			})
+		})
+
+		Context("when the local port range includes privileged ports", func() {
+			It("returns an error", func() {
+				tuning.Sysctls["net.ipv4.ip_local_port_range"] = "80 60999"
+				Expect(tuning.Validate()).To(MatchError(`invalid value "80 60999" of sysctl net.ipv4.ip_local_port_range: must be a range of ports between 1024 and 65535`))
+			})
+		})
+
+		Context("when the local port range is a single port", func() {
+			It("returns an error", func() {
+				tuning.Sysctls["net.ipv4.ip_local_port_range"] = "10000"
+				Expect(tuning.Validate()).To(MatchError(`invalid value "10000" of sysctl net.ipv4.ip_local_port_range: must be the first and last port`))
+			})
+		})
+
+		Context("when the txqueuelen is too large", func() {
+			It("returns an error", func() {
+				tuning.TxQueueLen = 100001
+				Expect(tuning.Validate()).To(MatchError("txQueueLen 100001 must be between 1 and 100000, or 0 to leave it as it is"))
+			})
+		})
+
+		Context("when the txqueuelen is negative", func() {
+			It("returns an error", func() {
+				tuning.TxQueueLen = -1
+				Expect(tuning.Validate()).To(MatchError("txQueueLen -1 must be between 1 and 100000, or 0 to leave it as it is"))
+			})
+		})
+
+		Context("when the txqueuelen is 0", func() {
+			It("leaves it as it is", func() {
+				tuning.TxQueueLen = 0
+				Expect(tuning.Validate()).To(Succeed())
+			})
+		})
+	})
+})
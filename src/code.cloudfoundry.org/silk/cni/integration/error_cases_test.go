@@ -22,7 +22,7 @@ var _ = Describe("errors", func() {
 	Describe("errors on ADD", func() {
 		Context("when the subnet file is missing", func() {
 			BeforeEach(func() {
-				cniStdin = cniConfigWithSubnetEnv(dataDir, datastorePath, "/path/does/not/exist")
+				cniStdin = cniConfigWithSubnetEnv(datastorePath, "/path/does/not/exist")
 			})
 
 			It("exits with nonzero status and prints a CNI error result as JSON to stdout", func() {
@@ -40,7 +40,7 @@ var _ = Describe("errors", func() {
 		Context("when the subnet file is corrupt", func() {
 			BeforeEach(func() {
 				subnetEnvFile = writeSubnetEnvFile("bad-subnet", fullNetwork.String())
-				cniStdin = cniConfigWithSubnetEnv(dataDir, datastorePath, subnetEnvFile)
+				cniStdin = cniConfigWithSubnetEnv(datastorePath, subnetEnvFile)
 			})
 
 			It("exits with nonzero status and prints a CNI error result as JSON to stdout", func() {
@@ -63,9 +63,8 @@ var _ = Describe("errors", func() {
 				"name": "my-silk-network",
 				"type": "silk",
 				"mtu": -123,
-				"dataDir": "%s",
 				"daemonPort": %d,
-				"datastore": "%s"}`, dataDir, daemonPort, datastorePath)
+				"datastore": "%s"}`, daemonPort, datastorePath)
 			})
 			It("exits with nonzero status and prints a CNI error result as JSON to stdout", func() {
 				session := startCommandInHost("ADD", cniStdin)
@@ -85,7 +84,7 @@ var _ = Describe("errors", func() {
 					fakeServer.Interrupt()
 					Eventually(fakeServer, "5s").Should(gexec.Exit())
 				}
-				cniStdin = cniConfig(dataDir, datastorePath, daemonPort)
+				cniStdin = cniConfig(datastorePath, daemonPort)
 			})
 
 			It("exits with nonzero status and prints a CNI error result as JSON to stdout", func() {
@@ -106,7 +105,7 @@ var _ = Describe("errors", func() {
 		Context("when the daemon network info cannot be unmarshaled", func() {
 			BeforeEach(func() {
 				fakeServer = startFakeDaemonInHost(daemonPort, http.StatusOK, `bad response`)
-				cniStdin = cniConfig(dataDir, datastorePath, daemonPort)
+				cniStdin = cniConfig(datastorePath, daemonPort)
 			})
 
 			It("exits with nonzero status and prints a CNI error result as JSON to stdout", func() {
@@ -121,10 +120,10 @@ var _ = Describe("errors", func() {
 			})
 		})
 
-		Context("when the ipam plugin errors on add", func() {
+		Context("when the ipam config cannot be generated", func() {
 			BeforeEach(func() {
 				fakeServer = startFakeDaemonInHost(daemonPort, http.StatusOK, `{"overlay_subnet": "10.255.30.0/33", "mtu": 1350}`)
-				cniStdin = cniConfig(dataDir, datastorePath, daemonPort)
+				cniStdin = cniConfig(datastorePath, daemonPort)
 			})
 			It("exits with nonzero status and prints a CNI error result as JSON to stdout", func() {
 				session := startCommandInHost("ADD", cniStdin)
@@ -141,7 +140,7 @@ var _ = Describe("errors", func() {
 		Context("when the veth manager fails to create a veth pair", func() {
 			It("exits with nonzero status and prints a CNI error", func() {
 				cniEnv["CNI_IFNAME"] = "some-bad-eth-name"
-				cniStdin = cniConfig(dataDir, datastorePath, daemonPort)
+				cniStdin = cniConfig(datastorePath, daemonPort)
 				session := startCommandInHost("ADD", cniStdin)
 				Eventually(session, cmdTimeout).Should(gexec.Exit(1))
 
@@ -159,16 +158,15 @@ var _ = Describe("errors", func() {
 					"cniVersion": "1.0.0",
 					"name": "my-silk-network",
 					"type": "silk",
-					"dataDir": "%s",
 					"daemonPort": %d,
 					"datastore": ""
-				}`, dataDir, daemonPort)
+				}`, daemonPort)
 				session := startCommandInHost("ADD", cniStdin)
 				Eventually(session, cmdTimeout).Should(gexec.Exit(1))
 
 				Expect(session.Out.Contents()).To(MatchJSON(`{
 					"code": 100,
-					"msg": "write container metadata",
+					"msg": "allocate ip",
 					"details": "open lock: open : no such file or directory"
 				}`))
 			})
@@ -203,12 +201,12 @@ var _ = Describe("errors", func() {
 					"cniVersion": "1.0.0",
 					"name": "my-silk-network",
 					"type": "silk",
-					"dataDir": "%s",
 					"daemonPort": %d,
 					"datastore": ""
-				}`, dataDir, daemonPort)
+				}`, daemonPort)
 				session := startCommandInHost("DEL", cniStdin)
 				Eventually(session, cmdTimeout).Should(gexec.Exit(0))
+				Expect(string(session.Err.Contents())).To(MatchRegexp(`release-ip-failed.*"open lock: open : no such file or directory"`))
 				Expect(string(session.Err.Contents())).To(MatchRegexp(`delete-from-container-metadata.*"open lock: open : no such file or directory"`))
 			})
 		})
@@ -13,11 +13,15 @@ import (
 	"syscall"
 	"time"
 
+	"code.cloudfoundry.org/silk/cni/ipam"
+
+	"github.com/containernetworking/cni/pkg/types"
 	current "github.com/containernetworking/cni/pkg/types/100"
 	"github.com/containernetworking/plugins/pkg/ns"
 	"github.com/containernetworking/plugins/pkg/testutils"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
 	"github.com/onsi/gomega/gexec"
 	"github.com/vishvananda/netlink"
 )
@@ -31,7 +35,6 @@ var (
 	containerNS     ns.NetNS
 	fakeHostNS      ns.NetNS
 	cniStdin        string
-	dataDir         string
 	flannelSubnet   *net.IPNet
 	fullNetwork     *net.IPNet
 	subnetEnvFile   string
@@ -65,9 +68,6 @@ var _ = BeforeEach(func() {
 	}
 	cniEnv["CNI_NETNS"] = containerNS.Path()
 
-	dataDir, err = os.MkdirTemp("", "cni-data-dir-")
-	Expect(err).NotTo(HaveOccurred())
-
 	flannelSubnetBaseIP, flannelSubnetCIDR, _ := net.ParseCIDR("10.255.30.0/24")
 	_, fullNetwork, _ = net.ParseCIDR("10.255.0.0/16")
 	flannelSubnet = &net.IPNet{
@@ -78,7 +78,7 @@ var _ = BeforeEach(func() {
 	daemonPort = 40000 + GinkgoParallelProcess()
 	fakeServer = startFakeDaemonInHost(daemonPort, http.StatusOK, `{"overlay_subnet": "10.255.30.0/24", "mtu": 1472}`)
 
-	cniStdin = cniConfig(dataDir, datastorePath, daemonPort)
+	cniStdin = cniConfig(datastorePath, daemonPort)
 
 	datastoreDir, err := os.MkdirTemp("", "metadata-dir-")
 	Expect(err).NotTo(HaveOccurred())
@@ -96,14 +96,13 @@ var _ = AfterEach(func() {
 	mustSucceed("ip", "netns", "del", fakeHostNSName)
 	mustSucceed("ip", "netns", "del", containerNSName)
 	Expect(os.RemoveAll(subnetEnvFile)).To(Succeed())
-	Expect(os.RemoveAll(dataDir)).To(Succeed())
-	Expect(os.RemoveAll(datastorePath)).To(Succeed())
+	Expect(os.RemoveAll(filepath.Dir(datastorePath))).To(Succeed())
 })
 
 var _ = Describe("Silk CNI Integration", func() {
 	Describe("veth devices", func() {
 		BeforeEach(func() {
-			cniStdin = cniConfig(dataDir, datastorePath, daemonPort)
+			cniStdin = cniConfig(datastorePath, daemonPort)
 		})
 
 		It("returns the expected CNI result", func() {
@@ -186,7 +185,7 @@ var _ = Describe("Silk CNI Integration", func() {
 			sess := startCommandInHost("ADD", cniStdin)
 			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
 
-			Expect(filepath.Join(dataDir, "ipam/my-silk-network/10.255.30.2")).To(BeAnExistingFile())
+			Expect(allocatedIPs()).To(HaveKey("10.255.30.2"))
 			fakeServer.Interrupt()
 			Eventually(fakeServer, "5s").Should(gexec.Exit())
 
@@ -194,7 +193,7 @@ var _ = Describe("Silk CNI Integration", func() {
 			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
 
 			By("checking that the ip reserved is freed")
-			Expect(filepath.Join(dataDir, "ipam/my-silk-network/10.255.30.2")).NotTo(BeAnExistingFile())
+			Expect(allocatedIPs()).NotTo(HaveKey("10.255.30.2"))
 		})
 
 		hostLinkFromResult := func(cniResult []byte) netlink.Link {
@@ -253,7 +252,7 @@ var _ = Describe("Silk CNI Integration", func() {
 		})
 
 		It("enables connectivity between the host and container", func() {
-			cniStdin = cniConfig(dataDir, datastorePath, daemonPort)
+			cniStdin = cniConfig(datastorePath, daemonPort)
 
 			sess := startCommandInHost("ADD", cniStdin)
 			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
@@ -269,7 +268,7 @@ var _ = Describe("Silk CNI Integration", func() {
 		})
 
 		It("turns off ARP for veth devices", func() {
-			cniStdin = cniConfig(dataDir, datastorePath, daemonPort)
+			cniStdin = cniConfig(datastorePath, daemonPort)
 
 			sess := startCommandInHost("ADD", cniStdin)
 			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
@@ -316,7 +315,7 @@ var _ = Describe("Silk CNI Integration", func() {
 		})
 
 		It("adds routes to the container", func() {
-			cniStdin = cniConfig(dataDir, datastorePath, daemonPort)
+			cniStdin = cniConfig(datastorePath, daemonPort)
 
 			sess := startCommandInHost("ADD", cniStdin)
 			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
@@ -347,7 +346,7 @@ var _ = Describe("Silk CNI Integration", func() {
 		})
 
 		It("allows the container to reach IP addresses on the host namespace", func() {
-			cniStdin = cniConfig(dataDir, datastorePath, daemonPort)
+			cniStdin = cniConfig(datastorePath, daemonPort)
 
 			sess := startCommandInHost("ADD", cniStdin)
 			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
@@ -372,7 +371,7 @@ var _ = Describe("Silk CNI Integration", func() {
 			fakeServer = startFakeDaemonInRealHostNamespace(daemonPort, http.StatusOK, `{"overlay_subnet": "10.255.30.0/24", "mtu": 1350}`)
 
 			By("calling CNI with ADD")
-			cniStdin = cniConfig(dataDir, datastorePath, daemonPort)
+			cniStdin = cniConfig(datastorePath, daemonPort)
 			sess := startCommandInRealHostNamespace("ADD", cniStdin)
 			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
 
@@ -437,10 +436,9 @@ var _ = Describe("Silk CNI Integration", func() {
 					"name": "my-silk-network",
 					"type": "silk",
 					"mtu": 1350,
-					"dataDir": "%s",
 					"daemonPort": %d,
 					"datastore": "%s"
-				}`, dataDir, daemonPort, datastorePath)
+				}`, daemonPort, datastorePath)
 				sess := startCommandInHost("ADD", cniStdin)
 				Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
 
@@ -484,7 +482,7 @@ var _ = Describe("Silk CNI Integration", func() {
 
 	Describe("Lifecycle", func() {
 		BeforeEach(func() {
-			cniStdin = cniConfig(dataDir, datastorePath, daemonPort)
+			cniStdin = cniConfig(datastorePath, daemonPort)
 		})
 		It("allocates and frees ips", func() {
 			By("calling ADD")
@@ -500,9 +498,11 @@ var _ = Describe("Silk CNI Integration", func() {
 			Expect(result.IPs[0].Gateway.String()).To(Equal("169.254.0.1"))
 
 			By("checking that the ip is reserved for the correct container id")
-			bytes, err := os.ReadFile(filepath.Join(dataDir, "ipam/my-silk-network/10.255.30.2"))
-			Expect(err).NotTo(HaveOccurred())
-			Expect(string(bytes)).To(Equal(fmt.Sprintf("%s\r\neth0", containerID)))
+			Expect(allocatedIPs()).To(HaveKeyWithValue("10.255.30.2", ipam.Allocation{
+				ContainerID: containerID,
+				IfName:      "eth0",
+				Netns:       containerNS.Path(),
+			}))
 
 			By("calling DEL")
 			sess = startCommandInHost("DEL", cniStdin)
@@ -510,7 +510,44 @@ var _ = Describe("Silk CNI Integration", func() {
 			Expect(sess.Out.Contents()).To(BeEmpty())
 
 			By("checking that the ip reserved is freed")
-			Expect(filepath.Join(dataDir, "ipam/my-silk-network/10.255.30.2")).NotTo(BeAnExistingFile())
+			Expect(allocatedIPs()).NotTo(HaveKey("10.255.30.2"))
+		})
+
+		It("allocates the ip requested through CNI_ARGS", func() {
+			cniEnv["CNI_ARGS"] = "IP=10.255.30.42"
+			sess := startCommandInHost("ADD", cniStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
+
+			result := cniResultForCurrentVersion(sess.Out.Contents())
+			Expect(result.IPs[0].Address.String()).To(Equal("10.255.30.42/32"))
+			Expect(allocatedIPs()).To(HaveKey("10.255.30.42"))
+		})
+
+		It("frees the ip of a container whose network namespace was deleted", func() {
+			sess := startCommandInHost("ADD", cniStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
+
+			By("deleting the network namespace without calling DEL")
+			staleNSName := fmt.Sprintf("stale-%03d", GinkgoParallelProcess())
+			mustSucceed("ip", "netns", "add", staleNSName)
+			cniEnv["CNI_NETNS"] = fmt.Sprintf("/var/run/netns/%s", staleNSName)
+			cniEnv["CNI_CONTAINERID"] = "stale-container"
+			sess = startCommandInHost("ADD", cniStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
+			Expect(allocatedIPs()).To(HaveKey("10.255.30.3"))
+			mustSucceed("ip", "netns", "del", staleNSName)
+
+			By("calling ADD for another container")
+			otherNS, err := testutils.NewNS()
+			Expect(err).NotTo(HaveOccurred())
+			defer otherNS.Close()
+			cniEnv["CNI_NETNS"] = otherNS.Path()
+			cniEnv["CNI_CONTAINERID"] = "other-container"
+			sess = startCommandInHost("ADD", cniStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
+
+			Expect(allocatedIPs()).NotTo(HaveKey("10.255.30.3"))
+			Expect(sess.Err).To(gbytes.Say(`collect-allocation.*"containerID":"stale-container"`))
 		})
 
 		It("writes and deletes container metadata", func() {
@@ -541,6 +578,551 @@ var _ = Describe("Silk CNI Integration", func() {
 
 			Expect(string(containerMetadata)).NotTo(ContainSubstring("169.254.0.1"))
 		})
+
+		Context("when setting up the interface fails after the ip is allocated", func() {
+			BeforeEach(func() {
+				mustSucceedInFakeHost("ip", "link", "add", "s-010255030002", "type", "veth", "peer", "name", "in-the-way")
+			})
+
+			It("releases the ip", func() {
+				sess := startCommandInHost("ADD", cniStdin)
+				Eventually(sess, cmdTimeout).Should(gexec.Exit(1))
+
+				Expect(sess.Out.Contents()).To(MatchJSON(`{
+					"code": 100,
+					"msg": "create veth pair",
+					"details": "creating veth pair: file exists"
+				}`))
+				Expect(allocatedIPs()).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("bandwidth limits", func() {
+		BeforeEach(func() {
+			cniStdin = cniConfigWithExtras(datastorePath, daemonPort, map[string]interface{}{
+				"bandwidth": map[string]interface{}{
+					"ingressRate":  1024 * 1024,
+					"ingressBurst": 512 * 1024,
+					"egressRate":   2048 * 1024,
+					"egressBurst":  1024 * 1024,
+				},
+			})
+		})
+
+		It("shapes ingress on the host device and egress on an ifb device", func() {
+			By("calling ADD")
+			sess := startCommandInHost("ADD", cniStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
+
+			By("checking the qdiscs of the host device")
+			qdiscs := mustSucceedInFakeHost("tc", "qdisc", "show", "dev", "s-010255030002")
+			Expect(qdiscs).To(ContainSubstring("qdisc tbf 1: root"))
+			Expect(qdiscs).To(ContainSubstring("rate 1048Kbit burst 64Kb"))
+			Expect(qdiscs).To(ContainSubstring("qdisc ingress ffff:"))
+
+			filters := mustSucceedInFakeHost("tc", "filter", "show", "dev", "s-010255030002", "parent", "ffff:")
+			Expect(filters).To(ContainSubstring("Egress Redirect to device i-010255030002"))
+
+			By("checking the ifb device")
+			qdiscs = mustSucceedInFakeHost("tc", "qdisc", "show", "dev", "i-010255030002")
+			Expect(qdiscs).To(ContainSubstring("qdisc tbf 1: root"))
+			Expect(qdiscs).To(ContainSubstring("rate 2097Kbit burst 128Kb"))
+
+			By("calling DEL")
+			sess = startCommandInHost("DEL", cniStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
+
+			By("checking that the ifb device is deleted")
+			mustFailInHost("does not exist", "ip", "link", "show", "i-010255030002")
+		})
+
+		Context("when the runtime passes bandwidth limits", func() {
+			BeforeEach(func() {
+				cniStdin = cniConfigWithExtras(datastorePath, daemonPort, map[string]interface{}{
+					"bandwidth": map[string]interface{}{
+						"ingressRate":  1024 * 1024,
+						"ingressBurst": 512 * 1024,
+						"egressRate":   2048 * 1024,
+						"egressBurst":  1024 * 1024,
+					},
+					"runtimeConfig": map[string]interface{}{
+						"bandwidth": map[string]interface{}{
+							"ingressRate":  4096 * 1024,
+							"ingressBurst": 512 * 1024,
+						},
+					},
+				})
+			})
+
+			It("uses them instead of the configured limits", func() {
+				sess := startCommandInHost("ADD", cniStdin)
+				Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
+
+				qdiscs := mustSucceedInFakeHost("tc", "qdisc", "show", "dev", "s-010255030002")
+				Expect(qdiscs).To(ContainSubstring("rate 4194Kbit burst 64Kb"))
+				Expect(qdiscs).NotTo(ContainSubstring("qdisc ingress"))
+
+				mustFailInHost("does not exist", "ip", "link", "show", "i-010255030002")
+			})
+		})
+
+		Context("when the burst is too small for a packet", func() {
+			BeforeEach(func() {
+				cniStdin = cniConfigWithExtras(datastorePath, daemonPort, map[string]interface{}{
+					"bandwidth": map[string]interface{}{
+						"ingressRate":  1024 * 1024,
+						"ingressBurst": 1024,
+					},
+				})
+			})
+
+			It("fails before allocating an IP", func() {
+				sess := startCommandInHost("ADD", cniStdin)
+				Eventually(sess, cmdTimeout).Should(gexec.Exit(1))
+
+				Expect(sess.Out.Contents()).To(MatchJSON(`{
+					"code": 100,
+					"msg": "validate bandwidth limits",
+					"details": "ingress burst 1024 bits is smaller than a packet of 1472 bytes"
+				}`))
+				Expect(allocatedIPs()).NotTo(HaveKey("10.255.30.2"))
+			})
+		})
+	})
+
+	Describe("Tuning", func() {
+		BeforeEach(func() {
+			cniStdin = cniConfigWithExtras(datastorePath, daemonPort, map[string]interface{}{
+				"tuning": map[string]interface{}{
+					"sysctls": map[string]string{
+						"net.core.somaxconn":           "4096",
+						"net.ipv4.ip_local_port_range": "10000 20000",
+					},
+					"txQueueLen": 500,
+					"gso":        false,
+				},
+			})
+		})
+
+		It("tunes the container namespace and device", func() {
+			sess := startCommandInHost("ADD", cniStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
+
+			Expect(mustSucceedInContainer("cat", "/proc/sys/net/core/somaxconn")).To(Equal("4096\n"))
+			Expect(mustSucceedInContainer("cat", "/proc/sys/net/ipv4/ip_local_port_range")).To(Equal("10000\t20000\n"))
+			Expect(mustSucceedInContainer("ip", "link", "show", "eth0")).To(ContainSubstring("qlen 500"))
+
+			sess = startCommandInHost("DEL", cniStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
+		})
+
+		Context("when a sysctl is not allowed", func() {
+			BeforeEach(func() {
+				cniStdin = cniConfigWithExtras(datastorePath, daemonPort, map[string]interface{}{
+					"tuning": map[string]interface{}{
+						"sysctls": map[string]string{"net.ipv4.ip_forward": "1"},
+					},
+				})
+			})
+
+			It("fails before allocating an IP", func() {
+				sess := startCommandInHost("ADD", cniStdin)
+				Eventually(sess, cmdTimeout).Should(gexec.Exit(1))
+
+				Expect(sess.Out.Contents()).To(MatchJSON(`{
+					"code": 100,
+					"msg": "validate tuning",
+					"details": "sysctl net.ipv4.ip_forward is not allowed"
+				}`))
+				Expect(allocatedIPs()).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Attachments", func() {
+		BeforeEach(func() {
+			subnetEnvFile = writeSubnetEnvFile("10.250.7.0/24", "10.250.0.0/16")
+			cniStdin = cniConfigWithExtras(datastorePath, daemonPort, map[string]interface{}{
+				"attachments": []map[string]interface{}{{
+					"ifName":     "eth1",
+					"name":       "mesh",
+					"network":    "10.250.0.0/16",
+					"subnetFile": subnetEnvFile,
+				}},
+			})
+		})
+
+		It("adds an interface in the overlay network of the attachment", func() {
+			sess := startCommandInHost("ADD", cniStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
+
+			result := cniResultForCurrentVersion(sess.Out.Contents())
+			Expect(result.Interfaces).To(HaveLen(4))
+			Expect(result.Interfaces[0].Name).To(Equal("s-010255030002"))
+			Expect(result.Interfaces[1].Name).To(Equal("eth0"))
+			Expect(result.Interfaces[2].Name).To(Equal("s-0afa070298b7"))
+			Expect(result.Interfaces[3].Name).To(Equal("eth1"))
+			Expect(result.IPs).To(HaveLen(2))
+			Expect(result.IPs[0].Address.String()).To(Equal("10.255.30.2/32"))
+			Expect(*result.IPs[1].Interface).To(Equal(3))
+			Expect(result.IPs[1].Address.String()).To(Equal("10.250.7.2/32"))
+
+			By("routing only the network of the attachment through its interface")
+			routes := mustSucceedInContainer("ip", "route", "show")
+			Expect(routes).To(ContainSubstring("default via 169.254.0.1 dev eth0 src 10.255.30.2"))
+			Expect(routes).To(ContainSubstring("10.250.0.0/16 via 169.254.0.1 dev eth1 src 10.250.7.2"))
+
+			mustSucceedInFakeHost("ip", "link", "show", "s-0afa070298b7")
+			Expect(allocatedIPsIn("mesh")).To(HaveKey("10.250.7.2"))
+
+			By("calling CHECK")
+			checkStdin := cniConfigWithExtras(datastorePath, daemonPort, map[string]interface{}{
+				"attachments": []map[string]interface{}{{
+					"ifName":     "eth1",
+					"name":       "mesh",
+					"network":    "10.250.0.0/16",
+					"subnetFile": subnetEnvFile,
+				}},
+				"prevResult": json.RawMessage(sess.Out.Contents()),
+			})
+			sess = startCommandInHost("CHECK", checkStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
+
+			By("calling DEL")
+			sess = startCommandInHost("DEL", cniStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
+
+			mustFailInContainer("does not exist", "ip", "link", "show", "eth1")
+			Expect(allocatedIPsIn("mesh")).To(BeEmpty())
+			Expect(allocatedIPs()).To(BeEmpty())
+		})
+
+		Context("when adding the attachment fails", func() {
+			BeforeEach(func() {
+				mustSucceedInFakeHost("ip", "link", "add", "s-0afa070298b7", "type", "veth", "peer", "name", "in-the-way")
+			})
+
+			It("removes the interfaces of the container and releases its ips", func() {
+				sess := startCommandInHost("ADD", cniStdin)
+				Eventually(sess, cmdTimeout).Should(gexec.Exit(1))
+
+				Expect(sess.Out.Contents()).To(MatchJSON(`{
+					"code": 100,
+					"msg": "add attachment eth1",
+					"details": "create veth pair: creating veth pair: file exists"
+				}`))
+
+				mustFailInContainer("does not exist", "ip", "link", "show", "eth0")
+				mustFailInHost("does not exist", "ip", "link", "show", "s-010255030002")
+				mustSucceedInFakeHost("ip", "link", "show", "s-0afa070298b7")
+				Expect(allocatedIPs()).To(BeEmpty())
+				Expect(allocatedIPsIn("mesh")).To(BeEmpty())
+			})
+		})
+
+		Context("when the attachment uses the interface of the primary network", func() {
+			BeforeEach(func() {
+				cniStdin = cniConfigWithExtras(datastorePath, daemonPort, map[string]interface{}{
+					"attachments": []map[string]interface{}{{
+						"ifName":     "eth0",
+						"name":       "mesh",
+						"network":    "10.250.0.0/16",
+						"subnetFile": subnetEnvFile,
+					}},
+				})
+			})
+
+			It("fails before allocating an IP", func() {
+				sess := startCommandInHost("ADD", cniStdin)
+				Eventually(sess, cmdTimeout).Should(gexec.Exit(1))
+
+				Expect(sess.Out.Contents()).To(MatchJSON(`{
+					"code": 100,
+					"msg": "validate attachments",
+					"details": "eth0: interface is already in use"
+				}`))
+				Expect(allocatedIPs()).To(BeEmpty())
+			})
+		})
+
+		Context("when the network of the attachment overlaps the subnet of the cell", func() {
+			BeforeEach(func() {
+				cniStdin = cniConfigWithExtras(datastorePath, daemonPort, map[string]interface{}{
+					"attachments": []map[string]interface{}{{
+						"ifName":     "eth1",
+						"name":       "mesh",
+						"network":    "10.255.0.0/16",
+						"subnetFile": subnetEnvFile,
+					}},
+				})
+			})
+
+			It("fails before allocating an IP", func() {
+				sess := startCommandInHost("ADD", cniStdin)
+				Eventually(sess, cmdTimeout).Should(gexec.Exit(1))
+
+				Expect(sess.Out.Contents()).To(MatchJSON(`{
+					"code": 100,
+					"msg": "validate attachments",
+					"details": "eth1: network 10.255.0.0/16 overlaps 10.255.30.0/24"
+				}`))
+				Expect(allocatedIPs()).To(BeEmpty())
+			})
+		})
+
+		Context("when the networks of two attachments overlap", func() {
+			BeforeEach(func() {
+				cniStdin = cniConfigWithExtras(datastorePath, daemonPort, map[string]interface{}{
+					"attachments": []map[string]interface{}{{
+						"ifName":     "eth1",
+						"name":       "mesh",
+						"network":    "10.250.0.0/16",
+						"subnetFile": subnetEnvFile,
+					}, {
+						"ifName":     "eth2",
+						"name":       "other-mesh",
+						"network":    "10.250.128.0/17",
+						"subnetFile": subnetEnvFile,
+					}},
+				})
+			})
+
+			It("fails before allocating an IP", func() {
+				sess := startCommandInHost("ADD", cniStdin)
+				Eventually(sess, cmdTimeout).Should(gexec.Exit(1))
+
+				Expect(sess.Out.Contents()).To(MatchJSON(`{
+					"code": 100,
+					"msg": "validate attachments",
+					"details": "eth2: network 10.250.128.0/17 overlaps 10.250.0.0/16"
+				}`))
+				Expect(allocatedIPs()).To(BeEmpty())
+				Expect(allocatedIPsIn("mesh")).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Routes and DNS", func() {
+		var extras map[string]interface{}
+
+		BeforeEach(func() {
+			subnetEnvFile = writeSubnetEnvFile("10.250.7.0/24", "10.250.0.0/16")
+			extras = map[string]interface{}{
+				"attachments": []map[string]interface{}{{
+					"ifName":     "eth1",
+					"name":       "mesh",
+					"network":    "10.250.0.0/16",
+					"subnetFile": subnetEnvFile,
+				}},
+				"routes": []map[string]interface{}{
+					{"dst": "192.168.0.0/16"},
+					{"dst": "172.16.5.0/24", "ifName": "eth1"},
+					{"dst": "172.16.6.0/24", "ifName": "eth1", "gw": "10.0.16.1"},
+				},
+				"dns": map[string]interface{}{
+					"nameservers": []string{"169.254.0.2"},
+					"search":      []string{"apps.internal"},
+					"options":     []string{"ndots:2"},
+				},
+			}
+			cniStdin = cniConfigWithExtras(datastorePath, daemonPort, extras)
+
+			mustSucceedInFakeHost("ip", "link", "add", "underlay", "type", "veth", "peer", "name", "underlay-peer")
+			mustSucceedInFakeHost("ip", "addr", "add", "10.0.16.5/24", "dev", "underlay")
+			mustSucceedInFakeHost("ip", "link", "set", "underlay-peer", "up")
+			mustSucceedInFakeHost("ip", "link", "set", "underlay", "up")
+		})
+
+		It("adds the routes in the container and returns them with the DNS settings", func() {
+			sess := startCommandInHost("ADD", cniStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
+
+			result := cniResultForCurrentVersion(sess.Out.Contents())
+			Expect(result.DNS).To(Equal(types.DNS{
+				Nameservers: []string{"169.254.0.2"},
+				Search:      []string{"apps.internal"},
+				Options:     []string{"ndots:2"},
+			}))
+			var resultRoutes []string
+			for _, r := range result.Routes {
+				resultRoutes = append(resultRoutes, fmt.Sprintf("%s via %s", r.Dst.String(), r.GW))
+			}
+			Expect(resultRoutes).To(Equal([]string{
+				"0.0.0.0/0 via 169.254.0.1",
+				"192.168.0.0/16 via 169.254.0.1",
+				"10.250.0.0/16 via 169.254.0.1",
+				"172.16.5.0/24 via 169.254.0.1",
+				"172.16.6.0/24 via 10.0.16.1",
+			}))
+
+			routes := mustSucceedInContainer("ip", "route", "show")
+			Expect(routes).To(ContainSubstring("192.168.0.0/16 via 169.254.0.1 dev eth0 src 10.255.30.2"))
+			Expect(routes).To(ContainSubstring("172.16.5.0/24 via 169.254.0.1 dev eth1 src 10.250.7.2"))
+			Expect(routes).To(ContainSubstring("172.16.6.0/24 via 10.0.16.1 dev eth1 src 10.250.7.2 onlink"))
+
+			By("pointing the gateway at the host end of the veth pair")
+			hostMAC := result.Interfaces[2].Mac
+			neighbors := mustSucceedInContainer("ip", "neigh", "show", "dev", "eth1")
+			Expect(neighbors).To(ContainSubstring("10.0.16.1 lladdr " + hostMAC + " PERMANENT"))
+
+			By("forwarding the traffic of the container to the gateway on the host")
+			hostDevice := result.Interfaces[2].Name
+			table := "73218" // 65536 plus the low 16 bits of 10.255.30.2, the primary IP of the container
+			rules := mustSucceedInFakeHost("ip", "rule", "show")
+			Expect(rules).To(ContainSubstring("100:\tfrom 10.250.7.2 to 172.16.6.0/24 iif " + hostDevice + " lookup " + table))
+			Expect(mustSucceedInFakeHost("ip", "route", "show", "table", table)).To(ContainSubstring("172.16.6.0/24 via 10.0.16.1 dev underlay"))
+			Expect(mustSucceedInFakeHost("ip", "route", "get", "172.16.6.7", "from", "10.250.7.2", "iif", hostDevice)).To(ContainSubstring("via 10.0.16.1 dev underlay"))
+			mustFailInHost("Network is unreachable", "ip", "route", "get", "172.16.6.7", "from", "10.255.30.2", "iif", result.Interfaces[0].Name)
+
+			By("calling CHECK")
+			extras["prevResult"] = json.RawMessage(sess.Out.Contents())
+			checkStdin := cniConfigWithExtras(datastorePath, daemonPort, extras)
+			sess = startCommandInHost("CHECK", checkStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
+
+			By("removing a route through the attachment")
+			mustSucceedInContainer("ip", "route", "del", "172.16.5.0/24")
+			sess = startCommandInHost("CHECK", checkStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(1))
+			Expect(sess.Out.Contents()).To(ContainSubstring("missing route to 172.16.5.0/24 via 169.254.0.1"))
+
+			By("removing the rule of the gateway route")
+			mustSucceedInContainer("ip", "route", "add", "172.16.5.0/24", "via", "169.254.0.1", "dev", "eth1", "src", "10.250.7.2")
+			mustSucceedInFakeHost("ip", "rule", "del", "from", "10.250.7.2", "to", "172.16.6.0/24", "iif", hostDevice, "lookup", table)
+			sess = startCommandInHost("CHECK", checkStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(1))
+			Expect(sess.Out.Contents()).To(ContainSubstring("missing rule for 172.16.6.0/24 from 10.250.7.2 in table " + table))
+			mustSucceedInFakeHost("ip", "rule", "add", "from", "10.250.7.2", "to", "172.16.6.0/24", "iif", hostDevice, "lookup", table, "priority", "100")
+
+			mustSucceedInFakeHost("ip", "rule", "add", "from", "10.250.7.9", "lookup", table, "priority", "200")
+			sess = startCommandInHost("DEL", cniStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
+
+			By("removing the gateway routes from the host and leaving other rules alone")
+			rules = mustSucceedInFakeHost("ip", "rule", "show")
+			Expect(rules).NotTo(ContainSubstring("100:\tfrom 10.250.7.2"))
+			Expect(rules).To(ContainSubstring("200:\tfrom 10.250.7.9 lookup " + table))
+			Expect(mustSucceedInFakeHost("ip", "route", "show", "table", "all")).NotTo(ContainSubstring("table " + table))
+		})
+
+		Context("when a route goes through an unknown interface", func() {
+			BeforeEach(func() {
+				extras["routes"] = []map[string]interface{}{{"dst": "172.16.5.0/24", "ifName": "eth2"}}
+				cniStdin = cniConfigWithExtras(datastorePath, daemonPort, extras)
+			})
+
+			It("fails before allocating an IP", func() {
+				sess := startCommandInHost("ADD", cniStdin)
+				Eventually(sess, cmdTimeout).Should(gexec.Exit(1))
+
+				Expect(sess.Out.Contents()).To(MatchJSON(`{
+					"code": 100,
+					"msg": "validate routes",
+					"details": "route to 172.16.5.0/24: no interface eth2"
+				}`))
+				Expect(allocatedIPs()).To(BeEmpty())
+			})
+		})
+
+		Context("when the host reaches a gateway through another gateway", func() {
+			BeforeEach(func() {
+				mustSucceedInFakeHost("ip", "route", "add", "10.99.0.0/16", "via", "10.0.16.1")
+				extras["routes"] = []map[string]interface{}{{"dst": "172.16.5.0/24", "ifName": "eth1", "gw": "10.99.0.9"}}
+				cniStdin = cniConfigWithExtras(datastorePath, daemonPort, extras)
+			})
+
+			It("fails before allocating an IP", func() {
+				sess := startCommandInHost("ADD", cniStdin)
+				Eventually(sess, cmdTimeout).Should(gexec.Exit(1))
+
+				Expect(sess.Out.Contents()).To(MatchJSON(`{
+					"code": 100,
+					"msg": "validate routes",
+					"details": "gateway 10.99.0.9 is not a neighbor of the host, it is reached via 10.0.16.1"
+				}`))
+				Expect(allocatedIPs()).To(BeEmpty())
+			})
+		})
+
+		Context("when a nameserver is not an IP", func() {
+			BeforeEach(func() {
+				extras["dns"] = map[string]interface{}{"nameservers": []string{"dns.internal"}}
+				cniStdin = cniConfigWithExtras(datastorePath, daemonPort, extras)
+			})
+
+			It("fails before allocating an IP", func() {
+				sess := startCommandInHost("ADD", cniStdin)
+				Eventually(sess, cmdTimeout).Should(gexec.Exit(1))
+
+				Expect(sess.Out.Contents()).To(MatchJSON(`{
+					"code": 100,
+					"msg": "validate dns",
+					"details": "invalid nameserver \"dns.internal\""
+				}`))
+				Expect(allocatedIPs()).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("Check", func() {
+		var checkStdin string
+
+		BeforeEach(func() {
+			cniStdin = cniConfig(datastorePath, daemonPort)
+
+			By("calling ADD")
+			sess := startCommandInHost("ADD", cniStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
+
+			checkStdin = cniConfigWithExtras(datastorePath, daemonPort, map[string]interface{}{
+				"prevResult": json.RawMessage(sess.Out.Contents()),
+			})
+		})
+
+		AfterEach(func() {
+			sess := startCommandInHost("DEL", cniStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
+		})
+
+		It("succeeds when the networking matches the cached result", func() {
+			sess := startCommandInHost("CHECK", checkStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(0))
+			Expect(sess.Out.Contents()).To(BeEmpty())
+		})
+
+		It("reports when the container default route has been removed", func() {
+			mustSucceedInContainer("ip", "route", "del", "default")
+
+			sess := startCommandInHost("CHECK", checkStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(1))
+			Expect(sess.Out.Contents()).To(ContainSubstring("check container"))
+			Expect(sess.Out.Contents()).To(ContainSubstring("missing route to 0.0.0.0/0 via 169.254.0.1"))
+		})
+
+		It("reports when ARP has been re-enabled on the host device", func() {
+			hostDevice := strings.TrimSpace(mustSucceedInFakeHost("sh", "-c", "ls /sys/class/net | grep '^s-'"))
+			mustSucceedInFakeHost("ip", "link", "set", "dev", hostDevice, "arp", "on")
+
+			sess := startCommandInHost("CHECK", checkStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(1))
+			Expect(sess.Out.Contents()).To(ContainSubstring("check host"))
+			Expect(sess.Out.Contents()).To(ContainSubstring("has ARP enabled"))
+		})
+
+		It("reports when the mtu has changed", func() {
+			mustSucceedInContainer("ip", "link", "set", "dev", "eth0", "mtu", "1300")
+
+			sess := startCommandInHost("CHECK", checkStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(1))
+			Expect(sess.Out.Contents()).To(ContainSubstring(`link \"eth0\" has mtu 1300, expected 1472`))
+		})
+
+		It("requires a prevResult", func() {
+			sess := startCommandInHost("CHECK", cniStdin)
+			Eventually(sess, cmdTimeout).Should(gexec.Exit(1))
+			Expect(sess.Out.Contents()).To(ContainSubstring("prevResult is required"))
+		})
 	})
 
 	Describe("Reserve all IPs", func() {
@@ -549,7 +1131,7 @@ var _ = Describe("Silk CNI Integration", func() {
 			numIPAllocations int
 		)
 		BeforeEach(func() {
-			cniStdin = cniConfig(dataDir, datastorePath, daemonPort)
+			cniStdin = cniConfig(datastorePath, daemonPort)
 			prefixSize := 29
 			fakeServer = startFakeDaemonInHost(daemonPort, http.StatusOK, fmt.Sprintf(`{"overlay_subnet": "10.255.30.0/%d", "mtu": 1350}`, prefixSize))
 			numIPAllocations = int(math.Pow(2, float64(32-prefixSize)) - 2)
@@ -588,8 +1170,8 @@ var _ = Describe("Silk CNI Integration", func() {
 			Eventually(sess, cmdTimeout).Should(gexec.Exit(1))
 			Expect(sess.Out.Contents()).To(MatchJSON(`{
 				"code": 100,
-				"msg": "run ipam plugin",
-				"details": "failed to allocate for range 0: no IP addresses available in range set: 10.255.30.1-10.255.30.6"
+				"msg": "allocate ip",
+				"details": "no IP addresses available in 10.255.30.0/29"
 				}`))
 		})
 	})
@@ -597,7 +1179,7 @@ var _ = Describe("Silk CNI Integration", func() {
 	Describe("when configured to use the subnet.env file", func() {
 		BeforeEach(func() {
 			subnetFile := writeSubnetEnvFile(flannelSubnet.String(), fullNetwork.String())
-			cniStdin = cniConfigWithSubnetEnv(dataDir, datastorePath, subnetFile)
+			cniStdin = cniConfigWithSubnetEnv(datastorePath, subnetFile)
 		})
 
 		It("returns the expected CNI result", func() {
@@ -652,12 +1234,11 @@ FLANNEL_IPMASQ=false  # we'll ignore this field
 	return tempFile.Name()
 }
 
-func cniConfigWithExtras(dataDir, datastore string, daemonPort int, extras map[string]interface{}) string {
+func cniConfigWithExtras(datastore string, daemonPort int, extras map[string]interface{}) string {
 	conf := map[string]interface{}{
 		"cniVersion": "1.0.0",
 		"name":       "my-silk-network",
 		"type":       "silk",
-		"dataDir":    dataDir,
 		"daemonPort": daemonPort,
 		"datastore":  datastore,
 	}
@@ -668,19 +1249,38 @@ func cniConfigWithExtras(dataDir, datastore string, daemonPort int, extras map[s
 	return string(confBytes)
 }
 
-func cniConfig(dataDir, datastore string, daemonPort int) string {
-	return cniConfigWithExtras(dataDir, datastore, daemonPort, nil)
+func cniConfig(datastore string, daemonPort int) string {
+	return cniConfigWithExtras(datastore, daemonPort, nil)
 }
 
-func cniConfigWithSubnetEnv(dataDir, datastore, subnetFile string) string {
+func cniConfigWithSubnetEnv(datastore, subnetFile string) string {
 	return fmt.Sprintf(`{
 	"cniVersion": "1.0.0",
 	"name": "my-silk-network",
 	"type": "silk",
-	"dataDir": "%s",
 	"subnetFile": "%s",
 	"datastore": "%s"
-}`, dataDir, subnetFile, datastore)
+}`, subnetFile, datastore)
+}
+
+// allocatedIPs reads the IPs of my-silk-network from the allocations that
+// silk-cni keeps next to the datastore
+func allocatedIPs() map[string]ipam.Allocation {
+	return allocatedIPsIn("my-silk-network")
+}
+
+func allocatedIPsIn(network string) map[string]ipam.Allocation {
+	bytes, err := os.ReadFile(filepath.Join(filepath.Dir(datastorePath), "ipam.json"))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	Expect(err).NotTo(HaveOccurred())
+
+	var networks map[string]struct {
+		Allocations map[string]ipam.Allocation `json:"allocations"`
+	}
+	Expect(json.Unmarshal(bytes, &networks)).To(Succeed())
+	return networks[network].Allocations
 }
 
 func startCommandInHost(cniCommand, cniStdin string) *gexec.Session {
@@ -0,0 +1,274 @@
+package ipam
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"code.cloudfoundry.org/filelock"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/cni/config"
+	"code.cloudfoundry.org/silk/lib/serial"
+)
+
+const (
+	allocationsFileName = "ipam.json"
+
+	hostLocalSubDir               = "ipam"
+	hostLocalLastReservedFileName = "last_reserved_ip.0"
+	hostLocalLockFileName         = "lock"
+)
+
+// Allocation is an IP that was given to an interface of a container.
+type Allocation struct {
+	ContainerID string `json:"container_id"`
+	IfName      string `json:"if_name"`
+	Netns       string `json:"netns"`
+}
+
+type network struct {
+	LastReserved string                `json:"last_reserved"`
+	Allocations  map[string]Allocation `json:"allocations"`
+}
+
+// Allocator gives out the IPs of the overlay subnet of the cell. The
+// allocations are kept in a file next to the silk datastore and are only
+// read and written while the lock of the datastore is held.
+//
+// The first time a network is used, the allocations that the host-local
+// plugin kept for it are imported, so that containers that were created
+// before silk-cni allocated IPs itself keep their IPs. HostLocalDataDir is
+// the dataDir silk-cni used to run host-local with, which kept the
+// allocations of each network in ipam/<network> below it.
+type Allocator struct {
+	Serializer       serial.Serializer
+	LockerNew        func(filePath string) filelock.FileLocker
+	HostLocalDataDir string
+	Logger           lager.Logger
+}
+
+// Allocate returns the IP of the given interface of a container, allocating
+// one if it has none yet. The first IP of the subnet is its network address
+// and the second is reserved for the gateway, so they are never allocated.
+// Allocations are handed out round robin, so that a released IP is not given
+// to the next container right away.
+//
+// Allocations of containers whose network namespace no longer exists are
+// released first, since their DEL is never going to come.
+func (a *Allocator) Allocate(datastore string, cfg *config.IPAMConfig, owner Allocation) (net.IP, error) {
+	first, last, err := allocatableRange(cfg.Subnet)
+	if err != nil {
+		return nil, err
+	}
+
+	var allocated net.IP
+	err = a.update(datastore, cfg.Name, func(n *network) error {
+		a.collectGarbage(cfg.Name, n)
+
+		for ip, allocation := range n.Allocations {
+			if allocation.ContainerID == owner.ContainerID && allocation.IfName == owner.IfName {
+				existing := net.ParseIP(ip).To4()
+				if existing != nil && cfg.Subnet.Contains(existing) && (len(cfg.IPArgs) == 0 || cfg.IPArgs[0].Equal(existing)) {
+					allocated = existing
+					return nil
+				}
+				delete(n.Allocations, ip)
+			}
+		}
+
+		if len(cfg.IPArgs) > 0 {
+			requested := cfg.IPArgs[0].To4()
+			if requested == nil || ipToUint32(requested) < first || ipToUint32(requested) > last {
+				return fmt.Errorf("requested ip %s is not in the range %s-%s", cfg.IPArgs[0], uint32ToIP(first), uint32ToIP(last))
+			}
+			if allocation, ok := n.Allocations[requested.String()]; ok {
+				return fmt.Errorf("requested ip %s is already allocated to container %s", requested, allocation.ContainerID)
+			}
+			allocated = requested
+		} else {
+			allocated = nextFree(n, first, last)
+			if allocated == nil {
+				return fmt.Errorf("no IP addresses available in %s", cfg.Subnet)
+			}
+		}
+
+		n.Allocations[allocated.String()] = owner
+		n.LastReserved = allocated.String()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return allocated, nil
+}
+
+// Release frees the IP of the given interface of a container. It does not
+// need the subnet, so that containers can be deleted after the subnet of the
+// cell changed.
+func (a *Allocator) Release(datastore, networkName, containerID, ifName string) error {
+	return a.update(datastore, networkName, func(n *network) error {
+		for ip, allocation := range n.Allocations {
+			if allocation.ContainerID == containerID && allocation.IfName == ifName {
+				delete(n.Allocations, ip)
+			}
+		}
+		return nil
+	})
+}
+
+func (a *Allocator) update(datastore, networkName string, change func(*network) error) error {
+	lock, err := a.LockerNew(datastore).Open()
+	if err != nil {
+		return fmt.Errorf("open lock: %s", err)
+	}
+	defer lock.Close()
+
+	file, err := a.LockerNew(filepath.Join(filepath.Dir(datastore), allocationsFileName)).Open()
+	if err != nil {
+		return fmt.Errorf("open allocations: %s", err)
+	}
+	defer file.Close()
+
+	networks := make(map[string]*network)
+	err = a.Serializer.DecodeAll(file, &networks)
+	if err != nil {
+		return fmt.Errorf("decoding allocations: %s", err)
+	}
+
+	n, ok := networks[networkName]
+	if !ok || n == nil {
+		n, err = a.importHostLocal(networkName)
+		if err != nil {
+			return fmt.Errorf("import host-local allocations: %s", err)
+		}
+		networks[networkName] = n
+	}
+	if n.Allocations == nil {
+		n.Allocations = make(map[string]Allocation)
+	}
+
+	if err := change(n); err != nil {
+		return err
+	}
+
+	err = a.Serializer.EncodeAndOverwrite(file, networks)
+	if err != nil {
+		return fmt.Errorf("encode and overwrite: %s", err)
+	}
+	return nil
+}
+
+// importHostLocal reads the allocations of a network from the directory the
+// host-local plugin keeps them in, ipam/<network> below HostLocalDataDir.
+// Each allocation is a file named by the IP that holds the container ID and,
+// since CNI 0.7, the interface name on the next line.
+func (a *Allocator) importHostLocal(networkName string) (*network, error) {
+	n := &network{Allocations: make(map[string]Allocation)}
+	if a.HostLocalDataDir == "" {
+		return n, nil
+	}
+
+	dir := filepath.Join(a.HostLocalDataDir, hostLocalSubDir, networkName)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return n, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name() == hostLocalLockFileName {
+			continue
+		}
+
+		contents, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		if entry.Name() == hostLocalLastReservedFileName {
+			if ip := net.ParseIP(strings.TrimSpace(string(contents))).To4(); ip != nil {
+				n.LastReserved = ip.String()
+			}
+			continue
+		}
+
+		ip := net.ParseIP(entry.Name()).To4()
+		if ip == nil {
+			continue
+		}
+
+		lines := strings.Split(strings.TrimSpace(string(contents)), "\n")
+		allocation := Allocation{ContainerID: strings.TrimSpace(lines[0])}
+		if len(lines) > 1 {
+			allocation.IfName = strings.TrimSpace(lines[1])
+		}
+		n.Allocations[ip.String()] = allocation
+		a.Logger.Info("import-host-local-allocation", lager.Data{"network": networkName, "ip": ip.String(), "containerID": allocation.ContainerID, "interface": allocation.IfName})
+	}
+	return n, nil
+}
+
+func (a *Allocator) collectGarbage(networkName string, n *network) {
+	for ip, allocation := range n.Allocations {
+		if allocation.Netns == "" {
+			continue
+		}
+		if _, err := os.Stat(allocation.Netns); errors.Is(err, os.ErrNotExist) {
+			a.Logger.Info("collect-allocation", lager.Data{"network": networkName, "ip": ip, "containerID": allocation.ContainerID, "netns": allocation.Netns})
+			delete(n.Allocations, ip)
+		}
+	}
+}
+
+func nextFree(n *network, first, last uint32) net.IP {
+	start := first
+	if lastReserved := net.ParseIP(n.LastReserved).To4(); lastReserved != nil {
+		if ip := ipToUint32(lastReserved); ip >= first && ip < last {
+			start = ip + 1
+		}
+	}
+
+	size := last - first + 1
+	for i := uint32(0); i < size; i++ {
+		candidate := uint32ToIP(first + (start-first+i)%size)
+		if _, ok := n.Allocations[candidate.String()]; !ok {
+			return candidate
+		}
+	}
+	return nil
+}
+
+func allocatableRange(subnet *net.IPNet) (uint32, uint32, error) {
+	network := subnet.IP.To4()
+	mask := subnet.Mask
+	if len(mask) == net.IPv6len {
+		mask = mask[12:]
+	}
+	ones, bits := mask.Size()
+	if network == nil || bits != 32 {
+		return 0, 0, fmt.Errorf("subnet %s is not ipv4", subnet)
+	}
+	if ones > 30 {
+		return 0, 0, fmt.Errorf("subnet %s is too small", subnet)
+	}
+
+	base := ipToUint32(network)
+	broadcast := base | ^binary.BigEndian.Uint32(mask)
+	return base + 2, broadcast - 1, nil
+}
+
+func ipToUint32(ip net.IP) uint32 {
+	return binary.BigEndian.Uint32(ip.To4())
+}
+
+func uint32ToIP(i uint32) net.IP {
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, i)
+	return ip
+}
@@ -0,0 +1,379 @@
+package ipam_test
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/filelock"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/cni/config"
+	"code.cloudfoundry.org/silk/cni/ipam"
+	libfakes "code.cloudfoundry.org/silk/lib/fakes"
+	"code.cloudfoundry.org/silk/lib/serial"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("Allocator", func() {
+	var (
+		dataDir   string
+		datastore string
+		logger    *lagertest.TestLogger
+		allocator *ipam.Allocator
+		cfg       *config.IPAMConfig
+		netnsDir  string
+	)
+
+	newNetns := func(name string) string {
+		path := filepath.Join(netnsDir, name)
+		Expect(os.WriteFile(path, nil, 0600)).To(Succeed())
+		return path
+	}
+
+	owner := func(containerID string) ipam.Allocation {
+		return ipam.Allocation{
+			ContainerID: containerID,
+			IfName:      "eth0",
+			Netns:       newNetns(containerID),
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		dataDir, err = os.MkdirTemp("", "ipam-")
+		Expect(err).NotTo(HaveOccurred())
+		datastore = filepath.Join(dataDir, "store.json")
+
+		netnsDir, err = os.MkdirTemp("", "netns-")
+		Expect(err).NotTo(HaveOccurred())
+
+		_, subnet, _ := net.ParseCIDR("10.255.30.0/29")
+		cfg = &config.IPAMConfig{
+			Name:   "some-network",
+			Subnet: subnet,
+		}
+
+		logger = lagertest.NewTestLogger("test")
+		allocator = &ipam.Allocator{
+			Serializer: &serial.Serial{},
+			LockerNew:  filelock.NewLocker,
+			Logger:     logger,
+		}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dataDir)).To(Succeed())
+		Expect(os.RemoveAll(netnsDir)).To(Succeed())
+	})
+
+	Describe("Allocate", func() {
+		It("skips the network address and the gateway", func() {
+			ip, err := allocator.Allocate(datastore, cfg, owner("container-1"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ip.String()).To(Equal("10.255.30.2"))
+		})
+
+		It("stores the allocation next to the datastore", func() {
+			container := owner("container-1")
+			_, err := allocator.Allocate(datastore, cfg, container)
+			Expect(err).NotTo(HaveOccurred())
+
+			contents, err := os.ReadFile(filepath.Join(dataDir, "ipam.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contents).To(MatchJSON(`{
+				"some-network": {
+					"last_reserved": "10.255.30.2",
+					"allocations": {
+						"10.255.30.2": {
+							"container_id": "container-1",
+							"if_name": "eth0",
+							"netns": "` + container.Netns + `"
+						}
+					}
+				}
+			}`))
+		})
+
+		It("returns the same IP when the interface already has one", func() {
+			container := owner("container-1")
+			first, err := allocator.Allocate(datastore, cfg, container)
+			Expect(err).NotTo(HaveOccurred())
+
+			second, err := allocator.Allocate(datastore, cfg, container)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second).To(Equal(first))
+		})
+
+		It("allocates round robin", func() {
+			ip, err := allocator.Allocate(datastore, cfg, owner("container-1"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ip.String()).To(Equal("10.255.30.2"))
+
+			Expect(allocator.Release(datastore, "some-network", "container-1", "eth0")).To(Succeed())
+
+			ip, err = allocator.Allocate(datastore, cfg, owner("container-2"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ip.String()).To(Equal("10.255.30.3"))
+		})
+
+		It("wraps around to released IPs at the end of the subnet", func() {
+			for _, id := range []string{"c-1", "c-2", "c-3", "c-4", "c-5"} {
+				_, err := allocator.Allocate(datastore, cfg, owner(id))
+				Expect(err).NotTo(HaveOccurred())
+			}
+			Expect(allocator.Release(datastore, "some-network", "c-2", "eth0")).To(Succeed())
+
+			ip, err := allocator.Allocate(datastore, cfg, owner("c-6"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ip.String()).To(Equal("10.255.30.3"))
+		})
+
+		Context("when the subnet is exhausted", func() {
+			It("returns an error", func() {
+				for _, id := range []string{"c-1", "c-2", "c-3", "c-4", "c-5"} {
+					_, err := allocator.Allocate(datastore, cfg, owner(id))
+					Expect(err).NotTo(HaveOccurred())
+				}
+
+				_, err := allocator.Allocate(datastore, cfg, owner("c-6"))
+				Expect(err).To(MatchError("no IP addresses available in 10.255.30.0/29"))
+			})
+		})
+
+		Context("when the network namespace of an allocation no longer exists", func() {
+			It("collects the allocation", func() {
+				for _, id := range []string{"c-1", "c-2", "c-3", "c-4", "c-5"} {
+					_, err := allocator.Allocate(datastore, cfg, owner(id))
+					Expect(err).NotTo(HaveOccurred())
+				}
+				Expect(os.Remove(filepath.Join(netnsDir, "c-3"))).To(Succeed())
+
+				ip, err := allocator.Allocate(datastore, cfg, owner("c-6"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ip.String()).To(Equal("10.255.30.4"))
+				Expect(logger).To(gbytes.Say(`test.collect-allocation.*"containerID":"c-3".*"ip":"10.255.30.4"`))
+			})
+		})
+
+		Context("when an IP is requested", func() {
+			BeforeEach(func() {
+				cfg.IPArgs = []net.IP{net.ParseIP("10.255.30.5")}
+			})
+
+			It("allocates it", func() {
+				ip, err := allocator.Allocate(datastore, cfg, owner("container-1"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ip.String()).To(Equal("10.255.30.5"))
+			})
+
+			It("moves an interface that has a different IP to it", func() {
+				container := owner("container-1")
+				cfg.IPArgs = nil
+				_, err := allocator.Allocate(datastore, cfg, container)
+				Expect(err).NotTo(HaveOccurred())
+
+				cfg.IPArgs = []net.IP{net.ParseIP("10.255.30.5")}
+				ip, err := allocator.Allocate(datastore, cfg, container)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ip.String()).To(Equal("10.255.30.5"))
+
+				cfg.IPArgs = nil
+				ip, err = allocator.Allocate(datastore, cfg, owner("container-2"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ip.String()).To(Equal("10.255.30.6"))
+			})
+
+			Context("when it is allocated to another container", func() {
+				It("returns an error", func() {
+					_, err := allocator.Allocate(datastore, cfg, owner("container-1"))
+					Expect(err).NotTo(HaveOccurred())
+
+					_, err = allocator.Allocate(datastore, cfg, owner("container-2"))
+					Expect(err).To(MatchError("requested ip 10.255.30.5 is already allocated to container container-1"))
+				})
+			})
+
+			Context("when it is not in the subnet", func() {
+				It("returns an error", func() {
+					cfg.IPArgs = []net.IP{net.ParseIP("10.255.31.5")}
+					_, err := allocator.Allocate(datastore, cfg, owner("container-1"))
+					Expect(err).To(MatchError("requested ip 10.255.31.5 is not in the range 10.255.30.2-10.255.30.6"))
+				})
+			})
+
+			Context("when it is the gateway", func() {
+				It("returns an error", func() {
+					cfg.IPArgs = []net.IP{net.ParseIP("10.255.30.1")}
+					_, err := allocator.Allocate(datastore, cfg, owner("container-1"))
+					Expect(err).To(MatchError("requested ip 10.255.30.1 is not in the range 10.255.30.2-10.255.30.6"))
+				})
+			})
+		})
+
+		Context("when the subnet is too small", func() {
+			It("returns an error", func() {
+				_, cfg.Subnet, _ = net.ParseCIDR("10.255.30.0/31")
+				_, err := allocator.Allocate(datastore, cfg, owner("container-1"))
+				Expect(err).To(MatchError("subnet 10.255.30.0/31 is too small"))
+			})
+		})
+
+		Context("when the subnet is not ipv4", func() {
+			It("returns an error", func() {
+				_, cfg.Subnet, _ = net.ParseCIDR("fd00::/64")
+				_, err := allocator.Allocate(datastore, cfg, owner("container-1"))
+				Expect(err).To(MatchError("subnet fd00::/64 is not ipv4"))
+			})
+		})
+
+		Context("when the datastore cannot be locked", func() {
+			BeforeEach(func() {
+				locker := &libfakes.FileLocker{}
+				locker.OpenReturns(nil, errors.New("potato"))
+				allocator.LockerNew = func(string) filelock.FileLocker { return locker }
+			})
+
+			It("returns an error", func() {
+				_, err := allocator.Allocate(datastore, cfg, owner("container-1"))
+				Expect(err).To(MatchError("open lock: potato"))
+			})
+		})
+
+		Context("when the allocations cannot be decoded", func() {
+			BeforeEach(func() {
+				Expect(os.WriteFile(filepath.Join(dataDir, "ipam.json"), []byte("{"), 0600)).To(Succeed())
+			})
+
+			It("returns an error", func() {
+				_, err := allocator.Allocate(datastore, cfg, owner("container-1"))
+				Expect(err).To(MatchError(HavePrefix("decoding allocations: ")))
+			})
+		})
+	})
+
+	Describe("Release", func() {
+		It("frees the IP of the interface", func() {
+			_, err := allocator.Allocate(datastore, cfg, owner("container-1"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(allocator.Release(datastore, "some-network", "container-1", "eth0")).To(Succeed())
+
+			contents, err := os.ReadFile(filepath.Join(dataDir, "ipam.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contents).NotTo(ContainSubstring("container-1"))
+		})
+
+		It("succeeds when the interface has no IP", func() {
+			Expect(allocator.Release(datastore, "some-network", "container-1", "eth0")).To(Succeed())
+		})
+
+		Context("when the datastore cannot be locked", func() {
+			It("returns an error", func() {
+				Expect(allocator.Release("", "some-network", "container-1", "eth0")).To(MatchError(HavePrefix("open lock: ")))
+			})
+		})
+	})
+
+	Describe("importing the allocations of host-local", func() {
+		var hostLocalDir string
+
+		BeforeEach(func() {
+			var err error
+			hostLocalDir, err = os.MkdirTemp("", "host-local-")
+			Expect(err).NotTo(HaveOccurred())
+			allocator.HostLocalDataDir = hostLocalDir
+
+			// host-local was run with dataDir <HostLocalDataDir>/ipam and
+			// keeps the allocations of each network in a directory below it
+			networkDir := filepath.Join(hostLocalDir, "ipam", "some-network")
+			Expect(os.MkdirAll(networkDir, 0755)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(networkDir, "10.255.30.2"), []byte("container-1\r\neth0"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(networkDir, "10.255.30.3"), []byte("container-2"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(networkDir, "last_reserved_ip.0"), []byte("10.255.30.3"), 0644)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(networkDir, "lock"), nil, 0644)).To(Succeed())
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(hostLocalDir)).To(Succeed())
+		})
+
+		It("does not give the imported IPs to other containers", func() {
+			ip, err := allocator.Allocate(datastore, cfg, owner("container-3"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ip.String()).To(Equal("10.255.30.4"))
+
+			Expect(logger).To(gbytes.Say("import-host-local-allocation.*container-1.*10.255.30.2"))
+		})
+
+		It("gives a container its imported IP", func() {
+			ip, err := allocator.Allocate(datastore, cfg, owner("container-1"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ip.String()).To(Equal("10.255.30.2"))
+		})
+
+		It("frees an imported IP on release", func() {
+			Expect(allocator.Release(datastore, "some-network", "container-1", "eth0")).To(Succeed())
+
+			contents, err := os.ReadFile(filepath.Join(dataDir, "ipam.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contents).NotTo(ContainSubstring("container-1"))
+			Expect(contents).To(ContainSubstring("container-2"))
+		})
+
+		It("only imports them while the network has no allocations of its own", func() {
+			Expect(allocator.Release(datastore, "some-network", "container-1", "eth0")).To(Succeed())
+			Expect(allocator.Release(datastore, "some-network", "container-2", "eth0")).To(Succeed())
+
+			ip, err := allocator.Allocate(datastore, cfg, owner("container-3"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ip.String()).To(Equal("10.255.30.4"))
+
+			contents, err := os.ReadFile(filepath.Join(dataDir, "ipam.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contents).NotTo(ContainSubstring("container-1"))
+		})
+
+		Context("when the allocations are directly below the data dir", func() {
+			BeforeEach(func() {
+				cfg.Name = "other-network"
+				networkDir := filepath.Join(hostLocalDir, "other-network")
+				Expect(os.Mkdir(networkDir, 0755)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(networkDir, "10.255.30.2"), []byte("container-1\r\neth0"), 0644)).To(Succeed())
+			})
+
+			It("does not import them, since host-local never kept them there", func() {
+				ip, err := allocator.Allocate(datastore, cfg, owner("container-3"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ip.String()).To(Equal("10.255.30.2"))
+			})
+		})
+
+		Context("when host-local has no allocations for the network", func() {
+			BeforeEach(func() {
+				cfg.Name = "other-network"
+			})
+
+			It("allocates from the start of the subnet", func() {
+				ip, err := allocator.Allocate(datastore, cfg, owner("container-3"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ip.String()).To(Equal("10.255.30.2"))
+			})
+		})
+
+		Context("when the allocations of host-local cannot be read", func() {
+			BeforeEach(func() {
+				Expect(os.WriteFile(filepath.Join(hostLocalDir, "ipam", "other-network"), nil, 0644)).To(Succeed())
+				cfg.Name = "other-network"
+			})
+
+			It("returns an error", func() {
+				_, err := allocator.Allocate(datastore, cfg, owner("container-3"))
+				Expect(err).To(MatchError(HavePrefix("import host-local allocations: ")))
+			})
+		})
+	})
+})
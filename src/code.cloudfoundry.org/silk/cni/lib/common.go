@@ -2,9 +2,12 @@ package lib
 
 import (
 	"fmt"
+	"net"
 
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/silk/cni/config"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 )
 
 // Common bevavior used by both the host-side and container-side Setup functions
@@ -73,3 +76,77 @@ func (s *Common) BasicSetup(deviceName string, local, peer config.DualAddress) e
 
 	return nil
 }
+
+// BasicCheck verifies that a veth device is still configured the way
+// BasicSetup left it. It is meant to be called by either Host.Check or
+// Container.Check
+func (s *Common) BasicCheck(deviceName string, local, peer config.DualAddress, mtu int) error {
+	s.Logger.Debug("basic-device-check", lager.Data{"deviceName": deviceName, "local": local.Hardware.String(), "peer": peer.Hardware.String()})
+	defer s.Logger.Debug("done")
+	link, err := s.NetlinkAdapter.LinkByName(deviceName)
+	if err != nil {
+		return fmt.Errorf("failed to find link %q: %s", deviceName, err)
+	}
+
+	if link.Type() != "veth" {
+		return fmt.Errorf("link %q has type %s, expected veth", deviceName, link.Type())
+	}
+
+	attrs := link.Attrs()
+	if attrs.HardwareAddr.String() != local.Hardware.String() {
+		return fmt.Errorf("link %q has hardware address %s, expected %s", deviceName, attrs.HardwareAddr, local.Hardware)
+	}
+
+	if attrs.Flags&net.FlagUp == 0 {
+		return fmt.Errorf("link %q is not up", deviceName)
+	}
+
+	if attrs.RawFlags&unix.IFF_NOARP == 0 {
+		return fmt.Errorf("link %q has ARP enabled", deviceName)
+	}
+
+	if mtu != 0 && attrs.MTU != mtu {
+		return fmt.Errorf("link %q has mtu %d, expected %d", deviceName, attrs.MTU, mtu)
+	}
+
+	addrs, err := s.NetlinkAdapter.AddrList(link, netlink.FAMILY_V4)
+	if err != nil {
+		return fmt.Errorf("listing addresses of link %q: %s", deviceName, err)
+	}
+	if !hasPointToPointAddress(addrs, local.IP, peer.IP) {
+		return fmt.Errorf("link %q is missing point to point address %s peer %s", deviceName, local.IP, peer.IP)
+	}
+
+	neighs, err := s.NetlinkAdapter.ARPList(attrs.Index)
+	if err != nil {
+		return fmt.Errorf("listing neighbors of link %q: %s", deviceName, err)
+	}
+	if !hasStaticNeighbor(neighs, peer.IP, peer.Hardware) {
+		return fmt.Errorf("link %q is missing permanent neighbor %s at %s", deviceName, peer.IP, peer.Hardware)
+	}
+
+	return nil
+}
+
+func hasPointToPointAddress(addrs []netlink.Addr, local, peer net.IP) bool {
+	for _, addr := range addrs {
+		if addr.IPNet == nil || addr.Peer == nil {
+			continue
+		}
+		if addr.IP.Equal(local) && addr.Peer.IP.Equal(peer) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasStaticNeighbor(neighs []netlink.Neigh, ip net.IP, hwAddr net.HardwareAddr) bool {
+	for _, neigh := range neighs {
+		if neigh.IP.Equal(ip) &&
+			neigh.HardwareAddr.String() == hwAddr.String() &&
+			neigh.State&netlink.NUD_PERMANENT != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -11,6 +11,7 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 )
 
 var _ = Describe("Common", func() {
@@ -219,4 +220,172 @@ var _ = Describe("Common", func() {
 		})
 
 	})
+
+	Describe("BasicCheck", func() {
+		var (
+			fakeNetlinkAdapter *fakes.NetlinkAdapter
+			fakeLink           *netlink.Veth
+			local              config.DualAddress
+			peer               config.DualAddress
+			common             *lib.Common
+		)
+		BeforeEach(func() {
+			localMAC, err := net.ParseMAC("aa:aa:12:34:56:78")
+			Expect(err).NotTo(HaveOccurred())
+			peerMAC, err := net.ParseMAC("ee:ee:12:34:56:78")
+			Expect(err).NotTo(HaveOccurred())
+			local = config.DualAddress{
+				IP:       net.IP{10, 255, 30, 4},
+				Hardware: localMAC,
+			}
+			peer = config.DualAddress{
+				IP:       net.IP{169, 254, 0, 1},
+				Hardware: peerMAC,
+			}
+
+			fakeNetlinkAdapter = &fakes.NetlinkAdapter{}
+			fakeLink = &netlink.Veth{
+				LinkAttrs: netlink.LinkAttrs{
+					Name:         "myDeviceName",
+					Index:        42,
+					MTU:          1472,
+					HardwareAddr: local.Hardware,
+					Flags:        net.FlagUp,
+					RawFlags:     unix.IFF_UP | unix.IFF_NOARP,
+				},
+			}
+			fakeNetlinkAdapter.LinkByNameReturns(fakeLink, nil)
+			fakeNetlinkAdapter.AddrListReturns([]netlink.Addr{{
+				IPNet: &net.IPNet{IP: local.IP, Mask: net.CIDRMask(32, 32)},
+				Peer:  &net.IPNet{IP: peer.IP, Mask: net.CIDRMask(32, 32)},
+			}}, nil)
+			fakeNetlinkAdapter.ARPListReturns([]netlink.Neigh{{
+				LinkIndex:    42,
+				IP:           peer.IP,
+				HardwareAddr: peer.Hardware,
+				State:        netlink.NUD_PERMANENT,
+			}}, nil)
+
+			common = &lib.Common{
+				NetlinkAdapter: fakeNetlinkAdapter,
+				LinkOperations: &fakes.LinkOperations{},
+				Logger:         lagertest.NewTestLogger("test"),
+			}
+		})
+
+		It("succeeds when the device is configured as expected", func() {
+			err := common.BasicCheck("myDeviceName", local, peer, 1472)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeNetlinkAdapter.LinkByNameArgsForCall(0)).To(Equal("myDeviceName"))
+			link, family := fakeNetlinkAdapter.AddrListArgsForCall(0)
+			Expect(link).To(Equal(fakeLink))
+			Expect(family).To(Equal(netlink.FAMILY_V4))
+			Expect(fakeNetlinkAdapter.ARPListArgsForCall(0)).To(Equal(42))
+		})
+
+		Context("when the link cannot be found", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.LinkByNameReturns(nil, errors.New("kiwi"))
+			})
+			It("returns a meaningful error", func() {
+				err := common.BasicCheck("myDeviceName", local, peer, 1472)
+				Expect(err).To(MatchError(`failed to find link "myDeviceName": kiwi`))
+			})
+		})
+
+		Context("when the link is not a veth device", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.LinkByNameReturns(&netlink.Bridge{LinkAttrs: fakeLink.LinkAttrs}, nil)
+			})
+			It("returns a meaningful error", func() {
+				err := common.BasicCheck("myDeviceName", local, peer, 1472)
+				Expect(err).To(MatchError(`link "myDeviceName" has type bridge, expected veth`))
+			})
+		})
+
+		Context("when the hardware address has changed", func() {
+			BeforeEach(func() {
+				fakeLink.HardwareAddr = net.HardwareAddr{0xbb, 0xbb, 0xbb, 0xbb, 0xbb, 0xbb}
+			})
+			It("returns a meaningful error", func() {
+				err := common.BasicCheck("myDeviceName", local, peer, 1472)
+				Expect(err).To(MatchError(`link "myDeviceName" has hardware address bb:bb:bb:bb:bb:bb, expected aa:aa:12:34:56:78`))
+			})
+		})
+
+		Context("when the link is down", func() {
+			BeforeEach(func() {
+				fakeLink.Flags = 0
+			})
+			It("returns a meaningful error", func() {
+				err := common.BasicCheck("myDeviceName", local, peer, 1472)
+				Expect(err).To(MatchError(`link "myDeviceName" is not up`))
+			})
+		})
+
+		Context("when ARP has been re-enabled", func() {
+			BeforeEach(func() {
+				fakeLink.RawFlags = unix.IFF_UP
+			})
+			It("returns a meaningful error", func() {
+				err := common.BasicCheck("myDeviceName", local, peer, 1472)
+				Expect(err).To(MatchError(`link "myDeviceName" has ARP enabled`))
+			})
+		})
+
+		Context("when the mtu has changed", func() {
+			It("returns a meaningful error", func() {
+				err := common.BasicCheck("myDeviceName", local, peer, 1400)
+				Expect(err).To(MatchError(`link "myDeviceName" has mtu 1472, expected 1400`))
+			})
+		})
+
+		Context("when listing addresses fails", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.AddrListReturns(nil, errors.New("papaya"))
+			})
+			It("returns a meaningful error", func() {
+				err := common.BasicCheck("myDeviceName", local, peer, 1472)
+				Expect(err).To(MatchError(`listing addresses of link "myDeviceName": papaya`))
+			})
+		})
+
+		Context("when the point to point address is missing", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.AddrListReturns([]netlink.Addr{{
+					IPNet: &net.IPNet{IP: local.IP, Mask: net.CIDRMask(32, 32)},
+				}}, nil)
+			})
+			It("returns a meaningful error", func() {
+				err := common.BasicCheck("myDeviceName", local, peer, 1472)
+				Expect(err).To(MatchError(`link "myDeviceName" is missing point to point address 10.255.30.4 peer 169.254.0.1`))
+			})
+		})
+
+		Context("when listing neighbors fails", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.ARPListReturns(nil, errors.New("mango"))
+			})
+			It("returns a meaningful error", func() {
+				err := common.BasicCheck("myDeviceName", local, peer, 1472)
+				Expect(err).To(MatchError(`listing neighbors of link "myDeviceName": mango`))
+			})
+		})
+
+		Context("when the static neighbor is no longer permanent", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.ARPListReturns([]netlink.Neigh{{
+					LinkIndex:    42,
+					IP:           peer.IP,
+					HardwareAddr: peer.Hardware,
+					State:        netlink.NUD_REACHABLE,
+				}}, nil)
+			})
+			It("returns a meaningful error", func() {
+				err := common.BasicCheck("myDeviceName", local, peer, 1472)
+				Expect(err).To(MatchError(`link "myDeviceName" is missing permanent neighbor 169.254.0.1 at ee:ee:12:34:56:78`))
+			})
+		})
+	})
 })
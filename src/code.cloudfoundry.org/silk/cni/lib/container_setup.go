@@ -48,10 +48,33 @@ func (c *Container) Setup(cfg *config.Config) error {
 			return fmt.Errorf("setting up device in container: %s", err)
 		}
 
-		if err := c.LinkOperations.RouteAddAll(cfg.Container.Routes, cfg.Container.Address.IP); err != nil {
+		if err := c.LinkOperations.RouteAddAll(deviceName, cfg.Container.Routes, cfg.Container.Address.IP, peer); err != nil {
 			return fmt.Errorf("adding route in container: %s", err)
 		}
 
 		return nil
 	})
 }
+
+// Check verifies that the network stack within the container is still
+// configured the way Setup left it.
+func (c *Container) Check(cfg *config.Config) error {
+	c.Logger.Debug("start")
+	defer c.Logger.Debug("done")
+	deviceName := cfg.Container.DeviceName
+
+	local := cfg.Container.Address
+	peer := cfg.Host.Address
+
+	return cfg.Container.Namespace.Do(func(_ ns.NetNS) error {
+		if err := c.Common.BasicCheck(deviceName, local, peer, cfg.Container.MTU); err != nil {
+			return fmt.Errorf("checking device in container: %s", err)
+		}
+
+		if err := c.LinkOperations.RouteCheckAll(deviceName, cfg.Container.Routes, cfg.Container.Address.IP); err != nil {
+			return fmt.Errorf("checking routes in container: %s", err)
+		}
+
+		return nil
+	})
+}
@@ -90,9 +90,11 @@ var _ = Describe("Container Setup", func() {
 
 			By("Adding all the routes")
 			Expect(fakeLinkOperations.RouteAddAllCallCount()).To(Equal(1))
-			routes, srcIP := fakeLinkOperations.RouteAddAllArgsForCall(0)
+			device, routes, srcIP, routePeer := fakeLinkOperations.RouteAddAllArgsForCall(0)
+			Expect(device).To(Equal("eth0"))
 			Expect(routes).To(Equal(cfg.Container.Routes))
 			Expect(srcIP).To(Equal(cfg.Container.Address.IP))
+			Expect(routePeer).To(Equal(hostAddr))
 		})
 
 		Context("when renaming the link fails", func() {
@@ -145,4 +147,51 @@ var _ = Describe("Container Setup", func() {
 			})
 		})
 	})
+
+	Describe("Check", func() {
+		BeforeEach(func() {
+			cfg.Container.MTU = 1472
+		})
+
+		It("calls basic check and checks the routes in the container namespace", func() {
+			err := containerSetup.Check(cfg)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(containerNS.DoCallCount()).To(Equal(1))
+			Expect(fakeLinkOperations.RenameLinkCallCount()).To(Equal(0))
+
+			Expect(fakeCommon.BasicCheckCallCount()).To(Equal(1))
+			device, local, peer, mtu := fakeCommon.BasicCheckArgsForCall(0)
+			Expect(device).To(Equal("eth0"))
+			Expect(local).To(Equal(containerAddr))
+			Expect(peer).To(Equal(hostAddr))
+			Expect(mtu).To(Equal(1472))
+
+			Expect(fakeLinkOperations.RouteCheckAllCallCount()).To(Equal(1))
+			device, routes, srcIP := fakeLinkOperations.RouteCheckAllArgsForCall(0)
+			Expect(device).To(Equal("eth0"))
+			Expect(routes).To(Equal(cfg.Container.Routes))
+			Expect(srcIP).To(Equal(cfg.Container.Address.IP))
+		})
+
+		Context("when the basic device check fails", func() {
+			BeforeEach(func() {
+				fakeCommon.BasicCheckReturns(errors.New("lettuce"))
+			})
+			It("returns a meaningful error", func() {
+				err := containerSetup.Check(cfg)
+				Expect(err).To(MatchError("checking device in container: lettuce"))
+			})
+		})
+
+		Context("when checking the routes fails", func() {
+			BeforeEach(func() {
+				fakeLinkOperations.RouteCheckAllReturns(errors.New("kale"))
+			})
+			It("returns a meaningful error", func() {
+				err := containerSetup.Check(cfg)
+				Expect(err).To(MatchError("checking routes in container: kale"))
+			})
+		})
+	})
 })
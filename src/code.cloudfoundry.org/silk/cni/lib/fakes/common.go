@@ -8,6 +8,20 @@ import (
 )
 
 type Common struct {
+	BasicCheckStub        func(string, config.DualAddress, config.DualAddress, int) error
+	basicCheckMutex       sync.RWMutex
+	basicCheckArgsForCall []struct {
+		arg1 string
+		arg2 config.DualAddress
+		arg3 config.DualAddress
+		arg4 int
+	}
+	basicCheckReturns struct {
+		result1 error
+	}
+	basicCheckReturnsOnCall map[int]struct {
+		result1 error
+	}
 	BasicSetupStub        func(string, config.DualAddress, config.DualAddress) error
 	basicSetupMutex       sync.RWMutex
 	basicSetupArgsForCall []struct {
@@ -25,6 +39,70 @@ type Common struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *Common) BasicCheck(arg1 string, arg2 config.DualAddress, arg3 config.DualAddress, arg4 int) error {
+	fake.basicCheckMutex.Lock()
+	ret, specificReturn := fake.basicCheckReturnsOnCall[len(fake.basicCheckArgsForCall)]
+	fake.basicCheckArgsForCall = append(fake.basicCheckArgsForCall, struct {
+		arg1 string
+		arg2 config.DualAddress
+		arg3 config.DualAddress
+		arg4 int
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.BasicCheckStub
+	fakeReturns := fake.basicCheckReturns
+	fake.recordInvocation("BasicCheck", []interface{}{arg1, arg2, arg3, arg4})
+	fake.basicCheckMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *Common) BasicCheckCallCount() int {
+	fake.basicCheckMutex.RLock()
+	defer fake.basicCheckMutex.RUnlock()
+	return len(fake.basicCheckArgsForCall)
+}
+
+func (fake *Common) BasicCheckCalls(stub func(string, config.DualAddress, config.DualAddress, int) error) {
+	fake.basicCheckMutex.Lock()
+	defer fake.basicCheckMutex.Unlock()
+	fake.BasicCheckStub = stub
+}
+
+func (fake *Common) BasicCheckArgsForCall(i int) (string, config.DualAddress, config.DualAddress, int) {
+	fake.basicCheckMutex.RLock()
+	defer fake.basicCheckMutex.RUnlock()
+	argsForCall := fake.basicCheckArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *Common) BasicCheckReturns(result1 error) {
+	fake.basicCheckMutex.Lock()
+	defer fake.basicCheckMutex.Unlock()
+	fake.BasicCheckStub = nil
+	fake.basicCheckReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *Common) BasicCheckReturnsOnCall(i int, result1 error) {
+	fake.basicCheckMutex.Lock()
+	defer fake.basicCheckMutex.Unlock()
+	fake.BasicCheckStub = nil
+	if fake.basicCheckReturnsOnCall == nil {
+		fake.basicCheckReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.basicCheckReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *Common) BasicSetup(arg1 string, arg2 config.DualAddress, arg3 config.DualAddress) error {
 	fake.basicSetupMutex.Lock()
 	ret, specificReturn := fake.basicSetupReturnsOnCall[len(fake.basicSetupArgsForCall)]
@@ -91,6 +169,8 @@ func (fake *Common) BasicSetupReturnsOnCall(i int, result1 error) {
 func (fake *Common) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.basicCheckMutex.RLock()
+	defer fake.basicCheckMutex.RUnlock()
 	fake.basicSetupMutex.RLock()
 	defer fake.basicSetupMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
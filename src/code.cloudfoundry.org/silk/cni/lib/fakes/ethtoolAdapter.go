@@ -0,0 +1,188 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+)
+
+type EthtoolAdapter struct {
+	ChangeStub        func(string, map[string]bool) error
+	changeMutex       sync.RWMutex
+	changeArgsForCall []struct {
+		arg1 string
+		arg2 map[string]bool
+	}
+	changeReturns struct {
+		result1 error
+	}
+	changeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	FeaturesStub        func(string) (map[string]bool, error)
+	featuresMutex       sync.RWMutex
+	featuresArgsForCall []struct {
+		arg1 string
+	}
+	featuresReturns struct {
+		result1 map[string]bool
+		result2 error
+	}
+	featuresReturnsOnCall map[int]struct {
+		result1 map[string]bool
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *EthtoolAdapter) Change(arg1 string, arg2 map[string]bool) error {
+	fake.changeMutex.Lock()
+	ret, specificReturn := fake.changeReturnsOnCall[len(fake.changeArgsForCall)]
+	fake.changeArgsForCall = append(fake.changeArgsForCall, struct {
+		arg1 string
+		arg2 map[string]bool
+	}{arg1, arg2})
+	stub := fake.ChangeStub
+	fakeReturns := fake.changeReturns
+	fake.recordInvocation("Change", []interface{}{arg1, arg2})
+	fake.changeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *EthtoolAdapter) ChangeCallCount() int {
+	fake.changeMutex.RLock()
+	defer fake.changeMutex.RUnlock()
+	return len(fake.changeArgsForCall)
+}
+
+func (fake *EthtoolAdapter) ChangeCalls(stub func(string, map[string]bool) error) {
+	fake.changeMutex.Lock()
+	defer fake.changeMutex.Unlock()
+	fake.ChangeStub = stub
+}
+
+func (fake *EthtoolAdapter) ChangeArgsForCall(i int) (string, map[string]bool) {
+	fake.changeMutex.RLock()
+	defer fake.changeMutex.RUnlock()
+	argsForCall := fake.changeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *EthtoolAdapter) ChangeReturns(result1 error) {
+	fake.changeMutex.Lock()
+	defer fake.changeMutex.Unlock()
+	fake.ChangeStub = nil
+	fake.changeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *EthtoolAdapter) ChangeReturnsOnCall(i int, result1 error) {
+	fake.changeMutex.Lock()
+	defer fake.changeMutex.Unlock()
+	fake.ChangeStub = nil
+	if fake.changeReturnsOnCall == nil {
+		fake.changeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.changeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *EthtoolAdapter) Features(arg1 string) (map[string]bool, error) {
+	fake.featuresMutex.Lock()
+	ret, specificReturn := fake.featuresReturnsOnCall[len(fake.featuresArgsForCall)]
+	fake.featuresArgsForCall = append(fake.featuresArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.FeaturesStub
+	fakeReturns := fake.featuresReturns
+	fake.recordInvocation("Features", []interface{}{arg1})
+	fake.featuresMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *EthtoolAdapter) FeaturesCallCount() int {
+	fake.featuresMutex.RLock()
+	defer fake.featuresMutex.RUnlock()
+	return len(fake.featuresArgsForCall)
+}
+
+func (fake *EthtoolAdapter) FeaturesCalls(stub func(string) (map[string]bool, error)) {
+	fake.featuresMutex.Lock()
+	defer fake.featuresMutex.Unlock()
+	fake.FeaturesStub = stub
+}
+
+func (fake *EthtoolAdapter) FeaturesArgsForCall(i int) string {
+	fake.featuresMutex.RLock()
+	defer fake.featuresMutex.RUnlock()
+	argsForCall := fake.featuresArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *EthtoolAdapter) FeaturesReturns(result1 map[string]bool, result2 error) {
+	fake.featuresMutex.Lock()
+	defer fake.featuresMutex.Unlock()
+	fake.FeaturesStub = nil
+	fake.featuresReturns = struct {
+		result1 map[string]bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *EthtoolAdapter) FeaturesReturnsOnCall(i int, result1 map[string]bool, result2 error) {
+	fake.featuresMutex.Lock()
+	defer fake.featuresMutex.Unlock()
+	fake.FeaturesStub = nil
+	if fake.featuresReturnsOnCall == nil {
+		fake.featuresReturnsOnCall = make(map[int]struct {
+			result1 map[string]bool
+			result2 error
+		})
+	}
+	fake.featuresReturnsOnCall[i] = struct {
+		result1 map[string]bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *EthtoolAdapter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.changeMutex.RLock()
+	defer fake.changeMutex.RUnlock()
+	fake.featuresMutex.RLock()
+	defer fake.featuresMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *EthtoolAdapter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
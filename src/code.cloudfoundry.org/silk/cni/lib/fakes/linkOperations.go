@@ -5,11 +5,23 @@ import (
 	"net"
 	"sync"
 
+	"code.cloudfoundry.org/silk/cni/config"
 	"github.com/containernetworking/cni/pkg/types"
 	"github.com/vishvananda/netlink"
 )
 
 type LinkOperations struct {
+	CheckGatewayStub        func(net.IP) error
+	checkGatewayMutex       sync.RWMutex
+	checkGatewayArgsForCall []struct {
+		arg1 net.IP
+	}
+	checkGatewayReturns struct {
+		result1 error
+	}
+	checkGatewayReturnsOnCall map[int]struct {
+		result1 error
+	}
 	DeleteLinkByNameStub        func(string) error
 	deleteLinkByNameMutex       sync.RWMutex
 	deleteLinkByNameArgsForCall []struct {
@@ -53,6 +65,45 @@ type LinkOperations struct {
 	enableReversePathFilteringReturnsOnCall map[int]struct {
 		result1 error
 	}
+	GatewayRouteAddAllStub        func(string, int, []*types.Route, net.IP) error
+	gatewayRouteAddAllMutex       sync.RWMutex
+	gatewayRouteAddAllArgsForCall []struct {
+		arg1 string
+		arg2 int
+		arg3 []*types.Route
+		arg4 net.IP
+	}
+	gatewayRouteAddAllReturns struct {
+		result1 error
+	}
+	gatewayRouteAddAllReturnsOnCall map[int]struct {
+		result1 error
+	}
+	GatewayRouteCheckAllStub        func(string, int, []*types.Route, net.IP) error
+	gatewayRouteCheckAllMutex       sync.RWMutex
+	gatewayRouteCheckAllArgsForCall []struct {
+		arg1 string
+		arg2 int
+		arg3 []*types.Route
+		arg4 net.IP
+	}
+	gatewayRouteCheckAllReturns struct {
+		result1 error
+	}
+	gatewayRouteCheckAllReturnsOnCall map[int]struct {
+		result1 error
+	}
+	GatewayRouteDelAllStub        func(int) error
+	gatewayRouteDelAllMutex       sync.RWMutex
+	gatewayRouteDelAllArgsForCall []struct {
+		arg1 int
+	}
+	gatewayRouteDelAllReturns struct {
+		result1 error
+	}
+	gatewayRouteDelAllReturnsOnCall map[int]struct {
+		result1 error
+	}
 	RenameLinkStub        func(string, string) error
 	renameLinkMutex       sync.RWMutex
 	renameLinkArgsForCall []struct {
@@ -65,11 +116,13 @@ type LinkOperations struct {
 	renameLinkReturnsOnCall map[int]struct {
 		result1 error
 	}
-	RouteAddAllStub        func([]*types.Route, net.IP) error
+	RouteAddAllStub        func(string, []*types.Route, net.IP, config.DualAddress) error
 	routeAddAllMutex       sync.RWMutex
 	routeAddAllArgsForCall []struct {
-		arg1 []*types.Route
-		arg2 net.IP
+		arg1 string
+		arg2 []*types.Route
+		arg3 net.IP
+		arg4 config.DualAddress
 	}
 	routeAddAllReturns struct {
 		result1 error
@@ -77,6 +130,19 @@ type LinkOperations struct {
 	routeAddAllReturnsOnCall map[int]struct {
 		result1 error
 	}
+	RouteCheckAllStub        func(string, []*types.Route, net.IP) error
+	routeCheckAllMutex       sync.RWMutex
+	routeCheckAllArgsForCall []struct {
+		arg1 string
+		arg2 []*types.Route
+		arg3 net.IP
+	}
+	routeCheckAllReturns struct {
+		result1 error
+	}
+	routeCheckAllReturnsOnCall map[int]struct {
+		result1 error
+	}
 	SetPointToPointAddressStub        func(netlink.Link, net.IP, net.IP) error
 	setPointToPointAddressMutex       sync.RWMutex
 	setPointToPointAddressArgsForCall []struct {
@@ -107,6 +173,67 @@ type LinkOperations struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *LinkOperations) CheckGateway(arg1 net.IP) error {
+	fake.checkGatewayMutex.Lock()
+	ret, specificReturn := fake.checkGatewayReturnsOnCall[len(fake.checkGatewayArgsForCall)]
+	fake.checkGatewayArgsForCall = append(fake.checkGatewayArgsForCall, struct {
+		arg1 net.IP
+	}{arg1})
+	stub := fake.CheckGatewayStub
+	fakeReturns := fake.checkGatewayReturns
+	fake.recordInvocation("CheckGateway", []interface{}{arg1})
+	fake.checkGatewayMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *LinkOperations) CheckGatewayCallCount() int {
+	fake.checkGatewayMutex.RLock()
+	defer fake.checkGatewayMutex.RUnlock()
+	return len(fake.checkGatewayArgsForCall)
+}
+
+func (fake *LinkOperations) CheckGatewayCalls(stub func(net.IP) error) {
+	fake.checkGatewayMutex.Lock()
+	defer fake.checkGatewayMutex.Unlock()
+	fake.CheckGatewayStub = stub
+}
+
+func (fake *LinkOperations) CheckGatewayArgsForCall(i int) net.IP {
+	fake.checkGatewayMutex.RLock()
+	defer fake.checkGatewayMutex.RUnlock()
+	argsForCall := fake.checkGatewayArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *LinkOperations) CheckGatewayReturns(result1 error) {
+	fake.checkGatewayMutex.Lock()
+	defer fake.checkGatewayMutex.Unlock()
+	fake.CheckGatewayStub = nil
+	fake.checkGatewayReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *LinkOperations) CheckGatewayReturnsOnCall(i int, result1 error) {
+	fake.checkGatewayMutex.Lock()
+	defer fake.checkGatewayMutex.Unlock()
+	fake.CheckGatewayStub = nil
+	if fake.checkGatewayReturnsOnCall == nil {
+		fake.checkGatewayReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.checkGatewayReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *LinkOperations) DeleteLinkByName(arg1 string) error {
 	fake.deleteLinkByNameMutex.Lock()
 	ret, specificReturn := fake.deleteLinkByNameReturnsOnCall[len(fake.deleteLinkByNameArgsForCall)]
@@ -343,6 +470,205 @@ func (fake *LinkOperations) EnableReversePathFilteringReturnsOnCall(i int, resul
 	}{result1}
 }
 
+func (fake *LinkOperations) GatewayRouteAddAll(arg1 string, arg2 int, arg3 []*types.Route, arg4 net.IP) error {
+	var arg3Copy []*types.Route
+	if arg3 != nil {
+		arg3Copy = make([]*types.Route, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.gatewayRouteAddAllMutex.Lock()
+	ret, specificReturn := fake.gatewayRouteAddAllReturnsOnCall[len(fake.gatewayRouteAddAllArgsForCall)]
+	fake.gatewayRouteAddAllArgsForCall = append(fake.gatewayRouteAddAllArgsForCall, struct {
+		arg1 string
+		arg2 int
+		arg3 []*types.Route
+		arg4 net.IP
+	}{arg1, arg2, arg3Copy, arg4})
+	stub := fake.GatewayRouteAddAllStub
+	fakeReturns := fake.gatewayRouteAddAllReturns
+	fake.recordInvocation("GatewayRouteAddAll", []interface{}{arg1, arg2, arg3Copy, arg4})
+	fake.gatewayRouteAddAllMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *LinkOperations) GatewayRouteAddAllCallCount() int {
+	fake.gatewayRouteAddAllMutex.RLock()
+	defer fake.gatewayRouteAddAllMutex.RUnlock()
+	return len(fake.gatewayRouteAddAllArgsForCall)
+}
+
+func (fake *LinkOperations) GatewayRouteAddAllCalls(stub func(string, int, []*types.Route, net.IP) error) {
+	fake.gatewayRouteAddAllMutex.Lock()
+	defer fake.gatewayRouteAddAllMutex.Unlock()
+	fake.GatewayRouteAddAllStub = stub
+}
+
+func (fake *LinkOperations) GatewayRouteAddAllArgsForCall(i int) (string, int, []*types.Route, net.IP) {
+	fake.gatewayRouteAddAllMutex.RLock()
+	defer fake.gatewayRouteAddAllMutex.RUnlock()
+	argsForCall := fake.gatewayRouteAddAllArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *LinkOperations) GatewayRouteAddAllReturns(result1 error) {
+	fake.gatewayRouteAddAllMutex.Lock()
+	defer fake.gatewayRouteAddAllMutex.Unlock()
+	fake.GatewayRouteAddAllStub = nil
+	fake.gatewayRouteAddAllReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *LinkOperations) GatewayRouteAddAllReturnsOnCall(i int, result1 error) {
+	fake.gatewayRouteAddAllMutex.Lock()
+	defer fake.gatewayRouteAddAllMutex.Unlock()
+	fake.GatewayRouteAddAllStub = nil
+	if fake.gatewayRouteAddAllReturnsOnCall == nil {
+		fake.gatewayRouteAddAllReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.gatewayRouteAddAllReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *LinkOperations) GatewayRouteCheckAll(arg1 string, arg2 int, arg3 []*types.Route, arg4 net.IP) error {
+	var arg3Copy []*types.Route
+	if arg3 != nil {
+		arg3Copy = make([]*types.Route, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.gatewayRouteCheckAllMutex.Lock()
+	ret, specificReturn := fake.gatewayRouteCheckAllReturnsOnCall[len(fake.gatewayRouteCheckAllArgsForCall)]
+	fake.gatewayRouteCheckAllArgsForCall = append(fake.gatewayRouteCheckAllArgsForCall, struct {
+		arg1 string
+		arg2 int
+		arg3 []*types.Route
+		arg4 net.IP
+	}{arg1, arg2, arg3Copy, arg4})
+	stub := fake.GatewayRouteCheckAllStub
+	fakeReturns := fake.gatewayRouteCheckAllReturns
+	fake.recordInvocation("GatewayRouteCheckAll", []interface{}{arg1, arg2, arg3Copy, arg4})
+	fake.gatewayRouteCheckAllMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *LinkOperations) GatewayRouteCheckAllCallCount() int {
+	fake.gatewayRouteCheckAllMutex.RLock()
+	defer fake.gatewayRouteCheckAllMutex.RUnlock()
+	return len(fake.gatewayRouteCheckAllArgsForCall)
+}
+
+func (fake *LinkOperations) GatewayRouteCheckAllCalls(stub func(string, int, []*types.Route, net.IP) error) {
+	fake.gatewayRouteCheckAllMutex.Lock()
+	defer fake.gatewayRouteCheckAllMutex.Unlock()
+	fake.GatewayRouteCheckAllStub = stub
+}
+
+func (fake *LinkOperations) GatewayRouteCheckAllArgsForCall(i int) (string, int, []*types.Route, net.IP) {
+	fake.gatewayRouteCheckAllMutex.RLock()
+	defer fake.gatewayRouteCheckAllMutex.RUnlock()
+	argsForCall := fake.gatewayRouteCheckAllArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *LinkOperations) GatewayRouteCheckAllReturns(result1 error) {
+	fake.gatewayRouteCheckAllMutex.Lock()
+	defer fake.gatewayRouteCheckAllMutex.Unlock()
+	fake.GatewayRouteCheckAllStub = nil
+	fake.gatewayRouteCheckAllReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *LinkOperations) GatewayRouteCheckAllReturnsOnCall(i int, result1 error) {
+	fake.gatewayRouteCheckAllMutex.Lock()
+	defer fake.gatewayRouteCheckAllMutex.Unlock()
+	fake.GatewayRouteCheckAllStub = nil
+	if fake.gatewayRouteCheckAllReturnsOnCall == nil {
+		fake.gatewayRouteCheckAllReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.gatewayRouteCheckAllReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *LinkOperations) GatewayRouteDelAll(arg1 int) error {
+	fake.gatewayRouteDelAllMutex.Lock()
+	ret, specificReturn := fake.gatewayRouteDelAllReturnsOnCall[len(fake.gatewayRouteDelAllArgsForCall)]
+	fake.gatewayRouteDelAllArgsForCall = append(fake.gatewayRouteDelAllArgsForCall, struct {
+		arg1 int
+	}{arg1})
+	stub := fake.GatewayRouteDelAllStub
+	fakeReturns := fake.gatewayRouteDelAllReturns
+	fake.recordInvocation("GatewayRouteDelAll", []interface{}{arg1})
+	fake.gatewayRouteDelAllMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *LinkOperations) GatewayRouteDelAllCallCount() int {
+	fake.gatewayRouteDelAllMutex.RLock()
+	defer fake.gatewayRouteDelAllMutex.RUnlock()
+	return len(fake.gatewayRouteDelAllArgsForCall)
+}
+
+func (fake *LinkOperations) GatewayRouteDelAllCalls(stub func(int) error) {
+	fake.gatewayRouteDelAllMutex.Lock()
+	defer fake.gatewayRouteDelAllMutex.Unlock()
+	fake.GatewayRouteDelAllStub = stub
+}
+
+func (fake *LinkOperations) GatewayRouteDelAllArgsForCall(i int) int {
+	fake.gatewayRouteDelAllMutex.RLock()
+	defer fake.gatewayRouteDelAllMutex.RUnlock()
+	argsForCall := fake.gatewayRouteDelAllArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *LinkOperations) GatewayRouteDelAllReturns(result1 error) {
+	fake.gatewayRouteDelAllMutex.Lock()
+	defer fake.gatewayRouteDelAllMutex.Unlock()
+	fake.GatewayRouteDelAllStub = nil
+	fake.gatewayRouteDelAllReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *LinkOperations) GatewayRouteDelAllReturnsOnCall(i int, result1 error) {
+	fake.gatewayRouteDelAllMutex.Lock()
+	defer fake.gatewayRouteDelAllMutex.Unlock()
+	fake.GatewayRouteDelAllStub = nil
+	if fake.gatewayRouteDelAllReturnsOnCall == nil {
+		fake.gatewayRouteDelAllReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.gatewayRouteDelAllReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *LinkOperations) RenameLink(arg1 string, arg2 string) error {
 	fake.renameLinkMutex.Lock()
 	ret, specificReturn := fake.renameLinkReturnsOnCall[len(fake.renameLinkArgsForCall)]
@@ -405,24 +731,26 @@ func (fake *LinkOperations) RenameLinkReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *LinkOperations) RouteAddAll(arg1 []*types.Route, arg2 net.IP) error {
-	var arg1Copy []*types.Route
-	if arg1 != nil {
-		arg1Copy = make([]*types.Route, len(arg1))
-		copy(arg1Copy, arg1)
+func (fake *LinkOperations) RouteAddAll(arg1 string, arg2 []*types.Route, arg3 net.IP, arg4 config.DualAddress) error {
+	var arg2Copy []*types.Route
+	if arg2 != nil {
+		arg2Copy = make([]*types.Route, len(arg2))
+		copy(arg2Copy, arg2)
 	}
 	fake.routeAddAllMutex.Lock()
 	ret, specificReturn := fake.routeAddAllReturnsOnCall[len(fake.routeAddAllArgsForCall)]
 	fake.routeAddAllArgsForCall = append(fake.routeAddAllArgsForCall, struct {
-		arg1 []*types.Route
-		arg2 net.IP
-	}{arg1Copy, arg2})
+		arg1 string
+		arg2 []*types.Route
+		arg3 net.IP
+		arg4 config.DualAddress
+	}{arg1, arg2Copy, arg3, arg4})
 	stub := fake.RouteAddAllStub
 	fakeReturns := fake.routeAddAllReturns
-	fake.recordInvocation("RouteAddAll", []interface{}{arg1Copy, arg2})
+	fake.recordInvocation("RouteAddAll", []interface{}{arg1, arg2Copy, arg3, arg4})
 	fake.routeAddAllMutex.Unlock()
 	if stub != nil {
-		return stub(arg1, arg2)
+		return stub(arg1, arg2, arg3, arg4)
 	}
 	if specificReturn {
 		return ret.result1
@@ -436,17 +764,17 @@ func (fake *LinkOperations) RouteAddAllCallCount() int {
 	return len(fake.routeAddAllArgsForCall)
 }
 
-func (fake *LinkOperations) RouteAddAllCalls(stub func([]*types.Route, net.IP) error) {
+func (fake *LinkOperations) RouteAddAllCalls(stub func(string, []*types.Route, net.IP, config.DualAddress) error) {
 	fake.routeAddAllMutex.Lock()
 	defer fake.routeAddAllMutex.Unlock()
 	fake.RouteAddAllStub = stub
 }
 
-func (fake *LinkOperations) RouteAddAllArgsForCall(i int) ([]*types.Route, net.IP) {
+func (fake *LinkOperations) RouteAddAllArgsForCall(i int) (string, []*types.Route, net.IP, config.DualAddress) {
 	fake.routeAddAllMutex.RLock()
 	defer fake.routeAddAllMutex.RUnlock()
 	argsForCall := fake.routeAddAllArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
 }
 
 func (fake *LinkOperations) RouteAddAllReturns(result1 error) {
@@ -472,6 +800,74 @@ func (fake *LinkOperations) RouteAddAllReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *LinkOperations) RouteCheckAll(arg1 string, arg2 []*types.Route, arg3 net.IP) error {
+	var arg2Copy []*types.Route
+	if arg2 != nil {
+		arg2Copy = make([]*types.Route, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.routeCheckAllMutex.Lock()
+	ret, specificReturn := fake.routeCheckAllReturnsOnCall[len(fake.routeCheckAllArgsForCall)]
+	fake.routeCheckAllArgsForCall = append(fake.routeCheckAllArgsForCall, struct {
+		arg1 string
+		arg2 []*types.Route
+		arg3 net.IP
+	}{arg1, arg2Copy, arg3})
+	stub := fake.RouteCheckAllStub
+	fakeReturns := fake.routeCheckAllReturns
+	fake.recordInvocation("RouteCheckAll", []interface{}{arg1, arg2Copy, arg3})
+	fake.routeCheckAllMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *LinkOperations) RouteCheckAllCallCount() int {
+	fake.routeCheckAllMutex.RLock()
+	defer fake.routeCheckAllMutex.RUnlock()
+	return len(fake.routeCheckAllArgsForCall)
+}
+
+func (fake *LinkOperations) RouteCheckAllCalls(stub func(string, []*types.Route, net.IP) error) {
+	fake.routeCheckAllMutex.Lock()
+	defer fake.routeCheckAllMutex.Unlock()
+	fake.RouteCheckAllStub = stub
+}
+
+func (fake *LinkOperations) RouteCheckAllArgsForCall(i int) (string, []*types.Route, net.IP) {
+	fake.routeCheckAllMutex.RLock()
+	defer fake.routeCheckAllMutex.RUnlock()
+	argsForCall := fake.routeCheckAllArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *LinkOperations) RouteCheckAllReturns(result1 error) {
+	fake.routeCheckAllMutex.Lock()
+	defer fake.routeCheckAllMutex.Unlock()
+	fake.RouteCheckAllStub = nil
+	fake.routeCheckAllReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *LinkOperations) RouteCheckAllReturnsOnCall(i int, result1 error) {
+	fake.routeCheckAllMutex.Lock()
+	defer fake.routeCheckAllMutex.Unlock()
+	fake.RouteCheckAllStub = nil
+	if fake.routeCheckAllReturnsOnCall == nil {
+		fake.routeCheckAllReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.routeCheckAllReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *LinkOperations) SetPointToPointAddress(arg1 netlink.Link, arg2 net.IP, arg3 net.IP) error {
 	fake.setPointToPointAddressMutex.Lock()
 	ret, specificReturn := fake.setPointToPointAddressReturnsOnCall[len(fake.setPointToPointAddressArgsForCall)]
@@ -601,6 +997,8 @@ func (fake *LinkOperations) StaticNeighborNoARPReturnsOnCall(i int, result1 erro
 func (fake *LinkOperations) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.checkGatewayMutex.RLock()
+	defer fake.checkGatewayMutex.RUnlock()
 	fake.deleteLinkByNameMutex.RLock()
 	defer fake.deleteLinkByNameMutex.RUnlock()
 	fake.disableIPv6Mutex.RLock()
@@ -609,10 +1007,18 @@ func (fake *LinkOperations) Invocations() map[string][][]interface{} {
 	defer fake.enableIPv4ForwardingMutex.RUnlock()
 	fake.enableReversePathFilteringMutex.RLock()
 	defer fake.enableReversePathFilteringMutex.RUnlock()
+	fake.gatewayRouteAddAllMutex.RLock()
+	defer fake.gatewayRouteAddAllMutex.RUnlock()
+	fake.gatewayRouteCheckAllMutex.RLock()
+	defer fake.gatewayRouteCheckAllMutex.RUnlock()
+	fake.gatewayRouteDelAllMutex.RLock()
+	defer fake.gatewayRouteDelAllMutex.RUnlock()
 	fake.renameLinkMutex.RLock()
 	defer fake.renameLinkMutex.RUnlock()
 	fake.routeAddAllMutex.RLock()
 	defer fake.routeAddAllMutex.RUnlock()
+	fake.routeCheckAllMutex.RLock()
+	defer fake.routeCheckAllMutex.RUnlock()
 	fake.setPointToPointAddressMutex.RLock()
 	defer fake.setPointToPointAddressMutex.RUnlock()
 	fake.staticNeighborNoARPMutex.RLock()
@@ -9,6 +9,19 @@ import (
 )
 
 type NetlinkAdapter struct {
+	ARPListStub        func(int) ([]netlink.Neigh, error)
+	aRPListMutex       sync.RWMutex
+	aRPListArgsForCall []struct {
+		arg1 int
+	}
+	aRPListReturns struct {
+		result1 []netlink.Neigh
+		result2 error
+	}
+	aRPListReturnsOnCall map[int]struct {
+		result1 []netlink.Neigh
+		result2 error
+	}
 	AddrAddScopeLinkStub        func(netlink.Link, *netlink.Addr) error
 	addrAddScopeLinkMutex       sync.RWMutex
 	addrAddScopeLinkArgsForCall []struct {
@@ -128,6 +141,18 @@ type NetlinkAdapter struct {
 	linkSetNsFdReturnsOnCall map[int]struct {
 		result1 error
 	}
+	LinkSetTxQLenStub        func(netlink.Link, int) error
+	linkSetTxQLenMutex       sync.RWMutex
+	linkSetTxQLenArgsForCall []struct {
+		arg1 netlink.Link
+		arg2 int
+	}
+	linkSetTxQLenReturns struct {
+		result1 error
+	}
+	linkSetTxQLenReturnsOnCall map[int]struct {
+		result1 error
+	}
 	LinkSetUpStub        func(netlink.Link) error
 	linkSetUpMutex       sync.RWMutex
 	linkSetUpArgsForCall []struct {
@@ -187,6 +212,96 @@ type NetlinkAdapter struct {
 	routeAddReturnsOnCall map[int]struct {
 		result1 error
 	}
+	RouteDelStub        func(*netlink.Route) error
+	routeDelMutex       sync.RWMutex
+	routeDelArgsForCall []struct {
+		arg1 *netlink.Route
+	}
+	routeDelReturns struct {
+		result1 error
+	}
+	routeDelReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RouteGetStub        func(net.IP) ([]netlink.Route, error)
+	routeGetMutex       sync.RWMutex
+	routeGetArgsForCall []struct {
+		arg1 net.IP
+	}
+	routeGetReturns struct {
+		result1 []netlink.Route
+		result2 error
+	}
+	routeGetReturnsOnCall map[int]struct {
+		result1 []netlink.Route
+		result2 error
+	}
+	RouteListStub        func(netlink.Link, int) ([]netlink.Route, error)
+	routeListMutex       sync.RWMutex
+	routeListArgsForCall []struct {
+		arg1 netlink.Link
+		arg2 int
+	}
+	routeListReturns struct {
+		result1 []netlink.Route
+		result2 error
+	}
+	routeListReturnsOnCall map[int]struct {
+		result1 []netlink.Route
+		result2 error
+	}
+	RouteListFilteredStub        func(int, *netlink.Route, uint64) ([]netlink.Route, error)
+	routeListFilteredMutex       sync.RWMutex
+	routeListFilteredArgsForCall []struct {
+		arg1 int
+		arg2 *netlink.Route
+		arg3 uint64
+	}
+	routeListFilteredReturns struct {
+		result1 []netlink.Route
+		result2 error
+	}
+	routeListFilteredReturnsOnCall map[int]struct {
+		result1 []netlink.Route
+		result2 error
+	}
+	RuleAddStub        func(*netlink.Rule) error
+	ruleAddMutex       sync.RWMutex
+	ruleAddArgsForCall []struct {
+		arg1 *netlink.Rule
+	}
+	ruleAddReturns struct {
+		result1 error
+	}
+	ruleAddReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RuleDelStub        func(*netlink.Rule) error
+	ruleDelMutex       sync.RWMutex
+	ruleDelArgsForCall []struct {
+		arg1 *netlink.Rule
+	}
+	ruleDelReturns struct {
+		result1 error
+	}
+	ruleDelReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RuleListFilteredStub        func(int, *netlink.Rule, uint64) ([]netlink.Rule, error)
+	ruleListFilteredMutex       sync.RWMutex
+	ruleListFilteredArgsForCall []struct {
+		arg1 int
+		arg2 *netlink.Rule
+		arg3 uint64
+	}
+	ruleListFilteredReturns struct {
+		result1 []netlink.Rule
+		result2 error
+	}
+	ruleListFilteredReturnsOnCall map[int]struct {
+		result1 []netlink.Rule
+		result2 error
+	}
 	TickInUsecStub        func() float64
 	tickInUsecMutex       sync.RWMutex
 	tickInUsecArgsForCall []struct {
@@ -201,6 +316,70 @@ type NetlinkAdapter struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *NetlinkAdapter) ARPList(arg1 int) ([]netlink.Neigh, error) {
+	fake.aRPListMutex.Lock()
+	ret, specificReturn := fake.aRPListReturnsOnCall[len(fake.aRPListArgsForCall)]
+	fake.aRPListArgsForCall = append(fake.aRPListArgsForCall, struct {
+		arg1 int
+	}{arg1})
+	stub := fake.ARPListStub
+	fakeReturns := fake.aRPListReturns
+	fake.recordInvocation("ARPList", []interface{}{arg1})
+	fake.aRPListMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *NetlinkAdapter) ARPListCallCount() int {
+	fake.aRPListMutex.RLock()
+	defer fake.aRPListMutex.RUnlock()
+	return len(fake.aRPListArgsForCall)
+}
+
+func (fake *NetlinkAdapter) ARPListCalls(stub func(int) ([]netlink.Neigh, error)) {
+	fake.aRPListMutex.Lock()
+	defer fake.aRPListMutex.Unlock()
+	fake.ARPListStub = stub
+}
+
+func (fake *NetlinkAdapter) ARPListArgsForCall(i int) int {
+	fake.aRPListMutex.RLock()
+	defer fake.aRPListMutex.RUnlock()
+	argsForCall := fake.aRPListArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *NetlinkAdapter) ARPListReturns(result1 []netlink.Neigh, result2 error) {
+	fake.aRPListMutex.Lock()
+	defer fake.aRPListMutex.Unlock()
+	fake.ARPListStub = nil
+	fake.aRPListReturns = struct {
+		result1 []netlink.Neigh
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *NetlinkAdapter) ARPListReturnsOnCall(i int, result1 []netlink.Neigh, result2 error) {
+	fake.aRPListMutex.Lock()
+	defer fake.aRPListMutex.Unlock()
+	fake.ARPListStub = nil
+	if fake.aRPListReturnsOnCall == nil {
+		fake.aRPListReturnsOnCall = make(map[int]struct {
+			result1 []netlink.Neigh
+			result2 error
+		})
+	}
+	fake.aRPListReturnsOnCall[i] = struct {
+		result1 []netlink.Neigh
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *NetlinkAdapter) AddrAddScopeLink(arg1 netlink.Link, arg2 *netlink.Addr) error {
 	fake.addrAddScopeLinkMutex.Lock()
 	ret, specificReturn := fake.addrAddScopeLinkReturnsOnCall[len(fake.addrAddScopeLinkArgsForCall)]
@@ -822,6 +1001,68 @@ func (fake *NetlinkAdapter) LinkSetNsFdReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *NetlinkAdapter) LinkSetTxQLen(arg1 netlink.Link, arg2 int) error {
+	fake.linkSetTxQLenMutex.Lock()
+	ret, specificReturn := fake.linkSetTxQLenReturnsOnCall[len(fake.linkSetTxQLenArgsForCall)]
+	fake.linkSetTxQLenArgsForCall = append(fake.linkSetTxQLenArgsForCall, struct {
+		arg1 netlink.Link
+		arg2 int
+	}{arg1, arg2})
+	stub := fake.LinkSetTxQLenStub
+	fakeReturns := fake.linkSetTxQLenReturns
+	fake.recordInvocation("LinkSetTxQLen", []interface{}{arg1, arg2})
+	fake.linkSetTxQLenMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *NetlinkAdapter) LinkSetTxQLenCallCount() int {
+	fake.linkSetTxQLenMutex.RLock()
+	defer fake.linkSetTxQLenMutex.RUnlock()
+	return len(fake.linkSetTxQLenArgsForCall)
+}
+
+func (fake *NetlinkAdapter) LinkSetTxQLenCalls(stub func(netlink.Link, int) error) {
+	fake.linkSetTxQLenMutex.Lock()
+	defer fake.linkSetTxQLenMutex.Unlock()
+	fake.LinkSetTxQLenStub = stub
+}
+
+func (fake *NetlinkAdapter) LinkSetTxQLenArgsForCall(i int) (netlink.Link, int) {
+	fake.linkSetTxQLenMutex.RLock()
+	defer fake.linkSetTxQLenMutex.RUnlock()
+	argsForCall := fake.linkSetTxQLenArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *NetlinkAdapter) LinkSetTxQLenReturns(result1 error) {
+	fake.linkSetTxQLenMutex.Lock()
+	defer fake.linkSetTxQLenMutex.Unlock()
+	fake.LinkSetTxQLenStub = nil
+	fake.linkSetTxQLenReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *NetlinkAdapter) LinkSetTxQLenReturnsOnCall(i int, result1 error) {
+	fake.linkSetTxQLenMutex.Lock()
+	defer fake.linkSetTxQLenMutex.Unlock()
+	fake.LinkSetTxQLenStub = nil
+	if fake.linkSetTxQLenReturnsOnCall == nil {
+		fake.linkSetTxQLenReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.linkSetTxQLenReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *NetlinkAdapter) LinkSetUp(arg1 netlink.Link) error {
 	fake.linkSetUpMutex.Lock()
 	ret, specificReturn := fake.linkSetUpReturnsOnCall[len(fake.linkSetUpArgsForCall)]
@@ -1132,6 +1373,450 @@ func (fake *NetlinkAdapter) RouteAddReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *NetlinkAdapter) RouteDel(arg1 *netlink.Route) error {
+	fake.routeDelMutex.Lock()
+	ret, specificReturn := fake.routeDelReturnsOnCall[len(fake.routeDelArgsForCall)]
+	fake.routeDelArgsForCall = append(fake.routeDelArgsForCall, struct {
+		arg1 *netlink.Route
+	}{arg1})
+	stub := fake.RouteDelStub
+	fakeReturns := fake.routeDelReturns
+	fake.recordInvocation("RouteDel", []interface{}{arg1})
+	fake.routeDelMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *NetlinkAdapter) RouteDelCallCount() int {
+	fake.routeDelMutex.RLock()
+	defer fake.routeDelMutex.RUnlock()
+	return len(fake.routeDelArgsForCall)
+}
+
+func (fake *NetlinkAdapter) RouteDelCalls(stub func(*netlink.Route) error) {
+	fake.routeDelMutex.Lock()
+	defer fake.routeDelMutex.Unlock()
+	fake.RouteDelStub = stub
+}
+
+func (fake *NetlinkAdapter) RouteDelArgsForCall(i int) *netlink.Route {
+	fake.routeDelMutex.RLock()
+	defer fake.routeDelMutex.RUnlock()
+	argsForCall := fake.routeDelArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *NetlinkAdapter) RouteDelReturns(result1 error) {
+	fake.routeDelMutex.Lock()
+	defer fake.routeDelMutex.Unlock()
+	fake.RouteDelStub = nil
+	fake.routeDelReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *NetlinkAdapter) RouteDelReturnsOnCall(i int, result1 error) {
+	fake.routeDelMutex.Lock()
+	defer fake.routeDelMutex.Unlock()
+	fake.RouteDelStub = nil
+	if fake.routeDelReturnsOnCall == nil {
+		fake.routeDelReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.routeDelReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *NetlinkAdapter) RouteGet(arg1 net.IP) ([]netlink.Route, error) {
+	fake.routeGetMutex.Lock()
+	ret, specificReturn := fake.routeGetReturnsOnCall[len(fake.routeGetArgsForCall)]
+	fake.routeGetArgsForCall = append(fake.routeGetArgsForCall, struct {
+		arg1 net.IP
+	}{arg1})
+	stub := fake.RouteGetStub
+	fakeReturns := fake.routeGetReturns
+	fake.recordInvocation("RouteGet", []interface{}{arg1})
+	fake.routeGetMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *NetlinkAdapter) RouteGetCallCount() int {
+	fake.routeGetMutex.RLock()
+	defer fake.routeGetMutex.RUnlock()
+	return len(fake.routeGetArgsForCall)
+}
+
+func (fake *NetlinkAdapter) RouteGetCalls(stub func(net.IP) ([]netlink.Route, error)) {
+	fake.routeGetMutex.Lock()
+	defer fake.routeGetMutex.Unlock()
+	fake.RouteGetStub = stub
+}
+
+func (fake *NetlinkAdapter) RouteGetArgsForCall(i int) net.IP {
+	fake.routeGetMutex.RLock()
+	defer fake.routeGetMutex.RUnlock()
+	argsForCall := fake.routeGetArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *NetlinkAdapter) RouteGetReturns(result1 []netlink.Route, result2 error) {
+	fake.routeGetMutex.Lock()
+	defer fake.routeGetMutex.Unlock()
+	fake.RouteGetStub = nil
+	fake.routeGetReturns = struct {
+		result1 []netlink.Route
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *NetlinkAdapter) RouteGetReturnsOnCall(i int, result1 []netlink.Route, result2 error) {
+	fake.routeGetMutex.Lock()
+	defer fake.routeGetMutex.Unlock()
+	fake.RouteGetStub = nil
+	if fake.routeGetReturnsOnCall == nil {
+		fake.routeGetReturnsOnCall = make(map[int]struct {
+			result1 []netlink.Route
+			result2 error
+		})
+	}
+	fake.routeGetReturnsOnCall[i] = struct {
+		result1 []netlink.Route
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *NetlinkAdapter) RouteList(arg1 netlink.Link, arg2 int) ([]netlink.Route, error) {
+	fake.routeListMutex.Lock()
+	ret, specificReturn := fake.routeListReturnsOnCall[len(fake.routeListArgsForCall)]
+	fake.routeListArgsForCall = append(fake.routeListArgsForCall, struct {
+		arg1 netlink.Link
+		arg2 int
+	}{arg1, arg2})
+	stub := fake.RouteListStub
+	fakeReturns := fake.routeListReturns
+	fake.recordInvocation("RouteList", []interface{}{arg1, arg2})
+	fake.routeListMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *NetlinkAdapter) RouteListCallCount() int {
+	fake.routeListMutex.RLock()
+	defer fake.routeListMutex.RUnlock()
+	return len(fake.routeListArgsForCall)
+}
+
+func (fake *NetlinkAdapter) RouteListCalls(stub func(netlink.Link, int) ([]netlink.Route, error)) {
+	fake.routeListMutex.Lock()
+	defer fake.routeListMutex.Unlock()
+	fake.RouteListStub = stub
+}
+
+func (fake *NetlinkAdapter) RouteListArgsForCall(i int) (netlink.Link, int) {
+	fake.routeListMutex.RLock()
+	defer fake.routeListMutex.RUnlock()
+	argsForCall := fake.routeListArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *NetlinkAdapter) RouteListReturns(result1 []netlink.Route, result2 error) {
+	fake.routeListMutex.Lock()
+	defer fake.routeListMutex.Unlock()
+	fake.RouteListStub = nil
+	fake.routeListReturns = struct {
+		result1 []netlink.Route
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *NetlinkAdapter) RouteListReturnsOnCall(i int, result1 []netlink.Route, result2 error) {
+	fake.routeListMutex.Lock()
+	defer fake.routeListMutex.Unlock()
+	fake.RouteListStub = nil
+	if fake.routeListReturnsOnCall == nil {
+		fake.routeListReturnsOnCall = make(map[int]struct {
+			result1 []netlink.Route
+			result2 error
+		})
+	}
+	fake.routeListReturnsOnCall[i] = struct {
+		result1 []netlink.Route
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *NetlinkAdapter) RouteListFiltered(arg1 int, arg2 *netlink.Route, arg3 uint64) ([]netlink.Route, error) {
+	fake.routeListFilteredMutex.Lock()
+	ret, specificReturn := fake.routeListFilteredReturnsOnCall[len(fake.routeListFilteredArgsForCall)]
+	fake.routeListFilteredArgsForCall = append(fake.routeListFilteredArgsForCall, struct {
+		arg1 int
+		arg2 *netlink.Route
+		arg3 uint64
+	}{arg1, arg2, arg3})
+	stub := fake.RouteListFilteredStub
+	fakeReturns := fake.routeListFilteredReturns
+	fake.recordInvocation("RouteListFiltered", []interface{}{arg1, arg2, arg3})
+	fake.routeListFilteredMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *NetlinkAdapter) RouteListFilteredCallCount() int {
+	fake.routeListFilteredMutex.RLock()
+	defer fake.routeListFilteredMutex.RUnlock()
+	return len(fake.routeListFilteredArgsForCall)
+}
+
+func (fake *NetlinkAdapter) RouteListFilteredCalls(stub func(int, *netlink.Route, uint64) ([]netlink.Route, error)) {
+	fake.routeListFilteredMutex.Lock()
+	defer fake.routeListFilteredMutex.Unlock()
+	fake.RouteListFilteredStub = stub
+}
+
+func (fake *NetlinkAdapter) RouteListFilteredArgsForCall(i int) (int, *netlink.Route, uint64) {
+	fake.routeListFilteredMutex.RLock()
+	defer fake.routeListFilteredMutex.RUnlock()
+	argsForCall := fake.routeListFilteredArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *NetlinkAdapter) RouteListFilteredReturns(result1 []netlink.Route, result2 error) {
+	fake.routeListFilteredMutex.Lock()
+	defer fake.routeListFilteredMutex.Unlock()
+	fake.RouteListFilteredStub = nil
+	fake.routeListFilteredReturns = struct {
+		result1 []netlink.Route
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *NetlinkAdapter) RouteListFilteredReturnsOnCall(i int, result1 []netlink.Route, result2 error) {
+	fake.routeListFilteredMutex.Lock()
+	defer fake.routeListFilteredMutex.Unlock()
+	fake.RouteListFilteredStub = nil
+	if fake.routeListFilteredReturnsOnCall == nil {
+		fake.routeListFilteredReturnsOnCall = make(map[int]struct {
+			result1 []netlink.Route
+			result2 error
+		})
+	}
+	fake.routeListFilteredReturnsOnCall[i] = struct {
+		result1 []netlink.Route
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *NetlinkAdapter) RuleAdd(arg1 *netlink.Rule) error {
+	fake.ruleAddMutex.Lock()
+	ret, specificReturn := fake.ruleAddReturnsOnCall[len(fake.ruleAddArgsForCall)]
+	fake.ruleAddArgsForCall = append(fake.ruleAddArgsForCall, struct {
+		arg1 *netlink.Rule
+	}{arg1})
+	stub := fake.RuleAddStub
+	fakeReturns := fake.ruleAddReturns
+	fake.recordInvocation("RuleAdd", []interface{}{arg1})
+	fake.ruleAddMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *NetlinkAdapter) RuleAddCallCount() int {
+	fake.ruleAddMutex.RLock()
+	defer fake.ruleAddMutex.RUnlock()
+	return len(fake.ruleAddArgsForCall)
+}
+
+func (fake *NetlinkAdapter) RuleAddCalls(stub func(*netlink.Rule) error) {
+	fake.ruleAddMutex.Lock()
+	defer fake.ruleAddMutex.Unlock()
+	fake.RuleAddStub = stub
+}
+
+func (fake *NetlinkAdapter) RuleAddArgsForCall(i int) *netlink.Rule {
+	fake.ruleAddMutex.RLock()
+	defer fake.ruleAddMutex.RUnlock()
+	argsForCall := fake.ruleAddArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *NetlinkAdapter) RuleAddReturns(result1 error) {
+	fake.ruleAddMutex.Lock()
+	defer fake.ruleAddMutex.Unlock()
+	fake.RuleAddStub = nil
+	fake.ruleAddReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *NetlinkAdapter) RuleAddReturnsOnCall(i int, result1 error) {
+	fake.ruleAddMutex.Lock()
+	defer fake.ruleAddMutex.Unlock()
+	fake.RuleAddStub = nil
+	if fake.ruleAddReturnsOnCall == nil {
+		fake.ruleAddReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.ruleAddReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *NetlinkAdapter) RuleDel(arg1 *netlink.Rule) error {
+	fake.ruleDelMutex.Lock()
+	ret, specificReturn := fake.ruleDelReturnsOnCall[len(fake.ruleDelArgsForCall)]
+	fake.ruleDelArgsForCall = append(fake.ruleDelArgsForCall, struct {
+		arg1 *netlink.Rule
+	}{arg1})
+	stub := fake.RuleDelStub
+	fakeReturns := fake.ruleDelReturns
+	fake.recordInvocation("RuleDel", []interface{}{arg1})
+	fake.ruleDelMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *NetlinkAdapter) RuleDelCallCount() int {
+	fake.ruleDelMutex.RLock()
+	defer fake.ruleDelMutex.RUnlock()
+	return len(fake.ruleDelArgsForCall)
+}
+
+func (fake *NetlinkAdapter) RuleDelCalls(stub func(*netlink.Rule) error) {
+	fake.ruleDelMutex.Lock()
+	defer fake.ruleDelMutex.Unlock()
+	fake.RuleDelStub = stub
+}
+
+func (fake *NetlinkAdapter) RuleDelArgsForCall(i int) *netlink.Rule {
+	fake.ruleDelMutex.RLock()
+	defer fake.ruleDelMutex.RUnlock()
+	argsForCall := fake.ruleDelArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *NetlinkAdapter) RuleDelReturns(result1 error) {
+	fake.ruleDelMutex.Lock()
+	defer fake.ruleDelMutex.Unlock()
+	fake.RuleDelStub = nil
+	fake.ruleDelReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *NetlinkAdapter) RuleDelReturnsOnCall(i int, result1 error) {
+	fake.ruleDelMutex.Lock()
+	defer fake.ruleDelMutex.Unlock()
+	fake.RuleDelStub = nil
+	if fake.ruleDelReturnsOnCall == nil {
+		fake.ruleDelReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.ruleDelReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *NetlinkAdapter) RuleListFiltered(arg1 int, arg2 *netlink.Rule, arg3 uint64) ([]netlink.Rule, error) {
+	fake.ruleListFilteredMutex.Lock()
+	ret, specificReturn := fake.ruleListFilteredReturnsOnCall[len(fake.ruleListFilteredArgsForCall)]
+	fake.ruleListFilteredArgsForCall = append(fake.ruleListFilteredArgsForCall, struct {
+		arg1 int
+		arg2 *netlink.Rule
+		arg3 uint64
+	}{arg1, arg2, arg3})
+	stub := fake.RuleListFilteredStub
+	fakeReturns := fake.ruleListFilteredReturns
+	fake.recordInvocation("RuleListFiltered", []interface{}{arg1, arg2, arg3})
+	fake.ruleListFilteredMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *NetlinkAdapter) RuleListFilteredCallCount() int {
+	fake.ruleListFilteredMutex.RLock()
+	defer fake.ruleListFilteredMutex.RUnlock()
+	return len(fake.ruleListFilteredArgsForCall)
+}
+
+func (fake *NetlinkAdapter) RuleListFilteredCalls(stub func(int, *netlink.Rule, uint64) ([]netlink.Rule, error)) {
+	fake.ruleListFilteredMutex.Lock()
+	defer fake.ruleListFilteredMutex.Unlock()
+	fake.RuleListFilteredStub = stub
+}
+
+func (fake *NetlinkAdapter) RuleListFilteredArgsForCall(i int) (int, *netlink.Rule, uint64) {
+	fake.ruleListFilteredMutex.RLock()
+	defer fake.ruleListFilteredMutex.RUnlock()
+	argsForCall := fake.ruleListFilteredArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *NetlinkAdapter) RuleListFilteredReturns(result1 []netlink.Rule, result2 error) {
+	fake.ruleListFilteredMutex.Lock()
+	defer fake.ruleListFilteredMutex.Unlock()
+	fake.RuleListFilteredStub = nil
+	fake.ruleListFilteredReturns = struct {
+		result1 []netlink.Rule
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *NetlinkAdapter) RuleListFilteredReturnsOnCall(i int, result1 []netlink.Rule, result2 error) {
+	fake.ruleListFilteredMutex.Lock()
+	defer fake.ruleListFilteredMutex.Unlock()
+	fake.RuleListFilteredStub = nil
+	if fake.ruleListFilteredReturnsOnCall == nil {
+		fake.ruleListFilteredReturnsOnCall = make(map[int]struct {
+			result1 []netlink.Rule
+			result2 error
+		})
+	}
+	fake.ruleListFilteredReturnsOnCall[i] = struct {
+		result1 []netlink.Rule
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *NetlinkAdapter) TickInUsec() float64 {
 	fake.tickInUsecMutex.Lock()
 	ret, specificReturn := fake.tickInUsecReturnsOnCall[len(fake.tickInUsecArgsForCall)]
@@ -1188,6 +1873,8 @@ func (fake *NetlinkAdapter) TickInUsecReturnsOnCall(i int, result1 float64) {
 func (fake *NetlinkAdapter) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.aRPListMutex.RLock()
+	defer fake.aRPListMutex.RUnlock()
 	fake.addrAddScopeLinkMutex.RLock()
 	defer fake.addrAddScopeLinkMutex.RUnlock()
 	fake.addrListMutex.RLock()
@@ -1208,6 +1895,8 @@ func (fake *NetlinkAdapter) Invocations() map[string][][]interface{} {
 	defer fake.linkSetNameMutex.RUnlock()
 	fake.linkSetNsFdMutex.RLock()
 	defer fake.linkSetNsFdMutex.RUnlock()
+	fake.linkSetTxQLenMutex.RLock()
+	defer fake.linkSetTxQLenMutex.RUnlock()
 	fake.linkSetUpMutex.RLock()
 	defer fake.linkSetUpMutex.RUnlock()
 	fake.neighAddPermanentIPv4Mutex.RLock()
@@ -1218,6 +1907,20 @@ func (fake *NetlinkAdapter) Invocations() map[string][][]interface{} {
 	defer fake.qdiscAddMutex.RUnlock()
 	fake.routeAddMutex.RLock()
 	defer fake.routeAddMutex.RUnlock()
+	fake.routeDelMutex.RLock()
+	defer fake.routeDelMutex.RUnlock()
+	fake.routeGetMutex.RLock()
+	defer fake.routeGetMutex.RUnlock()
+	fake.routeListMutex.RLock()
+	defer fake.routeListMutex.RUnlock()
+	fake.routeListFilteredMutex.RLock()
+	defer fake.routeListFilteredMutex.RUnlock()
+	fake.ruleAddMutex.RLock()
+	defer fake.ruleAddMutex.RUnlock()
+	fake.ruleDelMutex.RLock()
+	defer fake.ruleDelMutex.RUnlock()
+	fake.ruleListFilteredMutex.RLock()
+	defer fake.ruleListFilteredMutex.RUnlock()
 	fake.tickInUsecMutex.RLock()
 	defer fake.tickInUsecMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
@@ -2,6 +2,7 @@ package lib
 
 import (
 	"fmt"
+	"net"
 
 	"code.cloudfoundry.org/lager/v3"
 	"code.cloudfoundry.org/silk/cni/config"
@@ -31,6 +32,56 @@ func (h *Host) Setup(cfg *config.Config) error {
 		if err := h.LinkOperations.EnableIPv4Forwarding(); err != nil {
 			return fmt.Errorf("enabling packet forwarding on host: %s", err)
 		}
+
+		if err := h.LinkOperations.GatewayRouteAddAll(deviceName, cfg.Host.RouteTable, cfg.GatewayRoutes(), peer.IP); err != nil {
+			return fmt.Errorf("adding gateway routes on host: %s", err)
+		}
+		return nil
+	})
+}
+
+// CheckGateways verifies that the host can forward packets to each of the
+// gateways itself, before the container is given an IP.
+func (h *Host) CheckGateways(hostNS ns.NetNS, gateways []net.IP) error {
+	return hostNS.Do(func(_ ns.NetNS) error {
+		for _, gw := range gateways {
+			if err := h.LinkOperations.CheckGateway(gw); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// TeardownGatewayRoutes removes the routes and rules that Setup added to the
+// given table for the gateway routes of a container. Deleting the veth pair
+// leaves them behind, since they do not belong to a device.
+func (h *Host) TeardownGatewayRoutes(hostNS ns.NetNS, table int) error {
+	return hostNS.Do(func(_ ns.NetNS) error {
+		if err := h.LinkOperations.GatewayRouteDelAll(table); err != nil {
+			return fmt.Errorf("deleting gateway routes on host: %s", err)
+		}
+		return nil
+	})
+}
+
+// Check verifies that the host side of the veth pair is still configured
+// the way Setup left it.
+func (h *Host) Check(cfg *config.Config) error {
+	h.Logger.Debug("start")
+	defer h.Logger.Debug("done")
+	deviceName := cfg.Host.DeviceName
+	local := cfg.Host.Address
+	peer := cfg.Container.Address
+
+	return cfg.Host.Namespace.Do(func(_ ns.NetNS) error {
+		if err := h.Common.BasicCheck(deviceName, local, peer, cfg.Container.MTU); err != nil {
+			return fmt.Errorf("checking device in host: %s", err)
+		}
+
+		if err := h.LinkOperations.GatewayRouteCheckAll(deviceName, cfg.Host.RouteTable, cfg.GatewayRoutes(), peer.IP); err != nil {
+			return fmt.Errorf("checking gateway routes on host: %s", err)
+		}
 		return nil
 	})
 }
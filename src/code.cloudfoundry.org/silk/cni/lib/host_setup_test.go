@@ -8,6 +8,7 @@ import (
 	"code.cloudfoundry.org/silk/cni/config"
 	"code.cloudfoundry.org/silk/cni/lib"
 	"code.cloudfoundry.org/silk/cni/lib/fakes"
+	"github.com/containernetworking/cni/pkg/types"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 )
@@ -68,6 +69,24 @@ var _ = Describe("Host Setup", func() {
 			Expect(fakeLinkOperations.EnableIPv4ForwardingCallCount()).To(Equal(1))
 		})
 
+		It("adds the gateway routes of the container on the host", func() {
+			cfg.Host.RouteTable = 1234
+			cfg.Container.Routes = []*types.Route{
+				{Dst: net.IPNet{IP: net.IPv4zero, Mask: net.CIDRMask(0, 32)}, GW: hostAddr.IP},
+				{Dst: net.IPNet{IP: net.IP{172, 16, 6, 0}, Mask: net.CIDRMask(24, 32)}, GW: net.IP{10, 0, 16, 1}},
+			}
+
+			err := hostSetup.Setup(cfg)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeLinkOperations.GatewayRouteAddAllCallCount()).To(Equal(1))
+			device, table, routes, sourceIP := fakeLinkOperations.GatewayRouteAddAllArgsForCall(0)
+			Expect(device).To(Equal("someHostDeviceName"))
+			Expect(table).To(Equal(1234))
+			Expect(routes).To(Equal(cfg.Container.Routes[1:]))
+			Expect(sourceIP).To(Equal(containerAddr.IP))
+		})
+
 		Context("when the basic device setup fails", func() {
 			BeforeEach(func() {
 				fakeCommon.BasicSetupReturns(errors.New("beans"))
@@ -87,5 +106,93 @@ var _ = Describe("Host Setup", func() {
 				Expect(err).To(MatchError("enabling packet forwarding on host: beans"))
 			})
 		})
+
+		Context("when adding the gateway routes fails", func() {
+			BeforeEach(func() {
+				fakeLinkOperations.GatewayRouteAddAllReturns(errors.New("beans"))
+			})
+			It("returns a meaningful error", func() {
+				err := hostSetup.Setup(cfg)
+				Expect(err).To(MatchError("adding gateway routes on host: beans"))
+			})
+		})
+	})
+
+	Describe("Check", func() {
+		BeforeEach(func() {
+			cfg.Container.MTU = 1472
+		})
+
+		It("calls basic check in the host namespace", func() {
+			err := hostSetup.Check(cfg)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(hostNS.DoCallCount()).To(Equal(1))
+			Expect(fakeCommon.BasicCheckCallCount()).To(Equal(1))
+			device, local, peer, mtu := fakeCommon.BasicCheckArgsForCall(0)
+			Expect(device).To(Equal("someHostDeviceName"))
+			Expect(local).To(Equal(hostAddr))
+			Expect(peer).To(Equal(containerAddr))
+			Expect(mtu).To(Equal(1472))
+		})
+
+		Context("when the basic device check fails", func() {
+			BeforeEach(func() {
+				fakeCommon.BasicCheckReturns(errors.New("banana"))
+			})
+			It("returns a meaningful error", func() {
+				err := hostSetup.Check(cfg)
+				Expect(err).To(MatchError("checking device in host: banana"))
+			})
+		})
+
+		Context("when a gateway route is missing", func() {
+			BeforeEach(func() {
+				fakeLinkOperations.GatewayRouteCheckAllReturns(errors.New("banana"))
+			})
+			It("returns a meaningful error", func() {
+				err := hostSetup.Check(cfg)
+				Expect(err).To(MatchError("checking gateway routes on host: banana"))
+			})
+		})
+	})
+
+	Describe("CheckGateways", func() {
+		It("checks every gateway in the host namespace", func() {
+			err := hostSetup.CheckGateways(hostNS, []net.IP{{10, 0, 16, 1}, {10, 0, 17, 1}})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(hostNS.DoCallCount()).To(Equal(1))
+			Expect(fakeLinkOperations.CheckGatewayCallCount()).To(Equal(2))
+			Expect(fakeLinkOperations.CheckGatewayArgsForCall(1)).To(Equal(net.IP{10, 0, 17, 1}))
+		})
+
+		Context("when a gateway is not reachable", func() {
+			BeforeEach(func() {
+				fakeLinkOperations.CheckGatewayReturns(errors.New("banana"))
+			})
+			It("returns the error", func() {
+				err := hostSetup.CheckGateways(hostNS, []net.IP{{10, 0, 16, 1}})
+				Expect(err).To(MatchError("banana"))
+			})
+		})
+	})
+
+	Describe("TeardownGatewayRoutes", func() {
+		It("deletes the routes and rules of the table in the host namespace", func() {
+			Expect(hostSetup.TeardownGatewayRoutes(hostNS, 1234)).To(Succeed())
+
+			Expect(hostNS.DoCallCount()).To(Equal(1))
+			Expect(fakeLinkOperations.GatewayRouteDelAllArgsForCall(0)).To(Equal(1234))
+		})
+
+		Context("when deleting them fails", func() {
+			BeforeEach(func() {
+				fakeLinkOperations.GatewayRouteDelAllReturns(errors.New("banana"))
+			})
+			It("returns a meaningful error", func() {
+				Expect(hostSetup.TeardownGatewayRoutes(hostNS, 1234)).To(MatchError("deleting gateway routes on host: banana"))
+			})
+		})
 	})
 })
@@ -16,7 +16,12 @@ type linkOperations interface {
 	SetPointToPointAddress(link netlink.Link, localIPAddr, peerIPAddr net.IP) error
 	RenameLink(oldName, newName string) error
 	DeleteLinkByName(deviceName string) error
-	RouteAddAll(route []*types.Route, sourceIP net.IP) error
+	RouteAddAll(deviceName string, routes []*types.Route, sourceIP net.IP, peer config.DualAddress) error
+	RouteCheckAll(deviceName string, routes []*types.Route, sourceIP net.IP) error
+	CheckGateway(gw net.IP) error
+	GatewayRouteAddAll(deviceName string, table int, routes []*types.Route, sourceIP net.IP) error
+	GatewayRouteCheckAll(deviceName string, table int, routes []*types.Route, sourceIP net.IP) error
+	GatewayRouteDelAll(table int) error
 	EnableIPv4Forwarding() error
 	EnableReversePathFiltering(deviceName string) error
 }
@@ -24,6 +29,7 @@ type linkOperations interface {
 //go:generate counterfeiter -o fakes/common.go --fake-name Common . common
 type common interface {
 	BasicSetup(deviceName string, local, peer config.DualAddress) error
+	BasicCheck(deviceName string, local, peer config.DualAddress, mtu int) error
 }
 
 //go:generate counterfeiter -o fakes/netlinkAdapter.go --fake-name NetlinkAdapter . netlinkAdapter
@@ -36,13 +42,22 @@ type netlinkAdapter interface {
 	LinkSetARPOff(netlink.Link) error
 	LinkSetName(netlink.Link, string) error
 	LinkSetUp(netlink.Link) error
+	LinkSetTxQLen(netlink.Link, int) error
 	LinkDel(netlink.Link) error
 	LinkAdd(netlink.Link) error
 	LinkSetNsFd(netlink.Link, int) error
 	RouteAdd(route *netlink.Route) error
+	RouteDel(route *netlink.Route) error
+	RouteGet(destination net.IP) ([]netlink.Route, error)
+	RouteListFiltered(family int, filter *netlink.Route, filterMask uint64) ([]netlink.Route, error)
+	RuleAdd(rule *netlink.Rule) error
+	RuleDel(rule *netlink.Rule) error
+	RuleListFiltered(family int, filter *netlink.Rule, filterMask uint64) ([]netlink.Rule, error)
 	QdiscAdd(qdisc netlink.Qdisc) error
 	FilterAdd(netlink.Filter) error
 	AddrList(link netlink.Link, family int) ([]netlink.Addr, error)
+	ARPList(linkIndex int) ([]netlink.Neigh, error)
+	RouteList(link netlink.Link, family int) ([]netlink.Route, error)
 	TickInUsec() float64
 }
 
@@ -60,3 +75,9 @@ func NetNsDoStub(f func(h ns.NetNS) error) error {
 type sysctlAdapter interface {
 	Sysctl(name string, params ...string) (string, error)
 }
+
+//go:generate counterfeiter -o fakes/ethtoolAdapter.go --fake-name EthtoolAdapter . ethtoolAdapter
+type ethtoolAdapter interface {
+	Features(deviceName string) (map[string]bool, error)
+	Change(deviceName string, features map[string]bool) error
+}
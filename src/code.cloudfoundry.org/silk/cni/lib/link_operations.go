@@ -5,9 +5,11 @@ import (
 	"net"
 
 	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/cni/config"
 
 	"github.com/containernetworking/cni/pkg/types"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 )
 
 // LinkOperations exposes mid-level link setup operations.
@@ -110,17 +112,195 @@ func (s *LinkOperations) DeleteLinkByName(deviceName string) error {
 	return s.NetlinkAdapter.LinkDel(link)
 }
 
-func (s *LinkOperations) RouteAddAll(routes []*types.Route, sourceIP net.IP) error {
+// RouteAddAll adds the routes through the named device. The peer is the
+// only neighbor of the device, so a route via another gateway is marked
+// onlink and the gateway gets a permanent neighbor rule to the hardware
+// address of the peer, which forwards the packets.
+func (s *LinkOperations) RouteAddAll(deviceName string, routes []*types.Route, sourceIP net.IP, peer config.DualAddress) error {
+	link, err := s.NetlinkAdapter.LinkByName(deviceName)
+	if err != nil {
+		return fmt.Errorf("failed to find link %q: %s", deviceName, err)
+	}
+
+	neighbors := map[string]bool{peer.IP.String(): true}
+	for _, r := range routes {
+		dst := r.Dst
+		route := &netlink.Route{
+			LinkIndex: link.Attrs().Index,
+			Src:       sourceIP,
+			Dst:       &dst,
+			Gw:        r.GW,
+		}
+		if !r.GW.Equal(peer.IP) {
+			route.Flags = int(netlink.FLAG_ONLINK)
+			if !neighbors[r.GW.String()] {
+				err := s.NetlinkAdapter.NeighAddPermanentIPv4(link.Attrs().Index, r.GW, peer.Hardware)
+				if err != nil {
+					return fmt.Errorf("adding neighbor for gateway %s: %s", r.GW, err)
+				}
+				neighbors[r.GW.String()] = true
+			}
+		}
+
+		err := s.NetlinkAdapter.RouteAdd(route)
+		if err != nil {
+			return fmt.Errorf("adding route: %s", err)
+		}
+	}
+	return nil
+}
+
+// RouteCheckAll verifies that every route added by RouteAddAll is still
+// present on the named device.
+func (s *LinkOperations) RouteCheckAll(deviceName string, routes []*types.Route, sourceIP net.IP) error {
+	link, err := s.NetlinkAdapter.LinkByName(deviceName)
+	if err != nil {
+		return fmt.Errorf("failed to find link %q: %s", deviceName, err)
+	}
+
+	existing, err := s.NetlinkAdapter.RouteList(link, netlink.FAMILY_V4)
+	if err != nil {
+		return fmt.Errorf("listing routes: %s", err)
+	}
+
+	for _, r := range routes {
+		if !hasRoute(existing, r.Dst, r.GW, sourceIP) {
+			return fmt.Errorf("missing route to %s via %s", r.Dst.String(), r.GW)
+		}
+	}
+	return nil
+}
+
+// CheckGateway returns an error unless the host can send packets to the
+// gateway itself. A gateway that the host reaches through another gateway
+// would route the packets by their destination again.
+func (s *LinkOperations) CheckGateway(gw net.IP) error {
+	routes, err := s.NetlinkAdapter.RouteGet(gw)
+	if err != nil {
+		return fmt.Errorf("gateway %s is not reachable: %s", gw, err)
+	}
+	if len(routes) == 0 {
+		return fmt.Errorf("gateway %s is not reachable", gw)
+	}
+	if routes[0].Type == unix.RTN_LOCAL {
+		return fmt.Errorf("gateway %s is an address of the host", gw)
+	}
+	if routes[0].Gw != nil {
+		return fmt.Errorf("gateway %s is not a neighbor of the host, it is reached via %s", gw, routes[0].Gw)
+	}
+	return nil
+}
+
+// GatewayRouteAddAll makes the host forward the traffic of the container to
+// the destination of each route to its gateway. The routes go into the given
+// table and a rule sends the packets from the source IP that arrive on the
+// named host device to that table.
+func (s *LinkOperations) GatewayRouteAddAll(deviceName string, table int, routes []*types.Route, sourceIP net.IP) error {
 	for _, r := range routes {
 		dst := r.Dst
 		err := s.NetlinkAdapter.RouteAdd(&netlink.Route{
-			Src: sourceIP,
-			Dst: &dst,
-			Gw:  r.GW,
+			Table: table,
+			Dst:   &dst,
+			Gw:    r.GW,
 		})
 		if err != nil {
-			return fmt.Errorf("adding route: %s", err)
+			return fmt.Errorf("adding route to %s via %s: %s", dst.String(), r.GW, err)
+		}
+
+		err = s.NetlinkAdapter.RuleAdd(gatewayRule(deviceName, table, dst, sourceIP))
+		if err != nil {
+			return fmt.Errorf("adding rule for %s: %s", dst.String(), err)
+		}
+	}
+	return nil
+}
+
+// GatewayRouteCheckAll verifies that every route and rule added by
+// GatewayRouteAddAll is still present.
+func (s *LinkOperations) GatewayRouteCheckAll(deviceName string, table int, routes []*types.Route, sourceIP net.IP) error {
+	if len(routes) == 0 {
+		return nil
+	}
+
+	existingRoutes, err := s.NetlinkAdapter.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: table}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return fmt.Errorf("listing routes of table %d: %s", table, err)
+	}
+	existingRules, err := s.NetlinkAdapter.RuleListFiltered(netlink.FAMILY_V4, &netlink.Rule{Table: table}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return fmt.Errorf("listing rules of table %d: %s", table, err)
+	}
+
+	for _, r := range routes {
+		if !hasRoute(existingRoutes, r.Dst, r.GW, nil) {
+			return fmt.Errorf("missing route to %s via %s in table %d", r.Dst.String(), r.GW, table)
+		}
+		if !hasRule(existingRules, deviceName, r.Dst, sourceIP) {
+			return fmt.Errorf("missing rule for %s from %s in table %d", r.Dst.String(), sourceIP, table)
+		}
+	}
+	return nil
+}
+
+// GatewayRouteDelAll removes the rules that GatewayRouteAddAll added for the
+// given table and the routes of the table. Rules of the table with another
+// priority are left alone. It succeeds when the table is empty.
+func (s *LinkOperations) GatewayRouteDelAll(table int) error {
+	rules, err := s.NetlinkAdapter.RuleListFiltered(netlink.FAMILY_V4, &netlink.Rule{Table: table}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return fmt.Errorf("listing rules of table %d: %s", table, err)
+	}
+	for i := range rules {
+		if rules[i].Priority != config.GatewayRulePriority {
+			continue
+		}
+		if err := s.NetlinkAdapter.RuleDel(&rules[i]); err != nil {
+			return fmt.Errorf("deleting rule: %s", err)
+		}
+	}
+
+	routes, err := s.NetlinkAdapter.RouteListFiltered(netlink.FAMILY_V4, &netlink.Route{Table: table}, netlink.RT_FILTER_TABLE)
+	if err != nil {
+		return fmt.Errorf("listing routes of table %d: %s", table, err)
+	}
+	for i := range routes {
+		if err := s.NetlinkAdapter.RouteDel(&routes[i]); err != nil {
+			return fmt.Errorf("deleting route: %s", err)
 		}
 	}
 	return nil
 }
+
+func gatewayRule(deviceName string, table int, dst net.IPNet, sourceIP net.IP) *netlink.Rule {
+	rule := netlink.NewRule()
+	rule.Family = netlink.FAMILY_V4
+	rule.Priority = config.GatewayRulePriority
+	rule.Table = table
+	rule.IifName = deviceName
+	rule.Src = &net.IPNet{IP: sourceIP, Mask: net.CIDRMask(32, 32)}
+	rule.Dst = &dst
+	return rule
+}
+
+func hasRule(existing []netlink.Rule, deviceName string, dst net.IPNet, src net.IP) bool {
+	for _, rule := range existing {
+		if rule.IifName == deviceName && rule.Dst != nil && rule.Dst.String() == dst.String() && rule.Src != nil && rule.Src.IP.Equal(src) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasRoute(existing []netlink.Route, dst net.IPNet, gw, src net.IP) bool {
+	for _, route := range existing {
+		// the kernel reports default routes without a destination
+		routeDst := "0.0.0.0/0"
+		if route.Dst != nil {
+			routeDst = route.Dst.String()
+		}
+		if routeDst == dst.String() && route.Gw.Equal(gw) && route.Src.Equal(src) {
+			return true
+		}
+	}
+	return false
+}
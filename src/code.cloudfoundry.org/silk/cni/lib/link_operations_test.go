@@ -6,12 +6,14 @@ import (
 
 	"code.cloudfoundry.org/lager/v3/lagertest"
 
+	"code.cloudfoundry.org/silk/cni/config"
 	"code.cloudfoundry.org/silk/cni/lib"
 	"code.cloudfoundry.org/silk/cni/lib/fakes"
 	"github.com/containernetworking/cni/pkg/types"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 )
 
 var _ = Describe("Link Operations", func() {
@@ -315,16 +317,25 @@ var _ = Describe("Link Operations", func() {
 	})
 
 	Describe("RouteAddAll", func() {
+		var peer config.DualAddress
+
 		BeforeEach(func() {
+			fakeNetlinkAdapter.LinkByNameReturns(fakeLink, nil)
 			fakeNetlinkAdapter.RouteAddReturns(nil)
+			peer = config.DualAddress{
+				IP:       net.IP{10, 255, 30, 2},
+				Hardware: hwAddr,
+			}
 		})
-		It("adds all routes", func() {
-			err := linkOperations.RouteAddAll(routes, ipAddr)
+		It("adds all routes through the device", func() {
+			err := linkOperations.RouteAddAll("eth0", routes, ipAddr, peer)
 			Expect(err).NotTo(HaveOccurred())
 
+			Expect(fakeNetlinkAdapter.LinkByNameArgsForCall(0)).To(Equal("eth0"))
 			Expect(fakeNetlinkAdapter.RouteAddCallCount()).To(Equal(3))
 			Expect(fakeNetlinkAdapter.RouteAddArgsForCall(0)).To(Equal(&netlink.Route{
-				Src: ipAddr,
+				LinkIndex: 42,
+				Src:       ipAddr,
 				Dst: &net.IPNet{
 					IP:   []byte{200, 201, 202, 203},
 					Mask: []byte{255, 255, 255, 255},
@@ -332,23 +343,60 @@ var _ = Describe("Link Operations", func() {
 				Gw: net.IP{10, 255, 30, 2},
 			}))
 			Expect(fakeNetlinkAdapter.RouteAddArgsForCall(1)).To(Equal(&netlink.Route{
-				Src: ipAddr,
+				LinkIndex: 42,
+				Src:       ipAddr,
 				Dst: &net.IPNet{
 					IP:   []byte{100, 101, 102, 103},
 					Mask: []byte{255, 255, 255, 255},
 				},
-				Gw: net.IP{10, 255, 30, 1},
+				Gw:    net.IP{10, 255, 30, 1},
+				Flags: int(netlink.FLAG_ONLINK),
 			}))
 			Expect(fakeNetlinkAdapter.RouteAddArgsForCall(2)).To(Equal(&netlink.Route{
-				Src: ipAddr,
+				LinkIndex: 42,
+				Src:       ipAddr,
 				Dst: &net.IPNet{
 					IP:   []byte{0, 1, 2, 3},
 					Mask: []byte{255, 255, 255, 255},
 				},
-				Gw: net.IP{10, 255, 30, 0},
+				Gw:    net.IP{10, 255, 30, 0},
+				Flags: int(netlink.FLAG_ONLINK),
 			}))
 		})
 
+		It("points the gateways other than the peer at the peer", func() {
+			routes[2].GW = net.IP{10, 255, 30, 1}
+			err := linkOperations.RouteAddAll("eth0", routes, ipAddr, peer)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeNetlinkAdapter.NeighAddPermanentIPv4CallCount()).To(Equal(1))
+			index, ip, mac := fakeNetlinkAdapter.NeighAddPermanentIPv4ArgsForCall(0)
+			Expect(index).To(Equal(42))
+			Expect(ip).To(Equal(net.IP{10, 255, 30, 1}))
+			Expect(mac).To(Equal(hwAddr))
+		})
+
+		Context("when adding the neighbor of a gateway fails", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.NeighAddPermanentIPv4Returns(errors.New("pickle"))
+			})
+			It("returns a meaningful error", func() {
+				err := linkOperations.RouteAddAll("eth0", routes, ipAddr, peer)
+				Expect(err).To(MatchError("adding neighbor for gateway 10.255.30.1: pickle"))
+				Expect(fakeNetlinkAdapter.RouteAddCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when the device cannot be found", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.LinkByNameReturns(nil, errors.New("pickle"))
+			})
+			It("returns a meaningful error", func() {
+				err := linkOperations.RouteAddAll("eth0", routes, ipAddr, peer)
+				Expect(err).To(MatchError(`failed to find link "eth0": pickle`))
+			})
+		})
+
 		Context("when adding one of the routes fails", func() {
 			BeforeEach(func() {
 				fakeNetlinkAdapter.RouteAddStub = func(route *netlink.Route) error {
@@ -359,11 +407,266 @@ var _ = Describe("Link Operations", func() {
 				}
 			})
 			It("returns a meaningful error", func() {
-				err := linkOperations.RouteAddAll(routes, ipAddr)
+				err := linkOperations.RouteAddAll("eth0", routes, ipAddr, peer)
 				Expect(err).To(MatchError("adding route: pickle"))
 
 				Expect(fakeNetlinkAdapter.RouteAddCallCount()).To(Equal(2))
 			})
 		})
 	})
+
+	Describe("RouteCheckAll", func() {
+		BeforeEach(func() {
+			fakeNetlinkAdapter.LinkByNameReturns(fakeLink, nil)
+			var existing []netlink.Route
+			for _, r := range routes {
+				dst := r.Dst
+				existing = append(existing, netlink.Route{Src: ipAddr, Dst: &dst, Gw: r.GW})
+			}
+			fakeNetlinkAdapter.RouteListReturns(existing, nil)
+		})
+
+		It("succeeds when all routes are present", func() {
+			err := linkOperations.RouteCheckAll("eth0", routes, ipAddr)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeNetlinkAdapter.LinkByNameArgsForCall(0)).To(Equal("eth0"))
+			link, family := fakeNetlinkAdapter.RouteListArgsForCall(0)
+			Expect(link).To(Equal(fakeLink))
+			Expect(family).To(Equal(netlink.FAMILY_V4))
+		})
+
+		It("matches default routes reported without a destination", func() {
+			fakeNetlinkAdapter.RouteListReturns([]netlink.Route{{Src: ipAddr, Gw: peerIP}}, nil)
+			defaultRoute := []*types.Route{{
+				Dst: net.IPNet{IP: net.IPv4zero, Mask: net.CIDRMask(0, 32)},
+				GW:  peerIP,
+			}}
+
+			err := linkOperations.RouteCheckAll("eth0", defaultRoute, ipAddr)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("when a route is missing", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.RouteListReturns(nil, nil)
+			})
+			It("returns a meaningful error", func() {
+				err := linkOperations.RouteCheckAll("eth0", routes, ipAddr)
+				Expect(err).To(MatchError("missing route to 200.201.202.203/32 via 10.255.30.2"))
+			})
+		})
+
+		Context("when finding the link fails", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.LinkByNameReturns(nil, errors.New("pickle"))
+			})
+			It("returns a meaningful error", func() {
+				err := linkOperations.RouteCheckAll("eth0", routes, ipAddr)
+				Expect(err).To(MatchError(`failed to find link "eth0": pickle`))
+			})
+		})
+
+		Context("when listing the routes fails", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.RouteListReturns(nil, errors.New("pickle"))
+			})
+			It("returns a meaningful error", func() {
+				err := linkOperations.RouteCheckAll("eth0", routes, ipAddr)
+				Expect(err).To(MatchError("listing routes: pickle"))
+			})
+		})
+	})
+
+	Describe("CheckGateway", func() {
+		It("succeeds when the host reaches the gateway directly", func() {
+			fakeNetlinkAdapter.RouteGetReturns([]netlink.Route{{LinkIndex: 2, Type: unix.RTN_UNICAST}}, nil)
+
+			Expect(linkOperations.CheckGateway(net.IP{10, 0, 16, 1})).To(Succeed())
+			Expect(fakeNetlinkAdapter.RouteGetArgsForCall(0)).To(Equal(net.IP{10, 0, 16, 1}))
+		})
+
+		Context("when the host reaches the gateway through another gateway", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.RouteGetReturns([]netlink.Route{{LinkIndex: 2, Type: unix.RTN_UNICAST, Gw: net.IP{10, 0, 16, 1}}}, nil)
+			})
+			It("returns a meaningful error", func() {
+				err := linkOperations.CheckGateway(net.IP{10, 99, 0, 9})
+				Expect(err).To(MatchError("gateway 10.99.0.9 is not a neighbor of the host, it is reached via 10.0.16.1"))
+			})
+		})
+
+		Context("when the gateway is an address of the host", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.RouteGetReturns([]netlink.Route{{LinkIndex: 1, Type: unix.RTN_LOCAL}}, nil)
+			})
+			It("returns a meaningful error", func() {
+				err := linkOperations.CheckGateway(net.IP{10, 0, 16, 5})
+				Expect(err).To(MatchError("gateway 10.0.16.5 is an address of the host"))
+			})
+		})
+
+		Context("when the host has no route to the gateway", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.RouteGetReturns(nil, errors.New("network is unreachable"))
+			})
+			It("returns a meaningful error", func() {
+				err := linkOperations.CheckGateway(net.IP{10, 99, 0, 9})
+				Expect(err).To(MatchError("gateway 10.99.0.9 is not reachable: network is unreachable"))
+			})
+		})
+	})
+
+	Describe("GatewayRouteAddAll", func() {
+		It("adds the routes to the table and a rule for each of them", func() {
+			err := linkOperations.GatewayRouteAddAll("s-0afa070298b7", 1234, routes[:2], ipAddr)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeNetlinkAdapter.RouteAddCallCount()).To(Equal(2))
+			Expect(fakeNetlinkAdapter.RouteAddArgsForCall(0)).To(Equal(&netlink.Route{
+				Table: 1234,
+				Dst:   &routes[0].Dst,
+				Gw:    net.IP{10, 255, 30, 2},
+			}))
+
+			Expect(fakeNetlinkAdapter.RuleAddCallCount()).To(Equal(2))
+			rule := fakeNetlinkAdapter.RuleAddArgsForCall(1)
+			Expect(rule.Table).To(Equal(1234))
+			Expect(rule.Priority).To(Equal(config.GatewayRulePriority))
+			Expect(rule.IifName).To(Equal("s-0afa070298b7"))
+			Expect(rule.Src.String()).To(Equal("10.255.30.4/32"))
+			Expect(rule.Dst.String()).To(Equal("100.101.102.103/32"))
+		})
+
+		Context("when adding a route fails", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.RouteAddReturns(errors.New("network is unreachable"))
+			})
+			It("returns a meaningful error", func() {
+				err := linkOperations.GatewayRouteAddAll("s-0afa070298b7", 1234, routes, ipAddr)
+				Expect(err).To(MatchError("adding route to 200.201.202.203/32 via 10.255.30.2: network is unreachable"))
+			})
+		})
+
+		Context("when adding a rule fails", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.RuleAddReturns(errors.New("pickle"))
+			})
+			It("returns a meaningful error", func() {
+				err := linkOperations.GatewayRouteAddAll("s-0afa070298b7", 1234, routes, ipAddr)
+				Expect(err).To(MatchError("adding rule for 200.201.202.203/32: pickle"))
+			})
+		})
+	})
+
+	Describe("GatewayRouteCheckAll", func() {
+		BeforeEach(func() {
+			var existingRoutes []netlink.Route
+			var existingRules []netlink.Rule
+			for _, r := range routes {
+				dst := r.Dst
+				existingRoutes = append(existingRoutes, netlink.Route{Table: 1234, Dst: &dst, Gw: r.GW})
+				existingRules = append(existingRules, netlink.Rule{
+					Table:   1234,
+					IifName: "s-0afa070298b7",
+					Src:     &net.IPNet{IP: ipAddr, Mask: net.CIDRMask(32, 32)},
+					Dst:     &dst,
+				})
+			}
+			fakeNetlinkAdapter.RouteListFilteredReturns(existingRoutes, nil)
+			fakeNetlinkAdapter.RuleListFilteredReturns(existingRules, nil)
+		})
+
+		It("succeeds when all routes and rules are present", func() {
+			err := linkOperations.GatewayRouteCheckAll("s-0afa070298b7", 1234, routes, ipAddr)
+			Expect(err).NotTo(HaveOccurred())
+
+			family, routeFilter, routeMask := fakeNetlinkAdapter.RouteListFilteredArgsForCall(0)
+			Expect(family).To(Equal(netlink.FAMILY_V4))
+			Expect(routeFilter.Table).To(Equal(1234))
+			Expect(routeMask).To(Equal(netlink.RT_FILTER_TABLE))
+			_, ruleFilter, ruleMask := fakeNetlinkAdapter.RuleListFilteredArgsForCall(0)
+			Expect(ruleFilter.Table).To(Equal(1234))
+			Expect(ruleMask).To(Equal(netlink.RT_FILTER_TABLE))
+		})
+
+		It("does nothing without routes", func() {
+			Expect(linkOperations.GatewayRouteCheckAll("s-0afa070298b7", 1234, nil, ipAddr)).To(Succeed())
+			Expect(fakeNetlinkAdapter.RouteListFilteredCallCount()).To(Equal(0))
+		})
+
+		Context("when a route is missing", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.RouteListFilteredReturns(nil, nil)
+			})
+			It("returns a meaningful error", func() {
+				err := linkOperations.GatewayRouteCheckAll("s-0afa070298b7", 1234, routes, ipAddr)
+				Expect(err).To(MatchError("missing route to 200.201.202.203/32 via 10.255.30.2 in table 1234"))
+			})
+		})
+
+		Context("when a rule is missing", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.RuleListFilteredReturns(nil, nil)
+			})
+			It("returns a meaningful error", func() {
+				err := linkOperations.GatewayRouteCheckAll("s-0afa070298b7", 1234, routes, ipAddr)
+				Expect(err).To(MatchError("missing rule for 200.201.202.203/32 from 10.255.30.4 in table 1234"))
+			})
+		})
+	})
+
+	Describe("GatewayRouteDelAll", func() {
+		var (
+			rules       []netlink.Rule
+			tableRoutes []netlink.Route
+		)
+
+		BeforeEach(func() {
+			rules = []netlink.Rule{{Table: 1234, Priority: 100}}
+			tableRoutes = []netlink.Route{{Table: 1234, Gw: net.IP{10, 0, 16, 1}}}
+			fakeNetlinkAdapter.RuleListFilteredReturns(rules, nil)
+			fakeNetlinkAdapter.RouteListFilteredReturns(tableRoutes, nil)
+		})
+
+		It("deletes the rules and routes of the table", func() {
+			Expect(linkOperations.GatewayRouteDelAll(1234)).To(Succeed())
+
+			Expect(fakeNetlinkAdapter.RuleDelCallCount()).To(Equal(1))
+			Expect(fakeNetlinkAdapter.RuleDelArgsForCall(0)).To(Equal(&rules[0]))
+			Expect(fakeNetlinkAdapter.RouteDelCallCount()).To(Equal(1))
+			Expect(fakeNetlinkAdapter.RouteDelArgsForCall(0)).To(Equal(&tableRoutes[0]))
+		})
+
+		Context("when the table has rules that silk did not add", func() {
+			BeforeEach(func() {
+				rules = append(rules, netlink.Rule{Table: 1234, Priority: 200})
+				fakeNetlinkAdapter.RuleListFilteredReturns(rules, nil)
+			})
+			It("only deletes the rules at the gateway rule priority", func() {
+				Expect(linkOperations.GatewayRouteDelAll(1234)).To(Succeed())
+
+				Expect(fakeNetlinkAdapter.RuleDelCallCount()).To(Equal(1))
+				Expect(fakeNetlinkAdapter.RuleDelArgsForCall(0)).To(Equal(&rules[0]))
+			})
+		})
+
+		Context("when deleting a rule fails", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.RuleDelReturns(errors.New("pickle"))
+			})
+			It("returns a meaningful error", func() {
+				Expect(linkOperations.GatewayRouteDelAll(1234)).To(MatchError("deleting rule: pickle"))
+			})
+		})
+
+		Context("when listing the routes fails", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.RouteListFilteredReturns(nil, errors.New("pickle"))
+			})
+			It("returns a meaningful error", func() {
+				Expect(linkOperations.GatewayRouteDelAll(1234)).To(MatchError("listing routes of table 1234: pickle"))
+			})
+		})
+	})
 })
@@ -0,0 +1,152 @@
+package lib
+
+import (
+	"fmt"
+	"math"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/cni/config"
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+const latencyInMillis = 100
+
+// TokenBucketFilter limits the bandwidth of a container on the host side of
+// its veth pair, see docs/03-bandwidth-limiting.md.
+type TokenBucketFilter struct {
+	NetlinkAdapter netlinkAdapter
+	LinkOperations linkOperations
+	Logger         lager.Logger
+}
+
+// Setup shapes traffic into the container with a tbf qdisc on the host
+// device. Traffic out of the container is redirected from the host device
+// to an ifb device, which is shaped the same way.
+func (t *TokenBucketFilter) Setup(cfg *config.Config, limits config.Bandwidth) error {
+	t.Logger.Debug("start", lager.Data{"limits": limits})
+	defer t.Logger.Debug("done")
+
+	return cfg.Host.Namespace.Do(func(_ ns.NetNS) error {
+		hostLink, err := t.NetlinkAdapter.LinkByName(cfg.Host.DeviceName)
+		if err != nil {
+			return fmt.Errorf("failed to find link %q: %s", cfg.Host.DeviceName, err)
+		}
+
+		if limits.IngressRate > 0 {
+			if err := t.addTBF(hostLink, limits.IngressRate, limits.IngressBurst); err != nil {
+				return fmt.Errorf("limiting ingress: %s", err)
+			}
+		}
+
+		if limits.EgressRate > 0 {
+			if err := t.setupEgress(cfg, hostLink, limits.EgressRate, limits.EgressBurst); err != nil {
+				return fmt.Errorf("limiting egress: %s", err)
+			}
+		}
+		return nil
+	})
+}
+
+// Teardown deletes the ifb device of a container. The qdiscs on the host
+// device are deleted with the veth pair.
+func (t *TokenBucketFilter) Teardown(ifbDeviceName string) error {
+	t.Logger.Debug("start", lager.Data{"ifbDeviceName": ifbDeviceName})
+	defer t.Logger.Debug("done")
+
+	if err := t.LinkOperations.DeleteLinkByName(ifbDeviceName); err != nil {
+		return fmt.Errorf("deleting ifb device: %s", err)
+	}
+	return nil
+}
+
+func (t *TokenBucketFilter) setupEgress(cfg *config.Config, hostLink netlink.Link, rate, burst uint64) error {
+	ifbDeviceName := cfg.Host.IFBDeviceName
+
+	// an ifb of a previous container with the same IP is left behind when
+	// its DEL never happened
+	if err := t.LinkOperations.DeleteLinkByName(ifbDeviceName); err != nil {
+		return fmt.Errorf("deleting stale ifb device: %s", err)
+	}
+
+	err := t.NetlinkAdapter.LinkAdd(&netlink.Ifb{
+		LinkAttrs: netlink.LinkAttrs{
+			Name: ifbDeviceName,
+			MTU:  cfg.Container.MTU,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("adding ifb device %s: %s", ifbDeviceName, err)
+	}
+
+	ifbLink, err := t.NetlinkAdapter.LinkByName(ifbDeviceName)
+	if err != nil {
+		return fmt.Errorf("failed to find link %q: %s", ifbDeviceName, err)
+	}
+
+	if err := t.NetlinkAdapter.LinkSetUp(ifbLink); err != nil {
+		return fmt.Errorf("setting link %s up: %s", ifbDeviceName, err)
+	}
+
+	if err := t.addTBF(ifbLink, rate, burst); err != nil {
+		return err
+	}
+
+	err = t.NetlinkAdapter.QdiscAdd(&netlink.Ingress{
+		QdiscAttrs: netlink.QdiscAttrs{
+			LinkIndex: hostLink.Attrs().Index,
+			Handle:    netlink.MakeHandle(0xffff, 0),
+			Parent:    netlink.HANDLE_INGRESS,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("adding ingress qdisc: %s", err)
+	}
+
+	err = t.NetlinkAdapter.FilterAdd(&netlink.U32{
+		FilterAttrs: netlink.FilterAttrs{
+			LinkIndex: hostLink.Attrs().Index,
+			Parent:    netlink.MakeHandle(0xffff, 0),
+			Priority:  1,
+			Protocol:  unix.ETH_P_ALL,
+		},
+		ClassId: netlink.MakeHandle(1, 1),
+		Actions: []netlink.Action{netlink.NewMirredAction(ifbLink.Attrs().Index)},
+	})
+	if err != nil {
+		return fmt.Errorf("adding redirect to %s: %s", ifbDeviceName, err)
+	}
+	return nil
+}
+
+// addTBF is "tc qdisc add dev LINK root tbf rate RATEbit burst BURST
+// latency 100ms"
+func (t *TokenBucketFilter) addTBF(link netlink.Link, rateInBits, burstInBits uint64) error {
+	rateInBytes := rateInBits / 8
+	burstInBytes := burstInBits / 8
+
+	bufferInTicks := float64(burstInBytes) * netlink.TIME_UNITS_PER_SEC / float64(rateInBytes) * t.NetlinkAdapter.TickInUsec()
+	if bufferInTicks > math.MaxUint32 {
+		return fmt.Errorf("burst %d bits is too large for the rate %d bits per second", burstInBits, rateInBits)
+	}
+	limitInBytes := float64(rateInBytes)*latencyInMillis/1000 + float64(burstInBytes)
+	if limitInBytes > math.MaxUint32 {
+		limitInBytes = math.MaxUint32
+	}
+
+	err := t.NetlinkAdapter.QdiscAdd(&netlink.Tbf{
+		QdiscAttrs: netlink.QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    netlink.MakeHandle(1, 0),
+			Parent:    netlink.HANDLE_ROOT,
+		},
+		Rate:   rateInBytes,
+		Buffer: uint32(bufferInTicks),
+		Limit:  uint32(limitInBytes),
+	})
+	if err != nil {
+		return fmt.Errorf("adding tbf qdisc to %s: %s", link.Attrs().Name, err)
+	}
+	return nil
+}
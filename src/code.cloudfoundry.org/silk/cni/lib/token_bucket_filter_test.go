@@ -0,0 +1,256 @@
+package lib_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/cni/config"
+	"code.cloudfoundry.org/silk/cni/lib"
+	"code.cloudfoundry.org/silk/cni/lib/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+var _ = Describe("TokenBucketFilter", func() {
+	var (
+		hostNS             *fakes.NetNS
+		cfg                *config.Config
+		fakeNetlinkAdapter *fakes.NetlinkAdapter
+		fakeLinkOperations *fakes.LinkOperations
+		hostLink           *netlink.Veth
+		ifbLink            *netlink.Ifb
+		limits             config.Bandwidth
+		tbf                *lib.TokenBucketFilter
+	)
+
+	BeforeEach(func() {
+		hostNS = &fakes.NetNS{}
+		hostNS.DoStub = lib.NetNsDoStub
+
+		cfg = &config.Config{}
+		cfg.Host.DeviceName = "s-010255030004"
+		cfg.Host.IFBDeviceName = "i-010255030004"
+		cfg.Host.Namespace = hostNS
+		cfg.Container.MTU = 1450
+
+		hostLink = &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "s-010255030004", Index: 42}}
+		ifbLink = &netlink.Ifb{LinkAttrs: netlink.LinkAttrs{Name: "i-010255030004", Index: 43}}
+
+		fakeNetlinkAdapter = &fakes.NetlinkAdapter{}
+		fakeNetlinkAdapter.LinkByNameStub = func(name string) (netlink.Link, error) {
+			if name == ifbLink.Name {
+				return ifbLink, nil
+			}
+			return hostLink, nil
+		}
+		fakeNetlinkAdapter.TickInUsecReturns(2)
+		fakeLinkOperations = &fakes.LinkOperations{}
+
+		limits = config.Bandwidth{
+			IngressRate:  8000,
+			IngressBurst: 16000,
+			EgressRate:   80000,
+			EgressBurst:  24000,
+		}
+
+		tbf = &lib.TokenBucketFilter{
+			NetlinkAdapter: fakeNetlinkAdapter,
+			LinkOperations: fakeLinkOperations,
+			Logger:         lagertest.NewTestLogger("test"),
+		}
+	})
+
+	Describe("Setup", func() {
+		It("adds a tbf qdisc to the host device to limit ingress", func() {
+			Expect(tbf.Setup(cfg, limits)).To(Succeed())
+
+			Expect(hostNS.DoCallCount()).To(Equal(1))
+			Expect(fakeNetlinkAdapter.LinkByNameArgsForCall(0)).To(Equal("s-010255030004"))
+			Expect(fakeNetlinkAdapter.QdiscAddArgsForCall(0)).To(Equal(&netlink.Tbf{
+				QdiscAttrs: netlink.QdiscAttrs{
+					LinkIndex: 42,
+					Handle:    netlink.MakeHandle(1, 0),
+					Parent:    netlink.HANDLE_ROOT,
+				},
+				Rate:   1000,
+				Buffer: 4000000,
+				Limit:  2100,
+			}))
+		})
+
+		It("redirects egress to an ifb device with a tbf qdisc", func() {
+			Expect(tbf.Setup(cfg, limits)).To(Succeed())
+
+			By("replacing a stale ifb device")
+			Expect(fakeLinkOperations.DeleteLinkByNameCallCount()).To(Equal(1))
+			Expect(fakeLinkOperations.DeleteLinkByNameArgsForCall(0)).To(Equal("i-010255030004"))
+
+			Expect(fakeNetlinkAdapter.LinkAddCallCount()).To(Equal(1))
+			Expect(fakeNetlinkAdapter.LinkAddArgsForCall(0)).To(Equal(&netlink.Ifb{
+				LinkAttrs: netlink.LinkAttrs{Name: "i-010255030004", MTU: 1450},
+			}))
+			Expect(fakeNetlinkAdapter.LinkSetUpCallCount()).To(Equal(1))
+			Expect(fakeNetlinkAdapter.LinkSetUpArgsForCall(0)).To(Equal(ifbLink))
+
+			Expect(fakeNetlinkAdapter.QdiscAddCallCount()).To(Equal(3))
+			Expect(fakeNetlinkAdapter.QdiscAddArgsForCall(1)).To(Equal(&netlink.Tbf{
+				QdiscAttrs: netlink.QdiscAttrs{
+					LinkIndex: 43,
+					Handle:    netlink.MakeHandle(1, 0),
+					Parent:    netlink.HANDLE_ROOT,
+				},
+				Rate:   10000,
+				Buffer: 600000,
+				Limit:  4000,
+			}))
+			Expect(fakeNetlinkAdapter.QdiscAddArgsForCall(2)).To(Equal(&netlink.Ingress{
+				QdiscAttrs: netlink.QdiscAttrs{
+					LinkIndex: 42,
+					Handle:    netlink.MakeHandle(0xffff, 0),
+					Parent:    netlink.HANDLE_INGRESS,
+				},
+			}))
+
+			Expect(fakeNetlinkAdapter.FilterAddCallCount()).To(Equal(1))
+			Expect(fakeNetlinkAdapter.FilterAddArgsForCall(0)).To(Equal(&netlink.U32{
+				FilterAttrs: netlink.FilterAttrs{
+					LinkIndex: 42,
+					Parent:    netlink.MakeHandle(0xffff, 0),
+					Priority:  1,
+					Protocol:  unix.ETH_P_ALL,
+				},
+				ClassId: netlink.MakeHandle(1, 1),
+				Actions: []netlink.Action{netlink.NewMirredAction(43)},
+			}))
+		})
+
+		Context("when only ingress is limited", func() {
+			BeforeEach(func() {
+				limits.EgressRate = 0
+				limits.EgressBurst = 0
+			})
+
+			It("does not create an ifb device", func() {
+				Expect(tbf.Setup(cfg, limits)).To(Succeed())
+				Expect(fakeNetlinkAdapter.QdiscAddCallCount()).To(Equal(1))
+				Expect(fakeNetlinkAdapter.LinkAddCallCount()).To(Equal(0))
+				Expect(fakeNetlinkAdapter.FilterAddCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when only egress is limited", func() {
+			BeforeEach(func() {
+				limits.IngressRate = 0
+				limits.IngressBurst = 0
+			})
+
+			It("does not add a tbf qdisc to the host device", func() {
+				Expect(tbf.Setup(cfg, limits)).To(Succeed())
+				Expect(fakeNetlinkAdapter.QdiscAddCallCount()).To(Equal(2))
+				Expect(fakeNetlinkAdapter.QdiscAddArgsForCall(0).Attrs().LinkIndex).To(Equal(43))
+			})
+		})
+
+		Context("when the burst takes too many ticks to send at the rate", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.TickInUsecReturns(3000)
+			})
+
+			It("returns an error", func() {
+				Expect(tbf.Setup(cfg, limits)).To(MatchError("limiting ingress: burst 16000 bits is too large for the rate 8000 bits per second"))
+				Expect(fakeNetlinkAdapter.QdiscAddCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the host device cannot be found", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.LinkByNameStub = nil
+				fakeNetlinkAdapter.LinkByNameReturns(nil, errors.New("banana"))
+			})
+
+			It("returns an error", func() {
+				Expect(tbf.Setup(cfg, limits)).To(MatchError(`failed to find link "s-010255030004": banana`))
+			})
+		})
+
+		Context("when adding the tbf qdisc fails", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.QdiscAddReturns(errors.New("banana"))
+			})
+
+			It("returns an error", func() {
+				Expect(tbf.Setup(cfg, limits)).To(MatchError("limiting ingress: adding tbf qdisc to s-010255030004: banana"))
+			})
+		})
+
+		Context("when deleting a stale ifb device fails", func() {
+			BeforeEach(func() {
+				fakeLinkOperations.DeleteLinkByNameReturns(errors.New("banana"))
+			})
+
+			It("returns an error", func() {
+				Expect(tbf.Setup(cfg, limits)).To(MatchError("limiting egress: deleting stale ifb device: banana"))
+			})
+		})
+
+		Context("when adding the ifb device fails", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.LinkAddReturns(errors.New("banana"))
+			})
+
+			It("returns an error", func() {
+				Expect(tbf.Setup(cfg, limits)).To(MatchError("limiting egress: adding ifb device i-010255030004: banana"))
+			})
+		})
+
+		Context("when setting the ifb device up fails", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.LinkSetUpReturns(errors.New("banana"))
+			})
+
+			It("returns an error", func() {
+				Expect(tbf.Setup(cfg, limits)).To(MatchError("limiting egress: setting link i-010255030004 up: banana"))
+			})
+		})
+
+		Context("when adding the ingress qdisc fails", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.QdiscAddReturnsOnCall(2, errors.New("banana"))
+			})
+
+			It("returns an error", func() {
+				Expect(tbf.Setup(cfg, limits)).To(MatchError("limiting egress: adding ingress qdisc: banana"))
+			})
+		})
+
+		Context("when adding the redirect fails", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.FilterAddReturns(errors.New("banana"))
+			})
+
+			It("returns an error", func() {
+				Expect(tbf.Setup(cfg, limits)).To(MatchError("limiting egress: adding redirect to i-010255030004: banana"))
+			})
+		})
+	})
+
+	Describe("Teardown", func() {
+		It("deletes the ifb device", func() {
+			Expect(tbf.Teardown("i-010255030004")).To(Succeed())
+			Expect(fakeLinkOperations.DeleteLinkByNameCallCount()).To(Equal(1))
+			Expect(fakeLinkOperations.DeleteLinkByNameArgsForCall(0)).To(Equal("i-010255030004"))
+		})
+
+		Context("when deleting the ifb device fails", func() {
+			BeforeEach(func() {
+				fakeLinkOperations.DeleteLinkByNameReturns(errors.New("banana"))
+			})
+
+			It("returns an error", func() {
+				Expect(tbf.Teardown("i-010255030004")).To(MatchError("deleting ifb device: banana"))
+			})
+		})
+	})
+})
@@ -0,0 +1,101 @@
+package lib
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/cni/config"
+	"github.com/containernetworking/plugins/pkg/ns"
+)
+
+// the ethtool names of the offloads that config.Tuning turns on or off
+const (
+	gsoFeature = "tx-generic-segmentation"
+	groFeature = "rx-gro"
+)
+
+// Tuning applies the tuning profile of a deployment inside the namespace of
+// a container. When a setting fails, the settings that were already applied
+// are rolled back, so a container is either tuned completely or not at all.
+type Tuning struct {
+	SysctlAdapter  sysctlAdapter
+	NetlinkAdapter netlinkAdapter
+	EthtoolAdapter ethtoolAdapter
+	Logger         lager.Logger
+}
+
+// Setup applies the sysctls in the container namespace and the txqueuelen
+// and offloads of the container device. The tuning must be validated first.
+func (t *Tuning) Setup(cfg *config.Config, tuning config.Tuning) error {
+	t.Logger.Debug("start", lager.Data{"tuning": tuning})
+	defer t.Logger.Debug("done")
+
+	return cfg.Container.Namespace.Do(func(_ ns.NetNS) error {
+		var rollbacks []func() error
+		err := t.apply(cfg.Container.DeviceName, tuning, &rollbacks)
+		if err != nil {
+			for i := len(rollbacks) - 1; i >= 0; i-- {
+				if rollbackErr := rollbacks[i](); rollbackErr != nil {
+					t.Logger.Error("rollback-failed", rollbackErr)
+				}
+			}
+		}
+		return err
+	})
+}
+
+// apply adds a rollback for every setting that it changed
+func (t *Tuning) apply(deviceName string, tuning config.Tuning, rollbacks *[]func() error) error {
+	for _, name := range tuning.SysctlNames() {
+		previous, err := t.SysctlAdapter.Sysctl(name)
+		if err != nil {
+			return fmt.Errorf("reading sysctl %s: %s", name, err)
+		}
+		if _, err := t.SysctlAdapter.Sysctl(name, tuning.Sysctls[name]); err != nil {
+			return fmt.Errorf("setting sysctl %s: %s", name, err)
+		}
+		*rollbacks = append(*rollbacks, func() error {
+			_, err := t.SysctlAdapter.Sysctl(name, previous)
+			return err
+		})
+	}
+
+	if tuning.TxQueueLen > 0 {
+		link, err := t.NetlinkAdapter.LinkByName(deviceName)
+		if err != nil {
+			return fmt.Errorf("failed to find link %q: %s", deviceName, err)
+		}
+		previous := link.Attrs().TxQLen
+		if err := t.NetlinkAdapter.LinkSetTxQLen(link, tuning.TxQueueLen); err != nil {
+			return fmt.Errorf("setting txqueuelen of %s: %s", deviceName, err)
+		}
+		*rollbacks = append(*rollbacks, func() error {
+			return t.NetlinkAdapter.LinkSetTxQLen(link, previous)
+		})
+	}
+
+	features := map[string]bool{}
+	if tuning.GSO != nil {
+		features[gsoFeature] = *tuning.GSO
+	}
+	if tuning.GRO != nil {
+		features[groFeature] = *tuning.GRO
+	}
+	if len(features) > 0 {
+		current, err := t.EthtoolAdapter.Features(deviceName)
+		if err != nil {
+			return fmt.Errorf("reading features of %s: %s", deviceName, err)
+		}
+		previous := map[string]bool{}
+		for name := range features {
+			previous[name] = current[name]
+		}
+		if err := t.EthtoolAdapter.Change(deviceName, features); err != nil {
+			return fmt.Errorf("setting features of %s: %s", deviceName, err)
+		}
+		*rollbacks = append(*rollbacks, func() error {
+			return t.EthtoolAdapter.Change(deviceName, previous)
+		})
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package lib_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/cni/config"
+	"code.cloudfoundry.org/silk/cni/lib"
+	"code.cloudfoundry.org/silk/cni/lib/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/vishvananda/netlink"
+)
+
+var _ = Describe("Tuning", func() {
+	var (
+		containerNS        *fakes.NetNS
+		cfg                *config.Config
+		fakeSysctlAdapter  *fakes.SysctlAdapter
+		sysctlErrors       map[int]error
+		fakeNetlinkAdapter *fakes.NetlinkAdapter
+		fakeEthtoolAdapter *fakes.EthtoolAdapter
+		containerLink      *netlink.Veth
+		logger             *lagertest.TestLogger
+		tuning             config.Tuning
+		tuner              *lib.Tuning
+	)
+
+	BeforeEach(func() {
+		containerNS = &fakes.NetNS{}
+		containerNS.DoStub = lib.NetNsDoStub
+
+		cfg = &config.Config{}
+		cfg.Container.DeviceName = "eth0"
+		cfg.Container.Namespace = containerNS
+
+		containerLink = &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "eth0", TxQLen: 1000}}
+
+		sysctlErrors = map[int]error{}
+		fakeSysctlAdapter = &fakes.SysctlAdapter{}
+		fakeSysctlAdapter.SysctlStub = func(name string, params ...string) (string, error) {
+			if err, ok := sysctlErrors[fakeSysctlAdapter.SysctlCallCount()-1]; ok {
+				return "", err
+			}
+			if len(params) == 0 {
+				return "previous-" + name, nil
+			}
+			return params[0], nil
+		}
+		fakeNetlinkAdapter = &fakes.NetlinkAdapter{}
+		fakeNetlinkAdapter.LinkByNameReturns(containerLink, nil)
+		fakeEthtoolAdapter = &fakes.EthtoolAdapter{}
+		fakeEthtoolAdapter.FeaturesReturns(map[string]bool{
+			"tx-generic-segmentation": true,
+			"rx-gro":                  true,
+			"rx-checksum":             true,
+		}, nil)
+
+		off := false
+		tuning = config.Tuning{
+			Sysctls: map[string]string{
+				"net.ipv4.tcp_keepalive_time": "600",
+				"net.core.somaxconn":          "4096",
+			},
+			TxQueueLen: 500,
+			GSO:        &off,
+		}
+
+		logger = lagertest.NewTestLogger("test")
+		tuner = &lib.Tuning{
+			SysctlAdapter:  fakeSysctlAdapter,
+			NetlinkAdapter: fakeNetlinkAdapter,
+			EthtoolAdapter: fakeEthtoolAdapter,
+			Logger:         logger,
+		}
+	})
+
+	It("applies the sysctls in order inside the container namespace", func() {
+		Expect(tuner.Setup(cfg, tuning)).To(Succeed())
+
+		Expect(containerNS.DoCallCount()).To(Equal(1))
+		Expect(fakeSysctlAdapter.SysctlCallCount()).To(Equal(4))
+		name, params := fakeSysctlAdapter.SysctlArgsForCall(1)
+		Expect(name).To(Equal("net.core.somaxconn"))
+		Expect(params).To(Equal([]string{"4096"}))
+		name, params = fakeSysctlAdapter.SysctlArgsForCall(3)
+		Expect(name).To(Equal("net.ipv4.tcp_keepalive_time"))
+		Expect(params).To(Equal([]string{"600"}))
+	})
+
+	It("sets the txqueuelen of the container device", func() {
+		Expect(tuner.Setup(cfg, tuning)).To(Succeed())
+
+		Expect(fakeNetlinkAdapter.LinkByNameArgsForCall(0)).To(Equal("eth0"))
+		link, qlen := fakeNetlinkAdapter.LinkSetTxQLenArgsForCall(0)
+		Expect(link).To(Equal(containerLink))
+		Expect(qlen).To(Equal(500))
+	})
+
+	It("changes only the configured offloads of the container device", func() {
+		Expect(tuner.Setup(cfg, tuning)).To(Succeed())
+
+		Expect(fakeEthtoolAdapter.ChangeCallCount()).To(Equal(1))
+		deviceName, features := fakeEthtoolAdapter.ChangeArgsForCall(0)
+		Expect(deviceName).To(Equal("eth0"))
+		Expect(features).To(Equal(map[string]bool{"tx-generic-segmentation": false}))
+	})
+
+	Context("when nothing is tuned", func() {
+		It("changes nothing", func() {
+			Expect(tuner.Setup(cfg, config.Tuning{})).To(Succeed())
+			Expect(fakeSysctlAdapter.SysctlCallCount()).To(Equal(0))
+			Expect(fakeNetlinkAdapter.LinkSetTxQLenCallCount()).To(Equal(0))
+			Expect(fakeEthtoolAdapter.FeaturesCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when reading a sysctl fails", func() {
+		BeforeEach(func() {
+			sysctlErrors[0] = errors.New("banana")
+		})
+
+		It("returns an error", func() {
+			Expect(tuner.Setup(cfg, tuning)).To(MatchError("reading sysctl net.core.somaxconn: banana"))
+			Expect(fakeSysctlAdapter.SysctlCallCount()).To(Equal(1))
+		})
+	})
+
+	Context("when setting a sysctl fails", func() {
+		BeforeEach(func() {
+			sysctlErrors[3] = errors.New("banana")
+		})
+
+		It("restores the sysctls that were already set", func() {
+			Expect(tuner.Setup(cfg, tuning)).To(MatchError("setting sysctl net.ipv4.tcp_keepalive_time: banana"))
+
+			Expect(fakeSysctlAdapter.SysctlCallCount()).To(Equal(5))
+			name, params := fakeSysctlAdapter.SysctlArgsForCall(4)
+			Expect(name).To(Equal("net.core.somaxconn"))
+			Expect(params).To(Equal([]string{"previous-net.core.somaxconn"}))
+			Expect(fakeNetlinkAdapter.LinkSetTxQLenCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when the container device cannot be found", func() {
+		BeforeEach(func() {
+			fakeNetlinkAdapter.LinkByNameReturns(nil, errors.New("banana"))
+		})
+
+		It("returns an error", func() {
+			Expect(tuner.Setup(cfg, tuning)).To(MatchError(`failed to find link "eth0": banana`))
+		})
+	})
+
+	Context("when setting the txqueuelen fails", func() {
+		BeforeEach(func() {
+			fakeNetlinkAdapter.LinkSetTxQLenReturns(errors.New("banana"))
+		})
+
+		It("returns an error and restores the sysctls", func() {
+			Expect(tuner.Setup(cfg, tuning)).To(MatchError("setting txqueuelen of eth0: banana"))
+			Expect(fakeSysctlAdapter.SysctlCallCount()).To(Equal(6))
+		})
+	})
+
+	Context("when reading the offloads fails", func() {
+		BeforeEach(func() {
+			fakeEthtoolAdapter.FeaturesReturns(nil, errors.New("banana"))
+		})
+
+		It("returns an error", func() {
+			Expect(tuner.Setup(cfg, tuning)).To(MatchError("reading features of eth0: banana"))
+			Expect(fakeEthtoolAdapter.ChangeCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when changing the offloads fails", func() {
+		BeforeEach(func() {
+			fakeEthtoolAdapter.ChangeReturns(errors.New("banana"))
+		})
+
+		It("rolls back everything that was applied in reverse order", func() {
+			Expect(tuner.Setup(cfg, tuning)).To(MatchError("setting features of eth0: banana"))
+
+			Expect(fakeNetlinkAdapter.LinkSetTxQLenCallCount()).To(Equal(2))
+			_, qlen := fakeNetlinkAdapter.LinkSetTxQLenArgsForCall(1)
+			Expect(qlen).To(Equal(1000))
+
+			Expect(fakeSysctlAdapter.SysctlCallCount()).To(Equal(6))
+			name, params := fakeSysctlAdapter.SysctlArgsForCall(4)
+			Expect(name).To(Equal("net.ipv4.tcp_keepalive_time"))
+			Expect(params).To(Equal([]string{"previous-net.ipv4.tcp_keepalive_time"}))
+			name, _ = fakeSysctlAdapter.SysctlArgsForCall(5)
+			Expect(name).To(Equal("net.core.somaxconn"))
+		})
+	})
+
+	Context("when a rollback fails", func() {
+		BeforeEach(func() {
+			fakeNetlinkAdapter.LinkSetTxQLenReturns(errors.New("banana"))
+			sysctlErrors[4] = errors.New("kiwi")
+		})
+
+		It("logs the error and keeps rolling back", func() {
+			Expect(tuner.Setup(cfg, tuning)).To(MatchError("setting txqueuelen of eth0: banana"))
+			Expect(fakeSysctlAdapter.SysctlCallCount()).To(Equal(6))
+			Expect(logger).To(gbytes.Say("rollback-failed.*kiwi"))
+		})
+	})
+})
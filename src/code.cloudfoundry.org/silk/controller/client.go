@@ -3,6 +3,8 @@ package controller
 import (
 	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"code.cloudfoundry.org/cf-networking-helpers/json_client"
 	"code.cloudfoundry.org/lager/v3"
@@ -14,6 +16,12 @@ func (n NonRetriableError) Error() string {
 	return string(n)
 }
 
+type InvalidRequestError string
+
+func (n InvalidRequestError) Error() string {
+	return string(n)
+}
+
 type Client struct {
 	JsonClient json_client.JsonClient
 }
@@ -22,6 +30,13 @@ type Lease struct {
 	UnderlayIP          string `json:"underlay_ip"`
 	OverlaySubnet       string `json:"overlay_subnet"`
 	OverlayHardwareAddr string `json:"overlay_hardware_addr"`
+	OverlaySubnetV6     string `json:"overlay_subnet_v6,omitempty"`
+}
+
+// Reservation pins an overlay subnet to the cell with the given underlay IP.
+type Reservation struct {
+	UnderlayIP    string `json:"underlay_ip"`
+	OverlaySubnet string `json:"overlay_subnet"`
 }
 
 type ReleaseLeaseRequest struct {
@@ -50,6 +65,24 @@ func (c *Client) GetActiveLeases() ([]Lease, error) {
 	return response.Leases, nil
 }
 
+// WatchLeases waits up to waitSeconds for lease changes after the given
+// revision. An empty epoch asks for a full resync.
+func (c *Client) WatchLeases(epoch string, revision uint64, waitSeconds int) (LeaseUpdate, error) {
+	query := url.Values{}
+	if epoch != "" {
+		query.Set("epoch", epoch)
+		query.Set("revision", strconv.FormatUint(revision, 10))
+	}
+	query.Set("wait_seconds", strconv.Itoa(waitSeconds))
+
+	var response LeaseUpdate
+	err := c.JsonClient.Do("GET", "/leases/watch?"+query.Encode(), nil, &response, "")
+	if err != nil {
+		return LeaseUpdate{}, err
+	}
+	return response, nil
+}
+
 func (c *Client) AcquireSubnetLease(underlayIP string) (Lease, error) {
 	return c.acquireLease(underlayIP, false)
 }
@@ -32,7 +32,7 @@ var _ = Describe("Client", func() {
 				{
 					"leases": [
 						{ "underlay_ip": "10.0.3.1", "overlay_subnet": "10.255.90.0/24" },
-						{ "underlay_ip": "10.0.5.9", "overlay_subnet": "10.253.30.0/24" },
+						{ "underlay_ip": "10.0.5.9", "overlay_subnet": "10.253.30.0/24", "overlay_subnet_v6": "fd00:0:0:1e::/64" },
 						{ "underlay_ip": "10.0.0.8", "overlay_subnet": "10.255.255.55/32" }
 					]
 				}`)
@@ -58,8 +58,9 @@ var _ = Describe("Client", func() {
 					OverlaySubnet: "10.255.90.0/24",
 				},
 				{
-					UnderlayIP:    "10.0.5.9",
-					OverlaySubnet: "10.253.30.0/24",
+					UnderlayIP:      "10.0.5.9",
+					OverlaySubnet:   "10.253.30.0/24",
+					OverlaySubnetV6: "fd00:0:0:1e::/64",
 				},
 				{
 					UnderlayIP:    "10.0.0.8",
@@ -80,6 +81,65 @@ var _ = Describe("Client", func() {
 		})
 	})
 
+	Describe("WatchLeases", func() {
+		BeforeEach(func() {
+			jsonClient.DoStub = func(method, route string, reqData, respData interface{}, token string) error {
+				respBytes := []byte(`
+				{
+					"epoch": "some-epoch",
+					"revision": 12,
+					"previous_revision": 9,
+					"resync": false,
+					"added": [ { "underlay_ip": "10.0.3.1", "overlay_subnet": "10.255.90.0/24" } ],
+					"removed": [ { "underlay_ip": "10.0.0.8", "overlay_subnet": "10.255.255.55/32" } ]
+				}`)
+				json.Unmarshal(respBytes, respData)
+				return nil
+			}
+		})
+
+		It("returns the lease changes", func() {
+			update, err := client.WatchLeases("some-epoch", 9, 30)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(jsonClient.DoCallCount()).To(Equal(1))
+			method, route, reqData, _, token := jsonClient.DoArgsForCall(0)
+			Expect(method).To(Equal("GET"))
+			Expect(route).To(Equal("/leases/watch?epoch=some-epoch&revision=9&wait_seconds=30"))
+			Expect(reqData).To(BeNil())
+			Expect(token).To(BeEmpty())
+
+			Expect(update).To(Equal(controller.LeaseUpdate{
+				Epoch:            "some-epoch",
+				Revision:         12,
+				PreviousRevision: 9,
+				Added:            []controller.Lease{{UnderlayIP: "10.0.3.1", OverlaySubnet: "10.255.90.0/24"}},
+				Removed:          []controller.Lease{{UnderlayIP: "10.0.0.8", OverlaySubnet: "10.255.255.55/32"}},
+			}))
+		})
+
+		Context("when there is no epoch", func() {
+			It("asks for a full resync", func() {
+				_, err := client.WatchLeases("", 9, 0)
+				Expect(err).NotTo(HaveOccurred())
+
+				_, route, _, _, _ := jsonClient.DoArgsForCall(0)
+				Expect(route).To(Equal("/leases/watch?wait_seconds=0"))
+			})
+		})
+
+		Context("when the json client fails", func() {
+			BeforeEach(func() {
+				jsonClient.DoStub = nil
+				jsonClient.DoReturns(errors.New("banana"))
+			})
+			It("returns the error", func() {
+				_, err := client.WatchLeases("some-epoch", 9, 30)
+				Expect(err).To(MatchError("banana"))
+			})
+		})
+	})
+
 	Describe("AcquireSubnetLease", func() {
 		Context("when acquring a single overlay IP", func() {
 			BeforeEach(func() {
@@ -16,13 +16,21 @@ type Config struct {
 	CACertFile                    string    `json:"ca_cert_file" validate:"nonzero"`
 	ServerCertFile                string    `json:"server_cert_file" validate:"nonzero"`
 	ServerKeyFile                 string    `json:"server_key_file" validate:"nonzero"`
+	AdminListenPort               int       `json:"admin_listen_port" validate:"min=0"`
+	AdminCACertFile               string    `json:"admin_ca_cert_file"`
 	Network                       []string  `json:"network" validate:"nonzero"`
 	SubnetPrefixLength            int       `json:"subnet_prefix_length" validate:"nonzero"`
+	SubnetPrefixLengthV6          int       `json:"subnet_prefix_length_v6" validate:"min=0,max=128"`
+	AllocationStrategy            string    `json:"allocation_strategy"`
 	Database                      db.Config `json:"database" validate:"nonzero"`
 	LeaseExpirationSeconds        int       `json:"lease_expiration_seconds" validate:"min=1"`
 	MetronPort                    int       `json:"metron_port" validate:"min=1"`
 	HealthCheckPort               int       `json:"health_check_port" validate:"min=1"`
+	PrometheusPort                int       `json:"prometheus_port" validate:"min=0"`
 	MetricsEmitSeconds            int       `json:"metrics_emit_seconds" validate:"min=1"`
+	OverlayNetworksRefreshSeconds int       `json:"overlay_networks_refresh_seconds" validate:"min=0"`
+	LeaseWatchRefreshSeconds      int       `json:"lease_watch_refresh_seconds" validate:"min=0"`
+	LeaseHistoryRetentionSeconds  int       `json:"lease_history_retention_seconds" validate:"min=0"`
 	StalenessThresholdSeconds     int       `json:"staleness_threshold_seconds" validate:"min=1"`
 	LogPrefix                     string    `json:"log_prefix" validate:"nonzero"`
 	MaxIdleConnections            int       `json:"max_idle_connections" validate:"min=0"`
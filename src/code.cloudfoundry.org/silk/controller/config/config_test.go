@@ -114,5 +114,6 @@ var _ = Describe("Config.ReadFromFile", func() {
 		Entry("invalid max_idle_connections", "max_idle_connections", -2, "MaxIdleConnections: less than min"),
 		Entry("invalid connections_max_lifetime_seconds", "connections_max_lifetime_seconds", -2, "MaxConnectionsLifetimeSeconds: less than min"),
 		Entry("invalid network", "network", []string{}, "Network: zero value"),
+		Entry("invalid subnet_prefix_length_v6", "subnet_prefix_length_v6", 129, "SubnetPrefixLengthV6: greater than max"),
 	)
 })
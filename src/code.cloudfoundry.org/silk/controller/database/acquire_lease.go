@@ -0,0 +1,353 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"code.cloudfoundry.org/cf-networking-helpers/db"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+// LeaseAllocator builds a lease out of the subnets that are still free. taken
+// holds the IPv4 subnets of the requested kind, including reserved ones, and
+// takenV6 holds the IPv6 subnets. It returns IPv4PoolExhaustedError or
+// IPv6PoolExhaustedError when no subnet of that family is free. When ipv4Only
+// is set the lease must not get an IPv6 subnet.
+type LeaseAllocator func(taken, takenV6 []string, ipv4Only bool) (*controller.Lease, error)
+
+var (
+	IPv4PoolExhaustedError = errors.New("no free ipv4 subnet")
+	IPv6PoolExhaustedError = errors.New("no free ipv6 subnet")
+)
+
+// AcquireLease allocates and stores a lease for the underlay IP in a single
+// transaction. Concurrent acquisitions, including those from other
+// controllers, are serialized on a lock row, so the allocator always sees
+// every committed lease. When a family has no free subnet the oldest expired
+// lease that holds a subnet of that family is deleted and its subnets are
+// offered to the allocator, at most once per family. When there is no IPv6
+// subnet to reclaim the allocator is asked for an IPv4-only lease instead,
+// so that the cell still joins the overlay. It returns nil when there is no
+// IPv4 subnet to reclaim.
+// Reclaims and acquisitions are recorded in lease_events in the same
+// transaction. It fails when the allocated subnet is leased to another
+// underlay IP. If the underlay IP already holds a lease, that lease is
+// returned. Committed reclaims are added to ReclaimedLeaseCount.
+func (d *DatabaseHandler) AcquireLease(underlayIP string, singleOverlayIP bool, expirationSeconds int, allocate LeaseAllocator) (*controller.Lease, error) {
+	timestamp, err := timestampForDriver(d.db.DriverName())
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %s", err)
+	}
+
+	lease, reclaimed, err := acquireLeaseInTx(tx, timestamp, underlayIP, singleOverlayIP, expirationSeconds, allocate)
+	if err != nil || lease == nil {
+		rollbackErr := tx.Rollback()
+		if err == nil && rollbackErr != nil {
+			err = fmt.Errorf("rollback transaction: %s", rollbackErr)
+		}
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("commit transaction: %s", err)
+	}
+	d.reclaimedLeases.Add(int64(reclaimed))
+	return lease, nil
+}
+
+// acquireLeaseInTx returns the lease and how many expired leases were
+// reclaimed for it.
+func acquireLeaseInTx(tx db.Transaction, timestamp, underlayIP string, singleOverlayIP bool, expirationSeconds int, allocate LeaseAllocator) (*controller.Lease, int, error) {
+	err := lockLeases(tx)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	existing, err := scanLease(tx.QueryRow(tx.Rebind("SELECT underlay_ip, overlay_subnet, overlay_hwaddr, overlay_subnet_v6 FROM subnets WHERE underlay_ip = ?"), underlayIP))
+	if err != nil {
+		return nil, 0, fmt.Errorf("getting lease for underlay ip: %s", err)
+	}
+	if existing != nil {
+		return existing, 0, nil
+	}
+
+	taken, err := selectStrings(tx, fmt.Sprintf("SELECT overlay_subnet FROM subnets WHERE %s UNION SELECT overlay_subnet FROM reservations", leaseKindCondition(singleOverlayIP)))
+	if err != nil {
+		return nil, 0, fmt.Errorf("selecting taken subnets: %s", err)
+	}
+	takenV6, err := selectStrings(tx, "SELECT overlay_subnet_v6 FROM subnets WHERE overlay_subnet_v6 IS NOT NULL")
+	if err != nil {
+		return nil, 0, fmt.Errorf("selecting taken ipv6 subnets: %s", err)
+	}
+
+	var reclaimed []*controller.Lease
+	ipv4Only := false
+	lease, err := allocate(taken, takenV6, ipv4Only)
+	for err == IPv4PoolExhaustedError || err == IPv6PoolExhaustedError {
+		ipv6 := err == IPv6PoolExhaustedError
+		if ipv6 && ipv4Only {
+			return nil, 0, fmt.Errorf("allocating ipv4-only lease: %s", err)
+		}
+
+		var expired *controller.Lease
+		if !reclaimedFamily(reclaimed, ipv6) {
+			expired, err = reclaimOldestExpired(tx, timestamp, underlayIP, reclaimCondition(singleOverlayIP, ipv6), expirationSeconds)
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+		if expired == nil {
+			if !ipv6 {
+				return nil, 0, nil
+			}
+			ipv4Only = true
+			lease, err = allocate(taken, takenV6, ipv4Only)
+			continue
+		}
+		reclaimed = append(reclaimed, expired)
+
+		taken = without(taken, expired.OverlaySubnet)
+		takenV6 = without(takenV6, expired.OverlaySubnetV6)
+		lease, err = allocate(taken, takenV6, ipv4Only)
+	}
+	if err != nil || lease == nil {
+		return nil, 0, err
+	}
+
+	// reserved subnets are always taken, so the allocator of a reserved
+	// lease cannot see whether the subnet is leased to another underlay IP
+	holder, err := scanLease(tx.QueryRow(tx.Rebind("SELECT underlay_ip, overlay_subnet, overlay_hwaddr, overlay_subnet_v6 FROM subnets WHERE overlay_subnet = ?"), lease.OverlaySubnet))
+	if err != nil {
+		return nil, 0, fmt.Errorf("getting lease for overlay subnet: %s", err)
+	}
+	if holder != nil {
+		return nil, 0, fmt.Errorf("overlay subnet %s is leased to %s", holder.OverlaySubnet, holder.UnderlayIP)
+	}
+
+	_, err = tx.Exec(tx.Rebind(fmt.Sprintf("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, overlay_subnet_v6, last_renewed_at) VALUES (?, ?, ?, ?, %s)", timestamp)), lease.UnderlayIP, lease.OverlaySubnet, lease.OverlayHardwareAddr, nullString(lease.OverlaySubnetV6))
+	if err != nil {
+		return nil, 0, fmt.Errorf("adding entry: %s", err)
+	}
+
+	event := controller.LeaseEvent{
+		Type:            controller.LeaseEventAcquire,
+		UnderlayIP:      lease.UnderlayIP,
+		OverlaySubnet:   lease.OverlaySubnet,
+		OverlaySubnetV6: lease.OverlaySubnetV6,
+	}
+	for _, expired := range reclaimed {
+		if expired.OverlaySubnet == lease.OverlaySubnet {
+			event.PreviousUnderlayIP = expired.UnderlayIP
+		}
+	}
+	err = insertLeaseEvent(tx, timestamp, event)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return lease, len(reclaimed), nil
+}
+
+// reclaimedFamily returns whether one of the reclaimed leases held a subnet
+// of the family.
+func reclaimedFamily(reclaimed []*controller.Lease, ipv6 bool) bool {
+	for _, expired := range reclaimed {
+		if ipv6 && expired.OverlaySubnetV6 != "" || !ipv6 && expired.OverlaySubnet != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// reclaimOldestExpired deletes the oldest expired lease that matches the
+// condition and records the reclaim for the underlay IP.
+func reclaimOldestExpired(tx db.Transaction, timestamp, underlayIP, condition string, expirationSeconds int) (*controller.Lease, error) {
+	expired, err := scanLease(tx.QueryRow(oldestExpiredQuery(condition, expirationSeconds, timestamp)))
+	if err != nil {
+		return nil, fmt.Errorf("getting oldest expired lease: %s", err)
+	}
+	if expired == nil {
+		return nil, nil
+	}
+
+	_, err = tx.Exec(tx.Rebind("DELETE FROM subnets WHERE underlay_ip = ?"), expired.UnderlayIP)
+	if err != nil {
+		return nil, fmt.Errorf("deleting expired lease: %s", err)
+	}
+
+	err = insertLeaseEvent(tx, timestamp, controller.LeaseEvent{
+		Type:               controller.LeaseEventReclaim,
+		UnderlayIP:         underlayIP,
+		OverlaySubnet:      expired.OverlaySubnet,
+		OverlaySubnetV6:    expired.OverlaySubnetV6,
+		PreviousUnderlayIP: expired.UnderlayIP,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return expired, nil
+}
+
+// AddIPv6Subnet gives the IPv4-only lease of the underlay IP the IPv6 subnet
+// that allocate picks out of the free ones, in a transaction serialized with
+// lease acquisitions. It returns the lease as it is when it already has an
+// IPv6 subnet, and nil when there is no such lease or allocate finds nothing
+// free.
+func (d *DatabaseHandler) AddIPv6Subnet(underlayIP string, allocate func(takenV6 []string) string) (*controller.Lease, error) {
+	timestamp, err := timestampForDriver(d.db.DriverName())
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %s", err)
+	}
+
+	lease, err := addIPv6SubnetInTx(tx, timestamp, underlayIP, allocate)
+	if err != nil || lease == nil {
+		rollbackErr := tx.Rollback()
+		if err == nil && rollbackErr != nil {
+			err = fmt.Errorf("rollback transaction: %s", rollbackErr)
+		}
+		return nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, fmt.Errorf("commit transaction: %s", err)
+	}
+	return lease, nil
+}
+
+func addIPv6SubnetInTx(tx db.Transaction, timestamp, underlayIP string, allocate func(takenV6 []string) string) (*controller.Lease, error) {
+	err := lockLeases(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	lease, err := scanLease(tx.QueryRow(tx.Rebind("SELECT underlay_ip, overlay_subnet, overlay_hwaddr, overlay_subnet_v6 FROM subnets WHERE underlay_ip = ?"), underlayIP))
+	if err != nil {
+		return nil, fmt.Errorf("getting lease for underlay ip: %s", err)
+	}
+	if lease == nil || lease.OverlaySubnetV6 != "" {
+		return lease, nil
+	}
+
+	takenV6, err := selectStrings(tx, "SELECT overlay_subnet_v6 FROM subnets WHERE overlay_subnet_v6 IS NOT NULL")
+	if err != nil {
+		return nil, fmt.Errorf("selecting taken ipv6 subnets: %s", err)
+	}
+
+	lease.OverlaySubnetV6 = allocate(takenV6)
+	if lease.OverlaySubnetV6 == "" {
+		return nil, nil
+	}
+
+	_, err = tx.Exec(tx.Rebind("UPDATE subnets SET overlay_subnet_v6 = ? WHERE underlay_ip = ?"), lease.OverlaySubnetV6, underlayIP)
+	if err != nil {
+		return nil, fmt.Errorf("updating entry: %s", err)
+	}
+
+	err = insertLeaseEvent(tx, timestamp, controller.LeaseEvent{
+		Type:            controller.LeaseEventAcquire,
+		UnderlayIP:      lease.UnderlayIP,
+		OverlaySubnet:   lease.OverlaySubnet,
+		OverlaySubnetV6: lease.OverlaySubnetV6,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return lease, nil
+}
+
+// lockLeases serializes the transaction with every other transaction that
+// takes the lock, until it commits or rolls back.
+func lockLeases(tx db.Transaction) error {
+	var id int
+	err := tx.QueryRow("SELECT id FROM lease_acquisition_lock WHERE id = 1 FOR UPDATE").Scan(&id)
+	if err != nil {
+		return fmt.Errorf("locking leases: %s", err)
+	}
+	return nil
+}
+
+func scanLease(row db.RowScanner) (*controller.Lease, error) {
+	var underlayIP, overlaySubnet, overlayHWAddr string
+	var overlaySubnetV6 sql.NullString
+	err := row.Scan(&underlayIP, &overlaySubnet, &overlayHWAddr, &overlaySubnetV6)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &controller.Lease{
+		UnderlayIP:          underlayIP,
+		OverlaySubnet:       overlaySubnet,
+		OverlayHardwareAddr: overlayHWAddr,
+		OverlaySubnetV6:     overlaySubnetV6.String,
+	}, nil
+}
+
+func selectStrings(tx db.Transaction, query string) ([]string, error) {
+	rows, err := tx.Queryx(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close() // untested
+
+	var values []string
+	for rows.Next() {
+		var value string
+		err := rows.Scan(&value)
+		if err != nil {
+			return nil, fmt.Errorf("parsing result: %s", err)
+		}
+		values = append(values, value)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("getting next row: %s", err) // untested
+	}
+	return values, nil
+}
+
+func without(values []string, value string) []string {
+	var remaining []string
+	for _, v := range values {
+		if v != value {
+			remaining = append(remaining, v)
+		}
+	}
+	return remaining
+}
+
+func leaseKindCondition(singleOverlayIP bool) string {
+	if singleOverlayIP {
+		return "overlay_subnet LIKE '%/32'"
+	}
+	return "overlay_subnet NOT LIKE '%/32'"
+}
+
+// reclaimCondition selects the leases whose reclaim frees a subnet of the
+// exhausted family. Only block leases hold IPv6 subnets.
+func reclaimCondition(singleOverlayIP, ipv6 bool) string {
+	if ipv6 {
+		return leaseKindCondition(false) + " AND overlay_subnet_v6 IS NOT NULL"
+	}
+	return leaseKindCondition(singleOverlayIP)
+}
+
+// oldestExpiredQuery never selects reserved subnets, they stay with the
+// underlay IP they are reserved for.
+func oldestExpiredQuery(condition string, expirationTime int, timestamp string) string {
+	return fmt.Sprintf("SELECT underlay_ip, overlay_subnet, overlay_hwaddr, overlay_subnet_v6 FROM subnets WHERE %s AND overlay_subnet NOT IN (SELECT overlay_subnet FROM reservations) AND last_renewed_at + %d <= %s ORDER BY last_renewed_at ASC LIMIT 1", condition, expirationTime, timestamp)
+}
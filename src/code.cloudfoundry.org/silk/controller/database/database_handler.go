@@ -4,7 +4,9 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"sync/atomic"
 
+	"code.cloudfoundry.org/cf-networking-helpers/db"
 	"code.cloudfoundry.org/silk/controller"
 	"github.com/jmoiron/sqlx"
 	migrate "github.com/rubenv/sql-migrate"
@@ -25,6 +27,7 @@ type Db interface {
 	QueryRow(query string, args ...interface{}) *sql.Row
 	DriverName() string
 	RawConnection() *sqlx.DB
+	Beginx() (db.Transaction, error)
 }
 
 //go:generate counterfeiter -o fakes/migrateAdapter.go --fake-name MigrateAdapter . migrateAdapter
@@ -36,6 +39,8 @@ type DatabaseHandler struct {
 	migrator   migrateAdapter
 	migrations *migrate.MemoryMigrationSource
 	db         Db
+
+	reclaimedLeases atomic.Int64
 }
 
 func NewDatabaseHandler(migrator migrateAdapter, db Db) *DatabaseHandler {
@@ -48,6 +53,36 @@ func NewDatabaseHandler(migrator migrateAdapter, db Db) *DatabaseHandler {
 					Up:   []string{createSubnetTable(db.DriverName())},
 					Down: []string{"DROP TABLE subnets"},
 				},
+				{
+					Id:   "2",
+					Up:   addIPv6ToSubnetTable(db.DriverName()),
+					Down: []string{"ALTER TABLE subnets DROP COLUMN overlay_subnet_v6"},
+				},
+				{
+					Id:   "3",
+					Up:   []string{createReservationTable(db.DriverName())},
+					Down: []string{"DROP TABLE reservations"},
+				},
+				{
+					Id:   "4",
+					Up:   createLeaseAcquisitionLockTable(),
+					Down: []string{"DROP TABLE lease_acquisition_lock"},
+				},
+				{
+					Id:   "5",
+					Up:   createLeaseEventsTable(db.DriverName()),
+					Down: []string{"DROP TABLE lease_events"},
+				},
+				{
+					Id:   "6",
+					Up:   []string{createOverlayNetworksTable(db.DriverName())},
+					Down: []string{"DROP TABLE overlay_networks"},
+				},
+				{
+					Id:   "7",
+					Up:   indexLeaseEventsByAge(),
+					Down: dropLeaseEventsAgeIndex(db.DriverName()),
+				},
 			},
 		},
 		db: db,
@@ -60,7 +95,7 @@ func (d *DatabaseHandler) CheckDatabase() error {
 }
 
 func (d *DatabaseHandler) All() ([]controller.Lease, error) {
-	rows, err := d.db.Query("SELECT underlay_ip, overlay_subnet, overlay_hwaddr FROM subnets")
+	rows, err := d.db.Query("SELECT underlay_ip, overlay_subnet, overlay_hwaddr, overlay_subnet_v6 FROM subnets")
 	if err != nil {
 		return nil, fmt.Errorf("selecting all subnets: %s", err)
 	}
@@ -74,7 +109,7 @@ func (d *DatabaseHandler) All() ([]controller.Lease, error) {
 }
 
 func (d *DatabaseHandler) AllSingleIPSubnets() ([]controller.Lease, error) {
-	rows, err := d.db.Query("SELECT underlay_ip, overlay_subnet, overlay_hwaddr FROM subnets WHERE overlay_subnet LIKE '%/32'")
+	rows, err := d.db.Query("SELECT underlay_ip, overlay_subnet, overlay_hwaddr, overlay_subnet_v6 FROM subnets WHERE overlay_subnet LIKE '%/32'")
 	if err != nil {
 		return nil, fmt.Errorf("selecting all single ip subnets: %s", err)
 	}
@@ -87,26 +122,12 @@ func (d *DatabaseHandler) AllSingleIPSubnets() ([]controller.Lease, error) {
 	return leases, nil
 }
 
-func (d *DatabaseHandler) AllBlockSubnets() ([]controller.Lease, error) {
-	rows, err := d.db.Query("SELECT underlay_ip, overlay_subnet, overlay_hwaddr FROM subnets WHERE overlay_subnet NOT LIKE '%/32'")
-	if err != nil {
-		return nil, fmt.Errorf("selecting all block subnets: %s", err)
-	}
-	defer rows.Close() // untested
-	leases, err := rowsToLeases(rows)
-	if err != nil {
-		return nil, fmt.Errorf("selecting all block subnets: %s", err)
-	}
-
-	return leases, nil
-}
-
 func (d *DatabaseHandler) AllActive(duration int) ([]controller.Lease, error) {
 	timestamp, err := timestampForDriver(d.db.DriverName())
 	if err != nil {
 		return nil, err
 	}
-	rows, err := d.db.Query(fmt.Sprintf("SELECT underlay_ip, overlay_subnet, overlay_hwaddr FROM subnets WHERE last_renewed_at + %d > %s", duration, timestamp))
+	rows, err := d.db.Query(fmt.Sprintf("SELECT underlay_ip, overlay_subnet, overlay_hwaddr, overlay_subnet_v6 FROM subnets WHERE last_renewed_at + %d > %s", duration, timestamp))
 	if err != nil {
 		return nil, fmt.Errorf("selecting all active subnets: %s", err)
 	}
@@ -120,47 +141,24 @@ func (d *DatabaseHandler) AllActive(duration int) ([]controller.Lease, error) {
 }
 
 func (d *DatabaseHandler) OldestExpiredBlockSubnet(expirationTime int) (*controller.Lease, error) {
-	timestamp, err := timestampForDriver(d.db.DriverName())
-	if err != nil {
-		return nil, err
-	}
-
-	var underlayIP, overlaySubnet, overlayHWAddr string
-	result := d.db.QueryRow(fmt.Sprintf("SELECT underlay_ip, overlay_subnet, overlay_hwaddr FROM subnets WHERE overlay_subnet NOT LIKE '%%/32' AND last_renewed_at + %d <= %s ORDER BY last_renewed_at ASC LIMIT 1", expirationTime, timestamp))
-	err = result.Scan(&underlayIP, &overlaySubnet, &overlayHWAddr)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, fmt.Errorf("scan result: %s", err)
-	}
-	return &controller.Lease{
-		UnderlayIP:          underlayIP,
-		OverlaySubnet:       overlaySubnet,
-		OverlayHardwareAddr: overlayHWAddr,
-	}, nil
+	return d.oldestExpired(false, expirationTime)
 }
 
 func (d *DatabaseHandler) OldestExpiredSingleIP(expirationTime int) (*controller.Lease, error) {
+	return d.oldestExpired(true, expirationTime)
+}
+
+func (d *DatabaseHandler) oldestExpired(singleOverlayIP bool, expirationTime int) (*controller.Lease, error) {
 	timestamp, err := timestampForDriver(d.db.DriverName())
 	if err != nil {
 		return nil, err
 	}
 
-	var underlayIP, overlaySubnet, overlayHWAddr string
-	result := d.db.QueryRow(fmt.Sprintf("SELECT underlay_ip, overlay_subnet, overlay_hwaddr FROM subnets WHERE overlay_subnet LIKE '%%/32' AND last_renewed_at + %d <= %s ORDER BY last_renewed_at ASC LIMIT 1", expirationTime, timestamp))
-	err = result.Scan(&underlayIP, &overlaySubnet, &overlayHWAddr)
+	lease, err := scanLease(d.db.QueryRow(oldestExpiredQuery(leaseKindCondition(singleOverlayIP), expirationTime, timestamp)))
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
 		return nil, fmt.Errorf("scan result: %s", err)
 	}
-	return &controller.Lease{
-		UnderlayIP:          underlayIP,
-		OverlaySubnet:       overlaySubnet,
-		OverlayHardwareAddr: overlayHWAddr,
-	}, nil
+	return lease, nil
 }
 
 func (d *DatabaseHandler) Migrate() (int, error) {
@@ -178,7 +176,7 @@ func (d *DatabaseHandler) AddEntry(lease controller.Lease) error {
 		return err
 	}
 
-	_, err = d.db.Exec(d.db.Rebind(fmt.Sprintf("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at) VALUES (?, ?, ?, %s)", timestamp)), lease.UnderlayIP, lease.OverlaySubnet, lease.OverlayHardwareAddr)
+	_, err = d.db.Exec(d.db.Rebind(fmt.Sprintf("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, overlay_subnet_v6, last_renewed_at) VALUES (?, ?, ?, ?, %s)", timestamp)), lease.UnderlayIP, lease.OverlaySubnet, lease.OverlayHardwareAddr, nullString(lease.OverlaySubnetV6))
 	if err != nil {
 		return fmt.Errorf("adding entry: %s", err)
 	}
@@ -206,8 +204,9 @@ func (d *DatabaseHandler) DeleteEntry(underlayIP string) error {
 
 func (d *DatabaseHandler) LeaseForUnderlayIP(underlayIP string) (*controller.Lease, error) {
 	var overlaySubnet, overlayHWAddr string
-	result := d.db.QueryRow(d.db.Rebind("SELECT overlay_subnet, overlay_hwaddr FROM subnets WHERE underlay_ip = ?"), underlayIP)
-	err := result.Scan(&overlaySubnet, &overlayHWAddr)
+	var overlaySubnetV6 sql.NullString
+	result := d.db.QueryRow(d.db.Rebind("SELECT overlay_subnet, overlay_hwaddr, overlay_subnet_v6 FROM subnets WHERE underlay_ip = ?"), underlayIP)
+	err := result.Scan(&overlaySubnet, &overlayHWAddr, &overlaySubnetV6)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
@@ -218,6 +217,26 @@ func (d *DatabaseHandler) LeaseForUnderlayIP(underlayIP string) (*controller.Lea
 		UnderlayIP:          underlayIP,
 		OverlaySubnet:       overlaySubnet,
 		OverlayHardwareAddr: overlayHWAddr,
+		OverlaySubnetV6:     overlaySubnetV6.String,
+	}, nil
+}
+
+func (d *DatabaseHandler) LeaseForOverlaySubnet(overlaySubnet string) (*controller.Lease, error) {
+	var underlayIP, overlayHWAddr string
+	var overlaySubnetV6 sql.NullString
+	result := d.db.QueryRow(d.db.Rebind("SELECT underlay_ip, overlay_hwaddr, overlay_subnet_v6 FROM subnets WHERE overlay_subnet = ?"), overlaySubnet)
+	err := result.Scan(&underlayIP, &overlayHWAddr, &overlaySubnetV6)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("scan result: %s", err)
+	}
+	return &controller.Lease{
+		UnderlayIP:          underlayIP,
+		OverlaySubnet:       overlaySubnet,
+		OverlayHardwareAddr: overlayHWAddr,
+		OverlaySubnetV6:     overlaySubnetV6.String,
 	}, nil
 }
 
@@ -244,11 +263,145 @@ func (d *DatabaseHandler) LastRenewedAtForUnderlayIP(underlayIP string) (int64,
 	return lastRenewedAt, nil
 }
 
+// ReservationCheck decides whether a reservation may be added, given the
+// reservation of its underlay IP, the reservation of its overlay subnet and
+// the lease that holds its overlay subnet, each nil when there is none. It
+// returns false when the reservation is already in place.
+type ReservationCheck func(forUnderlayIP, forOverlaySubnet *controller.Reservation, lease *controller.Lease) (bool, error)
+
+// ReserveSubnet checks and adds the reservation in a single transaction that
+// is serialized with lease acquisitions, so no lease can take the overlay
+// subnet in between. Errors returned by check are returned unchanged. It
+// returns whether the reservation was added.
+func (d *DatabaseHandler) ReserveSubnet(reservation controller.Reservation, check ReservationCheck) (bool, error) {
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return false, fmt.Errorf("begin transaction: %s", err)
+	}
+
+	added, err := reserveSubnetInTx(tx, reservation, check)
+	if err != nil || !added {
+		rollbackErr := tx.Rollback()
+		if err == nil && rollbackErr != nil {
+			err = fmt.Errorf("rollback transaction: %s", rollbackErr)
+		}
+		return false, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return false, fmt.Errorf("commit transaction: %s", err)
+	}
+	return true, nil
+}
+
+func reserveSubnetInTx(tx db.Transaction, reservation controller.Reservation, check ReservationCheck) (bool, error) {
+	err := lockLeases(tx)
+	if err != nil {
+		return false, err
+	}
+
+	forUnderlayIP, err := scanReservation(tx.QueryRow(tx.Rebind("SELECT underlay_ip, overlay_subnet FROM reservations WHERE underlay_ip = ?"), reservation.UnderlayIP))
+	if err != nil {
+		return false, fmt.Errorf("getting reservation for underlay ip: %s", err)
+	}
+	forOverlaySubnet, err := scanReservation(tx.QueryRow(tx.Rebind("SELECT underlay_ip, overlay_subnet FROM reservations WHERE overlay_subnet = ?"), reservation.OverlaySubnet))
+	if err != nil {
+		return false, fmt.Errorf("getting reservation for overlay subnet: %s", err)
+	}
+	lease, err := scanLease(tx.QueryRow(tx.Rebind("SELECT underlay_ip, overlay_subnet, overlay_hwaddr, overlay_subnet_v6 FROM subnets WHERE overlay_subnet = ?"), reservation.OverlaySubnet))
+	if err != nil {
+		return false, fmt.Errorf("getting lease for overlay subnet: %s", err)
+	}
+
+	add, err := check(forUnderlayIP, forOverlaySubnet, lease)
+	if err != nil || !add {
+		return false, err
+	}
+
+	_, err = tx.Exec(tx.Rebind("INSERT INTO reservations (underlay_ip, overlay_subnet) VALUES (?, ?)"), reservation.UnderlayIP, reservation.OverlaySubnet)
+	if err != nil {
+		return false, fmt.Errorf("adding reservation: %s", err)
+	}
+	return true, nil
+}
+
+func (d *DatabaseHandler) DeleteReservation(underlayIP string) error {
+	deleteRows, err := d.db.Exec(d.db.Rebind("DELETE FROM reservations WHERE underlay_ip = ?"), underlayIP)
+	if err != nil {
+		return fmt.Errorf("deleting reservation: %s", err)
+	}
+
+	rowsAffected, err := deleteRows.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("parse result: %s", err)
+	}
+
+	if rowsAffected == 0 {
+		return RecordNotAffectedError
+	}
+
+	return nil
+}
+
+func (d *DatabaseHandler) AllReservations() ([]controller.Reservation, error) {
+	rows, err := d.db.Query("SELECT underlay_ip, overlay_subnet FROM reservations")
+	if err != nil {
+		return nil, fmt.Errorf("selecting all reservations: %s", err)
+	}
+	defer rows.Close() // untested
+
+	reservations := []controller.Reservation{}
+	for rows.Next() {
+		var reservation controller.Reservation
+		err := rows.Scan(&reservation.UnderlayIP, &reservation.OverlaySubnet)
+		if err != nil {
+			return nil, fmt.Errorf("selecting all reservations: parsing result: %s", err)
+		}
+		reservations = append(reservations, reservation)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("selecting all reservations: getting next row: %s", err) // untested
+	}
+
+	return reservations, nil
+}
+
+func (d *DatabaseHandler) ReservationForUnderlayIP(underlayIP string) (*controller.Reservation, error) {
+	return d.reservationWhere("underlay_ip", underlayIP)
+}
+
+func (d *DatabaseHandler) ReservationForOverlaySubnet(overlaySubnet string) (*controller.Reservation, error) {
+	return d.reservationWhere("overlay_subnet", overlaySubnet)
+}
+
+func (d *DatabaseHandler) reservationWhere(column, value string) (*controller.Reservation, error) {
+	reservation, err := scanReservation(d.db.QueryRow(d.db.Rebind(fmt.Sprintf("SELECT underlay_ip, overlay_subnet FROM reservations WHERE %s = ?", column)), value))
+	if err != nil {
+		return nil, fmt.Errorf("scan result: %s", err)
+	}
+	return reservation, nil
+}
+
+func scanReservation(row db.RowScanner) (*controller.Reservation, error) {
+	var reservation controller.Reservation
+	err := row.Scan(&reservation.UnderlayIP, &reservation.OverlaySubnet)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &reservation, nil
+}
+
 func rowsToLeases(rows *sql.Rows) ([]controller.Lease, error) {
 	leases := []controller.Lease{}
 	for rows.Next() {
 		var underlayIP, overlaySubnet, overlayHWAddr string
-		err := rows.Scan(&underlayIP, &overlaySubnet, &overlayHWAddr)
+		var overlaySubnetV6 sql.NullString
+		err := rows.Scan(&underlayIP, &overlaySubnet, &overlayHWAddr, &overlaySubnetV6)
 		if err != nil {
 			return nil, fmt.Errorf("parsing result: %s", err)
 		}
@@ -256,6 +409,7 @@ func rowsToLeases(rows *sql.Rows) ([]controller.Lease, error) {
 			UnderlayIP:          underlayIP,
 			OverlaySubnet:       overlaySubnet,
 			OverlayHardwareAddr: overlayHWAddr,
+			OverlaySubnetV6:     overlaySubnetV6.String,
 		})
 	}
 	err := rows.Err()
@@ -290,6 +444,59 @@ func createSubnetTable(dbType string) string {
 	return ""
 }
 
+func createReservationTable(dbType string) string {
+	baseCreateTable := "CREATE TABLE IF NOT EXISTS reservations (" +
+		"%s" +
+		", underlay_ip varchar(45) NOT NULL" +
+		", overlay_subnet varchar(18) NOT NULL" +
+		", UNIQUE (underlay_ip)" +
+		", UNIQUE (overlay_subnet)" +
+		");"
+	mysqlId := "id int NOT NULL AUTO_INCREMENT, PRIMARY KEY (id)"
+	psqlId := "id SERIAL PRIMARY KEY"
+
+	switch dbType {
+	case Postgres:
+		return fmt.Sprintf(baseCreateTable, psqlId)
+	case MySQL:
+		return fmt.Sprintf(baseCreateTable, mysqlId)
+	}
+
+	return ""
+}
+
+// createLeaseAcquisitionLockTable holds the single row that lease acquisition
+// locks with SELECT ... FOR UPDATE, which works the same on both databases.
+func createLeaseAcquisitionLockTable() []string {
+	return []string{
+		"CREATE TABLE IF NOT EXISTS lease_acquisition_lock (id int NOT NULL, PRIMARY KEY (id));",
+		"INSERT INTO lease_acquisition_lock (id) VALUES (1);",
+	}
+}
+
+// addIPv6ToSubnetTable widens underlay_ip to fit IPv6 underlays and adds
+// the optional IPv6 overlay block of dual-stack leases.
+func addIPv6ToSubnetTable(dbType string) []string {
+	switch dbType {
+	case Postgres:
+		return []string{
+			"ALTER TABLE subnets ALTER COLUMN underlay_ip TYPE varchar(45)",
+			"ALTER TABLE subnets ADD COLUMN overlay_subnet_v6 varchar(49) UNIQUE",
+		}
+	case MySQL:
+		return []string{
+			"ALTER TABLE subnets MODIFY underlay_ip varchar(45) NOT NULL",
+			"ALTER TABLE subnets ADD COLUMN overlay_subnet_v6 varchar(49) NULL, ADD UNIQUE (overlay_subnet_v6)",
+		}
+	}
+
+	return nil
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
 func timestampForDriver(driverName string) (string, error) {
 	switch driverName {
 	case MySQL:
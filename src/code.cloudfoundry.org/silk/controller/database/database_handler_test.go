@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"math/rand"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -32,6 +33,7 @@ var _ = Describe("DatabaseHandler", func() {
 		lease2             controller.Lease
 		singleIPLease      controller.Lease
 		singleIPLease2     controller.Lease
+		dualStackLease     controller.Lease
 	)
 	BeforeEach(func() {
 		mockDb = &fakes.Db{}
@@ -77,6 +79,12 @@ var _ = Describe("DatabaseHandler", func() {
 			OverlaySubnet:       "10.255.0.19/32",
 			OverlayHardwareAddr: "ee:ee:0a:ff:11:12",
 		}
+		dualStackLease = controller.Lease{
+			UnderlayIP:          "fd12:3456::2c",
+			OverlaySubnet:       "10.255.42.0/24",
+			OverlayHardwareAddr: "ee:ee:0a:ff:2a:00",
+			OverlaySubnetV6:     "fd00:0:0:2a::/64",
+		}
 	})
 
 	AfterEach(func() {
@@ -110,6 +118,48 @@ var _ = Describe("DatabaseHandler", func() {
 							Up:   []string{"CREATE TABLE IF NOT EXISTS subnets (id SERIAL PRIMARY KEY, underlay_ip varchar(15) NOT NULL, overlay_subnet varchar(18) NOT NULL, overlay_hwaddr varchar(17) NOT NULL, last_renewed_at bigint NOT NULL, UNIQUE (underlay_ip), UNIQUE (overlay_subnet), UNIQUE (overlay_hwaddr));"},
 							Down: []string{"DROP TABLE subnets"},
 						},
+						{
+							Id: "2",
+							Up: []string{
+								"ALTER TABLE subnets ALTER COLUMN underlay_ip TYPE varchar(45)",
+								"ALTER TABLE subnets ADD COLUMN overlay_subnet_v6 varchar(49) UNIQUE",
+							},
+							Down: []string{"ALTER TABLE subnets DROP COLUMN overlay_subnet_v6"},
+						},
+						{
+							Id:   "3",
+							Up:   []string{"CREATE TABLE IF NOT EXISTS reservations (id SERIAL PRIMARY KEY, underlay_ip varchar(45) NOT NULL, overlay_subnet varchar(18) NOT NULL, UNIQUE (underlay_ip), UNIQUE (overlay_subnet));"},
+							Down: []string{"DROP TABLE reservations"},
+						},
+						{
+							Id: "4",
+							Up: []string{
+								"CREATE TABLE IF NOT EXISTS lease_acquisition_lock (id int NOT NULL, PRIMARY KEY (id));",
+								"INSERT INTO lease_acquisition_lock (id) VALUES (1);",
+							},
+							Down: []string{"DROP TABLE lease_acquisition_lock"},
+						},
+						{
+							Id: "5",
+							Up: []string{
+								"CREATE TABLE IF NOT EXISTS lease_events (id BIGSERIAL PRIMARY KEY, event_type varchar(32) NOT NULL, underlay_ip varchar(45) NOT NULL, overlay_subnet varchar(18) NOT NULL, overlay_subnet_v6 varchar(49) NULL, previous_underlay_ip varchar(45) NULL, created_at bigint NOT NULL);",
+								"CREATE INDEX lease_events_underlay_ip ON lease_events (underlay_ip)",
+								"CREATE INDEX lease_events_overlay_subnet ON lease_events (overlay_subnet)",
+								"CREATE INDEX lease_events_overlay_subnet_v6 ON lease_events (overlay_subnet_v6)",
+								"CREATE INDEX lease_events_previous_underlay_ip ON lease_events (previous_underlay_ip)",
+							},
+							Down: []string{"DROP TABLE lease_events"},
+						},
+						{
+							Id:   "6",
+							Up:   []string{"CREATE TABLE IF NOT EXISTS overlay_networks (id SERIAL PRIMARY KEY, cidr varchar(49) NOT NULL, state varchar(16) NOT NULL, updated_at bigint NOT NULL, UNIQUE (cidr));"},
+							Down: []string{"DROP TABLE overlay_networks"},
+						},
+						{
+							Id:   "7",
+							Up:   []string{"CREATE INDEX lease_events_created_at ON lease_events (created_at)"},
+							Down: []string{"DROP INDEX lease_events_created_at"},
+						},
 					},
 				}))
 			} else {
@@ -120,6 +170,48 @@ var _ = Describe("DatabaseHandler", func() {
 							Up:   []string{"CREATE TABLE IF NOT EXISTS subnets (id int NOT NULL AUTO_INCREMENT, PRIMARY KEY (id), underlay_ip varchar(15) NOT NULL, overlay_subnet varchar(18) NOT NULL, overlay_hwaddr varchar(17) NOT NULL, last_renewed_at bigint NOT NULL, UNIQUE (underlay_ip), UNIQUE (overlay_subnet), UNIQUE (overlay_hwaddr));"},
 							Down: []string{"DROP TABLE subnets"},
 						},
+						{
+							Id: "2",
+							Up: []string{
+								"ALTER TABLE subnets MODIFY underlay_ip varchar(45) NOT NULL",
+								"ALTER TABLE subnets ADD COLUMN overlay_subnet_v6 varchar(49) NULL, ADD UNIQUE (overlay_subnet_v6)",
+							},
+							Down: []string{"ALTER TABLE subnets DROP COLUMN overlay_subnet_v6"},
+						},
+						{
+							Id:   "3",
+							Up:   []string{"CREATE TABLE IF NOT EXISTS reservations (id int NOT NULL AUTO_INCREMENT, PRIMARY KEY (id), underlay_ip varchar(45) NOT NULL, overlay_subnet varchar(18) NOT NULL, UNIQUE (underlay_ip), UNIQUE (overlay_subnet));"},
+							Down: []string{"DROP TABLE reservations"},
+						},
+						{
+							Id: "4",
+							Up: []string{
+								"CREATE TABLE IF NOT EXISTS lease_acquisition_lock (id int NOT NULL, PRIMARY KEY (id));",
+								"INSERT INTO lease_acquisition_lock (id) VALUES (1);",
+							},
+							Down: []string{"DROP TABLE lease_acquisition_lock"},
+						},
+						{
+							Id: "5",
+							Up: []string{
+								"CREATE TABLE IF NOT EXISTS lease_events (id bigint NOT NULL AUTO_INCREMENT, PRIMARY KEY (id), event_type varchar(32) NOT NULL, underlay_ip varchar(45) NOT NULL, overlay_subnet varchar(18) NOT NULL, overlay_subnet_v6 varchar(49) NULL, previous_underlay_ip varchar(45) NULL, created_at bigint NOT NULL);",
+								"CREATE INDEX lease_events_underlay_ip ON lease_events (underlay_ip)",
+								"CREATE INDEX lease_events_overlay_subnet ON lease_events (overlay_subnet)",
+								"CREATE INDEX lease_events_overlay_subnet_v6 ON lease_events (overlay_subnet_v6)",
+								"CREATE INDEX lease_events_previous_underlay_ip ON lease_events (previous_underlay_ip)",
+							},
+							Down: []string{"DROP TABLE lease_events"},
+						},
+						{
+							Id:   "6",
+							Up:   []string{"CREATE TABLE IF NOT EXISTS overlay_networks (id int NOT NULL AUTO_INCREMENT, PRIMARY KEY (id), cidr varchar(49) NOT NULL, state varchar(16) NOT NULL, updated_at bigint NOT NULL, UNIQUE (cidr));"},
+							Down: []string{"DROP TABLE overlay_networks"},
+						},
+						{
+							Id:   "7",
+							Up:   []string{"CREATE INDEX lease_events_created_at ON lease_events (created_at)"},
+							Down: []string{"DROP INDEX lease_events_created_at ON lease_events"},
+						},
 					},
 				}))
 			}
@@ -153,10 +245,21 @@ var _ = Describe("DatabaseHandler", func() {
 			Expect(leases).To(ContainElement(lease))
 		})
 
+		Context("when the lease is dual-stack", func() {
+			It("adds an entry with the ipv6 subnet to the DB", func() {
+				err := databaseHandler.AddEntry(dualStackLease)
+				Expect(err).NotTo(HaveOccurred())
+
+				leases, err := databaseHandler.All()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(leases).To(ContainElement(dualStackLease))
+			})
+		})
+
 		Context("when the database type is postgres", func() {
 			BeforeEach(func() {
 				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
-				mockDb.RebindReturns("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at) VALUES ($1, $2, $3, EXTRACT(EPOCH FROM now())::numeric::integer)")
+				mockDb.RebindReturns("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, overlay_subnet_v6, last_renewed_at) VALUES ($1, $2, $3, $4, EXTRACT(EPOCH FROM now())::numeric::integer)")
 				mockDb.DriverNameReturns("postgres")
 			})
 			It("adds an entry to the DB", func() {
@@ -165,9 +268,9 @@ var _ = Describe("DatabaseHandler", func() {
 
 				Expect(mockDb.ExecCallCount()).To(Equal(1))
 				query, args := mockDb.ExecArgsForCall(0)
-				Expect(mockDb.RebindArgsForCall(0)).To(Equal("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at) VALUES (?, ?, ?, EXTRACT(EPOCH FROM now())::numeric::integer)"))
-				Expect(query).To(Equal("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at) VALUES ($1, $2, $3, EXTRACT(EPOCH FROM now())::numeric::integer)"))
-				Expect(args).To(Equal([]interface{}{"10.244.11.22", "10.255.17.0/24", "ee:ee:0a:ff:11:00"}))
+				Expect(mockDb.RebindArgsForCall(0)).To(Equal("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, overlay_subnet_v6, last_renewed_at) VALUES (?, ?, ?, ?, EXTRACT(EPOCH FROM now())::numeric::integer)"))
+				Expect(query).To(Equal("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, overlay_subnet_v6, last_renewed_at) VALUES ($1, $2, $3, $4, EXTRACT(EPOCH FROM now())::numeric::integer)"))
+				Expect(args).To(Equal([]interface{}{"10.244.11.22", "10.255.17.0/24", "ee:ee:0a:ff:11:00", sql.NullString{}}))
 			})
 		})
 
@@ -175,7 +278,7 @@ var _ = Describe("DatabaseHandler", func() {
 			BeforeEach(func() {
 				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
 				mockDb.DriverNameReturns("mysql")
-				mockDb.RebindReturns("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at) VALUES (?, ?, ?, UNIX_TIMESTAMP())")
+				mockDb.RebindReturns("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, overlay_subnet_v6, last_renewed_at) VALUES (?, ?, ?, ?, UNIX_TIMESTAMP())")
 			})
 			It("adds an entry to the DB", func() {
 				err := databaseHandler.AddEntry(lease)
@@ -183,9 +286,9 @@ var _ = Describe("DatabaseHandler", func() {
 
 				Expect(mockDb.ExecCallCount()).To(Equal(1))
 				query, args := mockDb.ExecArgsForCall(0)
-				Expect(mockDb.RebindArgsForCall(0)).To(Equal("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at) VALUES (?, ?, ?, UNIX_TIMESTAMP())"))
-				Expect(query).To(Equal("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, last_renewed_at) VALUES (?, ?, ?, UNIX_TIMESTAMP())"))
-				Expect(args).To(Equal([]interface{}{"10.244.11.22", "10.255.17.0/24", "ee:ee:0a:ff:11:00"}))
+				Expect(mockDb.RebindArgsForCall(0)).To(Equal("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, overlay_subnet_v6, last_renewed_at) VALUES (?, ?, ?, ?, UNIX_TIMESTAMP())"))
+				Expect(query).To(Equal("INSERT INTO subnets (underlay_ip, overlay_subnet, overlay_hwaddr, overlay_subnet_v6, last_renewed_at) VALUES (?, ?, ?, ?, UNIX_TIMESTAMP())"))
+				Expect(args).To(Equal([]interface{}{"10.244.11.22", "10.255.17.0/24", "ee:ee:0a:ff:11:00", sql.NullString{}}))
 			})
 		})
 
@@ -295,6 +398,18 @@ var _ = Describe("DatabaseHandler", func() {
 			Expect(*found).To(Equal(lease))
 		})
 
+		Context("when the lease is dual-stack with an ipv6 underlay", func() {
+			BeforeEach(func() {
+				err := databaseHandler.AddEntry(dualStackLease)
+				Expect(err).NotTo(HaveOccurred())
+			})
+			It("returns the lease including the ipv6 subnet", func() {
+				found, err := databaseHandler.LeaseForUnderlayIP("fd12:3456::2c")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(*found).To(Equal(dualStackLease))
+			})
+		})
+
 		Context("when there is no entry for the underlay ip", func() {
 			It("returns nil", func() {
 				entry, err := databaseHandler.LeaseForUnderlayIP("10.244.11.23")
@@ -457,65 +572,6 @@ var _ = Describe("DatabaseHandler", func() {
 		})
 	})
 
-	Describe("AllBlockSubnets", func() {
-		BeforeEach(func() {
-			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
-			_, err := databaseHandler.Migrate()
-			Expect(err).NotTo(HaveOccurred())
-			err = databaseHandler.AddEntry(lease)
-			Expect(err).NotTo(HaveOccurred())
-			err = databaseHandler.AddEntry(lease2)
-			Expect(err).NotTo(HaveOccurred())
-			err = databaseHandler.AddEntry(singleIPLease)
-			Expect(err).NotTo(HaveOccurred())
-			err = databaseHandler.AddEntry(singleIPLease2)
-			Expect(err).NotTo(HaveOccurred())
-		})
-
-		It("returns all the saved subnets", func() {
-			leases, err := databaseHandler.AllBlockSubnets()
-			Expect(err).NotTo(HaveOccurred())
-
-			Expect(len(leases)).To(Equal(2))
-			Expect(leases).To(ConsistOf([]controller.Lease{
-				lease,
-				lease2,
-			}))
-		})
-
-		Context("when the query fails", func() {
-			BeforeEach(func() {
-				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
-				mockDb.QueryReturns(nil, errors.New("strawberry"))
-			})
-			It("returns an error", func() {
-				_, err := databaseHandler.AllBlockSubnets()
-				Expect(err).To(MatchError("selecting all block subnets: strawberry"))
-			})
-		})
-
-		Context("when the parsing the result fails", func() {
-			var rows *sql.Rows
-			BeforeEach(func() {
-				var err error
-				rows, err = realDb.Query("SELECT 1")
-				Expect(err).NotTo(HaveOccurred())
-
-				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
-				mockDb.QueryReturns(rows, nil)
-			})
-
-			AfterEach(func() {
-				Expect(rows.Close()).To(Succeed())
-			})
-
-			It("returns an error", func() {
-				_, err := databaseHandler.AllBlockSubnets()
-				Expect(err.Error()).To(ContainSubstring("selecting all block subnets: parsing result"))
-			})
-		})
-	})
-
 	Describe("AllSingleIPSubnets", func() {
 		BeforeEach(func() {
 			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
@@ -728,6 +784,925 @@ var _ = Describe("DatabaseHandler", func() {
 		})
 	})
 
+	Describe("OldestExpiredBlockSubnet when the expired subnet is reserved", func() {
+		BeforeEach(func() {
+			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := databaseHandler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databaseHandler.AddEntry(lease)).To(Succeed())
+			Expect(databaseHandler.AddEntry(singleIPLease)).To(Succeed())
+			Expect(reserveSubnet(databaseHandler, controller.Reservation{
+				UnderlayIP:    lease.UnderlayIP,
+				OverlaySubnet: lease.OverlaySubnet,
+			})).To(Succeed())
+			Expect(reserveSubnet(databaseHandler, controller.Reservation{
+				UnderlayIP:    singleIPLease.UnderlayIP,
+				OverlaySubnet: singleIPLease.OverlaySubnet,
+			})).To(Succeed())
+		})
+
+		It("never reclaims reserved subnets", func() {
+			expiredLease, err := databaseHandler.OldestExpiredBlockSubnet(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expiredLease).To(BeNil())
+
+			expiredLease, err = databaseHandler.OldestExpiredSingleIP(0)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(expiredLease).To(BeNil())
+		})
+	})
+
+	Describe("AcquireLease", func() {
+		var allocatedLease controller.Lease
+
+		BeforeEach(func() {
+			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := databaseHandler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databaseHandler.AddEntry(lease)).To(Succeed())
+			Expect(databaseHandler.AddEntry(singleIPLease)).To(Succeed())
+			Expect(databaseHandler.AddEntry(dualStackLease)).To(Succeed())
+			Expect(reserveSubnet(databaseHandler, controller.Reservation{
+				UnderlayIP:    "10.244.99.1",
+				OverlaySubnet: "10.255.99.0/24",
+			})).To(Succeed())
+
+			allocatedLease = controller.Lease{
+				UnderlayIP:          "10.244.5.6",
+				OverlaySubnet:       "10.255.76.0/24",
+				OverlayHardwareAddr: "ee:ee:0a:ff:4c:00",
+				OverlaySubnetV6:     "fd00:0:0:4c::/64",
+			}
+		})
+
+		It("stores the allocated lease", func() {
+			var taken, takenV6 []string
+			acquired, err := databaseHandler.AcquireLease("10.244.5.6", false, 60, func(t, tV6 []string, _ bool) (*controller.Lease, error) {
+				taken, takenV6 = t, tV6
+				return &allocatedLease, nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(acquired).To(Equal(&allocatedLease))
+
+			Expect(taken).To(ConsistOf(lease.OverlaySubnet, dualStackLease.OverlaySubnet, "10.255.99.0/24"))
+			Expect(takenV6).To(ConsistOf(dualStackLease.OverlaySubnetV6))
+
+			stored, err := databaseHandler.LeaseForUnderlayIP("10.244.5.6")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stored).To(Equal(&allocatedLease))
+
+			events, err := databaseHandler.LeaseEvents(controller.LeaseHistoryQuery{UnderlayIP: "10.244.5.6", Limit: 10})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Type).To(Equal(controller.LeaseEventAcquire))
+			Expect(events[0].OverlaySubnet).To(Equal(allocatedLease.OverlaySubnet))
+			Expect(events[0].OverlaySubnetV6).To(Equal(allocatedLease.OverlaySubnetV6))
+			Expect(events[0].PreviousUnderlayIP).To(BeEmpty())
+			Expect(events[0].Timestamp).To(BeNumerically(">", 0))
+		})
+
+		It("only offers single ip subnets as taken for single ip leases", func() {
+			var taken []string
+			_, err := databaseHandler.AcquireLease("10.244.5.6", true, 60, func(t, _ []string, _ bool) (*controller.Lease, error) {
+				taken = t
+				return nil, nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(taken).To(ConsistOf(singleIPLease.OverlaySubnet, "10.255.99.0/24"))
+		})
+
+		Context("when the underlay ip already holds a lease", func() {
+			It("returns that lease without allocating", func() {
+				acquired, err := databaseHandler.AcquireLease(lease.UnderlayIP, false, 60, func(_, _ []string, _ bool) (*controller.Lease, error) {
+					Fail("allocate should not be called")
+					return nil, nil
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(acquired).To(Equal(&lease))
+			})
+		})
+
+		Context("when nothing is free", func() {
+			It("reclaims the oldest expired lease and offers its subnets", func() {
+				var calls [][]string
+				acquired, err := databaseHandler.AcquireLease("10.244.5.6", false, 0, func(taken, takenV6 []string, _ bool) (*controller.Lease, error) {
+					calls = append(calls, taken)
+					if len(calls) == 1 {
+						return nil, database.IPv4PoolExhaustedError
+					}
+					return &allocatedLease, nil
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(acquired).To(Equal(&allocatedLease))
+				Expect(calls).To(HaveLen(2))
+				Expect(calls[1]).To(HaveLen(2))
+
+				leases, err := databaseHandler.All()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(leases).To(HaveLen(3))
+				Expect(leases).To(ContainElement(allocatedLease))
+
+				events, err := databaseHandler.LeaseEvents(controller.LeaseHistoryQuery{UnderlayIP: "10.244.5.6", Limit: 10})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(events).To(HaveLen(2))
+				Expect(events[0].Type).To(Equal(controller.LeaseEventAcquire))
+				Expect(events[1].Type).To(Equal(controller.LeaseEventReclaim))
+				Expect(events[1].UnderlayIP).To(Equal("10.244.5.6"))
+				Expect(events[1].PreviousUnderlayIP).To(BeElementOf(lease.UnderlayIP, dualStackLease.UnderlayIP))
+				Expect(databaseHandler.ReclaimedLeaseCount()).To(Equal(int64(1)))
+			})
+
+			It("returns nil when no lease has expired", func() {
+				acquired, err := databaseHandler.AcquireLease("10.244.5.6", false, 3600, func(_, _ []string, _ bool) (*controller.Lease, error) {
+					return nil, database.IPv4PoolExhaustedError
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(acquired).To(BeNil())
+
+				leases, err := databaseHandler.All()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(leases).To(HaveLen(3))
+			})
+		})
+
+		Context("when only the ipv6 pool is exhausted", func() {
+			It("reclaims the oldest expired lease that holds an ipv6 subnet", func() {
+				var calls [][]string
+				acquired, err := databaseHandler.AcquireLease("10.244.5.6", false, 0, func(taken, takenV6 []string, _ bool) (*controller.Lease, error) {
+					calls = append(calls, takenV6)
+					if len(calls) == 1 {
+						return nil, database.IPv6PoolExhaustedError
+					}
+					return &allocatedLease, nil
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(acquired).To(Equal(&allocatedLease))
+				Expect(calls).To(HaveLen(2))
+				Expect(calls[1]).To(BeEmpty())
+
+				leases, err := databaseHandler.All()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(leases).To(ConsistOf(lease, singleIPLease, allocatedLease))
+
+				events, err := databaseHandler.LeaseEvents(controller.LeaseHistoryQuery{UnderlayIP: "10.244.5.6", Limit: 10})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(events).To(HaveLen(2))
+				Expect(events[1].Type).To(Equal(controller.LeaseEventReclaim))
+				Expect(events[1].PreviousUnderlayIP).To(Equal(dualStackLease.UnderlayIP))
+			})
+
+			It("reclaims at most one lease for each family and then acquires an ipv4-only lease", func() {
+				var ipv4Only []bool
+				acquired, err := databaseHandler.AcquireLease("10.244.5.6", false, 0, func(_, _ []string, v4Only bool) (*controller.Lease, error) {
+					ipv4Only = append(ipv4Only, v4Only)
+					if !v4Only {
+						return nil, database.IPv6PoolExhaustedError
+					}
+					allocatedLease.OverlaySubnetV6 = ""
+					return &allocatedLease, nil
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(acquired).To(Equal(&allocatedLease))
+				Expect(ipv4Only).To(Equal([]bool{false, false, true}))
+
+				leases, err := databaseHandler.All()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(leases).To(ConsistOf(lease, singleIPLease, allocatedLease))
+			})
+
+			Context("when no lease with an ipv6 subnet has expired", func() {
+				It("acquires an ipv4-only lease without reclaiming", func() {
+					var ipv4Only []bool
+					acquired, err := databaseHandler.AcquireLease("10.244.5.6", false, 3600, func(_, _ []string, v4Only bool) (*controller.Lease, error) {
+						ipv4Only = append(ipv4Only, v4Only)
+						if !v4Only {
+							return nil, database.IPv6PoolExhaustedError
+						}
+						allocatedLease.OverlaySubnetV6 = ""
+						return &allocatedLease, nil
+					})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(acquired).To(Equal(&allocatedLease))
+					Expect(acquired.OverlaySubnetV6).To(BeEmpty())
+					Expect(ipv4Only).To(Equal([]bool{false, true}))
+
+					leases, err := databaseHandler.All()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(leases).To(ConsistOf(lease, singleIPLease, dualStackLease, allocatedLease))
+
+					events, err := databaseHandler.LeaseEvents(controller.LeaseHistoryQuery{UnderlayIP: "10.244.5.6", Limit: 10})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(events).To(HaveLen(1))
+					Expect(events[0].Type).To(Equal(controller.LeaseEventAcquire))
+					Expect(events[0].OverlaySubnetV6).To(BeEmpty())
+					Expect(databaseHandler.ReclaimedLeaseCount()).To(BeZero())
+				})
+			})
+
+			Context("when the allocator keeps asking for an ipv6 subnet", func() {
+				It("returns an error and stores nothing", func() {
+					_, err := databaseHandler.AcquireLease("10.244.5.6", false, 3600, func(_, _ []string, _ bool) (*controller.Lease, error) {
+						return nil, database.IPv6PoolExhaustedError
+					})
+					Expect(err).To(MatchError("allocating ipv4-only lease: no free ipv6 subnet"))
+
+					leases, err := databaseHandler.All()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(leases).To(ConsistOf(lease, singleIPLease, dualStackLease))
+				})
+			})
+		})
+
+		Context("when allocating fails", func() {
+			It("rolls back the reclaimed lease", func() {
+				calls := 0
+				_, err := databaseHandler.AcquireLease("10.244.5.6", false, 0, func(_, _ []string, _ bool) (*controller.Lease, error) {
+					calls++
+					if calls == 1 {
+						return nil, database.IPv4PoolExhaustedError
+					}
+					return nil, errors.New("kiwi")
+				})
+				Expect(err).To(MatchError("kiwi"))
+
+				leases, err := databaseHandler.All()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(leases).To(ConsistOf(lease, singleIPLease, dualStackLease))
+
+				events, err := databaseHandler.LeaseEvents(controller.LeaseHistoryQuery{Limit: 10})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(events).To(BeEmpty())
+				Expect(databaseHandler.ReclaimedLeaseCount()).To(BeZero())
+			})
+		})
+
+		Context("when the allocated subnet is already leased", func() {
+			It("returns an error and stores nothing", func() {
+				allocatedLease.OverlaySubnet = lease.OverlaySubnet
+				_, err := databaseHandler.AcquireLease("10.244.5.6", false, 60, func(_, _ []string, _ bool) (*controller.Lease, error) {
+					return &allocatedLease, nil
+				})
+				Expect(err).To(MatchError("overlay subnet 10.255.17.0/24 is leased to 10.244.11.22"))
+
+				stored, err := databaseHandler.LeaseForUnderlayIP("10.244.5.6")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stored).To(BeNil())
+			})
+
+			It("sees a lease that was acquired while it waited for the lock", func() {
+				reserved := controller.Lease{
+					UnderlayIP:          "10.244.99.1",
+					OverlaySubnet:       "10.255.99.0/24",
+					OverlayHardwareAddr: "ee:ee:0a:ff:63:00",
+				}
+				reservedErr := make(chan error, 1)
+				_, err := databaseHandler.AcquireLease("10.244.5.6", false, 60, func(_, _ []string, _ bool) (*controller.Lease, error) {
+					go func() {
+						_, err := databaseHandler.AcquireLease(reserved.UnderlayIP, false, 60, func(_, _ []string, _ bool) (*controller.Lease, error) {
+							return &reserved, nil
+						})
+						reservedErr <- err
+					}()
+					allocatedLease.OverlaySubnet = reserved.OverlaySubnet
+					return &allocatedLease, nil
+				})
+				Expect(err).NotTo(HaveOccurred())
+
+				Eventually(reservedErr).Should(Receive(MatchError("overlay subnet 10.255.99.0/24 is leased to 10.244.5.6")))
+				stored, err := databaseHandler.LeaseForUnderlayIP(reserved.UnderlayIP)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stored).To(BeNil())
+			})
+		})
+
+		It("never hands the same subnet to concurrent acquisitions", func() {
+			pool := []string{"10.255.1.0/24", "10.255.2.0/24", "10.255.3.0/24", "10.255.4.0/24", "10.255.5.0/24"}
+			results := make(chan *controller.Lease, len(pool))
+			errs := make(chan error, len(pool))
+			var wg sync.WaitGroup
+			for i := range pool {
+				wg.Add(1)
+				go func(i int) {
+					defer GinkgoRecover()
+					defer wg.Done()
+					underlayIP := fmt.Sprintf("10.244.6.%d", i)
+					acquired, err := databaseHandler.AcquireLease(underlayIP, false, 3600, func(taken, _ []string, _ bool) (*controller.Lease, error) {
+						for _, subnet := range pool {
+							if !contains(taken, subnet) {
+								return &controller.Lease{
+									UnderlayIP:          underlayIP,
+									OverlaySubnet:       subnet,
+									OverlayHardwareAddr: fmt.Sprintf("ee:ee:0a:ff:%02x:00", i),
+								}, nil
+							}
+						}
+						return nil, nil
+					})
+					errs <- err
+					results <- acquired
+				}(i)
+			}
+			wg.Wait()
+			close(results)
+			close(errs)
+
+			for err := range errs {
+				Expect(err).NotTo(HaveOccurred())
+			}
+			var subnets []string
+			for acquired := range results {
+				Expect(acquired).NotTo(BeNil())
+				subnets = append(subnets, acquired.OverlaySubnet)
+			}
+			Expect(subnets).To(ConsistOf(pool))
+		})
+
+		Context("when beginning the transaction fails", func() {
+			BeforeEach(func() {
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+				mockDb.BeginxReturns(nil, errors.New("apple"))
+			})
+			It("returns an error", func() {
+				_, err := databaseHandler.AcquireLease("10.244.5.6", false, 60, nil)
+				Expect(err).To(MatchError("begin transaction: apple"))
+			})
+		})
+
+		Context("when the database type is not supported", func() {
+			BeforeEach(func() {
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+				mockDb.DriverNameReturns("foo")
+			})
+			It("returns an error", func() {
+				_, err := databaseHandler.AcquireLease("10.244.5.6", false, 60, nil)
+				Expect(err).To(MatchError("database type foo is not supported"))
+				Expect(mockDb.BeginxCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("AddIPv6Subnet", func() {
+		BeforeEach(func() {
+			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := databaseHandler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databaseHandler.AddEntry(lease)).To(Succeed())
+			Expect(databaseHandler.AddEntry(dualStackLease)).To(Succeed())
+		})
+
+		It("adds the allocated ipv6 subnet to an ipv4-only lease", func() {
+			var takenV6 []string
+			updated, err := databaseHandler.AddIPv6Subnet(lease.UnderlayIP, func(t []string) string {
+				takenV6 = t
+				return "fd00:0:0:4e::/64"
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(takenV6).To(ConsistOf(dualStackLease.OverlaySubnetV6))
+
+			expected := lease
+			expected.OverlaySubnetV6 = "fd00:0:0:4e::/64"
+			Expect(updated).To(Equal(&expected))
+
+			stored, err := databaseHandler.LeaseForUnderlayIP(lease.UnderlayIP)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stored).To(Equal(&expected))
+
+			events, err := databaseHandler.LeaseEvents(controller.LeaseHistoryQuery{UnderlayIP: lease.UnderlayIP, Limit: 10})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Type).To(Equal(controller.LeaseEventAcquire))
+			Expect(events[0].OverlaySubnetV6).To(Equal("fd00:0:0:4e::/64"))
+		})
+
+		It("returns a lease that already has an ipv6 subnet as it is", func() {
+			updated, err := databaseHandler.AddIPv6Subnet(dualStackLease.UnderlayIP, func(_ []string) string {
+				Fail("allocate should not be called")
+				return ""
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(Equal(&dualStackLease))
+		})
+
+		It("returns nil when nothing is free", func() {
+			updated, err := databaseHandler.AddIPv6Subnet(lease.UnderlayIP, func(_ []string) string {
+				return ""
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(BeNil())
+
+			stored, err := databaseHandler.LeaseForUnderlayIP(lease.UnderlayIP)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stored).To(Equal(&lease))
+		})
+
+		It("returns nil when there is no lease", func() {
+			updated, err := databaseHandler.AddIPv6Subnet("10.244.5.9", func(_ []string) string {
+				Fail("allocate should not be called")
+				return ""
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(BeNil())
+		})
+	})
+
+	Describe("DeleteLease", func() {
+		BeforeEach(func() {
+			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := databaseHandler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databaseHandler.AddEntry(dualStackLease)).To(Succeed())
+		})
+
+		It("deletes the lease and records the event", func() {
+			err := databaseHandler.DeleteLease(dualStackLease.UnderlayIP, controller.LeaseEventRelease)
+			Expect(err).NotTo(HaveOccurred())
+
+			found, err := databaseHandler.LeaseForUnderlayIP(dualStackLease.UnderlayIP)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeNil())
+
+			events, err := databaseHandler.LeaseEvents(controller.LeaseHistoryQuery{Limit: 10})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].Type).To(Equal(controller.LeaseEventRelease))
+			Expect(events[0].UnderlayIP).To(Equal(dualStackLease.UnderlayIP))
+			Expect(events[0].OverlaySubnet).To(Equal(dualStackLease.OverlaySubnet))
+			Expect(events[0].OverlaySubnetV6).To(Equal(dualStackLease.OverlaySubnetV6))
+		})
+
+		Context("when there is no lease for the underlay ip", func() {
+			It("returns a RecordNotAffectedError and records nothing", func() {
+				err := databaseHandler.DeleteLease("10.244.99.99", controller.LeaseEventRelease)
+				Expect(err).To(Equal(database.RecordNotAffectedError))
+
+				events, err := databaseHandler.LeaseEvents(controller.LeaseHistoryQuery{Limit: 10})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(events).To(BeEmpty())
+			})
+		})
+
+		Context("when beginning the transaction fails", func() {
+			BeforeEach(func() {
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+				mockDb.BeginxReturns(nil, errors.New("apple"))
+			})
+			It("returns an error", func() {
+				err := databaseHandler.DeleteLease(dualStackLease.UnderlayIP, controller.LeaseEventRelease)
+				Expect(err).To(MatchError("begin transaction: apple"))
+			})
+		})
+	})
+
+	Describe("LeaseEvents", func() {
+		BeforeEach(func() {
+			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := databaseHandler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, event := range []controller.LeaseEvent{
+				{Type: controller.LeaseEventAcquire, UnderlayIP: "10.244.1.1", OverlaySubnet: "10.255.1.0/24", OverlaySubnetV6: "fd00:0:0:1::/64"},
+				{Type: controller.LeaseEventRenewMismatch, UnderlayIP: "10.244.2.2", OverlaySubnet: "10.255.1.0/24", PreviousUnderlayIP: "10.244.1.1"},
+				{Type: controller.LeaseEventPoolEviction, UnderlayIP: "10.244.1.1", OverlaySubnet: "10.255.1.0/24", OverlaySubnetV6: "fd00:0:0:1::/64"},
+				{Type: controller.LeaseEventAcquire, UnderlayIP: "10.244.3.3", OverlaySubnet: "10.255.3.0/24"},
+			} {
+				Expect(databaseHandler.AddLeaseEvent(event)).To(Succeed())
+			}
+		})
+
+		It("returns the newest events first", func() {
+			events, err := databaseHandler.LeaseEvents(controller.LeaseHistoryQuery{Limit: 10})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(4))
+			Expect(events[0].UnderlayIP).To(Equal("10.244.3.3"))
+			Expect(events[3].Type).To(Equal(controller.LeaseEventAcquire))
+			Expect(events[3].OverlaySubnetV6).To(Equal("fd00:0:0:1::/64"))
+			Expect(events[0].ID).To(BeNumerically(">", events[3].ID))
+		})
+
+		It("filters by underlay ip, including events where it was the previous holder", func() {
+			events, err := databaseHandler.LeaseEvents(controller.LeaseHistoryQuery{UnderlayIP: "10.244.1.1", Limit: 10})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(3))
+			Expect(events[1].Type).To(Equal(controller.LeaseEventRenewMismatch))
+		})
+
+		It("filters by ipv4 or ipv6 overlay subnet", func() {
+			events, err := databaseHandler.LeaseEvents(controller.LeaseHistoryQuery{OverlaySubnet: "10.255.1.0/24", Limit: 10})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(3))
+
+			events, err = databaseHandler.LeaseEvents(controller.LeaseHistoryQuery{OverlaySubnet: "fd00:0:0:1::/64", Limit: 10})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(2))
+		})
+
+		It("pages through the events", func() {
+			page, err := databaseHandler.LeaseEvents(controller.LeaseHistoryQuery{Limit: 2})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(page).To(HaveLen(2))
+
+			nextPage, err := databaseHandler.LeaseEvents(controller.LeaseHistoryQuery{Before: page[1].ID, Limit: 2})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(nextPage).To(HaveLen(2))
+			Expect(nextPage[0].ID).To(BeNumerically("<", page[1].ID))
+			Expect(nextPage[1].Type).To(Equal(controller.LeaseEventAcquire))
+			Expect(nextPage[1].UnderlayIP).To(Equal("10.244.1.1"))
+		})
+
+		Context("when the query fails", func() {
+			BeforeEach(func() {
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+				mockDb.QueryReturns(nil, errors.New("apple"))
+			})
+			It("returns an error", func() {
+				_, err := databaseHandler.LeaseEvents(controller.LeaseHistoryQuery{Limit: 2})
+				Expect(err).To(MatchError("selecting lease events: apple"))
+			})
+		})
+	})
+
+	Describe("DeleteLeaseEventsOlderThan", func() {
+		BeforeEach(func() {
+			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := databaseHandler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = realDb.Exec(realDb.Rebind("INSERT INTO lease_events (event_type, underlay_ip, overlay_subnet, created_at) VALUES (?, ?, ?, ?)"),
+				controller.LeaseEventRenewMismatch, "10.244.1.1", "10.255.1.0/24", 1)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databaseHandler.AddLeaseEvent(controller.LeaseEvent{Type: controller.LeaseEventAcquire, UnderlayIP: "10.244.1.2", OverlaySubnet: "10.255.2.0/24"})).To(Succeed())
+		})
+
+		It("deletes the events outside of the retention window", func() {
+			deleted, err := databaseHandler.DeleteLeaseEventsOlderThan(3600)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deleted).To(Equal(int64(1)))
+
+			events, err := databaseHandler.LeaseEvents(controller.LeaseHistoryQuery{Limit: 10})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(events).To(HaveLen(1))
+			Expect(events[0].UnderlayIP).To(Equal("10.244.1.2"))
+		})
+
+		Context("when the delete fails", func() {
+			BeforeEach(func() {
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+				mockDb.ExecReturns(nil, errors.New("cherry"))
+			})
+			It("returns an error", func() {
+				_, err := databaseHandler.DeleteLeaseEventsOlderThan(3600)
+				Expect(err).To(MatchError("deleting lease events: cherry"))
+			})
+		})
+	})
+
+	Describe("AddLeaseEvent", func() {
+		Context("when the database type is not supported", func() {
+			BeforeEach(func() {
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+				mockDb.DriverNameReturns("foo")
+			})
+			It("returns an error", func() {
+				err := databaseHandler.AddLeaseEvent(controller.LeaseEvent{})
+				Expect(err).To(MatchError("database type foo is not supported"))
+			})
+		})
+
+		Context("when the insert fails", func() {
+			BeforeEach(func() {
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+				mockDb.ExecReturns(nil, errors.New("apple"))
+			})
+			It("returns an error", func() {
+				err := databaseHandler.AddLeaseEvent(controller.LeaseEvent{})
+				Expect(err).To(MatchError("adding lease event: apple"))
+			})
+		})
+	})
+
+	Describe("LeaseForOverlaySubnet", func() {
+		BeforeEach(func() {
+			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := databaseHandler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databaseHandler.AddEntry(dualStackLease)).To(Succeed())
+		})
+
+		It("returns the lease holding the overlay subnet", func() {
+			found, err := databaseHandler.LeaseForOverlaySubnet(dualStackLease.OverlaySubnet)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(Equal(&dualStackLease))
+		})
+
+		Context("when the overlay subnet is not leased", func() {
+			It("returns nil", func() {
+				found, err := databaseHandler.LeaseForOverlaySubnet("10.255.99.0/24")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(BeNil())
+			})
+		})
+	})
+
+	Describe("Reservations", func() {
+		var reservation, reservation2 controller.Reservation
+
+		BeforeEach(func() {
+			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := databaseHandler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+
+			reservation = controller.Reservation{UnderlayIP: "10.244.11.22", OverlaySubnet: "10.255.17.0/24"}
+			reservation2 = controller.Reservation{UnderlayIP: "fd12:3456::2c", OverlaySubnet: "10.255.0.12/32"}
+			Expect(reserveSubnet(databaseHandler, reservation)).To(Succeed())
+			Expect(reserveSubnet(databaseHandler, reservation2)).To(Succeed())
+		})
+
+		It("lists all reservations", func() {
+			reservations, err := databaseHandler.AllReservations()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reservations).To(ConsistOf(reservation, reservation2))
+		})
+
+		It("finds reservations by underlay ip and by overlay subnet", func() {
+			found, err := databaseHandler.ReservationForUnderlayIP("fd12:3456::2c")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(Equal(&reservation2))
+
+			found, err = databaseHandler.ReservationForOverlaySubnet("10.255.17.0/24")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(Equal(&reservation))
+
+			found, err = databaseHandler.ReservationForUnderlayIP("10.244.99.99")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeNil())
+		})
+
+		It("does not allow an overlay subnet to be reserved twice", func() {
+			err := reserveSubnet(databaseHandler, controller.Reservation{UnderlayIP: "10.244.99.99", OverlaySubnet: "10.255.17.0/24"})
+			Expect(err).To(MatchError(ContainSubstring("adding reservation:")))
+		})
+
+		It("deletes reservations", func() {
+			Expect(databaseHandler.DeleteReservation("10.244.11.22")).To(Succeed())
+			reservations, err := databaseHandler.AllReservations()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reservations).To(ConsistOf(reservation2))
+
+			err = databaseHandler.DeleteReservation("10.244.11.22")
+			Expect(err).To(Equal(database.RecordNotAffectedError))
+		})
+
+		Context("when the query fails", func() {
+			BeforeEach(func() {
+				mockDb.QueryReturns(nil, errors.New("strawberry"))
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+			})
+			It("returns an error", func() {
+				_, err := databaseHandler.AllReservations()
+				Expect(err).To(MatchError("selecting all reservations: strawberry"))
+			})
+		})
+
+		Context("when the delete fails", func() {
+			BeforeEach(func() {
+				mockDb.ExecReturns(nil, errors.New("strawberry"))
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+			})
+			It("returns an error", func() {
+				err := databaseHandler.DeleteReservation("10.244.11.22")
+				Expect(err).To(MatchError("deleting reservation: strawberry"))
+			})
+		})
+	})
+
+	Describe("ReserveSubnet", func() {
+		var reservation controller.Reservation
+
+		BeforeEach(func() {
+			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := databaseHandler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databaseHandler.AddEntry(lease)).To(Succeed())
+			Expect(reserveSubnet(databaseHandler, controller.Reservation{UnderlayIP: "10.244.99.1", OverlaySubnet: "10.255.99.0/24"})).To(Succeed())
+
+			reservation = controller.Reservation{UnderlayIP: "10.244.5.6", OverlaySubnet: lease.OverlaySubnet}
+		})
+
+		It("passes the reservations and the lease that conflict to the check and adds the reservation", func() {
+			var forUnderlayIP, forOverlaySubnet *controller.Reservation
+			var holder *controller.Lease
+			added, err := databaseHandler.ReserveSubnet(reservation, func(u, o *controller.Reservation, l *controller.Lease) (bool, error) {
+				forUnderlayIP, forOverlaySubnet, holder = u, o, l
+				return true, nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(added).To(BeTrue())
+
+			Expect(forUnderlayIP).To(BeNil())
+			Expect(forOverlaySubnet).To(BeNil())
+			Expect(holder).To(Equal(&lease))
+
+			found, err := databaseHandler.ReservationForUnderlayIP("10.244.5.6")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(Equal(&reservation))
+		})
+
+		It("passes the existing reservations to the check", func() {
+			var forUnderlayIP, forOverlaySubnet *controller.Reservation
+			_, err := databaseHandler.ReserveSubnet(controller.Reservation{UnderlayIP: "10.244.99.1", OverlaySubnet: "10.255.99.0/24"}, func(u, o *controller.Reservation, _ *controller.Lease) (bool, error) {
+				forUnderlayIP, forOverlaySubnet = u, o
+				return false, nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			existing := &controller.Reservation{UnderlayIP: "10.244.99.1", OverlaySubnet: "10.255.99.0/24"}
+			Expect(forUnderlayIP).To(Equal(existing))
+			Expect(forOverlaySubnet).To(Equal(existing))
+		})
+
+		Context("when the check does not add the reservation", func() {
+			It("does not add it", func() {
+				added, err := databaseHandler.ReserveSubnet(reservation, func(_, _ *controller.Reservation, _ *controller.Lease) (bool, error) {
+					return false, nil
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(added).To(BeFalse())
+
+				found, err := databaseHandler.ReservationForUnderlayIP("10.244.5.6")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(BeNil())
+			})
+		})
+
+		Context("when the check fails", func() {
+			It("returns the error unchanged and does not add the reservation", func() {
+				checkErr := controller.NonRetriableError("banana")
+				_, err := databaseHandler.ReserveSubnet(reservation, func(_, _ *controller.Reservation, _ *controller.Lease) (bool, error) {
+					return false, checkErr
+				})
+				Expect(err).To(Equal(checkErr))
+
+				found, err := databaseHandler.ReservationForUnderlayIP("10.244.5.6")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(found).To(BeNil())
+			})
+		})
+
+		Context("when a lease is acquired concurrently", func() {
+			It("waits until the reservation is added", func() {
+				checking := make(chan struct{})
+				proceed := make(chan struct{})
+				done := make(chan error)
+				go func() {
+					_, err := databaseHandler.ReserveSubnet(controller.Reservation{UnderlayIP: "10.244.5.6", OverlaySubnet: "10.255.76.0/24"}, func(_, _ *controller.Reservation, _ *controller.Lease) (bool, error) {
+						close(checking)
+						<-proceed
+						return true, nil
+					})
+					done <- err
+				}()
+				Eventually(checking).Should(BeClosed())
+
+				var taken []string
+				acquired := make(chan error)
+				go func() {
+					_, err := databaseHandler.AcquireLease("10.244.7.7", false, 60, func(t, _ []string, _ bool) (*controller.Lease, error) {
+						taken = t
+						return nil, database.IPv4PoolExhaustedError
+					})
+					acquired <- err
+				}()
+				Consistently(acquired, "500ms").ShouldNot(Receive())
+
+				close(proceed)
+				Eventually(done).Should(Receive(BeNil()))
+				Eventually(acquired).Should(Receive(BeNil()))
+				Expect(taken).To(ContainElement("10.255.76.0/24"))
+			})
+		})
+
+		Context("when beginning the transaction fails", func() {
+			BeforeEach(func() {
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+				mockDb.BeginxReturns(nil, errors.New("apple"))
+			})
+			It("returns an error", func() {
+				_, err := databaseHandler.ReserveSubnet(reservation, nil)
+				Expect(err).To(MatchError("begin transaction: apple"))
+			})
+		})
+	})
+
+	Describe("OverlayNetworks", func() {
+		BeforeEach(func() {
+			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
+			_, err := databaseHandler.Migrate()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(databaseHandler.AddOverlayNetwork(controller.OverlayNetwork{CIDR: "10.255.0.0/16", State: controller.OverlayNetworkActive})).To(Succeed())
+			Expect(databaseHandler.AddOverlayNetwork(controller.OverlayNetwork{CIDR: "10.254.0.0/16", State: controller.OverlayNetworkActive})).To(Succeed())
+		})
+
+		It("lists the overlay networks in the order they were added", func() {
+			networks, err := databaseHandler.OverlayNetworks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(networks).To(Equal([]controller.OverlayNetwork{
+				{CIDR: "10.255.0.0/16", State: controller.OverlayNetworkActive},
+				{CIDR: "10.254.0.0/16", State: controller.OverlayNetworkActive},
+			}))
+		})
+
+		It("does not allow an overlay network to be added twice", func() {
+			err := databaseHandler.AddOverlayNetwork(controller.OverlayNetwork{CIDR: "10.255.0.0/16", State: controller.OverlayNetworkDraining})
+			Expect(err).To(MatchError(ContainSubstring("adding overlay network:")))
+		})
+
+		It("updates the state of an overlay network", func() {
+			Expect(databaseHandler.SetOverlayNetworkState("10.255.0.0/16", controller.OverlayNetworkDraining)).To(Succeed())
+			networks, err := databaseHandler.OverlayNetworks()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(networks[0]).To(Equal(controller.OverlayNetwork{CIDR: "10.255.0.0/16", State: controller.OverlayNetworkDraining}))
+
+			err = databaseHandler.SetOverlayNetworkState("10.253.0.0/16", controller.OverlayNetworkDraining)
+			Expect(err).To(Equal(database.RecordNotAffectedError))
+		})
+
+		Describe("RetireOverlayNetwork", func() {
+			var otherLease controller.Lease
+
+			BeforeEach(func() {
+				Expect(databaseHandler.SetOverlayNetworkState("10.254.0.0/16", controller.OverlayNetworkDraining)).To(Succeed())
+
+				lease.OverlaySubnet = "10.254.1.0/24"
+				Expect(databaseHandler.AddEntry(lease)).To(Succeed())
+				otherLease = controller.Lease{UnderlayIP: "10.244.5.9", OverlaySubnet: "10.255.1.0/24", OverlayHardwareAddr: "ee:ee:0a:ff:01:00"}
+				Expect(databaseHandler.AddEntry(otherLease)).To(Succeed())
+			})
+
+			It("retires the network and deletes its expired leases", func() {
+				deleted, active, err := databaseHandler.RetireOverlayNetwork("10.254.0.0/16", 0)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(active).To(Equal(0))
+				Expect(deleted).To(Equal([]controller.Lease{lease}))
+
+				networks, err := databaseHandler.OverlayNetworks()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(networks[1]).To(Equal(controller.OverlayNetwork{CIDR: "10.254.0.0/16", State: controller.OverlayNetworkRetired}))
+
+				leases, err := databaseHandler.All()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(leases).To(ConsistOf(otherLease))
+
+				events, err := databaseHandler.LeaseEvents(controller.LeaseHistoryQuery{UnderlayIP: lease.UnderlayIP, Limit: 10})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(events).To(HaveLen(1))
+				Expect(events[0].Type).To(Equal(controller.LeaseEventPoolEviction))
+			})
+
+			It("changes nothing when the network still has unexpired leases", func() {
+				deleted, active, err := databaseHandler.RetireOverlayNetwork("10.254.0.0/16", 60)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(active).To(Equal(1))
+				Expect(deleted).To(BeEmpty())
+
+				networks, err := databaseHandler.OverlayNetworks()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(networks[1].State).To(Equal(controller.OverlayNetworkDraining))
+
+				leases, err := databaseHandler.All()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(leases).To(ConsistOf(lease, otherLease))
+			})
+
+			It("returns RecordNotAffectedError when the network does not exist", func() {
+				_, _, err := databaseHandler.RetireOverlayNetwork("10.253.0.0/16", 0)
+				Expect(err).To(Equal(database.RecordNotAffectedError))
+			})
+		})
+
+		Context("when the query fails", func() {
+			BeforeEach(func() {
+				mockDb.QueryReturns(nil, errors.New("strawberry"))
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+			})
+			It("returns an error", func() {
+				_, err := databaseHandler.OverlayNetworks()
+				Expect(err).To(MatchError("selecting all overlay networks: strawberry"))
+			})
+		})
+
+		Context("when the update fails", func() {
+			BeforeEach(func() {
+				mockDb.ExecReturns(nil, errors.New("strawberry"))
+				databaseHandler = database.NewDatabaseHandler(mockMigrateAdapter, mockDb)
+			})
+			It("returns an error", func() {
+				err := databaseHandler.SetOverlayNetworkState("10.255.0.0/16", controller.OverlayNetworkDraining)
+				Expect(err).To(MatchError("updating overlay network: strawberry"))
+			})
+		})
+	})
+
 	Describe("OldestExipredSingleIP", func() {
 		BeforeEach(func() {
 			databaseHandler = database.NewDatabaseHandler(realMigrateAdapter, realDb)
@@ -830,3 +1805,20 @@ var _ = Describe("DatabaseHandler", func() {
 		})
 	})
 })
+
+// reserveSubnet adds the reservation without checking for conflicts.
+func reserveSubnet(databaseHandler *database.DatabaseHandler, reservation controller.Reservation) error {
+	_, err := databaseHandler.ReserveSubnet(reservation, func(_, _ *controller.Reservation, _ *controller.Lease) (bool, error) {
+		return true, nil
+	})
+	return err
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
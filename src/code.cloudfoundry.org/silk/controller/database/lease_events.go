@@ -0,0 +1,214 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"code.cloudfoundry.org/cf-networking-helpers/db"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Rebind(query string) string
+}
+
+// DeleteLease deletes the lease of the underlay IP and records the deletion
+// as an event of the given type in the same transaction.
+func (d *DatabaseHandler) DeleteLease(underlayIP, eventType string) error {
+	timestamp, err := timestampForDriver(d.db.DriverName())
+	if err != nil {
+		return err
+	}
+
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %s", err)
+	}
+
+	err = deleteLeaseInTx(tx, timestamp, underlayIP, eventType)
+	if err != nil {
+		rollbackErr := tx.Rollback()
+		if rollbackErr != nil {
+			return fmt.Errorf("rollback transaction: %s: %s", rollbackErr, err)
+		}
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return fmt.Errorf("commit transaction: %s", err)
+	}
+	return nil
+}
+
+func deleteLeaseInTx(tx db.Transaction, timestamp, underlayIP, eventType string) error {
+	lease, err := scanLease(tx.QueryRow(tx.Rebind("SELECT underlay_ip, overlay_subnet, overlay_hwaddr, overlay_subnet_v6 FROM subnets WHERE underlay_ip = ? FOR UPDATE"), underlayIP))
+	if err != nil {
+		return fmt.Errorf("getting lease for underlay ip: %s", err)
+	}
+	if lease == nil {
+		return RecordNotAffectedError
+	}
+
+	_, err = tx.Exec(tx.Rebind("DELETE FROM subnets WHERE underlay_ip = ?"), underlayIP)
+	if err != nil {
+		return fmt.Errorf("deleting entry: %s", err)
+	}
+
+	return insertLeaseEvent(tx, timestamp, controller.LeaseEvent{
+		Type:            eventType,
+		UnderlayIP:      lease.UnderlayIP,
+		OverlaySubnet:   lease.OverlaySubnet,
+		OverlaySubnetV6: lease.OverlaySubnetV6,
+	})
+}
+
+func (d *DatabaseHandler) AddLeaseEvent(event controller.LeaseEvent) error {
+	timestamp, err := timestampForDriver(d.db.DriverName())
+	if err != nil {
+		return err
+	}
+	return insertLeaseEvent(d.db, timestamp, event)
+}
+
+func (d *DatabaseHandler) LeaseEvents(query controller.LeaseHistoryQuery) ([]controller.LeaseEvent, error) {
+	var conditions []string
+	var args []interface{}
+	if query.UnderlayIP != "" {
+		conditions = append(conditions, "(underlay_ip = ? OR previous_underlay_ip = ?)")
+		args = append(args, query.UnderlayIP, query.UnderlayIP)
+	}
+	if query.OverlaySubnet != "" {
+		conditions = append(conditions, "(overlay_subnet = ? OR overlay_subnet_v6 = ?)")
+		args = append(args, query.OverlaySubnet, query.OverlaySubnet)
+	}
+	if query.Before > 0 {
+		conditions = append(conditions, "id < ?")
+		args = append(args, query.Before)
+	}
+
+	statement := "SELECT id, event_type, underlay_ip, overlay_subnet, overlay_subnet_v6, previous_underlay_ip, created_at FROM lease_events"
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	statement += fmt.Sprintf(" ORDER BY id DESC LIMIT %d", query.Limit)
+
+	rows, err := d.db.Query(d.db.Rebind(statement), args...)
+	if err != nil {
+		return nil, fmt.Errorf("selecting lease events: %s", err)
+	}
+	defer rows.Close() // untested
+
+	events := []controller.LeaseEvent{}
+	for rows.Next() {
+		var event controller.LeaseEvent
+		var overlaySubnetV6, previousUnderlayIP sql.NullString
+		err := rows.Scan(&event.ID, &event.Type, &event.UnderlayIP, &event.OverlaySubnet, &overlaySubnetV6, &previousUnderlayIP, &event.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("selecting lease events: parsing result: %s", err)
+		}
+		event.OverlaySubnetV6 = overlaySubnetV6.String
+		event.PreviousUnderlayIP = previousUnderlayIP.String
+		events = append(events, event)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("selecting lease events: getting next row: %s", err) // untested
+	}
+
+	return events, nil
+}
+
+// ReclaimedLeaseCount is the number of expired leases this handler has
+// reclaimed since it was created. It is kept in memory so that metrics do not
+// have to scan lease_events, so it only counts the reclaims of this process
+// and starts at 0 again when the controller restarts.
+func (d *DatabaseHandler) ReclaimedLeaseCount() int64 {
+	return d.reclaimedLeases.Load()
+}
+
+// DeleteLeaseEventsOlderThan deletes the lease events that were recorded more
+// than retentionSeconds ago and returns how many were deleted.
+func (d *DatabaseHandler) DeleteLeaseEventsOlderThan(retentionSeconds int) (int64, error) {
+	timestamp, err := timestampForDriver(d.db.DriverName())
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := d.db.Exec(fmt.Sprintf("DELETE FROM lease_events WHERE created_at < %s - %d", timestamp, retentionSeconds))
+	if err != nil {
+		return 0, fmt.Errorf("deleting lease events: %s", err)
+	}
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("deleting lease events: %s", err) // untested
+	}
+	return deleted, nil
+}
+
+func insertLeaseEvent(e execer, timestamp string, event controller.LeaseEvent) error {
+	_, err := e.Exec(e.Rebind(fmt.Sprintf("INSERT INTO lease_events (event_type, underlay_ip, overlay_subnet, overlay_subnet_v6, previous_underlay_ip, created_at) VALUES (?, ?, ?, ?, ?, %s)", timestamp)),
+		event.Type, event.UnderlayIP, event.OverlaySubnet, nullString(event.OverlaySubnetV6), nullString(event.PreviousUnderlayIP))
+	if err != nil {
+		return fmt.Errorf("adding lease event: %s", err)
+	}
+	return nil
+}
+
+// createLeaseEventsTable creates the lease history. Rows are never updated,
+// they are only deleted by DeleteLeaseEventsOlderThan once they fall out of
+// the retention window.
+func createLeaseEventsTable(dbType string) []string {
+	baseCreateTable := "CREATE TABLE IF NOT EXISTS lease_events (" +
+		"%s" +
+		", event_type varchar(32) NOT NULL" +
+		", underlay_ip varchar(45) NOT NULL" +
+		", overlay_subnet varchar(18) NOT NULL" +
+		", overlay_subnet_v6 varchar(49) NULL" +
+		", previous_underlay_ip varchar(45) NULL" +
+		", created_at bigint NOT NULL" +
+		");"
+	mysqlId := "id bigint NOT NULL AUTO_INCREMENT, PRIMARY KEY (id)"
+	psqlId := "id BIGSERIAL PRIMARY KEY"
+
+	var createTable string
+	switch dbType {
+	case Postgres:
+		createTable = fmt.Sprintf(baseCreateTable, psqlId)
+	case MySQL:
+		createTable = fmt.Sprintf(baseCreateTable, mysqlId)
+	default:
+		return nil
+	}
+
+	return []string{
+		createTable,
+		"CREATE INDEX lease_events_underlay_ip ON lease_events (underlay_ip)",
+		"CREATE INDEX lease_events_overlay_subnet ON lease_events (overlay_subnet)",
+		"CREATE INDEX lease_events_overlay_subnet_v6 ON lease_events (overlay_subnet_v6)",
+		"CREATE INDEX lease_events_previous_underlay_ip ON lease_events (previous_underlay_ip)",
+	}
+}
+
+// indexLeaseEventsByAge lets the retention pruner find old events and
+// queries for recent events of one type skip the rest of the table.
+// indexLeaseEventsByAge lets DeleteLeaseEventsOlderThan find the old events
+// without scanning the table.
+func indexLeaseEventsByAge() []string {
+	return []string{
+		"CREATE INDEX lease_events_created_at ON lease_events (created_at)",
+	}
+}
+
+func dropLeaseEventsAgeIndex(dbType string) []string {
+	switch dbType {
+	case Postgres:
+		return []string{"DROP INDEX lease_events_created_at"}
+	case MySQL:
+		return []string{"DROP INDEX lease_events_created_at ON lease_events"}
+	}
+
+	return nil
+}
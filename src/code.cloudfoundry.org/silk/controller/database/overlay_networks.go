@@ -0,0 +1,199 @@
+package database
+
+import (
+	"fmt"
+	"net"
+
+	"code.cloudfoundry.org/cf-networking-helpers/db"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+// OverlayNetworks returns the overlay networks in the order they were added.
+func (d *DatabaseHandler) OverlayNetworks() ([]controller.OverlayNetwork, error) {
+	rows, err := d.db.Query("SELECT cidr, state FROM overlay_networks ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("selecting all overlay networks: %s", err)
+	}
+	defer rows.Close() // untested
+
+	networks := []controller.OverlayNetwork{}
+	for rows.Next() {
+		var network controller.OverlayNetwork
+		err := rows.Scan(&network.CIDR, &network.State)
+		if err != nil {
+			return nil, fmt.Errorf("selecting all overlay networks: parsing result: %s", err)
+		}
+		networks = append(networks, network)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, fmt.Errorf("selecting all overlay networks: getting next row: %s", err) // untested
+	}
+
+	return networks, nil
+}
+
+func (d *DatabaseHandler) AddOverlayNetwork(network controller.OverlayNetwork) error {
+	timestamp, err := timestampForDriver(d.db.DriverName())
+	if err != nil {
+		return err
+	}
+
+	_, err = d.db.Exec(d.db.Rebind(fmt.Sprintf("INSERT INTO overlay_networks (cidr, state, updated_at) VALUES (?, ?, %s)", timestamp)), network.CIDR, network.State)
+	if err != nil {
+		return fmt.Errorf("adding overlay network: %s", err)
+	}
+	return nil
+}
+
+func (d *DatabaseHandler) SetOverlayNetworkState(cidr, state string) error {
+	timestamp, err := timestampForDriver(d.db.DriverName())
+	if err != nil {
+		return err
+	}
+
+	result, err := d.db.Exec(d.db.Rebind(fmt.Sprintf("UPDATE overlay_networks SET state = ?, updated_at = %s WHERE cidr = ?", timestamp)), state, cidr)
+	if err != nil {
+		return fmt.Errorf("updating overlay network: %s", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("parse result: %s", err)
+	}
+
+	if rowsAffected == 0 {
+		return RecordNotAffectedError
+	}
+
+	return nil
+}
+
+// RetireOverlayNetwork marks the network as retired and deletes the expired
+// leases in it. It runs in a transaction that is serialized with lease
+// acquisitions on the lock row, so no lease in the network can be acquired or
+// renewed into being active between the check and the deletes. When the
+// network still has unexpired leases nothing is changed and their number is
+// returned.
+func (d *DatabaseHandler) RetireOverlayNetwork(cidr string, expirationSeconds int) ([]controller.Lease, int, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, 0, fmt.Errorf("parsing overlay network: %s", err)
+	}
+
+	timestamp, err := timestampForDriver(d.db.DriverName())
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tx, err := d.db.Beginx()
+	if err != nil {
+		return nil, 0, fmt.Errorf("begin transaction: %s", err)
+	}
+
+	deleted, active, err := retireOverlayNetworkInTx(tx, timestamp, network, expirationSeconds)
+	if err != nil || active > 0 {
+		rollbackErr := tx.Rollback()
+		if err == nil && rollbackErr != nil {
+			err = fmt.Errorf("rollback transaction: %s", rollbackErr)
+		}
+		return nil, active, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, 0, fmt.Errorf("commit transaction: %s", err)
+	}
+	return deleted, 0, nil
+}
+
+func retireOverlayNetworkInTx(tx db.Transaction, timestamp string, network *net.IPNet, expirationSeconds int) ([]controller.Lease, int, error) {
+	var id int
+	err := tx.QueryRow("SELECT id FROM lease_acquisition_lock WHERE id = 1 FOR UPDATE").Scan(&id)
+	if err != nil {
+		return nil, 0, fmt.Errorf("locking leases: %s", err)
+	}
+
+	rows, err := tx.Queryx("SELECT underlay_ip, overlay_subnet, overlay_hwaddr, overlay_subnet_v6 FROM subnets")
+	if err != nil {
+		return nil, 0, fmt.Errorf("selecting all subnets: %s", err)
+	}
+	defer rows.Close() // untested
+	leases, err := rowsToLeases(rows.Rows)
+	if err != nil {
+		return nil, 0, fmt.Errorf("selecting all subnets: %s", err)
+	}
+
+	activeIPs, err := selectStrings(tx, fmt.Sprintf("SELECT underlay_ip FROM subnets WHERE last_renewed_at + %d > %s", expirationSeconds, timestamp))
+	if err != nil {
+		return nil, 0, fmt.Errorf("selecting active subnets: %s", err)
+	}
+	active := make(map[string]struct{}, len(activeIPs))
+	for _, ip := range activeIPs {
+		active[ip] = struct{}{}
+	}
+
+	var expired []controller.Lease
+	activeCount := 0
+	for _, lease := range leases {
+		if !subnetInNetwork(lease.OverlaySubnet, network) && !subnetInNetwork(lease.OverlaySubnetV6, network) {
+			continue
+		}
+		if _, ok := active[lease.UnderlayIP]; ok {
+			activeCount++
+			continue
+		}
+		expired = append(expired, lease)
+	}
+	if activeCount > 0 {
+		return nil, activeCount, nil
+	}
+
+	result, err := tx.Exec(tx.Rebind(fmt.Sprintf("UPDATE overlay_networks SET state = ?, updated_at = %s WHERE cidr = ?", timestamp)), controller.OverlayNetworkRetired, network.String())
+	if err != nil {
+		return nil, 0, fmt.Errorf("updating overlay network: %s", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, 0, fmt.Errorf("parse result: %s", err)
+	}
+	if rowsAffected == 0 {
+		return nil, 0, RecordNotAffectedError
+	}
+
+	for _, lease := range expired {
+		err := deleteLeaseInTx(tx, timestamp, lease.UnderlayIP, controller.LeaseEventPoolEviction)
+		if err != nil {
+			return nil, 0, fmt.Errorf("deleting expired lease for underlay ip %s: %s", lease.UnderlayIP, err)
+		}
+	}
+	return expired, 0, nil
+}
+
+func subnetInNetwork(subnet string, network *net.IPNet) bool {
+	ip, _, err := net.ParseCIDR(subnet)
+	return err == nil && network.Contains(ip)
+}
+
+// createOverlayNetworksTable keeps retired networks so that they can be made
+// active again through the admin API.
+func createOverlayNetworksTable(dbType string) string {
+	baseCreateTable := "CREATE TABLE IF NOT EXISTS overlay_networks (" +
+		"%s" +
+		", cidr varchar(49) NOT NULL" +
+		", state varchar(16) NOT NULL" +
+		", updated_at bigint NOT NULL" +
+		", UNIQUE (cidr)" +
+		");"
+	mysqlId := "id int NOT NULL AUTO_INCREMENT, PRIMARY KEY (id)"
+	psqlId := "id SERIAL PRIMARY KEY"
+
+	switch dbType {
+	case Postgres:
+		return fmt.Sprintf(baseCreateTable, psqlId)
+	case MySQL:
+		return fmt.Sprintf(baseCreateTable, mysqlId)
+	}
+
+	return ""
+}
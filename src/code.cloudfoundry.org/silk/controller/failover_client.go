@@ -0,0 +1,188 @@
+package controller
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/cf-networking-helpers/json_client"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+const (
+	failoverMinBackoff = 1 * time.Second
+	failoverMaxBackoff = 60 * time.Second
+)
+
+//go:generate counterfeiter -o fakes/metricSender.go --fake-name MetricSender . metricSender
+type metricSender interface {
+	IncrementCounter(name string)
+}
+
+// Endpoint is one silk-controller that a FailoverClient sends requests to.
+type Endpoint struct {
+	Name       string
+	JsonClient json_client.JsonClient
+
+	failures       int
+	unhealthyUntil time.Time
+}
+
+// FailoverClient spreads requests across several silk-controllers. An
+// endpoint that fails is marked unhealthy and skipped for a backoff that
+// doubles with every consecutive failure, from MinBackoff up to MaxBackoff.
+// A request is tried on every healthy endpoint, then on the unhealthy ones,
+// so it only fails when all of them have failed.
+//
+// When Sticky is set requests keep going to the endpoint that last
+// succeeded instead of being spread, which suits lease watches whose
+// revisions are only valid for one controller.
+type FailoverClient struct {
+	Logger       lager.Logger
+	Endpoints    []*Endpoint
+	MetricSender metricSender
+	MinBackoff   time.Duration
+	MaxBackoff   time.Duration
+	Sticky       bool
+
+	lock sync.Mutex
+	next int
+}
+
+func NewFailoverClient(logger lager.Logger, httpClient json_client.HttpClient, baseURLs []string, metricSender metricSender) *Client {
+	return &Client{
+		JsonClient: newFailoverJsonClient(logger, httpClient, baseURLs, metricSender, false),
+	}
+}
+
+// NewStickyFailoverClient is like NewFailoverClient, but keeps sending
+// requests to the same silk-controller while it is healthy.
+func NewStickyFailoverClient(logger lager.Logger, httpClient json_client.HttpClient, baseURLs []string, metricSender metricSender) *Client {
+	return &Client{
+		JsonClient: newFailoverJsonClient(logger, httpClient, baseURLs, metricSender, true),
+	}
+}
+
+func newFailoverJsonClient(logger lager.Logger, httpClient json_client.HttpClient, baseURLs []string, metricSender metricSender, sticky bool) *FailoverClient {
+	endpoints := make([]*Endpoint, 0, len(baseURLs))
+	for _, baseURL := range baseURLs {
+		endpoints = append(endpoints, &Endpoint{
+			Name:       endpointName(baseURL),
+			JsonClient: json_client.New(logger, httpClient, baseURL),
+		})
+	}
+	return &FailoverClient{
+		Logger:       logger,
+		Endpoints:    endpoints,
+		MetricSender: metricSender,
+		MinBackoff:   failoverMinBackoff,
+		MaxBackoff:   failoverMaxBackoff,
+		Sticky:       sticky,
+	}
+}
+
+func endpointName(baseURL string) string {
+	parsed, err := url.Parse(baseURL)
+	if err != nil || parsed.Host == "" {
+		return baseURL
+	}
+	return parsed.Host
+}
+
+func (c *FailoverClient) Do(method, route string, reqData, respData interface{}, token string) error {
+	err := errors.New("no silk-controller endpoints")
+	for _, index := range c.order() {
+		endpoint := c.Endpoints[index]
+		err = endpoint.JsonClient.Do(method, route, reqData, respData, token)
+		if err != nil && isEndpointFailure(err) {
+			c.failed(index, err)
+			continue
+		}
+		c.succeeded(index)
+		return err
+	}
+	return err
+}
+
+func (c *FailoverClient) CloseIdleConnections() {
+	for _, endpoint := range c.Endpoints {
+		endpoint.JsonClient.CloseIdleConnections()
+	}
+}
+
+// order lists the healthy endpoints, starting with the next one in turn,
+// followed by the unhealthy endpoints that will be healthy again soonest.
+func (c *FailoverClient) order() []int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	now := time.Now()
+	healthy := []int{}
+	unhealthy := []int{}
+	for i := range c.Endpoints {
+		index := (c.next + i) % len(c.Endpoints)
+		if c.Endpoints[index].unhealthyUntil.After(now) {
+			unhealthy = append(unhealthy, index)
+		} else {
+			healthy = append(healthy, index)
+		}
+	}
+	sort.SliceStable(unhealthy, func(i, j int) bool {
+		return c.Endpoints[unhealthy[i]].unhealthyUntil.Before(c.Endpoints[unhealthy[j]].unhealthyUntil)
+	})
+
+	if !c.Sticky && len(c.Endpoints) > 0 {
+		c.next = (c.next + 1) % len(c.Endpoints)
+	}
+	return append(healthy, unhealthy...)
+}
+
+func (c *FailoverClient) failed(index int, err error) {
+	c.lock.Lock()
+	endpoint := c.Endpoints[index]
+	endpoint.failures++
+	backoff := c.MinBackoff
+	for i := 1; i < endpoint.failures && backoff < c.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > c.MaxBackoff {
+		backoff = c.MaxBackoff
+	}
+	endpoint.unhealthyUntil = time.Now().Add(backoff)
+	failures := endpoint.failures
+	c.lock.Unlock()
+
+	c.Logger.Error("controller-request-failed", err, lager.Data{
+		"endpoint": endpoint.Name,
+		"failures": failures,
+		"backoff":  backoff.String(),
+	})
+	c.MetricSender.IncrementCounter("controllerRequestFailure")
+}
+
+func (c *FailoverClient) succeeded(index int) {
+	c.lock.Lock()
+	endpoint := c.Endpoints[index]
+	endpoint.failures = 0
+	endpoint.unhealthyUntil = time.Time{}
+	if c.Sticky {
+		c.next = index
+	}
+	c.lock.Unlock()
+
+	c.Logger.Debug("controller-request-succeeded", lager.Data{"endpoint": endpoint.Name})
+	c.MetricSender.IncrementCounter("controllerRequestSuccess")
+}
+
+// isEndpointFailure tells apart errors that another silk-controller might
+// not have, like a connection failure or a server error, from responses
+// that any of them would give, like a conflict.
+func isEndpointFailure(err error) bool {
+	httpResponseErr, ok := err.(*json_client.HttpResponseCodeError)
+	if ok {
+		return httpResponseErr.StatusCode >= 500
+	}
+	return true
+}
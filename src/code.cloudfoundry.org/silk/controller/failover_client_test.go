@@ -0,0 +1,198 @@
+package controller_test
+
+import (
+	"errors"
+	"time"
+
+	helpersfakes "code.cloudfoundry.org/cf-networking-helpers/fakes"
+	"code.cloudfoundry.org/cf-networking-helpers/json_client"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+var _ = Describe("FailoverClient", func() {
+	var (
+		logger           *lagertest.TestLogger
+		jsonClients      []*helpersfakes.JSONClient
+		fakeMetricSender *fakes.MetricSender
+		client           *controller.FailoverClient
+	)
+
+	counters := func() []string {
+		names := []string{}
+		for i := 0; i < fakeMetricSender.IncrementCounterCallCount(); i++ {
+			names = append(names, fakeMetricSender.IncrementCounterArgsForCall(i))
+		}
+		return names
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		fakeMetricSender = &fakes.MetricSender{}
+		jsonClients = []*helpersfakes.JSONClient{{}, {}, {}}
+
+		client = &controller.FailoverClient{
+			Logger:       logger,
+			MetricSender: fakeMetricSender,
+			MinBackoff:   time.Minute,
+			MaxBackoff:   4 * time.Minute,
+		}
+		for i, name := range []string{"controller-0", "controller-1", "controller-2"} {
+			client.Endpoints = append(client.Endpoints, &controller.Endpoint{
+				Name:       name,
+				JsonClient: jsonClients[i],
+			})
+		}
+	})
+
+	It("spreads requests across the endpoints", func() {
+		for i := 0; i < 6; i++ {
+			Expect(client.Do("GET", "/leases", nil, nil, "")).To(Succeed())
+		}
+
+		for _, jsonClient := range jsonClients {
+			Expect(jsonClient.DoCallCount()).To(Equal(2))
+			method, route, _, _, _ := jsonClient.DoArgsForCall(0)
+			Expect(method).To(Equal("GET"))
+			Expect(route).To(Equal("/leases"))
+		}
+		Expect(counters()).To(Equal([]string{
+			"controllerRequestSuccess", "controllerRequestSuccess", "controllerRequestSuccess",
+			"controllerRequestSuccess", "controllerRequestSuccess", "controllerRequestSuccess",
+		}))
+	})
+
+	Context("when an endpoint fails", func() {
+		BeforeEach(func() {
+			jsonClients[0].DoReturns(errors.New("banana"))
+		})
+
+		It("fails over to the next endpoint within the same request", func() {
+			Expect(client.Do("PUT", "/leases/renew", nil, nil, "")).To(Succeed())
+
+			Expect(jsonClients[0].DoCallCount()).To(Equal(1))
+			Expect(jsonClients[1].DoCallCount()).To(Equal(1))
+			Expect(counters()).To(Equal([]string{
+				"controllerRequestFailure",
+				"controllerRequestSuccess",
+			}))
+			Expect(logger).To(gbytes.Say(`controller-request-failed.*"endpoint":"controller-0","error":"banana"`))
+			Expect(logger).To(gbytes.Say(`controller-request-succeeded.*"endpoint":"controller-1"`))
+		})
+
+		It("skips the endpoint while it is backing off", func() {
+			for i := 0; i < 4; i++ {
+				Expect(client.Do("GET", "/leases", nil, nil, "")).To(Succeed())
+			}
+
+			Expect(jsonClients[0].DoCallCount()).To(Equal(1))
+			Expect(jsonClients[1].DoCallCount() + jsonClients[2].DoCallCount()).To(Equal(4))
+		})
+	})
+
+	Context("when the endpoint backoff has passed", func() {
+		BeforeEach(func() {
+			client.MinBackoff = time.Millisecond
+			client.MaxBackoff = time.Millisecond
+			jsonClients[0].DoReturnsOnCall(0, errors.New("banana"))
+		})
+
+		It("uses the endpoint again", func() {
+			Expect(client.Do("GET", "/leases", nil, nil, "")).To(Succeed())
+			time.Sleep(10 * time.Millisecond)
+
+			for i := 0; i < 3; i++ {
+				Expect(client.Do("GET", "/leases", nil, nil, "")).To(Succeed())
+			}
+			Expect(jsonClients[0].DoCallCount()).To(Equal(2))
+		})
+	})
+
+	Context("when every endpoint fails", func() {
+		BeforeEach(func() {
+			jsonClients[0].DoReturns(errors.New("banana"))
+			jsonClients[1].DoReturns(errors.New("kiwi"))
+			jsonClients[2].DoReturns(&json_client.HttpResponseCodeError{StatusCode: 503, Message: "pear"})
+		})
+
+		It("returns the last error", func() {
+			err := client.Do("GET", "/leases", nil, nil, "")
+			Expect(err).To(MatchError("http status 503: pear"))
+			Expect(counters()).To(Equal([]string{
+				"controllerRequestFailure",
+				"controllerRequestFailure",
+				"controllerRequestFailure",
+			}))
+		})
+
+		It("still tries the unhealthy endpoints, soonest healthy first", func() {
+			Expect(client.Do("GET", "/leases", nil, nil, "")).NotTo(Succeed())
+			jsonClients[2].DoReturns(nil)
+
+			Expect(client.Do("GET", "/leases", nil, nil, "")).To(Succeed())
+			Expect(jsonClients[0].DoCallCount()).To(Equal(2))
+			Expect(jsonClients[1].DoCallCount()).To(Equal(2))
+			Expect(jsonClients[2].DoCallCount()).To(Equal(2))
+		})
+	})
+
+	Context("when an endpoint rejects the request", func() {
+		BeforeEach(func() {
+			jsonClients[0].DoReturns(&json_client.HttpResponseCodeError{StatusCode: 409, Message: "conflict"})
+		})
+
+		It("returns the error without failing over", func() {
+			err := client.Do("PUT", "/leases/renew", nil, nil, "")
+			Expect(err).To(MatchError("http status 409: conflict"))
+			Expect(jsonClients[1].DoCallCount()).To(Equal(0))
+			Expect(counters()).To(Equal([]string{"controllerRequestSuccess"}))
+		})
+	})
+
+	Context("when the client is sticky", func() {
+		BeforeEach(func() {
+			client.Sticky = true
+		})
+
+		It("keeps using the endpoint that last succeeded", func() {
+			jsonClients[0].DoReturnsOnCall(0, errors.New("banana"))
+			for i := 0; i < 3; i++ {
+				Expect(client.Do("GET", "/leases/watch", nil, nil, "")).To(Succeed())
+			}
+
+			Expect(jsonClients[0].DoCallCount()).To(Equal(1))
+			Expect(jsonClients[1].DoCallCount()).To(Equal(3))
+			Expect(jsonClients[2].DoCallCount()).To(Equal(0))
+		})
+	})
+
+	Context("when there are no endpoints", func() {
+		BeforeEach(func() {
+			client.Endpoints = nil
+		})
+
+		It("returns an error", func() {
+			Expect(client.Do("GET", "/leases", nil, nil, "")).To(MatchError("no silk-controller endpoints"))
+		})
+	})
+
+	Describe("NewFailoverClient", func() {
+		It("names the endpoints after their hosts", func() {
+			c := controller.NewFailoverClient(logger, &helpersfakes.HTTPClient{}, []string{
+				"https://silk-controller-0.example.com:4103",
+				"https://silk-controller-1.example.com:4103",
+			}, fakeMetricSender)
+
+			failoverClient, ok := c.JsonClient.(*controller.FailoverClient)
+			Expect(ok).To(BeTrue())
+			Expect(failoverClient.Endpoints).To(HaveLen(2))
+			Expect(failoverClient.Endpoints[0].Name).To(Equal("silk-controller-0.example.com:4103"))
+			Expect(failoverClient.Endpoints[1].Name).To(Equal("silk-controller-1.example.com:4103"))
+			Expect(failoverClient.Sticky).To(BeFalse())
+		})
+	})
+})
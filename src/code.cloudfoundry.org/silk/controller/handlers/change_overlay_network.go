@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"code.cloudfoundry.org/cf-networking-helpers/marshal"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+const (
+	OverlayNetworkAdd    = "add"
+	OverlayNetworkDrain  = "drain"
+	OverlayNetworkRetire = "retire"
+)
+
+//go:generate counterfeiter -o fakes/overlay_network_changer.go --fake-name OverlayNetworkChanger . overlayNetworkChanger
+type overlayNetworkChanger interface {
+	AddOverlayNetwork(cidr string) error
+	DrainOverlayNetwork(cidr string) error
+	RetireOverlayNetwork(cidr string) error
+}
+
+// ChangeOverlayNetwork serves one of the add, drain and retire endpoints,
+// depending on Action.
+type ChangeOverlayNetwork struct {
+	Action                string
+	Unmarshaler           marshal.Unmarshaler
+	OverlayNetworkChanger overlayNetworkChanger
+	ErrorResponse         errorResponse
+}
+
+func (l *ChangeOverlayNetwork) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *http.Request) {
+	logger = logger.Session(fmt.Sprintf("overlay-networks-%s", l.Action))
+
+	bodyBytes, err := io.ReadAll(req.Body)
+	if err != nil {
+		l.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("read-body: %s", err.Error()))
+		return
+	}
+
+	var payload struct {
+		CIDR string `json:"cidr"`
+	}
+	err = l.Unmarshaler.Unmarshal(bodyBytes, &payload)
+	if err != nil {
+		l.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("unmarshal-request: %s", err.Error()))
+		return
+	}
+
+	switch l.Action {
+	case OverlayNetworkAdd:
+		err = l.OverlayNetworkChanger.AddOverlayNetwork(payload.CIDR)
+	case OverlayNetworkDrain:
+		err = l.OverlayNetworkChanger.DrainOverlayNetwork(payload.CIDR)
+	case OverlayNetworkRetire:
+		err = l.OverlayNetworkChanger.RetireOverlayNetwork(payload.CIDR)
+	default:
+		err = fmt.Errorf("unknown action: %s", l.Action)
+	}
+	if err != nil {
+		description := fmt.Sprintf("%s-overlay-network: %s", l.Action, err.Error())
+		switch err.(type) {
+		case controller.InvalidRequestError:
+			l.ErrorResponse.BadRequest(logger, w, err, description)
+		case controller.NonRetriableError:
+			l.ErrorResponse.Conflict(logger, w, err, description)
+		default:
+			l.ErrorResponse.InternalServerError(logger, w, err, description)
+		}
+		return
+	}
+
+	// #nosec G104 - ignore errors when writing HTTP responses so we don't spam our logs during a DoS
+	w.Write([]byte(`{}`))
+}
@@ -0,0 +1,159 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	hfakes "code.cloudfoundry.org/cf-networking-helpers/fakes"
+	"code.cloudfoundry.org/cf-networking-helpers/testsupport"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/handlers"
+	"code.cloudfoundry.org/silk/controller/handlers/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ChangeOverlayNetwork", func() {
+	var (
+		logger                *lagertest.TestLogger
+		expectedLogger        lager.Logger
+		handler               *handlers.ChangeOverlayNetwork
+		resp                  *httptest.ResponseRecorder
+		unmarshaler           *hfakes.Unmarshaler
+		overlayNetworkChanger *fakes.OverlayNetworkChanger
+		fakeErrorResponse     *fakes.ErrorResponse
+
+		request *http.Request
+	)
+
+	BeforeEach(func() {
+		expectedLogger = lager.NewLogger("test").Session("overlay-networks-drain")
+		testSink := lagertest.NewTestSink()
+		expectedLogger.RegisterSink(testSink)
+		expectedLogger.RegisterSink(lager.NewWriterSink(GinkgoWriter, lager.DEBUG))
+
+		logger = lagertest.NewTestLogger("test")
+		unmarshaler = &hfakes.Unmarshaler{}
+		unmarshaler.UnmarshalStub = json.Unmarshal
+		overlayNetworkChanger = &fakes.OverlayNetworkChanger{}
+		fakeErrorResponse = &fakes.ErrorResponse{}
+
+		handler = &handlers.ChangeOverlayNetwork{
+			Action:                handlers.OverlayNetworkDrain,
+			Unmarshaler:           unmarshaler,
+			OverlayNetworkChanger: overlayNetworkChanger,
+			ErrorResponse:         fakeErrorResponse,
+		}
+		resp = httptest.NewRecorder()
+
+		requestBody := bytes.NewBuffer([]byte(`{ "cidr": "10.255.0.0/16" }`))
+		var err error
+		request, err = http.NewRequest("PUT", "/overlay-networks/drain", requestBody)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	DescribeTable("calls the changer for the action",
+		func(action string, callCount func() int, cidr func() string) {
+			handler.Action = action
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(callCount()).To(Equal(1))
+			Expect(cidr()).To(Equal("10.255.0.0/16"))
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(resp.Body.String()).To(MatchJSON(`{}`))
+		},
+		Entry("add", handlers.OverlayNetworkAdd,
+			func() int { return overlayNetworkChanger.AddOverlayNetworkCallCount() },
+			func() string { return overlayNetworkChanger.AddOverlayNetworkArgsForCall(0) }),
+		Entry("drain", handlers.OverlayNetworkDrain,
+			func() int { return overlayNetworkChanger.DrainOverlayNetworkCallCount() },
+			func() string { return overlayNetworkChanger.DrainOverlayNetworkArgsForCall(0) }),
+		Entry("retire", handlers.OverlayNetworkRetire,
+			func() int { return overlayNetworkChanger.RetireOverlayNetworkCallCount() },
+			func() string { return overlayNetworkChanger.RetireOverlayNetworkArgsForCall(0) }),
+	)
+
+	Context("when there are errors reading the body bytes", func() {
+		BeforeEach(func() {
+			request.Body = io.NopCloser(&testsupport.BadReader{})
+		})
+
+		It("logs the error and returns a 400", func() {
+			handler.ServeHTTP(logger, resp, request)
+			Expect(fakeErrorResponse.BadRequestCallCount()).To(Equal(1))
+
+			l, w, err, description := fakeErrorResponse.BadRequestArgsForCall(0)
+			Expect(l).To(Equal(expectedLogger))
+			Expect(w).To(Equal(resp))
+			Expect(err).To(MatchError("banana"))
+			Expect(description).To(Equal("read-body: banana"))
+		})
+	})
+
+	Context("when the request cannot be unmarshaled", func() {
+		BeforeEach(func() {
+			unmarshaler.UnmarshalReturns(errors.New("kiwi"))
+		})
+
+		It("returns a 400", func() {
+			handler.ServeHTTP(logger, resp, request)
+			Expect(fakeErrorResponse.BadRequestCallCount()).To(Equal(1))
+
+			_, _, err, description := fakeErrorResponse.BadRequestArgsForCall(0)
+			Expect(err).To(MatchError("kiwi"))
+			Expect(description).To(Equal("unmarshal-request: kiwi"))
+		})
+	})
+
+	Context("when the request is invalid", func() {
+		BeforeEach(func() {
+			overlayNetworkChanger.DrainOverlayNetworkReturns(controller.InvalidRequestError("does not exist"))
+		})
+
+		It("returns a 400", func() {
+			handler.ServeHTTP(logger, resp, request)
+			Expect(fakeErrorResponse.BadRequestCallCount()).To(Equal(1))
+
+			_, _, err, description := fakeErrorResponse.BadRequestArgsForCall(0)
+			Expect(err).To(MatchError("does not exist"))
+			Expect(description).To(Equal("drain-overlay-network: does not exist"))
+		})
+	})
+
+	Context("when the change conflicts with the state of the network", func() {
+		BeforeEach(func() {
+			overlayNetworkChanger.DrainOverlayNetworkReturns(controller.NonRetriableError("last active network"))
+		})
+
+		It("returns a 409", func() {
+			handler.ServeHTTP(logger, resp, request)
+			Expect(fakeErrorResponse.ConflictCallCount()).To(Equal(1))
+
+			_, _, err, description := fakeErrorResponse.ConflictArgsForCall(0)
+			Expect(err).To(MatchError("last active network"))
+			Expect(description).To(Equal("drain-overlay-network: last active network"))
+		})
+	})
+
+	Context("when the change fails", func() {
+		BeforeEach(func() {
+			overlayNetworkChanger.DrainOverlayNetworkReturns(errors.New("database down"))
+		})
+
+		It("returns a 500", func() {
+			handler.ServeHTTP(logger, resp, request)
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+
+			_, _, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(err).To(MatchError("database down"))
+			Expect(description).To(Equal("drain-overlay-network: database down"))
+		})
+	})
+})
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"code.cloudfoundry.org/cf-networking-helpers/marshal"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+//go:generate counterfeiter -o fakes/reservation_deleter.go --fake-name ReservationDeleter . reservationDeleter
+type reservationDeleter interface {
+	DeleteReservation(underlayIP string) error
+}
+
+type DeleteReservation struct {
+	Unmarshaler        marshal.Unmarshaler
+	ReservationDeleter reservationDeleter
+	ErrorResponse      errorResponse
+}
+
+func (l *DeleteReservation) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *http.Request) {
+	logger = logger.Session("reservations-delete")
+
+	bodyBytes, err := io.ReadAll(req.Body)
+	if err != nil {
+		l.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("read-body: %s", err.Error()))
+		return
+	}
+
+	var payload struct {
+		UnderlayIP string `json:"underlay_ip"`
+	}
+	err = l.Unmarshaler.Unmarshal(bodyBytes, &payload)
+	if err != nil {
+		l.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("unmarshal-request: %s", err.Error()))
+		return
+	}
+
+	err = l.ReservationDeleter.DeleteReservation(payload.UnderlayIP)
+	if err != nil {
+		l.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("delete-reservation: %s", err.Error()))
+		return
+	}
+
+	// #nosec G104 - ignore errors when writing HTTP responses so we don't spam our logs during a DoS
+	w.Write([]byte(`{}`))
+}
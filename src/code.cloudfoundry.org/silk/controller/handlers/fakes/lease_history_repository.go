@@ -0,0 +1,114 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+type LeaseHistoryRepository struct {
+	LeaseHistoryStub        func(controller.LeaseHistoryQuery) (controller.LeaseHistory, error)
+	leaseHistoryMutex       sync.RWMutex
+	leaseHistoryArgsForCall []struct {
+		arg1 controller.LeaseHistoryQuery
+	}
+	leaseHistoryReturns struct {
+		result1 controller.LeaseHistory
+		result2 error
+	}
+	leaseHistoryReturnsOnCall map[int]struct {
+		result1 controller.LeaseHistory
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *LeaseHistoryRepository) LeaseHistory(arg1 controller.LeaseHistoryQuery) (controller.LeaseHistory, error) {
+	fake.leaseHistoryMutex.Lock()
+	ret, specificReturn := fake.leaseHistoryReturnsOnCall[len(fake.leaseHistoryArgsForCall)]
+	fake.leaseHistoryArgsForCall = append(fake.leaseHistoryArgsForCall, struct {
+		arg1 controller.LeaseHistoryQuery
+	}{arg1})
+	stub := fake.LeaseHistoryStub
+	fakeReturns := fake.leaseHistoryReturns
+	fake.recordInvocation("LeaseHistory", []interface{}{arg1})
+	fake.leaseHistoryMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *LeaseHistoryRepository) LeaseHistoryCallCount() int {
+	fake.leaseHistoryMutex.RLock()
+	defer fake.leaseHistoryMutex.RUnlock()
+	return len(fake.leaseHistoryArgsForCall)
+}
+
+func (fake *LeaseHistoryRepository) LeaseHistoryCalls(stub func(controller.LeaseHistoryQuery) (controller.LeaseHistory, error)) {
+	fake.leaseHistoryMutex.Lock()
+	defer fake.leaseHistoryMutex.Unlock()
+	fake.LeaseHistoryStub = stub
+}
+
+func (fake *LeaseHistoryRepository) LeaseHistoryArgsForCall(i int) controller.LeaseHistoryQuery {
+	fake.leaseHistoryMutex.RLock()
+	defer fake.leaseHistoryMutex.RUnlock()
+	argsForCall := fake.leaseHistoryArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *LeaseHistoryRepository) LeaseHistoryReturns(result1 controller.LeaseHistory, result2 error) {
+	fake.leaseHistoryMutex.Lock()
+	defer fake.leaseHistoryMutex.Unlock()
+	fake.LeaseHistoryStub = nil
+	fake.leaseHistoryReturns = struct {
+		result1 controller.LeaseHistory
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *LeaseHistoryRepository) LeaseHistoryReturnsOnCall(i int, result1 controller.LeaseHistory, result2 error) {
+	fake.leaseHistoryMutex.Lock()
+	defer fake.leaseHistoryMutex.Unlock()
+	fake.LeaseHistoryStub = nil
+	if fake.leaseHistoryReturnsOnCall == nil {
+		fake.leaseHistoryReturnsOnCall = make(map[int]struct {
+			result1 controller.LeaseHistory
+			result2 error
+		})
+	}
+	fake.leaseHistoryReturnsOnCall[i] = struct {
+		result1 controller.LeaseHistory
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *LeaseHistoryRepository) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.leaseHistoryMutex.RLock()
+	defer fake.leaseHistoryMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *LeaseHistoryRepository) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
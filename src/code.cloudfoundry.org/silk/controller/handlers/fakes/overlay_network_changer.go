@@ -0,0 +1,255 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+)
+
+type OverlayNetworkChanger struct {
+	AddOverlayNetworkStub        func(string) error
+	addOverlayNetworkMutex       sync.RWMutex
+	addOverlayNetworkArgsForCall []struct {
+		arg1 string
+	}
+	addOverlayNetworkReturns struct {
+		result1 error
+	}
+	addOverlayNetworkReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DrainOverlayNetworkStub        func(string) error
+	drainOverlayNetworkMutex       sync.RWMutex
+	drainOverlayNetworkArgsForCall []struct {
+		arg1 string
+	}
+	drainOverlayNetworkReturns struct {
+		result1 error
+	}
+	drainOverlayNetworkReturnsOnCall map[int]struct {
+		result1 error
+	}
+	RetireOverlayNetworkStub        func(string) error
+	retireOverlayNetworkMutex       sync.RWMutex
+	retireOverlayNetworkArgsForCall []struct {
+		arg1 string
+	}
+	retireOverlayNetworkReturns struct {
+		result1 error
+	}
+	retireOverlayNetworkReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *OverlayNetworkChanger) AddOverlayNetwork(arg1 string) error {
+	fake.addOverlayNetworkMutex.Lock()
+	ret, specificReturn := fake.addOverlayNetworkReturnsOnCall[len(fake.addOverlayNetworkArgsForCall)]
+	fake.addOverlayNetworkArgsForCall = append(fake.addOverlayNetworkArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.AddOverlayNetworkStub
+	fakeReturns := fake.addOverlayNetworkReturns
+	fake.recordInvocation("AddOverlayNetwork", []interface{}{arg1})
+	fake.addOverlayNetworkMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *OverlayNetworkChanger) AddOverlayNetworkCallCount() int {
+	fake.addOverlayNetworkMutex.RLock()
+	defer fake.addOverlayNetworkMutex.RUnlock()
+	return len(fake.addOverlayNetworkArgsForCall)
+}
+
+func (fake *OverlayNetworkChanger) AddOverlayNetworkCalls(stub func(string) error) {
+	fake.addOverlayNetworkMutex.Lock()
+	defer fake.addOverlayNetworkMutex.Unlock()
+	fake.AddOverlayNetworkStub = stub
+}
+
+func (fake *OverlayNetworkChanger) AddOverlayNetworkArgsForCall(i int) string {
+	fake.addOverlayNetworkMutex.RLock()
+	defer fake.addOverlayNetworkMutex.RUnlock()
+	argsForCall := fake.addOverlayNetworkArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *OverlayNetworkChanger) AddOverlayNetworkReturns(result1 error) {
+	fake.addOverlayNetworkMutex.Lock()
+	defer fake.addOverlayNetworkMutex.Unlock()
+	fake.AddOverlayNetworkStub = nil
+	fake.addOverlayNetworkReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *OverlayNetworkChanger) AddOverlayNetworkReturnsOnCall(i int, result1 error) {
+	fake.addOverlayNetworkMutex.Lock()
+	defer fake.addOverlayNetworkMutex.Unlock()
+	fake.AddOverlayNetworkStub = nil
+	if fake.addOverlayNetworkReturnsOnCall == nil {
+		fake.addOverlayNetworkReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addOverlayNetworkReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *OverlayNetworkChanger) DrainOverlayNetwork(arg1 string) error {
+	fake.drainOverlayNetworkMutex.Lock()
+	ret, specificReturn := fake.drainOverlayNetworkReturnsOnCall[len(fake.drainOverlayNetworkArgsForCall)]
+	fake.drainOverlayNetworkArgsForCall = append(fake.drainOverlayNetworkArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.DrainOverlayNetworkStub
+	fakeReturns := fake.drainOverlayNetworkReturns
+	fake.recordInvocation("DrainOverlayNetwork", []interface{}{arg1})
+	fake.drainOverlayNetworkMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *OverlayNetworkChanger) DrainOverlayNetworkCallCount() int {
+	fake.drainOverlayNetworkMutex.RLock()
+	defer fake.drainOverlayNetworkMutex.RUnlock()
+	return len(fake.drainOverlayNetworkArgsForCall)
+}
+
+func (fake *OverlayNetworkChanger) DrainOverlayNetworkCalls(stub func(string) error) {
+	fake.drainOverlayNetworkMutex.Lock()
+	defer fake.drainOverlayNetworkMutex.Unlock()
+	fake.DrainOverlayNetworkStub = stub
+}
+
+func (fake *OverlayNetworkChanger) DrainOverlayNetworkArgsForCall(i int) string {
+	fake.drainOverlayNetworkMutex.RLock()
+	defer fake.drainOverlayNetworkMutex.RUnlock()
+	argsForCall := fake.drainOverlayNetworkArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *OverlayNetworkChanger) DrainOverlayNetworkReturns(result1 error) {
+	fake.drainOverlayNetworkMutex.Lock()
+	defer fake.drainOverlayNetworkMutex.Unlock()
+	fake.DrainOverlayNetworkStub = nil
+	fake.drainOverlayNetworkReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *OverlayNetworkChanger) DrainOverlayNetworkReturnsOnCall(i int, result1 error) {
+	fake.drainOverlayNetworkMutex.Lock()
+	defer fake.drainOverlayNetworkMutex.Unlock()
+	fake.DrainOverlayNetworkStub = nil
+	if fake.drainOverlayNetworkReturnsOnCall == nil {
+		fake.drainOverlayNetworkReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.drainOverlayNetworkReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *OverlayNetworkChanger) RetireOverlayNetwork(arg1 string) error {
+	fake.retireOverlayNetworkMutex.Lock()
+	ret, specificReturn := fake.retireOverlayNetworkReturnsOnCall[len(fake.retireOverlayNetworkArgsForCall)]
+	fake.retireOverlayNetworkArgsForCall = append(fake.retireOverlayNetworkArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.RetireOverlayNetworkStub
+	fakeReturns := fake.retireOverlayNetworkReturns
+	fake.recordInvocation("RetireOverlayNetwork", []interface{}{arg1})
+	fake.retireOverlayNetworkMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *OverlayNetworkChanger) RetireOverlayNetworkCallCount() int {
+	fake.retireOverlayNetworkMutex.RLock()
+	defer fake.retireOverlayNetworkMutex.RUnlock()
+	return len(fake.retireOverlayNetworkArgsForCall)
+}
+
+func (fake *OverlayNetworkChanger) RetireOverlayNetworkCalls(stub func(string) error) {
+	fake.retireOverlayNetworkMutex.Lock()
+	defer fake.retireOverlayNetworkMutex.Unlock()
+	fake.RetireOverlayNetworkStub = stub
+}
+
+func (fake *OverlayNetworkChanger) RetireOverlayNetworkArgsForCall(i int) string {
+	fake.retireOverlayNetworkMutex.RLock()
+	defer fake.retireOverlayNetworkMutex.RUnlock()
+	argsForCall := fake.retireOverlayNetworkArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *OverlayNetworkChanger) RetireOverlayNetworkReturns(result1 error) {
+	fake.retireOverlayNetworkMutex.Lock()
+	defer fake.retireOverlayNetworkMutex.Unlock()
+	fake.RetireOverlayNetworkStub = nil
+	fake.retireOverlayNetworkReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *OverlayNetworkChanger) RetireOverlayNetworkReturnsOnCall(i int, result1 error) {
+	fake.retireOverlayNetworkMutex.Lock()
+	defer fake.retireOverlayNetworkMutex.Unlock()
+	fake.RetireOverlayNetworkStub = nil
+	if fake.retireOverlayNetworkReturnsOnCall == nil {
+		fake.retireOverlayNetworkReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.retireOverlayNetworkReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *OverlayNetworkChanger) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.addOverlayNetworkMutex.RLock()
+	defer fake.addOverlayNetworkMutex.RUnlock()
+	fake.drainOverlayNetworkMutex.RLock()
+	defer fake.drainOverlayNetworkMutex.RUnlock()
+	fake.retireOverlayNetworkMutex.RLock()
+	defer fake.retireOverlayNetworkMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *OverlayNetworkChanger) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -0,0 +1,105 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+type OverlayNetworkRepository struct {
+	OverlayNetworksStub        func() ([]controller.OverlayNetwork, error)
+	overlayNetworksMutex       sync.RWMutex
+	overlayNetworksArgsForCall []struct {
+	}
+	overlayNetworksReturns struct {
+		result1 []controller.OverlayNetwork
+		result2 error
+	}
+	overlayNetworksReturnsOnCall map[int]struct {
+		result1 []controller.OverlayNetwork
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *OverlayNetworkRepository) OverlayNetworks() ([]controller.OverlayNetwork, error) {
+	fake.overlayNetworksMutex.Lock()
+	ret, specificReturn := fake.overlayNetworksReturnsOnCall[len(fake.overlayNetworksArgsForCall)]
+	fake.overlayNetworksArgsForCall = append(fake.overlayNetworksArgsForCall, struct {
+	}{})
+	stub := fake.OverlayNetworksStub
+	fakeReturns := fake.overlayNetworksReturns
+	fake.recordInvocation("OverlayNetworks", []interface{}{})
+	fake.overlayNetworksMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *OverlayNetworkRepository) OverlayNetworksCallCount() int {
+	fake.overlayNetworksMutex.RLock()
+	defer fake.overlayNetworksMutex.RUnlock()
+	return len(fake.overlayNetworksArgsForCall)
+}
+
+func (fake *OverlayNetworkRepository) OverlayNetworksCalls(stub func() ([]controller.OverlayNetwork, error)) {
+	fake.overlayNetworksMutex.Lock()
+	defer fake.overlayNetworksMutex.Unlock()
+	fake.OverlayNetworksStub = stub
+}
+
+func (fake *OverlayNetworkRepository) OverlayNetworksReturns(result1 []controller.OverlayNetwork, result2 error) {
+	fake.overlayNetworksMutex.Lock()
+	defer fake.overlayNetworksMutex.Unlock()
+	fake.OverlayNetworksStub = nil
+	fake.overlayNetworksReturns = struct {
+		result1 []controller.OverlayNetwork
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *OverlayNetworkRepository) OverlayNetworksReturnsOnCall(i int, result1 []controller.OverlayNetwork, result2 error) {
+	fake.overlayNetworksMutex.Lock()
+	defer fake.overlayNetworksMutex.Unlock()
+	fake.OverlayNetworksStub = nil
+	if fake.overlayNetworksReturnsOnCall == nil {
+		fake.overlayNetworksReturnsOnCall = make(map[int]struct {
+			result1 []controller.OverlayNetwork
+			result2 error
+		})
+	}
+	fake.overlayNetworksReturnsOnCall[i] = struct {
+		result1 []controller.OverlayNetwork
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *OverlayNetworkRepository) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.overlayNetworksMutex.RLock()
+	defer fake.overlayNetworksMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *OverlayNetworkRepository) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
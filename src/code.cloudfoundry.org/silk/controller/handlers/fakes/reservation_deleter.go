@@ -0,0 +1,107 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+)
+
+type ReservationDeleter struct {
+	DeleteReservationStub        func(string) error
+	deleteReservationMutex       sync.RWMutex
+	deleteReservationArgsForCall []struct {
+		arg1 string
+	}
+	deleteReservationReturns struct {
+		result1 error
+	}
+	deleteReservationReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *ReservationDeleter) DeleteReservation(arg1 string) error {
+	fake.deleteReservationMutex.Lock()
+	ret, specificReturn := fake.deleteReservationReturnsOnCall[len(fake.deleteReservationArgsForCall)]
+	fake.deleteReservationArgsForCall = append(fake.deleteReservationArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.DeleteReservationStub
+	fakeReturns := fake.deleteReservationReturns
+	fake.recordInvocation("DeleteReservation", []interface{}{arg1})
+	fake.deleteReservationMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *ReservationDeleter) DeleteReservationCallCount() int {
+	fake.deleteReservationMutex.RLock()
+	defer fake.deleteReservationMutex.RUnlock()
+	return len(fake.deleteReservationArgsForCall)
+}
+
+func (fake *ReservationDeleter) DeleteReservationCalls(stub func(string) error) {
+	fake.deleteReservationMutex.Lock()
+	defer fake.deleteReservationMutex.Unlock()
+	fake.DeleteReservationStub = stub
+}
+
+func (fake *ReservationDeleter) DeleteReservationArgsForCall(i int) string {
+	fake.deleteReservationMutex.RLock()
+	defer fake.deleteReservationMutex.RUnlock()
+	argsForCall := fake.deleteReservationArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *ReservationDeleter) DeleteReservationReturns(result1 error) {
+	fake.deleteReservationMutex.Lock()
+	defer fake.deleteReservationMutex.Unlock()
+	fake.DeleteReservationStub = nil
+	fake.deleteReservationReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *ReservationDeleter) DeleteReservationReturnsOnCall(i int, result1 error) {
+	fake.deleteReservationMutex.Lock()
+	defer fake.deleteReservationMutex.Unlock()
+	fake.DeleteReservationStub = nil
+	if fake.deleteReservationReturnsOnCall == nil {
+		fake.deleteReservationReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteReservationReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *ReservationDeleter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.deleteReservationMutex.RLock()
+	defer fake.deleteReservationMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *ReservationDeleter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"code.cloudfoundry.org/cf-networking-helpers/marshal"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+const maxLeaseHistoryLimit = 1000
+
+//go:generate counterfeiter -o fakes/lease_history_repository.go --fake-name LeaseHistoryRepository . leaseHistoryRepository
+type leaseHistoryRepository interface {
+	LeaseHistory(query controller.LeaseHistoryQuery) (controller.LeaseHistory, error)
+}
+
+type LeaseHistory struct {
+	Marshaler              marshal.Marshaler
+	LeaseHistoryRepository leaseHistoryRepository
+	ErrorResponse          errorResponse
+}
+
+func (l *LeaseHistory) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *http.Request) {
+	logger = logger.Session("leases-history")
+
+	params := req.URL.Query()
+	query := controller.LeaseHistoryQuery{
+		UnderlayIP:    params.Get("underlay_ip"),
+		OverlaySubnet: params.Get("overlay_subnet"),
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		var err error
+		query.Limit, err = strconv.Atoi(limit)
+		if err == nil && (query.Limit < 1 || query.Limit > maxLeaseHistoryLimit) {
+			err = fmt.Errorf("must be between 1 and %d", maxLeaseHistoryLimit)
+		}
+		if err != nil {
+			l.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("invalid-limit: %s", err.Error()))
+			return
+		}
+	}
+
+	if before := params.Get("before"); before != "" {
+		var err error
+		query.Before, err = strconv.ParseInt(before, 10, 64)
+		if err == nil && query.Before < 1 {
+			err = fmt.Errorf("must be positive")
+		}
+		if err != nil {
+			l.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("invalid-before: %s", err.Error()))
+			return
+		}
+	}
+
+	history, err := l.LeaseHistoryRepository.LeaseHistory(query)
+	if err != nil {
+		l.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("lease-history: %s", err.Error()))
+		return
+	}
+
+	bytes, err := l.Marshaler.Marshal(history)
+	if err != nil {
+		l.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("marshal-response: %s", err.Error()))
+		return
+	}
+
+	// #nosec G104 - ignore errors when writing HTTP responses so we don't spam our logs during a DoS
+	w.Write(bytes)
+}
@@ -0,0 +1,149 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	hfakes "code.cloudfoundry.org/cf-networking-helpers/fakes"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/handlers"
+	"code.cloudfoundry.org/silk/controller/handlers/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LeaseHistory", func() {
+	var (
+		logger                 *lagertest.TestLogger
+		expectedLogger         lager.Logger
+		handler                *handlers.LeaseHistory
+		leaseHistoryRepository *fakes.LeaseHistoryRepository
+		resp                   *httptest.ResponseRecorder
+		marshaler              *hfakes.Marshaler
+		fakeErrorResponse      *fakes.ErrorResponse
+	)
+
+	newRequest := func(url string) *http.Request {
+		request, err := http.NewRequest("GET", url, nil)
+		Expect(err).NotTo(HaveOccurred())
+		return request
+	}
+
+	BeforeEach(func() {
+		expectedLogger = lager.NewLogger("test").Session("leases-history")
+
+		testSink := lagertest.NewTestSink()
+		expectedLogger.RegisterSink(testSink)
+		expectedLogger.RegisterSink(lager.NewWriterSink(GinkgoWriter, lager.DEBUG))
+
+		logger = lagertest.NewTestLogger("test")
+		marshaler = &hfakes.Marshaler{}
+		marshaler.MarshalStub = json.Marshal
+		leaseHistoryRepository = &fakes.LeaseHistoryRepository{}
+		fakeErrorResponse = &fakes.ErrorResponse{}
+		handler = &handlers.LeaseHistory{
+			Marshaler:              marshaler,
+			LeaseHistoryRepository: leaseHistoryRepository,
+			ErrorResponse:          fakeErrorResponse,
+		}
+		resp = httptest.NewRecorder()
+		leaseHistoryRepository.LeaseHistoryReturns(controller.LeaseHistory{
+			Events: []controller.LeaseEvent{
+				{
+					ID:                 12,
+					Type:               "reclaim",
+					UnderlayIP:         "10.244.5.9",
+					OverlaySubnet:      "10.255.16.0/24",
+					PreviousUnderlayIP: "10.244.5.8",
+					Timestamp:          1700000000,
+				},
+			},
+			NextBefore: 12,
+		}, nil)
+	})
+
+	It("returns the filtered lease history", func() {
+		handler.ServeHTTP(logger, resp, newRequest("/leases/history?underlay_ip=10.244.5.9&overlay_subnet=10.255.16.0%2F24&limit=1&before=20"))
+
+		Expect(leaseHistoryRepository.LeaseHistoryCallCount()).To(Equal(1))
+		Expect(leaseHistoryRepository.LeaseHistoryArgsForCall(0)).To(Equal(controller.LeaseHistoryQuery{
+			UnderlayIP:    "10.244.5.9",
+			OverlaySubnet: "10.255.16.0/24",
+			Limit:         1,
+			Before:        20,
+		}))
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body).To(MatchJSON(`{
+			"events": [{
+				"id": 12,
+				"type": "reclaim",
+				"underlay_ip": "10.244.5.9",
+				"overlay_subnet": "10.255.16.0/24",
+				"previous_underlay_ip": "10.244.5.8",
+				"timestamp": 1700000000
+			}],
+			"next_before": 12
+		}`))
+	})
+
+	It("leaves unset parameters empty", func() {
+		handler.ServeHTTP(logger, resp, newRequest("/leases/history"))
+
+		Expect(leaseHistoryRepository.LeaseHistoryArgsForCall(0)).To(Equal(controller.LeaseHistoryQuery{}))
+	})
+
+	DescribeTable("rejects invalid paging parameters",
+		func(url, description string) {
+			handler.ServeHTTP(logger, resp, newRequest(url))
+
+			Expect(leaseHistoryRepository.LeaseHistoryCallCount()).To(Equal(0))
+			Expect(fakeErrorResponse.BadRequestCallCount()).To(Equal(1))
+			l, w, _, actualDescription := fakeErrorResponse.BadRequestArgsForCall(0)
+			Expect(l).To(Equal(expectedLogger))
+			Expect(w).To(Equal(resp))
+			Expect(actualDescription).To(Equal(description))
+		},
+		Entry("a non-numeric limit", "/leases/history?limit=banana", `invalid-limit: strconv.Atoi: parsing "banana": invalid syntax`),
+		Entry("a zero limit", "/leases/history?limit=0", "invalid-limit: must be between 1 and 1000"),
+		Entry("a limit that is too large", "/leases/history?limit=1001", "invalid-limit: must be between 1 and 1000"),
+		Entry("a non-numeric before", "/leases/history?before=banana", `invalid-before: strconv.ParseInt: parsing "banana": invalid syntax`),
+		Entry("a negative before", "/leases/history?before=-1", "invalid-before: must be positive"),
+	)
+
+	Context("when getting the lease history fails", func() {
+		BeforeEach(func() {
+			leaseHistoryRepository.LeaseHistoryReturns(controller.LeaseHistory{}, errors.New("butter"))
+		})
+
+		It("calls the internal server error handler", func() {
+			handler.ServeHTTP(logger, resp, newRequest("/leases/history"))
+
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+			_, _, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(err).To(MatchError("butter"))
+			Expect(description).To(Equal("lease-history: butter"))
+		})
+	})
+
+	Context("when the response cannot be marshaled", func() {
+		BeforeEach(func() {
+			marshaler.MarshalStub = func(interface{}) ([]byte, error) {
+				return nil, errors.New("grapes")
+			}
+		})
+
+		It("calls the internal server error handler", func() {
+			handler.ServeHTTP(logger, resp, newRequest("/leases/history"))
+
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+			_, _, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(err).To(MatchError("grapes"))
+			Expect(description).To(Equal("marshal-response: grapes"))
+		})
+	})
+})
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"code.cloudfoundry.org/cf-networking-helpers/marshal"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+//go:generate counterfeiter -o fakes/lease_watcher.go --fake-name LeaseWatcher . leaseWatcher
+type leaseWatcher interface {
+	Watch(ctx context.Context, epoch string, revision uint64) (controller.LeaseUpdate, error)
+}
+
+// LeasesWatch is a long poll for lease changes. Callers pass the epoch and
+// revision of the last update they got, and wait_seconds to wait for a
+// change when they are up to date. Requests without an epoch get every
+// routable lease.
+type LeasesWatch struct {
+	Marshaler     marshal.Marshaler
+	LeaseWatcher  leaseWatcher
+	ErrorResponse errorResponse
+	MaxWait       time.Duration
+}
+
+func (l *LeasesWatch) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *http.Request) {
+	logger = logger.Session("leases-watch")
+
+	params := req.URL.Query()
+
+	var revision uint64
+	if value := params.Get("revision"); value != "" {
+		var err error
+		revision, err = strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			l.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("invalid-revision: %s", err.Error()))
+			return
+		}
+	}
+
+	var wait time.Duration
+	if value := params.Get("wait_seconds"); value != "" {
+		seconds, err := strconv.Atoi(value)
+		if err == nil && seconds < 0 {
+			err = fmt.Errorf("must not be negative")
+		}
+		if err != nil {
+			l.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("invalid-wait-seconds: %s", err.Error()))
+			return
+		}
+		wait = time.Duration(seconds) * time.Second
+	}
+	if wait > l.MaxWait {
+		wait = l.MaxWait
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), wait)
+	defer cancel()
+
+	update, err := l.LeaseWatcher.Watch(ctx, params.Get("epoch"), revision)
+	if err != nil {
+		l.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("watch-leases: %s", err.Error()))
+		return
+	}
+
+	bytes, err := l.Marshaler.Marshal(update)
+	if err != nil {
+		l.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("marshal-response: %s", err.Error()))
+		return
+	}
+
+	// #nosec G104 - ignore errors when writing HTTP responses so we don't spam our logs during a DoS
+	w.Write(bytes)
+}
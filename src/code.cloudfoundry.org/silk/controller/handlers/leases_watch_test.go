@@ -0,0 +1,158 @@
+package handlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	hfakes "code.cloudfoundry.org/cf-networking-helpers/fakes"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/handlers"
+	"code.cloudfoundry.org/silk/controller/handlers/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LeasesWatch", func() {
+	var (
+		logger            *lagertest.TestLogger
+		expectedLogger    lager.Logger
+		handler           *handlers.LeasesWatch
+		leaseWatcher      *fakes.LeaseWatcher
+		resp              *httptest.ResponseRecorder
+		marshaler         *hfakes.Marshaler
+		fakeErrorResponse *fakes.ErrorResponse
+		waited            time.Duration
+	)
+
+	request := func(query string) *http.Request {
+		req, err := http.NewRequest("GET", "/leases/watch"+query, nil)
+		Expect(err).NotTo(HaveOccurred())
+		return req
+	}
+
+	BeforeEach(func() {
+		expectedLogger = lager.NewLogger("test").Session("leases-watch")
+
+		testSink := lagertest.NewTestSink()
+		expectedLogger.RegisterSink(testSink)
+		expectedLogger.RegisterSink(lager.NewWriterSink(GinkgoWriter, lager.DEBUG))
+
+		logger = lagertest.NewTestLogger("test")
+		marshaler = &hfakes.Marshaler{}
+		marshaler.MarshalStub = json.Marshal
+		leaseWatcher = &fakes.LeaseWatcher{}
+		fakeErrorResponse = &fakes.ErrorResponse{}
+		handler = &handlers.LeasesWatch{
+			Marshaler:     marshaler,
+			LeaseWatcher:  leaseWatcher,
+			ErrorResponse: fakeErrorResponse,
+			MaxWait:       time.Minute,
+		}
+		resp = httptest.NewRecorder()
+
+		waited = 0
+		leaseWatcher.WatchStub = func(ctx context.Context, epoch string, revision uint64) (controller.LeaseUpdate, error) {
+			deadline, ok := ctx.Deadline()
+			Expect(ok).To(BeTrue())
+			waited = time.Until(deadline)
+			return controller.LeaseUpdate{
+				Epoch:            "some-epoch",
+				Revision:         9,
+				PreviousRevision: 7,
+				Added:            []controller.Lease{{UnderlayIP: "10.0.0.1", OverlaySubnet: "10.255.1.0/24"}},
+			}, nil
+		}
+	})
+
+	It("returns the changes since the given revision", func() {
+		handler.ServeHTTP(logger, resp, request("?epoch=some-epoch&revision=7&wait_seconds=30"))
+
+		Expect(leaseWatcher.WatchCallCount()).To(Equal(1))
+		_, epoch, revision := leaseWatcher.WatchArgsForCall(0)
+		Expect(epoch).To(Equal("some-epoch"))
+		Expect(revision).To(BeEquivalentTo(7))
+		Expect(waited).To(BeNumerically("~", 30*time.Second, time.Second))
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body).To(MatchJSON(`{
+			"epoch": "some-epoch",
+			"revision": 9,
+			"previous_revision": 7,
+			"resync": false,
+			"added": [ { "underlay_ip": "10.0.0.1", "overlay_subnet": "10.255.1.0/24", "overlay_hardware_addr": "" } ]
+		}`))
+	})
+
+	It("does not wait by default", func() {
+		handler.ServeHTTP(logger, resp, request(""))
+
+		_, epoch, revision := leaseWatcher.WatchArgsForCall(0)
+		Expect(epoch).To(BeEmpty())
+		Expect(revision).To(BeZero())
+		Expect(waited).To(BeNumerically("<=", 0))
+	})
+
+	It("waits at most MaxWait", func() {
+		handler.ServeHTTP(logger, resp, request("?wait_seconds=3600"))
+		Expect(waited).To(BeNumerically("~", time.Minute, time.Second))
+	})
+
+	DescribeTable("rejects invalid parameters",
+		func(query, expectedDescription string) {
+			handler.ServeHTTP(logger, resp, request(query))
+
+			Expect(leaseWatcher.WatchCallCount()).To(Equal(0))
+			Expect(fakeErrorResponse.BadRequestCallCount()).To(Equal(1))
+			l, w, _, description := fakeErrorResponse.BadRequestArgsForCall(0)
+			Expect(l).To(Equal(expectedLogger))
+			Expect(w).To(Equal(resp))
+			Expect(description).To(Equal(expectedDescription))
+		},
+		Entry("revision is not a number", "?epoch=e&revision=banana", `invalid-revision: strconv.ParseUint: parsing "banana": invalid syntax`),
+		Entry("revision is negative", "?epoch=e&revision=-1", `invalid-revision: strconv.ParseUint: parsing "-1": invalid syntax`),
+		Entry("wait_seconds is not a number", "?wait_seconds=banana", `invalid-wait-seconds: strconv.Atoi: parsing "banana": invalid syntax`),
+		Entry("wait_seconds is negative", "?wait_seconds=-1", "invalid-wait-seconds: must not be negative"),
+	)
+
+	Context("when watching fails", func() {
+		BeforeEach(func() {
+			leaseWatcher.WatchStub = nil
+			leaseWatcher.WatchReturns(controller.LeaseUpdate{}, errors.New("butter"))
+		})
+
+		It("calls the internal server error handler", func() {
+			handler.ServeHTTP(logger, resp, request(""))
+
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+			l, w, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(l).To(Equal(expectedLogger))
+			Expect(w).To(Equal(resp))
+			Expect(err).To(MatchError("butter"))
+			Expect(description).To(Equal("watch-leases: butter"))
+		})
+	})
+
+	Context("when the response cannot be marshaled", func() {
+		BeforeEach(func() {
+			marshaler.MarshalStub = func(interface{}) ([]byte, error) {
+				return nil, errors.New("grapes")
+			}
+		})
+
+		It("calls the internal server error handler", func() {
+			handler.ServeHTTP(logger, resp, request(""))
+
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+			_, _, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(err).To(MatchError("grapes"))
+			Expect(description).To(Equal("marshal-response: grapes"))
+		})
+	})
+})
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.cloudfoundry.org/cf-networking-helpers/marshal"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+//go:generate counterfeiter -o fakes/overlay_network_repository.go --fake-name OverlayNetworkRepository . overlayNetworkRepository
+type overlayNetworkRepository interface {
+	OverlayNetworks() ([]controller.OverlayNetwork, error)
+}
+
+type OverlayNetworksIndex struct {
+	Marshaler                marshal.Marshaler
+	OverlayNetworkRepository overlayNetworkRepository
+	ErrorResponse            errorResponse
+}
+
+func (l *OverlayNetworksIndex) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *http.Request) {
+	logger = logger.Session("overlay-networks-index")
+
+	networks, err := l.OverlayNetworkRepository.OverlayNetworks()
+	if err != nil {
+		l.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("all-overlay-networks: %s", err.Error()))
+		return
+	}
+
+	response := struct {
+		OverlayNetworks []controller.OverlayNetwork `json:"overlay_networks"`
+	}{networks}
+	bytes, err := l.Marshaler.Marshal(response)
+	if err != nil {
+		l.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("marshal-response: %s", err.Error()))
+		return
+	}
+
+	// #nosec G104 - ignore errors when writing HTTP responses so we don't spam our logs during a DoS
+	w.Write(bytes)
+}
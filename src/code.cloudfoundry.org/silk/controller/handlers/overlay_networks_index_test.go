@@ -0,0 +1,103 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	hfakes "code.cloudfoundry.org/cf-networking-helpers/fakes"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/handlers"
+	"code.cloudfoundry.org/silk/controller/handlers/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OverlayNetworksIndex", func() {
+	var (
+		logger                   *lagertest.TestLogger
+		expectedLogger           lager.Logger
+		handler                  *handlers.OverlayNetworksIndex
+		overlayNetworkRepository *fakes.OverlayNetworkRepository
+		resp                     *httptest.ResponseRecorder
+		marshaler                *hfakes.Marshaler
+		fakeErrorResponse        *fakes.ErrorResponse
+		request                  *http.Request
+	)
+
+	BeforeEach(func() {
+		expectedLogger = lager.NewLogger("test").Session("overlay-networks-index")
+
+		testSink := lagertest.NewTestSink()
+		expectedLogger.RegisterSink(testSink)
+		expectedLogger.RegisterSink(lager.NewWriterSink(GinkgoWriter, lager.DEBUG))
+
+		logger = lagertest.NewTestLogger("test")
+		marshaler = &hfakes.Marshaler{}
+		marshaler.MarshalStub = json.Marshal
+		overlayNetworkRepository = &fakes.OverlayNetworkRepository{}
+		fakeErrorResponse = &fakes.ErrorResponse{}
+		handler = &handlers.OverlayNetworksIndex{
+			Marshaler:                marshaler,
+			OverlayNetworkRepository: overlayNetworkRepository,
+			ErrorResponse:            fakeErrorResponse,
+		}
+		resp = httptest.NewRecorder()
+		overlayNetworkRepository.OverlayNetworksReturns([]controller.OverlayNetwork{
+			{CIDR: "10.255.0.0/16", State: controller.OverlayNetworkDraining},
+			{CIDR: "10.254.0.0/16", State: controller.OverlayNetworkActive},
+		}, nil)
+
+		var err error
+		request, err = http.NewRequest("GET", "/overlay-networks", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("returns the overlay networks", func() {
+		handler.ServeHTTP(logger, resp, request)
+		Expect(overlayNetworkRepository.OverlayNetworksCallCount()).To(Equal(1))
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body).To(MatchJSON(`{ "overlay_networks": [
+			{ "cidr": "10.255.0.0/16", "state": "draining" },
+			{ "cidr": "10.254.0.0/16", "state": "active" }
+		] }`))
+	})
+
+	Context("when getting the overlay networks fails", func() {
+		BeforeEach(func() {
+			overlayNetworkRepository.OverlayNetworksReturns(nil, errors.New("butter"))
+		})
+
+		It("calls the internal server error handler", func() {
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+			l, w, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(l).To(Equal(expectedLogger))
+			Expect(w).To(Equal(resp))
+			Expect(err).To(MatchError("butter"))
+			Expect(description).To(Equal("all-overlay-networks: butter"))
+		})
+	})
+
+	Context("when the response cannot be marshaled", func() {
+		BeforeEach(func() {
+			marshaler.MarshalStub = func(interface{}) ([]byte, error) {
+				return nil, errors.New("grapes")
+			}
+		})
+
+		It("calls the internal server error handler", func() {
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+			_, _, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(err).To(MatchError("grapes"))
+			Expect(description).To(Equal("marshal-response: grapes"))
+		})
+	})
+})
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"code.cloudfoundry.org/cf-networking-helpers/marshal"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+//go:generate counterfeiter -o fakes/reservation_repository.go --fake-name ReservationRepository . reservationRepository
+type reservationRepository interface {
+	Reservations() ([]controller.Reservation, error)
+}
+
+type ReservationsIndex struct {
+	Marshaler             marshal.Marshaler
+	ReservationRepository reservationRepository
+	ErrorResponse         errorResponse
+}
+
+func (l *ReservationsIndex) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *http.Request) {
+	logger = logger.Session("reservations-index")
+
+	reservations, err := l.ReservationRepository.Reservations()
+	if err != nil {
+		l.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("all-reservations: %s", err.Error()))
+		return
+	}
+
+	response := struct {
+		Reservations []controller.Reservation `json:"reservations"`
+	}{reservations}
+	bytes, err := l.Marshaler.Marshal(response)
+	if err != nil {
+		l.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("marshal-response: %s", err.Error()))
+		return
+	}
+
+	// #nosec G104 - ignore errors when writing HTTP responses so we don't spam our logs during a DoS
+	w.Write(bytes)
+}
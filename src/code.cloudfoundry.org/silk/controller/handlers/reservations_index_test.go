@@ -0,0 +1,103 @@
+package handlers_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+
+	hfakes "code.cloudfoundry.org/cf-networking-helpers/fakes"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/handlers"
+	"code.cloudfoundry.org/silk/controller/handlers/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReservationsIndex", func() {
+	var (
+		logger                *lagertest.TestLogger
+		expectedLogger        lager.Logger
+		handler               *handlers.ReservationsIndex
+		reservationRepository *fakes.ReservationRepository
+		resp                  *httptest.ResponseRecorder
+		marshaler             *hfakes.Marshaler
+		fakeErrorResponse     *fakes.ErrorResponse
+		request               *http.Request
+	)
+
+	BeforeEach(func() {
+		expectedLogger = lager.NewLogger("test").Session("reservations-index")
+
+		testSink := lagertest.NewTestSink()
+		expectedLogger.RegisterSink(testSink)
+		expectedLogger.RegisterSink(lager.NewWriterSink(GinkgoWriter, lager.DEBUG))
+
+		logger = lagertest.NewTestLogger("test")
+		marshaler = &hfakes.Marshaler{}
+		marshaler.MarshalStub = json.Marshal
+		reservationRepository = &fakes.ReservationRepository{}
+		fakeErrorResponse = &fakes.ErrorResponse{}
+		handler = &handlers.ReservationsIndex{
+			Marshaler:             marshaler,
+			ReservationRepository: reservationRepository,
+			ErrorResponse:         fakeErrorResponse,
+		}
+		resp = httptest.NewRecorder()
+		reservationRepository.ReservationsReturns([]controller.Reservation{
+			{UnderlayIP: "10.244.5.9", OverlaySubnet: "10.255.16.0/24"},
+			{UnderlayIP: "10.244.22.33", OverlaySubnet: "10.255.0.75/32"},
+		}, nil)
+
+		var err error
+		request, err = http.NewRequest("GET", "/reservations", nil)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("returns the reservations", func() {
+		handler.ServeHTTP(logger, resp, request)
+		Expect(reservationRepository.ReservationsCallCount()).To(Equal(1))
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body).To(MatchJSON(`{ "reservations": [
+			{ "underlay_ip": "10.244.5.9", "overlay_subnet": "10.255.16.0/24" },
+			{ "underlay_ip": "10.244.22.33", "overlay_subnet": "10.255.0.75/32" }
+		] }`))
+	})
+
+	Context("when getting the reservations fails", func() {
+		BeforeEach(func() {
+			reservationRepository.ReservationsReturns(nil, errors.New("butter"))
+		})
+
+		It("calls the internal server error handler", func() {
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+			l, w, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(l).To(Equal(expectedLogger))
+			Expect(w).To(Equal(resp))
+			Expect(err).To(MatchError("butter"))
+			Expect(description).To(Equal("all-reservations: butter"))
+		})
+	})
+
+	Context("when the response cannot be marshaled", func() {
+		BeforeEach(func() {
+			marshaler.MarshalStub = func(interface{}) ([]byte, error) {
+				return nil, errors.New("grapes")
+			}
+		})
+
+		It("calls the internal server error handler", func() {
+			handler.ServeHTTP(logger, resp, request)
+
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+			_, _, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(err).To(MatchError("grapes"))
+			Expect(description).To(Equal("marshal-response: grapes"))
+		})
+	})
+})
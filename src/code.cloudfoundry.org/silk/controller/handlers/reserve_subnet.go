@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+
+	"code.cloudfoundry.org/cf-networking-helpers/marshal"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+//go:generate counterfeiter -o fakes/subnet_reserver.go --fake-name SubnetReserver . subnetReserver
+type subnetReserver interface {
+	ReserveSubnet(reservation controller.Reservation) error
+}
+
+type ReserveSubnet struct {
+	Marshaler      marshal.Marshaler
+	Unmarshaler    marshal.Unmarshaler
+	SubnetReserver subnetReserver
+	ErrorResponse  errorResponse
+}
+
+func (l *ReserveSubnet) ServeHTTP(logger lager.Logger, w http.ResponseWriter, req *http.Request) {
+	logger = logger.Session("reservations-reserve")
+
+	bodyBytes, err := io.ReadAll(req.Body)
+	if err != nil {
+		l.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("read-body: %s", err.Error()))
+		return
+	}
+
+	var reservation controller.Reservation
+	err = l.Unmarshaler.Unmarshal(bodyBytes, &reservation)
+	if err != nil {
+		l.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("unmarshal-request: %s", err.Error()))
+		return
+	}
+
+	err = l.SubnetReserver.ReserveSubnet(reservation)
+	if err != nil {
+		switch err.(type) {
+		case controller.InvalidRequestError:
+			l.ErrorResponse.BadRequest(logger, w, err, fmt.Sprintf("reserve-subnet: %s", err.Error()))
+		case controller.NonRetriableError:
+			l.ErrorResponse.Conflict(logger, w, err, fmt.Sprintf("reserve-subnet: %s", err.Error()))
+		default:
+			l.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("reserve-subnet: %s", err.Error()))
+		}
+		return
+	}
+
+	bytes, err := l.Marshaler.Marshal(reservation)
+	if err != nil {
+		l.ErrorResponse.InternalServerError(logger, w, err, fmt.Sprintf("marshal-response: %s", err.Error()))
+		return
+	}
+
+	// #nosec G104 - ignore errors when writing HTTP responses so we don't spam our logs during a DoS
+	w.Write(bytes)
+}
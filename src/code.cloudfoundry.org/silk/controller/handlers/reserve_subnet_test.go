@@ -0,0 +1,154 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	hfakes "code.cloudfoundry.org/cf-networking-helpers/fakes"
+	"code.cloudfoundry.org/cf-networking-helpers/testsupport"
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/handlers"
+	"code.cloudfoundry.org/silk/controller/handlers/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ReserveSubnet", func() {
+	var (
+		logger            *lagertest.TestLogger
+		expectedLogger    lager.Logger
+		handler           *handlers.ReserveSubnet
+		resp              *httptest.ResponseRecorder
+		marshaler         *hfakes.Marshaler
+		unmarshaler       *hfakes.Unmarshaler
+		subnetReserver    *fakes.SubnetReserver
+		fakeErrorResponse *fakes.ErrorResponse
+
+		request *http.Request
+	)
+
+	BeforeEach(func() {
+		expectedLogger = lager.NewLogger("test").Session("reservations-reserve")
+		testSink := lagertest.NewTestSink()
+		expectedLogger.RegisterSink(testSink)
+		expectedLogger.RegisterSink(lager.NewWriterSink(GinkgoWriter, lager.DEBUG))
+
+		logger = lagertest.NewTestLogger("test")
+		marshaler = &hfakes.Marshaler{}
+		marshaler.MarshalStub = json.Marshal
+		unmarshaler = &hfakes.Unmarshaler{}
+		unmarshaler.UnmarshalStub = json.Unmarshal
+		subnetReserver = &fakes.SubnetReserver{}
+		fakeErrorResponse = &fakes.ErrorResponse{}
+
+		handler = &handlers.ReserveSubnet{
+			Marshaler:      marshaler,
+			Unmarshaler:    unmarshaler,
+			SubnetReserver: subnetReserver,
+			ErrorResponse:  fakeErrorResponse,
+		}
+		resp = httptest.NewRecorder()
+
+		requestBody := bytes.NewBuffer([]byte(`{ "underlay_ip": "10.244.16.11", "overlay_subnet": "10.255.99.0/24" }`))
+		var err error
+		request, err = http.NewRequest("PUT", "/reservations/reserve", requestBody)
+		Expect(err).NotTo(HaveOccurred())
+		request.RemoteAddr = "some-host:some-port"
+	})
+
+	It("reserves the subnet", func() {
+		handler.ServeHTTP(logger, resp, request)
+		Expect(subnetReserver.ReserveSubnetCallCount()).To(Equal(1))
+		Expect(subnetReserver.ReserveSubnetArgsForCall(0)).To(Equal(controller.Reservation{
+			UnderlayIP:    "10.244.16.11",
+			OverlaySubnet: "10.255.99.0/24",
+		}))
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Body.String()).To(MatchJSON(`{ "underlay_ip": "10.244.16.11", "overlay_subnet": "10.255.99.0/24" }`))
+	})
+
+	Context("when there are errors reading the body bytes", func() {
+		BeforeEach(func() {
+			request.Body = io.NopCloser(&testsupport.BadReader{})
+		})
+
+		It("logs the error and returns a 400", func() {
+			handler.ServeHTTP(logger, resp, request)
+			Expect(fakeErrorResponse.BadRequestCallCount()).To(Equal(1))
+
+			l, w, err, description := fakeErrorResponse.BadRequestArgsForCall(0)
+			Expect(l).To(Equal(expectedLogger))
+			Expect(w).To(Equal(resp))
+			Expect(err).To(MatchError("banana"))
+			Expect(description).To(Equal("read-body: banana"))
+		})
+	})
+
+	Context("when the request cannot be unmarshaled", func() {
+		BeforeEach(func() {
+			unmarshaler.UnmarshalReturns(errors.New("kiwi"))
+		})
+
+		It("returns a 400", func() {
+			handler.ServeHTTP(logger, resp, request)
+			Expect(fakeErrorResponse.BadRequestCallCount()).To(Equal(1))
+
+			_, _, err, description := fakeErrorResponse.BadRequestArgsForCall(0)
+			Expect(err).To(MatchError("kiwi"))
+			Expect(description).To(Equal("unmarshal-request: kiwi"))
+		})
+	})
+
+	Context("when the reservation is invalid", func() {
+		BeforeEach(func() {
+			subnetReserver.ReserveSubnetReturns(controller.InvalidRequestError("bad subnet"))
+		})
+
+		It("returns a 400", func() {
+			handler.ServeHTTP(logger, resp, request)
+			Expect(fakeErrorResponse.BadRequestCallCount()).To(Equal(1))
+
+			_, _, err, description := fakeErrorResponse.BadRequestArgsForCall(0)
+			Expect(err).To(MatchError("bad subnet"))
+			Expect(description).To(Equal("reserve-subnet: bad subnet"))
+		})
+	})
+
+	Context("when the reservation conflicts", func() {
+		BeforeEach(func() {
+			subnetReserver.ReserveSubnetReturns(controller.NonRetriableError("already reserved"))
+		})
+
+		It("returns a 409", func() {
+			handler.ServeHTTP(logger, resp, request)
+			Expect(fakeErrorResponse.ConflictCallCount()).To(Equal(1))
+
+			_, _, err, description := fakeErrorResponse.ConflictArgsForCall(0)
+			Expect(err).To(MatchError("already reserved"))
+			Expect(description).To(Equal("reserve-subnet: already reserved"))
+		})
+	})
+
+	Context("when reserving fails", func() {
+		BeforeEach(func() {
+			subnetReserver.ReserveSubnetReturns(errors.New("database down"))
+		})
+
+		It("returns a 500", func() {
+			handler.ServeHTTP(logger, resp, request)
+			Expect(fakeErrorResponse.InternalServerErrorCallCount()).To(Equal(1))
+
+			_, _, err, description := fakeErrorResponse.InternalServerErrorArgsForCall(0)
+			Expect(err).To(MatchError("database down"))
+			Expect(description).To(Equal("reserve-subnet: database down"))
+		})
+	})
+})
@@ -14,6 +14,10 @@ certstrap sign client --CA "ca"
 certstrap request-cert --common-name "server" --passphrase "" --ip "127.0.0.1"
 certstrap sign server --CA "ca"
 
+certstrap init --common-name "admin-ca" --passphrase ""
+certstrap request-cert --common-name "admin-client" --passphrase "" --ip "127.0.0.1"
+certstrap sign admin-client --CA "admin-ca"
+
 mv -f out/* ./
 rm -rf out
 
@@ -31,6 +31,8 @@ func DefaultTestConfig(dbConf db.Config, fixturesPath string) config.Config {
 		CACertFile:                filepath.Join(fixturesPath, "ca.crt"),
 		ServerCertFile:            filepath.Join(fixturesPath, "server.crt"),
 		ServerKeyFile:             filepath.Join(fixturesPath, "server.key"),
+		AdminListenPort:           ports.PickAPort(),
+		AdminCACertFile:           filepath.Join(fixturesPath, "admin-ca.crt"),
 		Network:                   []string{"10.255.0.0/16", "10.250.0.0/16"},
 		SubnetPrefixLength:        24,
 		Database:                  dbConf,
@@ -78,18 +80,28 @@ func StopServer(session *gexec.Session) {
 }
 
 func TestClient(conf config.Config, fixturesPath string) *controller.Client {
-	baseURL := fmt.Sprintf("https://%s:%d", conf.ListenHost, conf.ListenPort)
+	return newTestClient(conf.ListenHost, conf.ListenPort, fixturesPath, "client")
+}
+
+// AdminTestClient connects to the admin api with a client cert signed by
+// the admin ca.
+func AdminTestClient(conf config.Config, fixturesPath string) *controller.Client {
+	return newTestClient(conf.ListenHost, conf.AdminListenPort, fixturesPath, "admin-client")
+}
+
+func newTestClient(host string, port int, fixturesPath, clientName string) *controller.Client {
+	baseURL := fmt.Sprintf("https://%s:%d", host, port)
 	httpClient := &http.Client{
 		Transport: &http.Transport{
-			TLSClientConfig: makeClientTLSConfig(fixturesPath),
+			TLSClientConfig: makeClientTLSConfig(fixturesPath, clientName),
 		},
 	}
 	return controller.NewClient(lagertest.NewTestLogger("test"), httpClient, baseURL)
 }
 
-func makeClientTLSConfig(fixturesPath string) *tls.Config {
-	clientCertPath := filepath.Join(fixturesPath, "client.crt")
-	clientKeyPath := filepath.Join(fixturesPath, "client.key")
+func makeClientTLSConfig(fixturesPath, clientName string) *tls.Config {
+	clientCertPath := filepath.Join(fixturesPath, clientName+".crt")
+	clientKeyPath := filepath.Join(fixturesPath, clientName+".key")
 	cert, err := tls.LoadX509KeyPair(clientCertPath, clientKeyPath)
 	Expect(err).NotTo(HaveOccurred())
 
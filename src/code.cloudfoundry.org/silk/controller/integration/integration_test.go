@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"net/url"
 	"time"
 
 	"code.cloudfoundry.org/cf-networking-helpers/db"
@@ -153,6 +154,54 @@ var _ = Describe("Silk Controller", func() {
 		})
 	})
 
+	Describe("the admin api", func() {
+		var adminClient *controller.Client
+
+		BeforeEach(func() {
+			adminClient = helpers.AdminTestClient(conf, "fixtures")
+		})
+
+		AfterEach(func() {
+			adminClient.JsonClient.CloseIdleConnections()
+		})
+
+		It("serves reservations to clients of the admin ca", func() {
+			reservation := controller.Reservation{UnderlayIP: "10.244.4.5", OverlaySubnet: "10.255.42.0/24"}
+			err := adminClient.JsonClient.Do("PUT", "/reservations/reserve", reservation, nil, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			var reservations struct {
+				Reservations []controller.Reservation `json:"reservations"`
+			}
+			err = adminClient.JsonClient.Do("GET", "/reservations", nil, &reservations, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reservations.Reservations).To(ConsistOf(reservation))
+
+			lease, err := testClient.AcquireSubnetLease("10.244.4.5")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lease.OverlaySubnet).To(Equal("10.255.42.0/24"))
+		})
+
+		It("does not serve reservations on the daemon api", func() {
+			reservation := controller.Reservation{UnderlayIP: "10.244.4.5", OverlaySubnet: "10.255.42.0/24"}
+			err := testClient.JsonClient.Do("PUT", "/reservations/reserve", reservation, nil, "")
+			Expect(err).To(MatchError(ContainSubstring("http status 404")))
+		})
+
+		It("does not serve the lease history on the daemon api", func() {
+			err := testClient.JsonClient.Do("GET", "/leases/history", nil, nil, "")
+			Expect(err).To(MatchError(ContainSubstring("http status 404")))
+		})
+
+		It("rejects silk-daemon client certs", func() {
+			adminConf := conf
+			adminConf.ListenPort = conf.AdminListenPort
+			daemonCertClient := helpers.TestClient(adminConf, "fixtures")
+			err := daemonCertClient.JsonClient.Do("GET", "/reservations", nil, nil, "")
+			Expect(err).To(MatchError(ContainSubstring("tls")))
+		})
+	})
+
 	Describe("releasing", func() {
 		It("releases a subnet lease", func() {
 			By("getting a valid lease")
@@ -236,6 +285,21 @@ var _ = Describe("Silk Controller", func() {
 			newLease, err := testClient.AcquireSubnetLease("10.244.4.15")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(newLease.OverlaySubnet).To(Equal(oldLease.OverlaySubnet))
+
+			By("recording who held the subnet")
+			adminClient := helpers.AdminTestClient(conf, "fixtures")
+			defer adminClient.JsonClient.CloseIdleConnections()
+			var history controller.LeaseHistory
+			err = adminClient.JsonClient.Do("GET", "/leases/history?overlay_subnet="+url.QueryEscape(oldLease.OverlaySubnet), nil, &history, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(history.Events).To(HaveLen(3))
+			Expect(history.Events[0].Type).To(Equal(controller.LeaseEventAcquire))
+			Expect(history.Events[0].UnderlayIP).To(Equal("10.244.4.15"))
+			Expect(history.Events[0].PreviousUnderlayIP).To(Equal("10.244.4.5"))
+			Expect(history.Events[1].Type).To(Equal(controller.LeaseEventReclaim))
+			Expect(history.Events[1].PreviousUnderlayIP).To(Equal("10.244.4.5"))
+			Expect(history.Events[2].Type).To(Equal(controller.LeaseEventAcquire))
+			Expect(history.Events[2].UnderlayIP).To(Equal("10.244.4.5"))
 		})
 	})
 
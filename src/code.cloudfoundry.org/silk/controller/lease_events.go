@@ -0,0 +1,38 @@
+package controller
+
+const (
+	LeaseEventAcquire             = "acquire"
+	LeaseEventRenewMismatch       = "renew-mismatch"
+	LeaseEventRelease             = "release"
+	LeaseEventReclaim             = "reclaim"
+	LeaseEventPoolEviction        = "pool-eviction"
+	LeaseEventReservationEviction = "reservation-eviction"
+)
+
+// LeaseEvent records a change to the holder of an overlay subnet. For
+// acquire and reclaim events PreviousUnderlayIP is the cell whose expired
+// lease was taken over. For renew-mismatch events it is the cell that holds
+// the subnet the renewing cell asked for.
+type LeaseEvent struct {
+	ID                 int64  `json:"id"`
+	Type               string `json:"type"`
+	UnderlayIP         string `json:"underlay_ip"`
+	OverlaySubnet      string `json:"overlay_subnet"`
+	OverlaySubnetV6    string `json:"overlay_subnet_v6,omitempty"`
+	PreviousUnderlayIP string `json:"previous_underlay_ip,omitempty"`
+	Timestamp          int64  `json:"timestamp"`
+}
+
+// LeaseHistoryQuery selects lease events, newest first. Empty filters match
+// every event and Before, when set, only returns events older than that ID.
+type LeaseHistoryQuery struct {
+	UnderlayIP    string
+	OverlaySubnet string
+	Before        int64
+	Limit         int
+}
+
+type LeaseHistory struct {
+	Events     []LeaseEvent `json:"events"`
+	NextBefore int64        `json:"next_before,omitempty"`
+}
@@ -0,0 +1,16 @@
+package controller
+
+// LeaseUpdate is the response of the lease watch API. The epoch identifies
+// the controller process that numbered the revisions. When Resync is set,
+// Leases holds every routable lease at Revision. Otherwise Added and Removed
+// hold the changes from PreviousRevision to Revision. A lease whose subnet
+// changed is in both lists.
+type LeaseUpdate struct {
+	Epoch            string  `json:"epoch"`
+	Revision         uint64  `json:"revision"`
+	PreviousRevision uint64  `json:"previous_revision"`
+	Resync           bool    `json:"resync"`
+	Leases           []Lease `json:"leases,omitempty"`
+	Added            []Lease `json:"added,omitempty"`
+	Removed          []Lease `json:"removed,omitempty"`
+}
@@ -0,0 +1,183 @@
+package leaser
+
+import (
+	"fmt"
+	"hash/fnv"
+	mathRand "math/rand"
+	"sync"
+)
+
+const (
+	RandomAllocation                = "random"
+	LowestFreeAllocation            = "lowest-free"
+	LeastRecentlyReleasedAllocation = "least-recently-released"
+	ConsistentHashAllocation        = "consistent-hash"
+)
+
+// randomProbeAttempts bounds how many random guesses are made before falling
+// back to scanning the pool, which only happens when it is nearly exhausted.
+const randomProbeAttempts = 64
+
+// AllocationStrategy picks a free subnet out of a pool. The pool is ordered
+// by address and must not be modified. Implementations should only walk as
+// much of the pool as they need to and must never copy it.
+type AllocationStrategy interface {
+	Allocate(pool []string, taken map[string]struct{}, underlayIP string) string
+}
+
+// AllocationStrategyFactory creates a new strategy. Strategies may keep
+// state about the pool they allocate from, so each pool gets its own.
+type AllocationStrategyFactory func() AllocationStrategy
+
+func AllocationStrategyByName(name string) (AllocationStrategyFactory, error) {
+	switch name {
+	case "", RandomAllocation:
+		return func() AllocationStrategy { return &RandomStrategy{} }, nil
+	case LowestFreeAllocation:
+		return func() AllocationStrategy { return &LowestFreeStrategy{} }, nil
+	case LeastRecentlyReleasedAllocation:
+		return func() AllocationStrategy { return NewLeastRecentlyReleasedStrategy() }, nil
+	case ConsistentHashAllocation:
+		return func() AllocationStrategy { return &ConsistentHashStrategy{} }, nil
+	default:
+		return nil, fmt.Errorf("unknown allocation strategy %q", name)
+	}
+}
+
+// RandomStrategy picks any free subnet with equal probability.
+type RandomStrategy struct{}
+
+func (s *RandomStrategy) Allocate(pool []string, taken map[string]struct{}, _ string) string {
+	if len(pool) == 0 {
+		return ""
+	}
+
+	for i := 0; i < randomProbeAttempts; i++ {
+		// #nosec - G404 - subnets do not need a cryptographically secure choice
+		subnet := pool[mathRand.Intn(len(pool))]
+		if _, ok := taken[subnet]; !ok {
+			return subnet
+		}
+	}
+
+	var free []int
+	for i, subnet := range pool {
+		if _, ok := taken[subnet]; !ok {
+			free = append(free, i)
+		}
+	}
+	if len(free) == 0 {
+		return ""
+	}
+	// #nosec - G404 - subnets do not need a cryptographically secure choice
+	return pool[free[mathRand.Intn(len(free))]]
+}
+
+// LowestFreeStrategy picks the first free subnet in address order.
+type LowestFreeStrategy struct{}
+
+func (s *LowestFreeStrategy) Allocate(pool []string, taken map[string]struct{}, _ string) string {
+	for _, subnet := range pool {
+		if _, ok := taken[subnet]; !ok {
+			return subnet
+		}
+	}
+	return ""
+}
+
+// LeastRecentlyReleasedStrategy prefers subnets that have never been handed
+// out, and otherwise the subnet that was released the longest time ago, so
+// that stale routes to a subnet have the most time to age out before it is
+// reused. Releases are observed by comparing the taken subnets between calls,
+// which means the ordering only covers releases seen by this process.
+type LeastRecentlyReleasedStrategy struct {
+	mutex      sync.Mutex
+	sequence   uint64
+	lastTaken  map[string]struct{}
+	releasedAt map[string]uint64
+}
+
+func NewLeastRecentlyReleasedStrategy() *LeastRecentlyReleasedStrategy {
+	return &LeastRecentlyReleasedStrategy{
+		lastTaken:  map[string]struct{}{},
+		releasedAt: map[string]uint64{},
+	}
+}
+
+func (s *LeastRecentlyReleasedStrategy) Allocate(pool []string, taken map[string]struct{}, _ string) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for subnet := range s.lastTaken {
+		if _, ok := taken[subnet]; !ok {
+			s.sequence++
+			s.releasedAt[subnet] = s.sequence
+		}
+	}
+	s.lastTaken = make(map[string]struct{}, len(taken))
+	for subnet := range taken {
+		s.lastTaken[subnet] = struct{}{}
+		delete(s.releasedAt, subnet)
+	}
+
+	for _, subnet := range pool {
+		if _, ok := taken[subnet]; ok {
+			continue
+		}
+		if _, ok := s.releasedAt[subnet]; ok {
+			continue
+		}
+		return subnet
+	}
+
+	var oldest string
+	var oldestSequence uint64
+	for subnet, sequence := range s.releasedAt {
+		if oldest == "" || sequence < oldestSequence {
+			oldest = subnet
+			oldestSequence = sequence
+		}
+	}
+	return oldest
+}
+
+// ConsistentHashStrategy uses rendezvous (highest random weight) hashing:
+// every free subnet is scored with a hash of the underlay IP and the subnet,
+// and the highest score wins. A cell gets the same subnet back after its lease
+// is released or expires, and adding or removing subnets only moves the cells
+// whose best subnet was added or removed. Unlike the other strategies it has
+// to score every free subnet in the pool.
+type ConsistentHashStrategy struct{}
+
+func (s *ConsistentHashStrategy) Allocate(pool []string, taken map[string]struct{}, underlayIP string) string {
+	var best string
+	var bestScore uint64
+	for _, subnet := range pool {
+		if _, ok := taken[subnet]; ok {
+			continue
+		}
+		score := rendezvousScore(underlayIP, subnet)
+		if best == "" || score > bestScore || (score == bestScore && subnet < best) {
+			best = subnet
+			bestScore = score
+		}
+	}
+	return best
+}
+
+// rendezvousScore hashes the pair with FNV-1a and mixes the result with the
+// splitmix64 finalizer, because FNV alone scores similar addresses alike.
+func rendezvousScore(underlayIP, subnet string) uint64 {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(underlayIP))
+	_, _ = hash.Write([]byte{0})
+	_, _ = hash.Write([]byte(subnet))
+
+	score := hash.Sum64()
+	score ^= score >> 30
+	score *= 0xbf58476d1ce4e5b9
+	score ^= score >> 27
+	score *= 0x94d049bb133111eb
+	score ^= score >> 31
+	return score
+}
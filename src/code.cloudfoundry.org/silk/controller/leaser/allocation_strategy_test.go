@@ -0,0 +1,158 @@
+package leaser_test
+
+import (
+	"code.cloudfoundry.org/silk/controller/leaser"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("AllocationStrategy", func() {
+	var pool []string
+
+	BeforeEach(func() {
+		pool = []string{"10.255.1.0/24", "10.255.2.0/24", "10.255.3.0/24", "10.255.4.0/24"}
+	})
+
+	takenSet := func(subnets ...string) map[string]struct{} {
+		taken := map[string]struct{}{}
+		for _, subnet := range subnets {
+			taken[subnet] = struct{}{}
+		}
+		return taken
+	}
+
+	Describe("AllocationStrategyByName", func() {
+		DescribeTable("returns a factory for the strategy",
+			func(name string, expected leaser.AllocationStrategy) {
+				newStrategy, err := leaser.AllocationStrategyByName(name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(newStrategy()).To(BeAssignableToTypeOf(expected))
+			},
+			Entry("when no name is given", "", &leaser.RandomStrategy{}),
+			Entry("random", "random", &leaser.RandomStrategy{}),
+			Entry("lowest-free", "lowest-free", &leaser.LowestFreeStrategy{}),
+			Entry("least-recently-released", "least-recently-released", &leaser.LeastRecentlyReleasedStrategy{}),
+			Entry("consistent-hash", "consistent-hash", &leaser.ConsistentHashStrategy{}),
+		)
+
+		It("returns an error for unknown strategies", func() {
+			_, err := leaser.AllocationStrategyByName("banana")
+			Expect(err).To(MatchError(`unknown allocation strategy "banana"`))
+		})
+	})
+
+	Describe("RandomStrategy", func() {
+		var strategy *leaser.RandomStrategy
+
+		BeforeEach(func() {
+			strategy = &leaser.RandomStrategy{}
+		})
+
+		It("eventually hands out every free subnet", func() {
+			seen := map[string]struct{}{}
+			for i := 0; i < 1000; i++ {
+				seen[strategy.Allocate(pool, takenSet("10.255.2.0/24"), "10.0.0.1")] = struct{}{}
+			}
+			Expect(seen).To(HaveLen(3))
+			Expect(seen).NotTo(HaveKey("10.255.2.0/24"))
+		})
+
+		It("finds the last free subnet in a nearly full pool", func() {
+			for i := 0; i < 100; i++ {
+				Expect(strategy.Allocate(pool, takenSet("10.255.1.0/24", "10.255.2.0/24", "10.255.4.0/24"), "10.0.0.1")).To(Equal("10.255.3.0/24"))
+			}
+		})
+
+		It("returns an empty string when the pool is full", func() {
+			Expect(strategy.Allocate(pool, takenSet(pool...), "10.0.0.1")).To(BeEmpty())
+			Expect(strategy.Allocate(nil, takenSet(), "10.0.0.1")).To(BeEmpty())
+		})
+	})
+
+	Describe("LowestFreeStrategy", func() {
+		It("hands out the first free subnet", func() {
+			strategy := &leaser.LowestFreeStrategy{}
+			Expect(strategy.Allocate(pool, takenSet(), "10.0.0.1")).To(Equal("10.255.1.0/24"))
+			Expect(strategy.Allocate(pool, takenSet("10.255.1.0/24", "10.255.3.0/24"), "10.0.0.1")).To(Equal("10.255.2.0/24"))
+			Expect(strategy.Allocate(pool, takenSet(pool...), "10.0.0.1")).To(BeEmpty())
+		})
+	})
+
+	Describe("LeastRecentlyReleasedStrategy", func() {
+		var strategy *leaser.LeastRecentlyReleasedStrategy
+
+		BeforeEach(func() {
+			strategy = leaser.NewLeastRecentlyReleasedStrategy()
+		})
+
+		It("prefers subnets that have never been handed out", func() {
+			Expect(strategy.Allocate(pool, takenSet("10.255.1.0/24", "10.255.2.0/24"), "10.0.0.1")).To(Equal("10.255.3.0/24"))
+			Expect(strategy.Allocate(pool, takenSet("10.255.2.0/24"), "10.0.0.1")).To(Equal("10.255.3.0/24"))
+		})
+
+		It("hands out the subnet that was released the longest time ago", func() {
+			Expect(strategy.Allocate(pool, takenSet(pool...), "10.0.0.1")).To(BeEmpty())
+			Expect(strategy.Allocate(pool, takenSet("10.255.1.0/24", "10.255.2.0/24", "10.255.4.0/24"), "10.0.0.1")).To(Equal("10.255.3.0/24"))
+			Expect(strategy.Allocate(pool, takenSet("10.255.2.0/24", "10.255.4.0/24"), "10.0.0.1")).To(Equal("10.255.3.0/24"))
+			Expect(strategy.Allocate(pool, takenSet("10.255.2.0/24", "10.255.3.0/24", "10.255.4.0/24"), "10.0.0.1")).To(Equal("10.255.1.0/24"))
+		})
+
+		It("forgets the release once the subnet is taken again", func() {
+			Expect(strategy.Allocate(pool, takenSet(pool...), "10.0.0.1")).To(BeEmpty())
+			Expect(strategy.Allocate(pool, takenSet("10.255.2.0/24", "10.255.3.0/24", "10.255.4.0/24"), "10.0.0.1")).To(Equal("10.255.1.0/24"))
+			Expect(strategy.Allocate(pool, takenSet("10.255.1.0/24", "10.255.3.0/24", "10.255.4.0/24"), "10.0.0.1")).To(Equal("10.255.2.0/24"))
+			Expect(strategy.Allocate(pool, takenSet("10.255.3.0/24", "10.255.4.0/24"), "10.0.0.1")).To(Equal("10.255.2.0/24"))
+		})
+	})
+
+	Describe("ConsistentHashStrategy", func() {
+		var strategy *leaser.ConsistentHashStrategy
+
+		BeforeEach(func() {
+			strategy = &leaser.ConsistentHashStrategy{}
+		})
+
+		It("hands the same underlay ip the same subnet", func() {
+			first := strategy.Allocate(pool, takenSet(), "10.0.16.4")
+			Expect(first).NotTo(BeEmpty())
+			for i := 0; i < 10; i++ {
+				Expect(strategy.Allocate(pool, takenSet(), "10.0.16.4")).To(Equal(first))
+			}
+		})
+
+		It("spreads underlay ips across the pool", func() {
+			seen := map[string]struct{}{}
+			for _, underlayIP := range []string{"10.0.16.1", "10.0.16.2", "10.0.16.3", "10.0.16.4", "10.0.16.5", "10.0.16.6", "10.0.16.7", "10.0.16.8"} {
+				seen[strategy.Allocate(pool, takenSet(), underlayIP)] = struct{}{}
+			}
+			Expect(len(seen)).To(BeNumerically(">", 1))
+		})
+
+		It("only moves an underlay ip when its subnet is added to or removed from the pool", func() {
+			for _, underlayIP := range []string{"10.0.16.1", "10.0.16.2", "10.0.16.3", "10.0.16.4", "10.0.16.5", "10.0.16.6", "10.0.16.7", "10.0.16.8"} {
+				smaller := strategy.Allocate(pool[1:], takenSet(), underlayIP)
+				full := strategy.Allocate(pool, takenSet(), underlayIP)
+				if full != pool[0] {
+					Expect(full).To(Equal(smaller))
+				}
+			}
+		})
+
+		It("does not depend on the order of the pool", func() {
+			reversed := make([]string, len(pool))
+			for i, subnet := range pool {
+				reversed[len(pool)-1-i] = subnet
+			}
+			Expect(strategy.Allocate(reversed, takenSet(), "10.0.16.4")).To(Equal(strategy.Allocate(pool, takenSet(), "10.0.16.4")))
+		})
+
+		It("moves on to another free subnet when its subnet is taken", func() {
+			first := strategy.Allocate(pool, takenSet(), "10.0.16.4")
+			second := strategy.Allocate(pool, takenSet(first), "10.0.16.4")
+			Expect(second).NotTo(BeEmpty())
+			Expect(second).NotTo(Equal(first))
+			Expect(strategy.Allocate(pool, takenSet(pool...), "10.0.16.4")).To(BeEmpty())
+		})
+	})
+})
@@ -1,99 +1,248 @@
 package leaser
 
 import (
-	mcn "code.cloudfoundry.org/lib/multiple-cidr-network"
+	"bytes"
 	"fmt"
-	mathRand "math/rand"
+	"math/big"
+	"net"
+	"sort"
+
+	mcn "code.cloudfoundry.org/lib/multiple-cidr-network"
 
 	"github.com/ziutek/utils/netaddr"
 )
 
+// IPv6 overlay networks are far too large to enumerate, so each configured
+// IPv6 network may be carved into at most 2^maxIPv6BlockBits blocks.
+const maxIPv6BlockBits = 16
+
 type CIDRPool struct {
-	blockPool  map[string]struct{}
-	singlePool map[string]struct{}
+	networks     []string
+	networkSizes map[string]PoolUsage
+	blockPool    subnetPool
+	singlePool   subnetPool
+	blockPoolV6  subnetPool
+}
+
+// subnetPool keeps the subnets in address order for the allocation strategy
+// alongside a set for fast membership checks and the overlay network each
+// subnet belongs to. Subnets of draining networks are members but are never
+// allocated.
+type subnetPool struct {
+	subnets  []string
+	members  map[string]struct{}
+	networks map[string]string
+	draining []string
+	strategy AllocationStrategy
+}
+
+func newSubnetPool(subnets []string, networks []*net.IPNet, drainingNetworks map[string]struct{}, strategy AllocationStrategy) subnetPool {
+	members := make(map[string]struct{}, len(subnets))
+	subnetNetworks := make(map[string]string, len(subnets))
+	var draining []string
+	for _, subnet := range subnets {
+		members[subnet] = struct{}{}
+		ip, _, _ := net.ParseCIDR(subnet)
+		for _, network := range networks {
+			if network.Contains(ip) {
+				subnetNetworks[subnet] = network.String()
+				if _, ok := drainingNetworks[network.String()]; ok {
+					draining = append(draining, subnet)
+				}
+				break
+			}
+		}
+	}
+	return subnetPool{
+		subnets:  subnets,
+		members:  members,
+		networks: subnetNetworks,
+		draining: draining,
+		strategy: strategy,
+	}
+}
+
+func (p subnetPool) getAvailable(taken []string, underlayIP string) string {
+	takenSet := make(map[string]struct{}, len(taken)+len(p.draining))
+	for _, subnet := range taken {
+		if _, ok := p.members[subnet]; ok {
+			takenSet[subnet] = struct{}{}
+		}
+	}
+	for _, subnet := range p.draining {
+		takenSet[subnet] = struct{}{}
+	}
+	if len(takenSet) == len(p.subnets) {
+		return ""
+	}
+	return p.strategy.Allocate(p.subnets, takenSet, underlayIP)
 }
 
 func NewCIDRPool(subnetRanges []string, subnetMask int) *CIDRPool {
+	return NewDualStackCIDRPool(subnetRanges, subnetMask, 0)
+}
+
+func NewDualStackCIDRPool(subnetRanges []string, subnetMask, subnetMaskV6 int) *CIDRPool {
+	newStrategy, _ := AllocationStrategyByName(RandomAllocation)
+	return NewCIDRPoolWithStrategy(subnetRanges, subnetMask, subnetMaskV6, newStrategy)
+}
 
+func NewCIDRPoolWithStrategy(subnetRanges []string, subnetMask, subnetMaskV6 int, newStrategy AllocationStrategyFactory) *CIDRPool {
+	pool, err := newCIDRPool(subnetRanges, nil, subnetMask, subnetMaskV6, newStrategy)
+	if err != nil {
+		panic(err)
+	}
+	return pool
+}
+
+// newCIDRPool builds the pools out of every range in subnetRanges. Subnets
+// in the draining ranges are members of the pools but are never allocated.
+func newCIDRPool(subnetRanges, drainingRanges []string, subnetMask, subnetMaskV6 int, newStrategy AllocationStrategyFactory) (*CIDRPool, error) {
 	if len(subnetRanges) == 0 {
-		panic(fmt.Errorf("network must be provided"))
+		return nil, fmt.Errorf("network must be provided")
+	}
+
+	ipv4Ranges, ipv6Ranges, err := mcn.SplitByFamily(subnetRanges)
+	if err != nil {
+		return nil, fmt.Errorf("invalid overlay network: %s", err)
 	}
 
-	overlayNetworks, err := mcn.NewMultipleCIDRNetwork(subnetRanges)
+	// the silk-daemon needs an IPv4 subnet for its VTEP, so IPv6 networks
+	// can only be added alongside IPv4 ones
+	if len(ipv4Ranges) == 0 {
+		return nil, fmt.Errorf("at least one ipv4 network must be provided, ipv6 networks can only be added alongside ipv4 ones")
+	}
+
+	overlayNetworks, err := mcn.NewMultipleCIDRNetwork(ipv4Ranges)
 	if err != nil {
-		panic(fmt.Errorf("invalid overlay network: %s", err))
+		return nil, fmt.Errorf("invalid overlay network: %s", err)
 	}
 
 	if subnetMask > 32 || subnetMask < 0 {
-		panic(fmt.Errorf("subnet mask must be between [0-32]"))
+		return nil, fmt.Errorf("subnet mask must be between [0-32]")
+	}
+
+	networks := sortedNetworks(overlayNetworks)
+
+	var networksV6 []*net.IPNet
+	var blockPoolV6 []string
+	if len(ipv6Ranges) > 0 {
+		overlayNetworksV6, err := mcn.NewMultipleCIDRNetwork(ipv6Ranges)
+		if err != nil {
+			return nil, fmt.Errorf("invalid overlay network: %s", err) // not possible, already parsed
+		}
+
+		if subnetMaskV6 > 128 || subnetMaskV6 < 1 {
+			return nil, fmt.Errorf("ipv6 subnet mask must be between [1-128]")
+		}
+
+		for _, network := range overlayNetworksV6.Networks {
+			networkMask, _ := network.Mask.Size()
+			if subnetMaskV6 <= networkMask {
+				return nil, fmt.Errorf("ipv6 subnet mask %d must be longer than network %s", subnetMaskV6, network)
+			}
+			if subnetMaskV6-networkMask > maxIPv6BlockBits {
+				return nil, fmt.Errorf("ipv6 network %s contains too many /%d subnets, at most %d bits may be allocated", network, subnetMaskV6, maxIPv6BlockBits)
+			}
+		}
+
+		networksV6 = sortedNetworks(overlayNetworksV6)
+		blockPoolV6 = generateBlockPoolV6(overlayNetworksV6, subnetMaskV6)
+	}
+
+	var networkNames []string
+	for _, network := range append(append([]*net.IPNet{}, networks...), networksV6...) {
+		networkNames = append(networkNames, network.String())
 	}
 
-	return &CIDRPool{
+	draining := make(map[string]struct{}, len(drainingRanges))
+	for _, drainingRange := range drainingRanges {
+		_, network, err := net.ParseCIDR(drainingRange)
+		if err != nil {
+			return nil, fmt.Errorf("invalid draining network: %s", err)
+		}
+		draining[network.String()] = struct{}{}
+	}
+
+	pool := &CIDRPool{
+		networks: networkNames,
 		// #nosec - G115 - we check valid values above for IPv4 subnet masks
-		blockPool: generateBlockPool(overlayNetworks, uint(subnetMask)),
+		blockPool: newSubnetPool(generateBlockPool(overlayNetworks, uint(subnetMask)), networks, draining, newStrategy()),
 		// #nosec - G115 - we check valid values above for IPv4 subnet masks
-		singlePool: generateSingleIPPool(overlayNetworks, uint(subnetMask)),
+		singlePool:  newSubnetPool(generateSingleIPPool(overlayNetworks, uint(subnetMask)), networks, draining, newStrategy()),
+		blockPoolV6: newSubnetPool(blockPoolV6, networksV6, draining, newStrategy()),
 	}
+	pool.networkSizes = pool.countNetworkSizes()
+	return pool, nil
+}
+
+// Networks returns the overlay networks in address order, IPv4 networks
+// first.
+func (c *CIDRPool) Networks() []string {
+	return c.networks
 }
 
 func (c *CIDRPool) GetBlockPool() map[string]struct{} {
-	return c.blockPool
+	return c.blockPool.members
 }
 
 func (c *CIDRPool) GetSinglePool() map[string]struct{} {
-	return c.singlePool
+	return c.singlePool.members
+}
+
+func (c *CIDRPool) GetBlockPoolV6() map[string]struct{} {
+	return c.blockPoolV6.members
 }
 
 func (c *CIDRPool) BlockPoolSize() int {
-	return len(c.blockPool)
+	return len(c.blockPool.subnets)
 }
 
 func (c *CIDRPool) SingleIPPoolSize() int {
-	return len(c.singlePool)
+	return len(c.singlePool.subnets)
+}
+
+func (c *CIDRPool) BlockPoolV6Size() int {
+	return len(c.blockPoolV6.subnets)
+}
+
+func (c *CIDRPool) IPv6Enabled() bool {
+	return len(c.blockPoolV6.subnets) > 0
+}
+
+func (c *CIDRPool) GetAvailableBlock(taken []string, underlayIP string) string {
+	return c.blockPool.getAvailable(taken, underlayIP)
 }
 
-func (c *CIDRPool) GetAvailableBlock(taken []string) string {
-	return getAvailable(taken, c.blockPool)
+func (c *CIDRPool) GetAvailableSingleIP(taken []string, underlayIP string) string {
+	return c.singlePool.getAvailable(taken, underlayIP)
 }
 
-func (c *CIDRPool) GetAvailableSingleIP(taken []string) string {
-	return getAvailable(taken, c.singlePool)
+func (c *CIDRPool) GetAvailableBlockV6(taken []string, underlayIP string) string {
+	return c.blockPoolV6.getAvailable(taken, underlayIP)
 }
 
 func (c *CIDRPool) IsMember(subnet string) bool {
-	_, blockOk := c.blockPool[subnet]
-	_, singleOk := c.singlePool[subnet]
+	_, blockOk := c.blockPool.members[subnet]
+	_, singleOk := c.singlePool.members[subnet]
 	return blockOk || singleOk
 }
 
-func getAvailable(taken []string, pool map[string]struct{}) string {
-	available := make(map[string]struct{})
-	for k, v := range pool {
-		available[k] = v
-	}
-	for _, subnet := range taken {
-		delete(available, subnet)
-	}
-	if len(available) == 0 {
-		return ""
-	}
-	i := mathRand.Intn(len(available))
-	n := 0
-	for subnet := range available {
-		if i == n {
-			return subnet
-		}
-		n++
+func (c *CIDRPool) IsMemberV6(subnet string) bool {
+	_, network, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return false
 	}
-	return ""
+	_, ok := c.blockPoolV6.members[network.String()]
+	return ok
 }
 
-func generateBlockPool(overlayNetworks mcn.MultipleCIDRNetwork, cidrMaskBlock uint) map[string]struct{} {
-	pool := make(map[string]struct{})
+func generateBlockPool(overlayNetworks mcn.MultipleCIDRNetwork, cidrMaskBlock uint) []string {
+	var pool []string
 	blockSize := 1 << (32 - cidrMaskBlock)
 
 	// loop over all subnets
-	for _, subnet := range overlayNetworks.Networks {
+	for _, subnet := range sortedNetworks(overlayNetworks) {
 		// get the starting IP
 		ipStart := subnet.IP
 
@@ -105,27 +254,59 @@ func generateBlockPool(overlayNetworks mcn.MultipleCIDRNetwork, cidrMaskBlock ui
 		// single IP pool and for setting up the networking between cells
 		for i := blockSize; i < fullRange; i += blockSize {
 			subnet := fmt.Sprintf("%s/%d", netaddr.IPAdd(ipStart, i), cidrMaskBlock)
-			pool[subnet] = struct{}{}
+			pool = append(pool, subnet)
+		}
+	}
+	return pool
+}
+
+func generateBlockPoolV6(overlayNetworks mcn.MultipleCIDRNetwork, cidrMaskBlock int) []string {
+	var pool []string
+
+	for _, subnet := range sortedNetworks(overlayNetworks) {
+		start := new(big.Int).SetBytes(subnet.IP.To16())
+		blockSize := new(big.Int).Lsh(big.NewInt(1), uint(128-cidrMaskBlock))
+
+		cidrMask, _ := subnet.Mask.Size()
+		numBlocks := 1 << (cidrMaskBlock - cidrMask)
+
+		// as with IPv4, the first block of every network is reserved for
+		// setting up the networking between cells
+		for i := 1; i < numBlocks; i++ {
+			offset := new(big.Int).Mul(blockSize, big.NewInt(int64(i)))
+			ip := make(net.IP, net.IPv6len)
+			new(big.Int).Add(start, offset).FillBytes(ip)
+			pool = append(pool, fmt.Sprintf("%s/%d", ip, cidrMaskBlock))
 		}
 	}
 	return pool
 }
 
-func generateSingleIPPool(overlayNetworks mcn.MultipleCIDRNetwork, cidrMaskBlock uint) map[string]struct{} {
+func generateSingleIPPool(overlayNetworks mcn.MultipleCIDRNetwork, cidrMaskBlock uint) []string {
 	// Only use IPs from the 1st network. SingleIPs from different networks
-	// can't talk to each other. We check above to make sure there is at leas
+	// can't talk to each other. We check above to make sure there is at least
 	// one, so it is safe to use the 0 index.
 	firstNetwork := overlayNetworks.Networks[0]
 
-	pool := make(map[string]struct{})
+	var pool []string
 	blockSize := 1 << (32 - cidrMaskBlock)
 
 	// Never create a lease from the first IP. This is used for setting up the
 	// networking between the cells. That is why this starts at i := 1.
 	for i := 1; i < blockSize; i++ {
 		singleCIDR := fmt.Sprintf("%s/32", netaddr.IPAdd(firstNetwork.IP, i))
-		pool[singleCIDR] = struct{}{}
+		pool = append(pool, singleCIDR)
 	}
 
 	return pool
 }
+
+// sortedNetworks orders the networks by address so that the pools are built
+// in address order, which the allocation strategies rely on.
+func sortedNetworks(overlayNetworks mcn.MultipleCIDRNetwork) []*net.IPNet {
+	networks := append([]*net.IPNet{}, overlayNetworks.Networks...)
+	sort.Slice(networks, func(i, j int) bool {
+		return bytes.Compare(networks[i].IP.To16(), networks[j].IP.To16()) < 0
+	})
+	return networks
+}
@@ -152,7 +152,7 @@ var _ = Describe("CIDRPool", func() {
 			var taken []string
 			for i := 1; i <= expectedNumBlockLeases; i++ {
 				By("testing that there are still leases left")
-				lease := cidrPool.GetAvailableBlock(taken)
+				lease := cidrPool.GetAvailableBlock(taken, "10.0.0.1")
 				Expect(lease).ToNot(Equal(""))
 
 				By("testing that the lease is a valid subnet")
@@ -178,7 +178,7 @@ var _ = Describe("CIDRPool", func() {
 			Expect(taken).To(HaveLen(expectedNumBlockLeases))
 
 			By("testing that there are no more leases left")
-			lease := cidrPool.GetAvailableBlock(taken)
+			lease := cidrPool.GetAvailableBlock(taken, "10.0.0.1")
 			Expect(lease).To(Equal(""))
 
 			By("testing that all of the leases are unique")
@@ -212,7 +212,7 @@ var _ = Describe("CIDRPool", func() {
 			var taken []string
 			for i := 1; i <= expectedNumSingleIPLeases; i++ {
 				By("testing that there are still leases left")
-				lease := cidrPool.GetAvailableSingleIP(taken)
+				lease := cidrPool.GetAvailableSingleIP(taken, "10.0.0.1")
 				Expect(lease).ToNot(Equal(""))
 
 				By("testing that the lease is a valid subnet")
@@ -238,7 +238,7 @@ var _ = Describe("CIDRPool", func() {
 			Expect(taken).To(HaveLen(expectedNumSingleIPLeases))
 
 			By("testing that there are no more leases left")
-			lease := cidrPool.GetAvailableSingleIP(taken)
+			lease := cidrPool.GetAvailableSingleIP(taken, "10.0.0.1")
 			Expect(lease).To(Equal(""))
 
 			By("testing that all of the leases are unique")
@@ -255,6 +255,129 @@ var _ = Describe("CIDRPool", func() {
 		Entry("When the subnet mask is 29", 29, 7),
 	)
 
+	Describe("NewDualStackCIDRPool", func() {
+		DescribeTable("panics when the ipv6 networks cannot be carved into subnets",
+			func(subnetRange []string, subnetMaskV6 int, expectedError string) {
+				shouldPanic := func() {
+					leaser.NewDualStackCIDRPool(subnetRange, 24, subnetMaskV6)
+				}
+				Expect(shouldPanic).To(PanicWith(MatchError(ContainSubstring(expectedError))))
+			},
+			Entry("when the ipv6 subnet mask is missing", []string{"10.255.0.0/16", "fd00::/48"}, 0, "ipv6 subnet mask must be between [1-128]"),
+			Entry("when the ipv6 subnet mask is > 128", []string{"10.255.0.0/16", "fd00::/48"}, 129, "ipv6 subnet mask must be between [1-128]"),
+			Entry("when the ipv6 subnet mask is not longer than the network", []string{"10.255.0.0/16", "fd00::/64"}, 64, "ipv6 subnet mask 64 must be longer than network fd00::/64"),
+			Entry("when the ipv6 network contains too many subnets", []string{"10.255.0.0/16", "fd00::/32"}, 64, "ipv6 network fd00::/32 contains too many /64 subnets"),
+		)
+
+		It("panics when there are no ipv4 networks, since the daemon needs an ipv4 subnet", func() {
+			shouldPanic := func() {
+				leaser.NewDualStackCIDRPool([]string{"fd00::/56"}, 24, 64)
+			}
+			Expect(shouldPanic).To(PanicWith(MatchError("at least one ipv4 network must be provided, ipv6 networks can only be added alongside ipv4 ones")))
+		})
+
+		It("builds the ipv4 pools from the ipv4 networks only", func() {
+			cidrPool := leaser.NewDualStackCIDRPool([]string{"fd00::/56", "10.255.0.0/16"}, 24, 64)
+			Expect(cidrPool.BlockPoolSize()).To(Equal(255))
+			Expect(cidrPool.SingleIPPoolSize()).To(Equal(255))
+			Expect(cidrPool.IPv6Enabled()).To(BeTrue())
+		})
+
+		DescribeTable("returns the number of ipv6 subnets that can be allocated",
+			func(subnetRange []string, subnetMaskV6, expectedSize int) {
+				cidrPool := leaser.NewDualStackCIDRPool(subnetRange, 24, subnetMaskV6)
+				Expect(cidrPool.BlockPoolV6Size()).To(Equal(expectedSize))
+			},
+			// /56 -> /64 = 256 ; 256 - 1 (first block is excluded) = 255
+			Entry("when the range is /56 and mask is /64", []string{"10.255.0.0/16", "fd00::/56"}, 64, 255),
+			// /48 -> /64 = 65536 ; 65536 - 1 (first block is excluded) = 65535
+			Entry("when the range is /48 and mask is /64", []string{"10.255.0.0/16", "fd00::/48"}, 64, 65535),
+			// (256 - 1) + (16 - 1) = 270
+			Entry("when there are multiple ipv6 ranges", []string{"10.255.0.0/16", "fd00::/56", "fd01::/60"}, 64, 270),
+			Entry("when there are no ipv6 ranges", []string{"10.255.0.0/16"}, 0, 0),
+		)
+
+		It("produces valid ipv6 subnets within the ipv6 networks", func() {
+			cidrPool := leaser.NewDualStackCIDRPool([]string{"10.255.0.0/16", "fd00:0:0:ff00::/56"}, 24, 64)
+			_, network, _ := net.ParseCIDR("fd00:0:0:ff00::/56")
+
+			Expect(cidrPool.GetBlockPoolV6()).NotTo(HaveKey("fd00:0:0:ff00::/64"))
+			Expect(cidrPool.GetBlockPoolV6()).To(HaveKey("fd00:0:0:ff01::/64"))
+			Expect(cidrPool.GetBlockPoolV6()).To(HaveKey("fd00:0:0:ffff::/64"))
+			for subnet := range cidrPool.GetBlockPoolV6() {
+				ip, ipNet, err := net.ParseCIDR(subnet)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(network.Contains(ip)).To(BeTrue())
+				Expect(ipNet.String()).To(Equal(subnet))
+			}
+		})
+	})
+
+	Describe("NewCIDRPoolWithStrategy", func() {
+		var cidrPool *leaser.CIDRPool
+
+		BeforeEach(func() {
+			newStrategy, err := leaser.AllocationStrategyByName("lowest-free")
+			Expect(err).NotTo(HaveOccurred())
+			cidrPool = leaser.NewCIDRPoolWithStrategy([]string{"10.255.0.0/23", "10.250.0.0/23", "fd00::/63"}, 24, 64, newStrategy)
+		})
+
+		It("allocates from the pools in address order", func() {
+			Expect(cidrPool.GetAvailableBlock(nil, "10.0.0.1")).To(Equal("10.250.1.0/24"))
+			Expect(cidrPool.GetAvailableBlock([]string{"10.250.1.0/24"}, "10.0.0.1")).To(Equal("10.255.1.0/24"))
+			Expect(cidrPool.GetAvailableSingleIP([]string{"10.255.0.1/32"}, "10.0.0.1")).To(Equal("10.255.0.2/32"))
+			Expect(cidrPool.GetAvailableBlockV6(nil, "10.0.0.1")).To(Equal("fd00:0:0:1::/64"))
+		})
+
+		It("ignores taken subnets that are not in the pool", func() {
+			Expect(cidrPool.GetAvailableBlock([]string{"10.250.1.0/24", "10.10.0.0/24"}, "10.0.0.1")).To(Equal("10.255.1.0/24"))
+			Expect(cidrPool.GetAvailableBlock([]string{"10.250.1.0/24", "10.255.1.0/24", "10.10.0.0/24"}, "10.0.0.1")).To(BeEmpty())
+		})
+	})
+
+	Describe("GetAvailableBlockV6", func() {
+		It("hands out every ipv6 subnet exactly once", func() {
+			cidrPool := leaser.NewDualStackCIDRPool([]string{"10.255.0.0/16", "fd00::/60"}, 24, 64)
+
+			var taken []string
+			for i := 0; i < 15; i++ {
+				subnet := cidrPool.GetAvailableBlockV6(taken, "10.0.0.1")
+				Expect(subnet).NotTo(BeEmpty())
+				Expect(taken).NotTo(ContainElement(subnet))
+				taken = append(taken, subnet)
+			}
+
+			Expect(cidrPool.GetAvailableBlockV6(taken, "10.0.0.1")).To(BeEmpty())
+		})
+	})
+
+	Describe("IsMemberV6", func() {
+		var cidrPool *leaser.CIDRPool
+		BeforeEach(func() {
+			cidrPool = leaser.NewDualStackCIDRPool([]string{"10.255.0.0/16", "fd00::/48"}, 24, 64)
+		})
+
+		It("returns true for subnets in the ipv6 pool", func() {
+			Expect(cidrPool.IsMemberV6("fd00:0:0:30::/64")).To(BeTrue())
+		})
+
+		It("accepts non-canonical representations", func() {
+			Expect(cidrPool.IsMemberV6("fd00:0000:0000:0030:0000::/64")).To(BeTrue())
+		})
+
+		It("returns false for the reserved first subnet", func() {
+			Expect(cidrPool.IsMemberV6("fd00::/64")).To(BeFalse())
+		})
+
+		It("returns false when the subnet size is not a match", func() {
+			Expect(cidrPool.IsMemberV6("fd00:0:0:30::/80")).To(BeFalse())
+		})
+
+		It("returns false for invalid subnets", func() {
+			Expect(cidrPool.IsMemberV6("banana")).To(BeFalse())
+		})
+	})
+
 	Describe("IsMember", func() {
 		var cidrPool *leaser.CIDRPool
 		BeforeEach(func() {
@@ -6,10 +6,11 @@ import (
 )
 
 type CIDRPool struct {
-	GetAvailableBlockStub        func([]string) string
+	GetAvailableBlockStub        func([]string, string) string
 	getAvailableBlockMutex       sync.RWMutex
 	getAvailableBlockArgsForCall []struct {
 		arg1 []string
+		arg2 string
 	}
 	getAvailableBlockReturns struct {
 		result1 string
@@ -17,10 +18,23 @@ type CIDRPool struct {
 	getAvailableBlockReturnsOnCall map[int]struct {
 		result1 string
 	}
-	GetAvailableSingleIPStub        func([]string) string
+	GetAvailableBlockV6Stub        func([]string, string) string
+	getAvailableBlockV6Mutex       sync.RWMutex
+	getAvailableBlockV6ArgsForCall []struct {
+		arg1 []string
+		arg2 string
+	}
+	getAvailableBlockV6Returns struct {
+		result1 string
+	}
+	getAvailableBlockV6ReturnsOnCall map[int]struct {
+		result1 string
+	}
+	GetAvailableSingleIPStub        func([]string, string) string
 	getAvailableSingleIPMutex       sync.RWMutex
 	getAvailableSingleIPArgsForCall []struct {
 		arg1 []string
+		arg2 string
 	}
 	getAvailableSingleIPReturns struct {
 		result1 string
@@ -28,6 +42,16 @@ type CIDRPool struct {
 	getAvailableSingleIPReturnsOnCall map[int]struct {
 		result1 string
 	}
+	IPv6EnabledStub        func() bool
+	iPv6EnabledMutex       sync.RWMutex
+	iPv6EnabledArgsForCall []struct {
+	}
+	iPv6EnabledReturns struct {
+		result1 bool
+	}
+	iPv6EnabledReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	IsMemberStub        func(string) bool
 	isMemberMutex       sync.RWMutex
 	isMemberArgsForCall []struct {
@@ -39,11 +63,22 @@ type CIDRPool struct {
 	isMemberReturnsOnCall map[int]struct {
 		result1 bool
 	}
+	IsMemberV6Stub        func(string) bool
+	isMemberV6Mutex       sync.RWMutex
+	isMemberV6ArgsForCall []struct {
+		arg1 string
+	}
+	isMemberV6Returns struct {
+		result1 bool
+	}
+	isMemberV6ReturnsOnCall map[int]struct {
+		result1 bool
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *CIDRPool) GetAvailableBlock(arg1 []string) string {
+func (fake *CIDRPool) GetAvailableBlock(arg1 []string, arg2 string) string {
 	var arg1Copy []string
 	if arg1 != nil {
 		arg1Copy = make([]string, len(arg1))
@@ -53,13 +88,14 @@ func (fake *CIDRPool) GetAvailableBlock(arg1 []string) string {
 	ret, specificReturn := fake.getAvailableBlockReturnsOnCall[len(fake.getAvailableBlockArgsForCall)]
 	fake.getAvailableBlockArgsForCall = append(fake.getAvailableBlockArgsForCall, struct {
 		arg1 []string
-	}{arg1Copy})
+		arg2 string
+	}{arg1Copy, arg2})
 	stub := fake.GetAvailableBlockStub
 	fakeReturns := fake.getAvailableBlockReturns
-	fake.recordInvocation("GetAvailableBlock", []interface{}{arg1Copy})
+	fake.recordInvocation("GetAvailableBlock", []interface{}{arg1Copy, arg2})
 	fake.getAvailableBlockMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
@@ -73,17 +109,17 @@ func (fake *CIDRPool) GetAvailableBlockCallCount() int {
 	return len(fake.getAvailableBlockArgsForCall)
 }
 
-func (fake *CIDRPool) GetAvailableBlockCalls(stub func([]string) string) {
+func (fake *CIDRPool) GetAvailableBlockCalls(stub func([]string, string) string) {
 	fake.getAvailableBlockMutex.Lock()
 	defer fake.getAvailableBlockMutex.Unlock()
 	fake.GetAvailableBlockStub = stub
 }
 
-func (fake *CIDRPool) GetAvailableBlockArgsForCall(i int) []string {
+func (fake *CIDRPool) GetAvailableBlockArgsForCall(i int) ([]string, string) {
 	fake.getAvailableBlockMutex.RLock()
 	defer fake.getAvailableBlockMutex.RUnlock()
 	argsForCall := fake.getAvailableBlockArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *CIDRPool) GetAvailableBlockReturns(result1 string) {
@@ -109,7 +145,74 @@ func (fake *CIDRPool) GetAvailableBlockReturnsOnCall(i int, result1 string) {
 	}{result1}
 }
 
-func (fake *CIDRPool) GetAvailableSingleIP(arg1 []string) string {
+func (fake *CIDRPool) GetAvailableBlockV6(arg1 []string, arg2 string) string {
+	var arg1Copy []string
+	if arg1 != nil {
+		arg1Copy = make([]string, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	fake.getAvailableBlockV6Mutex.Lock()
+	ret, specificReturn := fake.getAvailableBlockV6ReturnsOnCall[len(fake.getAvailableBlockV6ArgsForCall)]
+	fake.getAvailableBlockV6ArgsForCall = append(fake.getAvailableBlockV6ArgsForCall, struct {
+		arg1 []string
+		arg2 string
+	}{arg1Copy, arg2})
+	stub := fake.GetAvailableBlockV6Stub
+	fakeReturns := fake.getAvailableBlockV6Returns
+	fake.recordInvocation("GetAvailableBlockV6", []interface{}{arg1Copy, arg2})
+	fake.getAvailableBlockV6Mutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *CIDRPool) GetAvailableBlockV6CallCount() int {
+	fake.getAvailableBlockV6Mutex.RLock()
+	defer fake.getAvailableBlockV6Mutex.RUnlock()
+	return len(fake.getAvailableBlockV6ArgsForCall)
+}
+
+func (fake *CIDRPool) GetAvailableBlockV6Calls(stub func([]string, string) string) {
+	fake.getAvailableBlockV6Mutex.Lock()
+	defer fake.getAvailableBlockV6Mutex.Unlock()
+	fake.GetAvailableBlockV6Stub = stub
+}
+
+func (fake *CIDRPool) GetAvailableBlockV6ArgsForCall(i int) ([]string, string) {
+	fake.getAvailableBlockV6Mutex.RLock()
+	defer fake.getAvailableBlockV6Mutex.RUnlock()
+	argsForCall := fake.getAvailableBlockV6ArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *CIDRPool) GetAvailableBlockV6Returns(result1 string) {
+	fake.getAvailableBlockV6Mutex.Lock()
+	defer fake.getAvailableBlockV6Mutex.Unlock()
+	fake.GetAvailableBlockV6Stub = nil
+	fake.getAvailableBlockV6Returns = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *CIDRPool) GetAvailableBlockV6ReturnsOnCall(i int, result1 string) {
+	fake.getAvailableBlockV6Mutex.Lock()
+	defer fake.getAvailableBlockV6Mutex.Unlock()
+	fake.GetAvailableBlockV6Stub = nil
+	if fake.getAvailableBlockV6ReturnsOnCall == nil {
+		fake.getAvailableBlockV6ReturnsOnCall = make(map[int]struct {
+			result1 string
+		})
+	}
+	fake.getAvailableBlockV6ReturnsOnCall[i] = struct {
+		result1 string
+	}{result1}
+}
+
+func (fake *CIDRPool) GetAvailableSingleIP(arg1 []string, arg2 string) string {
 	var arg1Copy []string
 	if arg1 != nil {
 		arg1Copy = make([]string, len(arg1))
@@ -119,13 +222,14 @@ func (fake *CIDRPool) GetAvailableSingleIP(arg1 []string) string {
 	ret, specificReturn := fake.getAvailableSingleIPReturnsOnCall[len(fake.getAvailableSingleIPArgsForCall)]
 	fake.getAvailableSingleIPArgsForCall = append(fake.getAvailableSingleIPArgsForCall, struct {
 		arg1 []string
-	}{arg1Copy})
+		arg2 string
+	}{arg1Copy, arg2})
 	stub := fake.GetAvailableSingleIPStub
 	fakeReturns := fake.getAvailableSingleIPReturns
-	fake.recordInvocation("GetAvailableSingleIP", []interface{}{arg1Copy})
+	fake.recordInvocation("GetAvailableSingleIP", []interface{}{arg1Copy, arg2})
 	fake.getAvailableSingleIPMutex.Unlock()
 	if stub != nil {
-		return stub(arg1)
+		return stub(arg1, arg2)
 	}
 	if specificReturn {
 		return ret.result1
@@ -139,17 +243,17 @@ func (fake *CIDRPool) GetAvailableSingleIPCallCount() int {
 	return len(fake.getAvailableSingleIPArgsForCall)
 }
 
-func (fake *CIDRPool) GetAvailableSingleIPCalls(stub func([]string) string) {
+func (fake *CIDRPool) GetAvailableSingleIPCalls(stub func([]string, string) string) {
 	fake.getAvailableSingleIPMutex.Lock()
 	defer fake.getAvailableSingleIPMutex.Unlock()
 	fake.GetAvailableSingleIPStub = stub
 }
 
-func (fake *CIDRPool) GetAvailableSingleIPArgsForCall(i int) []string {
+func (fake *CIDRPool) GetAvailableSingleIPArgsForCall(i int) ([]string, string) {
 	fake.getAvailableSingleIPMutex.RLock()
 	defer fake.getAvailableSingleIPMutex.RUnlock()
 	argsForCall := fake.getAvailableSingleIPArgsForCall[i]
-	return argsForCall.arg1
+	return argsForCall.arg1, argsForCall.arg2
 }
 
 func (fake *CIDRPool) GetAvailableSingleIPReturns(result1 string) {
@@ -175,6 +279,59 @@ func (fake *CIDRPool) GetAvailableSingleIPReturnsOnCall(i int, result1 string) {
 	}{result1}
 }
 
+func (fake *CIDRPool) IPv6Enabled() bool {
+	fake.iPv6EnabledMutex.Lock()
+	ret, specificReturn := fake.iPv6EnabledReturnsOnCall[len(fake.iPv6EnabledArgsForCall)]
+	fake.iPv6EnabledArgsForCall = append(fake.iPv6EnabledArgsForCall, struct {
+	}{})
+	stub := fake.IPv6EnabledStub
+	fakeReturns := fake.iPv6EnabledReturns
+	fake.recordInvocation("IPv6Enabled", []interface{}{})
+	fake.iPv6EnabledMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *CIDRPool) IPv6EnabledCallCount() int {
+	fake.iPv6EnabledMutex.RLock()
+	defer fake.iPv6EnabledMutex.RUnlock()
+	return len(fake.iPv6EnabledArgsForCall)
+}
+
+func (fake *CIDRPool) IPv6EnabledCalls(stub func() bool) {
+	fake.iPv6EnabledMutex.Lock()
+	defer fake.iPv6EnabledMutex.Unlock()
+	fake.IPv6EnabledStub = stub
+}
+
+func (fake *CIDRPool) IPv6EnabledReturns(result1 bool) {
+	fake.iPv6EnabledMutex.Lock()
+	defer fake.iPv6EnabledMutex.Unlock()
+	fake.IPv6EnabledStub = nil
+	fake.iPv6EnabledReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *CIDRPool) IPv6EnabledReturnsOnCall(i int, result1 bool) {
+	fake.iPv6EnabledMutex.Lock()
+	defer fake.iPv6EnabledMutex.Unlock()
+	fake.IPv6EnabledStub = nil
+	if fake.iPv6EnabledReturnsOnCall == nil {
+		fake.iPv6EnabledReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.iPv6EnabledReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *CIDRPool) IsMember(arg1 string) bool {
 	fake.isMemberMutex.Lock()
 	ret, specificReturn := fake.isMemberReturnsOnCall[len(fake.isMemberArgsForCall)]
@@ -236,15 +393,82 @@ func (fake *CIDRPool) IsMemberReturnsOnCall(i int, result1 bool) {
 	}{result1}
 }
 
+func (fake *CIDRPool) IsMemberV6(arg1 string) bool {
+	fake.isMemberV6Mutex.Lock()
+	ret, specificReturn := fake.isMemberV6ReturnsOnCall[len(fake.isMemberV6ArgsForCall)]
+	fake.isMemberV6ArgsForCall = append(fake.isMemberV6ArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.IsMemberV6Stub
+	fakeReturns := fake.isMemberV6Returns
+	fake.recordInvocation("IsMemberV6", []interface{}{arg1})
+	fake.isMemberV6Mutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *CIDRPool) IsMemberV6CallCount() int {
+	fake.isMemberV6Mutex.RLock()
+	defer fake.isMemberV6Mutex.RUnlock()
+	return len(fake.isMemberV6ArgsForCall)
+}
+
+func (fake *CIDRPool) IsMemberV6Calls(stub func(string) bool) {
+	fake.isMemberV6Mutex.Lock()
+	defer fake.isMemberV6Mutex.Unlock()
+	fake.IsMemberV6Stub = stub
+}
+
+func (fake *CIDRPool) IsMemberV6ArgsForCall(i int) string {
+	fake.isMemberV6Mutex.RLock()
+	defer fake.isMemberV6Mutex.RUnlock()
+	argsForCall := fake.isMemberV6ArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *CIDRPool) IsMemberV6Returns(result1 bool) {
+	fake.isMemberV6Mutex.Lock()
+	defer fake.isMemberV6Mutex.Unlock()
+	fake.IsMemberV6Stub = nil
+	fake.isMemberV6Returns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *CIDRPool) IsMemberV6ReturnsOnCall(i int, result1 bool) {
+	fake.isMemberV6Mutex.Lock()
+	defer fake.isMemberV6Mutex.Unlock()
+	fake.IsMemberV6Stub = nil
+	if fake.isMemberV6ReturnsOnCall == nil {
+		fake.isMemberV6ReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.isMemberV6ReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
 func (fake *CIDRPool) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.getAvailableBlockMutex.RLock()
 	defer fake.getAvailableBlockMutex.RUnlock()
+	fake.getAvailableBlockV6Mutex.RLock()
+	defer fake.getAvailableBlockV6Mutex.RUnlock()
 	fake.getAvailableSingleIPMutex.RLock()
 	defer fake.getAvailableSingleIPMutex.RUnlock()
+	fake.iPv6EnabledMutex.RLock()
+	defer fake.iPv6EnabledMutex.RUnlock()
 	fake.isMemberMutex.RLock()
 	defer fake.isMemberMutex.RUnlock()
+	fake.isMemberV6Mutex.RLock()
+	defer fake.isMemberV6Mutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
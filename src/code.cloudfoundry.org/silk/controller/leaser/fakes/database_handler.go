@@ -5,9 +5,26 @@ import (
 	"sync"
 
 	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/database"
 )
 
 type DatabaseHandler struct {
+	AcquireLeaseStub        func(string, bool, int, database.LeaseAllocator) (*controller.Lease, error)
+	acquireLeaseMutex       sync.RWMutex
+	acquireLeaseArgsForCall []struct {
+		arg1 string
+		arg2 bool
+		arg3 int
+		arg4 database.LeaseAllocator
+	}
+	acquireLeaseReturns struct {
+		result1 *controller.Lease
+		result2 error
+	}
+	acquireLeaseReturnsOnCall map[int]struct {
+		result1 *controller.Lease
+		result2 error
+	}
 	AddEntryStub        func(controller.Lease) error
 	addEntryMutex       sync.RWMutex
 	addEntryArgsForCall []struct {
@@ -19,6 +36,31 @@ type DatabaseHandler struct {
 	addEntryReturnsOnCall map[int]struct {
 		result1 error
 	}
+	AddIPv6SubnetStub        func(string, func(takenV6 []string) string) (*controller.Lease, error)
+	addIPv6SubnetMutex       sync.RWMutex
+	addIPv6SubnetArgsForCall []struct {
+		arg1 string
+		arg2 func(takenV6 []string) string
+	}
+	addIPv6SubnetReturns struct {
+		result1 *controller.Lease
+		result2 error
+	}
+	addIPv6SubnetReturnsOnCall map[int]struct {
+		result1 *controller.Lease
+		result2 error
+	}
+	AddLeaseEventStub        func(controller.LeaseEvent) error
+	addLeaseEventMutex       sync.RWMutex
+	addLeaseEventArgsForCall []struct {
+		arg1 controller.LeaseEvent
+	}
+	addLeaseEventReturns struct {
+		result1 error
+	}
+	addLeaseEventReturnsOnCall map[int]struct {
+		result1 error
+	}
 	AllStub        func() ([]controller.Lease, error)
 	allMutex       sync.RWMutex
 	allArgsForCall []struct {
@@ -44,28 +86,16 @@ type DatabaseHandler struct {
 		result1 []controller.Lease
 		result2 error
 	}
-	AllBlockSubnetsStub        func() ([]controller.Lease, error)
-	allBlockSubnetsMutex       sync.RWMutex
-	allBlockSubnetsArgsForCall []struct {
+	AllReservationsStub        func() ([]controller.Reservation, error)
+	allReservationsMutex       sync.RWMutex
+	allReservationsArgsForCall []struct {
 	}
-	allBlockSubnetsReturns struct {
-		result1 []controller.Lease
+	allReservationsReturns struct {
+		result1 []controller.Reservation
 		result2 error
 	}
-	allBlockSubnetsReturnsOnCall map[int]struct {
-		result1 []controller.Lease
-		result2 error
-	}
-	AllSingleIPSubnetsStub        func() ([]controller.Lease, error)
-	allSingleIPSubnetsMutex       sync.RWMutex
-	allSingleIPSubnetsArgsForCall []struct {
-	}
-	allSingleIPSubnetsReturns struct {
-		result1 []controller.Lease
-		result2 error
-	}
-	allSingleIPSubnetsReturnsOnCall map[int]struct {
-		result1 []controller.Lease
+	allReservationsReturnsOnCall map[int]struct {
+		result1 []controller.Reservation
 		result2 error
 	}
 	DeleteEntryStub        func(string) error
@@ -79,6 +109,29 @@ type DatabaseHandler struct {
 	deleteEntryReturnsOnCall map[int]struct {
 		result1 error
 	}
+	DeleteLeaseStub        func(string, string) error
+	deleteLeaseMutex       sync.RWMutex
+	deleteLeaseArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	deleteLeaseReturns struct {
+		result1 error
+	}
+	deleteLeaseReturnsOnCall map[int]struct {
+		result1 error
+	}
+	DeleteReservationStub        func(string) error
+	deleteReservationMutex       sync.RWMutex
+	deleteReservationArgsForCall []struct {
+		arg1 string
+	}
+	deleteReservationReturns struct {
+		result1 error
+	}
+	deleteReservationReturnsOnCall map[int]struct {
+		result1 error
+	}
 	LastRenewedAtForUnderlayIPStub        func(string) (int64, error)
 	lastRenewedAtForUnderlayIPMutex       sync.RWMutex
 	lastRenewedAtForUnderlayIPArgsForCall []struct {
@@ -92,42 +145,42 @@ type DatabaseHandler struct {
 		result1 int64
 		result2 error
 	}
-	LeaseForUnderlayIPStub        func(string) (*controller.Lease, error)
-	leaseForUnderlayIPMutex       sync.RWMutex
-	leaseForUnderlayIPArgsForCall []struct {
-		arg1 string
+	LeaseEventsStub        func(controller.LeaseHistoryQuery) ([]controller.LeaseEvent, error)
+	leaseEventsMutex       sync.RWMutex
+	leaseEventsArgsForCall []struct {
+		arg1 controller.LeaseHistoryQuery
 	}
-	leaseForUnderlayIPReturns struct {
-		result1 *controller.Lease
+	leaseEventsReturns struct {
+		result1 []controller.LeaseEvent
 		result2 error
 	}
-	leaseForUnderlayIPReturnsOnCall map[int]struct {
-		result1 *controller.Lease
+	leaseEventsReturnsOnCall map[int]struct {
+		result1 []controller.LeaseEvent
 		result2 error
 	}
-	OldestExpiredBlockSubnetStub        func(int) (*controller.Lease, error)
-	oldestExpiredBlockSubnetMutex       sync.RWMutex
-	oldestExpiredBlockSubnetArgsForCall []struct {
-		arg1 int
+	LeaseForOverlaySubnetStub        func(string) (*controller.Lease, error)
+	leaseForOverlaySubnetMutex       sync.RWMutex
+	leaseForOverlaySubnetArgsForCall []struct {
+		arg1 string
 	}
-	oldestExpiredBlockSubnetReturns struct {
+	leaseForOverlaySubnetReturns struct {
 		result1 *controller.Lease
 		result2 error
 	}
-	oldestExpiredBlockSubnetReturnsOnCall map[int]struct {
+	leaseForOverlaySubnetReturnsOnCall map[int]struct {
 		result1 *controller.Lease
 		result2 error
 	}
-	OldestExpiredSingleIPStub        func(int) (*controller.Lease, error)
-	oldestExpiredSingleIPMutex       sync.RWMutex
-	oldestExpiredSingleIPArgsForCall []struct {
-		arg1 int
+	LeaseForUnderlayIPStub        func(string) (*controller.Lease, error)
+	leaseForUnderlayIPMutex       sync.RWMutex
+	leaseForUnderlayIPArgsForCall []struct {
+		arg1 string
 	}
-	oldestExpiredSingleIPReturns struct {
+	leaseForUnderlayIPReturns struct {
 		result1 *controller.Lease
 		result2 error
 	}
-	oldestExpiredSingleIPReturnsOnCall map[int]struct {
+	leaseForUnderlayIPReturnsOnCall map[int]struct {
 		result1 *controller.Lease
 		result2 error
 	}
@@ -142,10 +195,117 @@ type DatabaseHandler struct {
 	renewLeaseForUnderlayIPReturnsOnCall map[int]struct {
 		result1 error
 	}
+	ReservationForOverlaySubnetStub        func(string) (*controller.Reservation, error)
+	reservationForOverlaySubnetMutex       sync.RWMutex
+	reservationForOverlaySubnetArgsForCall []struct {
+		arg1 string
+	}
+	reservationForOverlaySubnetReturns struct {
+		result1 *controller.Reservation
+		result2 error
+	}
+	reservationForOverlaySubnetReturnsOnCall map[int]struct {
+		result1 *controller.Reservation
+		result2 error
+	}
+	ReservationForUnderlayIPStub        func(string) (*controller.Reservation, error)
+	reservationForUnderlayIPMutex       sync.RWMutex
+	reservationForUnderlayIPArgsForCall []struct {
+		arg1 string
+	}
+	reservationForUnderlayIPReturns struct {
+		result1 *controller.Reservation
+		result2 error
+	}
+	reservationForUnderlayIPReturnsOnCall map[int]struct {
+		result1 *controller.Reservation
+		result2 error
+	}
+	ReserveSubnetStub        func(controller.Reservation, database.ReservationCheck) (bool, error)
+	reserveSubnetMutex       sync.RWMutex
+	reserveSubnetArgsForCall []struct {
+		arg1 controller.Reservation
+		arg2 database.ReservationCheck
+	}
+	reserveSubnetReturns struct {
+		result1 bool
+		result2 error
+	}
+	reserveSubnetReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *DatabaseHandler) AcquireLease(arg1 string, arg2 bool, arg3 int, arg4 database.LeaseAllocator) (*controller.Lease, error) {
+	fake.acquireLeaseMutex.Lock()
+	ret, specificReturn := fake.acquireLeaseReturnsOnCall[len(fake.acquireLeaseArgsForCall)]
+	fake.acquireLeaseArgsForCall = append(fake.acquireLeaseArgsForCall, struct {
+		arg1 string
+		arg2 bool
+		arg3 int
+		arg4 database.LeaseAllocator
+	}{arg1, arg2, arg3, arg4})
+	stub := fake.AcquireLeaseStub
+	fakeReturns := fake.acquireLeaseReturns
+	fake.recordInvocation("AcquireLease", []interface{}{arg1, arg2, arg3, arg4})
+	fake.acquireLeaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3, arg4)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *DatabaseHandler) AcquireLeaseCallCount() int {
+	fake.acquireLeaseMutex.RLock()
+	defer fake.acquireLeaseMutex.RUnlock()
+	return len(fake.acquireLeaseArgsForCall)
+}
+
+func (fake *DatabaseHandler) AcquireLeaseCalls(stub func(string, bool, int, database.LeaseAllocator) (*controller.Lease, error)) {
+	fake.acquireLeaseMutex.Lock()
+	defer fake.acquireLeaseMutex.Unlock()
+	fake.AcquireLeaseStub = stub
+}
+
+func (fake *DatabaseHandler) AcquireLeaseArgsForCall(i int) (string, bool, int, database.LeaseAllocator) {
+	fake.acquireLeaseMutex.RLock()
+	defer fake.acquireLeaseMutex.RUnlock()
+	argsForCall := fake.acquireLeaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3, argsForCall.arg4
+}
+
+func (fake *DatabaseHandler) AcquireLeaseReturns(result1 *controller.Lease, result2 error) {
+	fake.acquireLeaseMutex.Lock()
+	defer fake.acquireLeaseMutex.Unlock()
+	fake.AcquireLeaseStub = nil
+	fake.acquireLeaseReturns = struct {
+		result1 *controller.Lease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) AcquireLeaseReturnsOnCall(i int, result1 *controller.Lease, result2 error) {
+	fake.acquireLeaseMutex.Lock()
+	defer fake.acquireLeaseMutex.Unlock()
+	fake.AcquireLeaseStub = nil
+	if fake.acquireLeaseReturnsOnCall == nil {
+		fake.acquireLeaseReturnsOnCall = make(map[int]struct {
+			result1 *controller.Lease
+			result2 error
+		})
+	}
+	fake.acquireLeaseReturnsOnCall[i] = struct {
+		result1 *controller.Lease
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *DatabaseHandler) AddEntry(arg1 controller.Lease) error {
 	fake.addEntryMutex.Lock()
 	ret, specificReturn := fake.addEntryReturnsOnCall[len(fake.addEntryArgsForCall)]
@@ -207,6 +367,132 @@ func (fake *DatabaseHandler) AddEntryReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *DatabaseHandler) AddIPv6Subnet(arg1 string, arg2 func(takenV6 []string) string) (*controller.Lease, error) {
+	fake.addIPv6SubnetMutex.Lock()
+	ret, specificReturn := fake.addIPv6SubnetReturnsOnCall[len(fake.addIPv6SubnetArgsForCall)]
+	fake.addIPv6SubnetArgsForCall = append(fake.addIPv6SubnetArgsForCall, struct {
+		arg1 string
+		arg2 func(takenV6 []string) string
+	}{arg1, arg2})
+	stub := fake.AddIPv6SubnetStub
+	fakeReturns := fake.addIPv6SubnetReturns
+	fake.recordInvocation("AddIPv6Subnet", []interface{}{arg1, arg2})
+	fake.addIPv6SubnetMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *DatabaseHandler) AddIPv6SubnetCallCount() int {
+	fake.addIPv6SubnetMutex.RLock()
+	defer fake.addIPv6SubnetMutex.RUnlock()
+	return len(fake.addIPv6SubnetArgsForCall)
+}
+
+func (fake *DatabaseHandler) AddIPv6SubnetCalls(stub func(string, func(takenV6 []string) string) (*controller.Lease, error)) {
+	fake.addIPv6SubnetMutex.Lock()
+	defer fake.addIPv6SubnetMutex.Unlock()
+	fake.AddIPv6SubnetStub = stub
+}
+
+func (fake *DatabaseHandler) AddIPv6SubnetArgsForCall(i int) (string, func(takenV6 []string) string) {
+	fake.addIPv6SubnetMutex.RLock()
+	defer fake.addIPv6SubnetMutex.RUnlock()
+	argsForCall := fake.addIPv6SubnetArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *DatabaseHandler) AddIPv6SubnetReturns(result1 *controller.Lease, result2 error) {
+	fake.addIPv6SubnetMutex.Lock()
+	defer fake.addIPv6SubnetMutex.Unlock()
+	fake.AddIPv6SubnetStub = nil
+	fake.addIPv6SubnetReturns = struct {
+		result1 *controller.Lease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) AddIPv6SubnetReturnsOnCall(i int, result1 *controller.Lease, result2 error) {
+	fake.addIPv6SubnetMutex.Lock()
+	defer fake.addIPv6SubnetMutex.Unlock()
+	fake.AddIPv6SubnetStub = nil
+	if fake.addIPv6SubnetReturnsOnCall == nil {
+		fake.addIPv6SubnetReturnsOnCall = make(map[int]struct {
+			result1 *controller.Lease
+			result2 error
+		})
+	}
+	fake.addIPv6SubnetReturnsOnCall[i] = struct {
+		result1 *controller.Lease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) AddLeaseEvent(arg1 controller.LeaseEvent) error {
+	fake.addLeaseEventMutex.Lock()
+	ret, specificReturn := fake.addLeaseEventReturnsOnCall[len(fake.addLeaseEventArgsForCall)]
+	fake.addLeaseEventArgsForCall = append(fake.addLeaseEventArgsForCall, struct {
+		arg1 controller.LeaseEvent
+	}{arg1})
+	stub := fake.AddLeaseEventStub
+	fakeReturns := fake.addLeaseEventReturns
+	fake.recordInvocation("AddLeaseEvent", []interface{}{arg1})
+	fake.addLeaseEventMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *DatabaseHandler) AddLeaseEventCallCount() int {
+	fake.addLeaseEventMutex.RLock()
+	defer fake.addLeaseEventMutex.RUnlock()
+	return len(fake.addLeaseEventArgsForCall)
+}
+
+func (fake *DatabaseHandler) AddLeaseEventCalls(stub func(controller.LeaseEvent) error) {
+	fake.addLeaseEventMutex.Lock()
+	defer fake.addLeaseEventMutex.Unlock()
+	fake.AddLeaseEventStub = stub
+}
+
+func (fake *DatabaseHandler) AddLeaseEventArgsForCall(i int) controller.LeaseEvent {
+	fake.addLeaseEventMutex.RLock()
+	defer fake.addLeaseEventMutex.RUnlock()
+	argsForCall := fake.addLeaseEventArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *DatabaseHandler) AddLeaseEventReturns(result1 error) {
+	fake.addLeaseEventMutex.Lock()
+	defer fake.addLeaseEventMutex.Unlock()
+	fake.AddLeaseEventStub = nil
+	fake.addLeaseEventReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *DatabaseHandler) AddLeaseEventReturnsOnCall(i int, result1 error) {
+	fake.addLeaseEventMutex.Lock()
+	defer fake.addLeaseEventMutex.Unlock()
+	fake.AddLeaseEventStub = nil
+	if fake.addLeaseEventReturnsOnCall == nil {
+		fake.addLeaseEventReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addLeaseEventReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *DatabaseHandler) All() ([]controller.Lease, error) {
 	fake.allMutex.Lock()
 	ret, specificReturn := fake.allReturnsOnCall[len(fake.allArgsForCall)]
@@ -327,71 +613,15 @@ func (fake *DatabaseHandler) AllActiveReturnsOnCall(i int, result1 []controller.
 	}{result1, result2}
 }
 
-func (fake *DatabaseHandler) AllBlockSubnets() ([]controller.Lease, error) {
-	fake.allBlockSubnetsMutex.Lock()
-	ret, specificReturn := fake.allBlockSubnetsReturnsOnCall[len(fake.allBlockSubnetsArgsForCall)]
-	fake.allBlockSubnetsArgsForCall = append(fake.allBlockSubnetsArgsForCall, struct {
-	}{})
-	stub := fake.AllBlockSubnetsStub
-	fakeReturns := fake.allBlockSubnetsReturns
-	fake.recordInvocation("AllBlockSubnets", []interface{}{})
-	fake.allBlockSubnetsMutex.Unlock()
-	if stub != nil {
-		return stub()
-	}
-	if specificReturn {
-		return ret.result1, ret.result2
-	}
-	return fakeReturns.result1, fakeReturns.result2
-}
-
-func (fake *DatabaseHandler) AllBlockSubnetsCallCount() int {
-	fake.allBlockSubnetsMutex.RLock()
-	defer fake.allBlockSubnetsMutex.RUnlock()
-	return len(fake.allBlockSubnetsArgsForCall)
-}
-
-func (fake *DatabaseHandler) AllBlockSubnetsCalls(stub func() ([]controller.Lease, error)) {
-	fake.allBlockSubnetsMutex.Lock()
-	defer fake.allBlockSubnetsMutex.Unlock()
-	fake.AllBlockSubnetsStub = stub
-}
-
-func (fake *DatabaseHandler) AllBlockSubnetsReturns(result1 []controller.Lease, result2 error) {
-	fake.allBlockSubnetsMutex.Lock()
-	defer fake.allBlockSubnetsMutex.Unlock()
-	fake.AllBlockSubnetsStub = nil
-	fake.allBlockSubnetsReturns = struct {
-		result1 []controller.Lease
-		result2 error
-	}{result1, result2}
-}
-
-func (fake *DatabaseHandler) AllBlockSubnetsReturnsOnCall(i int, result1 []controller.Lease, result2 error) {
-	fake.allBlockSubnetsMutex.Lock()
-	defer fake.allBlockSubnetsMutex.Unlock()
-	fake.AllBlockSubnetsStub = nil
-	if fake.allBlockSubnetsReturnsOnCall == nil {
-		fake.allBlockSubnetsReturnsOnCall = make(map[int]struct {
-			result1 []controller.Lease
-			result2 error
-		})
-	}
-	fake.allBlockSubnetsReturnsOnCall[i] = struct {
-		result1 []controller.Lease
-		result2 error
-	}{result1, result2}
-}
-
-func (fake *DatabaseHandler) AllSingleIPSubnets() ([]controller.Lease, error) {
-	fake.allSingleIPSubnetsMutex.Lock()
-	ret, specificReturn := fake.allSingleIPSubnetsReturnsOnCall[len(fake.allSingleIPSubnetsArgsForCall)]
-	fake.allSingleIPSubnetsArgsForCall = append(fake.allSingleIPSubnetsArgsForCall, struct {
+func (fake *DatabaseHandler) AllReservations() ([]controller.Reservation, error) {
+	fake.allReservationsMutex.Lock()
+	ret, specificReturn := fake.allReservationsReturnsOnCall[len(fake.allReservationsArgsForCall)]
+	fake.allReservationsArgsForCall = append(fake.allReservationsArgsForCall, struct {
 	}{})
-	stub := fake.AllSingleIPSubnetsStub
-	fakeReturns := fake.allSingleIPSubnetsReturns
-	fake.recordInvocation("AllSingleIPSubnets", []interface{}{})
-	fake.allSingleIPSubnetsMutex.Unlock()
+	stub := fake.AllReservationsStub
+	fakeReturns := fake.allReservationsReturns
+	fake.recordInvocation("AllReservations", []interface{}{})
+	fake.allReservationsMutex.Unlock()
 	if stub != nil {
 		return stub()
 	}
@@ -401,40 +631,40 @@ func (fake *DatabaseHandler) AllSingleIPSubnets() ([]controller.Lease, error) {
 	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *DatabaseHandler) AllSingleIPSubnetsCallCount() int {
-	fake.allSingleIPSubnetsMutex.RLock()
-	defer fake.allSingleIPSubnetsMutex.RUnlock()
-	return len(fake.allSingleIPSubnetsArgsForCall)
+func (fake *DatabaseHandler) AllReservationsCallCount() int {
+	fake.allReservationsMutex.RLock()
+	defer fake.allReservationsMutex.RUnlock()
+	return len(fake.allReservationsArgsForCall)
 }
 
-func (fake *DatabaseHandler) AllSingleIPSubnetsCalls(stub func() ([]controller.Lease, error)) {
-	fake.allSingleIPSubnetsMutex.Lock()
-	defer fake.allSingleIPSubnetsMutex.Unlock()
-	fake.AllSingleIPSubnetsStub = stub
+func (fake *DatabaseHandler) AllReservationsCalls(stub func() ([]controller.Reservation, error)) {
+	fake.allReservationsMutex.Lock()
+	defer fake.allReservationsMutex.Unlock()
+	fake.AllReservationsStub = stub
 }
 
-func (fake *DatabaseHandler) AllSingleIPSubnetsReturns(result1 []controller.Lease, result2 error) {
-	fake.allSingleIPSubnetsMutex.Lock()
-	defer fake.allSingleIPSubnetsMutex.Unlock()
-	fake.AllSingleIPSubnetsStub = nil
-	fake.allSingleIPSubnetsReturns = struct {
-		result1 []controller.Lease
+func (fake *DatabaseHandler) AllReservationsReturns(result1 []controller.Reservation, result2 error) {
+	fake.allReservationsMutex.Lock()
+	defer fake.allReservationsMutex.Unlock()
+	fake.AllReservationsStub = nil
+	fake.allReservationsReturns = struct {
+		result1 []controller.Reservation
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *DatabaseHandler) AllSingleIPSubnetsReturnsOnCall(i int, result1 []controller.Lease, result2 error) {
-	fake.allSingleIPSubnetsMutex.Lock()
-	defer fake.allSingleIPSubnetsMutex.Unlock()
-	fake.AllSingleIPSubnetsStub = nil
-	if fake.allSingleIPSubnetsReturnsOnCall == nil {
-		fake.allSingleIPSubnetsReturnsOnCall = make(map[int]struct {
-			result1 []controller.Lease
+func (fake *DatabaseHandler) AllReservationsReturnsOnCall(i int, result1 []controller.Reservation, result2 error) {
+	fake.allReservationsMutex.Lock()
+	defer fake.allReservationsMutex.Unlock()
+	fake.AllReservationsStub = nil
+	if fake.allReservationsReturnsOnCall == nil {
+		fake.allReservationsReturnsOnCall = make(map[int]struct {
+			result1 []controller.Reservation
 			result2 error
 		})
 	}
-	fake.allSingleIPSubnetsReturnsOnCall[i] = struct {
-		result1 []controller.Lease
+	fake.allReservationsReturnsOnCall[i] = struct {
+		result1 []controller.Reservation
 		result2 error
 	}{result1, result2}
 }
@@ -500,6 +730,129 @@ func (fake *DatabaseHandler) DeleteEntryReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *DatabaseHandler) DeleteLease(arg1 string, arg2 string) error {
+	fake.deleteLeaseMutex.Lock()
+	ret, specificReturn := fake.deleteLeaseReturnsOnCall[len(fake.deleteLeaseArgsForCall)]
+	fake.deleteLeaseArgsForCall = append(fake.deleteLeaseArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.DeleteLeaseStub
+	fakeReturns := fake.deleteLeaseReturns
+	fake.recordInvocation("DeleteLease", []interface{}{arg1, arg2})
+	fake.deleteLeaseMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *DatabaseHandler) DeleteLeaseCallCount() int {
+	fake.deleteLeaseMutex.RLock()
+	defer fake.deleteLeaseMutex.RUnlock()
+	return len(fake.deleteLeaseArgsForCall)
+}
+
+func (fake *DatabaseHandler) DeleteLeaseCalls(stub func(string, string) error) {
+	fake.deleteLeaseMutex.Lock()
+	defer fake.deleteLeaseMutex.Unlock()
+	fake.DeleteLeaseStub = stub
+}
+
+func (fake *DatabaseHandler) DeleteLeaseArgsForCall(i int) (string, string) {
+	fake.deleteLeaseMutex.RLock()
+	defer fake.deleteLeaseMutex.RUnlock()
+	argsForCall := fake.deleteLeaseArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *DatabaseHandler) DeleteLeaseReturns(result1 error) {
+	fake.deleteLeaseMutex.Lock()
+	defer fake.deleteLeaseMutex.Unlock()
+	fake.DeleteLeaseStub = nil
+	fake.deleteLeaseReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *DatabaseHandler) DeleteLeaseReturnsOnCall(i int, result1 error) {
+	fake.deleteLeaseMutex.Lock()
+	defer fake.deleteLeaseMutex.Unlock()
+	fake.DeleteLeaseStub = nil
+	if fake.deleteLeaseReturnsOnCall == nil {
+		fake.deleteLeaseReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteLeaseReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *DatabaseHandler) DeleteReservation(arg1 string) error {
+	fake.deleteReservationMutex.Lock()
+	ret, specificReturn := fake.deleteReservationReturnsOnCall[len(fake.deleteReservationArgsForCall)]
+	fake.deleteReservationArgsForCall = append(fake.deleteReservationArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.DeleteReservationStub
+	fakeReturns := fake.deleteReservationReturns
+	fake.recordInvocation("DeleteReservation", []interface{}{arg1})
+	fake.deleteReservationMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *DatabaseHandler) DeleteReservationCallCount() int {
+	fake.deleteReservationMutex.RLock()
+	defer fake.deleteReservationMutex.RUnlock()
+	return len(fake.deleteReservationArgsForCall)
+}
+
+func (fake *DatabaseHandler) DeleteReservationCalls(stub func(string) error) {
+	fake.deleteReservationMutex.Lock()
+	defer fake.deleteReservationMutex.Unlock()
+	fake.DeleteReservationStub = stub
+}
+
+func (fake *DatabaseHandler) DeleteReservationArgsForCall(i int) string {
+	fake.deleteReservationMutex.RLock()
+	defer fake.deleteReservationMutex.RUnlock()
+	argsForCall := fake.deleteReservationArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *DatabaseHandler) DeleteReservationReturns(result1 error) {
+	fake.deleteReservationMutex.Lock()
+	defer fake.deleteReservationMutex.Unlock()
+	fake.DeleteReservationStub = nil
+	fake.deleteReservationReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *DatabaseHandler) DeleteReservationReturnsOnCall(i int, result1 error) {
+	fake.deleteReservationMutex.Lock()
+	defer fake.deleteReservationMutex.Unlock()
+	fake.DeleteReservationStub = nil
+	if fake.deleteReservationReturnsOnCall == nil {
+		fake.deleteReservationReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteReservationReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *DatabaseHandler) LastRenewedAtForUnderlayIP(arg1 string) (int64, error) {
 	fake.lastRenewedAtForUnderlayIPMutex.Lock()
 	ret, specificReturn := fake.lastRenewedAtForUnderlayIPReturnsOnCall[len(fake.lastRenewedAtForUnderlayIPArgsForCall)]
@@ -564,16 +917,16 @@ func (fake *DatabaseHandler) LastRenewedAtForUnderlayIPReturnsOnCall(i int, resu
 	}{result1, result2}
 }
 
-func (fake *DatabaseHandler) LeaseForUnderlayIP(arg1 string) (*controller.Lease, error) {
-	fake.leaseForUnderlayIPMutex.Lock()
-	ret, specificReturn := fake.leaseForUnderlayIPReturnsOnCall[len(fake.leaseForUnderlayIPArgsForCall)]
-	fake.leaseForUnderlayIPArgsForCall = append(fake.leaseForUnderlayIPArgsForCall, struct {
-		arg1 string
+func (fake *DatabaseHandler) LeaseEvents(arg1 controller.LeaseHistoryQuery) ([]controller.LeaseEvent, error) {
+	fake.leaseEventsMutex.Lock()
+	ret, specificReturn := fake.leaseEventsReturnsOnCall[len(fake.leaseEventsArgsForCall)]
+	fake.leaseEventsArgsForCall = append(fake.leaseEventsArgsForCall, struct {
+		arg1 controller.LeaseHistoryQuery
 	}{arg1})
-	stub := fake.LeaseForUnderlayIPStub
-	fakeReturns := fake.leaseForUnderlayIPReturns
-	fake.recordInvocation("LeaseForUnderlayIP", []interface{}{arg1})
-	fake.leaseForUnderlayIPMutex.Unlock()
+	stub := fake.LeaseEventsStub
+	fakeReturns := fake.leaseEventsReturns
+	fake.recordInvocation("LeaseEvents", []interface{}{arg1})
+	fake.leaseEventsMutex.Unlock()
 	if stub != nil {
 		return stub(arg1)
 	}
@@ -583,61 +936,61 @@ func (fake *DatabaseHandler) LeaseForUnderlayIP(arg1 string) (*controller.Lease,
 	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *DatabaseHandler) LeaseForUnderlayIPCallCount() int {
-	fake.leaseForUnderlayIPMutex.RLock()
-	defer fake.leaseForUnderlayIPMutex.RUnlock()
-	return len(fake.leaseForUnderlayIPArgsForCall)
+func (fake *DatabaseHandler) LeaseEventsCallCount() int {
+	fake.leaseEventsMutex.RLock()
+	defer fake.leaseEventsMutex.RUnlock()
+	return len(fake.leaseEventsArgsForCall)
 }
 
-func (fake *DatabaseHandler) LeaseForUnderlayIPCalls(stub func(string) (*controller.Lease, error)) {
-	fake.leaseForUnderlayIPMutex.Lock()
-	defer fake.leaseForUnderlayIPMutex.Unlock()
-	fake.LeaseForUnderlayIPStub = stub
+func (fake *DatabaseHandler) LeaseEventsCalls(stub func(controller.LeaseHistoryQuery) ([]controller.LeaseEvent, error)) {
+	fake.leaseEventsMutex.Lock()
+	defer fake.leaseEventsMutex.Unlock()
+	fake.LeaseEventsStub = stub
 }
 
-func (fake *DatabaseHandler) LeaseForUnderlayIPArgsForCall(i int) string {
-	fake.leaseForUnderlayIPMutex.RLock()
-	defer fake.leaseForUnderlayIPMutex.RUnlock()
-	argsForCall := fake.leaseForUnderlayIPArgsForCall[i]
+func (fake *DatabaseHandler) LeaseEventsArgsForCall(i int) controller.LeaseHistoryQuery {
+	fake.leaseEventsMutex.RLock()
+	defer fake.leaseEventsMutex.RUnlock()
+	argsForCall := fake.leaseEventsArgsForCall[i]
 	return argsForCall.arg1
 }
 
-func (fake *DatabaseHandler) LeaseForUnderlayIPReturns(result1 *controller.Lease, result2 error) {
-	fake.leaseForUnderlayIPMutex.Lock()
-	defer fake.leaseForUnderlayIPMutex.Unlock()
-	fake.LeaseForUnderlayIPStub = nil
-	fake.leaseForUnderlayIPReturns = struct {
-		result1 *controller.Lease
+func (fake *DatabaseHandler) LeaseEventsReturns(result1 []controller.LeaseEvent, result2 error) {
+	fake.leaseEventsMutex.Lock()
+	defer fake.leaseEventsMutex.Unlock()
+	fake.LeaseEventsStub = nil
+	fake.leaseEventsReturns = struct {
+		result1 []controller.LeaseEvent
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *DatabaseHandler) LeaseForUnderlayIPReturnsOnCall(i int, result1 *controller.Lease, result2 error) {
-	fake.leaseForUnderlayIPMutex.Lock()
-	defer fake.leaseForUnderlayIPMutex.Unlock()
-	fake.LeaseForUnderlayIPStub = nil
-	if fake.leaseForUnderlayIPReturnsOnCall == nil {
-		fake.leaseForUnderlayIPReturnsOnCall = make(map[int]struct {
-			result1 *controller.Lease
+func (fake *DatabaseHandler) LeaseEventsReturnsOnCall(i int, result1 []controller.LeaseEvent, result2 error) {
+	fake.leaseEventsMutex.Lock()
+	defer fake.leaseEventsMutex.Unlock()
+	fake.LeaseEventsStub = nil
+	if fake.leaseEventsReturnsOnCall == nil {
+		fake.leaseEventsReturnsOnCall = make(map[int]struct {
+			result1 []controller.LeaseEvent
 			result2 error
 		})
 	}
-	fake.leaseForUnderlayIPReturnsOnCall[i] = struct {
-		result1 *controller.Lease
+	fake.leaseEventsReturnsOnCall[i] = struct {
+		result1 []controller.LeaseEvent
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *DatabaseHandler) OldestExpiredBlockSubnet(arg1 int) (*controller.Lease, error) {
-	fake.oldestExpiredBlockSubnetMutex.Lock()
-	ret, specificReturn := fake.oldestExpiredBlockSubnetReturnsOnCall[len(fake.oldestExpiredBlockSubnetArgsForCall)]
-	fake.oldestExpiredBlockSubnetArgsForCall = append(fake.oldestExpiredBlockSubnetArgsForCall, struct {
-		arg1 int
+func (fake *DatabaseHandler) LeaseForOverlaySubnet(arg1 string) (*controller.Lease, error) {
+	fake.leaseForOverlaySubnetMutex.Lock()
+	ret, specificReturn := fake.leaseForOverlaySubnetReturnsOnCall[len(fake.leaseForOverlaySubnetArgsForCall)]
+	fake.leaseForOverlaySubnetArgsForCall = append(fake.leaseForOverlaySubnetArgsForCall, struct {
+		arg1 string
 	}{arg1})
-	stub := fake.OldestExpiredBlockSubnetStub
-	fakeReturns := fake.oldestExpiredBlockSubnetReturns
-	fake.recordInvocation("OldestExpiredBlockSubnet", []interface{}{arg1})
-	fake.oldestExpiredBlockSubnetMutex.Unlock()
+	stub := fake.LeaseForOverlaySubnetStub
+	fakeReturns := fake.leaseForOverlaySubnetReturns
+	fake.recordInvocation("LeaseForOverlaySubnet", []interface{}{arg1})
+	fake.leaseForOverlaySubnetMutex.Unlock()
 	if stub != nil {
 		return stub(arg1)
 	}
@@ -647,61 +1000,61 @@ func (fake *DatabaseHandler) OldestExpiredBlockSubnet(arg1 int) (*controller.Lea
 	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *DatabaseHandler) OldestExpiredBlockSubnetCallCount() int {
-	fake.oldestExpiredBlockSubnetMutex.RLock()
-	defer fake.oldestExpiredBlockSubnetMutex.RUnlock()
-	return len(fake.oldestExpiredBlockSubnetArgsForCall)
+func (fake *DatabaseHandler) LeaseForOverlaySubnetCallCount() int {
+	fake.leaseForOverlaySubnetMutex.RLock()
+	defer fake.leaseForOverlaySubnetMutex.RUnlock()
+	return len(fake.leaseForOverlaySubnetArgsForCall)
 }
 
-func (fake *DatabaseHandler) OldestExpiredBlockSubnetCalls(stub func(int) (*controller.Lease, error)) {
-	fake.oldestExpiredBlockSubnetMutex.Lock()
-	defer fake.oldestExpiredBlockSubnetMutex.Unlock()
-	fake.OldestExpiredBlockSubnetStub = stub
+func (fake *DatabaseHandler) LeaseForOverlaySubnetCalls(stub func(string) (*controller.Lease, error)) {
+	fake.leaseForOverlaySubnetMutex.Lock()
+	defer fake.leaseForOverlaySubnetMutex.Unlock()
+	fake.LeaseForOverlaySubnetStub = stub
 }
 
-func (fake *DatabaseHandler) OldestExpiredBlockSubnetArgsForCall(i int) int {
-	fake.oldestExpiredBlockSubnetMutex.RLock()
-	defer fake.oldestExpiredBlockSubnetMutex.RUnlock()
-	argsForCall := fake.oldestExpiredBlockSubnetArgsForCall[i]
+func (fake *DatabaseHandler) LeaseForOverlaySubnetArgsForCall(i int) string {
+	fake.leaseForOverlaySubnetMutex.RLock()
+	defer fake.leaseForOverlaySubnetMutex.RUnlock()
+	argsForCall := fake.leaseForOverlaySubnetArgsForCall[i]
 	return argsForCall.arg1
 }
 
-func (fake *DatabaseHandler) OldestExpiredBlockSubnetReturns(result1 *controller.Lease, result2 error) {
-	fake.oldestExpiredBlockSubnetMutex.Lock()
-	defer fake.oldestExpiredBlockSubnetMutex.Unlock()
-	fake.OldestExpiredBlockSubnetStub = nil
-	fake.oldestExpiredBlockSubnetReturns = struct {
+func (fake *DatabaseHandler) LeaseForOverlaySubnetReturns(result1 *controller.Lease, result2 error) {
+	fake.leaseForOverlaySubnetMutex.Lock()
+	defer fake.leaseForOverlaySubnetMutex.Unlock()
+	fake.LeaseForOverlaySubnetStub = nil
+	fake.leaseForOverlaySubnetReturns = struct {
 		result1 *controller.Lease
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *DatabaseHandler) OldestExpiredBlockSubnetReturnsOnCall(i int, result1 *controller.Lease, result2 error) {
-	fake.oldestExpiredBlockSubnetMutex.Lock()
-	defer fake.oldestExpiredBlockSubnetMutex.Unlock()
-	fake.OldestExpiredBlockSubnetStub = nil
-	if fake.oldestExpiredBlockSubnetReturnsOnCall == nil {
-		fake.oldestExpiredBlockSubnetReturnsOnCall = make(map[int]struct {
+func (fake *DatabaseHandler) LeaseForOverlaySubnetReturnsOnCall(i int, result1 *controller.Lease, result2 error) {
+	fake.leaseForOverlaySubnetMutex.Lock()
+	defer fake.leaseForOverlaySubnetMutex.Unlock()
+	fake.LeaseForOverlaySubnetStub = nil
+	if fake.leaseForOverlaySubnetReturnsOnCall == nil {
+		fake.leaseForOverlaySubnetReturnsOnCall = make(map[int]struct {
 			result1 *controller.Lease
 			result2 error
 		})
 	}
-	fake.oldestExpiredBlockSubnetReturnsOnCall[i] = struct {
+	fake.leaseForOverlaySubnetReturnsOnCall[i] = struct {
 		result1 *controller.Lease
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *DatabaseHandler) OldestExpiredSingleIP(arg1 int) (*controller.Lease, error) {
-	fake.oldestExpiredSingleIPMutex.Lock()
-	ret, specificReturn := fake.oldestExpiredSingleIPReturnsOnCall[len(fake.oldestExpiredSingleIPArgsForCall)]
-	fake.oldestExpiredSingleIPArgsForCall = append(fake.oldestExpiredSingleIPArgsForCall, struct {
-		arg1 int
+func (fake *DatabaseHandler) LeaseForUnderlayIP(arg1 string) (*controller.Lease, error) {
+	fake.leaseForUnderlayIPMutex.Lock()
+	ret, specificReturn := fake.leaseForUnderlayIPReturnsOnCall[len(fake.leaseForUnderlayIPArgsForCall)]
+	fake.leaseForUnderlayIPArgsForCall = append(fake.leaseForUnderlayIPArgsForCall, struct {
+		arg1 string
 	}{arg1})
-	stub := fake.OldestExpiredSingleIPStub
-	fakeReturns := fake.oldestExpiredSingleIPReturns
-	fake.recordInvocation("OldestExpiredSingleIP", []interface{}{arg1})
-	fake.oldestExpiredSingleIPMutex.Unlock()
+	stub := fake.LeaseForUnderlayIPStub
+	fakeReturns := fake.leaseForUnderlayIPReturns
+	fake.recordInvocation("LeaseForUnderlayIP", []interface{}{arg1})
+	fake.leaseForUnderlayIPMutex.Unlock()
 	if stub != nil {
 		return stub(arg1)
 	}
@@ -711,46 +1064,46 @@ func (fake *DatabaseHandler) OldestExpiredSingleIP(arg1 int) (*controller.Lease,
 	return fakeReturns.result1, fakeReturns.result2
 }
 
-func (fake *DatabaseHandler) OldestExpiredSingleIPCallCount() int {
-	fake.oldestExpiredSingleIPMutex.RLock()
-	defer fake.oldestExpiredSingleIPMutex.RUnlock()
-	return len(fake.oldestExpiredSingleIPArgsForCall)
+func (fake *DatabaseHandler) LeaseForUnderlayIPCallCount() int {
+	fake.leaseForUnderlayIPMutex.RLock()
+	defer fake.leaseForUnderlayIPMutex.RUnlock()
+	return len(fake.leaseForUnderlayIPArgsForCall)
 }
 
-func (fake *DatabaseHandler) OldestExpiredSingleIPCalls(stub func(int) (*controller.Lease, error)) {
-	fake.oldestExpiredSingleIPMutex.Lock()
-	defer fake.oldestExpiredSingleIPMutex.Unlock()
-	fake.OldestExpiredSingleIPStub = stub
+func (fake *DatabaseHandler) LeaseForUnderlayIPCalls(stub func(string) (*controller.Lease, error)) {
+	fake.leaseForUnderlayIPMutex.Lock()
+	defer fake.leaseForUnderlayIPMutex.Unlock()
+	fake.LeaseForUnderlayIPStub = stub
 }
 
-func (fake *DatabaseHandler) OldestExpiredSingleIPArgsForCall(i int) int {
-	fake.oldestExpiredSingleIPMutex.RLock()
-	defer fake.oldestExpiredSingleIPMutex.RUnlock()
-	argsForCall := fake.oldestExpiredSingleIPArgsForCall[i]
+func (fake *DatabaseHandler) LeaseForUnderlayIPArgsForCall(i int) string {
+	fake.leaseForUnderlayIPMutex.RLock()
+	defer fake.leaseForUnderlayIPMutex.RUnlock()
+	argsForCall := fake.leaseForUnderlayIPArgsForCall[i]
 	return argsForCall.arg1
 }
 
-func (fake *DatabaseHandler) OldestExpiredSingleIPReturns(result1 *controller.Lease, result2 error) {
-	fake.oldestExpiredSingleIPMutex.Lock()
-	defer fake.oldestExpiredSingleIPMutex.Unlock()
-	fake.OldestExpiredSingleIPStub = nil
-	fake.oldestExpiredSingleIPReturns = struct {
+func (fake *DatabaseHandler) LeaseForUnderlayIPReturns(result1 *controller.Lease, result2 error) {
+	fake.leaseForUnderlayIPMutex.Lock()
+	defer fake.leaseForUnderlayIPMutex.Unlock()
+	fake.LeaseForUnderlayIPStub = nil
+	fake.leaseForUnderlayIPReturns = struct {
 		result1 *controller.Lease
 		result2 error
 	}{result1, result2}
 }
 
-func (fake *DatabaseHandler) OldestExpiredSingleIPReturnsOnCall(i int, result1 *controller.Lease, result2 error) {
-	fake.oldestExpiredSingleIPMutex.Lock()
-	defer fake.oldestExpiredSingleIPMutex.Unlock()
-	fake.OldestExpiredSingleIPStub = nil
-	if fake.oldestExpiredSingleIPReturnsOnCall == nil {
-		fake.oldestExpiredSingleIPReturnsOnCall = make(map[int]struct {
+func (fake *DatabaseHandler) LeaseForUnderlayIPReturnsOnCall(i int, result1 *controller.Lease, result2 error) {
+	fake.leaseForUnderlayIPMutex.Lock()
+	defer fake.leaseForUnderlayIPMutex.Unlock()
+	fake.LeaseForUnderlayIPStub = nil
+	if fake.leaseForUnderlayIPReturnsOnCall == nil {
+		fake.leaseForUnderlayIPReturnsOnCall = make(map[int]struct {
 			result1 *controller.Lease
 			result2 error
 		})
 	}
-	fake.oldestExpiredSingleIPReturnsOnCall[i] = struct {
+	fake.leaseForUnderlayIPReturnsOnCall[i] = struct {
 		result1 *controller.Lease
 		result2 error
 	}{result1, result2}
@@ -817,31 +1170,238 @@ func (fake *DatabaseHandler) RenewLeaseForUnderlayIPReturnsOnCall(i int, result1
 	}{result1}
 }
 
+func (fake *DatabaseHandler) ReservationForOverlaySubnet(arg1 string) (*controller.Reservation, error) {
+	fake.reservationForOverlaySubnetMutex.Lock()
+	ret, specificReturn := fake.reservationForOverlaySubnetReturnsOnCall[len(fake.reservationForOverlaySubnetArgsForCall)]
+	fake.reservationForOverlaySubnetArgsForCall = append(fake.reservationForOverlaySubnetArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ReservationForOverlaySubnetStub
+	fakeReturns := fake.reservationForOverlaySubnetReturns
+	fake.recordInvocation("ReservationForOverlaySubnet", []interface{}{arg1})
+	fake.reservationForOverlaySubnetMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *DatabaseHandler) ReservationForOverlaySubnetCallCount() int {
+	fake.reservationForOverlaySubnetMutex.RLock()
+	defer fake.reservationForOverlaySubnetMutex.RUnlock()
+	return len(fake.reservationForOverlaySubnetArgsForCall)
+}
+
+func (fake *DatabaseHandler) ReservationForOverlaySubnetCalls(stub func(string) (*controller.Reservation, error)) {
+	fake.reservationForOverlaySubnetMutex.Lock()
+	defer fake.reservationForOverlaySubnetMutex.Unlock()
+	fake.ReservationForOverlaySubnetStub = stub
+}
+
+func (fake *DatabaseHandler) ReservationForOverlaySubnetArgsForCall(i int) string {
+	fake.reservationForOverlaySubnetMutex.RLock()
+	defer fake.reservationForOverlaySubnetMutex.RUnlock()
+	argsForCall := fake.reservationForOverlaySubnetArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *DatabaseHandler) ReservationForOverlaySubnetReturns(result1 *controller.Reservation, result2 error) {
+	fake.reservationForOverlaySubnetMutex.Lock()
+	defer fake.reservationForOverlaySubnetMutex.Unlock()
+	fake.ReservationForOverlaySubnetStub = nil
+	fake.reservationForOverlaySubnetReturns = struct {
+		result1 *controller.Reservation
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) ReservationForOverlaySubnetReturnsOnCall(i int, result1 *controller.Reservation, result2 error) {
+	fake.reservationForOverlaySubnetMutex.Lock()
+	defer fake.reservationForOverlaySubnetMutex.Unlock()
+	fake.ReservationForOverlaySubnetStub = nil
+	if fake.reservationForOverlaySubnetReturnsOnCall == nil {
+		fake.reservationForOverlaySubnetReturnsOnCall = make(map[int]struct {
+			result1 *controller.Reservation
+			result2 error
+		})
+	}
+	fake.reservationForOverlaySubnetReturnsOnCall[i] = struct {
+		result1 *controller.Reservation
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) ReservationForUnderlayIP(arg1 string) (*controller.Reservation, error) {
+	fake.reservationForUnderlayIPMutex.Lock()
+	ret, specificReturn := fake.reservationForUnderlayIPReturnsOnCall[len(fake.reservationForUnderlayIPArgsForCall)]
+	fake.reservationForUnderlayIPArgsForCall = append(fake.reservationForUnderlayIPArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ReservationForUnderlayIPStub
+	fakeReturns := fake.reservationForUnderlayIPReturns
+	fake.recordInvocation("ReservationForUnderlayIP", []interface{}{arg1})
+	fake.reservationForUnderlayIPMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *DatabaseHandler) ReservationForUnderlayIPCallCount() int {
+	fake.reservationForUnderlayIPMutex.RLock()
+	defer fake.reservationForUnderlayIPMutex.RUnlock()
+	return len(fake.reservationForUnderlayIPArgsForCall)
+}
+
+func (fake *DatabaseHandler) ReservationForUnderlayIPCalls(stub func(string) (*controller.Reservation, error)) {
+	fake.reservationForUnderlayIPMutex.Lock()
+	defer fake.reservationForUnderlayIPMutex.Unlock()
+	fake.ReservationForUnderlayIPStub = stub
+}
+
+func (fake *DatabaseHandler) ReservationForUnderlayIPArgsForCall(i int) string {
+	fake.reservationForUnderlayIPMutex.RLock()
+	defer fake.reservationForUnderlayIPMutex.RUnlock()
+	argsForCall := fake.reservationForUnderlayIPArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *DatabaseHandler) ReservationForUnderlayIPReturns(result1 *controller.Reservation, result2 error) {
+	fake.reservationForUnderlayIPMutex.Lock()
+	defer fake.reservationForUnderlayIPMutex.Unlock()
+	fake.ReservationForUnderlayIPStub = nil
+	fake.reservationForUnderlayIPReturns = struct {
+		result1 *controller.Reservation
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) ReservationForUnderlayIPReturnsOnCall(i int, result1 *controller.Reservation, result2 error) {
+	fake.reservationForUnderlayIPMutex.Lock()
+	defer fake.reservationForUnderlayIPMutex.Unlock()
+	fake.ReservationForUnderlayIPStub = nil
+	if fake.reservationForUnderlayIPReturnsOnCall == nil {
+		fake.reservationForUnderlayIPReturnsOnCall = make(map[int]struct {
+			result1 *controller.Reservation
+			result2 error
+		})
+	}
+	fake.reservationForUnderlayIPReturnsOnCall[i] = struct {
+		result1 *controller.Reservation
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) ReserveSubnet(arg1 controller.Reservation, arg2 database.ReservationCheck) (bool, error) {
+	fake.reserveSubnetMutex.Lock()
+	ret, specificReturn := fake.reserveSubnetReturnsOnCall[len(fake.reserveSubnetArgsForCall)]
+	fake.reserveSubnetArgsForCall = append(fake.reserveSubnetArgsForCall, struct {
+		arg1 controller.Reservation
+		arg2 database.ReservationCheck
+	}{arg1, arg2})
+	stub := fake.ReserveSubnetStub
+	fakeReturns := fake.reserveSubnetReturns
+	fake.recordInvocation("ReserveSubnet", []interface{}{arg1, arg2})
+	fake.reserveSubnetMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *DatabaseHandler) ReserveSubnetCallCount() int {
+	fake.reserveSubnetMutex.RLock()
+	defer fake.reserveSubnetMutex.RUnlock()
+	return len(fake.reserveSubnetArgsForCall)
+}
+
+func (fake *DatabaseHandler) ReserveSubnetCalls(stub func(controller.Reservation, database.ReservationCheck) (bool, error)) {
+	fake.reserveSubnetMutex.Lock()
+	defer fake.reserveSubnetMutex.Unlock()
+	fake.ReserveSubnetStub = stub
+}
+
+func (fake *DatabaseHandler) ReserveSubnetArgsForCall(i int) (controller.Reservation, database.ReservationCheck) {
+	fake.reserveSubnetMutex.RLock()
+	defer fake.reserveSubnetMutex.RUnlock()
+	argsForCall := fake.reserveSubnetArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *DatabaseHandler) ReserveSubnetReturns(result1 bool, result2 error) {
+	fake.reserveSubnetMutex.Lock()
+	defer fake.reserveSubnetMutex.Unlock()
+	fake.ReserveSubnetStub = nil
+	fake.reserveSubnetReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) ReserveSubnetReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.reserveSubnetMutex.Lock()
+	defer fake.reserveSubnetMutex.Unlock()
+	fake.ReserveSubnetStub = nil
+	if fake.reserveSubnetReturnsOnCall == nil {
+		fake.reserveSubnetReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.reserveSubnetReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *DatabaseHandler) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.acquireLeaseMutex.RLock()
+	defer fake.acquireLeaseMutex.RUnlock()
 	fake.addEntryMutex.RLock()
 	defer fake.addEntryMutex.RUnlock()
+	fake.addIPv6SubnetMutex.RLock()
+	defer fake.addIPv6SubnetMutex.RUnlock()
+	fake.addLeaseEventMutex.RLock()
+	defer fake.addLeaseEventMutex.RUnlock()
 	fake.allMutex.RLock()
 	defer fake.allMutex.RUnlock()
 	fake.allActiveMutex.RLock()
 	defer fake.allActiveMutex.RUnlock()
-	fake.allBlockSubnetsMutex.RLock()
-	defer fake.allBlockSubnetsMutex.RUnlock()
-	fake.allSingleIPSubnetsMutex.RLock()
-	defer fake.allSingleIPSubnetsMutex.RUnlock()
+	fake.allReservationsMutex.RLock()
+	defer fake.allReservationsMutex.RUnlock()
 	fake.deleteEntryMutex.RLock()
 	defer fake.deleteEntryMutex.RUnlock()
+	fake.deleteLeaseMutex.RLock()
+	defer fake.deleteLeaseMutex.RUnlock()
+	fake.deleteReservationMutex.RLock()
+	defer fake.deleteReservationMutex.RUnlock()
 	fake.lastRenewedAtForUnderlayIPMutex.RLock()
 	defer fake.lastRenewedAtForUnderlayIPMutex.RUnlock()
+	fake.leaseEventsMutex.RLock()
+	defer fake.leaseEventsMutex.RUnlock()
+	fake.leaseForOverlaySubnetMutex.RLock()
+	defer fake.leaseForOverlaySubnetMutex.RUnlock()
 	fake.leaseForUnderlayIPMutex.RLock()
 	defer fake.leaseForUnderlayIPMutex.RUnlock()
-	fake.oldestExpiredBlockSubnetMutex.RLock()
-	defer fake.oldestExpiredBlockSubnetMutex.RUnlock()
-	fake.oldestExpiredSingleIPMutex.RLock()
-	defer fake.oldestExpiredSingleIPMutex.RUnlock()
 	fake.renewLeaseForUnderlayIPMutex.RLock()
 	defer fake.renewLeaseForUnderlayIPMutex.RUnlock()
+	fake.reservationForOverlaySubnetMutex.RLock()
+	defer fake.reservationForOverlaySubnetMutex.RUnlock()
+	fake.reservationForUnderlayIPMutex.RLock()
+	defer fake.reservationForUnderlayIPMutex.RUnlock()
+	fake.reserveSubnetMutex.RLock()
+	defer fake.reserveSubnetMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
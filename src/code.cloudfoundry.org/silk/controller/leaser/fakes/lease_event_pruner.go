@@ -0,0 +1,112 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+)
+
+type LeaseEventPruner struct {
+	DeleteLeaseEventsOlderThanStub        func(int) (int64, error)
+	deleteLeaseEventsOlderThanMutex       sync.RWMutex
+	deleteLeaseEventsOlderThanArgsForCall []struct {
+		arg1 int
+	}
+	deleteLeaseEventsOlderThanReturns struct {
+		result1 int64
+		result2 error
+	}
+	deleteLeaseEventsOlderThanReturnsOnCall map[int]struct {
+		result1 int64
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *LeaseEventPruner) DeleteLeaseEventsOlderThan(arg1 int) (int64, error) {
+	fake.deleteLeaseEventsOlderThanMutex.Lock()
+	ret, specificReturn := fake.deleteLeaseEventsOlderThanReturnsOnCall[len(fake.deleteLeaseEventsOlderThanArgsForCall)]
+	fake.deleteLeaseEventsOlderThanArgsForCall = append(fake.deleteLeaseEventsOlderThanArgsForCall, struct {
+		arg1 int
+	}{arg1})
+	stub := fake.DeleteLeaseEventsOlderThanStub
+	fakeReturns := fake.deleteLeaseEventsOlderThanReturns
+	fake.recordInvocation("DeleteLeaseEventsOlderThan", []interface{}{arg1})
+	fake.deleteLeaseEventsOlderThanMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *LeaseEventPruner) DeleteLeaseEventsOlderThanCallCount() int {
+	fake.deleteLeaseEventsOlderThanMutex.RLock()
+	defer fake.deleteLeaseEventsOlderThanMutex.RUnlock()
+	return len(fake.deleteLeaseEventsOlderThanArgsForCall)
+}
+
+func (fake *LeaseEventPruner) DeleteLeaseEventsOlderThanCalls(stub func(int) (int64, error)) {
+	fake.deleteLeaseEventsOlderThanMutex.Lock()
+	defer fake.deleteLeaseEventsOlderThanMutex.Unlock()
+	fake.DeleteLeaseEventsOlderThanStub = stub
+}
+
+func (fake *LeaseEventPruner) DeleteLeaseEventsOlderThanArgsForCall(i int) int {
+	fake.deleteLeaseEventsOlderThanMutex.RLock()
+	defer fake.deleteLeaseEventsOlderThanMutex.RUnlock()
+	argsForCall := fake.deleteLeaseEventsOlderThanArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *LeaseEventPruner) DeleteLeaseEventsOlderThanReturns(result1 int64, result2 error) {
+	fake.deleteLeaseEventsOlderThanMutex.Lock()
+	defer fake.deleteLeaseEventsOlderThanMutex.Unlock()
+	fake.DeleteLeaseEventsOlderThanStub = nil
+	fake.deleteLeaseEventsOlderThanReturns = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *LeaseEventPruner) DeleteLeaseEventsOlderThanReturnsOnCall(i int, result1 int64, result2 error) {
+	fake.deleteLeaseEventsOlderThanMutex.Lock()
+	defer fake.deleteLeaseEventsOlderThanMutex.Unlock()
+	fake.DeleteLeaseEventsOlderThanStub = nil
+	if fake.deleteLeaseEventsOlderThanReturnsOnCall == nil {
+		fake.deleteLeaseEventsOlderThanReturnsOnCall = make(map[int]struct {
+			result1 int64
+			result2 error
+		})
+	}
+	fake.deleteLeaseEventsOlderThanReturnsOnCall[i] = struct {
+		result1 int64
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *LeaseEventPruner) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.deleteLeaseEventsOlderThanMutex.RLock()
+	defer fake.deleteLeaseEventsOlderThanMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *LeaseEventPruner) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -0,0 +1,411 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+type OverlayNetworkStore struct {
+	AddOverlayNetworkStub        func(controller.OverlayNetwork) error
+	addOverlayNetworkMutex       sync.RWMutex
+	addOverlayNetworkArgsForCall []struct {
+		arg1 controller.OverlayNetwork
+	}
+	addOverlayNetworkReturns struct {
+		result1 error
+	}
+	addOverlayNetworkReturnsOnCall map[int]struct {
+		result1 error
+	}
+	AllReservationsStub        func() ([]controller.Reservation, error)
+	allReservationsMutex       sync.RWMutex
+	allReservationsArgsForCall []struct {
+	}
+	allReservationsReturns struct {
+		result1 []controller.Reservation
+		result2 error
+	}
+	allReservationsReturnsOnCall map[int]struct {
+		result1 []controller.Reservation
+		result2 error
+	}
+	OverlayNetworksStub        func() ([]controller.OverlayNetwork, error)
+	overlayNetworksMutex       sync.RWMutex
+	overlayNetworksArgsForCall []struct {
+	}
+	overlayNetworksReturns struct {
+		result1 []controller.OverlayNetwork
+		result2 error
+	}
+	overlayNetworksReturnsOnCall map[int]struct {
+		result1 []controller.OverlayNetwork
+		result2 error
+	}
+	RetireOverlayNetworkStub        func(string, int) ([]controller.Lease, int, error)
+	retireOverlayNetworkMutex       sync.RWMutex
+	retireOverlayNetworkArgsForCall []struct {
+		arg1 string
+		arg2 int
+	}
+	retireOverlayNetworkReturns struct {
+		result1 []controller.Lease
+		result2 int
+		result3 error
+	}
+	retireOverlayNetworkReturnsOnCall map[int]struct {
+		result1 []controller.Lease
+		result2 int
+		result3 error
+	}
+	SetOverlayNetworkStateStub        func(string, string) error
+	setOverlayNetworkStateMutex       sync.RWMutex
+	setOverlayNetworkStateArgsForCall []struct {
+		arg1 string
+		arg2 string
+	}
+	setOverlayNetworkStateReturns struct {
+		result1 error
+	}
+	setOverlayNetworkStateReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *OverlayNetworkStore) AddOverlayNetwork(arg1 controller.OverlayNetwork) error {
+	fake.addOverlayNetworkMutex.Lock()
+	ret, specificReturn := fake.addOverlayNetworkReturnsOnCall[len(fake.addOverlayNetworkArgsForCall)]
+	fake.addOverlayNetworkArgsForCall = append(fake.addOverlayNetworkArgsForCall, struct {
+		arg1 controller.OverlayNetwork
+	}{arg1})
+	stub := fake.AddOverlayNetworkStub
+	fakeReturns := fake.addOverlayNetworkReturns
+	fake.recordInvocation("AddOverlayNetwork", []interface{}{arg1})
+	fake.addOverlayNetworkMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *OverlayNetworkStore) AddOverlayNetworkCallCount() int {
+	fake.addOverlayNetworkMutex.RLock()
+	defer fake.addOverlayNetworkMutex.RUnlock()
+	return len(fake.addOverlayNetworkArgsForCall)
+}
+
+func (fake *OverlayNetworkStore) AddOverlayNetworkCalls(stub func(controller.OverlayNetwork) error) {
+	fake.addOverlayNetworkMutex.Lock()
+	defer fake.addOverlayNetworkMutex.Unlock()
+	fake.AddOverlayNetworkStub = stub
+}
+
+func (fake *OverlayNetworkStore) AddOverlayNetworkArgsForCall(i int) controller.OverlayNetwork {
+	fake.addOverlayNetworkMutex.RLock()
+	defer fake.addOverlayNetworkMutex.RUnlock()
+	argsForCall := fake.addOverlayNetworkArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *OverlayNetworkStore) AddOverlayNetworkReturns(result1 error) {
+	fake.addOverlayNetworkMutex.Lock()
+	defer fake.addOverlayNetworkMutex.Unlock()
+	fake.AddOverlayNetworkStub = nil
+	fake.addOverlayNetworkReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *OverlayNetworkStore) AddOverlayNetworkReturnsOnCall(i int, result1 error) {
+	fake.addOverlayNetworkMutex.Lock()
+	defer fake.addOverlayNetworkMutex.Unlock()
+	fake.AddOverlayNetworkStub = nil
+	if fake.addOverlayNetworkReturnsOnCall == nil {
+		fake.addOverlayNetworkReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addOverlayNetworkReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *OverlayNetworkStore) AllReservations() ([]controller.Reservation, error) {
+	fake.allReservationsMutex.Lock()
+	ret, specificReturn := fake.allReservationsReturnsOnCall[len(fake.allReservationsArgsForCall)]
+	fake.allReservationsArgsForCall = append(fake.allReservationsArgsForCall, struct {
+	}{})
+	stub := fake.AllReservationsStub
+	fakeReturns := fake.allReservationsReturns
+	fake.recordInvocation("AllReservations", []interface{}{})
+	fake.allReservationsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *OverlayNetworkStore) AllReservationsCallCount() int {
+	fake.allReservationsMutex.RLock()
+	defer fake.allReservationsMutex.RUnlock()
+	return len(fake.allReservationsArgsForCall)
+}
+
+func (fake *OverlayNetworkStore) AllReservationsCalls(stub func() ([]controller.Reservation, error)) {
+	fake.allReservationsMutex.Lock()
+	defer fake.allReservationsMutex.Unlock()
+	fake.AllReservationsStub = stub
+}
+
+func (fake *OverlayNetworkStore) AllReservationsReturns(result1 []controller.Reservation, result2 error) {
+	fake.allReservationsMutex.Lock()
+	defer fake.allReservationsMutex.Unlock()
+	fake.AllReservationsStub = nil
+	fake.allReservationsReturns = struct {
+		result1 []controller.Reservation
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *OverlayNetworkStore) AllReservationsReturnsOnCall(i int, result1 []controller.Reservation, result2 error) {
+	fake.allReservationsMutex.Lock()
+	defer fake.allReservationsMutex.Unlock()
+	fake.AllReservationsStub = nil
+	if fake.allReservationsReturnsOnCall == nil {
+		fake.allReservationsReturnsOnCall = make(map[int]struct {
+			result1 []controller.Reservation
+			result2 error
+		})
+	}
+	fake.allReservationsReturnsOnCall[i] = struct {
+		result1 []controller.Reservation
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *OverlayNetworkStore) OverlayNetworks() ([]controller.OverlayNetwork, error) {
+	fake.overlayNetworksMutex.Lock()
+	ret, specificReturn := fake.overlayNetworksReturnsOnCall[len(fake.overlayNetworksArgsForCall)]
+	fake.overlayNetworksArgsForCall = append(fake.overlayNetworksArgsForCall, struct {
+	}{})
+	stub := fake.OverlayNetworksStub
+	fakeReturns := fake.overlayNetworksReturns
+	fake.recordInvocation("OverlayNetworks", []interface{}{})
+	fake.overlayNetworksMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *OverlayNetworkStore) OverlayNetworksCallCount() int {
+	fake.overlayNetworksMutex.RLock()
+	defer fake.overlayNetworksMutex.RUnlock()
+	return len(fake.overlayNetworksArgsForCall)
+}
+
+func (fake *OverlayNetworkStore) OverlayNetworksCalls(stub func() ([]controller.OverlayNetwork, error)) {
+	fake.overlayNetworksMutex.Lock()
+	defer fake.overlayNetworksMutex.Unlock()
+	fake.OverlayNetworksStub = stub
+}
+
+func (fake *OverlayNetworkStore) OverlayNetworksReturns(result1 []controller.OverlayNetwork, result2 error) {
+	fake.overlayNetworksMutex.Lock()
+	defer fake.overlayNetworksMutex.Unlock()
+	fake.OverlayNetworksStub = nil
+	fake.overlayNetworksReturns = struct {
+		result1 []controller.OverlayNetwork
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *OverlayNetworkStore) OverlayNetworksReturnsOnCall(i int, result1 []controller.OverlayNetwork, result2 error) {
+	fake.overlayNetworksMutex.Lock()
+	defer fake.overlayNetworksMutex.Unlock()
+	fake.OverlayNetworksStub = nil
+	if fake.overlayNetworksReturnsOnCall == nil {
+		fake.overlayNetworksReturnsOnCall = make(map[int]struct {
+			result1 []controller.OverlayNetwork
+			result2 error
+		})
+	}
+	fake.overlayNetworksReturnsOnCall[i] = struct {
+		result1 []controller.OverlayNetwork
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *OverlayNetworkStore) RetireOverlayNetwork(arg1 string, arg2 int) ([]controller.Lease, int, error) {
+	fake.retireOverlayNetworkMutex.Lock()
+	ret, specificReturn := fake.retireOverlayNetworkReturnsOnCall[len(fake.retireOverlayNetworkArgsForCall)]
+	fake.retireOverlayNetworkArgsForCall = append(fake.retireOverlayNetworkArgsForCall, struct {
+		arg1 string
+		arg2 int
+	}{arg1, arg2})
+	stub := fake.RetireOverlayNetworkStub
+	fakeReturns := fake.retireOverlayNetworkReturns
+	fake.recordInvocation("RetireOverlayNetwork", []interface{}{arg1, arg2})
+	fake.retireOverlayNetworkMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2, ret.result3
+	}
+	return fakeReturns.result1, fakeReturns.result2, fakeReturns.result3
+}
+
+func (fake *OverlayNetworkStore) RetireOverlayNetworkCallCount() int {
+	fake.retireOverlayNetworkMutex.RLock()
+	defer fake.retireOverlayNetworkMutex.RUnlock()
+	return len(fake.retireOverlayNetworkArgsForCall)
+}
+
+func (fake *OverlayNetworkStore) RetireOverlayNetworkCalls(stub func(string, int) ([]controller.Lease, int, error)) {
+	fake.retireOverlayNetworkMutex.Lock()
+	defer fake.retireOverlayNetworkMutex.Unlock()
+	fake.RetireOverlayNetworkStub = stub
+}
+
+func (fake *OverlayNetworkStore) RetireOverlayNetworkArgsForCall(i int) (string, int) {
+	fake.retireOverlayNetworkMutex.RLock()
+	defer fake.retireOverlayNetworkMutex.RUnlock()
+	argsForCall := fake.retireOverlayNetworkArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *OverlayNetworkStore) RetireOverlayNetworkReturns(result1 []controller.Lease, result2 int, result3 error) {
+	fake.retireOverlayNetworkMutex.Lock()
+	defer fake.retireOverlayNetworkMutex.Unlock()
+	fake.RetireOverlayNetworkStub = nil
+	fake.retireOverlayNetworkReturns = struct {
+		result1 []controller.Lease
+		result2 int
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *OverlayNetworkStore) RetireOverlayNetworkReturnsOnCall(i int, result1 []controller.Lease, result2 int, result3 error) {
+	fake.retireOverlayNetworkMutex.Lock()
+	defer fake.retireOverlayNetworkMutex.Unlock()
+	fake.RetireOverlayNetworkStub = nil
+	if fake.retireOverlayNetworkReturnsOnCall == nil {
+		fake.retireOverlayNetworkReturnsOnCall = make(map[int]struct {
+			result1 []controller.Lease
+			result2 int
+			result3 error
+		})
+	}
+	fake.retireOverlayNetworkReturnsOnCall[i] = struct {
+		result1 []controller.Lease
+		result2 int
+		result3 error
+	}{result1, result2, result3}
+}
+
+func (fake *OverlayNetworkStore) SetOverlayNetworkState(arg1 string, arg2 string) error {
+	fake.setOverlayNetworkStateMutex.Lock()
+	ret, specificReturn := fake.setOverlayNetworkStateReturnsOnCall[len(fake.setOverlayNetworkStateArgsForCall)]
+	fake.setOverlayNetworkStateArgsForCall = append(fake.setOverlayNetworkStateArgsForCall, struct {
+		arg1 string
+		arg2 string
+	}{arg1, arg2})
+	stub := fake.SetOverlayNetworkStateStub
+	fakeReturns := fake.setOverlayNetworkStateReturns
+	fake.recordInvocation("SetOverlayNetworkState", []interface{}{arg1, arg2})
+	fake.setOverlayNetworkStateMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *OverlayNetworkStore) SetOverlayNetworkStateCallCount() int {
+	fake.setOverlayNetworkStateMutex.RLock()
+	defer fake.setOverlayNetworkStateMutex.RUnlock()
+	return len(fake.setOverlayNetworkStateArgsForCall)
+}
+
+func (fake *OverlayNetworkStore) SetOverlayNetworkStateCalls(stub func(string, string) error) {
+	fake.setOverlayNetworkStateMutex.Lock()
+	defer fake.setOverlayNetworkStateMutex.Unlock()
+	fake.SetOverlayNetworkStateStub = stub
+}
+
+func (fake *OverlayNetworkStore) SetOverlayNetworkStateArgsForCall(i int) (string, string) {
+	fake.setOverlayNetworkStateMutex.RLock()
+	defer fake.setOverlayNetworkStateMutex.RUnlock()
+	argsForCall := fake.setOverlayNetworkStateArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *OverlayNetworkStore) SetOverlayNetworkStateReturns(result1 error) {
+	fake.setOverlayNetworkStateMutex.Lock()
+	defer fake.setOverlayNetworkStateMutex.Unlock()
+	fake.SetOverlayNetworkStateStub = nil
+	fake.setOverlayNetworkStateReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *OverlayNetworkStore) SetOverlayNetworkStateReturnsOnCall(i int, result1 error) {
+	fake.setOverlayNetworkStateMutex.Lock()
+	defer fake.setOverlayNetworkStateMutex.Unlock()
+	fake.SetOverlayNetworkStateStub = nil
+	if fake.setOverlayNetworkStateReturnsOnCall == nil {
+		fake.setOverlayNetworkStateReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.setOverlayNetworkStateReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *OverlayNetworkStore) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.addOverlayNetworkMutex.RLock()
+	defer fake.addOverlayNetworkMutex.RUnlock()
+	fake.allReservationsMutex.RLock()
+	defer fake.allReservationsMutex.RUnlock()
+	fake.overlayNetworksMutex.RLock()
+	defer fake.overlayNetworksMutex.RUnlock()
+	fake.retireOverlayNetworkMutex.RLock()
+	defer fake.retireOverlayNetworkMutex.RUnlock()
+	fake.setOverlayNetworkStateMutex.RLock()
+	defer fake.setOverlayNetworkStateMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *OverlayNetworkStore) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -0,0 +1,193 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+type ReservationRepository struct {
+	ReservationForOverlaySubnetStub        func(string) (*controller.Reservation, error)
+	reservationForOverlaySubnetMutex       sync.RWMutex
+	reservationForOverlaySubnetArgsForCall []struct {
+		arg1 string
+	}
+	reservationForOverlaySubnetReturns struct {
+		result1 *controller.Reservation
+		result2 error
+	}
+	reservationForOverlaySubnetReturnsOnCall map[int]struct {
+		result1 *controller.Reservation
+		result2 error
+	}
+	ReservationForUnderlayIPStub        func(string) (*controller.Reservation, error)
+	reservationForUnderlayIPMutex       sync.RWMutex
+	reservationForUnderlayIPArgsForCall []struct {
+		arg1 string
+	}
+	reservationForUnderlayIPReturns struct {
+		result1 *controller.Reservation
+		result2 error
+	}
+	reservationForUnderlayIPReturnsOnCall map[int]struct {
+		result1 *controller.Reservation
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *ReservationRepository) ReservationForOverlaySubnet(arg1 string) (*controller.Reservation, error) {
+	fake.reservationForOverlaySubnetMutex.Lock()
+	ret, specificReturn := fake.reservationForOverlaySubnetReturnsOnCall[len(fake.reservationForOverlaySubnetArgsForCall)]
+	fake.reservationForOverlaySubnetArgsForCall = append(fake.reservationForOverlaySubnetArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ReservationForOverlaySubnetStub
+	fakeReturns := fake.reservationForOverlaySubnetReturns
+	fake.recordInvocation("ReservationForOverlaySubnet", []interface{}{arg1})
+	fake.reservationForOverlaySubnetMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *ReservationRepository) ReservationForOverlaySubnetCallCount() int {
+	fake.reservationForOverlaySubnetMutex.RLock()
+	defer fake.reservationForOverlaySubnetMutex.RUnlock()
+	return len(fake.reservationForOverlaySubnetArgsForCall)
+}
+
+func (fake *ReservationRepository) ReservationForOverlaySubnetCalls(stub func(string) (*controller.Reservation, error)) {
+	fake.reservationForOverlaySubnetMutex.Lock()
+	defer fake.reservationForOverlaySubnetMutex.Unlock()
+	fake.ReservationForOverlaySubnetStub = stub
+}
+
+func (fake *ReservationRepository) ReservationForOverlaySubnetArgsForCall(i int) string {
+	fake.reservationForOverlaySubnetMutex.RLock()
+	defer fake.reservationForOverlaySubnetMutex.RUnlock()
+	argsForCall := fake.reservationForOverlaySubnetArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *ReservationRepository) ReservationForOverlaySubnetReturns(result1 *controller.Reservation, result2 error) {
+	fake.reservationForOverlaySubnetMutex.Lock()
+	defer fake.reservationForOverlaySubnetMutex.Unlock()
+	fake.ReservationForOverlaySubnetStub = nil
+	fake.reservationForOverlaySubnetReturns = struct {
+		result1 *controller.Reservation
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReservationRepository) ReservationForOverlaySubnetReturnsOnCall(i int, result1 *controller.Reservation, result2 error) {
+	fake.reservationForOverlaySubnetMutex.Lock()
+	defer fake.reservationForOverlaySubnetMutex.Unlock()
+	fake.ReservationForOverlaySubnetStub = nil
+	if fake.reservationForOverlaySubnetReturnsOnCall == nil {
+		fake.reservationForOverlaySubnetReturnsOnCall = make(map[int]struct {
+			result1 *controller.Reservation
+			result2 error
+		})
+	}
+	fake.reservationForOverlaySubnetReturnsOnCall[i] = struct {
+		result1 *controller.Reservation
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReservationRepository) ReservationForUnderlayIP(arg1 string) (*controller.Reservation, error) {
+	fake.reservationForUnderlayIPMutex.Lock()
+	ret, specificReturn := fake.reservationForUnderlayIPReturnsOnCall[len(fake.reservationForUnderlayIPArgsForCall)]
+	fake.reservationForUnderlayIPArgsForCall = append(fake.reservationForUnderlayIPArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.ReservationForUnderlayIPStub
+	fakeReturns := fake.reservationForUnderlayIPReturns
+	fake.recordInvocation("ReservationForUnderlayIP", []interface{}{arg1})
+	fake.reservationForUnderlayIPMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *ReservationRepository) ReservationForUnderlayIPCallCount() int {
+	fake.reservationForUnderlayIPMutex.RLock()
+	defer fake.reservationForUnderlayIPMutex.RUnlock()
+	return len(fake.reservationForUnderlayIPArgsForCall)
+}
+
+func (fake *ReservationRepository) ReservationForUnderlayIPCalls(stub func(string) (*controller.Reservation, error)) {
+	fake.reservationForUnderlayIPMutex.Lock()
+	defer fake.reservationForUnderlayIPMutex.Unlock()
+	fake.ReservationForUnderlayIPStub = stub
+}
+
+func (fake *ReservationRepository) ReservationForUnderlayIPArgsForCall(i int) string {
+	fake.reservationForUnderlayIPMutex.RLock()
+	defer fake.reservationForUnderlayIPMutex.RUnlock()
+	argsForCall := fake.reservationForUnderlayIPArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *ReservationRepository) ReservationForUnderlayIPReturns(result1 *controller.Reservation, result2 error) {
+	fake.reservationForUnderlayIPMutex.Lock()
+	defer fake.reservationForUnderlayIPMutex.Unlock()
+	fake.ReservationForUnderlayIPStub = nil
+	fake.reservationForUnderlayIPReturns = struct {
+		result1 *controller.Reservation
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReservationRepository) ReservationForUnderlayIPReturnsOnCall(i int, result1 *controller.Reservation, result2 error) {
+	fake.reservationForUnderlayIPMutex.Lock()
+	defer fake.reservationForUnderlayIPMutex.Unlock()
+	fake.ReservationForUnderlayIPStub = nil
+	if fake.reservationForUnderlayIPReturnsOnCall == nil {
+		fake.reservationForUnderlayIPReturnsOnCall = make(map[int]struct {
+			result1 *controller.Reservation
+			result2 error
+		})
+	}
+	fake.reservationForUnderlayIPReturnsOnCall[i] = struct {
+		result1 *controller.Reservation
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *ReservationRepository) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.reservationForOverlaySubnetMutex.RLock()
+	defer fake.reservationForOverlaySubnetMutex.RUnlock()
+	fake.reservationForUnderlayIPMutex.RLock()
+	defer fake.reservationForUnderlayIPMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *ReservationRepository) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
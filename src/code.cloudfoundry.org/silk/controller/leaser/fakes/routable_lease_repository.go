@@ -0,0 +1,105 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+type RoutableLeaseRepository struct {
+	RoutableLeasesStub        func() ([]controller.Lease, error)
+	routableLeasesMutex       sync.RWMutex
+	routableLeasesArgsForCall []struct {
+	}
+	routableLeasesReturns struct {
+		result1 []controller.Lease
+		result2 error
+	}
+	routableLeasesReturnsOnCall map[int]struct {
+		result1 []controller.Lease
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *RoutableLeaseRepository) RoutableLeases() ([]controller.Lease, error) {
+	fake.routableLeasesMutex.Lock()
+	ret, specificReturn := fake.routableLeasesReturnsOnCall[len(fake.routableLeasesArgsForCall)]
+	fake.routableLeasesArgsForCall = append(fake.routableLeasesArgsForCall, struct {
+	}{})
+	stub := fake.RoutableLeasesStub
+	fakeReturns := fake.routableLeasesReturns
+	fake.recordInvocation("RoutableLeases", []interface{}{})
+	fake.routableLeasesMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *RoutableLeaseRepository) RoutableLeasesCallCount() int {
+	fake.routableLeasesMutex.RLock()
+	defer fake.routableLeasesMutex.RUnlock()
+	return len(fake.routableLeasesArgsForCall)
+}
+
+func (fake *RoutableLeaseRepository) RoutableLeasesCalls(stub func() ([]controller.Lease, error)) {
+	fake.routableLeasesMutex.Lock()
+	defer fake.routableLeasesMutex.Unlock()
+	fake.RoutableLeasesStub = stub
+}
+
+func (fake *RoutableLeaseRepository) RoutableLeasesReturns(result1 []controller.Lease, result2 error) {
+	fake.routableLeasesMutex.Lock()
+	defer fake.routableLeasesMutex.Unlock()
+	fake.RoutableLeasesStub = nil
+	fake.routableLeasesReturns = struct {
+		result1 []controller.Lease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *RoutableLeaseRepository) RoutableLeasesReturnsOnCall(i int, result1 []controller.Lease, result2 error) {
+	fake.routableLeasesMutex.Lock()
+	defer fake.routableLeasesMutex.Unlock()
+	fake.RoutableLeasesStub = nil
+	if fake.routableLeasesReturnsOnCall == nil {
+		fake.routableLeasesReturnsOnCall = make(map[int]struct {
+			result1 []controller.Lease
+			result2 error
+		})
+	}
+	fake.routableLeasesReturnsOnCall[i] = struct {
+		result1 []controller.Lease
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *RoutableLeaseRepository) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.routableLeasesMutex.RLock()
+	defer fake.routableLeasesMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *RoutableLeaseRepository) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -17,11 +17,18 @@ type databaseHandler interface {
 	LastRenewedAtForUnderlayIP(string) (int64, error)
 	RenewLeaseForUnderlayIP(string) error
 	All() ([]controller.Lease, error)
-	AllBlockSubnets() ([]controller.Lease, error)
-	AllSingleIPSubnets() ([]controller.Lease, error)
 	AllActive(int) ([]controller.Lease, error)
-	OldestExpiredBlockSubnet(int) (*controller.Lease, error)
-	OldestExpiredSingleIP(int) (*controller.Lease, error)
+	AcquireLease(underlayIP string, singleOverlayIP bool, expirationSeconds int, allocate database.LeaseAllocator) (*controller.Lease, error)
+	AddIPv6Subnet(underlayIP string, allocate func(takenV6 []string) string) (*controller.Lease, error)
+	DeleteLease(underlayIP, eventType string) error
+	AddLeaseEvent(controller.LeaseEvent) error
+	LeaseEvents(controller.LeaseHistoryQuery) ([]controller.LeaseEvent, error)
+	LeaseForOverlaySubnet(string) (*controller.Lease, error)
+	ReserveSubnet(controller.Reservation, database.ReservationCheck) (bool, error)
+	DeleteReservation(string) error
+	AllReservations() ([]controller.Reservation, error)
+	ReservationForUnderlayIP(string) (*controller.Reservation, error)
+	ReservationForOverlaySubnet(string) (*controller.Reservation, error)
 }
 
 //go:generate counterfeiter -o fakes/lease_validator.go --fake-name LeaseValidator . leaseValidator
@@ -31,9 +38,12 @@ type leaseValidator interface {
 
 //go:generate counterfeiter -o fakes/cidr_pool.go --fake-name CIDRPool . cidrPool
 type cidrPool interface {
-	GetAvailableBlock([]string) string
-	GetAvailableSingleIP([]string) string
+	GetAvailableBlock(taken []string, underlayIP string) string
+	GetAvailableSingleIP(taken []string, underlayIP string) string
+	GetAvailableBlockV6(taken []string, underlayIP string) string
 	IsMember(string) bool
+	IsMemberV6(string) bool
+	IPv6Enabled() bool
 }
 
 //go:generate counterfeiter -o fakes/hardwareAddressGenerator.go --fake-name HardwareAddressGenerator . hardwareAddressGenerator
@@ -42,17 +52,16 @@ type hardwareAddressGenerator interface {
 }
 
 type LeaseController struct {
-	DatabaseHandler            databaseHandler
-	HardwareAddressGenerator   hardwareAddressGenerator
-	AcquireSubnetLeaseAttempts int
-	CIDRPool                   cidrPool
-	LeaseValidator             leaseValidator
-	LeaseExpirationSeconds     int
-	Logger                     lager.Logger
+	DatabaseHandler          databaseHandler
+	HardwareAddressGenerator hardwareAddressGenerator
+	CIDRPool                 cidrPool
+	LeaseValidator           leaseValidator
+	LeaseExpirationSeconds   int
+	Logger                   lager.Logger
 }
 
 func (c *LeaseController) ReleaseSubnetLease(underlayIP string) error {
-	err := c.DatabaseHandler.DeleteEntry(underlayIP)
+	err := c.DatabaseHandler.DeleteLease(underlayIP, controller.LeaseEventRelease)
 	if err == database.RecordNotAffectedError {
 		c.Logger.Debug("lease-not-found", lager.Data{"underlay_ip": underlayIP})
 		return nil
@@ -69,8 +78,8 @@ func (c *LeaseController) AcquireSubnetLease(underlayIP string, singleOverlayIP
 	var err error
 	var lease *controller.Lease
 
-	if net.ParseIP(underlayIP).To4() == nil {
-		return nil, fmt.Errorf("invalid ipv4 address: %s", underlayIP)
+	if net.ParseIP(underlayIP) == nil {
+		return nil, fmt.Errorf("invalid ip address: %s", underlayIP)
 	}
 
 	lease, err = c.DatabaseHandler.LeaseForUnderlayIP(underlayIP)
@@ -78,33 +87,62 @@ func (c *LeaseController) AcquireSubnetLease(underlayIP string, singleOverlayIP
 		return nil, fmt.Errorf("getting lease for underlay ip: %s", err)
 	}
 
+	reservation, err := c.DatabaseHandler.ReservationForUnderlayIP(underlayIP)
+	if err != nil {
+		return nil, fmt.Errorf("getting reservation for underlay ip: %s", err)
+	}
+
 	if lease != nil {
-		if c.CIDRPool.IsMember(lease.OverlaySubnet) {
-			c.Logger.Info("lease-renewed", lager.Data{"lease": lease})
-			return lease, nil
+		eventType := controller.LeaseEventPoolEviction
+		if c.isMember(*lease) {
+			if reservation == nil || reservation.OverlaySubnet == lease.OverlaySubnet {
+				lease, err = c.addMissingIPv6Subnet(*lease)
+				if err != nil {
+					return nil, err
+				}
+				c.Logger.Info("lease-renewed", lager.Data{"lease": lease})
+				return lease, nil
+			}
+			eventType = controller.LeaseEventReservationEviction
 		}
-		err := c.DatabaseHandler.DeleteEntry(underlayIP)
+		err := c.DatabaseHandler.DeleteLease(underlayIP, eventType)
 		if err != nil {
 			return nil, fmt.Errorf("deleting lease for underlay ip %s: %s", underlayIP, err)
 		}
 		c.Logger.Info("lease-deleted", lager.Data{"lease": lease})
 	}
 
-	for numErrs := 0; numErrs < c.AcquireSubnetLeaseAttempts; numErrs++ {
-		lease, err = c.tryAcquireLease(underlayIP, singleOverlayIP)
+	if reservation != nil {
+		lease, err = c.acquireReservedLease(*reservation, singleOverlayIP)
+		if err != nil {
+			return nil, fmt.Errorf("acquiring reserved lease: %s", err)
+		}
 		if lease != nil {
-			c.Logger.Info("lease-acquired", lager.Data{"lease": lease})
-			return lease, nil
+			c.Logger.Info("lease-acquired", lager.Data{"lease": lease, "reserved": true})
 		}
+		return lease, nil
 	}
 
-	return nil, err
+	// the acquisition is serialized with the others and reclaims expired
+	// leases itself, so trying again would not find a free subnet either
+	lease, err = c.acquireLease(underlayIP, singleOverlayIP)
+	if err != nil {
+		return nil, err
+	}
+	if lease != nil {
+		c.Logger.Info("lease-acquired", lager.Data{"lease": lease})
+	}
+	return lease, nil
 }
 
 func (c *LeaseController) RenewSubnetLease(lease controller.Lease) error {
 	err := c.LeaseValidator.Validate(lease)
+	if _, ok := err.(controller.NonRetriableError); ok {
+		c.recordRenewMismatch(lease)
+		return err
+	}
 	if err != nil {
-		return controller.NonRetriableError(err.Error())
+		return fmt.Errorf("validating lease: %s", err)
 	}
 
 	existingLease, err := c.DatabaseHandler.LeaseForUnderlayIP(lease.UnderlayIP)
@@ -114,9 +152,11 @@ func (c *LeaseController) RenewSubnetLease(lease controller.Lease) error {
 	if existingLease == nil {
 		err := c.DatabaseHandler.AddEntry(lease)
 		if err != nil {
+			c.recordRenewMismatch(lease)
 			return controller.NonRetriableError(err.Error())
 		}
-	} else if lease != *existingLease {
+	} else if !leaseMatches(lease, *existingLease) {
+		c.recordRenewMismatch(lease)
 		return controller.NonRetriableError("lease mismatch")
 	}
 
@@ -143,25 +183,39 @@ func (c *LeaseController) RoutableLeases() ([]controller.Lease, error) {
 	return leases, nil
 }
 
-func (c *LeaseController) tryAcquireLease(underlayIP string, singleOverlayIP bool) (*controller.Lease, error) {
-	var subnet string
-	if singleOverlayIP {
-		var err error
-		subnet, err = c.tryAcquireAvailableSingleIPSubnet(underlayIP)
-		if err != nil {
-			return nil, err
+func (c *LeaseController) acquireLease(underlayIP string, singleOverlayIP bool) (*controller.Lease, error) {
+	lease, err := c.DatabaseHandler.AcquireLease(underlayIP, singleOverlayIP, c.LeaseExpirationSeconds, func(taken, takenV6 []string, ipv4Only bool) (*controller.Lease, error) {
+		var subnet string
+		if singleOverlayIP {
+			subnet = c.CIDRPool.GetAvailableSingleIP(taken, underlayIP)
+		} else {
+			subnet = c.CIDRPool.GetAvailableBlock(taken, underlayIP)
 		}
-	} else {
-		var err error
-		subnet, err = c.tryAcquireAvailableBlockSubnet(underlayIP)
-		if err != nil {
-			return nil, err
+		if subnet == "" {
+			return nil, database.IPv4PoolExhaustedError
 		}
+
+		return c.newLease(underlayIP, subnet, singleOverlayIP || ipv4Only, takenV6)
+	})
+	if err != nil || lease == nil {
+		return lease, err
 	}
-	if subnet == "" {
-		return nil, nil
+	c.logMissingIPv6Subnet(*lease)
+	return lease, nil
+}
+
+// logMissingIPv6Subnet reports block leases that were acquired without an
+// IPv6 subnet because the IPv6 pool is exhausted. They get one the next time
+// their daemon acquires its lease, see addMissingIPv6Subnet.
+func (c *LeaseController) logMissingIPv6Subnet(lease controller.Lease) {
+	if lease.OverlaySubnetV6 == "" && c.CIDRPool.IPv6Enabled() && !isSingleIPSubnet(lease.OverlaySubnet) {
+		c.Logger.Info("ipv6-subnet-unavailable", lager.Data{"lease": lease})
 	}
+}
 
+// newLease adds an IPv6 subnet to block leases when IPv6 is enabled, unless
+// ipv4Only is set.
+func (c *LeaseController) newLease(underlayIP, subnet string, ipv4Only bool, takenV6 []string) (*controller.Lease, error) {
 	vtepIP, _, err := net.ParseCIDR(subnet)
 	if err != nil {
 		return nil, fmt.Errorf("parse subnet: %s", err)
@@ -177,69 +231,52 @@ func (c *LeaseController) tryAcquireLease(underlayIP string, singleOverlayIP boo
 		OverlayHardwareAddr: hwAddr.String(),
 	}
 
-	err = c.DatabaseHandler.AddEntry(lease)
-	if err != nil {
-		return nil, fmt.Errorf("adding lease entry: %s", err)
+	if !ipv4Only && c.CIDRPool.IPv6Enabled() {
+		lease.OverlaySubnetV6 = c.CIDRPool.GetAvailableBlockV6(takenV6, underlayIP)
+		if lease.OverlaySubnetV6 == "" {
+			return nil, database.IPv6PoolExhaustedError
+		}
 	}
+
 	return &lease, nil
 }
 
-func (c *LeaseController) tryAcquireAvailableSingleIPSubnet(underlayIP string) (string, error) {
-	var subnet string
-	leases, err := c.DatabaseHandler.AllSingleIPSubnets()
-	if err != nil {
-		return "", fmt.Errorf("getting all single ip subnets: %s", err)
-	}
-	var taken []string
-	for _, lease := range leases {
-		taken = append(taken, lease.OverlaySubnet)
+// addMissingIPv6Subnet gives block leases that were acquired before IPv6
+// was enabled an IPv6 subnet, so that upgraded cells become dual-stack the
+// next time their daemon acquires its lease. The lease stays IPv4-only while
+// the IPv6 pool is exhausted.
+func (c *LeaseController) addMissingIPv6Subnet(lease controller.Lease) (*controller.Lease, error) {
+	if lease.OverlaySubnetV6 != "" || !c.CIDRPool.IPv6Enabled() || isSingleIPSubnet(lease.OverlaySubnet) {
+		return &lease, nil
 	}
 
-	subnet = c.CIDRPool.GetAvailableSingleIP(taken)
-	if subnet == "" {
-		lease, err := c.DatabaseHandler.OldestExpiredSingleIP(c.LeaseExpirationSeconds)
-		if err != nil {
-			return "", fmt.Errorf("get oldest expired single ip: %s", err)
-		} else if lease == nil {
-			return "", nil
-		} else {
-			err := c.DatabaseHandler.DeleteEntry(lease.UnderlayIP)
-			if err != nil {
-				return "", fmt.Errorf("delete expired subnet: %s", err)
-			}
-			subnet = lease.OverlaySubnet
-		}
+	updated, err := c.DatabaseHandler.AddIPv6Subnet(lease.UnderlayIP, func(takenV6 []string) string {
+		return c.CIDRPool.GetAvailableBlockV6(takenV6, lease.UnderlayIP)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("adding ipv6 subnet: %s", err)
+	}
+	if updated == nil {
+		c.Logger.Info("ipv6-subnet-unavailable", lager.Data{"lease": lease})
+		return &lease, nil
 	}
 
-	return subnet, nil
+	c.Logger.Info("ipv6-subnet-added", lager.Data{"lease": updated})
+	return updated, nil
 }
 
-func (c *LeaseController) tryAcquireAvailableBlockSubnet(underlayIP string) (string, error) {
-	var subnet string
-	leases, err := c.DatabaseHandler.AllBlockSubnets()
-	if err != nil {
-		return "", fmt.Errorf("getting all subnets: %s", err)
-	}
-	var taken []string
-	for _, lease := range leases {
-		taken = append(taken, lease.OverlaySubnet)
+func (c *LeaseController) isMember(lease controller.Lease) bool {
+	if !c.CIDRPool.IsMember(lease.OverlaySubnet) {
+		return false
 	}
+	return lease.OverlaySubnetV6 == "" || c.CIDRPool.IsMemberV6(lease.OverlaySubnetV6)
+}
 
-	subnet = c.CIDRPool.GetAvailableBlock(taken)
-	if subnet == "" {
-		lease, err := c.DatabaseHandler.OldestExpiredBlockSubnet(c.LeaseExpirationSeconds)
-		if err != nil {
-			return "", fmt.Errorf("get oldest expired: %s", err)
-		} else if lease == nil {
-			return "", nil
-		} else {
-			err := c.DatabaseHandler.DeleteEntry(lease.UnderlayIP)
-			if err != nil {
-				return "", fmt.Errorf("delete expired subnet: %s", err) // test
-			}
-			subnet = lease.OverlaySubnet
-		}
+// leaseMatches allows daemons that predate IPv6 overlay support to keep
+// renewing a dual-stack lease without knowing its IPv6 subnet.
+func leaseMatches(requested, existing controller.Lease) bool {
+	if requested.OverlaySubnetV6 == "" {
+		existing.OverlaySubnetV6 = ""
 	}
-
-	return subnet, nil
+	return requested == existing
 }
@@ -46,17 +46,32 @@ var _ = Describe("LeaseController", func() {
 	})
 
 	Describe("AcquireSubnetLease", func() {
+		var takenBlocks, takenSingleIPs, takenV6 []string
+		var allocateErrs []error
+
 		BeforeEach(func() {
-			leaseController.AcquireSubnetLeaseAttempts = 10
 			leaseController.CIDRPool = cidrPool
-			databaseHandler.AllBlockSubnetsReturns([]controller.Lease{
-				{UnderlayIP: "10.244.11.22", OverlaySubnet: "10.255.33.0/24"},
-				{UnderlayIP: "10.244.22.33", OverlaySubnet: "10.255.44.0/24"},
-			}, nil)
-			databaseHandler.AllSingleIPSubnetsReturns([]controller.Lease{
-				{UnderlayIP: "10.244.33.44", OverlaySubnet: "10.255.0.11/32"},
-				{UnderlayIP: "10.244.44.55", OverlaySubnet: "10.255.0.12/32"},
-			}, nil)
+			takenBlocks = []string{"10.255.33.0/24", "10.255.44.0/24"}
+			takenSingleIPs = []string{"10.255.0.11/32", "10.255.0.12/32"}
+			takenV6 = nil
+			allocateErrs = nil
+			databaseHandler.AcquireLeaseStub = func(underlayIP string, singleOverlayIP bool, expirationSeconds int, allocate database.LeaseAllocator) (*controller.Lease, error) {
+				taken := takenBlocks
+				if singleOverlayIP {
+					taken = takenSingleIPs
+				}
+				lease, err := allocate(taken, takenV6, false)
+				allocateErrs = append(allocateErrs, err)
+				// like the database when there is nothing to reclaim
+				if err == database.IPv6PoolExhaustedError {
+					lease, err = allocate(taken, takenV6, true)
+					allocateErrs = append(allocateErrs, err)
+				}
+				if err == database.IPv4PoolExhaustedError {
+					return nil, nil
+				}
+				return lease, err
+			}
 			cidrPool.GetAvailableBlockReturns("10.255.76.0/24")
 			cidrPool.GetAvailableSingleIPReturns("10.255.0.13/32")
 		})
@@ -66,18 +81,15 @@ var _ = Describe("LeaseController", func() {
 				lease, err := leaseController.AcquireSubnetLease("10.244.55.66", true)
 				Expect(err).NotTo(HaveOccurred())
 				Expect(lease.OverlaySubnet).To(Equal("10.255.0.13/32"))
-			})
-
-			Context("when retrieving all single ip subnets fails", func() {
-				It("returns an error", func() {
-					databaseHandler.AllSingleIPSubnetsReturns(nil, errors.New("guava"))
 
-					_, err := leaseController.AcquireSubnetLease("10.244.5.6", true)
-					Expect(err).To(MatchError("getting all single ip subnets: guava"))
+				underlayIP, singleOverlayIP, expirationSeconds, _ := databaseHandler.AcquireLeaseArgsForCall(0)
+				Expect(underlayIP).To(Equal("10.244.55.66"))
+				Expect(singleOverlayIP).To(BeTrue())
+				Expect(expirationSeconds).To(Equal(42))
 
-					Expect(databaseHandler.AllSingleIPSubnetsCallCount()).To(Equal(10))
-					Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
-				})
+				taken, underlayIP := cidrPool.GetAvailableSingleIPArgsForCall(0)
+				Expect(taken).To(Equal([]string{"10.255.0.11/32", "10.255.0.12/32"}))
+				Expect(underlayIP).To(Equal("10.244.55.66"))
 			})
 
 			Context("when no single ip subnets are free", func() {
@@ -85,71 +97,14 @@ var _ = Describe("LeaseController", func() {
 					cidrPool.GetAvailableSingleIPReturns("")
 				})
 
-				Context("when there are no single ip expired leases", func() {
-					It("eventually returns an error after failing to find a free subnet", func() {
-						lease, err := leaseController.AcquireSubnetLease("10.244.5.6", true)
-						Expect(err).NotTo(HaveOccurred())
-						Expect(lease).To(BeNil())
-
-						Expect(databaseHandler.AllSingleIPSubnetsCallCount()).To(Equal(10))
-						Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
-
-						Expect(databaseHandler.OldestExpiredSingleIPCallCount()).To(Equal(10))
-						Expect(databaseHandler.OldestExpiredSingleIPArgsForCall(0)).To(Equal(42))
-					})
-				})
-
-				Context("when there is a single ip expired lease", func() {
-					var expiredLease *controller.Lease
-
-					BeforeEach(func() {
-						expiredLease = &controller.Lease{
-							UnderlayIP:          "10.244.5.60",
-							OverlaySubnet:       "10.255.0.6/32",
-							OverlayHardwareAddr: "ee:ee:0a:ff:4c:00",
-						}
-						databaseHandler.OldestExpiredSingleIPReturns(expiredLease, nil)
-					})
-
-					It("deletes the expired lease and assigns that lease's subnet", func() {
-						lease, err := leaseController.AcquireSubnetLease("10.244.5.6", true)
-						Expect(err).NotTo(HaveOccurred())
-						Expect(lease).To(Equal(&controller.Lease{
-							UnderlayIP:          "10.244.5.6",
-							OverlaySubnet:       expiredLease.OverlaySubnet,
-							OverlayHardwareAddr: expiredLease.OverlayHardwareAddr,
-						}))
-
-						Expect(databaseHandler.AllSingleIPSubnetsCallCount()).To(Equal(1))
-						Expect(databaseHandler.AddEntryCallCount()).To(Equal(1))
-						Expect(databaseHandler.DeleteEntryCallCount()).To(Equal(1))
-						Expect(databaseHandler.DeleteEntryArgsForCall(0)).To(Equal(expiredLease.UnderlayIP))
-
-						Expect(databaseHandler.OldestExpiredSingleIPCallCount()).To(Equal(1))
-						Expect(databaseHandler.OldestExpiredSingleIPArgsForCall(0)).To(Equal(42))
-					})
-
-					Context("when getting the oldest expired lease returns an error", func() {
-						BeforeEach(func() {
-							databaseHandler.OldestExpiredSingleIPReturns(nil, errors.New("guava"))
-						})
-
-						It("returns an error", func() {
-							_, err := leaseController.AcquireSubnetLease("10.244.5.6", true)
-							Expect(err).To(MatchError("get oldest expired single ip: guava"))
-						})
-					})
-
-					Context("when deleting the entry errors", func() {
-						BeforeEach(func() {
-							databaseHandler.DeleteEntryReturns(errors.New("guava"))
-						})
+				It("returns no lease without trying again", func() {
+					lease, err := leaseController.AcquireSubnetLease("10.244.5.6", true)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease).To(BeNil())
 
-						It("returns an error", func() {
-							_, err := leaseController.AcquireSubnetLease("10.244.5.6", true)
-							Expect(err).To(MatchError("delete expired subnet: guava"))
-						})
-					})
+					Expect(databaseHandler.AcquireLeaseCallCount()).To(Equal(1))
+					Expect(hardwareAddressGenerator.GenerateForVTEPCallCount()).To(Equal(0))
+					Expect(allocateErrs[0]).To(Equal(database.IPv4PoolExhaustedError))
 				})
 			})
 		})
@@ -166,26 +121,30 @@ var _ = Describe("LeaseController", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(loggedLease).To(MatchJSON(`{"underlay_ip":"10.244.5.6","overlay_subnet":"10.255.76.0/24","overlay_hardware_addr":"ee:ee:0a:ff:4c:00"}`))
 
-			Expect(databaseHandler.AllBlockSubnetsCallCount()).To(Equal(1))
-			Expect(cidrPool.GetAvailableBlockCallCount()).To(Equal(1))
-			Expect(cidrPool.GetAvailableBlockArgsForCall(0)).To(Equal([]string{"10.255.33.0/24", "10.255.44.0/24"}))
-			Expect(databaseHandler.AddEntryCallCount()).To(Equal(1))
+			Expect(databaseHandler.AcquireLeaseCallCount()).To(Equal(1))
+			underlayIP, singleOverlayIP, expirationSeconds, _ := databaseHandler.AcquireLeaseArgsForCall(0)
+			Expect(underlayIP).To(Equal("10.244.5.6"))
+			Expect(singleOverlayIP).To(BeFalse())
+			Expect(expirationSeconds).To(Equal(42))
 
-			savedLease := databaseHandler.AddEntryArgsForCall(0)
-			Expect(savedLease.UnderlayIP).To(Equal("10.244.5.6"))
-			Expect(savedLease.OverlaySubnet).To(Equal("10.255.76.0/24"))
-			Expect(savedLease.OverlayHardwareAddr).To(Equal("ee:ee:0a:ff:4c:00"))
+			Expect(cidrPool.GetAvailableBlockCallCount()).To(Equal(1))
+			taken, underlayIP := cidrPool.GetAvailableBlockArgsForCall(0)
+			Expect(taken).To(Equal([]string{"10.255.33.0/24", "10.255.44.0/24"}))
+			Expect(underlayIP).To(Equal("10.244.5.6"))
+			Expect(hardwareAddressGenerator.GenerateForVTEPArgsForCall(0).Equal(net.ParseIP("10.255.76.0"))).To(BeTrue())
 		})
 
-		Context("when getting all taken subnets returns an error", func() {
-			It("returns an error", func() {
-				databaseHandler.AllBlockSubnetsReturns(nil, errors.New("guava"))
+		Context("when acquiring the lease in the database fails", func() {
+			BeforeEach(func() {
+				databaseHandler.AcquireLeaseStub = nil
+				databaseHandler.AcquireLeaseReturns(nil, errors.New("guava"))
+			})
 
+			It("returns the error without trying again", func() {
 				_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
-				Expect(err).To(MatchError("getting all subnets: guava"))
+				Expect(err).To(MatchError("guava"))
 
-				Expect(databaseHandler.AllBlockSubnetsCallCount()).To(Equal(10))
-				Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+				Expect(databaseHandler.AcquireLeaseCallCount()).To(Equal(1))
 			})
 		})
 
@@ -194,76 +153,225 @@ var _ = Describe("LeaseController", func() {
 				cidrPool.GetAvailableBlockReturns("")
 			})
 
-			Context("when there are no expired leases", func() {
-				It("eventually returns an error after failing to find a free subnet", func() {
-					lease, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
+			It("returns no lease without trying again", func() {
+				lease, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lease).To(BeNil())
+				Expect(logger.Logs()).To(BeEmpty())
+
+				Expect(databaseHandler.AcquireLeaseCallCount()).To(Equal(1))
+				Expect(cidrPool.GetAvailableBlockCallCount()).To(Equal(1))
+				Expect(allocateErrs[0]).To(Equal(database.IPv4PoolExhaustedError))
+			})
+		})
+
+		Context("when the database offers the subnets of an expired lease on a later attempt", func() {
+			BeforeEach(func() {
+				databaseHandler.AcquireLeaseStub = func(underlayIP string, singleOverlayIP bool, expirationSeconds int, allocate database.LeaseAllocator) (*controller.Lease, error) {
+					lease, err := allocate(takenBlocks, takenV6, false)
+					if err != database.IPv4PoolExhaustedError {
+						return lease, err
+					}
+					return allocate(takenBlocks[1:], takenV6, false)
+				}
+				cidrPool.GetAvailableBlockStub = func(taken []string, underlayIP string) string {
+					if len(taken) == 2 {
+						return ""
+					}
+					return "10.255.33.0/24"
+				}
+			})
+
+			It("assigns the reclaimed subnet", func() {
+				lease, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lease.OverlaySubnet).To(Equal("10.255.33.0/24"))
+				Expect(databaseHandler.AcquireLeaseCallCount()).To(Equal(1))
+			})
+		})
+
+		Context("when the underlay ip is not an IP addr", func() {
+			It("returns an error", func() {
+				_, err := leaseController.AcquireSubnetLease("banana", false)
+				Expect(err).To(MatchError("invalid ip address: banana"))
+				Expect(databaseHandler.AcquireLeaseCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the underlay ip is an IPv6 addr", func() {
+			It("acquires a lease", func() {
+				lease, err := leaseController.AcquireSubnetLease("fd12:3456::2c", false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lease.UnderlayIP).To(Equal("fd12:3456::2c"))
+				Expect(lease.OverlaySubnet).To(Equal("10.255.76.0/24"))
+			})
+		})
+
+		Context("when ipv6 overlay networks are configured", func() {
+			BeforeEach(func() {
+				cidrPool.IPv6EnabledReturns(true)
+				takenV6 = []string{"fd00:0:0:21::/64"}
+				cidrPool.GetAvailableBlockV6Returns("fd00:0:0:4c::/64")
+			})
+
+			It("acquires a dual-stack lease", func() {
+				lease, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lease).To(Equal(&controller.Lease{
+					UnderlayIP:          "10.244.5.6",
+					OverlaySubnet:       "10.255.76.0/24",
+					OverlayHardwareAddr: "ee:ee:0a:ff:4c:00",
+					OverlaySubnetV6:     "fd00:0:0:4c::/64",
+				}))
+
+				Expect(cidrPool.GetAvailableBlockV6CallCount()).To(Equal(1))
+				taken, underlayIP := cidrPool.GetAvailableBlockV6ArgsForCall(0)
+				Expect(taken).To(Equal([]string{"fd00:0:0:21::/64"}))
+				Expect(underlayIP).To(Equal("10.244.5.6"))
+			})
+
+			It("does not assign an ipv6 subnet to single ip leases", func() {
+				lease, err := leaseController.AcquireSubnetLease("10.244.55.66", true)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lease.OverlaySubnetV6).To(BeEmpty())
+				Expect(cidrPool.GetAvailableBlockV6CallCount()).To(Equal(0))
+			})
+
+			Context("when no ipv6 subnets are free", func() {
+				BeforeEach(func() {
+					cidrPool.GetAvailableBlockV6Returns("")
+				})
+
+				It("tells the database that only the ipv6 pool is exhausted", func() {
+					_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
 					Expect(err).NotTo(HaveOccurred())
-					Expect(lease).To(BeNil())
+					Expect(allocateErrs[0]).To(Equal(database.IPv6PoolExhaustedError))
+				})
 
-					Expect(databaseHandler.AllBlockSubnetsCallCount()).To(Equal(10))
-					Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+				Context("when the database has no ipv6 subnet to reclaim", func() {
+					It("acquires an ipv4-only lease and logs it", func() {
+						lease, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
+						Expect(err).NotTo(HaveOccurred())
+						Expect(lease).To(Equal(&controller.Lease{
+							UnderlayIP:          "10.244.5.6",
+							OverlaySubnet:       "10.255.76.0/24",
+							OverlayHardwareAddr: "ee:ee:0a:ff:4c:00",
+						}))
+						Expect(allocateErrs).To(Equal([]error{database.IPv6PoolExhaustedError, nil}))
+						Expect(cidrPool.GetAvailableBlockV6CallCount()).To(Equal(1))
 
-					Expect(databaseHandler.OldestExpiredBlockSubnetCallCount()).To(Equal(10))
-					Expect(databaseHandler.OldestExpiredBlockSubnetArgsForCall(0)).To(Equal(42))
+						Expect(logger.Logs()).To(HaveLen(2))
+						Expect(logger.Logs()[0].Message).To(Equal("test.ipv6-subnet-unavailable"))
+						Expect(logger.Logs()[1].Message).To(Equal("test.lease-acquired"))
+					})
 				})
 			})
 
-			Context("when there is an expired lease", func() {
-				var expiredLease *controller.Lease
-
+			Context("when an existing lease has an ipv6 subnet that is no longer in the pool", func() {
 				BeforeEach(func() {
-					expiredLease = &controller.Lease{
-						UnderlayIP:          "10.244.5.60",
+					databaseHandler.LeaseForUnderlayIPReturns(&controller.Lease{
+						UnderlayIP:          "10.244.5.6",
 						OverlaySubnet:       "10.255.76.0/24",
 						OverlayHardwareAddr: "ee:ee:0a:ff:4c:00",
-					}
-					databaseHandler.OldestExpiredBlockSubnetReturns(expiredLease, nil)
+						OverlaySubnetV6:     "fd01:0:0:4c::/64",
+					}, nil)
+					cidrPool.IsMemberReturns(true)
+					cidrPool.IsMemberV6Returns(false)
 				})
 
-				It("Deletes the expired lease and assigns that lease's subnet", func() {
+				It("deletes the previously assigned lease and assigns a new one", func() {
 					lease, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
 					Expect(err).NotTo(HaveOccurred())
-					Expect(lease).To(Equal(&controller.Lease{
-						UnderlayIP:          "10.244.5.6",
-						OverlaySubnet:       expiredLease.OverlaySubnet,
-						OverlayHardwareAddr: expiredLease.OverlayHardwareAddr,
-					}))
+					Expect(lease.OverlaySubnetV6).To(Equal("fd00:0:0:4c::/64"))
 
-					Expect(databaseHandler.AllBlockSubnetsCallCount()).To(Equal(1))
-					Expect(databaseHandler.AddEntryCallCount()).To(Equal(1))
-					Expect(databaseHandler.DeleteEntryCallCount()).To(Equal(1))
-					Expect(databaseHandler.DeleteEntryArgsForCall(0)).To(Equal(expiredLease.UnderlayIP))
+					Expect(cidrPool.IsMemberV6ArgsForCall(0)).To(Equal("fd01:0:0:4c::/64"))
+					Expect(databaseHandler.DeleteLeaseCallCount()).To(Equal(1))
+					Expect(databaseHandler.AcquireLeaseCallCount()).To(Equal(1))
+				})
+			})
+		})
 
-					Expect(databaseHandler.OldestExpiredBlockSubnetCallCount()).To(Equal(1))
-					Expect(databaseHandler.OldestExpiredBlockSubnetArgsForCall(0)).To(Equal(42))
+		Context("when an ipv4-only lease has already been assigned and ipv6 is enabled", func() {
+			var existingLease *controller.Lease
+			BeforeEach(func() {
+				existingLease = &controller.Lease{
+					UnderlayIP:          "10.244.5.6",
+					OverlaySubnet:       "10.255.76.0/24",
+					OverlayHardwareAddr: "ee:ee:0a:ff:4c:00",
+				}
+				databaseHandler.LeaseForUnderlayIPReturns(existingLease, nil)
+				cidrPool.IsMemberReturns(true)
+				cidrPool.IPv6EnabledReturns(true)
+				cidrPool.GetAvailableBlockV6Returns("fd00:0:0:4c::/64")
+				databaseHandler.AddIPv6SubnetStub = func(underlayIP string, allocate func([]string) string) (*controller.Lease, error) {
+					subnetV6 := allocate([]string{"fd00:0:0:21::/64"})
+					if subnetV6 == "" {
+						return nil, nil
+					}
+					lease := *existingLease
+					lease.OverlaySubnetV6 = subnetV6
+					return &lease, nil
+				}
+			})
+
+			It("adds an ipv6 subnet to the lease", func() {
+				lease, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lease).To(Equal(&controller.Lease{
+					UnderlayIP:          "10.244.5.6",
+					OverlaySubnet:       "10.255.76.0/24",
+					OverlayHardwareAddr: "ee:ee:0a:ff:4c:00",
+					OverlaySubnetV6:     "fd00:0:0:4c::/64",
+				}))
+
+				Expect(databaseHandler.AddIPv6SubnetCallCount()).To(Equal(1))
+				underlayIP, _ := databaseHandler.AddIPv6SubnetArgsForCall(0)
+				Expect(underlayIP).To(Equal("10.244.5.6"))
+				taken, underlayIP := cidrPool.GetAvailableBlockV6ArgsForCall(0)
+				Expect(taken).To(Equal([]string{"fd00:0:0:21::/64"}))
+				Expect(underlayIP).To(Equal("10.244.5.6"))
+
+				Expect(logger.Logs()[0].Message).To(Equal("test.ipv6-subnet-added"))
+				Expect(databaseHandler.DeleteLeaseCallCount()).To(Equal(0))
+				Expect(databaseHandler.AcquireLeaseCallCount()).To(Equal(0))
+			})
+
+			Context("when no ipv6 subnets are free", func() {
+				BeforeEach(func() {
+					cidrPool.GetAvailableBlockV6Returns("")
 				})
 
-				Context("when getting the oldest expired lease returns an error", func() {
-					BeforeEach(func() {
-						databaseHandler.OldestExpiredBlockSubnetReturns(nil, errors.New("guava"))
-					})
-					It("returns an error", func() {
-						_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
-						Expect(err).To(MatchError("get oldest expired: guava"))
-					})
+				It("keeps the ipv4-only lease", func() {
+					lease, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease).To(Equal(existingLease))
+					Expect(logger.Logs()[0].Message).To(Equal("test.ipv6-subnet-unavailable"))
 				})
+			})
 
-				Context("when deleting the entry errors", func() {
-					BeforeEach(func() {
-						databaseHandler.DeleteEntryReturns(errors.New("guava"))
-					})
-					It("returns an error", func() {
-						_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
-						Expect(err).To(MatchError("delete expired subnet: guava"))
-					})
+			Context("when the lease is a single ip lease", func() {
+				BeforeEach(func() {
+					existingLease.OverlaySubnet = "10.255.0.13/32"
+				})
+
+				It("does not add an ipv6 subnet", func() {
+					lease, err := leaseController.AcquireSubnetLease("10.244.5.6", true)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease).To(Equal(existingLease))
+					Expect(databaseHandler.AddIPv6SubnetCallCount()).To(Equal(0))
 				})
 			})
-		})
 
-		Context("when the underlay ip is not an IPv4 addr", func() {
-			It("returns an error", func() {
-				_, err := leaseController.AcquireSubnetLease("banana", false)
-				Expect(err).To(MatchError("invalid ipv4 address: banana"))
+			Context("when adding the ipv6 subnet fails", func() {
+				BeforeEach(func() {
+					databaseHandler.AddIPv6SubnetStub = nil
+					databaseHandler.AddIPv6SubnetReturns(nil, errors.New("papaya"))
+				})
+
+				It("returns an error", func() {
+					_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
+					Expect(err).To(MatchError("adding ipv6 subnet: papaya"))
+				})
 			})
 		})
 
@@ -271,12 +379,11 @@ var _ = Describe("LeaseController", func() {
 			BeforeEach(func() {
 				cidrPool.GetAvailableBlockReturns("foo")
 			})
-			It("eventually returns an error after failing to find a free subnet", func() {
+			It("returns an error", func() {
 				_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
 				Expect(err).To(MatchError("parse subnet: invalid CIDR address: foo"))
 
-				Expect(databaseHandler.AllBlockSubnetsCallCount()).To(Equal(10))
-				Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+				Expect(databaseHandler.AcquireLeaseCallCount()).To(Equal(1))
 			})
 		})
 
@@ -284,23 +391,11 @@ var _ = Describe("LeaseController", func() {
 			BeforeEach(func() {
 				hardwareAddressGenerator.GenerateForVTEPReturns(nil, errors.New("guava"))
 			})
-			It("eventually returns an error after failing to find a free subnet", func() {
-				_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
-				Expect(err).To(MatchError("generate hardware address: guava"))
-
-				Expect(databaseHandler.AllBlockSubnetsCallCount()).To(Equal(10))
-				Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
-			})
-		})
-
-		Context("when adding the lease entry fails", func() {
 			It("returns an error", func() {
-				databaseHandler.AddEntryReturns(errors.New("guava"))
-
 				_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
-				Expect(err).To(MatchError("adding lease entry: guava"))
+				Expect(err).To(MatchError("generate hardware address: guava"))
 
-				Expect(databaseHandler.AddEntryCallCount()).To(Equal(10))
+				Expect(databaseHandler.AcquireLeaseCallCount()).To(Equal(1))
 			})
 		})
 
@@ -326,7 +421,7 @@ var _ = Describe("LeaseController", func() {
 				Expect(err).NotTo(HaveOccurred())
 				Expect(loggedLease).To(MatchJSON(`{"underlay_ip":"10.244.5.6","overlay_subnet":"10.255.76.0/24","overlay_hardware_addr":"ee:ee:0a:ff:4c:00"}`))
 
-				Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+				Expect(databaseHandler.AcquireLeaseCallCount()).To(Equal(0))
 			})
 		})
 
@@ -352,18 +447,134 @@ var _ = Describe("LeaseController", func() {
 				Expect(err).NotTo(HaveOccurred())
 				Expect(deletedLease).To(MatchJSON(`{"underlay_ip":"10.244.5.6","overlay_subnet":"10.254.76.0/24","overlay_hardware_addr":"ee:ee:0a:fe:4c:00"}`))
 
-				Expect(databaseHandler.DeleteEntryCallCount()).To(Equal(1))
-				Expect(databaseHandler.AddEntryCallCount()).To(Equal(1))
+				Expect(databaseHandler.DeleteLeaseCallCount()).To(Equal(1))
+				deletedUnderlayIP, eventType := databaseHandler.DeleteLeaseArgsForCall(0)
+				Expect(deletedUnderlayIP).To(Equal("10.244.5.6"))
+				Expect(eventType).To(Equal(controller.LeaseEventPoolEviction))
+				Expect(databaseHandler.AcquireLeaseCallCount()).To(Equal(1))
 			})
 
 			Context("when deleting the existing entry fails", func() {
 				BeforeEach(func() {
-					databaseHandler.DeleteEntryReturns(fmt.Errorf("peanut"))
+					databaseHandler.DeleteLeaseReturns(fmt.Errorf("peanut"))
 				})
 				It("returns an error", func() {
 					_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
 					Expect(err).To(MatchError("deleting lease for underlay ip 10.244.5.6: peanut"))
-					Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+					Expect(databaseHandler.AcquireLeaseCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when the underlay ip has a reservation", func() {
+			BeforeEach(func() {
+				databaseHandler.ReservationForUnderlayIPReturns(&controller.Reservation{
+					UnderlayIP:    "10.244.5.6",
+					OverlaySubnet: "10.255.99.0/24",
+				}, nil)
+			})
+
+			It("acquires the reserved subnet", func() {
+				lease, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(lease).To(Equal(&controller.Lease{
+					UnderlayIP:          "10.244.5.6",
+					OverlaySubnet:       "10.255.99.0/24",
+					OverlayHardwareAddr: "ee:ee:0a:ff:4c:00",
+				}))
+
+				Expect(databaseHandler.ReservationForUnderlayIPArgsForCall(0)).To(Equal("10.244.5.6"))
+				Expect(cidrPool.GetAvailableBlockCallCount()).To(Equal(0))
+				Expect(databaseHandler.AcquireLeaseCallCount()).To(Equal(1))
+				underlayIP, singleOverlayIP, _, _ := databaseHandler.AcquireLeaseArgsForCall(0)
+				Expect(underlayIP).To(Equal("10.244.5.6"))
+				Expect(singleOverlayIP).To(BeFalse())
+			})
+
+			Context("when ipv6 overlay networks are configured", func() {
+				BeforeEach(func() {
+					cidrPool.IPv6EnabledReturns(true)
+					takenV6 = []string{"fd00:0:0:21::/64"}
+					cidrPool.GetAvailableBlockV6Returns("fd00:0:0:4c::/64")
+				})
+
+				It("acquires an ipv6 subnet alongside the reserved subnet", func() {
+					lease, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease.OverlaySubnet).To(Equal("10.255.99.0/24"))
+					Expect(lease.OverlaySubnetV6).To(Equal("fd00:0:0:4c::/64"))
+
+					taken, _ := cidrPool.GetAvailableBlockV6ArgsForCall(0)
+					Expect(taken).To(Equal([]string{"fd00:0:0:21::/64"}))
+				})
+			})
+
+			Context("when the cell already holds the reserved subnet", func() {
+				BeforeEach(func() {
+					databaseHandler.LeaseForUnderlayIPReturns(&controller.Lease{
+						UnderlayIP:          "10.244.5.6",
+						OverlaySubnet:       "10.255.99.0/24",
+						OverlayHardwareAddr: "ee:ee:0a:ff:63:00",
+					}, nil)
+					cidrPool.IsMemberReturns(true)
+				})
+				It("returns the existing lease", func() {
+					lease, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease.OverlaySubnet).To(Equal("10.255.99.0/24"))
+					Expect(databaseHandler.DeleteLeaseCallCount()).To(Equal(0))
+					Expect(databaseHandler.AcquireLeaseCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when the cell holds a different subnet", func() {
+				BeforeEach(func() {
+					databaseHandler.LeaseForUnderlayIPReturns(&controller.Lease{
+						UnderlayIP:          "10.244.5.6",
+						OverlaySubnet:       "10.255.76.0/24",
+						OverlayHardwareAddr: "ee:ee:0a:ff:4c:00",
+					}, nil)
+					cidrPool.IsMemberReturns(true)
+				})
+				It("replaces the lease with the reserved subnet", func() {
+					lease, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(lease.OverlaySubnet).To(Equal("10.255.99.0/24"))
+					Expect(databaseHandler.DeleteLeaseCallCount()).To(Equal(1))
+					deletedUnderlayIP, eventType := databaseHandler.DeleteLeaseArgsForCall(0)
+					Expect(deletedUnderlayIP).To(Equal("10.244.5.6"))
+					Expect(eventType).To(Equal(controller.LeaseEventReservationEviction))
+					Expect(databaseHandler.AcquireLeaseCallCount()).To(Equal(1))
+				})
+			})
+
+			Context("when a single ip lease is requested", func() {
+				It("returns an error", func() {
+					_, err := leaseController.AcquireSubnetLease("10.244.5.6", true)
+					Expect(err).To(MatchError("acquiring reserved lease: reservation of 10.255.99.0/24 for 10.244.5.6 does not match the requested lease type"))
+					Expect(databaseHandler.AcquireLeaseCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when acquiring the reserved lease in the database fails", func() {
+				BeforeEach(func() {
+					databaseHandler.AcquireLeaseStub = nil
+					databaseHandler.AcquireLeaseReturns(nil, errors.New("kiwi"))
+				})
+				It("returns an error", func() {
+					_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
+					Expect(err).To(MatchError("acquiring reserved lease: kiwi"))
+				})
+			})
+
+			Context("when getting the reservation fails", func() {
+				BeforeEach(func() {
+					databaseHandler.ReservationForUnderlayIPReturns(nil, errors.New("kiwi"))
+				})
+				It("returns an error", func() {
+					_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
+					Expect(err).To(MatchError("getting reservation for underlay ip: kiwi"))
+					Expect(databaseHandler.AcquireLeaseCallCount()).To(Equal(0))
 				})
 			})
 		})
@@ -375,7 +586,7 @@ var _ = Describe("LeaseController", func() {
 			It("returns an error", func() {
 				_, err := leaseController.AcquireSubnetLease("10.244.5.6", false)
 				Expect(err).To(MatchError("getting lease for underlay ip: fruit"))
-				Expect(databaseHandler.AddEntryCallCount()).To(Equal(0))
+				Expect(databaseHandler.AcquireLeaseCallCount()).To(Equal(0))
 			})
 		})
 	})
@@ -428,6 +639,73 @@ var _ = Describe("LeaseController", func() {
 				Expect(err).To(BeAssignableToTypeOf(controller.NonRetriableError("")))
 				Expect(err).To(MatchError("lease mismatch"))
 			})
+
+			It("records a renew-mismatch event with the holder of the requested subnet", func() {
+				databaseHandler.LeaseForOverlaySubnetReturns(&controller.Lease{
+					UnderlayIP:    "10.244.99.99",
+					OverlaySubnet: leaseToRenew.OverlaySubnet,
+				}, nil)
+
+				_ = leaseController.RenewSubnetLease(leaseToRenew)
+
+				Expect(databaseHandler.LeaseForOverlaySubnetArgsForCall(0)).To(Equal("10.255.33.0/24"))
+				Expect(databaseHandler.AddLeaseEventCallCount()).To(Equal(1))
+				Expect(databaseHandler.AddLeaseEventArgsForCall(0)).To(Equal(controller.LeaseEvent{
+					Type:               controller.LeaseEventRenewMismatch,
+					UnderlayIP:         "10.244.11.22",
+					OverlaySubnet:      "10.255.33.0/24",
+					PreviousUnderlayIP: "10.244.99.99",
+				}))
+			})
+
+			Context("when recording the event fails", func() {
+				BeforeEach(func() {
+					databaseHandler.LeaseForOverlaySubnetReturns(nil, errors.New("kiwi"))
+					databaseHandler.AddLeaseEventReturns(errors.New("banana"))
+				})
+				It("logs the errors and still rejects the renewal", func() {
+					err := leaseController.RenewSubnetLease(leaseToRenew)
+					Expect(err).To(MatchError("lease mismatch"))
+					Expect(databaseHandler.AddLeaseEventArgsForCall(0).PreviousUnderlayIP).To(BeEmpty())
+					Expect(logger.Logs()).To(HaveLen(2))
+					Expect(logger.Logs()[0].Message).To(Equal("test.get-lease-for-overlay-subnet"))
+					Expect(logger.Logs()[1].Message).To(Equal("test.add-lease-event"))
+				})
+			})
+		})
+
+		Context("when the existing lease has an ipv6 subnet", func() {
+			var existingLease *controller.Lease
+			BeforeEach(func() {
+				existingLease = &controller.Lease{
+					UnderlayIP:          leaseToRenew.UnderlayIP,
+					OverlaySubnet:       leaseToRenew.OverlaySubnet,
+					OverlayHardwareAddr: leaseToRenew.OverlayHardwareAddr,
+					OverlaySubnetV6:     "fd00:0:0:21::/64",
+				}
+				databaseHandler.LeaseForUnderlayIPReturns(existingLease, nil)
+			})
+
+			It("renews the lease when the ipv6 subnet matches", func() {
+				leaseToRenew.OverlaySubnetV6 = "fd00:0:0:21::/64"
+				err := leaseController.RenewSubnetLease(leaseToRenew)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(databaseHandler.RenewLeaseForUnderlayIPCallCount()).To(Equal(1))
+			})
+
+			It("renews the lease when the renewal does not include an ipv6 subnet", func() {
+				err := leaseController.RenewSubnetLease(leaseToRenew)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(databaseHandler.RenewLeaseForUnderlayIPCallCount()).To(Equal(1))
+			})
+
+			It("returns a non-retriable error when the ipv6 subnet differs", func() {
+				leaseToRenew.OverlaySubnetV6 = "fd00:0:0:22::/64"
+				err := leaseController.RenewSubnetLease(leaseToRenew)
+				Expect(err).To(BeAssignableToTypeOf(controller.NonRetriableError("")))
+				Expect(err).To(MatchError("lease mismatch"))
+				Expect(databaseHandler.RenewLeaseForUnderlayIPCallCount()).To(Equal(0))
+			})
 		})
 
 		Context("when the existing lease does not exist", func() {
@@ -466,19 +744,38 @@ var _ = Describe("LeaseController", func() {
 					Expect(err).To(HaveOccurred())
 					Expect(err).To(BeAssignableToTypeOf(controller.NonRetriableError("")))
 					Expect(err).To(MatchError("pineapple"))
+					Expect(databaseHandler.AddLeaseEventArgsForCall(0).Type).To(Equal(controller.LeaseEventRenewMismatch))
 				})
 			})
 		})
 
 		Context("when the lease is not valid", func() {
 			BeforeEach(func() {
-				validator.ValidateReturns(errors.New("banana"))
+				validator.ValidateReturns(controller.NonRetriableError("banana"))
 			})
 			It("returns a non-retriable error", func() {
 				err := leaseController.RenewSubnetLease(leaseToRenew)
 				Expect(err).To(HaveOccurred())
 				Expect(err).To(BeAssignableToTypeOf(controller.NonRetriableError("")))
 				Expect(err).To(MatchError("banana"))
+				Expect(databaseHandler.AddLeaseEventArgsForCall(0).Type).To(Equal(controller.LeaseEventRenewMismatch))
+			})
+		})
+
+		Context("when the reservations cannot be read", func() {
+			BeforeEach(func() {
+				reservationRepository := &fakes.ReservationRepository{}
+				reservationRepository.ReservationForUnderlayIPReturns(nil, errors.New("banana"))
+				leaseController.LeaseValidator = &leaser.LeaseValidator{
+					ReservationRepository: reservationRepository,
+				}
+			})
+			It("returns a retriable error, so that the cell keeps its lease", func() {
+				err := leaseController.RenewSubnetLease(leaseToRenew)
+				Expect(err).To(MatchError("validating lease: getting reservation for underlay ip: banana"))
+				Expect(err).NotTo(BeAssignableToTypeOf(controller.NonRetriableError("")))
+				Expect(databaseHandler.AddLeaseEventCallCount()).To(Equal(0))
+				Expect(databaseHandler.RenewLeaseForUnderlayIPCallCount()).To(Equal(0))
 			})
 		})
 
@@ -524,8 +821,10 @@ var _ = Describe("LeaseController", func() {
 			err := leaseController.ReleaseSubnetLease(underlayIP)
 			Expect(err).NotTo(HaveOccurred())
 
-			Expect(databaseHandler.DeleteEntryCallCount()).To(Equal(1))
-			Expect(databaseHandler.DeleteEntryArgsForCall(0)).To(Equal(underlayIP))
+			Expect(databaseHandler.DeleteLeaseCallCount()).To(Equal(1))
+			deletedUnderlayIP, eventType := databaseHandler.DeleteLeaseArgsForCall(0)
+			Expect(deletedUnderlayIP).To(Equal(underlayIP))
+			Expect(eventType).To(Equal(controller.LeaseEventRelease))
 
 			Expect(logger.Logs()).To(HaveLen(1))
 			Expect(logger.Logs()[0].Data["underlay_ip"]).To(Equal("10.244.5.0"))
@@ -534,7 +833,7 @@ var _ = Describe("LeaseController", func() {
 
 		Context("when the database returns RecordNotAffectedError", func() {
 			BeforeEach(func() {
-				databaseHandler.DeleteEntryReturns(database.RecordNotAffectedError)
+				databaseHandler.DeleteLeaseReturns(database.RecordNotAffectedError)
 			})
 			It("swallows the error and logs it at DEBUG level", func() {
 				err := leaseController.ReleaseSubnetLease(underlayIP)
@@ -549,7 +848,7 @@ var _ = Describe("LeaseController", func() {
 
 		Context("when the database returns some other error", func() {
 			BeforeEach(func() {
-				databaseHandler.DeleteEntryReturns(errors.New("banana"))
+				databaseHandler.DeleteLeaseReturns(errors.New("banana"))
 			})
 			It("wraps the error from the database handler", func() {
 				err := leaseController.ReleaseSubnetLease(underlayIP)
@@ -0,0 +1,51 @@
+package leaser
+
+import (
+	"fmt"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+const defaultLeaseHistoryLimit = 100
+
+func (c *LeaseController) LeaseHistory(query controller.LeaseHistoryQuery) (controller.LeaseHistory, error) {
+	limit := query.Limit
+	if limit <= 0 {
+		limit = defaultLeaseHistoryLimit
+	}
+	query.Limit = limit + 1
+	events, err := c.DatabaseHandler.LeaseEvents(query)
+	if err != nil {
+		return controller.LeaseHistory{}, fmt.Errorf("getting lease events: %s", err)
+	}
+
+	history := controller.LeaseHistory{Events: events}
+	if len(events) > limit {
+		history.Events = events[:limit]
+		history.NextBefore = events[limit-1].ID
+	}
+	return history, nil
+}
+
+// recordRenewMismatch is best effort, the renewal is rejected either way.
+func (c *LeaseController) recordRenewMismatch(lease controller.Lease) {
+	event := controller.LeaseEvent{
+		Type:            controller.LeaseEventRenewMismatch,
+		UnderlayIP:      lease.UnderlayIP,
+		OverlaySubnet:   lease.OverlaySubnet,
+		OverlaySubnetV6: lease.OverlaySubnetV6,
+	}
+
+	holder, err := c.DatabaseHandler.LeaseForOverlaySubnet(lease.OverlaySubnet)
+	if err != nil {
+		c.Logger.Error("get-lease-for-overlay-subnet", err, lager.Data{"lease": lease})
+	} else if holder != nil && holder.UnderlayIP != lease.UnderlayIP {
+		event.PreviousUnderlayIP = holder.UnderlayIP
+	}
+
+	err = c.DatabaseHandler.AddLeaseEvent(event)
+	if err != nil {
+		c.Logger.Error("add-lease-event", err, lager.Data{"event": event})
+	}
+}
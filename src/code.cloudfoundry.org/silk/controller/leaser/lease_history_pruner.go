@@ -0,0 +1,50 @@
+package leaser
+
+import (
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3"
+)
+
+//go:generate counterfeiter -o fakes/lease_event_pruner.go --fake-name LeaseEventPruner . leaseEventPruner
+type leaseEventPruner interface {
+	DeleteLeaseEventsOlderThan(retentionSeconds int) (int64, error)
+}
+
+// LeaseHistoryPruner keeps the lease history to a retention window by
+// deleting older events every PruneInterval. Every controller runs one, the
+// deletes are idempotent.
+type LeaseHistoryPruner struct {
+	Store            leaseEventPruner
+	RetentionSeconds int
+	PruneInterval    time.Duration
+	Logger           lager.Logger
+}
+
+func (p *LeaseHistoryPruner) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	ticker := time.NewTicker(p.PruneInterval)
+	defer ticker.Stop()
+
+	close(ready)
+	p.prune()
+	for {
+		select {
+		case <-signals:
+			return nil
+		case <-ticker.C:
+			p.prune()
+		}
+	}
+}
+
+func (p *LeaseHistoryPruner) prune() {
+	deleted, err := p.Store.DeleteLeaseEventsOlderThan(p.RetentionSeconds)
+	if err != nil {
+		p.Logger.Error("prune-lease-history", err)
+		return
+	}
+	if deleted > 0 {
+		p.Logger.Info("pruned-lease-history", lager.Data{"deleted": deleted, "retention_seconds": p.RetentionSeconds})
+	}
+}
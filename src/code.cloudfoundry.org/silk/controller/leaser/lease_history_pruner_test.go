@@ -0,0 +1,66 @@
+package leaser_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/tedsuo/ifrit"
+
+	"code.cloudfoundry.org/silk/controller/leaser"
+	"code.cloudfoundry.org/silk/controller/leaser/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LeaseHistoryPruner", func() {
+	var (
+		logger *lagertest.TestLogger
+		store  *fakes.LeaseEventPruner
+		pruner *leaser.LeaseHistoryPruner
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		store = &fakes.LeaseEventPruner{}
+		store.DeleteLeaseEventsOlderThanReturns(3, nil)
+		pruner = &leaser.LeaseHistoryPruner{
+			Store:            store,
+			RetentionSeconds: 3600,
+			PruneInterval:    10 * time.Millisecond,
+			Logger:           logger,
+		}
+	})
+
+	It("deletes the events outside of the retention window on start and every interval", func() {
+		process := ifrit.Invoke(pruner)
+		defer func() {
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+		}()
+
+		Eventually(store.DeleteLeaseEventsOlderThanCallCount).Should(BeNumerically(">=", 2))
+		Expect(store.DeleteLeaseEventsOlderThanArgsForCall(0)).To(Equal(3600))
+		Expect(logger).To(gbytes.Say("pruned-lease-history.*\"deleted\":3"))
+	})
+
+	Context("when deleting fails", func() {
+		BeforeEach(func() {
+			store.DeleteLeaseEventsOlderThanReturns(0, errors.New("banana"))
+		})
+
+		It("logs the error and keeps running", func() {
+			process := ifrit.Invoke(pruner)
+			defer func() {
+				process.Signal(os.Interrupt)
+				Eventually(process.Wait()).Should(Receive(BeNil()))
+			}()
+
+			Eventually(logger).Should(gbytes.Say("prune-lease-history.*banana"))
+			Eventually(store.DeleteLeaseEventsOlderThanCallCount).Should(BeNumerically(">=", 2))
+		})
+	})
+})
@@ -0,0 +1,81 @@
+package leaser_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/lager/v3/lagertest"
+
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/leaser"
+	"code.cloudfoundry.org/silk/controller/leaser/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LeaseHistory", func() {
+	var (
+		databaseHandler *fakes.DatabaseHandler
+		leaseController leaser.LeaseController
+		events          []controller.LeaseEvent
+	)
+
+	BeforeEach(func() {
+		databaseHandler = &fakes.DatabaseHandler{}
+		leaseController = leaser.LeaseController{
+			DatabaseHandler: databaseHandler,
+			Logger:          lagertest.NewTestLogger("test"),
+		}
+		events = []controller.LeaseEvent{
+			{ID: 9, Type: controller.LeaseEventRelease, UnderlayIP: "10.244.5.6", OverlaySubnet: "10.255.76.0/24"},
+			{ID: 7, Type: controller.LeaseEventAcquire, UnderlayIP: "10.244.5.6", OverlaySubnet: "10.255.76.0/24"},
+			{ID: 4, Type: controller.LeaseEventAcquire, UnderlayIP: "10.244.5.7", OverlaySubnet: "10.255.77.0/24"},
+		}
+	})
+
+	It("asks for one more event than the limit to find the next page", func() {
+		databaseHandler.LeaseEventsReturns(events, nil)
+
+		history, err := leaseController.LeaseHistory(controller.LeaseHistoryQuery{
+			UnderlayIP: "10.244.5.6",
+			Before:     12,
+			Limit:      2,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(history.Events).To(Equal(events[:2]))
+		Expect(history.NextBefore).To(Equal(int64(7)))
+
+		Expect(databaseHandler.LeaseEventsArgsForCall(0)).To(Equal(controller.LeaseHistoryQuery{
+			UnderlayIP: "10.244.5.6",
+			Before:     12,
+			Limit:      3,
+		}))
+	})
+
+	It("does not return a next page after the last event", func() {
+		databaseHandler.LeaseEventsReturns(events, nil)
+
+		history, err := leaseController.LeaseHistory(controller.LeaseHistoryQuery{Limit: 3})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(history.Events).To(Equal(events))
+		Expect(history.NextBefore).To(BeZero())
+	})
+
+	It("defaults the limit", func() {
+		databaseHandler.LeaseEventsReturns(events, nil)
+
+		_, err := leaseController.LeaseHistory(controller.LeaseHistoryQuery{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(databaseHandler.LeaseEventsArgsForCall(0).Limit).To(Equal(101))
+	})
+
+	Context("when the database fails", func() {
+		BeforeEach(func() {
+			databaseHandler.LeaseEventsReturns(nil, errors.New("kiwi"))
+		})
+		It("returns an error", func() {
+			_, err := leaseController.LeaseHistory(controller.LeaseHistoryQuery{Limit: 10})
+			Expect(err).To(MatchError("getting lease events: kiwi"))
+		})
+	})
+})
@@ -0,0 +1,254 @@
+package leaser
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+// LeaseWatchHistorySize is the number of revisions a LeaseWatcher can send
+// deltas from. Watchers that are further behind get a full resync.
+const LeaseWatchHistorySize = 100
+
+//go:generate counterfeiter -o fakes/routable_lease_repository.go --fake-name RoutableLeaseRepository . routableLeaseRepository
+type routableLeaseRepository interface {
+	RoutableLeases() ([]controller.Lease, error)
+}
+
+type leaseChange struct {
+	revision uint64
+	added    []controller.Lease
+	removed  []controller.Lease
+}
+
+// LeaseWatcher keeps a copy of the routable leases in memory, so that the
+// lease watch API only reads the database once every RefreshInterval no
+// matter how many daemons are watching. Each refresh that changes the leases
+// is a new revision. Revisions are only meaningful within the epoch of one
+// controller process. They are not derived from the database because lease
+// expiry writes nothing there, so watches are sticky to one controller
+// instead and a watch that moves to another controller gets a resync.
+type LeaseWatcher struct {
+	LeaseRepository routableLeaseRepository
+	RefreshInterval time.Duration
+	Logger          lager.Logger
+
+	lock     sync.Mutex
+	epoch    string
+	revision uint64
+	leases   map[string]controller.Lease
+	history  []leaseChange
+	changed  chan struct{}
+}
+
+// Refresh reads the routable leases and records the differences to the
+// previous read as a new revision.
+func (w *LeaseWatcher) Refresh() error {
+	leases, err := w.LeaseRepository.RoutableLeases()
+	if err != nil {
+		return fmt.Errorf("getting routable leases: %s", err)
+	}
+
+	current := make(map[string]controller.Lease, len(leases))
+	for _, lease := range leases {
+		current[lease.UnderlayIP] = lease
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.epoch == "" {
+		w.epoch, err = newEpoch()
+		if err != nil {
+			return err
+		}
+		w.revision = 1
+		w.leases = current
+		w.changed = make(chan struct{})
+		return nil
+	}
+
+	change := leaseChange{revision: w.revision + 1}
+	for underlayIP, lease := range w.leases {
+		if newLease, ok := current[underlayIP]; !ok || newLease != lease {
+			change.removed = append(change.removed, lease)
+		}
+	}
+	for underlayIP, lease := range current {
+		if oldLease, ok := w.leases[underlayIP]; !ok || oldLease != lease {
+			change.added = append(change.added, lease)
+		}
+	}
+	if len(change.added) == 0 && len(change.removed) == 0 {
+		return nil
+	}
+
+	w.revision = change.revision
+	w.leases = current
+	w.history = append(w.history, change)
+	if len(w.history) > LeaseWatchHistorySize {
+		w.history = w.history[len(w.history)-LeaseWatchHistorySize:]
+	}
+	close(w.changed)
+	w.changed = make(chan struct{})
+
+	w.Logger.Debug("leases-changed", lager.Data{"revision": w.revision, "added": len(change.added), "removed": len(change.removed)})
+	return nil
+}
+
+func (w *LeaseWatcher) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	err := w.Refresh()
+	if err != nil {
+		w.Logger.Error("refresh-leases", err)
+	}
+
+	ticker := time.NewTicker(w.RefreshInterval)
+	defer ticker.Stop()
+
+	close(ready)
+	for {
+		select {
+		case <-signals:
+			return nil
+		case <-ticker.C:
+			err := w.Refresh()
+			if err != nil {
+				w.Logger.Error("refresh-leases", err)
+			}
+		}
+	}
+}
+
+// Watch returns the changes since the given revision. When the caller is up
+// to date it waits for the next change until ctx is done, and then returns
+// an update without changes. An unknown epoch or a revision that is not in
+// the history gets a full resync.
+func (w *LeaseWatcher) Watch(ctx context.Context, epoch string, revision uint64) (controller.LeaseUpdate, error) {
+	for {
+		w.lock.Lock()
+		if w.epoch == "" {
+			w.lock.Unlock()
+			return controller.LeaseUpdate{}, errors.New("leases have not been read yet")
+		}
+
+		if epoch != w.epoch || revision > w.revision || revision < w.oldestRevision() {
+			update := controller.LeaseUpdate{
+				Epoch:            w.epoch,
+				Revision:         w.revision,
+				PreviousRevision: revision,
+				Resync:           true,
+				Leases:           sortedLeases(w.leases),
+			}
+			w.lock.Unlock()
+			return update, nil
+		}
+
+		if revision < w.revision {
+			update := w.delta(revision)
+			w.lock.Unlock()
+			return update, nil
+		}
+
+		changed := w.changed
+		w.lock.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return controller.LeaseUpdate{
+				Epoch:            epoch,
+				Revision:         revision,
+				PreviousRevision: revision,
+			}, nil
+		}
+	}
+}
+
+// oldestRevision is the oldest revision that deltas can be built from.
+func (w *LeaseWatcher) oldestRevision() uint64 {
+	if len(w.history) == 0 {
+		return w.revision
+	}
+	return w.history[0].revision - 1
+}
+
+// delta combines the changes after the given revision. A lease that was
+// removed and added back unchanged is left out.
+func (w *LeaseWatcher) delta(revision uint64) controller.LeaseUpdate {
+	before := map[string]*controller.Lease{}
+	after := map[string]*controller.Lease{}
+	for _, change := range w.history {
+		if change.revision <= revision {
+			continue
+		}
+		for i := range change.removed {
+			lease := change.removed[i]
+			if _, ok := before[lease.UnderlayIP]; !ok {
+				before[lease.UnderlayIP] = &lease
+			}
+			after[lease.UnderlayIP] = nil
+		}
+		for i := range change.added {
+			lease := change.added[i]
+			if _, ok := before[lease.UnderlayIP]; !ok {
+				before[lease.UnderlayIP] = nil
+			}
+			after[lease.UnderlayIP] = &lease
+		}
+	}
+
+	removed := map[string]controller.Lease{}
+	added := map[string]controller.Lease{}
+	for underlayIP, oldLease := range before {
+		newLease := after[underlayIP]
+		if oldLease != nil && newLease != nil && *oldLease == *newLease {
+			continue
+		}
+		if oldLease != nil {
+			removed[underlayIP] = *oldLease
+		}
+		if newLease != nil {
+			added[underlayIP] = *newLease
+		}
+	}
+
+	return controller.LeaseUpdate{
+		Epoch:            w.epoch,
+		Revision:         w.revision,
+		PreviousRevision: revision,
+		Added:            sortedLeases(added),
+		Removed:          sortedLeases(removed),
+	}
+}
+
+func sortedLeases(leases map[string]controller.Lease) []controller.Lease {
+	if len(leases) == 0 {
+		return nil
+	}
+	sorted := make([]controller.Lease, 0, len(leases))
+	for _, lease := range leases {
+		sorted = append(sorted, lease)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].UnderlayIP < sorted[j].UnderlayIP
+	})
+	return sorted
+}
+
+func newEpoch() (string, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", fmt.Errorf("generating epoch: %s", err) // untested
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,240 @@
+package leaser_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/tedsuo/ifrit"
+
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/leaser"
+	"code.cloudfoundry.org/silk/controller/leaser/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LeaseWatcher", func() {
+	var (
+		logger          *lagertest.TestLogger
+		leaseRepository *fakes.RoutableLeaseRepository
+		watcher         *leaser.LeaseWatcher
+		leasesLock      sync.Mutex
+		leases          []controller.Lease
+		lease1          controller.Lease
+		lease2          controller.Lease
+		lease3          controller.Lease
+	)
+
+	setLeases := func(l ...controller.Lease) {
+		leasesLock.Lock()
+		defer leasesLock.Unlock()
+		leases = l
+	}
+
+	watchNow := func(epoch string, revision uint64) controller.LeaseUpdate {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		update, err := watcher.Watch(ctx, epoch, revision)
+		Expect(err).NotTo(HaveOccurred())
+		return update
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		lease1 = controller.Lease{UnderlayIP: "10.0.0.1", OverlaySubnet: "10.255.1.0/24", OverlayHardwareAddr: "ee:ee:0a:ff:01:00"}
+		lease2 = controller.Lease{UnderlayIP: "10.0.0.2", OverlaySubnet: "10.255.2.0/24", OverlayHardwareAddr: "ee:ee:0a:ff:02:00"}
+		lease3 = controller.Lease{UnderlayIP: "10.0.0.3", OverlaySubnet: "10.255.3.0/24", OverlayHardwareAddr: "ee:ee:0a:ff:03:00"}
+		setLeases(lease2, lease1)
+
+		leaseRepository = &fakes.RoutableLeaseRepository{}
+		leaseRepository.RoutableLeasesStub = func() ([]controller.Lease, error) {
+			leasesLock.Lock()
+			defer leasesLock.Unlock()
+			return append([]controller.Lease{}, leases...), nil
+		}
+		watcher = &leaser.LeaseWatcher{
+			LeaseRepository: leaseRepository,
+			RefreshInterval: time.Hour,
+			Logger:          logger,
+		}
+	})
+
+	Context("before the leases have been read", func() {
+		It("returns an error", func() {
+			_, err := watcher.Watch(context.Background(), "", 0)
+			Expect(err).To(MatchError("leases have not been read yet"))
+		})
+	})
+
+	Context("when the leases have been read", func() {
+		var epoch string
+
+		BeforeEach(func() {
+			Expect(watcher.Refresh()).To(Succeed())
+			update := watchNow("", 0)
+			epoch = update.Epoch
+		})
+
+		It("sends a full resync to callers without an epoch", func() {
+			update := watchNow("", 0)
+			Expect(update).To(Equal(controller.LeaseUpdate{
+				Epoch:    epoch,
+				Revision: 1,
+				Resync:   true,
+				Leases:   []controller.Lease{lease1, lease2},
+			}))
+			Expect(epoch).NotTo(BeEmpty())
+		})
+
+		It("sends a full resync to callers from another epoch", func() {
+			update := watchNow("other-epoch", 1)
+			Expect(update.Resync).To(BeTrue())
+			Expect(update.Epoch).To(Equal(epoch))
+		})
+
+		It("sends a full resync to callers ahead of the current revision", func() {
+			update := watchNow(epoch, 2)
+			Expect(update.Resync).To(BeTrue())
+			Expect(update.Revision).To(BeEquivalentTo(1))
+		})
+
+		It("only counts refreshes that change the leases as revisions", func() {
+			Expect(watcher.Refresh()).To(Succeed())
+			Expect(watchNow(epoch, 1)).To(Equal(controller.LeaseUpdate{
+				Epoch:            epoch,
+				Revision:         1,
+				PreviousRevision: 1,
+			}))
+		})
+
+		It("sends the changes since the given revision", func() {
+			changed := lease2
+			changed.OverlaySubnet = "10.255.9.0/24"
+
+			setLeases(changed, lease1, lease3)
+			Expect(watcher.Refresh()).To(Succeed())
+			setLeases(changed, lease3)
+			Expect(watcher.Refresh()).To(Succeed())
+
+			Expect(watchNow(epoch, 1)).To(Equal(controller.LeaseUpdate{
+				Epoch:            epoch,
+				Revision:         3,
+				PreviousRevision: 1,
+				Added:            []controller.Lease{changed, lease3},
+				Removed:          []controller.Lease{lease1, lease2},
+			}))
+			Expect(watchNow(epoch, 2)).To(Equal(controller.LeaseUpdate{
+				Epoch:            epoch,
+				Revision:         3,
+				PreviousRevision: 2,
+				Removed:          []controller.Lease{lease1},
+			}))
+		})
+
+		It("leaves out leases that were removed and added back unchanged", func() {
+			setLeases(lease2)
+			Expect(watcher.Refresh()).To(Succeed())
+			setLeases(lease2, lease1)
+			Expect(watcher.Refresh()).To(Succeed())
+
+			update := watchNow(epoch, 1)
+			Expect(update.Revision).To(BeEquivalentTo(3))
+			Expect(update.Added).To(BeEmpty())
+			Expect(update.Removed).To(BeEmpty())
+		})
+
+		It("waits for the next change when the caller is up to date", func() {
+			updates := make(chan controller.LeaseUpdate)
+			go func() {
+				defer GinkgoRecover()
+				update, err := watcher.Watch(context.Background(), epoch, 1)
+				Expect(err).NotTo(HaveOccurred())
+				updates <- update
+			}()
+			Consistently(updates, 50*time.Millisecond).ShouldNot(Receive())
+
+			setLeases(lease1)
+			Expect(watcher.Refresh()).To(Succeed())
+			Eventually(updates).Should(Receive(Equal(controller.LeaseUpdate{
+				Epoch:            epoch,
+				Revision:         2,
+				PreviousRevision: 1,
+				Removed:          []controller.Lease{lease2},
+			})))
+		})
+
+		Context("when the revision is no longer in the history", func() {
+			It("sends a full resync", func() {
+				for i := 0; i <= leaser.LeaseWatchHistorySize; i++ {
+					if i%2 == 0 {
+						setLeases(lease1)
+					} else {
+						setLeases(lease1, lease2)
+					}
+					Expect(watcher.Refresh()).To(Succeed())
+				}
+
+				update := watchNow(epoch, 1)
+				Expect(update.Resync).To(BeTrue())
+				Expect(update.Revision).To(BeEquivalentTo(leaser.LeaseWatchHistorySize + 2))
+
+				update = watchNow(epoch, 2)
+				Expect(update.Resync).To(BeFalse())
+				Expect(update.PreviousRevision).To(BeEquivalentTo(2))
+			})
+		})
+
+		Context("when reading the leases fails", func() {
+			BeforeEach(func() {
+				leaseRepository.RoutableLeasesStub = nil
+				leaseRepository.RoutableLeasesReturns(nil, errors.New("banana"))
+			})
+
+			It("returns the error and keeps the leases", func() {
+				Expect(watcher.Refresh()).To(MatchError("getting routable leases: banana"))
+				Expect(watchNow("", 0).Leases).To(Equal([]controller.Lease{lease1, lease2}))
+			})
+		})
+	})
+
+	Describe("Run", func() {
+		BeforeEach(func() {
+			watcher.RefreshInterval = 10 * time.Millisecond
+		})
+
+		It("reads the leases before it is ready and then keeps refreshing them", func() {
+			process := ifrit.Invoke(watcher)
+			defer func() {
+				process.Signal(os.Interrupt)
+				Eventually(process.Wait()).Should(Receive(BeNil()))
+			}()
+
+			update := watchNow("", 0)
+			Expect(update.Leases).To(Equal([]controller.Lease{lease1, lease2}))
+
+			setLeases(lease3)
+			Eventually(func() []controller.Lease { return watchNow("", 0).Leases }).Should(Equal([]controller.Lease{lease3}))
+		})
+
+		Context("when refreshing fails", func() {
+			It("logs the error", func() {
+				leaseRepository.RoutableLeasesStub = nil
+				leaseRepository.RoutableLeasesReturns(nil, errors.New("banana"))
+
+				process := ifrit.Invoke(watcher)
+				defer func() {
+					process.Signal(os.Interrupt)
+					Eventually(process.Wait()).Should(Receive(BeNil()))
+				}()
+
+				Eventually(logger).Should(gbytes.Say("refresh-leases.*banana"))
+			})
+		})
+	})
+})
@@ -0,0 +1,454 @@
+package leaser
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"reflect"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+)
+
+//go:generate counterfeiter -o fakes/overlay_network_store.go --fake-name OverlayNetworkStore . overlayNetworkStore
+type overlayNetworkStore interface {
+	OverlayNetworks() ([]controller.OverlayNetwork, error)
+	AddOverlayNetwork(controller.OverlayNetwork) error
+	SetOverlayNetworkState(cidr, state string) error
+	AllReservations() ([]controller.Reservation, error)
+	RetireOverlayNetwork(cidr string, expirationSeconds int) ([]controller.Lease, int, error)
+}
+
+// OverlayNetworkPool is the CIDRPool of the networks in the overlay_networks
+// table. It is rebuilt whenever a network is changed through it, and
+// refreshed every RefreshInterval to pick up changes made through other
+// controllers. A refresh that finds the same networks keeps the pool, so the
+// allocation strategies keep what they have learned.
+//
+// The table is seeded from the configured networks on the first start. After
+// that it is the source of truth and networks are only changed through the
+// admin API.
+type OverlayNetworkPool struct {
+	Store                  overlayNetworkStore
+	SubnetPrefixLength     int
+	SubnetPrefixLengthV6   int
+	NewAllocationStrategy  AllocationStrategyFactory
+	LeaseExpirationSeconds int
+	RefreshInterval        time.Duration
+	Logger                 lager.Logger
+
+	changeLock sync.Mutex
+	configured []string
+	networks   []controller.OverlayNetwork
+	poolLock   sync.RWMutex
+	pool       *CIDRPool
+}
+
+// Init seeds an empty table with the configured networks as active networks
+// and builds the pool. When the table already has networks their state is
+// kept, and the differences with the configuration are only logged.
+func (p *OverlayNetworkPool) Init(configured []string) error {
+	p.changeLock.Lock()
+	defer p.changeLock.Unlock()
+
+	networks, err := p.Store.OverlayNetworks()
+	if err != nil {
+		return fmt.Errorf("getting overlay networks: %s", err)
+	}
+
+	p.configured = nil
+	for _, cidr := range configured {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return fmt.Errorf("invalid overlay network: %s", err)
+		}
+		p.configured = append(p.configured, network.String())
+	}
+
+	if len(networks) > 0 {
+		p.logConfigurationDrift(networks)
+		return p.refresh()
+	}
+
+	for _, cidr := range p.configured {
+		_, network, _ := net.ParseCIDR(cidr)
+
+		overlayNetwork := controller.OverlayNetwork{CIDR: network.String(), State: controller.OverlayNetworkActive}
+		err = p.Store.AddOverlayNetwork(overlayNetwork)
+		if err != nil {
+			// another controller may have added it in the meantime
+			networks, listErr := p.Store.OverlayNetworks()
+			if listErr != nil || findOverlayNetwork(networks, network.String()) == nil {
+				return fmt.Errorf("adding overlay network: %s", err)
+			}
+			continue
+		}
+		networks = append(networks, overlayNetwork)
+		p.Logger.Info("overlay-network-added", lager.Data{"overlay_network": overlayNetwork})
+	}
+
+	return p.refresh()
+}
+
+// logConfigurationDrift warns about the configured networks that are not in
+// the table, which have to be added through the admin API, and about the
+// networks in use that were removed from the configuration, which the
+// silk-daemons no longer route to.
+func (p *OverlayNetworkPool) logConfigurationDrift(networks []controller.OverlayNetwork) {
+	for _, cidr := range p.configured {
+		if findOverlayNetwork(networks, cidr) == nil {
+			p.Logger.Info("overlay-network-not-in-table", lager.Data{"cidr": cidr})
+		}
+	}
+	for _, network := range networks {
+		if network.State != controller.OverlayNetworkRetired && !containsString(p.configured, network.CIDR) {
+			p.Logger.Info("overlay-network-not-configured", lager.Data{"overlay_network": network})
+		}
+	}
+}
+
+// Refresh rebuilds the pool from the overlay_networks table.
+func (p *OverlayNetworkPool) Refresh() error {
+	p.changeLock.Lock()
+	defer p.changeLock.Unlock()
+	return p.refresh()
+}
+
+func (p *OverlayNetworkPool) refresh() error {
+	networks, err := p.Store.OverlayNetworks()
+	if err != nil {
+		return fmt.Errorf("getting overlay networks: %s", err)
+	}
+
+	if p.current() != nil && reflect.DeepEqual(networks, p.networks) {
+		return nil
+	}
+
+	pool, err := p.buildPool(networks)
+	if err != nil {
+		return fmt.Errorf("building pool: %s", err)
+	}
+
+	p.networks = networks
+	p.poolLock.Lock()
+	p.pool = pool
+	p.poolLock.Unlock()
+	return nil
+}
+
+func (p *OverlayNetworkPool) buildPool(networks []controller.OverlayNetwork) (*CIDRPool, error) {
+	var members, draining []string
+	for _, network := range networks {
+		switch network.State {
+		case controller.OverlayNetworkActive:
+			members = append(members, network.CIDR)
+		case controller.OverlayNetworkDraining:
+			members = append(members, network.CIDR)
+			draining = append(draining, network.CIDR)
+		}
+	}
+	return newCIDRPool(members, draining, p.SubnetPrefixLength, p.SubnetPrefixLengthV6, p.NewAllocationStrategy)
+}
+
+func (p *OverlayNetworkPool) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	ticker := time.NewTicker(p.RefreshInterval)
+	defer ticker.Stop()
+
+	close(ready)
+	for {
+		select {
+		case <-signals:
+			return nil
+		case <-ticker.C:
+			err := p.Refresh()
+			if err != nil {
+				p.Logger.Error("refresh-overlay-networks", err)
+			}
+		}
+	}
+}
+
+func (p *OverlayNetworkPool) OverlayNetworks() ([]controller.OverlayNetwork, error) {
+	networks, err := p.Store.OverlayNetworks()
+	if err != nil {
+		return nil, fmt.Errorf("getting overlay networks: %s", err)
+	}
+	return networks, nil
+}
+
+// AddOverlayNetwork adds a network that is not in the table, or makes a
+// draining or retired network active again. The network must not overlap
+// another network that is not retired.
+func (p *OverlayNetworkPool) AddOverlayNetwork(cidr string) error {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return controller.InvalidRequestError(fmt.Sprintf("invalid overlay network: %s", err))
+	}
+	cidr = network.String()
+
+	p.changeLock.Lock()
+	defer p.changeLock.Unlock()
+
+	networks, err := p.Store.OverlayNetworks()
+	if err != nil {
+		return fmt.Errorf("getting overlay networks: %s", err)
+	}
+
+	existing := findOverlayNetwork(networks, cidr)
+	if existing != nil && existing.State == controller.OverlayNetworkActive {
+		return nil
+	}
+
+	for _, other := range networks {
+		if other.CIDR == cidr || other.State == controller.OverlayNetworkRetired {
+			continue
+		}
+		_, otherNetwork, _ := net.ParseCIDR(other.CIDR)
+		if otherNetwork.Contains(network.IP) || network.Contains(otherNetwork.IP) {
+			return controller.InvalidRequestError(fmt.Sprintf("overlay network %s overlaps %s", cidr, other.CIDR))
+		}
+	}
+
+	changed := setOverlayNetworkState(networks, cidr, controller.OverlayNetworkActive)
+	if existing == nil {
+		changed = append(changed, controller.OverlayNetwork{CIDR: cidr, State: controller.OverlayNetworkActive})
+	}
+	err = p.validate(cidr, changed)
+	if err != nil {
+		return err
+	}
+
+	if existing == nil {
+		err = p.Store.AddOverlayNetwork(controller.OverlayNetwork{CIDR: cidr, State: controller.OverlayNetworkActive})
+	} else {
+		err = p.Store.SetOverlayNetworkState(cidr, controller.OverlayNetworkActive)
+	}
+	if err != nil {
+		return fmt.Errorf("adding overlay network: %s", err)
+	}
+
+	p.Logger.Info("overlay-network-added", lager.Data{"cidr": cidr})
+	return p.refresh()
+}
+
+// DrainOverlayNetwork stops new leases from being allocated out of the
+// network. Existing leases are still renewed.
+func (p *OverlayNetworkPool) DrainOverlayNetwork(cidr string) error {
+	cidr, err := normalizeCIDR(cidr)
+	if err != nil {
+		return err
+	}
+
+	p.changeLock.Lock()
+	defer p.changeLock.Unlock()
+
+	networks, err := p.Store.OverlayNetworks()
+	if err != nil {
+		return fmt.Errorf("getting overlay networks: %s", err)
+	}
+
+	existing := findOverlayNetwork(networks, cidr)
+	if existing == nil {
+		return controller.InvalidRequestError(fmt.Sprintf("overlay network %s does not exist", cidr))
+	}
+	switch existing.State {
+	case controller.OverlayNetworkDraining:
+		return nil
+	case controller.OverlayNetworkRetired:
+		return controller.NonRetriableError(fmt.Sprintf("overlay network %s is retired", cidr))
+	}
+
+	if isIPv4CIDR(cidr) && countActiveIPv4(networks) == 1 {
+		return controller.NonRetriableError(fmt.Sprintf("overlay network %s is the last active ipv4 network", cidr))
+	}
+
+	err = p.validate(cidr, setOverlayNetworkState(networks, cidr, controller.OverlayNetworkDraining))
+	if err != nil {
+		return err
+	}
+
+	err = p.Store.SetOverlayNetworkState(cidr, controller.OverlayNetworkDraining)
+	if err != nil {
+		return fmt.Errorf("draining overlay network: %s", err)
+	}
+
+	p.Logger.Info("overlay-network-draining", lager.Data{"cidr": cidr})
+	return p.refresh()
+}
+
+// RetireOverlayNetwork removes a draining network from the overlay once no
+// cell holds an unexpired lease in it. Expired leases in the network are
+// deleted in the same transaction as the check.
+func (p *OverlayNetworkPool) RetireOverlayNetwork(cidr string) error {
+	cidr, err := normalizeCIDR(cidr)
+	if err != nil {
+		return err
+	}
+
+	p.changeLock.Lock()
+	defer p.changeLock.Unlock()
+
+	networks, err := p.Store.OverlayNetworks()
+	if err != nil {
+		return fmt.Errorf("getting overlay networks: %s", err)
+	}
+
+	existing := findOverlayNetwork(networks, cidr)
+	if existing == nil {
+		return controller.InvalidRequestError(fmt.Sprintf("overlay network %s does not exist", cidr))
+	}
+	switch existing.State {
+	case controller.OverlayNetworkRetired:
+		return nil
+	case controller.OverlayNetworkActive:
+		return controller.NonRetriableError(fmt.Sprintf("overlay network %s must be draining before it is retired", cidr))
+	}
+
+	_, network, _ := net.ParseCIDR(cidr)
+
+	reservations, err := p.Store.AllReservations()
+	if err != nil {
+		return fmt.Errorf("getting all reservations: %s", err)
+	}
+	for _, reservation := range reservations {
+		if subnetInNetwork(reservation.OverlaySubnet, network) {
+			return controller.NonRetriableError(fmt.Sprintf("overlay network %s contains the reservation of %s for %s", cidr, reservation.OverlaySubnet, reservation.UnderlayIP))
+		}
+	}
+
+	err = p.validate(cidr, setOverlayNetworkState(networks, cidr, controller.OverlayNetworkRetired))
+	if err != nil {
+		return err
+	}
+
+	deleted, active, err := p.Store.RetireOverlayNetwork(cidr, p.LeaseExpirationSeconds)
+	if err != nil {
+		return fmt.Errorf("retiring overlay network: %s", err)
+	}
+	if active > 0 {
+		return controller.NonRetriableError(fmt.Sprintf("overlay network %s still has %d active leases", cidr, active))
+	}
+
+	p.Logger.Info("overlay-network-retired", lager.Data{"cidr": cidr})
+	for _, lease := range deleted {
+		p.Logger.Info("lease-deleted", lager.Data{"lease": lease})
+	}
+
+	return p.refresh()
+}
+
+// validate builds the pool the change would result in, so that a network
+// that cannot be carved into subnets is rejected before it is stored.
+func (p *OverlayNetworkPool) validate(cidr string, networks []controller.OverlayNetwork) error {
+	_, err := p.buildPool(networks)
+	if err != nil {
+		return controller.InvalidRequestError(fmt.Sprintf("overlay network %s: %s", cidr, err))
+	}
+	return nil
+}
+
+func (p *OverlayNetworkPool) current() *CIDRPool {
+	p.poolLock.RLock()
+	defer p.poolLock.RUnlock()
+	return p.pool
+}
+
+func (p *OverlayNetworkPool) GetAvailableBlock(taken []string, underlayIP string) string {
+	return p.current().GetAvailableBlock(taken, underlayIP)
+}
+
+func (p *OverlayNetworkPool) GetAvailableSingleIP(taken []string, underlayIP string) string {
+	return p.current().GetAvailableSingleIP(taken, underlayIP)
+}
+
+func (p *OverlayNetworkPool) GetAvailableBlockV6(taken []string, underlayIP string) string {
+	return p.current().GetAvailableBlockV6(taken, underlayIP)
+}
+
+func (p *OverlayNetworkPool) IsMember(subnet string) bool {
+	return p.current().IsMember(subnet)
+}
+
+func (p *OverlayNetworkPool) IsMemberV6(subnet string) bool {
+	return p.current().IsMemberV6(subnet)
+}
+
+func (p *OverlayNetworkPool) IPv6Enabled() bool {
+	return p.current().IPv6Enabled()
+}
+
+// Networks returns the networks of the pool followed by the configured
+// networks that are not in it, so that metrics cover every network that may
+// become active.
+func (p *OverlayNetworkPool) Networks() []string {
+	networks := append([]string{}, p.current().Networks()...)
+	for _, cidr := range p.configured {
+		if !containsString(networks, cidr) {
+			networks = append(networks, cidr)
+		}
+	}
+	return networks
+}
+
+func (p *OverlayNetworkPool) Usage(leases, active []controller.Lease, reservations []controller.Reservation) (PoolUsage, map[string]PoolUsage) {
+	return p.current().Usage(leases, active, reservations)
+}
+
+func normalizeCIDR(cidr string) (string, error) {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return "", controller.InvalidRequestError(fmt.Sprintf("invalid overlay network: %s", err))
+	}
+	return network.String(), nil
+}
+
+func findOverlayNetwork(networks []controller.OverlayNetwork, cidr string) *controller.OverlayNetwork {
+	for i := range networks {
+		if networks[i].CIDR == cidr {
+			return &networks[i]
+		}
+	}
+	return nil
+}
+
+// setOverlayNetworkState returns a copy of networks with the state of cidr
+// changed.
+func setOverlayNetworkState(networks []controller.OverlayNetwork, cidr, state string) []controller.OverlayNetwork {
+	changed := make([]controller.OverlayNetwork, len(networks))
+	copy(changed, networks)
+	if network := findOverlayNetwork(changed, cidr); network != nil {
+		network.State = state
+	}
+	return changed
+}
+
+func countActiveIPv4(networks []controller.OverlayNetwork) int {
+	count := 0
+	for _, network := range networks {
+		if network.State == controller.OverlayNetworkActive && isIPv4CIDR(network.CIDR) {
+			count++
+		}
+	}
+	return count
+}
+
+func isIPv4CIDR(cidr string) bool {
+	ip, _, err := net.ParseCIDR(cidr)
+	return err == nil && ip.To4() != nil
+}
+
+func subnetInNetwork(subnet string, network *net.IPNet) bool {
+	ip, _, err := net.ParseCIDR(subnet)
+	return err == nil && network.Contains(ip)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
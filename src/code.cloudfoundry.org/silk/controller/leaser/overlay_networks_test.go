@@ -0,0 +1,431 @@
+package leaser_test
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/tedsuo/ifrit"
+
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/database"
+	"code.cloudfoundry.org/silk/controller/leaser"
+	"code.cloudfoundry.org/silk/controller/leaser/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OverlayNetworkPool", func() {
+	var (
+		logger   *lagertest.TestLogger
+		store    *fakes.OverlayNetworkStore
+		networks []controller.OverlayNetwork
+		pool     *leaser.OverlayNetworkPool
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		networks = []controller.OverlayNetwork{}
+
+		store = &fakes.OverlayNetworkStore{}
+		store.OverlayNetworksStub = func() ([]controller.OverlayNetwork, error) {
+			return append([]controller.OverlayNetwork{}, networks...), nil
+		}
+		store.AddOverlayNetworkStub = func(network controller.OverlayNetwork) error {
+			networks = append(networks, network)
+			return nil
+		}
+		store.SetOverlayNetworkStateStub = func(cidr, state string) error {
+			for i := range networks {
+				if networks[i].CIDR == cidr {
+					networks[i].State = state
+					return nil
+				}
+			}
+			return database.RecordNotAffectedError
+		}
+		store.RetireOverlayNetworkStub = func(cidr string, expirationSeconds int) ([]controller.Lease, int, error) {
+			return nil, 0, store.SetOverlayNetworkStateStub(cidr, controller.OverlayNetworkRetired)
+		}
+
+		newStrategy, err := leaser.AllocationStrategyByName(leaser.LowestFreeAllocation)
+		Expect(err).NotTo(HaveOccurred())
+		pool = &leaser.OverlayNetworkPool{
+			Store:                  store,
+			SubnetPrefixLength:     24,
+			SubnetPrefixLengthV6:   64,
+			NewAllocationStrategy:  newStrategy,
+			LeaseExpirationSeconds: 60,
+			Logger:                 logger,
+		}
+	})
+
+	Describe("Init", func() {
+		It("adds the configured networks as active networks", func() {
+			Expect(pool.Init([]string{"10.255.0.1/23", "10.254.0.0/23"})).To(Succeed())
+
+			Expect(networks).To(Equal([]controller.OverlayNetwork{
+				{CIDR: "10.255.0.0/23", State: controller.OverlayNetworkActive},
+				{CIDR: "10.254.0.0/23", State: controller.OverlayNetworkActive},
+			}))
+			Expect(pool.Networks()).To(Equal([]string{"10.254.0.0/23", "10.255.0.0/23"}))
+			Expect(pool.IsMember("10.254.1.0/24")).To(BeTrue())
+		})
+
+		Context("when the table already has networks", func() {
+			BeforeEach(func() {
+				networks = []controller.OverlayNetwork{
+					{CIDR: "10.255.0.0/23", State: controller.OverlayNetworkDraining},
+					{CIDR: "10.254.0.0/23", State: controller.OverlayNetworkRetired},
+					{CIDR: "10.253.0.0/23", State: controller.OverlayNetworkActive},
+				}
+			})
+
+			It("keeps their state and builds the pool from the table", func() {
+				Expect(pool.Init([]string{"10.255.0.0/23", "10.254.0.0/23", "10.252.0.0/23"})).To(Succeed())
+
+				Expect(store.AddOverlayNetworkCallCount()).To(Equal(0))
+				Expect(store.SetOverlayNetworkStateCallCount()).To(Equal(0))
+				Expect(pool.IsMember("10.255.1.0/24")).To(BeTrue())
+				Expect(pool.IsMember("10.254.1.0/24")).To(BeFalse())
+				Expect(pool.IsMember("10.252.1.0/24")).To(BeFalse())
+				Expect(pool.GetAvailableBlock(nil, "10.0.0.1")).To(Equal("10.253.1.0/24"))
+			})
+
+			It("logs the configured networks that are not in the table", func() {
+				Expect(pool.Init([]string{"10.255.0.0/23", "10.254.0.0/23", "10.252.0.0/23"})).To(Succeed())
+				Expect(logger).To(gbytes.Say(`overlay-network-not-in-table.*"cidr":"10.252.0.0/23"`))
+			})
+
+			It("logs the networks in use that are not configured", func() {
+				Expect(pool.Init([]string{"10.255.0.0/23"})).To(Succeed())
+				Expect(logger).To(gbytes.Say(`overlay-network-not-configured.*"cidr":"10.253.0.0/23"`))
+				Expect(logger).NotTo(gbytes.Say(`overlay-network-not-configured.*"cidr":"10.254.0.0/23"`))
+			})
+		})
+
+		Context("when another controller adds the network first", func() {
+			BeforeEach(func() {
+				store.AddOverlayNetworkStub = func(network controller.OverlayNetwork) error {
+					networks = append(networks, network)
+					return errors.New("duplicate key")
+				}
+			})
+
+			It("uses the network added by the other controller", func() {
+				Expect(pool.Init([]string{"10.255.0.0/23"})).To(Succeed())
+				Expect(pool.IsMember("10.255.1.0/24")).To(BeTrue())
+			})
+		})
+
+		Context("when adding the network fails", func() {
+			BeforeEach(func() {
+				store.AddOverlayNetworkReturns(errors.New("banana"))
+				store.AddOverlayNetworkStub = nil
+			})
+
+			It("returns an error", func() {
+				err := pool.Init([]string{"10.255.0.0/23"})
+				Expect(err).To(MatchError("adding overlay network: banana"))
+			})
+		})
+
+		Context("when a configured network is invalid", func() {
+			It("returns an error", func() {
+				err := pool.Init([]string{"banana"})
+				Expect(err).To(MatchError("invalid overlay network: invalid CIDR address: banana"))
+			})
+		})
+
+		Context("when the pool cannot be built", func() {
+			It("returns an error", func() {
+				err := pool.Init([]string{"fd00::/48"})
+				Expect(err).To(MatchError("building pool: at least one ipv4 network must be provided, ipv6 networks can only be added alongside ipv4 ones"))
+			})
+		})
+
+		Context("when getting the networks fails", func() {
+			BeforeEach(func() {
+				store.OverlayNetworksStub = nil
+				store.OverlayNetworksReturns(nil, errors.New("banana"))
+			})
+
+			It("returns an error", func() {
+				err := pool.Init([]string{"10.255.0.0/23"})
+				Expect(err).To(MatchError("getting overlay networks: banana"))
+			})
+		})
+	})
+
+	Context("when the pool is initialized", func() {
+		BeforeEach(func() {
+			Expect(pool.Init([]string{"10.255.0.0/23", "10.254.0.0/23"})).To(Succeed())
+		})
+
+		Describe("AddOverlayNetwork", func() {
+			It("adds a network that is missing from the table and rebuilds the pool", func() {
+				Expect(pool.AddOverlayNetwork("10.253.0.1/23")).To(Succeed())
+
+				Expect(networks).To(ContainElement(controller.OverlayNetwork{CIDR: "10.253.0.0/23", State: controller.OverlayNetworkActive}))
+				Expect(pool.IsMember("10.253.1.0/24")).To(BeTrue())
+				Expect(logger).To(gbytes.Say("overlay-network-added"))
+			})
+
+			It("makes a retired network active again", func() {
+				Expect(pool.DrainOverlayNetwork("10.254.0.0/23")).To(Succeed())
+				Expect(pool.RetireOverlayNetwork("10.254.0.0/23")).To(Succeed())
+				Expect(pool.IsMember("10.254.1.0/24")).To(BeFalse())
+
+				Expect(pool.AddOverlayNetwork("10.254.0.0/23")).To(Succeed())
+
+				Expect(networks[1].State).To(Equal(controller.OverlayNetworkActive))
+				Expect(pool.IsMember("10.254.1.0/24")).To(BeTrue())
+			})
+
+			It("does nothing when the network is already active", func() {
+				Expect(pool.AddOverlayNetwork("10.255.0.0/23")).To(Succeed())
+				Expect(store.AddOverlayNetworkCallCount()).To(Equal(2))
+				Expect(store.SetOverlayNetworkStateCallCount()).To(Equal(0))
+			})
+
+			It("makes a draining network active again", func() {
+				Expect(pool.DrainOverlayNetwork("10.254.0.0/23")).To(Succeed())
+				Expect(pool.AddOverlayNetwork("10.254.0.0/23")).To(Succeed())
+
+				Expect(networks[1].State).To(Equal(controller.OverlayNetworkActive))
+				Expect(pool.GetAvailableBlock(nil, "10.0.0.1")).To(Equal("10.254.1.0/24"))
+			})
+
+			DescribeTable("rejects invalid networks",
+				func(cidr, message string) {
+					err := pool.AddOverlayNetwork(cidr)
+					Expect(err).To(BeAssignableToTypeOf(controller.InvalidRequestError("")))
+					Expect(err).To(MatchError(ContainSubstring(message)))
+					Expect(store.AddOverlayNetworkCallCount()).To(Equal(2))
+				},
+				Entry("not a cidr", "banana", "invalid overlay network: invalid CIDR address: banana"),
+				Entry("overlapping a network", "10.255.1.0/24", "overlay network 10.255.1.0/24 overlaps 10.255.0.0/23"),
+				Entry("containing a network", "10.252.0.0/14", "overlay network 10.252.0.0/14 overlaps 10.255.0.0/23"),
+			)
+
+			Context("when another controller added an overlapping network", func() {
+				BeforeEach(func() {
+					networks = append(networks, controller.OverlayNetwork{CIDR: "10.252.0.0/14", State: controller.OverlayNetworkDraining})
+					Expect(pool.DrainOverlayNetwork("10.254.0.0/23")).To(Succeed())
+				})
+
+				It("rejects the network", func() {
+					err := pool.AddOverlayNetwork("10.254.0.0/23")
+					Expect(err).To(Equal(controller.InvalidRequestError("overlay network 10.254.0.0/23 overlaps 10.252.0.0/14")))
+				})
+			})
+
+			Context("when storing the network fails", func() {
+				BeforeEach(func() {
+					store.AddOverlayNetworkStub = nil
+					store.AddOverlayNetworkReturns(errors.New("banana"))
+				})
+
+				It("returns an error and keeps the pool", func() {
+					networks = networks[:1]
+					Expect(pool.Refresh()).To(Succeed())
+
+					err := pool.AddOverlayNetwork("10.254.0.0/23")
+					Expect(err).To(MatchError("adding overlay network: banana"))
+					Expect(pool.IsMember("10.254.1.0/24")).To(BeFalse())
+				})
+			})
+		})
+
+		Describe("DrainOverlayNetwork", func() {
+			It("stops allocating from the network but keeps its leases members", func() {
+				Expect(pool.GetAvailableBlock(nil, "10.0.0.1")).To(Equal("10.254.1.0/24"))
+
+				Expect(pool.DrainOverlayNetwork("10.254.0.0/23")).To(Succeed())
+
+				Expect(networks[1].State).To(Equal(controller.OverlayNetworkDraining))
+				Expect(pool.GetAvailableBlock(nil, "10.0.0.1")).To(Equal("10.255.1.0/24"))
+				Expect(pool.GetAvailableBlock([]string{"10.255.1.0/24"}, "10.0.0.1")).To(Equal(""))
+				Expect(pool.IsMember("10.254.1.0/24")).To(BeTrue())
+			})
+
+			It("does nothing when the network is already draining", func() {
+				Expect(pool.DrainOverlayNetwork("10.254.0.0/23")).To(Succeed())
+				Expect(pool.DrainOverlayNetwork("10.254.0.0/23")).To(Succeed())
+				Expect(store.SetOverlayNetworkStateCallCount()).To(Equal(1))
+			})
+
+			It("refuses to drain the last active ipv4 network", func() {
+				Expect(pool.DrainOverlayNetwork("10.254.0.0/23")).To(Succeed())
+				err := pool.DrainOverlayNetwork("10.255.0.0/23")
+				Expect(err).To(Equal(controller.NonRetriableError("overlay network 10.255.0.0/23 is the last active ipv4 network")))
+			})
+
+			It("returns an invalid request error when the network does not exist", func() {
+				err := pool.DrainOverlayNetwork("10.253.0.0/23")
+				Expect(err).To(Equal(controller.InvalidRequestError("overlay network 10.253.0.0/23 does not exist")))
+			})
+
+			Context("when storing the state fails", func() {
+				BeforeEach(func() {
+					store.SetOverlayNetworkStateStub = nil
+					store.SetOverlayNetworkStateReturns(errors.New("banana"))
+				})
+
+				It("returns an error", func() {
+					err := pool.DrainOverlayNetwork("10.254.0.0/23")
+					Expect(err).To(MatchError("draining overlay network: banana"))
+				})
+			})
+		})
+
+		Describe("RetireOverlayNetwork", func() {
+			var expiredLease controller.Lease
+
+			BeforeEach(func() {
+				Expect(pool.DrainOverlayNetwork("10.254.0.0/23")).To(Succeed())
+
+				expiredLease = controller.Lease{UnderlayIP: "10.0.0.2", OverlaySubnet: "10.254.1.0/24"}
+				store.RetireOverlayNetworkStub = func(cidr string, expirationSeconds int) ([]controller.Lease, int, error) {
+					return []controller.Lease{expiredLease}, 0, store.SetOverlayNetworkStateStub(cidr, controller.OverlayNetworkRetired)
+				}
+			})
+
+			It("removes the network from the pool and logs its deleted expired leases", func() {
+				Expect(pool.RetireOverlayNetwork("10.254.0.0/23")).To(Succeed())
+
+				Expect(store.RetireOverlayNetworkCallCount()).To(Equal(1))
+				cidr, expirationSeconds := store.RetireOverlayNetworkArgsForCall(0)
+				Expect(cidr).To(Equal("10.254.0.0/23"))
+				Expect(expirationSeconds).To(Equal(60))
+
+				Expect(networks[1].State).To(Equal(controller.OverlayNetworkRetired))
+				Expect(pool.IsMember("10.254.1.0/24")).To(BeFalse())
+				Expect(logger).To(gbytes.Say("overlay-network-retired"))
+				Expect(logger).To(gbytes.Say(`lease-deleted.*"underlay_ip":"10.0.0.2"`))
+			})
+
+			It("does nothing when the network is already retired", func() {
+				Expect(pool.RetireOverlayNetwork("10.254.0.0/23")).To(Succeed())
+				Expect(pool.RetireOverlayNetwork("10.254.0.0/23")).To(Succeed())
+				Expect(store.RetireOverlayNetworkCallCount()).To(Equal(1))
+			})
+
+			It("refuses to retire an active network", func() {
+				err := pool.RetireOverlayNetwork("10.255.0.0/23")
+				Expect(err).To(Equal(controller.NonRetriableError("overlay network 10.255.0.0/23 must be draining before it is retired")))
+				Expect(store.RetireOverlayNetworkCallCount()).To(Equal(0))
+			})
+
+			Context("when the network still has active leases", func() {
+				BeforeEach(func() {
+					store.RetireOverlayNetworkStub = nil
+					store.RetireOverlayNetworkReturns(nil, 1, nil)
+				})
+
+				It("refuses to retire it", func() {
+					err := pool.RetireOverlayNetwork("10.254.0.0/23")
+					Expect(err).To(Equal(controller.NonRetriableError("overlay network 10.254.0.0/23 still has 1 active leases")))
+					Expect(networks[1].State).To(Equal(controller.OverlayNetworkDraining))
+					Expect(pool.IsMember("10.254.1.0/24")).To(BeTrue())
+				})
+			})
+
+			Context("when the network contains a reservation", func() {
+				BeforeEach(func() {
+					store.AllReservationsReturns([]controller.Reservation{{UnderlayIP: "10.0.0.3", OverlaySubnet: "10.254.0.5/32"}}, nil)
+				})
+
+				It("refuses to retire it", func() {
+					err := pool.RetireOverlayNetwork("10.254.0.0/23")
+					Expect(err).To(Equal(controller.NonRetriableError("overlay network 10.254.0.0/23 contains the reservation of 10.254.0.5/32 for 10.0.0.3")))
+					Expect(store.RetireOverlayNetworkCallCount()).To(Equal(0))
+				})
+			})
+
+			Context("when retiring the network fails", func() {
+				BeforeEach(func() {
+					store.RetireOverlayNetworkStub = nil
+					store.RetireOverlayNetworkReturns(nil, 0, errors.New("banana"))
+				})
+
+				It("returns an error and keeps the pool", func() {
+					err := pool.RetireOverlayNetwork("10.254.0.0/23")
+					Expect(err).To(MatchError("retiring overlay network: banana"))
+					Expect(pool.IsMember("10.254.1.0/24")).To(BeTrue())
+				})
+			})
+		})
+
+		Describe("Refresh", func() {
+			var strategies int
+
+			BeforeEach(func() {
+				strategies = 0
+				pool.NewAllocationStrategy = func() leaser.AllocationStrategy {
+					strategies++
+					return leaser.NewLeastRecentlyReleasedStrategy()
+				}
+				networks = append(networks, controller.OverlayNetwork{CIDR: "10.253.0.0/23", State: controller.OverlayNetworkActive})
+				Expect(pool.Refresh()).To(Succeed())
+			})
+
+			It("keeps the pool and its allocation strategies when the networks have not changed", func() {
+				Expect(strategies).To(Equal(3))
+				Expect(pool.Refresh()).To(Succeed())
+				Expect(strategies).To(Equal(3))
+			})
+
+			It("rebuilds the pool when a network changes", func() {
+				networks[2].State = controller.OverlayNetworkDraining
+				Expect(pool.Refresh()).To(Succeed())
+				Expect(strategies).To(Equal(6))
+			})
+		})
+
+		Describe("Networks", func() {
+			It("includes the configured networks that are not in the pool", func() {
+				Expect(pool.DrainOverlayNetwork("10.254.0.0/23")).To(Succeed())
+				Expect(pool.RetireOverlayNetwork("10.254.0.0/23")).To(Succeed())
+
+				Expect(pool.Networks()).To(Equal([]string{"10.255.0.0/23", "10.254.0.0/23"}))
+			})
+		})
+
+		Describe("Run", func() {
+			BeforeEach(func() {
+				pool.RefreshInterval = 10 * time.Millisecond
+			})
+
+			It("picks up networks changed by other controllers", func() {
+				process := ifrit.Invoke(pool)
+				defer func() {
+					process.Signal(os.Interrupt)
+					Eventually(process.Wait()).Should(Receive(BeNil()))
+				}()
+
+				store.OverlayNetworksReturns(append(networks, controller.OverlayNetwork{CIDR: "10.253.0.0/23", State: controller.OverlayNetworkActive}), nil)
+				Eventually(func() bool { return pool.IsMember("10.253.1.0/24") }).Should(BeTrue())
+			})
+
+			Context("when refreshing fails", func() {
+				It("logs the error and keeps the pool", func() {
+					store.OverlayNetworksStub = nil
+					store.OverlayNetworksReturns(nil, errors.New("banana"))
+
+					process := ifrit.Invoke(pool)
+					defer func() {
+						process.Signal(os.Interrupt)
+						Eventually(process.Wait()).Should(Receive(BeNil()))
+					}()
+
+					Eventually(logger).Should(gbytes.Say("refresh-overlay-networks.*banana"))
+					Expect(pool.IsMember("10.255.1.0/24")).To(BeTrue())
+				})
+			})
+		})
+	})
+})
@@ -0,0 +1,127 @@
+package leaser
+
+import (
+	"net"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+// SubnetUsage counts the subnets of one pool. Used includes the stale
+// leases. Reserved counts the reserved subnets that no lease holds, a
+// reserved subnet that is leased is only counted as used. Leases and
+// reservations for subnets outside of the pool are not counted at all.
+type SubnetUsage struct {
+	Size     int
+	Used     int
+	Stale    int
+	Reserved int
+}
+
+// Free is the number of subnets that can be given to any cell.
+func (u SubnetUsage) Free() int {
+	return u.Size - u.Used - u.Reserved
+}
+
+type PoolUsage struct {
+	Block    SubnetUsage
+	SingleIP SubnetUsage
+	BlockV6  SubnetUsage
+}
+
+// Usage sorts the leases and reservations into the pools by membership. It
+// returns the usage of the whole CIDRPool and of each overlay network. A
+// lease is stale when its underlay IP is not in active.
+func (c *CIDRPool) Usage(leases, active []controller.Lease, reservations []controller.Reservation) (PoolUsage, map[string]PoolUsage) {
+	activeIPs := make(map[string]struct{}, len(active))
+	for _, lease := range active {
+		activeIPs[lease.UnderlayIP] = struct{}{}
+	}
+
+	total := PoolUsage{
+		Block:    SubnetUsage{Size: len(c.blockPool.subnets)},
+		SingleIP: SubnetUsage{Size: len(c.singlePool.subnets)},
+		BlockV6:  SubnetUsage{Size: len(c.blockPoolV6.subnets)},
+	}
+	perNetwork := make(map[string]PoolUsage, len(c.networkSizes))
+	for network, sizes := range c.networkSizes {
+		perNetwork[network] = sizes
+	}
+
+	leased := make(map[string]struct{}, len(leases))
+	for _, lease := range leases {
+		leased[lease.OverlaySubnet] = struct{}{}
+		_, isActive := activeIPs[lease.UnderlayIP]
+
+		if network, ok := c.blockPool.networks[lease.OverlaySubnet]; ok {
+			countLease(&total.Block, isActive)
+			usage := perNetwork[network]
+			countLease(&usage.Block, isActive)
+			perNetwork[network] = usage
+		} else if network, ok := c.singlePool.networks[lease.OverlaySubnet]; ok {
+			countLease(&total.SingleIP, isActive)
+			usage := perNetwork[network]
+			countLease(&usage.SingleIP, isActive)
+			perNetwork[network] = usage
+		}
+
+		if lease.OverlaySubnetV6 == "" {
+			continue
+		}
+		_, subnetV6, err := net.ParseCIDR(lease.OverlaySubnetV6)
+		if err != nil {
+			continue
+		}
+		if network, ok := c.blockPoolV6.networks[subnetV6.String()]; ok {
+			countLease(&total.BlockV6, isActive)
+			usage := perNetwork[network]
+			countLease(&usage.BlockV6, isActive)
+			perNetwork[network] = usage
+		}
+	}
+
+	for _, reservation := range reservations {
+		if _, ok := leased[reservation.OverlaySubnet]; ok {
+			continue
+		}
+		if network, ok := c.blockPool.networks[reservation.OverlaySubnet]; ok {
+			total.Block.Reserved++
+			usage := perNetwork[network]
+			usage.Block.Reserved++
+			perNetwork[network] = usage
+		} else if network, ok := c.singlePool.networks[reservation.OverlaySubnet]; ok {
+			total.SingleIP.Reserved++
+			usage := perNetwork[network]
+			usage.SingleIP.Reserved++
+			perNetwork[network] = usage
+		}
+	}
+
+	return total, perNetwork
+}
+
+// countNetworkSizes returns the number of subnets each pool has in each
+// overlay network.
+func (c *CIDRPool) countNetworkSizes() map[string]PoolUsage {
+	sizes := make(map[string]PoolUsage, len(c.networks))
+	for _, network := range c.networks {
+		sizes[network] = PoolUsage{}
+	}
+	count := func(pool subnetPool, subnetUsage func(*PoolUsage) *SubnetUsage) {
+		for _, network := range pool.networks {
+			usage := sizes[network]
+			subnetUsage(&usage).Size++
+			sizes[network] = usage
+		}
+	}
+	count(c.blockPool, func(u *PoolUsage) *SubnetUsage { return &u.Block })
+	count(c.singlePool, func(u *PoolUsage) *SubnetUsage { return &u.SingleIP })
+	count(c.blockPoolV6, func(u *PoolUsage) *SubnetUsage { return &u.BlockV6 })
+	return sizes
+}
+
+func countLease(usage *SubnetUsage, isActive bool) {
+	usage.Used++
+	if !isActive {
+		usage.Stale++
+	}
+}
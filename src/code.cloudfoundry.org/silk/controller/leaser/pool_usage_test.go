@@ -0,0 +1,99 @@
+package leaser_test
+
+import (
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/leaser"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CIDRPool Usage", func() {
+	var cidrPool *leaser.CIDRPool
+
+	BeforeEach(func() {
+		cidrPool = leaser.NewDualStackCIDRPool([]string{"10.255.0.0/22", "fd00:0:0:ff00::/62", "10.254.0.0/23"}, 24, 64)
+	})
+
+	It("lists the overlay networks in address order, ipv4 first", func() {
+		Expect(cidrPool.Networks()).To(Equal([]string{"10.254.0.0/23", "10.255.0.0/22", "fd00:0:0:ff00::/62"}))
+	})
+
+	Context("when there are no leases", func() {
+		It("reports every subnet as free", func() {
+			total, perNetwork := cidrPool.Usage(nil, nil, nil)
+			Expect(total).To(Equal(leaser.PoolUsage{
+				Block:    leaser.SubnetUsage{Size: 4},
+				SingleIP: leaser.SubnetUsage{Size: 255},
+				BlockV6:  leaser.SubnetUsage{Size: 3},
+			}))
+			Expect(total.Block.Free()).To(Equal(4))
+
+			Expect(perNetwork).To(Equal(map[string]leaser.PoolUsage{
+				"10.254.0.0/23":      {Block: leaser.SubnetUsage{Size: 1}},
+				"10.255.0.0/22":      {Block: leaser.SubnetUsage{Size: 3}, SingleIP: leaser.SubnetUsage{Size: 255}},
+				"fd00:0:0:ff00::/62": {BlockV6: leaser.SubnetUsage{Size: 3}},
+			}))
+		})
+	})
+
+	Context("when there are leases", func() {
+		var leases, active []controller.Lease
+
+		BeforeEach(func() {
+			leases = []controller.Lease{
+				{UnderlayIP: "10.0.0.1", OverlaySubnet: "10.255.1.0/24", OverlaySubnetV6: "fd00:0:0:ff01::/64"},
+				{UnderlayIP: "10.0.0.2", OverlaySubnet: "10.254.1.0/24"},
+				{UnderlayIP: "10.0.0.3", OverlaySubnet: "10.255.0.7/32"},
+				{UnderlayIP: "10.0.0.4", OverlaySubnet: "10.255.2.0/24", OverlaySubnetV6: "fd00:0:0:ff02:0:0:0:0/64"},
+				{UnderlayIP: "10.0.0.5", OverlaySubnet: "10.253.1.0/24", OverlaySubnetV6: "fd00:0:0:aa01::/64"},
+				{UnderlayIP: "10.0.0.6", OverlaySubnet: "10.255.0.0/24"},
+			}
+			active = []controller.Lease{leases[0], leases[2], leases[4]}
+		})
+
+		It("counts the leases in the pools they are members of", func() {
+			total, perNetwork := cidrPool.Usage(leases, active, nil)
+			Expect(total).To(Equal(leaser.PoolUsage{
+				Block:    leaser.SubnetUsage{Size: 4, Used: 3, Stale: 2},
+				SingleIP: leaser.SubnetUsage{Size: 255, Used: 1},
+				BlockV6:  leaser.SubnetUsage{Size: 3, Used: 2, Stale: 1},
+			}))
+			Expect(total.Block.Free()).To(Equal(1))
+
+			Expect(perNetwork["10.254.0.0/23"]).To(Equal(leaser.PoolUsage{
+				Block: leaser.SubnetUsage{Size: 1, Used: 1, Stale: 1},
+			}))
+			Expect(perNetwork["10.255.0.0/22"]).To(Equal(leaser.PoolUsage{
+				Block:    leaser.SubnetUsage{Size: 3, Used: 2, Stale: 1},
+				SingleIP: leaser.SubnetUsage{Size: 255, Used: 1},
+			}))
+			Expect(perNetwork["fd00:0:0:ff00::/62"]).To(Equal(leaser.PoolUsage{
+				BlockV6: leaser.SubnetUsage{Size: 3, Used: 2, Stale: 1},
+			}))
+		})
+
+		It("counts the reserved subnets that are not leased as reserved instead of free", func() {
+			reservations := []controller.Reservation{
+				{UnderlayIP: "10.0.0.7", OverlaySubnet: "10.255.3.0/24"},
+				{UnderlayIP: "10.0.0.8", OverlaySubnet: "10.255.0.9/32"},
+				{UnderlayIP: "10.0.0.1", OverlaySubnet: "10.255.1.0/24"},
+				{UnderlayIP: "10.0.0.9", OverlaySubnet: "10.253.2.0/24"},
+			}
+			total, perNetwork := cidrPool.Usage(leases, active, reservations)
+			Expect(total.Block).To(Equal(leaser.SubnetUsage{Size: 4, Used: 3, Stale: 2, Reserved: 1}))
+			Expect(total.Block.Free()).To(Equal(0))
+			Expect(total.SingleIP).To(Equal(leaser.SubnetUsage{Size: 255, Used: 1, Reserved: 1}))
+			Expect(total.SingleIP.Free()).To(Equal(253))
+
+			Expect(perNetwork["10.255.0.0/22"].Block).To(Equal(leaser.SubnetUsage{Size: 3, Used: 2, Stale: 1, Reserved: 1}))
+			Expect(perNetwork["10.255.0.0/22"].SingleIP).To(Equal(leaser.SubnetUsage{Size: 255, Used: 1, Reserved: 1}))
+			Expect(perNetwork["10.254.0.0/23"].Block.Reserved).To(Equal(0))
+		})
+
+		It("does not count leases outside of the pools", func() {
+			_, perNetwork := cidrPool.Usage(leases, active, nil)
+			Expect(perNetwork).To(HaveLen(3))
+			Expect(perNetwork).NotTo(HaveKey("10.253.0.0/16"))
+		})
+	})
+})
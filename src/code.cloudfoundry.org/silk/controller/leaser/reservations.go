@@ -0,0 +1,93 @@
+package leaser
+
+import (
+	"fmt"
+	"net"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/database"
+)
+
+func (c *LeaseController) Reservations() ([]controller.Reservation, error) {
+	reservations, err := c.DatabaseHandler.AllReservations()
+	if err != nil {
+		return nil, fmt.Errorf("getting all reservations: %s", err)
+	}
+	return reservations, nil
+}
+
+func (c *LeaseController) ReserveSubnet(reservation controller.Reservation) error {
+	if net.ParseIP(reservation.UnderlayIP) == nil {
+		return controller.InvalidRequestError(fmt.Sprintf("invalid underlay ip: %s", reservation.UnderlayIP))
+	}
+	if !c.CIDRPool.IsMember(reservation.OverlaySubnet) {
+		return controller.InvalidRequestError(fmt.Sprintf("overlay subnet %s is not a subnet of the overlay network", reservation.OverlaySubnet))
+	}
+
+	added, err := c.DatabaseHandler.ReserveSubnet(reservation, func(forUnderlayIP, forOverlaySubnet *controller.Reservation, lease *controller.Lease) (bool, error) {
+		if forUnderlayIP != nil {
+			if *forUnderlayIP == reservation {
+				return false, nil
+			}
+			return false, controller.NonRetriableError(fmt.Sprintf("underlay ip %s already has a reservation for %s", forUnderlayIP.UnderlayIP, forUnderlayIP.OverlaySubnet))
+		}
+		if forOverlaySubnet != nil {
+			return false, controller.NonRetriableError(fmt.Sprintf("overlay subnet %s is already reserved for %s", forOverlaySubnet.OverlaySubnet, forOverlaySubnet.UnderlayIP))
+		}
+		if lease != nil && lease.UnderlayIP != reservation.UnderlayIP {
+			return false, controller.NonRetriableError(fmt.Sprintf("overlay subnet %s is leased to %s", lease.OverlaySubnet, lease.UnderlayIP))
+		}
+		return true, nil
+	})
+	if _, ok := err.(controller.NonRetriableError); ok {
+		return err
+	}
+	if err != nil {
+		return fmt.Errorf("reserving subnet: %s", err)
+	}
+	if !added {
+		return nil
+	}
+
+	c.Logger.Info("reservation-added", lager.Data{"reservation": reservation})
+	return nil
+}
+
+func (c *LeaseController) DeleteReservation(underlayIP string) error {
+	err := c.DatabaseHandler.DeleteReservation(underlayIP)
+	if err == database.RecordNotAffectedError {
+		c.Logger.Debug("reservation-not-found", lager.Data{"underlay_ip": underlayIP})
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("delete reservation: %s", err)
+	}
+
+	c.Logger.Info("reservation-deleted", lager.Data{"underlay_ip": underlayIP})
+	return nil
+}
+
+func (c *LeaseController) acquireReservedLease(reservation controller.Reservation, singleOverlayIP bool) (*controller.Lease, error) {
+	if isSingleIPSubnet(reservation.OverlaySubnet) != singleOverlayIP {
+		return nil, fmt.Errorf("reservation of %s for %s does not match the requested lease type", reservation.OverlaySubnet, reservation.UnderlayIP)
+	}
+
+	lease, err := c.DatabaseHandler.AcquireLease(reservation.UnderlayIP, singleOverlayIP, c.LeaseExpirationSeconds, func(_, takenV6 []string, ipv4Only bool) (*controller.Lease, error) {
+		return c.newLease(reservation.UnderlayIP, reservation.OverlaySubnet, singleOverlayIP || ipv4Only, takenV6)
+	})
+	if err != nil || lease == nil {
+		return lease, err
+	}
+	c.logMissingIPv6Subnet(*lease)
+	return lease, nil
+}
+
+func isSingleIPSubnet(subnet string) bool {
+	_, network, err := net.ParseCIDR(subnet)
+	if err != nil {
+		return false
+	}
+	ones, bits := network.Mask.Size()
+	return ones == bits
+}
@@ -0,0 +1,223 @@
+package leaser_test
+
+import (
+	"errors"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/database"
+	"code.cloudfoundry.org/silk/controller/leaser"
+	"code.cloudfoundry.org/silk/controller/leaser/fakes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Reservations", func() {
+	var (
+		logger          *lagertest.TestLogger
+		databaseHandler *fakes.DatabaseHandler
+		cidrPool        *fakes.CIDRPool
+		leaseController leaser.LeaseController
+		reservation     controller.Reservation
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		databaseHandler = &fakes.DatabaseHandler{}
+		cidrPool = &fakes.CIDRPool{}
+		leaseController = leaser.LeaseController{
+			DatabaseHandler: databaseHandler,
+			CIDRPool:        cidrPool,
+			Logger:          logger,
+		}
+		reservation = controller.Reservation{
+			UnderlayIP:    "10.244.5.6",
+			OverlaySubnet: "10.255.99.0/24",
+		}
+		cidrPool.IsMemberReturns(true)
+	})
+
+	Describe("ReserveSubnet", func() {
+		var (
+			forUnderlayIP    *controller.Reservation
+			forOverlaySubnet *controller.Reservation
+			holder           *controller.Lease
+			checkErr         error
+		)
+
+		BeforeEach(func() {
+			forUnderlayIP, forOverlaySubnet, holder, checkErr = nil, nil, nil, nil
+			databaseHandler.ReserveSubnetStub = func(_ controller.Reservation, check database.ReservationCheck) (bool, error) {
+				var added bool
+				added, checkErr = check(forUnderlayIP, forOverlaySubnet, holder)
+				return added, checkErr
+			}
+		})
+
+		It("adds the reservation in one database transaction", func() {
+			err := leaseController.ReserveSubnet(reservation)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(cidrPool.IsMemberArgsForCall(0)).To(Equal("10.255.99.0/24"))
+			Expect(databaseHandler.ReserveSubnetCallCount()).To(Equal(1))
+			reserved, _ := databaseHandler.ReserveSubnetArgsForCall(0)
+			Expect(reserved).To(Equal(reservation))
+			Expect(logger.Logs()[0].Message).To(Equal("test.reservation-added"))
+		})
+
+		Context("when the underlay ip is invalid", func() {
+			BeforeEach(func() {
+				reservation.UnderlayIP = "banana"
+			})
+			It("returns an invalid request error", func() {
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).To(Equal(controller.InvalidRequestError("invalid underlay ip: banana")))
+				Expect(databaseHandler.ReserveSubnetCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the overlay subnet is not in the pool", func() {
+			BeforeEach(func() {
+				cidrPool.IsMemberReturns(false)
+			})
+			It("returns an invalid request error", func() {
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).To(Equal(controller.InvalidRequestError("overlay subnet 10.255.99.0/24 is not a subnet of the overlay network")))
+				Expect(databaseHandler.ReserveSubnetCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the reservation already exists", func() {
+			BeforeEach(func() {
+				forUnderlayIP = &controller.Reservation{
+					UnderlayIP:    "10.244.5.6",
+					OverlaySubnet: "10.255.99.0/24",
+				}
+			})
+			It("succeeds without adding it again", func() {
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(checkErr).NotTo(HaveOccurred())
+				Expect(logger.Logs()).To(BeEmpty())
+			})
+		})
+
+		Context("when the underlay ip has a reservation for a different subnet", func() {
+			BeforeEach(func() {
+				forUnderlayIP = &controller.Reservation{
+					UnderlayIP:    "10.244.5.6",
+					OverlaySubnet: "10.255.98.0/24",
+				}
+			})
+			It("returns a conflict", func() {
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).To(Equal(controller.NonRetriableError("underlay ip 10.244.5.6 already has a reservation for 10.255.98.0/24")))
+			})
+		})
+
+		Context("when the subnet is reserved for another underlay ip", func() {
+			BeforeEach(func() {
+				forOverlaySubnet = &controller.Reservation{
+					UnderlayIP:    "10.244.7.7",
+					OverlaySubnet: "10.255.99.0/24",
+				}
+			})
+			It("returns a conflict", func() {
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).To(Equal(controller.NonRetriableError("overlay subnet 10.255.99.0/24 is already reserved for 10.244.7.7")))
+			})
+		})
+
+		Context("when the subnet is leased to another underlay ip", func() {
+			BeforeEach(func() {
+				holder = &controller.Lease{
+					UnderlayIP:    "10.244.7.7",
+					OverlaySubnet: "10.255.99.0/24",
+				}
+			})
+			It("returns a conflict", func() {
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).To(Equal(controller.NonRetriableError("overlay subnet 10.255.99.0/24 is leased to 10.244.7.7")))
+			})
+		})
+
+		Context("when the subnet is already leased to the underlay ip", func() {
+			BeforeEach(func() {
+				holder = &controller.Lease{
+					UnderlayIP:    "10.244.5.6",
+					OverlaySubnet: "10.255.99.0/24",
+				}
+			})
+			It("adds the reservation", func() {
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(logger.Logs()[0].Message).To(Equal("test.reservation-added"))
+			})
+		})
+
+		Context("when the database fails", func() {
+			BeforeEach(func() {
+				databaseHandler.ReserveSubnetStub = nil
+				databaseHandler.ReserveSubnetReturns(false, errors.New("kiwi"))
+			})
+			It("returns an error", func() {
+				err := leaseController.ReserveSubnet(reservation)
+				Expect(err).To(MatchError("reserving subnet: kiwi"))
+				Expect(logger.Logs()).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("DeleteReservation", func() {
+		It("deletes the reservation", func() {
+			err := leaseController.DeleteReservation("10.244.5.6")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(databaseHandler.DeleteReservationArgsForCall(0)).To(Equal("10.244.5.6"))
+			Expect(logger.Logs()[0].Message).To(Equal("test.reservation-deleted"))
+		})
+
+		Context("when the reservation does not exist", func() {
+			BeforeEach(func() {
+				databaseHandler.DeleteReservationReturns(database.RecordNotAffectedError)
+			})
+			It("swallows the error and logs it at DEBUG level", func() {
+				err := leaseController.DeleteReservation("10.244.5.6")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(logger.Logs()[0].Message).To(Equal("test.reservation-not-found"))
+				Expect(logger.Logs()[0].LogLevel).To(Equal(lager.DEBUG))
+			})
+		})
+
+		Context("when the database returns some other error", func() {
+			BeforeEach(func() {
+				databaseHandler.DeleteReservationReturns(errors.New("kiwi"))
+			})
+			It("returns an error", func() {
+				err := leaseController.DeleteReservation("10.244.5.6")
+				Expect(err).To(MatchError("delete reservation: kiwi"))
+			})
+		})
+	})
+
+	Describe("Reservations", func() {
+		It("returns all reservations", func() {
+			databaseHandler.AllReservationsReturns([]controller.Reservation{reservation}, nil)
+			reservations, err := leaseController.Reservations()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reservations).To(Equal([]controller.Reservation{reservation}))
+		})
+
+		Context("when the database fails", func() {
+			BeforeEach(func() {
+				databaseHandler.AllReservationsReturns(nil, errors.New("kiwi"))
+			})
+			It("returns an error", func() {
+				_, err := leaseController.Reservations()
+				Expect(err).To(MatchError("getting all reservations: kiwi"))
+			})
+		})
+	})
+})
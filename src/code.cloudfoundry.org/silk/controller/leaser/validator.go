@@ -7,9 +7,27 @@ import (
 	"code.cloudfoundry.org/silk/controller"
 )
 
-type LeaseValidator struct{}
+//go:generate counterfeiter -o fakes/reservation_repository.go --fake-name ReservationRepository . reservationRepository
+type reservationRepository interface {
+	ReservationForUnderlayIP(string) (*controller.Reservation, error)
+	ReservationForOverlaySubnet(string) (*controller.Reservation, error)
+}
+
+type LeaseValidator struct {
+	ReservationRepository reservationRepository
+}
 
+// Validate returns a NonRetriableError when the lease is malformed or
+// conflicts with a reservation. Errors looking up the reservations are
+// returned as they are, since the lease may be fine once the database is.
 func (v *LeaseValidator) Validate(lease controller.Lease) error {
+	if err := validateLease(lease); err != nil {
+		return controller.NonRetriableError(err.Error())
+	}
+	return v.validateReservations(lease)
+}
+
+func validateLease(lease controller.Lease) error {
 	if net.ParseIP(lease.UnderlayIP) == nil {
 		return fmt.Errorf("invalid underlay ip: %s", lease.UnderlayIP)
 	}
@@ -24,5 +42,35 @@ func (v *LeaseValidator) Validate(lease controller.Lease) error {
 		return err
 	}
 
+	if lease.OverlaySubnetV6 != "" {
+		ip, _, err := net.ParseCIDR(lease.OverlaySubnetV6)
+		if err != nil {
+			return err
+		}
+		if ip.To4() != nil {
+			return fmt.Errorf("invalid ipv6 overlay subnet: %s", lease.OverlaySubnetV6)
+		}
+	}
+
+	return nil
+}
+
+func (v *LeaseValidator) validateReservations(lease controller.Lease) error {
+	reservation, err := v.ReservationRepository.ReservationForUnderlayIP(lease.UnderlayIP)
+	if err != nil {
+		return fmt.Errorf("getting reservation for underlay ip: %s", err)
+	}
+	if reservation != nil && reservation.OverlaySubnet != lease.OverlaySubnet {
+		return controller.NonRetriableError(fmt.Sprintf("lease conflicts with reservation of %s for %s", reservation.OverlaySubnet, reservation.UnderlayIP))
+	}
+
+	reservation, err = v.ReservationRepository.ReservationForOverlaySubnet(lease.OverlaySubnet)
+	if err != nil {
+		return fmt.Errorf("getting reservation for overlay subnet: %s", err)
+	}
+	if reservation != nil && reservation.UnderlayIP != lease.UnderlayIP {
+		return controller.NonRetriableError(fmt.Sprintf("lease conflicts with reservation of %s for %s", reservation.OverlaySubnet, reservation.UnderlayIP))
+	}
+
 	return nil
 }
@@ -1,8 +1,11 @@
 package leaser_test
 
 import (
+	"errors"
+
 	"code.cloudfoundry.org/silk/controller"
 	"code.cloudfoundry.org/silk/controller/leaser"
+	"code.cloudfoundry.org/silk/controller/leaser/fakes"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -10,8 +13,9 @@ import (
 
 var _ = Describe("Validator", func() {
 	var (
-		validator *leaser.LeaseValidator
-		lease     controller.Lease
+		validator             *leaser.LeaseValidator
+		reservationRepository *fakes.ReservationRepository
+		lease                 controller.Lease
 	)
 
 	BeforeEach(func() {
@@ -20,7 +24,10 @@ var _ = Describe("Validator", func() {
 			OverlaySubnet:       "5.4.3.2/24",
 			OverlayHardwareAddr: "aa:bb:cc:dd:ee:ff",
 		}
-		validator = &leaser.LeaseValidator{}
+		reservationRepository = &fakes.ReservationRepository{}
+		validator = &leaser.LeaseValidator{
+			ReservationRepository: reservationRepository,
+		}
 	})
 
 	It("checks that the lease is valid", func() {
@@ -32,8 +39,9 @@ var _ = Describe("Validator", func() {
 		BeforeEach(func() {
 			lease.UnderlayIP = "not-an-ip"
 		})
-		It("returns an error", func() {
+		It("returns a non-retriable error", func() {
 			err := validator.Validate(lease)
+			Expect(err).To(BeAssignableToTypeOf(controller.NonRetriableError("")))
 			Expect(err).To(MatchError("invalid underlay ip: not-an-ip"))
 		})
 	})
@@ -57,4 +65,102 @@ var _ = Describe("Validator", func() {
 			Expect(err).To(MatchError(ContainSubstring("invalid MAC address")))
 		})
 	})
+
+	Context("when the lease has an ipv6 overlay subnet", func() {
+		BeforeEach(func() {
+			lease.OverlaySubnetV6 = "fd00:0:0:3::/64"
+		})
+		It("checks that the lease is valid", func() {
+			err := validator.Validate(lease)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		Context("when the ipv6 overlay subnet is invalid", func() {
+			BeforeEach(func() {
+				lease.OverlaySubnetV6 = "not-a-subnet"
+			})
+			It("returns an error", func() {
+				err := validator.Validate(lease)
+				Expect(err).To(MatchError("invalid CIDR address: not-a-subnet"))
+			})
+		})
+
+		Context("when the ipv6 overlay subnet is an ipv4 subnet", func() {
+			BeforeEach(func() {
+				lease.OverlaySubnetV6 = "10.255.3.0/24"
+			})
+			It("returns an error", func() {
+				err := validator.Validate(lease)
+				Expect(err).To(MatchError("invalid ipv6 overlay subnet: 10.255.3.0/24"))
+			})
+		})
+	})
+
+	Context("when the underlay ip has a reservation", func() {
+		BeforeEach(func() {
+			reservationRepository.ReservationForUnderlayIPReturns(&controller.Reservation{
+				UnderlayIP:    "1.2.3.4",
+				OverlaySubnet: "5.4.3.2/24",
+			}, nil)
+			reservationRepository.ReservationForOverlaySubnetReturns(&controller.Reservation{
+				UnderlayIP:    "1.2.3.4",
+				OverlaySubnet: "5.4.3.2/24",
+			}, nil)
+		})
+
+		It("accepts the reserved subnet", func() {
+			err := validator.Validate(lease)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reservationRepository.ReservationForUnderlayIPArgsForCall(0)).To(Equal("1.2.3.4"))
+			Expect(reservationRepository.ReservationForOverlaySubnetArgsForCall(0)).To(Equal("5.4.3.2/24"))
+		})
+
+		Context("when the lease is for a different subnet", func() {
+			BeforeEach(func() {
+				lease.OverlaySubnet = "5.4.4.0/24"
+				reservationRepository.ReservationForOverlaySubnetReturns(nil, nil)
+			})
+			It("returns a non-retriable error", func() {
+				err := validator.Validate(lease)
+				Expect(err).To(BeAssignableToTypeOf(controller.NonRetriableError("")))
+				Expect(err).To(MatchError("lease conflicts with reservation of 5.4.3.2/24 for 1.2.3.4"))
+			})
+		})
+
+		Context("when getting the reservation fails", func() {
+			BeforeEach(func() {
+				reservationRepository.ReservationForUnderlayIPReturns(nil, errors.New("banana"))
+			})
+			It("returns a retriable error", func() {
+				err := validator.Validate(lease)
+				Expect(err).To(MatchError("getting reservation for underlay ip: banana"))
+				Expect(err).NotTo(BeAssignableToTypeOf(controller.NonRetriableError("")))
+			})
+		})
+	})
+
+	Context("when the overlay subnet is reserved for another underlay ip", func() {
+		BeforeEach(func() {
+			reservationRepository.ReservationForOverlaySubnetReturns(&controller.Reservation{
+				UnderlayIP:    "1.2.3.5",
+				OverlaySubnet: "5.4.3.2/24",
+			}, nil)
+		})
+		It("returns a non-retriable error", func() {
+			err := validator.Validate(lease)
+			Expect(err).To(BeAssignableToTypeOf(controller.NonRetriableError("")))
+			Expect(err).To(MatchError("lease conflicts with reservation of 5.4.3.2/24 for 1.2.3.5"))
+		})
+
+		Context("when getting the reservation fails", func() {
+			BeforeEach(func() {
+				reservationRepository.ReservationForOverlaySubnetReturns(nil, errors.New("banana"))
+			})
+			It("returns a retriable error", func() {
+				err := validator.Validate(lease)
+				Expect(err).To(MatchError("getting reservation for overlay subnet: banana"))
+				Expect(err).NotTo(BeAssignableToTypeOf(controller.NonRetriableError("")))
+			})
+		})
+	})
 })
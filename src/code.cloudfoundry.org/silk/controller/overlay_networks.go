@@ -0,0 +1,15 @@
+package controller
+
+const (
+	OverlayNetworkActive   = "active"
+	OverlayNetworkDraining = "draining"
+	OverlayNetworkRetired  = "retired"
+)
+
+// OverlayNetwork is a range that subnet leases are carved out of. New leases
+// only come from active networks. Leases in draining networks are still
+// renewed, and retired networks are no longer part of the overlay.
+type OverlayNetwork struct {
+	CIDR  string `json:"cidr"`
+	State string `json:"state"`
+}
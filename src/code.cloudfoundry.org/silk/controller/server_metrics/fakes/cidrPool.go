@@ -3,32 +3,113 @@ package fakes
 
 import (
 	"sync"
+
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/leaser"
 )
 
 type CIDRPool struct {
-	BlockPoolSizeStub        func() int
-	blockPoolSizeMutex       sync.RWMutex
-	blockPoolSizeArgsForCall []struct {
+	IPv6EnabledStub        func() bool
+	iPv6EnabledMutex       sync.RWMutex
+	iPv6EnabledArgsForCall []struct {
+	}
+	iPv6EnabledReturns struct {
+		result1 bool
+	}
+	iPv6EnabledReturnsOnCall map[int]struct {
+		result1 bool
+	}
+	NetworksStub        func() []string
+	networksMutex       sync.RWMutex
+	networksArgsForCall []struct {
+	}
+	networksReturns struct {
+		result1 []string
+	}
+	networksReturnsOnCall map[int]struct {
+		result1 []string
+	}
+	UsageStub        func([]controller.Lease, []controller.Lease, []controller.Reservation) (leaser.PoolUsage, map[string]leaser.PoolUsage)
+	usageMutex       sync.RWMutex
+	usageArgsForCall []struct {
+		arg1 []controller.Lease
+		arg2 []controller.Lease
+		arg3 []controller.Reservation
 	}
-	blockPoolSizeReturns struct {
-		result1 int
+	usageReturns struct {
+		result1 leaser.PoolUsage
+		result2 map[string]leaser.PoolUsage
 	}
-	blockPoolSizeReturnsOnCall map[int]struct {
-		result1 int
+	usageReturnsOnCall map[int]struct {
+		result1 leaser.PoolUsage
+		result2 map[string]leaser.PoolUsage
 	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
 
-func (fake *CIDRPool) BlockPoolSize() int {
-	fake.blockPoolSizeMutex.Lock()
-	ret, specificReturn := fake.blockPoolSizeReturnsOnCall[len(fake.blockPoolSizeArgsForCall)]
-	fake.blockPoolSizeArgsForCall = append(fake.blockPoolSizeArgsForCall, struct {
+func (fake *CIDRPool) IPv6Enabled() bool {
+	fake.iPv6EnabledMutex.Lock()
+	ret, specificReturn := fake.iPv6EnabledReturnsOnCall[len(fake.iPv6EnabledArgsForCall)]
+	fake.iPv6EnabledArgsForCall = append(fake.iPv6EnabledArgsForCall, struct {
+	}{})
+	stub := fake.IPv6EnabledStub
+	fakeReturns := fake.iPv6EnabledReturns
+	fake.recordInvocation("IPv6Enabled", []interface{}{})
+	fake.iPv6EnabledMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *CIDRPool) IPv6EnabledCallCount() int {
+	fake.iPv6EnabledMutex.RLock()
+	defer fake.iPv6EnabledMutex.RUnlock()
+	return len(fake.iPv6EnabledArgsForCall)
+}
+
+func (fake *CIDRPool) IPv6EnabledCalls(stub func() bool) {
+	fake.iPv6EnabledMutex.Lock()
+	defer fake.iPv6EnabledMutex.Unlock()
+	fake.IPv6EnabledStub = stub
+}
+
+func (fake *CIDRPool) IPv6EnabledReturns(result1 bool) {
+	fake.iPv6EnabledMutex.Lock()
+	defer fake.iPv6EnabledMutex.Unlock()
+	fake.IPv6EnabledStub = nil
+	fake.iPv6EnabledReturns = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *CIDRPool) IPv6EnabledReturnsOnCall(i int, result1 bool) {
+	fake.iPv6EnabledMutex.Lock()
+	defer fake.iPv6EnabledMutex.Unlock()
+	fake.IPv6EnabledStub = nil
+	if fake.iPv6EnabledReturnsOnCall == nil {
+		fake.iPv6EnabledReturnsOnCall = make(map[int]struct {
+			result1 bool
+		})
+	}
+	fake.iPv6EnabledReturnsOnCall[i] = struct {
+		result1 bool
+	}{result1}
+}
+
+func (fake *CIDRPool) Networks() []string {
+	fake.networksMutex.Lock()
+	ret, specificReturn := fake.networksReturnsOnCall[len(fake.networksArgsForCall)]
+	fake.networksArgsForCall = append(fake.networksArgsForCall, struct {
 	}{})
-	stub := fake.BlockPoolSizeStub
-	fakeReturns := fake.blockPoolSizeReturns
-	fake.recordInvocation("BlockPoolSize", []interface{}{})
-	fake.blockPoolSizeMutex.Unlock()
+	stub := fake.NetworksStub
+	fakeReturns := fake.networksReturns
+	fake.recordInvocation("Networks", []interface{}{})
+	fake.networksMutex.Unlock()
 	if stub != nil {
 		return stub()
 	}
@@ -38,46 +119,131 @@ func (fake *CIDRPool) BlockPoolSize() int {
 	return fakeReturns.result1
 }
 
-func (fake *CIDRPool) BlockPoolSizeCallCount() int {
-	fake.blockPoolSizeMutex.RLock()
-	defer fake.blockPoolSizeMutex.RUnlock()
-	return len(fake.blockPoolSizeArgsForCall)
+func (fake *CIDRPool) NetworksCallCount() int {
+	fake.networksMutex.RLock()
+	defer fake.networksMutex.RUnlock()
+	return len(fake.networksArgsForCall)
 }
 
-func (fake *CIDRPool) BlockPoolSizeCalls(stub func() int) {
-	fake.blockPoolSizeMutex.Lock()
-	defer fake.blockPoolSizeMutex.Unlock()
-	fake.BlockPoolSizeStub = stub
+func (fake *CIDRPool) NetworksCalls(stub func() []string) {
+	fake.networksMutex.Lock()
+	defer fake.networksMutex.Unlock()
+	fake.NetworksStub = stub
 }
 
-func (fake *CIDRPool) BlockPoolSizeReturns(result1 int) {
-	fake.blockPoolSizeMutex.Lock()
-	defer fake.blockPoolSizeMutex.Unlock()
-	fake.BlockPoolSizeStub = nil
-	fake.blockPoolSizeReturns = struct {
-		result1 int
+func (fake *CIDRPool) NetworksReturns(result1 []string) {
+	fake.networksMutex.Lock()
+	defer fake.networksMutex.Unlock()
+	fake.NetworksStub = nil
+	fake.networksReturns = struct {
+		result1 []string
 	}{result1}
 }
 
-func (fake *CIDRPool) BlockPoolSizeReturnsOnCall(i int, result1 int) {
-	fake.blockPoolSizeMutex.Lock()
-	defer fake.blockPoolSizeMutex.Unlock()
-	fake.BlockPoolSizeStub = nil
-	if fake.blockPoolSizeReturnsOnCall == nil {
-		fake.blockPoolSizeReturnsOnCall = make(map[int]struct {
-			result1 int
+func (fake *CIDRPool) NetworksReturnsOnCall(i int, result1 []string) {
+	fake.networksMutex.Lock()
+	defer fake.networksMutex.Unlock()
+	fake.NetworksStub = nil
+	if fake.networksReturnsOnCall == nil {
+		fake.networksReturnsOnCall = make(map[int]struct {
+			result1 []string
 		})
 	}
-	fake.blockPoolSizeReturnsOnCall[i] = struct {
-		result1 int
+	fake.networksReturnsOnCall[i] = struct {
+		result1 []string
 	}{result1}
 }
 
+func (fake *CIDRPool) Usage(arg1 []controller.Lease, arg2 []controller.Lease, arg3 []controller.Reservation) (leaser.PoolUsage, map[string]leaser.PoolUsage) {
+	var arg1Copy []controller.Lease
+	if arg1 != nil {
+		arg1Copy = make([]controller.Lease, len(arg1))
+		copy(arg1Copy, arg1)
+	}
+	var arg2Copy []controller.Lease
+	if arg2 != nil {
+		arg2Copy = make([]controller.Lease, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	var arg3Copy []controller.Reservation
+	if arg3 != nil {
+		arg3Copy = make([]controller.Reservation, len(arg3))
+		copy(arg3Copy, arg3)
+	}
+	fake.usageMutex.Lock()
+	ret, specificReturn := fake.usageReturnsOnCall[len(fake.usageArgsForCall)]
+	fake.usageArgsForCall = append(fake.usageArgsForCall, struct {
+		arg1 []controller.Lease
+		arg2 []controller.Lease
+		arg3 []controller.Reservation
+	}{arg1Copy, arg2Copy, arg3Copy})
+	stub := fake.UsageStub
+	fakeReturns := fake.usageReturns
+	fake.recordInvocation("Usage", []interface{}{arg1Copy, arg2Copy, arg3Copy})
+	fake.usageMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *CIDRPool) UsageCallCount() int {
+	fake.usageMutex.RLock()
+	defer fake.usageMutex.RUnlock()
+	return len(fake.usageArgsForCall)
+}
+
+func (fake *CIDRPool) UsageCalls(stub func([]controller.Lease, []controller.Lease, []controller.Reservation) (leaser.PoolUsage, map[string]leaser.PoolUsage)) {
+	fake.usageMutex.Lock()
+	defer fake.usageMutex.Unlock()
+	fake.UsageStub = stub
+}
+
+func (fake *CIDRPool) UsageArgsForCall(i int) ([]controller.Lease, []controller.Lease, []controller.Reservation) {
+	fake.usageMutex.RLock()
+	defer fake.usageMutex.RUnlock()
+	argsForCall := fake.usageArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *CIDRPool) UsageReturns(result1 leaser.PoolUsage, result2 map[string]leaser.PoolUsage) {
+	fake.usageMutex.Lock()
+	defer fake.usageMutex.Unlock()
+	fake.UsageStub = nil
+	fake.usageReturns = struct {
+		result1 leaser.PoolUsage
+		result2 map[string]leaser.PoolUsage
+	}{result1, result2}
+}
+
+func (fake *CIDRPool) UsageReturnsOnCall(i int, result1 leaser.PoolUsage, result2 map[string]leaser.PoolUsage) {
+	fake.usageMutex.Lock()
+	defer fake.usageMutex.Unlock()
+	fake.UsageStub = nil
+	if fake.usageReturnsOnCall == nil {
+		fake.usageReturnsOnCall = make(map[int]struct {
+			result1 leaser.PoolUsage
+			result2 map[string]leaser.PoolUsage
+		})
+	}
+	fake.usageReturnsOnCall[i] = struct {
+		result1 leaser.PoolUsage
+		result2 map[string]leaser.PoolUsage
+	}{result1, result2}
+}
+
 func (fake *CIDRPool) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
-	fake.blockPoolSizeMutex.RLock()
-	defer fake.blockPoolSizeMutex.RUnlock()
+	fake.iPv6EnabledMutex.RLock()
+	defer fake.iPv6EnabledMutex.RUnlock()
+	fake.networksMutex.RLock()
+	defer fake.networksMutex.RUnlock()
+	fake.usageMutex.RLock()
+	defer fake.usageMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -33,6 +33,28 @@ type DatabaseHandler struct {
 		result1 []controller.Lease
 		result2 error
 	}
+	AllReservationsStub        func() ([]controller.Reservation, error)
+	allReservationsMutex       sync.RWMutex
+	allReservationsArgsForCall []struct {
+	}
+	allReservationsReturns struct {
+		result1 []controller.Reservation
+		result2 error
+	}
+	allReservationsReturnsOnCall map[int]struct {
+		result1 []controller.Reservation
+		result2 error
+	}
+	ReclaimedLeaseCountStub        func() int64
+	reclaimedLeaseCountMutex       sync.RWMutex
+	reclaimedLeaseCountArgsForCall []struct {
+	}
+	reclaimedLeaseCountReturns struct {
+		result1 int64
+	}
+	reclaimedLeaseCountReturnsOnCall map[int]struct {
+		result1 int64
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -157,6 +179,115 @@ func (fake *DatabaseHandler) AllActiveReturnsOnCall(i int, result1 []controller.
 	}{result1, result2}
 }
 
+func (fake *DatabaseHandler) AllReservations() ([]controller.Reservation, error) {
+	fake.allReservationsMutex.Lock()
+	ret, specificReturn := fake.allReservationsReturnsOnCall[len(fake.allReservationsArgsForCall)]
+	fake.allReservationsArgsForCall = append(fake.allReservationsArgsForCall, struct {
+	}{})
+	stub := fake.AllReservationsStub
+	fakeReturns := fake.allReservationsReturns
+	fake.recordInvocation("AllReservations", []interface{}{})
+	fake.allReservationsMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *DatabaseHandler) AllReservationsCallCount() int {
+	fake.allReservationsMutex.RLock()
+	defer fake.allReservationsMutex.RUnlock()
+	return len(fake.allReservationsArgsForCall)
+}
+
+func (fake *DatabaseHandler) AllReservationsCalls(stub func() ([]controller.Reservation, error)) {
+	fake.allReservationsMutex.Lock()
+	defer fake.allReservationsMutex.Unlock()
+	fake.AllReservationsStub = stub
+}
+
+func (fake *DatabaseHandler) AllReservationsReturns(result1 []controller.Reservation, result2 error) {
+	fake.allReservationsMutex.Lock()
+	defer fake.allReservationsMutex.Unlock()
+	fake.AllReservationsStub = nil
+	fake.allReservationsReturns = struct {
+		result1 []controller.Reservation
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) AllReservationsReturnsOnCall(i int, result1 []controller.Reservation, result2 error) {
+	fake.allReservationsMutex.Lock()
+	defer fake.allReservationsMutex.Unlock()
+	fake.AllReservationsStub = nil
+	if fake.allReservationsReturnsOnCall == nil {
+		fake.allReservationsReturnsOnCall = make(map[int]struct {
+			result1 []controller.Reservation
+			result2 error
+		})
+	}
+	fake.allReservationsReturnsOnCall[i] = struct {
+		result1 []controller.Reservation
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *DatabaseHandler) ReclaimedLeaseCount() int64 {
+	fake.reclaimedLeaseCountMutex.Lock()
+	ret, specificReturn := fake.reclaimedLeaseCountReturnsOnCall[len(fake.reclaimedLeaseCountArgsForCall)]
+	fake.reclaimedLeaseCountArgsForCall = append(fake.reclaimedLeaseCountArgsForCall, struct {
+	}{})
+	stub := fake.ReclaimedLeaseCountStub
+	fakeReturns := fake.reclaimedLeaseCountReturns
+	fake.recordInvocation("ReclaimedLeaseCount", []interface{}{})
+	fake.reclaimedLeaseCountMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *DatabaseHandler) ReclaimedLeaseCountCallCount() int {
+	fake.reclaimedLeaseCountMutex.RLock()
+	defer fake.reclaimedLeaseCountMutex.RUnlock()
+	return len(fake.reclaimedLeaseCountArgsForCall)
+}
+
+func (fake *DatabaseHandler) ReclaimedLeaseCountCalls(stub func() int64) {
+	fake.reclaimedLeaseCountMutex.Lock()
+	defer fake.reclaimedLeaseCountMutex.Unlock()
+	fake.ReclaimedLeaseCountStub = stub
+}
+
+func (fake *DatabaseHandler) ReclaimedLeaseCountReturns(result1 int64) {
+	fake.reclaimedLeaseCountMutex.Lock()
+	defer fake.reclaimedLeaseCountMutex.Unlock()
+	fake.ReclaimedLeaseCountStub = nil
+	fake.reclaimedLeaseCountReturns = struct {
+		result1 int64
+	}{result1}
+}
+
+func (fake *DatabaseHandler) ReclaimedLeaseCountReturnsOnCall(i int, result1 int64) {
+	fake.reclaimedLeaseCountMutex.Lock()
+	defer fake.reclaimedLeaseCountMutex.Unlock()
+	fake.ReclaimedLeaseCountStub = nil
+	if fake.reclaimedLeaseCountReturnsOnCall == nil {
+		fake.reclaimedLeaseCountReturnsOnCall = make(map[int]struct {
+			result1 int64
+		})
+	}
+	fake.reclaimedLeaseCountReturnsOnCall[i] = struct {
+		result1 int64
+	}{result1}
+}
+
 func (fake *DatabaseHandler) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
@@ -164,6 +295,10 @@ func (fake *DatabaseHandler) Invocations() map[string][][]interface{} {
 	defer fake.allMutex.RUnlock()
 	fake.allActiveMutex.RLock()
 	defer fake.allActiveMutex.RUnlock()
+	fake.allReservationsMutex.RLock()
+	defer fake.allReservationsMutex.RUnlock()
+	fake.reclaimedLeaseCountMutex.RLock()
+	defer fake.reclaimedLeaseCountMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
@@ -0,0 +1,241 @@
+package server_metrics
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"code.cloudfoundry.org/cf-networking-helpers/db/monitor"
+	"code.cloudfoundry.org/cf-networking-helpers/metrics"
+	"code.cloudfoundry.org/lager/v3"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const (
+	namespace = "silk_controller"
+
+	// AcquireRouteName is the route whose 409 responses are counted as
+	// acquire conflicts.
+	AcquireRouteName = "LeasesAcquire"
+
+	// WatchRouteName is the long polling route. Its requests last until a
+	// lease changes or the wait runs out, so they are observed in their own
+	// histogram instead of the request durations.
+	WatchRouteName = "LeasesWatch"
+)
+
+// resetOnRead lists the db monitor sources whose getters reset the value
+// they report. Reading them here would hide samples from the metron emitter,
+// so they are left out and the db query duration histogram is exposed instead.
+var resetOnRead = map[string]bool{
+	"DBQueriesInFlight":  true,
+	"DBQueryDurationMax": true,
+}
+
+// PrometheusMetrics exposes the metric sources emitted to metron as gauges,
+// together with request and db query histograms that are only available to
+// Prometheus.
+type PrometheusMetrics struct {
+	registry         *prometheus.Registry
+	requestDuration  *prometheus.HistogramVec
+	watchDuration    prometheus.Histogram
+	acquireConflicts prometheus.Counter
+	dbQueryDuration  prometheus.Histogram
+}
+
+func NewPrometheusMetrics(logger lager.Logger, sources ...metrics.MetricSource) (*PrometheusMetrics, error) {
+	p := &PrometheusMetrics{
+		registry: prometheus.NewRegistry(),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "request_duration_seconds",
+			Help:      "Time taken to serve a request, by route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route"}),
+		watchDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "lease_watch_duration_seconds",
+			Help:      "Time a lease watch was held open.",
+			Buckets:   []float64{0.1, 1, 5, 10, 20, 30, 45, 60, 90, 120},
+		}),
+		acquireConflicts: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "lease_acquire_conflicts_total",
+			Help:      "Lease acquisitions rejected with a conflict.",
+		}),
+		dbQueryDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Time taken by database queries.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+	}
+
+	sourceCollector, err := newMetricSourceCollector(logger.Session("prometheus-metrics"), sources)
+	if err != nil {
+		return nil, err
+	}
+
+	collectors := []prometheus.Collector{
+		sourceCollector,
+		p.requestDuration,
+		p.watchDuration,
+		p.acquireConflicts,
+		p.dbQueryDuration,
+	}
+	for _, collector := range collectors {
+		err := p.registry.Register(collector)
+		if err != nil {
+			return nil, fmt.Errorf("registering collector: %s", err)
+		}
+	}
+	return p, nil
+}
+
+func (p *PrometheusMetrics) Handler() http.Handler {
+	return promhttp.HandlerFor(p.registry, promhttp.HandlerOpts{})
+}
+
+// WrapRoute observes the duration of every request served by handler.
+func (p *PrometheusMetrics) WrapRoute(name string, handler http.Handler) http.Handler {
+	var requestDuration prometheus.Observer = p.watchDuration
+	if name != WatchRouteName {
+		requestDuration = p.requestDuration.WithLabelValues(name)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		handler.ServeHTTP(recorder, r)
+		requestDuration.Observe(time.Since(start).Seconds())
+
+		if name == AcquireRouteName && recorder.status == http.StatusConflict {
+			p.acquireConflicts.Inc()
+		}
+	})
+}
+
+// MonitorDB returns a monitor that records every query duration before
+// passing the query on to m.
+func (p *PrometheusMetrics) MonitorDB(m monitor.Monitor) monitor.Monitor {
+	return &histogramMonitor{monitor: m, histogram: p.dbQueryDuration}
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	s.status = status
+	s.ResponseWriter.WriteHeader(status)
+}
+
+type histogramMonitor struct {
+	monitor   monitor.Monitor
+	histogram prometheus.Histogram
+}
+
+func (h *histogramMonitor) Monitor(f func() error) error {
+	start := time.Now()
+	defer func() {
+		h.histogram.Observe(time.Since(start).Seconds())
+	}()
+	return h.monitor.Monitor(f)
+}
+
+func (h *histogramMonitor) Total() int64     { return h.monitor.Total() }
+func (h *histogramMonitor) Succeeded() int64 { return h.monitor.Succeeded() }
+func (h *histogramMonitor) Failed() int64    { return h.monitor.Failed() }
+
+func (h *histogramMonitor) ReadAndResetDurationMax() time.Duration {
+	return h.monitor.ReadAndResetDurationMax()
+}
+
+func (h *histogramMonitor) ReadAndResetInFlightMax() int64 {
+	return h.monitor.ReadAndResetInFlightMax()
+}
+
+type metricSourceCollector struct {
+	logger  lager.Logger
+	sources []metrics.MetricSource
+	descs   []*prometheus.Desc
+}
+
+func newMetricSourceCollector(logger lager.Logger, sources []metrics.MetricSource) (*metricSourceCollector, error) {
+	c := &metricSourceCollector{logger: logger}
+	names := map[string]bool{}
+	for _, source := range sources {
+		if resetOnRead[source.Name] {
+			continue
+		}
+		if names[source.Name] {
+			return nil, fmt.Errorf("duplicate metric name: %s", PrometheusName(source))
+		}
+		names[source.Name] = true
+		c.sources = append(c.sources, source)
+		c.descs = append(c.descs, newMetricSourceDesc(source))
+	}
+	return c, nil
+}
+
+// newMetricSourceDesc exposes the per network sources, named with
+// NetworkMetricName, as silk_controller_network_<name> with a network label.
+func newMetricSourceDesc(source metrics.MetricSource) *prometheus.Desc {
+	name, network, found := strings.Cut(source.Name, ".")
+	help := fmt.Sprintf("Same as the %s metric emitted to metron.", name)
+	if !found {
+		return prometheus.NewDesc(PrometheusName(source), help, nil, nil)
+	}
+	networkSource := metrics.MetricSource{Name: "network" + upperFirst(name), Unit: source.Unit}
+	return prometheus.NewDesc(PrometheusName(networkSource), help, nil, prometheus.Labels{"network": network})
+}
+
+func upperFirst(s string) string {
+	runes := []rune(s)
+	if len(runes) > 0 {
+		runes[0] = unicode.ToUpper(runes[0])
+	}
+	return string(runes)
+}
+
+func (c *metricSourceCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range c.descs {
+		ch <- desc
+	}
+}
+
+func (c *metricSourceCollector) Collect(ch chan<- prometheus.Metric) {
+	for i, source := range c.sources {
+		value, err := source.Getter()
+		if err != nil {
+			c.logger.Error("get-metric-value", err, lager.Data{"metric": source.Name})
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.descs[i], prometheus.GaugeValue, value)
+	}
+}
+
+// PrometheusName converts the camel case name of a metric source to a snake
+// case Prometheus name, for example freeSingleIPLeases becomes
+// silk_controller_free_single_ip_leases. The unit is appended when set.
+func PrometheusName(source metrics.MetricSource) string {
+	runes := []rune(source.Name)
+	var name strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			previousLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if previousLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				name.WriteRune('_')
+			}
+		}
+		name.WriteRune(unicode.ToLower(r))
+	}
+	if source.Unit != "" {
+		name.WriteString("_" + source.Unit)
+	}
+	return namespace + "_" + name.String()
+}
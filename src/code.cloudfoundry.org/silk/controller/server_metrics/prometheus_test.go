@@ -0,0 +1,154 @@
+package server_metrics_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"code.cloudfoundry.org/cf-networking-helpers/db/monitor"
+	"code.cloudfoundry.org/cf-networking-helpers/metrics"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller/server_metrics"
+	"github.com/onsi/gomega/gbytes"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("PrometheusMetrics", func() {
+	var (
+		logger            *lagertest.TestLogger
+		sources           []metrics.MetricSource
+		prometheusMetrics *server_metrics.PrometheusMetrics
+	)
+
+	scrape := func() string {
+		resp := httptest.NewRecorder()
+		prometheusMetrics.Handler().ServeHTTP(resp, httptest.NewRequest("GET", "/metrics", nil))
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		body, err := io.ReadAll(resp.Body)
+		Expect(err).NotTo(HaveOccurred())
+		return string(body)
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		sources = []metrics.MetricSource{
+			{Name: "freeSingleIPLeases", Getter: func() (float64, error) { return 42, nil }},
+			{Name: "uptime", Unit: "seconds", Getter: func() (float64, error) { return 7, nil }},
+			{Name: "DBOpenConnections", Getter: func() (float64, error) { return 3, nil }},
+			{Name: "freeBlockLeases.10.255.0.0/16", Getter: func() (float64, error) { return 5, nil }},
+			{Name: "freeBlockLeases.10.254.0.0/16", Getter: func() (float64, error) { return 6, nil }},
+			{Name: "DBQueryDurationMax", Getter: func() (float64, error) {
+				Fail("reset on read sources must not be collected")
+				return 0, nil
+			}},
+		}
+	})
+
+	JustBeforeEach(func() {
+		var err error
+		prometheusMetrics, err = server_metrics.NewPrometheusMetrics(logger, sources...)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("exposes every metric source as a gauge", func() {
+		body := scrape()
+		Expect(body).To(ContainSubstring("# TYPE silk_controller_free_single_ip_leases gauge"))
+		Expect(body).To(ContainSubstring("silk_controller_free_single_ip_leases 42"))
+		Expect(body).To(ContainSubstring("silk_controller_uptime_seconds 7"))
+		Expect(body).To(ContainSubstring("silk_controller_db_open_connections 3"))
+		Expect(body).NotTo(ContainSubstring("db_query_duration_max"))
+	})
+
+	It("exposes per network metric sources with a network label", func() {
+		body := scrape()
+		Expect(body).To(ContainSubstring(`silk_controller_network_free_block_leases{network="10.255.0.0/16"} 5`))
+		Expect(body).To(ContainSubstring(`silk_controller_network_free_block_leases{network="10.254.0.0/16"} 6`))
+	})
+
+	Context("when a metric source fails", func() {
+		BeforeEach(func() {
+			sources[0].Getter = func() (float64, error) { return 0, errors.New("banana") }
+		})
+
+		It("logs the error and exposes the other metrics", func() {
+			body := scrape()
+			Expect(body).NotTo(ContainSubstring("silk_controller_free_single_ip_leases "))
+			Expect(body).To(ContainSubstring("silk_controller_uptime_seconds 7"))
+			Expect(logger).To(gbytes.Say("get-metric-value.*banana.*freeSingleIPLeases"))
+		})
+	})
+
+	Context("when two metric sources have the same name", func() {
+		It("returns an error", func() {
+			_, err := server_metrics.NewPrometheusMetrics(logger, append(sources, sources[0])...)
+			Expect(err).To(MatchError("duplicate metric name: silk_controller_free_single_ip_leases"))
+		})
+	})
+
+	Describe("WrapRoute", func() {
+		serve := func(name string, status int) {
+			handler := prometheusMetrics.WrapRoute(name, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(status)
+			}))
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, httptest.NewRequest("PUT", "/", nil))
+			Expect(resp.Code).To(Equal(status))
+		}
+
+		It("observes the request duration per route", func() {
+			serve("LeasesRenew", http.StatusOK)
+			serve("LeasesRenew", http.StatusOK)
+			serve("LeasesIndex", http.StatusOK)
+
+			body := scrape()
+			Expect(body).To(ContainSubstring(`silk_controller_request_duration_seconds_count{route="LeasesRenew"} 2`))
+			Expect(body).To(ContainSubstring(`silk_controller_request_duration_seconds_count{route="LeasesIndex"} 1`))
+		})
+
+		It("observes the watch route in its own histogram", func() {
+			serve(server_metrics.WatchRouteName, http.StatusOK)
+
+			body := scrape()
+			Expect(body).To(ContainSubstring("silk_controller_lease_watch_duration_seconds_count 1"))
+			Expect(body).NotTo(ContainSubstring(`silk_controller_request_duration_seconds_count{route="LeasesWatch"}`))
+		})
+
+		It("counts conflicts returned by the acquire route", func() {
+			serve(server_metrics.AcquireRouteName, http.StatusConflict)
+			serve(server_metrics.AcquireRouteName, http.StatusOK)
+			serve("LeasesRenew", http.StatusConflict)
+
+			Expect(scrape()).To(ContainSubstring("silk_controller_lease_acquire_conflicts_total 1"))
+		})
+	})
+
+	Describe("MonitorDB", func() {
+		It("observes query durations and delegates to the wrapped monitor", func() {
+			inner := monitor.New()
+			m := prometheusMetrics.MonitorDB(inner)
+
+			Expect(m.Monitor(func() error { return nil })).To(Succeed())
+			Expect(m.Monitor(func() error { return errors.New("banana") })).To(MatchError("banana"))
+
+			Expect(inner.Total()).To(Equal(int64(2)))
+			Expect(m.Succeeded()).To(Equal(int64(1)))
+			Expect(m.Failed()).To(Equal(int64(1)))
+			Expect(scrape()).To(ContainSubstring("silk_controller_db_query_duration_seconds_count 2"))
+		})
+	})
+
+	Describe("PrometheusName", func() {
+		DescribeTable("converts metric source names",
+			func(name, unit, expected string) {
+				Expect(server_metrics.PrometheusName(metrics.MetricSource{Name: name, Unit: unit})).To(Equal(expected))
+			},
+			Entry("camel case", "totalLeases", "", "silk_controller_total_leases"),
+			Entry("acronym in the middle", "freeSingleIPLeases", "", "silk_controller_free_single_ip_leases"),
+			Entry("leading acronym", "DBQueriesTotal", "", "silk_controller_db_queries_total"),
+			Entry("with a unit", "uptime", "seconds", "silk_controller_uptime_seconds"),
+		)
+	})
+})
@@ -1,19 +1,32 @@
 package server_metrics
 
 import (
+	"strings"
+	"sync"
+	"time"
+
 	"code.cloudfoundry.org/cf-networking-helpers/metrics"
 	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/leaser"
 )
 
+// poolUsageMaxAge lets the pool usage sources that are read one after the
+// other share a single read of the leases.
+const poolUsageMaxAge = time.Second
+
 //go:generate counterfeiter -o fakes/databaseHandler.go --fake-name DatabaseHandler . databaseHandler
 type databaseHandler interface {
 	All() ([]controller.Lease, error)
 	AllActive(int) ([]controller.Lease, error)
+	ReclaimedLeaseCount() int64
+	AllReservations() ([]controller.Reservation, error)
 }
 
 //go:generate counterfeiter -o fakes/cidrPool.go --fake-name CIDRPool . cidrPool
 type cidrPool interface {
-	BlockPoolSize() int
+	Networks() []string
+	IPv6Enabled() bool
+	Usage(leases, active []controller.Lease, reservations []controller.Reservation) (leaser.PoolUsage, map[string]leaser.PoolUsage)
 }
 
 func NewTotalLeasesSource(lister databaseHandler) metrics.MetricSource {
@@ -27,30 +40,154 @@ func NewTotalLeasesSource(lister databaseHandler) metrics.MetricSource {
 	}
 }
 
-func NewFreeLeasesSource(lister databaseHandler, pool cidrPool) metrics.MetricSource {
+func NewStaleLeasesSource(lister databaseHandler, seconds int) metrics.MetricSource {
 	return metrics.MetricSource{
-		Name: "freeLeases",
+		Name: "staleLeases",
 		Unit: "",
 		Getter: func() (float64, error) {
 			allLeases, err := lister.All()
-			size := pool.BlockPoolSize()
-			return float64(size - len(allLeases)), err
+			if err != nil {
+				return 0.0, err
+			}
+			allActiveLeases, err := lister.AllActive(seconds)
+
+			return float64(len(allLeases) - len(allActiveLeases)), err
 		},
 	}
 }
 
-func NewStaleLeasesSource(lister databaseHandler, seconds int) metrics.MetricSource {
+// NewReclaimedLeasesSource counts the expired leases that this controller
+// process has given to another cell since it started. The count starts at 0
+// on every restart.
+func NewReclaimedLeasesSource(lister databaseHandler) metrics.MetricSource {
 	return metrics.MetricSource{
-		Name: "staleLeases",
+		Name: "reclaimedLeases",
 		Unit: "",
 		Getter: func() (float64, error) {
-			allLeases, err := lister.All()
+			return float64(lister.ReclaimedLeaseCount()), nil
+		},
+	}
+}
+
+type poolKind struct {
+	name  string
+	usage func(leaser.PoolUsage) leaser.SubnetUsage
+}
+
+var (
+	blockKind    = poolKind{"Block", func(u leaser.PoolUsage) leaser.SubnetUsage { return u.Block }}
+	singleIPKind = poolKind{"SingleIP", func(u leaser.PoolUsage) leaser.SubnetUsage { return u.SingleIP }}
+	blockV6Kind  = poolKind{"BlockV6", func(u leaser.PoolUsage) leaser.SubnetUsage { return u.BlockV6 }}
+)
+
+// NewPoolUsageSources reports the free, used, stale and reserved leases of
+// the block, single IP and, when enabled, IPv6 block pools, e.g.
+// freeSingleIPLeases. Reserved subnets are not free.
+// The same counts are reported for each overlay network with the network
+// appended to the name, e.g. usedBlockLeases.10.255.0.0/16. Leases are
+// counted by pool membership, leases outside of the pools are left out.
+// freeLeases is the same as freeBlockLeases.
+func NewPoolUsageSources(lister databaseHandler, pool cidrPool, stalenessThresholdSeconds int) []metrics.MetricSource {
+	reader := &poolUsageReader{
+		lister:                    lister,
+		pool:                      pool,
+		stalenessThresholdSeconds: stalenessThresholdSeconds,
+	}
+
+	sources := []metrics.MetricSource{
+		reader.source("freeLeases", "", blockKind, leaser.SubnetUsage.Free),
+	}
+
+	kinds := []poolKind{blockKind, singleIPKind}
+	if pool.IPv6Enabled() {
+		kinds = append(kinds, blockV6Kind)
+	}
+	sources = append(sources, reader.sources("", kinds)...)
+
+	for _, network := range pool.Networks() {
+		networkKinds := []poolKind{blockKind, singleIPKind}
+		if strings.Contains(network, ":") {
+			networkKinds = []poolKind{blockV6Kind}
+		}
+		sources = append(sources, reader.sources(network, networkKinds)...)
+	}
+	return sources
+}
+
+// NetworkMetricName is the name of the source reporting the named metric for
+// one overlay network.
+func NetworkMetricName(name, network string) string {
+	return name + "." + network
+}
+
+type poolUsageReader struct {
+	lister                    databaseHandler
+	pool                      cidrPool
+	stalenessThresholdSeconds int
+
+	mutex      sync.Mutex
+	readAt     time.Time
+	total      leaser.PoolUsage
+	perNetwork map[string]leaser.PoolUsage
+}
+
+func (r *poolUsageReader) sources(network string, kinds []poolKind) []metrics.MetricSource {
+	var sources []metrics.MetricSource
+	for _, kind := range kinds {
+		sources = append(sources,
+			r.source("free"+kind.name+"Leases", network, kind, leaser.SubnetUsage.Free),
+			r.source("used"+kind.name+"Leases", network, kind, func(u leaser.SubnetUsage) int { return u.Used }),
+			r.source("stale"+kind.name+"Leases", network, kind, func(u leaser.SubnetUsage) int { return u.Stale }),
+			r.source("reserved"+kind.name+"Leases", network, kind, func(u leaser.SubnetUsage) int { return u.Reserved }),
+		)
+	}
+	return sources
+}
+
+func (r *poolUsageReader) source(name, network string, kind poolKind, count func(leaser.SubnetUsage) int) metrics.MetricSource {
+	if network != "" {
+		name = NetworkMetricName(name, network)
+	}
+	return metrics.MetricSource{
+		Name: name,
+		Unit: "",
+		Getter: func() (float64, error) {
+			total, perNetwork, err := r.read()
 			if err != nil {
 				return 0.0, err
 			}
-			allActiveLeases, err := lister.AllActive(seconds)
-
-			return float64(len(allLeases) - len(allActiveLeases)), err
+			usage := total
+			if network != "" {
+				usage = perNetwork[network]
+			}
+			return float64(count(kind.usage(usage))), nil
 		},
 	}
 }
+
+func (r *poolUsageReader) read() (leaser.PoolUsage, map[string]leaser.PoolUsage, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if time.Since(r.readAt) < poolUsageMaxAge {
+		return r.total, r.perNetwork, nil
+	}
+
+	allLeases, err := r.lister.All()
+	if err != nil {
+		return leaser.PoolUsage{}, nil, err
+	}
+	allActiveLeases, err := r.lister.AllActive(r.stalenessThresholdSeconds)
+	if err != nil {
+		return leaser.PoolUsage{}, nil, err
+	}
+
+	reservations, err := r.lister.AllReservations()
+	if err != nil {
+		return leaser.PoolUsage{}, nil, err
+	}
+
+	r.total, r.perNetwork = r.pool.Usage(allLeases, allActiveLeases, reservations)
+	r.readAt = time.Now()
+	return r.total, r.perNetwork, nil
+}
@@ -1,7 +1,11 @@
 package server_metrics_test
 
 import (
+	"errors"
+
+	"code.cloudfoundry.org/cf-networking-helpers/metrics"
 	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/controller/leaser"
 	"code.cloudfoundry.org/silk/controller/server_metrics"
 	"code.cloudfoundry.org/silk/controller/server_metrics/fakes"
 
@@ -30,9 +34,10 @@ var _ = Describe("ServerMetrics", func() {
 		fakeDatabaseHandler = &fakes.DatabaseHandler{}
 		fakeDatabaseHandler.AllReturns(allLeases, nil)
 		fakeDatabaseHandler.AllActiveReturns([]controller.Lease{allLeases[0]}, nil)
+		fakeDatabaseHandler.AllReservationsReturns([]controller.Reservation{{UnderlayIP: "10.244.0.9", OverlaySubnet: "10.255.9.0/24"}}, nil)
 
 		fakeCIDRPool = &fakes.CIDRPool{}
-		fakeCIDRPool.BlockPoolSizeReturns(100)
+		fakeDatabaseHandler.ReclaimedLeaseCountReturns(3)
 	})
 
 	Describe("totalLeases", func() {
@@ -50,36 +55,160 @@ var _ = Describe("ServerMetrics", func() {
 		})
 	})
 
-	Describe("freeLeases", func() {
-		It("returns the total number of free leases", func() {
-			source := server_metrics.NewFreeLeasesSource(fakeDatabaseHandler, fakeCIDRPool)
+	Describe("staleLeases", func() {
+		It("returns the total number of stale leases in the datastore", func() {
+			source := server_metrics.NewStaleLeasesSource(fakeDatabaseHandler, 5)
 
-			Expect(source.Name).To(Equal("freeLeases"))
+			Expect(source.Name).To(Equal("staleLeases"))
 			Expect(source.Unit).To(Equal(""))
 
 			value, err := source.Getter()
 			Expect(err).NotTo(HaveOccurred())
 
 			Expect(fakeDatabaseHandler.AllCallCount()).To(Equal(1))
-			Expect(fakeCIDRPool.BlockPoolSizeCallCount()).To(Equal(1))
-			Expect(value).To(Equal(98.0))
+			Expect(fakeDatabaseHandler.AllActiveCallCount()).To(Equal(1))
+			Expect(fakeDatabaseHandler.AllActiveArgsForCall(0)).To(Equal(5))
+			Expect(value).To(Equal(1.0))
 		})
 	})
 
-	Describe("staleLeases", func() {
-		It("returns the total number of stale leases in the datastore", func() {
-			source := server_metrics.NewStaleLeasesSource(fakeDatabaseHandler, 5)
+	Describe("NewPoolUsageSources", func() {
+		var sources map[string]metrics.MetricSource
+
+		BeforeEach(func() {
+			fakeCIDRPool.NetworksReturns([]string{"10.255.0.0/16", "fd00::/48"})
+			fakeCIDRPool.UsageReturns(
+				leaser.PoolUsage{
+					Block:    leaser.SubnetUsage{Size: 100, Used: 7, Stale: 2, Reserved: 3},
+					SingleIP: leaser.SubnetUsage{Size: 50, Used: 3, Stale: 1},
+					BlockV6:  leaser.SubnetUsage{Size: 20, Used: 4},
+				},
+				map[string]leaser.PoolUsage{
+					"10.255.0.0/16": {Block: leaser.SubnetUsage{Size: 60, Used: 5, Stale: 2, Reserved: 1}, SingleIP: leaser.SubnetUsage{Size: 50, Used: 3, Stale: 1}},
+					"fd00::/48":     {BlockV6: leaser.SubnetUsage{Size: 20, Used: 4}},
+				},
+			)
+		})
 
-			Expect(source.Name).To(Equal("staleLeases"))
-			Expect(source.Unit).To(Equal(""))
+		JustBeforeEach(func() {
+			sources = map[string]metrics.MetricSource{}
+			for _, source := range server_metrics.NewPoolUsageSources(fakeDatabaseHandler, fakeCIDRPool, 5) {
+				Expect(source.Unit).To(Equal(""))
+				sources[source.Name] = source
+			}
+		})
 
-			value, err := source.Getter()
+		value := func(name string) float64 {
+			Expect(sources).To(HaveKey(name))
+			v, err := sources[name].Getter()
 			Expect(err).NotTo(HaveOccurred())
+			return v
+		}
+
+		It("reports the usage of each pool", func() {
+			Expect(value("freeLeases")).To(Equal(90.0))
+			Expect(value("freeBlockLeases")).To(Equal(90.0))
+			Expect(value("usedBlockLeases")).To(Equal(7.0))
+			Expect(value("staleBlockLeases")).To(Equal(2.0))
+			Expect(value("reservedBlockLeases")).To(Equal(3.0))
+			Expect(value("freeSingleIPLeases")).To(Equal(47.0))
+			Expect(value("usedSingleIPLeases")).To(Equal(3.0))
+			Expect(value("staleSingleIPLeases")).To(Equal(1.0))
 
+			Expect(fakeDatabaseHandler.AllActiveArgsForCall(0)).To(Equal(5))
+			leases, active, reservations := fakeCIDRPool.UsageArgsForCall(0)
+			Expect(leases).To(Equal(allLeases))
+			Expect(active).To(Equal([]controller.Lease{allLeases[0]}))
+			Expect(reservations).To(Equal([]controller.Reservation{{UnderlayIP: "10.244.0.9", OverlaySubnet: "10.255.9.0/24"}}))
+		})
+
+		It("reports the usage of each overlay network", func() {
+			Expect(value("freeBlockLeases.10.255.0.0/16")).To(Equal(54.0))
+			Expect(value("usedBlockLeases.10.255.0.0/16")).To(Equal(5.0))
+			Expect(value("reservedBlockLeases.10.255.0.0/16")).To(Equal(1.0))
+			Expect(value("staleBlockLeases.10.255.0.0/16")).To(Equal(2.0))
+			Expect(value("freeSingleIPLeases.10.255.0.0/16")).To(Equal(47.0))
+			Expect(value("usedBlockV6Leases.fd00::/48")).To(Equal(4.0))
+			Expect(sources).NotTo(HaveKey("freeBlockLeases.fd00::/48"))
+			Expect(sources).NotTo(HaveKey("freeBlockV6Leases.10.255.0.0/16"))
+		})
+
+		It("reads the leases once for all the sources", func() {
+			for name := range sources {
+				value(name)
+			}
 			Expect(fakeDatabaseHandler.AllCallCount()).To(Equal(1))
 			Expect(fakeDatabaseHandler.AllActiveCallCount()).To(Equal(1))
-			Expect(fakeDatabaseHandler.AllActiveArgsForCall(0)).To(Equal(5))
-			Expect(value).To(Equal(1.0))
+			Expect(fakeDatabaseHandler.AllReservationsCallCount()).To(Equal(1))
+			Expect(fakeCIDRPool.UsageCallCount()).To(Equal(1))
+		})
+
+		Context("when ipv6 is not enabled", func() {
+			It("does not report the ipv6 block pool", func() {
+				Expect(sources).NotTo(HaveKey("freeBlockV6Leases"))
+			})
+		})
+
+		Context("when ipv6 is enabled", func() {
+			BeforeEach(func() {
+				fakeCIDRPool.IPv6EnabledReturns(true)
+			})
+
+			It("reports the ipv6 block pool", func() {
+				Expect(value("freeBlockV6Leases")).To(Equal(16.0))
+				Expect(value("usedBlockV6Leases")).To(Equal(4.0))
+				Expect(value("staleBlockV6Leases")).To(Equal(0.0))
+			})
+		})
+
+		Context("when getting the leases fails", func() {
+			BeforeEach(func() {
+				fakeDatabaseHandler.AllReturns(nil, errors.New("banana"))
+			})
+
+			It("returns the error", func() {
+				_, err := sources["freeLeases"].Getter()
+				Expect(err).To(MatchError("banana"))
+			})
+		})
+
+		Context("when getting the active leases fails", func() {
+			BeforeEach(func() {
+				fakeDatabaseHandler.AllActiveReturns(nil, errors.New("banana"))
+			})
+
+			It("returns the error", func() {
+				_, err := sources["usedBlockLeases"].Getter()
+				Expect(err).To(MatchError("banana"))
+				Expect(fakeCIDRPool.UsageCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when getting the reservations fails", func() {
+			BeforeEach(func() {
+				fakeDatabaseHandler.AllReservationsReturns(nil, errors.New("banana"))
+			})
+
+			It("returns the error", func() {
+				_, err := sources["freeBlockLeases"].Getter()
+				Expect(err).To(MatchError("banana"))
+				Expect(fakeCIDRPool.UsageCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("reclaimedLeases", func() {
+		It("returns the number of leases this controller reclaimed", func() {
+			source := server_metrics.NewReclaimedLeasesSource(fakeDatabaseHandler)
+
+			Expect(source.Name).To(Equal("reclaimedLeases"))
+			Expect(source.Unit).To(Equal(""))
+
+			value, err := source.Getter()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(fakeDatabaseHandler.ReclaimedLeaseCountCallCount()).To(Equal(1))
+			Expect(value).To(Equal(3.0))
 		})
 	})
 
@@ -0,0 +1,13 @@
+package backoff_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestBackoff(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Backoff Suite")
+}
@@ -0,0 +1,151 @@
+package backoff
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/cf-networking-helpers/poller"
+	"code.cloudfoundry.org/lager/v3"
+)
+
+// Poller runs SingleCycleFunc every PollInterval, like the cf-networking
+// poller, but spreads the cycles of many cells apart. Every interval is
+// moved by up to JitterPercent of itself in either direction, but never
+// below half of PollInterval. While cycles
+// are failing the interval doubles with every consecutive failure, up to
+// MaxBackoff, so that cells do not all retry at the same moment after a
+// controller outage. A MaxBackoff no longer than PollInterval turns the
+// backoff off.
+//
+// When Deadline is set the next cycle is never scheduled after it, and there
+// is no backoff once it has passed, so that a decision that depends on the
+// wall clock, such as giving up once the partition tolerance has passed, is
+// not put off by the backoff.
+//
+// As with the cf-networking poller, a poller.FatalError from a cycle stops
+// the Poller.
+type Poller struct {
+	Logger                 lager.Logger
+	PollInterval           time.Duration
+	JitterPercent          int
+	MaxBackoff             time.Duration
+	Deadline               func() time.Time
+	RunBeforeFirstInterval bool
+
+	SingleCycleFunc func() error
+
+	// Random returns a number in [0, 1). It defaults to rand.Float64.
+	Random func() float64
+
+	lock  sync.Mutex
+	state State
+}
+
+// State is how the Poller is backing off.
+type State struct {
+	ConsecutiveFailures int
+	Interval            time.Duration
+	NextCycle           time.Time
+}
+
+func (p *Poller) State() State {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.state
+}
+
+func (p *Poller) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	close(ready)
+
+	if p.RunBeforeFirstInterval {
+		if err := p.runFunction(); err != nil {
+			return err
+		}
+	} else {
+		p.schedule(0)
+	}
+
+	for {
+		select {
+		case <-signals:
+			return nil
+		case <-time.After(p.State().Interval):
+			if err := p.runFunction(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (p *Poller) runFunction() error {
+	err := p.SingleCycleFunc()
+	if err == nil {
+		p.schedule(0)
+		return nil
+	}
+
+	p.Logger.Error("poll-cycle", err)
+	if _, ok := err.(poller.FatalError); ok {
+		return fmt.Errorf("This cell must be restarted (run \"bosh restart <job>\"): %s", err)
+	}
+
+	failures := p.State().ConsecutiveFailures + 1
+	p.schedule(failures)
+	if p.MaxBackoff > p.PollInterval {
+		p.Logger.Info("backing-off", lager.Data{
+			"consecutive_failures": failures,
+			"interval":             p.State().Interval.String(),
+		})
+	}
+	return nil
+}
+
+func (p *Poller) schedule(failures int) {
+	interval := p.interval(failures)
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.state.ConsecutiveFailures = failures
+	p.state.Interval = interval
+	p.state.NextCycle = time.Now().Add(interval)
+}
+
+// interval is the time to wait after the given number of consecutive
+// failures, with the jitter applied and cut short at the Deadline.
+func (p *Poller) interval(failures int) time.Duration {
+	interval := p.PollInterval
+	for i := 0; i < failures && interval < p.MaxBackoff; i++ {
+		interval *= 2
+	}
+	if p.MaxBackoff > p.PollInterval && interval > p.MaxBackoff {
+		interval = p.MaxBackoff
+	}
+
+	if p.JitterPercent > 0 {
+		random := rand.Float64
+		if p.Random != nil {
+			random = p.Random
+		}
+		jitter := float64(interval) * float64(p.JitterPercent) / 100
+		interval += time.Duration((random()*2 - 1) * jitter)
+		if interval < p.PollInterval/2 {
+			interval = p.PollInterval / 2
+		}
+	}
+
+	if p.Deadline != nil {
+		// once the deadline has passed there is nothing left to wait for,
+		// so the cycle runs every PollInterval without backing off
+		limit := time.Until(p.Deadline())
+		if limit <= 0 {
+			limit = p.PollInterval
+		}
+		if interval > limit {
+			interval = limit
+		}
+	}
+	return interval
+}
@@ -0,0 +1,199 @@
+package backoff_test
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/cf-networking-helpers/poller"
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/daemon/backoff"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/tedsuo/ifrit"
+)
+
+var _ = Describe("Poller", func() {
+	var (
+		logger     *lagertest.TestLogger
+		p          *backoff.Poller
+		lock       sync.Mutex
+		cycles     int
+		cycleError error
+	)
+
+	cycleCount := func() int {
+		lock.Lock()
+		defer lock.Unlock()
+		return cycles
+	}
+
+	setCycleError := func(err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		cycleError = err
+	}
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		cycles = 0
+		cycleError = nil
+
+		p = &backoff.Poller{
+			Logger:       logger,
+			PollInterval: 10 * time.Millisecond,
+			MaxBackoff:   80 * time.Millisecond,
+			SingleCycleFunc: func() error {
+				lock.Lock()
+				defer lock.Unlock()
+				cycles++
+				return cycleError
+			},
+			Random: func() float64 { return 0.5 },
+		}
+	})
+
+	Context("when it is running", func() {
+		var process ifrit.Process
+
+		JustBeforeEach(func() {
+			process = ifrit.Invoke(p)
+		})
+
+		AfterEach(func() {
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+		})
+
+		It("runs the cycle every poll interval", func() {
+			Eventually(cycleCount).Should(BeNumerically(">=", 3))
+			Expect(p.State().ConsecutiveFailures).To(Equal(0))
+			Expect(p.State().Interval).To(Equal(10 * time.Millisecond))
+		})
+
+		Context("when the cycle fails", func() {
+			BeforeEach(func() {
+				cycleError = errors.New("banana")
+			})
+
+			It("backs off exponentially up to the max backoff", func() {
+				Eventually(func() int { return p.State().ConsecutiveFailures }).Should(Equal(1))
+				Expect(p.State().Interval).To(Equal(20 * time.Millisecond))
+
+				Eventually(func() int { return p.State().ConsecutiveFailures }).Should(Equal(2))
+				Expect(p.State().Interval).To(Equal(40 * time.Millisecond))
+
+				Eventually(func() int { return p.State().ConsecutiveFailures }).Should(Equal(4))
+				Expect(p.State().Interval).To(Equal(80 * time.Millisecond))
+				Expect(p.State().NextCycle).To(BeTemporally("~", time.Now(), 80*time.Millisecond))
+
+				Expect(logger).To(gbytes.Say("poll-cycle.*banana"))
+				Expect(logger).To(gbytes.Say("backing-off.*consecutive_failures"))
+			})
+
+			It("returns to the poll interval once the cycle succeeds", func() {
+				Eventually(func() int { return p.State().ConsecutiveFailures }).Should(Equal(2))
+				setCycleError(nil)
+
+				Eventually(func() int { return p.State().ConsecutiveFailures }).Should(Equal(0))
+				Expect(p.State().Interval).To(Equal(10 * time.Millisecond))
+			})
+		})
+
+		Context("when the deadline comes before the backoff", func() {
+			var deadline time.Time
+
+			BeforeEach(func() {
+				p.MaxBackoff = time.Hour
+				deadline = time.Now().Add(50 * time.Millisecond)
+				p.Deadline = func() time.Time { return deadline }
+				cycleError = errors.New("banana")
+			})
+
+			It("runs the next cycle at the deadline and stops backing off after it", func() {
+				Eventually(func() int { return p.State().ConsecutiveFailures }).Should(Equal(2))
+				Expect(p.State().NextCycle).To(BeTemporally("~", deadline, 5*time.Millisecond))
+
+				Eventually(func() int { return p.State().ConsecutiveFailures }).Should(BeNumerically(">=", 4))
+				Expect(p.State().Interval).To(Equal(10 * time.Millisecond))
+			})
+		})
+
+		Context("when the backoff is turned off", func() {
+			BeforeEach(func() {
+				p.MaxBackoff = 0
+				cycleError = errors.New("banana")
+			})
+
+			It("keeps polling every poll interval", func() {
+				Eventually(func() int { return p.State().ConsecutiveFailures }).Should(BeNumerically(">=", 3))
+				Expect(p.State().Interval).To(Equal(10 * time.Millisecond))
+				Expect(logger).NotTo(gbytes.Say("backing-off"))
+			})
+		})
+	})
+
+	DescribeTable("jitter",
+		func(random float64, expected time.Duration) {
+			p.PollInterval = time.Hour
+			p.JitterPercent = 10
+			p.Random = func() float64 { return random }
+			p.RunBeforeFirstInterval = false
+
+			process := ifrit.Invoke(p)
+			Expect(p.State().Interval).To(Equal(expected))
+
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+			Expect(cycleCount()).To(Equal(0))
+		},
+		Entry("at the shortest", 0.0, 54*time.Minute),
+		Entry("in the middle", 0.5, time.Hour),
+		Entry("at the longest", 0.99, 65*time.Minute+52800*time.Millisecond),
+	)
+
+	It("never waits less than half the poll interval", func() {
+		p.PollInterval = time.Hour
+		p.JitterPercent = 100
+		p.Random = func() float64 { return 0 }
+		p.RunBeforeFirstInterval = false
+
+		process := ifrit.Invoke(p)
+		Expect(p.State().Interval).To(Equal(30 * time.Minute))
+
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive(BeNil()))
+		Expect(cycleCount()).To(Equal(0))
+	})
+
+	Context("when RunBeforeFirstInterval is set", func() {
+		BeforeEach(func() {
+			p.PollInterval = time.Hour
+			p.RunBeforeFirstInterval = true
+		})
+
+		It("runs the cycle straight away", func() {
+			process := ifrit.Invoke(p)
+			Expect(cycleCount()).To(Equal(1))
+
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+		})
+	})
+
+	Context("when the cycle returns a fatal error", func() {
+		BeforeEach(func() {
+			p.RunBeforeFirstInterval = true
+			cycleError = poller.FatalError("kiwi")
+		})
+
+		It("returns the error", func() {
+			process := ifrit.Background(p)
+			Eventually(process.Wait()).Should(Receive(MatchError(
+				`This cell must be restarted (run "bosh restart <job>"): fatal: kiwi`,
+			)))
+		})
+	})
+})
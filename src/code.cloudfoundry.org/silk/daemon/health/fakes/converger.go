@@ -0,0 +1,103 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+)
+
+type Converger struct {
+	ProgrammedStub        func() (int, int)
+	programmedMutex       sync.RWMutex
+	programmedArgsForCall []struct {
+	}
+	programmedReturns struct {
+		result1 int
+		result2 int
+	}
+	programmedReturnsOnCall map[int]struct {
+		result1 int
+		result2 int
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *Converger) Programmed() (int, int) {
+	fake.programmedMutex.Lock()
+	ret, specificReturn := fake.programmedReturnsOnCall[len(fake.programmedArgsForCall)]
+	fake.programmedArgsForCall = append(fake.programmedArgsForCall, struct {
+	}{})
+	stub := fake.ProgrammedStub
+	fakeReturns := fake.programmedReturns
+	fake.recordInvocation("Programmed", []interface{}{})
+	fake.programmedMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *Converger) ProgrammedCallCount() int {
+	fake.programmedMutex.RLock()
+	defer fake.programmedMutex.RUnlock()
+	return len(fake.programmedArgsForCall)
+}
+
+func (fake *Converger) ProgrammedCalls(stub func() (int, int)) {
+	fake.programmedMutex.Lock()
+	defer fake.programmedMutex.Unlock()
+	fake.ProgrammedStub = stub
+}
+
+func (fake *Converger) ProgrammedReturns(result1 int, result2 int) {
+	fake.programmedMutex.Lock()
+	defer fake.programmedMutex.Unlock()
+	fake.ProgrammedStub = nil
+	fake.programmedReturns = struct {
+		result1 int
+		result2 int
+	}{result1, result2}
+}
+
+func (fake *Converger) ProgrammedReturnsOnCall(i int, result1 int, result2 int) {
+	fake.programmedMutex.Lock()
+	defer fake.programmedMutex.Unlock()
+	fake.ProgrammedStub = nil
+	if fake.programmedReturnsOnCall == nil {
+		fake.programmedReturnsOnCall = make(map[int]struct {
+			result1 int
+			result2 int
+		})
+	}
+	fake.programmedReturnsOnCall[i] = struct {
+		result1 int
+		result2 int
+	}{result1, result2}
+}
+
+func (fake *Converger) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.programmedMutex.RLock()
+	defer fake.programmedMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *Converger) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
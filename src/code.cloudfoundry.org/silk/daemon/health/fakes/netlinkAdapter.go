@@ -0,0 +1,114 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"github.com/vishvananda/netlink"
+)
+
+type NetlinkAdapter struct {
+	LinkByNameStub        func(string) (netlink.Link, error)
+	linkByNameMutex       sync.RWMutex
+	linkByNameArgsForCall []struct {
+		arg1 string
+	}
+	linkByNameReturns struct {
+		result1 netlink.Link
+		result2 error
+	}
+	linkByNameReturnsOnCall map[int]struct {
+		result1 netlink.Link
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *NetlinkAdapter) LinkByName(arg1 string) (netlink.Link, error) {
+	fake.linkByNameMutex.Lock()
+	ret, specificReturn := fake.linkByNameReturnsOnCall[len(fake.linkByNameArgsForCall)]
+	fake.linkByNameArgsForCall = append(fake.linkByNameArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.LinkByNameStub
+	fakeReturns := fake.linkByNameReturns
+	fake.recordInvocation("LinkByName", []interface{}{arg1})
+	fake.linkByNameMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *NetlinkAdapter) LinkByNameCallCount() int {
+	fake.linkByNameMutex.RLock()
+	defer fake.linkByNameMutex.RUnlock()
+	return len(fake.linkByNameArgsForCall)
+}
+
+func (fake *NetlinkAdapter) LinkByNameCalls(stub func(string) (netlink.Link, error)) {
+	fake.linkByNameMutex.Lock()
+	defer fake.linkByNameMutex.Unlock()
+	fake.LinkByNameStub = stub
+}
+
+func (fake *NetlinkAdapter) LinkByNameArgsForCall(i int) string {
+	fake.linkByNameMutex.RLock()
+	defer fake.linkByNameMutex.RUnlock()
+	argsForCall := fake.linkByNameArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *NetlinkAdapter) LinkByNameReturns(result1 netlink.Link, result2 error) {
+	fake.linkByNameMutex.Lock()
+	defer fake.linkByNameMutex.Unlock()
+	fake.LinkByNameStub = nil
+	fake.linkByNameReturns = struct {
+		result1 netlink.Link
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *NetlinkAdapter) LinkByNameReturnsOnCall(i int, result1 netlink.Link, result2 error) {
+	fake.linkByNameMutex.Lock()
+	defer fake.linkByNameMutex.Unlock()
+	fake.LinkByNameStub = nil
+	if fake.linkByNameReturnsOnCall == nil {
+		fake.linkByNameReturnsOnCall = make(map[int]struct {
+			result1 netlink.Link
+			result2 error
+		})
+	}
+	fake.linkByNameReturnsOnCall[i] = struct {
+		result1 netlink.Link
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *NetlinkAdapter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.linkByNameMutex.RLock()
+	defer fake.linkByNameMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *NetlinkAdapter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
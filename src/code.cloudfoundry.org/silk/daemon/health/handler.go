@@ -0,0 +1,154 @@
+package health
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"time"
+
+	"code.cloudfoundry.org/silk/daemon/backoff"
+	"code.cloudfoundry.org/silk/daemon/planner"
+	"github.com/vishvananda/netlink"
+)
+
+//go:generate counterfeiter -o fakes/planner.go --fake-name Planner . vxlanPlanner
+type vxlanPlanner interface {
+	Status() planner.Status
+}
+
+//go:generate counterfeiter -o fakes/poller.go --fake-name Poller . poller
+type poller interface {
+	State() backoff.State
+}
+
+//go:generate counterfeiter -o fakes/converger.go --fake-name Converger . converger
+type converger interface {
+	Programmed() (int, int)
+}
+
+//go:generate counterfeiter -o fakes/netlinkAdapter.go --fake-name NetlinkAdapter . netlinkAdapter
+type netlinkAdapter interface {
+	LinkByName(string) (netlink.Link, error)
+}
+
+// Health is the state of silk-daemon served at /health.
+type Health struct {
+	Healthy                            bool       `json:"healthy"`
+	LastRenewSuccess                   *time.Time `json:"last_renew_success"`
+	LastRenewError                     string     `json:"last_renew_error,omitempty"`
+	LastConvergeSuccess                *time.Time `json:"last_converge_success"`
+	LastConvergeError                  string     `json:"last_converge_error,omitempty"`
+	PartitionToleranceRemainingSeconds float64    `json:"partition_tolerance_remaining_seconds"`
+	RenewBackoff                       Backoff    `json:"renew_backoff"`
+	Routes                             int        `json:"routes"`
+	Neighbours                         int        `json:"neighbours"`
+	VTEP                               VTEP       `json:"vtep"`
+}
+
+// Backoff is how long silk-daemon waits before it renews the lease again.
+type Backoff struct {
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	IntervalSeconds     float64    `json:"interval_seconds"`
+	NextRenew           *time.Time `json:"next_renew,omitempty"`
+}
+
+type VTEP struct {
+	Name      string `json:"name"`
+	Index     int    `json:"index,omitempty"`
+	Up        bool   `json:"up"`
+	OperState string `json:"oper_state,omitempty"`
+	Error     string `json:"error,omitempty"`
+	// Drift lists the attributes of the vtep that differ from the
+	// configuration and were kept because containers are running.
+	Drift []string `json:"drift,omitempty"`
+}
+
+// Handler reports the Health of silk-daemon. The daemon is degraded, and the
+// response is a 503, when the last lease renewal or convergence failed, when
+// the partition tolerance has run out, or when the vtep is missing, down or
+// does not match the configuration.
+type Handler struct {
+	Planner            vxlanPlanner
+	Poller             poller
+	Converger          converger
+	NetlinkAdapter     netlinkAdapter
+	VTEPName           string
+	PartitionTolerance time.Duration
+
+	// VTEPDrift is the drift of the vtep that was found at startup and not
+	// applied.
+	VTEPDrift []string
+
+	// Started is when the partition tolerance starts counting down if the
+	// lease has not been renewed since.
+	Started time.Time
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	status := h.Planner.Status()
+
+	health := Health{
+		LastRenewError:    status.LastRenewError,
+		LastConvergeError: status.LastConvergeError,
+		VTEP:              h.vtep(),
+	}
+	health.Routes, health.Neighbours = h.Converger.Programmed()
+
+	pollerState := h.Poller.State()
+	health.RenewBackoff = Backoff{
+		ConsecutiveFailures: pollerState.ConsecutiveFailures,
+		IntervalSeconds:     pollerState.Interval.Seconds(),
+	}
+	if !pollerState.NextCycle.IsZero() {
+		health.RenewBackoff.NextRenew = &pollerState.NextCycle
+	}
+
+	lastRenew := h.Started
+	if !status.LastRenewSuccess.IsZero() {
+		health.LastRenewSuccess = &status.LastRenewSuccess
+		lastRenew = status.LastRenewSuccess
+	}
+	if !status.LastConvergeSuccess.IsZero() {
+		health.LastConvergeSuccess = &status.LastConvergeSuccess
+	}
+	remaining := h.PartitionTolerance - time.Since(lastRenew)
+	if remaining < 0 {
+		remaining = 0
+	}
+	health.PartitionToleranceRemainingSeconds = remaining.Seconds()
+
+	health.Healthy = health.LastRenewError == "" &&
+		health.LastConvergeError == "" &&
+		remaining > 0 &&
+		health.VTEP.Up &&
+		len(health.VTEP.Drift) == 0
+
+	responseBytes, err := json.Marshal(health)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError) // not possible
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !health.Healthy {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	// #nosec G104 - ignore errors when writing HTTP responses so we don't spam our logs during a DoS
+	w.Write(responseBytes)
+}
+
+func (h *Handler) vtep() VTEP {
+	vtep := VTEP{Name: h.VTEPName, Drift: h.VTEPDrift}
+
+	link, err := h.NetlinkAdapter.LinkByName(h.VTEPName)
+	if err != nil {
+		vtep.Error = err.Error()
+		return vtep
+	}
+
+	attrs := link.Attrs()
+	vtep.Index = attrs.Index
+	vtep.Up = attrs.Flags&net.FlagUp != 0
+	vtep.OperState = attrs.OperState.String()
+	return vtep
+}
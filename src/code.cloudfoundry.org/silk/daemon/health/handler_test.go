@@ -0,0 +1,177 @@
+package health_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"code.cloudfoundry.org/silk/daemon/backoff"
+	"code.cloudfoundry.org/silk/daemon/health"
+	"code.cloudfoundry.org/silk/daemon/health/fakes"
+	"code.cloudfoundry.org/silk/daemon/planner"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+)
+
+var _ = Describe("Handler", func() {
+	var (
+		fakePlanner    *fakes.Planner
+		fakePoller     *fakes.Poller
+		fakeConverger  *fakes.Converger
+		fakeNetlink    *fakes.NetlinkAdapter
+		handler        *health.Handler
+		resp           *httptest.ResponseRecorder
+		lastRenew      time.Time
+		lastConverge   time.Time
+		started        time.Time
+		responseHealth health.Health
+	)
+
+	serve := func() {
+		req, err := http.NewRequest("GET", "/health", nil)
+		Expect(err).NotTo(HaveOccurred())
+		handler.ServeHTTP(resp, req)
+		Expect(json.Unmarshal(resp.Body.Bytes(), &responseHealth)).To(Succeed())
+	}
+
+	BeforeEach(func() {
+		fakePlanner = &fakes.Planner{}
+		fakePoller = &fakes.Poller{}
+		fakeConverger = &fakes.Converger{}
+		fakeNetlink = &fakes.NetlinkAdapter{}
+
+		started = time.Now().Add(-time.Minute)
+		lastRenew = time.Now().Add(-10 * time.Second).UTC()
+		lastConverge = time.Now().Add(-5 * time.Second).UTC()
+		fakePlanner.StatusReturns(planner.Status{
+			LastRenewSuccess:    lastRenew,
+			LastConvergeSuccess: lastConverge,
+		})
+		fakeConverger.ProgrammedReturns(3, 6)
+		fakeNetlink.LinkByNameReturns(&netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{
+			Name:      "silk-vtep",
+			Index:     42,
+			Flags:     net.FlagUp,
+			OperState: netlink.OperUnknown,
+		}}, nil)
+
+		handler = &health.Handler{
+			Planner:            fakePlanner,
+			Poller:             fakePoller,
+			Converger:          fakeConverger,
+			NetlinkAdapter:     fakeNetlink,
+			VTEPName:           "silk-vtep",
+			PartitionTolerance: time.Hour,
+			Started:            started,
+		}
+		resp = httptest.NewRecorder()
+		responseHealth = health.Health{}
+	})
+
+	It("reports the renewals, convergences and the vtep", func() {
+		serve()
+
+		Expect(resp.Code).To(Equal(http.StatusOK))
+		Expect(resp.Header().Get("Content-Type")).To(Equal("application/json"))
+		Expect(responseHealth.Healthy).To(BeTrue())
+		Expect(*responseHealth.LastRenewSuccess).To(BeTemporally("==", lastRenew))
+		Expect(*responseHealth.LastConvergeSuccess).To(BeTemporally("==", lastConverge))
+		Expect(responseHealth.PartitionToleranceRemainingSeconds).To(BeNumerically("~", 3590, 1))
+		Expect(responseHealth.Routes).To(Equal(3))
+		Expect(responseHealth.Neighbours).To(Equal(6))
+		Expect(responseHealth.VTEP).To(Equal(health.VTEP{
+			Name:      "silk-vtep",
+			Index:     42,
+			Up:        true,
+			OperState: "unknown",
+		}))
+		Expect(fakeNetlink.LinkByNameArgsForCall(0)).To(Equal("silk-vtep"))
+	})
+
+	Context("when renewals are backing off", func() {
+		var nextRenew time.Time
+
+		BeforeEach(func() {
+			nextRenew = time.Now().Add(time.Minute).UTC()
+			fakePoller.StateReturns(backoff.State{
+				ConsecutiveFailures: 3,
+				Interval:            80 * time.Second,
+				NextCycle:           nextRenew,
+			})
+		})
+
+		It("reports the backoff", func() {
+			serve()
+
+			Expect(responseHealth.RenewBackoff.ConsecutiveFailures).To(Equal(3))
+			Expect(responseHealth.RenewBackoff.IntervalSeconds).To(Equal(80.0))
+			Expect(*responseHealth.RenewBackoff.NextRenew).To(BeTemporally("==", nextRenew))
+		})
+	})
+
+	Context("before the lease has been renewed", func() {
+		BeforeEach(func() {
+			fakePlanner.StatusReturns(planner.Status{})
+		})
+
+		It("counts the partition tolerance from the start", func() {
+			serve()
+
+			Expect(resp.Code).To(Equal(http.StatusOK))
+			Expect(responseHealth.LastRenewSuccess).To(BeNil())
+			Expect(responseHealth.LastConvergeSuccess).To(BeNil())
+			Expect(responseHealth.PartitionToleranceRemainingSeconds).To(BeNumerically("~", 3540, 1))
+		})
+	})
+
+	DescribeTable("when the daemon is degraded",
+		func(setup func(), check func()) {
+			setup()
+			serve()
+
+			Expect(resp.Code).To(Equal(http.StatusServiceUnavailable))
+			Expect(responseHealth.Healthy).To(BeFalse())
+			check()
+		},
+		Entry("the last renewal failed", func() {
+			fakePlanner.StatusReturns(planner.Status{LastRenewSuccess: lastRenew, LastRenewError: "guava"})
+		}, func() {
+			Expect(responseHealth.LastRenewError).To(Equal("guava"))
+		}),
+		Entry("the last convergence failed", func() {
+			fakePlanner.StatusReturns(planner.Status{LastRenewSuccess: lastRenew, LastConvergeError: "banana"})
+		}, func() {
+			Expect(responseHealth.LastConvergeError).To(Equal("banana"))
+		}),
+		Entry("the partition tolerance has run out", func() {
+			handler.PartitionTolerance = 5 * time.Second
+		}, func() {
+			Expect(responseHealth.PartitionToleranceRemainingSeconds).To(BeZero())
+		}),
+		Entry("the vtep is missing", func() {
+			fakeNetlink.LinkByNameReturns(nil, errors.New("Link not found"))
+		}, func() {
+			Expect(responseHealth.VTEP).To(Equal(health.VTEP{Name: "silk-vtep", Error: "Link not found"}))
+		}),
+		Entry("the vtep is down", func() {
+			fakeNetlink.LinkByNameReturns(&netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{
+				Name:      "silk-vtep",
+				Index:     42,
+				OperState: netlink.OperDown,
+			}}, nil)
+		}, func() {
+			Expect(responseHealth.VTEP.Up).To(BeFalse())
+			Expect(responseHealth.VTEP.OperState).To(Equal("down"))
+		}),
+		Entry("the vtep does not match the configuration", func() {
+			handler.VTEPDrift = []string{"vni: 1, want 2", "port: 4789, want 4790"}
+		}, func() {
+			Expect(responseHealth.VTEP.Up).To(BeTrue())
+			Expect(responseHealth.VTEP.Drift).To(Equal([]string{"vni: 1, want 2", "port: 4789, want 4790"}))
+		}),
+	)
+})
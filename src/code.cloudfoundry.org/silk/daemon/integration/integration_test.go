@@ -21,6 +21,7 @@ import (
 	"code.cloudfoundry.org/silk/client/config"
 	"code.cloudfoundry.org/silk/controller"
 	"code.cloudfoundry.org/silk/daemon"
+	"code.cloudfoundry.org/silk/daemon/health"
 	"code.cloudfoundry.org/silk/daemon/vtep"
 	"code.cloudfoundry.org/silk/lib/adapter"
 	"code.cloudfoundry.org/silk/testsupport"
@@ -94,7 +95,7 @@ var _ = BeforeEach(func() {
 	vni = GinkgoParallelProcess()
 	vtepName = fmt.Sprintf("silk-vtep-%d", GinkgoParallelProcess())
 	daemonHealthCheckPort := ports.PickAPort()
-	daemonHealthCheckURL = fmt.Sprintf("http://127.0.0.1:%d/health", daemonHealthCheckPort)
+	daemonHealthCheckURL = fmt.Sprintf("http://127.0.0.1:%d/", daemonHealthCheckPort)
 	daemonDebugServerPort = ports.PickAPort()
 	serverListenPort = ports.PickAPort()
 	vtepPort = ports.PickAPort()
@@ -696,6 +697,131 @@ var _ = Describe("Daemon Integration", func() {
 		})
 	})
 
+	Context("when the mtu of the existing vtep differs from the config", func() {
+		BeforeEach(func() {
+			stopDaemon()
+			daemonConf.VTEPMTU = 1200
+			fakeServer.SetHandler("/leases/renew", &testsupport.FakeHandler{
+				ResponseCode: 200,
+				ResponseBody: struct{}{},
+			})
+		})
+
+		It("changes the mtu in place", func() {
+			before, err := netlink.LinkByName(vtepName)
+			Expect(err).NotTo(HaveOccurred())
+
+			startAndWaitForDaemon()
+			Expect(session.Out).To(gbytes.Say(`vtep-mtu-changed.*"mtu":%d`, daemonConf.VTEPMTU))
+			Expect(session.Out).NotTo(gbytes.Say("recreating-vtep"))
+
+			link, err := netlink.LinkByName(vtepName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(link.Attrs().MTU).To(Equal(daemonConf.VTEPMTU))
+			Expect(link.Attrs().Index).To(Equal(before.Attrs().Index))
+		})
+
+		Context("when containers are running", func() {
+			var before netlink.Link
+
+			BeforeEach(func() {
+				Expect(os.WriteFile(datastorePath, []byte(`{"some-handle": {"handle": "some-handle", "ip": "10.255.30.2"}}`), 0644)).To(Succeed())
+
+				var err error
+				before, err = netlink.LinkByName(vtepName)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("keeps the larger mtu and reports it on /health", func() {
+				startAndWaitForDaemon()
+				Expect(session.Out).To(gbytes.Say(`vtep-drift.*"drift":\["mtu: %d, want %d"\]`, before.Attrs().MTU, daemonConf.VTEPMTU))
+
+				link, err := netlink.LinkByName(vtepName)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(link.Attrs().MTU).To(Equal(before.Attrs().MTU))
+
+				resp, err := http.Get(daemonHealthCheckURL + "health")
+				Expect(err).NotTo(HaveOccurred())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+			})
+
+			Context("when recreating the vtep is forced", func() {
+				BeforeEach(func() {
+					daemonConf.ForceVTEPRecreate = true
+				})
+
+				It("lowers the mtu", func() {
+					startAndWaitForDaemon()
+					Expect(session.Out).To(gbytes.Say(`vtep-mtu-changed.*"mtu":%d`, daemonConf.VTEPMTU))
+
+					link, err := netlink.LinkByName(vtepName)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(link.Attrs().MTU).To(Equal(daemonConf.VTEPMTU))
+				})
+			})
+		})
+	})
+
+	Context("when the vni of the existing vtep differs from the config", func() {
+		BeforeEach(func() {
+			stopDaemon()
+			daemonConf.VNI = vni + 1
+			fakeServer.SetHandler("/leases/renew", &testsupport.FakeHandler{
+				ResponseCode: 200,
+				ResponseBody: struct{}{},
+			})
+		})
+
+		It("recreates the vtep with the same lease", func() {
+			startAndWaitForDaemon()
+			Expect(session.Out).To(gbytes.Say(`recreating-vtep.*"drift":\["vni: %d, want %d"\]`, vni, vni+1))
+
+			link, err := netlink.LinkByName(vtepName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(link.(*netlink.Vxlan).VxlanId).To(Equal(vni + 1))
+			doHealthCheck()
+		})
+
+		Context("when containers are running", func() {
+			BeforeEach(func() {
+				Expect(os.WriteFile(datastorePath, []byte(`{"some-handle": {"handle": "some-handle", "ip": "10.255.30.2"}}`), 0644)).To(Succeed())
+			})
+
+			It("keeps the vtep", func() {
+				startAndWaitForDaemon()
+				Expect(session.Out).To(gbytes.Say(`vtep-drift.*"error":"vtep does not match config and has containers: 1"`))
+
+				link, err := netlink.LinkByName(vtepName)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(link.(*netlink.Vxlan).VxlanId).To(Equal(vni))
+
+				resp, err := http.Get(daemonHealthCheckURL + "health")
+				Expect(err).NotTo(HaveOccurred())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusServiceUnavailable))
+				var daemonHealth health.Health
+				Expect(json.NewDecoder(resp.Body).Decode(&daemonHealth)).To(Succeed())
+				Expect(daemonHealth.VTEP.Drift).To(Equal([]string{fmt.Sprintf("vni: %d, want %d", vni, vni+1)}))
+			})
+
+			Context("when recreating the vtep is forced", func() {
+				BeforeEach(func() {
+					daemonConf.ForceVTEPRecreate = true
+				})
+
+				It("recreates the vtep", func() {
+					startAndWaitForDaemon()
+					Expect(session.Out).To(gbytes.Say(`recreating-vtep.*"containers":1`))
+
+					link, err := netlink.LinkByName(vtepName)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(link.(*netlink.Vxlan).VxlanId).To(Equal(vni + 1))
+				})
+			})
+		})
+	})
+
 	Context("when the discovered lease is not in the overlay network", func() {
 		BeforeEach(func() {
 			stopDaemon()
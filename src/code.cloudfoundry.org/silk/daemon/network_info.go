@@ -1,6 +1,7 @@
 package daemon
 
 type NetworkInfo struct {
-	OverlaySubnet string `json:"overlay_subnet"`
-	MTU           int    `json:"mtu"`
+	OverlaySubnet   string `json:"overlay_subnet"`
+	MTU             int    `json:"mtu"`
+	OverlaySubnetV6 string `json:"overlay_subnet_v6,omitempty"`
 }
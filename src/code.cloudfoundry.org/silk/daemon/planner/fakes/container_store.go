@@ -0,0 +1,111 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/silk/lib/datastore"
+)
+
+type ContainerStore struct {
+	ReadAllLockedStub        func(string, func(map[string]datastore.Container) error) error
+	readAllLockedMutex       sync.RWMutex
+	readAllLockedArgsForCall []struct {
+		arg1 string
+		arg2 func(map[string]datastore.Container) error
+	}
+	readAllLockedReturns struct {
+		result1 error
+	}
+	readAllLockedReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *ContainerStore) ReadAllLocked(arg1 string, arg2 func(map[string]datastore.Container) error) error {
+	fake.readAllLockedMutex.Lock()
+	ret, specificReturn := fake.readAllLockedReturnsOnCall[len(fake.readAllLockedArgsForCall)]
+	fake.readAllLockedArgsForCall = append(fake.readAllLockedArgsForCall, struct {
+		arg1 string
+		arg2 func(map[string]datastore.Container) error
+	}{arg1, arg2})
+	stub := fake.ReadAllLockedStub
+	fakeReturns := fake.readAllLockedReturns
+	fake.recordInvocation("ReadAllLocked", []interface{}{arg1, arg2})
+	fake.readAllLockedMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *ContainerStore) ReadAllLockedCallCount() int {
+	fake.readAllLockedMutex.RLock()
+	defer fake.readAllLockedMutex.RUnlock()
+	return len(fake.readAllLockedArgsForCall)
+}
+
+func (fake *ContainerStore) ReadAllLockedCalls(stub func(string, func(map[string]datastore.Container) error) error) {
+	fake.readAllLockedMutex.Lock()
+	defer fake.readAllLockedMutex.Unlock()
+	fake.ReadAllLockedStub = stub
+}
+
+func (fake *ContainerStore) ReadAllLockedArgsForCall(i int) (string, func(map[string]datastore.Container) error) {
+	fake.readAllLockedMutex.RLock()
+	defer fake.readAllLockedMutex.RUnlock()
+	argsForCall := fake.readAllLockedArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *ContainerStore) ReadAllLockedReturns(result1 error) {
+	fake.readAllLockedMutex.Lock()
+	defer fake.readAllLockedMutex.Unlock()
+	fake.ReadAllLockedStub = nil
+	fake.readAllLockedReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *ContainerStore) ReadAllLockedReturnsOnCall(i int, result1 error) {
+	fake.readAllLockedMutex.Lock()
+	defer fake.readAllLockedMutex.Unlock()
+	fake.ReadAllLockedStub = nil
+	if fake.readAllLockedReturnsOnCall == nil {
+		fake.readAllLockedReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.readAllLockedReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *ContainerStore) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.readAllLockedMutex.RLock()
+	defer fake.readAllLockedMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *ContainerStore) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
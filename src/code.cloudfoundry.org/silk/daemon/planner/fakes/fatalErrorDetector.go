@@ -3,11 +3,22 @@ package fakes
 
 import (
 	"sync"
+	"time"
 
 	"code.cloudfoundry.org/silk/daemon/planner"
 )
 
 type FatalErrorDetector struct {
+	DeadlineStub        func() time.Time
+	deadlineMutex       sync.RWMutex
+	deadlineArgsForCall []struct {
+	}
+	deadlineReturns struct {
+		result1 time.Time
+	}
+	deadlineReturnsOnCall map[int]struct {
+		result1 time.Time
+	}
 	GotSuccessStub        func()
 	gotSuccessMutex       sync.RWMutex
 	gotSuccessArgsForCall []struct {
@@ -27,6 +38,59 @@ type FatalErrorDetector struct {
 	invocationsMutex sync.RWMutex
 }
 
+func (fake *FatalErrorDetector) Deadline() time.Time {
+	fake.deadlineMutex.Lock()
+	ret, specificReturn := fake.deadlineReturnsOnCall[len(fake.deadlineArgsForCall)]
+	fake.deadlineArgsForCall = append(fake.deadlineArgsForCall, struct {
+	}{})
+	stub := fake.DeadlineStub
+	fakeReturns := fake.deadlineReturns
+	fake.recordInvocation("Deadline", []interface{}{})
+	fake.deadlineMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *FatalErrorDetector) DeadlineCallCount() int {
+	fake.deadlineMutex.RLock()
+	defer fake.deadlineMutex.RUnlock()
+	return len(fake.deadlineArgsForCall)
+}
+
+func (fake *FatalErrorDetector) DeadlineCalls(stub func() time.Time) {
+	fake.deadlineMutex.Lock()
+	defer fake.deadlineMutex.Unlock()
+	fake.DeadlineStub = stub
+}
+
+func (fake *FatalErrorDetector) DeadlineReturns(result1 time.Time) {
+	fake.deadlineMutex.Lock()
+	defer fake.deadlineMutex.Unlock()
+	fake.DeadlineStub = nil
+	fake.deadlineReturns = struct {
+		result1 time.Time
+	}{result1}
+}
+
+func (fake *FatalErrorDetector) DeadlineReturnsOnCall(i int, result1 time.Time) {
+	fake.deadlineMutex.Lock()
+	defer fake.deadlineMutex.Unlock()
+	fake.DeadlineStub = nil
+	if fake.deadlineReturnsOnCall == nil {
+		fake.deadlineReturnsOnCall = make(map[int]struct {
+			result1 time.Time
+		})
+	}
+	fake.deadlineReturnsOnCall[i] = struct {
+		result1 time.Time
+	}{result1}
+}
+
 func (fake *FatalErrorDetector) GotSuccess() {
 	fake.gotSuccessMutex.Lock()
 	fake.gotSuccessArgsForCall = append(fake.gotSuccessArgsForCall, struct {
@@ -115,6 +179,8 @@ func (fake *FatalErrorDetector) IsFatalReturnsOnCall(i int, result1 bool) {
 func (fake *FatalErrorDetector) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
+	fake.deadlineMutex.RLock()
+	defer fake.deadlineMutex.RUnlock()
 	fake.gotSuccessMutex.RLock()
 	defer fake.gotSuccessMutex.RUnlock()
 	fake.isFatalMutex.RLock()
@@ -0,0 +1,118 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/silk/controller"
+)
+
+type LeaseWatcher struct {
+	WatchLeasesStub        func(string, uint64, int) (controller.LeaseUpdate, error)
+	watchLeasesMutex       sync.RWMutex
+	watchLeasesArgsForCall []struct {
+		arg1 string
+		arg2 uint64
+		arg3 int
+	}
+	watchLeasesReturns struct {
+		result1 controller.LeaseUpdate
+		result2 error
+	}
+	watchLeasesReturnsOnCall map[int]struct {
+		result1 controller.LeaseUpdate
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *LeaseWatcher) WatchLeases(arg1 string, arg2 uint64, arg3 int) (controller.LeaseUpdate, error) {
+	fake.watchLeasesMutex.Lock()
+	ret, specificReturn := fake.watchLeasesReturnsOnCall[len(fake.watchLeasesArgsForCall)]
+	fake.watchLeasesArgsForCall = append(fake.watchLeasesArgsForCall, struct {
+		arg1 string
+		arg2 uint64
+		arg3 int
+	}{arg1, arg2, arg3})
+	stub := fake.WatchLeasesStub
+	fakeReturns := fake.watchLeasesReturns
+	fake.recordInvocation("WatchLeases", []interface{}{arg1, arg2, arg3})
+	fake.watchLeasesMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2, arg3)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *LeaseWatcher) WatchLeasesCallCount() int {
+	fake.watchLeasesMutex.RLock()
+	defer fake.watchLeasesMutex.RUnlock()
+	return len(fake.watchLeasesArgsForCall)
+}
+
+func (fake *LeaseWatcher) WatchLeasesCalls(stub func(string, uint64, int) (controller.LeaseUpdate, error)) {
+	fake.watchLeasesMutex.Lock()
+	defer fake.watchLeasesMutex.Unlock()
+	fake.WatchLeasesStub = stub
+}
+
+func (fake *LeaseWatcher) WatchLeasesArgsForCall(i int) (string, uint64, int) {
+	fake.watchLeasesMutex.RLock()
+	defer fake.watchLeasesMutex.RUnlock()
+	argsForCall := fake.watchLeasesArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
+}
+
+func (fake *LeaseWatcher) WatchLeasesReturns(result1 controller.LeaseUpdate, result2 error) {
+	fake.watchLeasesMutex.Lock()
+	defer fake.watchLeasesMutex.Unlock()
+	fake.WatchLeasesStub = nil
+	fake.watchLeasesReturns = struct {
+		result1 controller.LeaseUpdate
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *LeaseWatcher) WatchLeasesReturnsOnCall(i int, result1 controller.LeaseUpdate, result2 error) {
+	fake.watchLeasesMutex.Lock()
+	defer fake.watchLeasesMutex.Unlock()
+	fake.WatchLeasesStub = nil
+	if fake.watchLeasesReturnsOnCall == nil {
+		fake.watchLeasesReturnsOnCall = make(map[int]struct {
+			result1 controller.LeaseUpdate
+			result2 error
+		})
+	}
+	fake.watchLeasesReturnsOnCall[i] = struct {
+		result1 controller.LeaseUpdate
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *LeaseWatcher) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.watchLeasesMutex.RLock()
+	defer fake.watchLeasesMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *LeaseWatcher) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
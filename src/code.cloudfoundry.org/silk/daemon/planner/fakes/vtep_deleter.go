@@ -0,0 +1,107 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+)
+
+type VTEPDeleter struct {
+	DeleteVTEPStub        func(string) error
+	deleteVTEPMutex       sync.RWMutex
+	deleteVTEPArgsForCall []struct {
+		arg1 string
+	}
+	deleteVTEPReturns struct {
+		result1 error
+	}
+	deleteVTEPReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *VTEPDeleter) DeleteVTEP(arg1 string) error {
+	fake.deleteVTEPMutex.Lock()
+	ret, specificReturn := fake.deleteVTEPReturnsOnCall[len(fake.deleteVTEPArgsForCall)]
+	fake.deleteVTEPArgsForCall = append(fake.deleteVTEPArgsForCall, struct {
+		arg1 string
+	}{arg1})
+	stub := fake.DeleteVTEPStub
+	fakeReturns := fake.deleteVTEPReturns
+	fake.recordInvocation("DeleteVTEP", []interface{}{arg1})
+	fake.deleteVTEPMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *VTEPDeleter) DeleteVTEPCallCount() int {
+	fake.deleteVTEPMutex.RLock()
+	defer fake.deleteVTEPMutex.RUnlock()
+	return len(fake.deleteVTEPArgsForCall)
+}
+
+func (fake *VTEPDeleter) DeleteVTEPCalls(stub func(string) error) {
+	fake.deleteVTEPMutex.Lock()
+	defer fake.deleteVTEPMutex.Unlock()
+	fake.DeleteVTEPStub = stub
+}
+
+func (fake *VTEPDeleter) DeleteVTEPArgsForCall(i int) string {
+	fake.deleteVTEPMutex.RLock()
+	defer fake.deleteVTEPMutex.RUnlock()
+	argsForCall := fake.deleteVTEPArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *VTEPDeleter) DeleteVTEPReturns(result1 error) {
+	fake.deleteVTEPMutex.Lock()
+	defer fake.deleteVTEPMutex.Unlock()
+	fake.DeleteVTEPStub = nil
+	fake.deleteVTEPReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *VTEPDeleter) DeleteVTEPReturnsOnCall(i int, result1 error) {
+	fake.deleteVTEPMutex.Lock()
+	defer fake.deleteVTEPMutex.Unlock()
+	fake.DeleteVTEPStub = nil
+	if fake.deleteVTEPReturnsOnCall == nil {
+		fake.deleteVTEPReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.deleteVTEPReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *VTEPDeleter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.deleteVTEPMutex.RLock()
+	defer fake.deleteVTEPMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *VTEPDeleter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -10,6 +10,7 @@ import (
 type FatalErrorDetector interface {
 	GotSuccess()
 	IsFatal(error) bool
+	Deadline() time.Time
 }
 
 type gracefulDetector struct {
@@ -35,3 +36,9 @@ func (fed *gracefulDetector) IsFatal(err error) bool {
 	}
 	return time.Since(fed.lastSuccessTime) >= fed.graceDuration
 }
+
+// Deadline is when errors start to be fatal, unless there is a success
+// before then.
+func (fed *gracefulDetector) Deadline() time.Time {
+	return fed.lastSuccessTime.Add(fed.graceDuration)
+}
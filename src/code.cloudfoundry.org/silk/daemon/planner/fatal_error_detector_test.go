@@ -18,6 +18,13 @@ var _ = Describe("FatalErrorDetector", func() {
 		fed = planner.NewGracefulDetector(graceDuration)
 	})
 
+	Describe("Deadline", func() {
+		It("is the grace duration after the last success", func() {
+			fed.GotSuccess()
+			Expect(fed.Deadline()).To(BeTemporally("~", time.Now().Add(graceDuration), 5*time.Millisecond))
+		})
+	})
+
 	Describe("IsFatal", func() {
 		Context("when given a non-retriable error", func() {
 			It("reports it as fatal", func() {
@@ -0,0 +1,101 @@
+package planner
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"code.cloudfoundry.org/lager/v3"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/lib/datastore"
+)
+
+//go:generate counterfeiter -o fakes/lease_release_client.go --fake-name LeaseReleaseClient . leaseReleaseClient
+type leaseReleaseClient interface {
+	ReleaseSubnetLease(underlayIP string) error
+}
+
+//go:generate counterfeiter -o fakes/container_store.go --fake-name ContainerStore . containerStore
+type containerStore interface {
+	ReadAllLocked(filePath string, f func(map[string]datastore.Container) error) error
+}
+
+//go:generate counterfeiter -o fakes/vtep_deleter.go --fake-name VTEPDeleter . vtepDeleter
+type vtepDeleter interface {
+	DeleteVTEP(deviceName string) error
+}
+
+// LeaseReleaser gives the lease back to the controller and deletes the vtep
+// when silk-daemon is stopped without any containers on the cell, so that
+// the subnet does not stay taken until the lease expires. Once both are done
+// it writes the lease to ReleaseFile, which tells silk-daemon-shutdown that
+// the release is confirmed. The datastore stays locked from the check for
+// containers until the release file is written, so silk-cni cannot add a
+// container to a cell that is giving up its subnet.
+type LeaseReleaser struct {
+	Logger           lager.Logger
+	ControllerClient leaseReleaseClient
+	Store            containerStore
+	DatastorePath    string
+	VTEPDeleter      vtepDeleter
+	VTEPName         string
+	Lease            controller.Lease
+	ReleaseFile      string
+}
+
+func (r *LeaseReleaser) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	if r.ReleaseFile != "" {
+		err := os.Remove(r.ReleaseFile)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("remove lease release file: %s", err)
+		}
+	}
+
+	close(ready)
+	<-signals
+
+	logger := r.Logger.Session("release-lease", lager.Data{"lease": r.Lease})
+
+	err := r.Store.ReadAllLocked(r.DatastorePath, func(containers map[string]datastore.Container) error {
+		r.release(logger, containers)
+		return nil
+	})
+	if err != nil {
+		logger.Error("read-datastore", err)
+	}
+	return nil
+}
+
+func (r *LeaseReleaser) release(logger lager.Logger, containers map[string]datastore.Container) {
+	if len(containers) != 0 {
+		logger.Info("skipped", lager.Data{"containers": len(containers)})
+		return
+	}
+
+	err := r.ControllerClient.ReleaseSubnetLease(r.Lease.UnderlayIP)
+	if err != nil {
+		logger.Error("release-subnet-lease", err)
+		return
+	}
+
+	err = r.VTEPDeleter.DeleteVTEP(r.VTEPName)
+	if err != nil {
+		logger.Error("delete-vtep", err)
+		return
+	}
+
+	if r.ReleaseFile != "" {
+		leaseBytes, err := json.Marshal(r.Lease)
+		if err != nil {
+			logger.Error("marshal-lease", err) // not possible
+			return
+		}
+		err = os.WriteFile(r.ReleaseFile, leaseBytes, 0644)
+		if err != nil {
+			logger.Error("write-lease-release-file", err)
+			return
+		}
+	}
+
+	logger.Info("released")
+}
@@ -0,0 +1,190 @@
+package planner_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/controller"
+	"code.cloudfoundry.org/silk/daemon/planner"
+	"code.cloudfoundry.org/silk/daemon/planner/fakes"
+	"code.cloudfoundry.org/silk/lib/datastore"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/tedsuo/ifrit"
+)
+
+var _ = Describe("LeaseReleaser", func() {
+	var (
+		logger           *lagertest.TestLogger
+		controllerClient *fakes.LeaseReleaseClient
+		store            *fakes.ContainerStore
+		vtepDeleter      *fakes.VTEPDeleter
+		releaseFile      string
+		releaser         *planner.LeaseReleaser
+		process          ifrit.Process
+	)
+
+	BeforeEach(func() {
+		logger = lagertest.NewTestLogger("test")
+		controllerClient = &fakes.LeaseReleaseClient{}
+		store = &fakes.ContainerStore{}
+		vtepDeleter = &fakes.VTEPDeleter{}
+		releaseFile = filepath.Join(GinkgoT().TempDir(), "lease-released")
+
+		containers := map[string]datastore.Container{}
+		store.ReadAllLockedStub = func(_ string, f func(map[string]datastore.Container) error) error {
+			return f(containers)
+		}
+
+		releaser = &planner.LeaseReleaser{
+			Logger:           logger,
+			ControllerClient: controllerClient,
+			Store:            store,
+			DatastorePath:    "/some/datastore.json",
+			VTEPDeleter:      vtepDeleter,
+			VTEPName:         "silk-vtep",
+			Lease: controller.Lease{
+				UnderlayIP:          "10.244.4.5",
+				OverlaySubnet:       "10.255.4.0/24",
+				OverlayHardwareAddr: "ee:ee:0a:ff:04:00",
+			},
+			ReleaseFile: releaseFile,
+		}
+	})
+
+	stop := func() {
+		process = ifrit.Invoke(releaser)
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive(BeNil()))
+	}
+
+	It("removes a release file left over from a previous run", func() {
+		Expect(os.WriteFile(releaseFile, []byte("{}"), 0644)).To(Succeed())
+
+		process = ifrit.Invoke(releaser)
+		Expect(releaseFile).NotTo(BeAnExistingFile())
+
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive(BeNil()))
+	})
+
+	It("does nothing until it is signalled", func() {
+		process = ifrit.Invoke(releaser)
+		Consistently(controllerClient.ReleaseSubnetLeaseCallCount).Should(Equal(0))
+
+		process.Signal(os.Interrupt)
+		Eventually(process.Wait()).Should(Receive(BeNil()))
+	})
+
+	Context("when there are no containers on the cell", func() {
+		It("releases the lease, deletes the vtep and writes the release file", func() {
+			stop()
+
+			Expect(store.ReadAllLockedCallCount()).To(Equal(1))
+			path, _ := store.ReadAllLockedArgsForCall(0)
+			Expect(path).To(Equal("/some/datastore.json"))
+
+			Expect(controllerClient.ReleaseSubnetLeaseCallCount()).To(Equal(1))
+			Expect(controllerClient.ReleaseSubnetLeaseArgsForCall(0)).To(Equal("10.244.4.5"))
+
+			Expect(vtepDeleter.DeleteVTEPCallCount()).To(Equal(1))
+			Expect(vtepDeleter.DeleteVTEPArgsForCall(0)).To(Equal("silk-vtep"))
+
+			contents, err := os.ReadFile(releaseFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(contents).To(MatchJSON(`{
+				"underlay_ip": "10.244.4.5",
+				"overlay_subnet": "10.255.4.0/24",
+				"overlay_hardware_addr": "ee:ee:0a:ff:04:00"
+			}`))
+			Expect(logger).To(gbytes.Say("release-lease.released"))
+		})
+
+		It("holds the datastore lock until the release file is written", func() {
+			locked := false
+			store.ReadAllLockedStub = func(_ string, f func(map[string]datastore.Container) error) error {
+				locked = true
+				defer func() { locked = false }()
+				return f(map[string]datastore.Container{})
+			}
+			controllerClient.ReleaseSubnetLeaseStub = func(string) error {
+				Expect(locked).To(BeTrue())
+				return nil
+			}
+			vtepDeleter.DeleteVTEPStub = func(string) error {
+				Expect(locked).To(BeTrue())
+				Expect(releaseFile).NotTo(BeAnExistingFile())
+				return nil
+			}
+
+			stop()
+
+			Expect(releaseFile).To(BeAnExistingFile())
+			Expect(locked).To(BeFalse())
+		})
+
+		Context("when releasing the lease fails", func() {
+			BeforeEach(func() {
+				controllerClient.ReleaseSubnetLeaseReturns(errors.New("banana"))
+			})
+
+			It("logs the error and keeps the vtep", func() {
+				stop()
+
+				Expect(logger).To(gbytes.Say("release-lease.release-subnet-lease.*banana"))
+				Expect(vtepDeleter.DeleteVTEPCallCount()).To(Equal(0))
+				Expect(releaseFile).NotTo(BeAnExistingFile())
+			})
+		})
+
+		Context("when deleting the vtep fails", func() {
+			BeforeEach(func() {
+				vtepDeleter.DeleteVTEPReturns(errors.New("kiwi"))
+			})
+
+			It("logs the error and does not write the release file", func() {
+				stop()
+
+				Expect(logger).To(gbytes.Say("release-lease.delete-vtep.*kiwi"))
+				Expect(releaseFile).NotTo(BeAnExistingFile())
+			})
+		})
+	})
+
+	Context("when there are containers on the cell", func() {
+		BeforeEach(func() {
+			store.ReadAllLockedStub = func(_ string, f func(map[string]datastore.Container) error) error {
+				return f(map[string]datastore.Container{
+					"some-handle": {Handle: "some-handle"},
+				})
+			}
+		})
+
+		It("keeps the lease and the vtep", func() {
+			stop()
+
+			Expect(logger).To(gbytes.Say("release-lease.skipped"))
+			Expect(controllerClient.ReleaseSubnetLeaseCallCount()).To(Equal(0))
+			Expect(vtepDeleter.DeleteVTEPCallCount()).To(Equal(0))
+			Expect(releaseFile).NotTo(BeAnExistingFile())
+		})
+	})
+
+	Context("when the datastore cannot be read", func() {
+		BeforeEach(func() {
+			store.ReadAllLockedStub = nil
+			store.ReadAllLockedReturns(errors.New("pear"))
+		})
+
+		It("logs the error and keeps the lease", func() {
+			stop()
+
+			Expect(logger).To(gbytes.Say("release-lease.read-datastore.*pear"))
+			Expect(controllerClient.ReleaseSubnetLeaseCallCount()).To(Equal(0))
+			Expect(releaseFile).NotTo(BeAnExistingFile())
+		})
+	})
+})
@@ -2,6 +2,9 @@ package planner
 
 import (
 	"fmt"
+	"sort"
+	"sync"
+	"time"
 
 	"code.cloudfoundry.org/cf-networking-helpers/poller"
 	"code.cloudfoundry.org/lager/v3"
@@ -14,6 +17,11 @@ type controllerClient interface {
 	RenewSubnetLease(controller.Lease) error
 }
 
+//go:generate counterfeiter -o fakes/lease_watcher.go --fake-name LeaseWatcher . leaseWatcher
+type leaseWatcher interface {
+	WatchLeases(epoch string, revision uint64, waitSeconds int) (controller.LeaseUpdate, error)
+}
+
 //go:generate counterfeiter -o fakes/converger.go --fake-name Converger . converger
 type converger interface {
 	Converge([]controller.Lease) error
@@ -32,10 +40,44 @@ type VXLANPlanner struct {
 	Lease            controller.Lease
 	ErrorDetector    FatalErrorDetector
 	MetricSender     metricSender
+
+	// When LeaseWatcher is set DoCycle only renews the lease, and
+	// WatchCycle keeps the routable leases up to date instead.
+	LeaseWatcher leaseWatcher
+	WatchSeconds int
+
+	epoch    string
+	revision uint64
+	leases   map[string]controller.Lease
+
+	statusLock sync.Mutex
+	status     Status
+}
+
+// Status is the outcome of the last lease renewal and convergence.
+type Status struct {
+	LastRenewSuccess    time.Time
+	LastRenewError      string
+	LastConvergeSuccess time.Time
+	LastConvergeError   string
+}
+
+func (v *VXLANPlanner) Status() Status {
+	v.statusLock.Lock()
+	defer v.statusLock.Unlock()
+	return v.status
 }
 
 func (v *VXLANPlanner) DoCycle() error {
 	err := v.ControllerClient.RenewSubnetLease(v.Lease)
+	v.setStatus(func(status *Status) {
+		if err != nil {
+			status.LastRenewError = err.Error()
+		} else {
+			status.LastRenewSuccess = time.Now()
+			status.LastRenewError = ""
+		}
+	})
 	if err != nil {
 		v.MetricSender.IncrementCounter("renewFailure")
 		if v.ErrorDetector.IsFatal(err) {
@@ -48,14 +90,82 @@ func (v *VXLANPlanner) DoCycle() error {
 
 	v.MetricSender.IncrementCounter("renewSuccess")
 
+	if v.LeaseWatcher != nil {
+		return nil
+	}
+
 	leases, err := v.ControllerClient.GetActiveLeases()
 	if err != nil {
 		return fmt.Errorf("get routable leases: %s", err)
 	}
 
+	return v.converge(leases)
+}
+
+// WatchCycle waits for lease changes and converges on the result. It falls
+// back to a full resync when the changes do not follow on from the last
+// revision it applied.
+func (v *VXLANPlanner) WatchCycle() error {
+	update, err := v.LeaseWatcher.WatchLeases(v.epoch, v.revision, v.WatchSeconds)
+	if err != nil {
+		return fmt.Errorf("watch leases: %s", err)
+	}
+
+	if !update.Resync && (v.leases == nil || update.Epoch != v.epoch || update.PreviousRevision != v.revision) {
+		v.Logger.Info("lease-revision-gap", lager.Data{
+			"epoch":             v.epoch,
+			"revision":          v.revision,
+			"update_epoch":      update.Epoch,
+			"previous_revision": update.PreviousRevision,
+		})
+		update, err = v.LeaseWatcher.WatchLeases("", 0, 0)
+		if err != nil {
+			return fmt.Errorf("resync leases: %s", err)
+		}
+		if !update.Resync {
+			return fmt.Errorf("resync leases: got changes from revision %d", update.PreviousRevision)
+		}
+	}
+
+	if update.Resync {
+		v.leases = make(map[string]controller.Lease, len(update.Leases))
+		for _, lease := range update.Leases {
+			v.leases[lease.UnderlayIP] = lease
+		}
+	} else {
+		for _, lease := range update.Removed {
+			delete(v.leases, lease.UnderlayIP)
+		}
+		for _, lease := range update.Added {
+			v.leases[lease.UnderlayIP] = lease
+		}
+	}
+	v.epoch = update.Epoch
+	v.revision = update.Revision
+
+	leases := make([]controller.Lease, 0, len(v.leases))
+	for _, lease := range v.leases {
+		leases = append(leases, lease)
+	}
+	sort.Slice(leases, func(i, j int) bool {
+		return leases[i].UnderlayIP < leases[j].UnderlayIP
+	})
+
+	return v.converge(leases)
+}
+
+func (v *VXLANPlanner) converge(leases []controller.Lease) error {
 	v.MetricSender.SendValue("numberLeases", float64(len(leases)), "")
 
-	err = v.Converger.Converge(leases)
+	err := v.Converger.Converge(leases)
+	v.setStatus(func(status *Status) {
+		if err != nil {
+			status.LastConvergeError = err.Error()
+		} else {
+			status.LastConvergeSuccess = time.Now()
+			status.LastConvergeError = ""
+		}
+	})
 	if err != nil {
 		v.MetricSender.IncrementCounter("convergeFailure")
 		return fmt.Errorf("converge leases: %s", err)
@@ -65,3 +175,9 @@ func (v *VXLANPlanner) DoCycle() error {
 	v.Logger.Debug("converge-leases", lager.Data{"leases": leases})
 	return nil
 }
+
+func (v *VXLANPlanner) setStatus(update func(*Status)) {
+	v.statusLock.Lock()
+	defer v.statusLock.Unlock()
+	update(&v.status)
+}
@@ -2,6 +2,7 @@ package planner_test
 
 import (
 	"errors"
+	"time"
 
 	"code.cloudfoundry.org/cf-networking-helpers/poller"
 	"code.cloudfoundry.org/lager/v3"
@@ -164,6 +165,19 @@ var _ = Describe("VxlanPlanner", func() {
 			Expect(metricSender.IncrementCounterArgsForCall(1)).To(Equal("convergeSuccess"))
 		})
 
+		It("records the time of the last successful renewal and convergence", func() {
+			Expect(vxlanPlanner.Status()).To(Equal(planner.Status{}))
+
+			err := vxlanPlanner.DoCycle()
+			Expect(err).NotTo(HaveOccurred())
+
+			status := vxlanPlanner.Status()
+			Expect(status.LastRenewSuccess).To(BeTemporally("~", time.Now(), time.Second))
+			Expect(status.LastConvergeSuccess).To(BeTemporally("~", time.Now(), time.Second))
+			Expect(status.LastRenewError).To(BeEmpty())
+			Expect(status.LastConvergeError).To(BeEmpty())
+		})
+
 		Context("when renewing the subnet lease fails", func() {
 			Context("when the error is detected as non-fatal", func() {
 				BeforeEach(func() {
@@ -183,6 +197,17 @@ var _ = Describe("VxlanPlanner", func() {
 					Expect(metricSender.IncrementCounterCallCount()).To(Equal(1))
 					Expect(metricSender.IncrementCounterArgsForCall(0)).To(Equal("renewFailure"))
 				})
+
+				It("records the error until the next successful renewal", func() {
+					Expect(vxlanPlanner.DoCycle()).NotTo(Succeed())
+					Expect(vxlanPlanner.Status().LastRenewError).To(Equal("guava"))
+					Expect(vxlanPlanner.Status().LastRenewSuccess).To(BeZero())
+
+					controllerClient.RenewSubnetLeaseReturns(nil)
+					Expect(vxlanPlanner.DoCycle()).To(Succeed())
+					Expect(vxlanPlanner.Status().LastRenewError).To(BeEmpty())
+					Expect(vxlanPlanner.Status().LastRenewSuccess).NotTo(BeZero())
+				})
 			})
 
 			Context("when the error is detected as fatal", func() {
@@ -228,6 +253,181 @@ var _ = Describe("VxlanPlanner", func() {
 				Expect(metricSender.IncrementCounterCallCount()).To(Equal(2))
 				Expect(metricSender.IncrementCounterArgsForCall(1)).To(Equal("convergeFailure"))
 			})
+
+			It("records the error", func() {
+				Expect(vxlanPlanner.DoCycle()).NotTo(Succeed())
+				Expect(vxlanPlanner.Status().LastConvergeError).To(Equal("banana"))
+				Expect(vxlanPlanner.Status().LastConvergeSuccess).To(BeZero())
+			})
+		})
+
+		Context("when leases are watched", func() {
+			BeforeEach(func() {
+				vxlanPlanner.LeaseWatcher = &fakes.LeaseWatcher{}
+			})
+
+			It("only renews the lease", func() {
+				err := vxlanPlanner.DoCycle()
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(controllerClient.RenewSubnetLeaseCallCount()).To(Equal(1))
+				Expect(controllerClient.GetActiveLeasesCallCount()).To(Equal(0))
+				Expect(converger.ConvergeCallCount()).To(Equal(0))
+			})
+		})
+	})
+
+	Describe("WatchCycle", func() {
+		var (
+			leaseWatcher *fakes.LeaseWatcher
+			lease1       controller.Lease
+			lease2       controller.Lease
+			lease3       controller.Lease
+		)
+
+		BeforeEach(func() {
+			lease1 = controller.Lease{UnderlayIP: "172.244.15.0", OverlaySubnet: "10.244.15.0/24", OverlayHardwareAddr: "ee:ee:0a:f4:0f:00"}
+			lease2 = controller.Lease{UnderlayIP: "172.244.16.0", OverlaySubnet: "10.244.16.0/24", OverlayHardwareAddr: "ee:ee:0a:f4:10:00"}
+			lease3 = controller.Lease{UnderlayIP: "172.244.18.0", OverlaySubnet: "10.244.18.0/24", OverlayHardwareAddr: "ee:ee:0a:f4:12:00"}
+
+			leaseWatcher = &fakes.LeaseWatcher{}
+			leaseWatcher.WatchLeasesReturnsOnCall(0, controller.LeaseUpdate{
+				Epoch:    "some-epoch",
+				Revision: 4,
+				Resync:   true,
+				Leases:   []controller.Lease{lease2, lease1},
+			}, nil)
+			vxlanPlanner.LeaseWatcher = leaseWatcher
+			vxlanPlanner.WatchSeconds = 30
+		})
+
+		It("starts with a full resync and converges on the leases", func() {
+			err := vxlanPlanner.WatchCycle()
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(leaseWatcher.WatchLeasesCallCount()).To(Equal(1))
+			epoch, revision, waitSeconds := leaseWatcher.WatchLeasesArgsForCall(0)
+			Expect(epoch).To(BeEmpty())
+			Expect(revision).To(BeZero())
+			Expect(waitSeconds).To(Equal(30))
+
+			Expect(converger.ConvergeCallCount()).To(Equal(1))
+			Expect(converger.ConvergeArgsForCall(0)).To(Equal([]controller.Lease{lease1, lease2}))
+
+			name, value, _ := metricSender.SendValueArgsForCall(0)
+			Expect(name).To(Equal("numberLeases"))
+			Expect(value).To(BeEquivalentTo(2))
+			Expect(metricSender.IncrementCounterArgsForCall(0)).To(Equal("convergeSuccess"))
+		})
+
+		It("applies the changes on top of the last revision", func() {
+			changed := lease2
+			changed.OverlaySubnet = "10.244.19.0/24"
+			leaseWatcher.WatchLeasesReturnsOnCall(1, controller.LeaseUpdate{
+				Epoch:            "some-epoch",
+				Revision:         6,
+				PreviousRevision: 4,
+				Added:            []controller.Lease{changed, lease3},
+				Removed:          []controller.Lease{lease1, lease2},
+			}, nil)
+
+			Expect(vxlanPlanner.WatchCycle()).To(Succeed())
+			Expect(vxlanPlanner.WatchCycle()).To(Succeed())
+
+			epoch, revision, _ := leaseWatcher.WatchLeasesArgsForCall(1)
+			Expect(epoch).To(Equal("some-epoch"))
+			Expect(revision).To(BeEquivalentTo(4))
+
+			Expect(converger.ConvergeCallCount()).To(Equal(2))
+			Expect(converger.ConvergeArgsForCall(1)).To(Equal([]controller.Lease{changed, lease3}))
+		})
+
+		It("converges again when nothing changed", func() {
+			leaseWatcher.WatchLeasesReturnsOnCall(1, controller.LeaseUpdate{
+				Epoch:            "some-epoch",
+				Revision:         4,
+				PreviousRevision: 4,
+			}, nil)
+
+			Expect(vxlanPlanner.WatchCycle()).To(Succeed())
+			Expect(vxlanPlanner.WatchCycle()).To(Succeed())
+
+			Expect(converger.ConvergeCallCount()).To(Equal(2))
+			Expect(converger.ConvergeArgsForCall(1)).To(Equal([]controller.Lease{lease1, lease2}))
+		})
+
+		DescribeTable("falling back to a full resync when the changes do not follow on",
+			func(update controller.LeaseUpdate) {
+				leaseWatcher.WatchLeasesReturnsOnCall(1, update, nil)
+				leaseWatcher.WatchLeasesReturnsOnCall(2, controller.LeaseUpdate{
+					Epoch:    "other-epoch",
+					Revision: 2,
+					Resync:   true,
+					Leases:   []controller.Lease{lease3},
+				}, nil)
+
+				Expect(vxlanPlanner.WatchCycle()).To(Succeed())
+				Expect(vxlanPlanner.WatchCycle()).To(Succeed())
+
+				Expect(leaseWatcher.WatchLeasesCallCount()).To(Equal(3))
+				epoch, revision, waitSeconds := leaseWatcher.WatchLeasesArgsForCall(2)
+				Expect(epoch).To(BeEmpty())
+				Expect(revision).To(BeZero())
+				Expect(waitSeconds).To(BeZero())
+
+				Expect(converger.ConvergeArgsForCall(1)).To(Equal([]controller.Lease{lease3}))
+				Expect(logger.Logs()).To(ContainElement(LogsWith(lager.INFO, "test.lease-revision-gap")))
+
+				leaseWatcher.WatchLeasesReturnsOnCall(3, controller.LeaseUpdate{Epoch: "other-epoch", Revision: 2, PreviousRevision: 2}, nil)
+				Expect(vxlanPlanner.WatchCycle()).To(Succeed())
+				epoch, revision, _ = leaseWatcher.WatchLeasesArgsForCall(3)
+				Expect(epoch).To(Equal("other-epoch"))
+				Expect(revision).To(BeEquivalentTo(2))
+			},
+			Entry("when a revision was skipped", controller.LeaseUpdate{
+				Epoch: "some-epoch", Revision: 7, PreviousRevision: 5, Added: []controller.Lease{lease3},
+			}),
+			Entry("when the epoch changed", controller.LeaseUpdate{
+				Epoch: "other-epoch", Revision: 5, PreviousRevision: 4, Added: []controller.Lease{lease3},
+			}),
+		)
+
+		Context("when watching fails", func() {
+			BeforeEach(func() {
+				leaseWatcher.WatchLeasesReturnsOnCall(0, controller.LeaseUpdate{}, errors.New("guava"))
+			})
+			It("returns the error", func() {
+				err := vxlanPlanner.WatchCycle()
+				Expect(err).To(MatchError("watch leases: guava"))
+				Expect(converger.ConvergeCallCount()).To(Equal(0))
+			})
+		})
+
+		Context("when the resync fails", func() {
+			BeforeEach(func() {
+				leaseWatcher.WatchLeasesReturnsOnCall(0, controller.LeaseUpdate{Epoch: "some-epoch", Revision: 4, PreviousRevision: 3}, nil)
+				leaseWatcher.WatchLeasesReturnsOnCall(1, controller.LeaseUpdate{}, errors.New("guava"))
+			})
+			It("returns the error", func() {
+				err := vxlanPlanner.WatchCycle()
+				Expect(err).To(MatchError("resync leases: guava"))
+			})
+		})
+
+		Context("when the converger fails", func() {
+			BeforeEach(func() {
+				converger.ConvergeReturns(errors.New("banana"))
+			})
+			It("returns an error and converges on the leases next time", func() {
+				err := vxlanPlanner.WatchCycle()
+				Expect(err).To(MatchError("converge leases: banana"))
+				Expect(metricSender.IncrementCounterArgsForCall(0)).To(Equal("convergeFailure"))
+
+				leaseWatcher.WatchLeasesReturnsOnCall(1, controller.LeaseUpdate{Epoch: "some-epoch", Revision: 4, PreviousRevision: 4}, nil)
+				converger.ConvergeReturns(nil)
+				Expect(vxlanPlanner.WatchCycle()).To(Succeed())
+				Expect(converger.ConvergeArgsForCall(1)).To(Equal([]controller.Lease{lease1, lease2}))
+			})
 		})
 	})
 })
@@ -28,7 +28,11 @@ type Config struct {
 	OverlayHardwareAddr net.HardwareAddr
 	VNI                 int
 	VTEPPort            int
+	MTU                 int
+	Encapsulation       Encapsulation
 	OverlayNetworks     mcn.MultipleCIDRNetwork
+	LeaseIPv6           net.IP
+	OverlayNetworksV6   mcn.MultipleCIDRNetwork
 }
 
 func (c *ConfigCreator) Create(clientConf clientConfig.Config, lease controller.Lease) (*Config, error) {
@@ -61,6 +65,22 @@ func (c *ConfigCreator) Create(clientConf clientConfig.Config, lease controller.
 		}
 	}
 
+	encapsulation, err := NewEncapsulation(clientConf.Encapsulation)
+	if err != nil {
+		return nil, err
+	}
+
+	overhead := encapsulation.Overhead(underlayIP)
+	maxMTU := underlayInterface.MTU - overhead
+	mtu := maxMTU
+	if clientConf.VTEPMTU != 0 {
+		if clientConf.VTEPMTU > maxMTU {
+			return nil, fmt.Errorf("vtep mtu %d is larger than the mtu %d of %s minus the %s overhead %d",
+				clientConf.VTEPMTU, underlayInterface.MTU, underlayInterface.Name, encapsulation.Name(), overhead)
+		}
+		mtu = clientConf.VTEPMTU
+	}
+
 	leaseIP, _, err := net.ParseCIDR(lease.OverlaySubnet)
 	if err != nil {
 		return nil, fmt.Errorf("determine vtep overlay ip: %s", err)
@@ -75,16 +95,44 @@ func (c *ConfigCreator) Create(clientConf clientConfig.Config, lease controller.
 		return nil, fmt.Errorf("no overlay networks specified")
 	}
 
-	overlayNetworks, err := mcn.NewMultipleCIDRNetwork(clientConf.OverlayNetworks)
+	ipv4OverlayNetworks, ipv6OverlayNetworks, err := mcn.SplitByFamily(clientConf.OverlayNetworks)
 	if err != nil {
 		return nil, fmt.Errorf("creating multiple CIDR Network: %s", err)
 	}
 
+	overlayNetworks, err := mcn.NewMultipleCIDRNetwork(ipv4OverlayNetworks)
+	if err != nil {
+		return nil, fmt.Errorf("creating multiple CIDR Network: %s", err) // not tested, already parsed
+	}
+
 	if overlayNetworks.SmallestMask >= clientConf.SubnetPrefixLength {
 		return nil, fmt.Errorf("overlay prefix %d must be smaller than subnet prefix %d",
 			overlayNetworks.SmallestMask, clientConf.SubnetPrefixLength)
 	}
 
+	var leaseIPv6 net.IP
+	var overlayNetworksV6 mcn.MultipleCIDRNetwork
+	if lease.OverlaySubnetV6 != "" {
+		if len(ipv6OverlayNetworks) == 0 {
+			return nil, fmt.Errorf("lease has ipv6 subnet %s but no ipv6 overlay networks specified", lease.OverlaySubnetV6)
+		}
+
+		leaseIPv6, _, err = net.ParseCIDR(lease.OverlaySubnetV6)
+		if err != nil {
+			return nil, fmt.Errorf("determine vtep overlay ipv6: %s", err)
+		}
+
+		overlayNetworksV6, err = mcn.NewMultipleCIDRNetwork(ipv6OverlayNetworks)
+		if err != nil {
+			return nil, fmt.Errorf("creating multiple CIDR Network: %s", err) // not tested, already parsed
+		}
+
+		if overlayNetworksV6.SmallestMask >= clientConf.SubnetPrefixLengthV6 {
+			return nil, fmt.Errorf("overlay ipv6 prefix %d must be smaller than ipv6 subnet prefix %d",
+				overlayNetworksV6.SmallestMask, clientConf.SubnetPrefixLengthV6)
+		}
+	}
+
 	return &Config{
 		VTEPName:            clientConf.VTEPName,
 		UnderlayInterface:   underlayInterface,
@@ -94,6 +142,10 @@ func (c *ConfigCreator) Create(clientConf clientConfig.Config, lease controller.
 		OverlayHardwareAddr: overlayHardwareAddr,
 		VNI:                 clientConf.VNI,
 		VTEPPort:            clientConf.VTEPPort,
+		MTU:                 mtu,
+		Encapsulation:       encapsulation,
+		LeaseIPv6:           leaseIPv6,
+		OverlayNetworksV6:   overlayNetworksV6,
 	}, nil
 }
 
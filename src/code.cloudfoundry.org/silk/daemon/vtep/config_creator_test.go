@@ -41,6 +41,8 @@ var _ = Describe("ConfigCreator", func() {
 
 			fakeNetAdapter.InterfacesReturns([]net.Interface{net.Interface{
 				Index: 42,
+				MTU:   9000,
+				Name:  "eth0",
 			}}, nil)
 			fakeNetAdapter.InterfaceAddrsReturns([]net.Addr{
 				&net.IPNet{
@@ -54,19 +56,83 @@ var _ = Describe("ConfigCreator", func() {
 			conf, err := creator.Create(clientConf, lease)
 			Expect(err).NotTo(HaveOccurred())
 			Expect(conf.VTEPName).To(Equal("some-vtep-name"))
-			Expect(conf.UnderlayInterface).To(Equal(net.Interface{Index: 42}))
+			Expect(conf.UnderlayInterface).To(Equal(net.Interface{Index: 42, MTU: 9000, Name: "eth0"}))
 			Expect(conf.UnderlayIP.String()).To(Equal("172.255.30.2"))
 			Expect(conf.LeaseIP.String()).To(Equal("10.255.30.0"))
 			Expect(conf.OverlayHardwareAddr).To(Equal(net.HardwareAddr{0xee, 0xee, 0x0a, 0xff, 0x1e, 0x00}))
 			Expect(conf.VNI).To(Equal(99))
 			Expect(conf.VTEPPort).To(Equal(12225))
+			Expect(conf.MTU).To(Equal(8950))
+			Expect(conf.Encapsulation.Name()).To(Equal("vxlan-gbp"))
 
 			Expect(fakeNetAdapter.InterfacesCallCount()).To(Equal(1))
 			Expect(fakeNetAdapter.InterfaceAddrsCallCount()).To(Equal(1))
-			Expect(fakeNetAdapter.InterfaceAddrsArgsForCall(0)).To(Equal(net.Interface{Index: 42}))
+			Expect(fakeNetAdapter.InterfaceAddrsArgsForCall(0)).To(Equal(net.Interface{Index: 42, MTU: 9000, Name: "eth0"}))
 			Expect(fakeNetAdapter.InterfaceByNameCallCount()).To(Equal(0))
 		})
 
+		Context("when the vtep mtu is set", func() {
+			BeforeEach(func() {
+				clientConf.VTEPMTU = 1500
+			})
+
+			It("uses it", func() {
+				conf, err := creator.Create(clientConf, lease)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(conf.MTU).To(Equal(1500))
+			})
+
+			Context("when it does not fit in the mtu of the underlay interface", func() {
+				BeforeEach(func() {
+					clientConf.VTEPMTU = 8960
+				})
+
+				It("returns an error", func() {
+					_, err := creator.Create(clientConf, lease)
+					Expect(err).To(MatchError("vtep mtu 8960 is larger than the mtu 9000 of eth0 minus the vxlan-gbp overhead 50"))
+				})
+			})
+		})
+
+		Context("when the encapsulation is geneve", func() {
+			BeforeEach(func() {
+				clientConf.Encapsulation = "geneve"
+			})
+
+			It("allows for the geneve header and the policy tag option", func() {
+				conf, err := creator.Create(clientConf, lease)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(conf.Encapsulation.Name()).To(Equal("geneve"))
+				Expect(conf.MTU).To(Equal(8942))
+			})
+		})
+
+		Context("when the encapsulation is unknown", func() {
+			BeforeEach(func() {
+				clientConf.Encapsulation = "gre"
+			})
+
+			It("returns an error", func() {
+				_, err := creator.Create(clientConf, lease)
+				Expect(err).To(MatchError(`unknown encapsulation "gre", want vxlan-gbp or geneve`))
+			})
+		})
+
+		Context("when the underlay is ipv6", func() {
+			BeforeEach(func() {
+				clientConf.UnderlayIP = "fd00::2"
+				fakeNetAdapter.InterfaceAddrsReturns([]net.Addr{
+					&net.IPNet{IP: net.ParseIP("fd00::2"), Mask: net.CIDRMask(128, 128)},
+				}, nil)
+			})
+
+			It("allows for the larger outer ipv6 header", func() {
+				conf, err := creator.Create(clientConf, lease)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(conf.MTU).To(Equal(8930))
+			})
+		})
+
 		Context("when VxlanInterfaceName is set", func() {
 			BeforeEach(func() {
 				clientConf.VxlanInterfaceName = "eth1"
@@ -264,5 +330,69 @@ var _ = Describe("ConfigCreator", func() {
 				})
 			})
 		})
+
+		Context("when the lease has an ipv6 subnet", func() {
+			BeforeEach(func() {
+				clientConf.OverlayNetworks = append(clientConf.OverlayNetworks, "fd00::/48")
+				clientConf.SubnetPrefixLengthV6 = 64
+				lease.OverlaySubnetV6 = "fd00:0:0:2a::/64"
+			})
+
+			It("returns a config with the ipv6 lease and overlay networks", func() {
+				conf, err := creator.Create(clientConf, lease)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(conf.LeaseIP.String()).To(Equal("10.255.30.0"))
+				Expect(conf.LeaseIPv6.String()).To(Equal("fd00:0:0:2a::"))
+				Expect(conf.OverlayNetworks.Networks).To(HaveLen(1))
+				Expect(conf.OverlayNetworksV6.Networks).To(HaveLen(1))
+				Expect(conf.OverlayNetworksV6.Networks[0].String()).To(Equal("fd00::/48"))
+			})
+
+			Context("when there are no ipv6 overlay networks", func() {
+				BeforeEach(func() {
+					clientConf.OverlayNetworks = []string{"10.255.0.0/16"}
+				})
+
+				It("returns a sensible error", func() {
+					_, err := creator.Create(clientConf, lease)
+					Expect(err).To(MatchError("lease has ipv6 subnet fd00:0:0:2a::/64 but no ipv6 overlay networks specified"))
+				})
+			})
+
+			Context("when the ipv6 lease subnet is malformed", func() {
+				BeforeEach(func() {
+					lease.OverlaySubnetV6 = "fd00::2a::/64"
+				})
+
+				It("returns a sensible error", func() {
+					_, err := creator.Create(clientConf, lease)
+					Expect(err).To(MatchError(ContainSubstring("determine vtep overlay ipv6")))
+				})
+			})
+
+			Context("when the ipv6 overlay prefix is not smaller than the ipv6 subnet prefix", func() {
+				BeforeEach(func() {
+					clientConf.SubnetPrefixLengthV6 = 48
+				})
+
+				It("returns a sensible error", func() {
+					_, err := creator.Create(clientConf, lease)
+					Expect(err).To(MatchError("overlay ipv6 prefix 48 must be smaller than ipv6 subnet prefix 48"))
+				})
+			})
+		})
+
+		Context("when the overlay networks include ipv6 but the lease does not", func() {
+			BeforeEach(func() {
+				clientConf.OverlayNetworks = append(clientConf.OverlayNetworks, "fd00::/48")
+			})
+
+			It("returns an ipv4 only config", func() {
+				conf, err := creator.Create(clientConf, lease)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(conf.LeaseIPv6).To(BeNil())
+				Expect(conf.OverlayNetworks.Networks).To(HaveLen(1))
+			})
+		})
 	})
 })
@@ -6,6 +6,7 @@ import (
 	"syscall"
 
 	"strings"
+	"sync"
 
 	"code.cloudfoundry.org/lager/v3"
 	mcn "code.cloudfoundry.org/lib/multiple-cidr-network"
@@ -14,28 +15,200 @@ import (
 	"golang.org/x/sys/unix"
 )
 
+//go:generate counterfeiter -o fakes/metricSender.go --fake-name MetricSender . metricSender
+type metricSender interface {
+	SendValue(name string, value float64, units string)
+	IncrementCounter(name string)
+}
+
 type Converger struct {
-	OverlayNetwork mcn.MultipleCIDRNetwork
-	LocalSubnet    *net.IPNet
-	LocalVTEP      net.Interface
-	NetlinkAdapter netlinkAdapter
-	Logger         lager.Logger
-	IsSingleIP     bool
+	OverlayNetwork   mcn.MultipleCIDRNetwork
+	LocalSubnet      *net.IPNet
+	OverlayNetworkV6 mcn.MultipleCIDRNetwork
+	LocalSubnetV6    *net.IPNet
+	LocalVTEP        net.Interface
+	NetlinkAdapter   netlinkAdapter
+	Logger           lager.Logger
+	IsSingleIP       bool
+	MetricSender     metricSender
+	Encapsulation    Encapsulation
+	VNI              int
+
+	lock       sync.Mutex
+	leases     []controller.Lease
+	programmed convergeCounts
+	// tunnels are the encaps of the routes that were programmed, since
+	// they can not be read back from the vtep
+	tunnels map[string]string
+}
+
+// convergeCounts counts the routes and neighbours of one Converge call.
+// Added includes the entries that were replaced because they changed.
+type convergeCounts struct {
+	routesAdded     int
+	routesRemoved   int
+	routesUnchanged int
+	neighsAdded     int
+	neighsRemoved   int
+	neighsUnchanged int
 }
 
+// Converge compares the routes and neighbours for the leases with the ones
+// on the vtep, keyed by destination, and only changes the entries that
+// differ.
 func (c *Converger) Converge(leases []controller.Lease) error {
-	previousRoutes, previousNeighs, err := c.getPreviousState(c.LocalVTEP.Index)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	counts, err := c.converge(leases)
 	if err != nil {
 		return err
 	}
+	c.leases = leases
+	c.programmed = counts
+	return nil
+}
+
+// Repair converges the vtep to the leases of the last successful Converge
+// again, so that only the entries that were changed outside of silk are
+// rewritten. It returns whether anything had to be changed.
+func (c *Converger) Repair() (bool, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.leases == nil {
+		return false, nil
+	}
+
+	counts, err := c.converge(c.leases)
+	if err != nil {
+		return false, err
+	}
+	c.programmed = counts
+	return counts.routesAdded+counts.routesRemoved+counts.neighsAdded+counts.neighsRemoved > 0, nil
+}
+
+// Programmed returns the number of routes and neighbours on the vtep after
+// the last successful convergence.
+func (c *Converger) Programmed() (int, int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.programmed.routesAdded + c.programmed.routesUnchanged,
+		c.programmed.neighsAdded + c.programmed.neighsUnchanged
+}
+
+// SetVTEPIndex points the converger at a recreated vtep.
+func (c *Converger) SetVTEPIndex(index int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.LocalVTEP.Index = index
+}
+
+func (c *Converger) converge(leases []controller.Lease) (convergeCounts, error) {
+	var counts convergeCounts
+
+	previousRoutes, previousNeighs, err := c.getPreviousState(c.LocalVTEP.Index)
+	if err != nil {
+		return counts, err
+	}
+
+	currentRoutes, currentNeighs, err := c.desiredState(leases)
+	if err != nil {
+		return counts, err
+	}
+
+	currentRouteKeys := make(map[string]struct{}, len(currentRoutes))
+	for _, route := range currentRoutes {
+		currentRouteKeys[routeKey(route)] = struct{}{}
+	}
+	previousRouteByKey := make(map[string]netlink.Route, len(previousRoutes))
+	for _, route := range previousRoutes {
+		key := routeKey(route)
+		previousRouteByKey[key] = route
+		if _, ok := currentRouteKeys[key]; ok {
+			continue
+		}
+		if route.LinkIndex == c.LocalVTEP.Index && (c.OverlayNetwork.Contains(route.Gw) || c.isOverlayV6(route.Gw)) {
+			err = c.NetlinkAdapter.RouteDel(&route)
+			if err != nil {
+				return counts, fmt.Errorf("del route: %s", err)
+			}
+			counts.routesRemoved++
+		}
+	}
+
+	currentNeighKeys := make(map[string]struct{}, len(currentNeighs))
+	for _, neigh := range currentNeighs {
+		currentNeighKeys[neighKey(neigh)] = struct{}{}
+	}
+	previousNeighByKey := make(map[string]netlink.Neigh, len(previousNeighs))
+	for _, neigh := range previousNeighs {
+		key := neighKey(neigh)
+		previousNeighByKey[key] = neigh
+		if _, ok := currentNeighKeys[key]; ok {
+			continue
+		}
+		if neigh.LinkIndex == c.LocalVTEP.Index {
+			err = c.NetlinkAdapter.NeighDel(&neigh)
+			if err != nil {
+				return counts, fmt.Errorf("del neigh with ip/hwaddr %s: %s", &neigh, err)
+			}
+			counts.neighsRemoved++
+		}
+	}
+
+	tunnels := make(map[string]string, len(currentRoutes))
+	for _, route := range currentRoutes {
+		key := routeKey(route)
+		if route.Encap != nil {
+			tunnels[key] = route.Encap.String()
+		}
+		if previous, ok := previousRouteByKey[key]; ok && routeEqual(previous, route) && c.tunnels[key] == tunnels[key] {
+			counts.routesUnchanged++
+			continue
+		}
+		err = c.NetlinkAdapter.RouteReplace(&route)
+		if err != nil {
+			if route.Dst.IP.To4() == nil {
+				return counts, fmt.Errorf("add ipv6 route: %s", err)
+			}
+			return counts, fmt.Errorf("add route: %s", err)
+		}
+		counts.routesAdded++
+	}
+	c.tunnels = tunnels
+
+	for _, neigh := range currentNeighs {
+		if previous, ok := previousNeighByKey[neighKey(neigh)]; ok && neighEqual(previous, neigh) {
+			counts.neighsUnchanged++
+			continue
+		}
+		err = c.NetlinkAdapter.NeighSet(&neigh)
+		if err != nil {
+			if neigh.Family == syscall.AF_INET6 {
+				return counts, fmt.Errorf("set ipv6 neigh: %s", err)
+			}
+			return counts, fmt.Errorf("set neigh: %s", err)
+		}
+		counts.neighsAdded++
+	}
 
+	c.sendCounts(counts)
+	return counts, nil
+}
+
+// desiredState builds the routes and neighbours for the remote leases
+// without touching the vtep.
+func (c *Converger) desiredState(leases []controller.Lease) ([]netlink.Route, []netlink.Neigh, error) {
 	nonRoutableLeaseCount := 0
 	var currentRoutes []netlink.Route
 	var currentNeighs []netlink.Neigh
 	for _, lease := range leases {
 		destAddr, destNet, err := net.ParseCIDR(lease.OverlaySubnet)
 		if err != nil {
-			return fmt.Errorf("parse lease: %s", err)
+			return nil, nil, fmt.Errorf("parse lease: %s", err)
 		}
 
 		if c.isLocal(destNet) {
@@ -47,47 +220,41 @@ func (c *Converger) Converge(leases []controller.Lease) error {
 			continue
 		}
 
-		if !isSingleIPLease(lease) {
-			route, err := c.addRoute(destNet, destAddr)
+		underlayIP := net.ParseIP(lease.UnderlayIP)
+		if underlayIP == nil {
+			return nil, nil, fmt.Errorf("invalid underlay ip: %s", lease.UnderlayIP)
+		}
+		tunnel := c.Encapsulation.Tunnel(c.VNI, underlayIP)
+
+		// without an FDB a single ip lease needs a route to carry its tunnel
+		if !isSingleIPLease(lease) || tunnel != nil {
+			route, err := c.route(destNet, destAddr)
 			if err != nil {
-				return err
+				return nil, nil, err
 			}
+			if isSingleIPLease(lease) {
+				route.Flags = unix.RTNH_F_ONLINK
+			}
+			route.Encap = tunnel
 			currentRoutes = append(currentRoutes, route)
 		}
 
-		underlayIP := net.ParseIP(lease.UnderlayIP)
-		if underlayIP == nil {
-			return fmt.Errorf("invalid underlay ip: %s", lease.UnderlayIP)
-		}
-
 		remoteMac, err := net.ParseMAC(lease.OverlayHardwareAddr)
 		if err != nil {
-			return fmt.Errorf("invalid hardware addr: %s", lease.OverlayHardwareAddr)
+			return nil, nil, fmt.Errorf("invalid hardware addr: %s", lease.OverlayHardwareAddr)
 		}
 
-		neighs, err := c.addNeighs(underlayIP, destAddr, remoteMac)
-		if err != nil {
-			return err
-		}
-		currentNeighs = append(currentNeighs, neighs...)
-	}
+		currentNeighs = append(currentNeighs, c.neighs(underlayIP, destAddr, remoteMac)...)
 
-	routesForDeletion := getDeletedRoutes(previousRoutes, currentRoutes)
-	for _, route := range routesForDeletion {
-		if route.LinkIndex == c.LocalVTEP.Index && c.OverlayNetwork.Contains(route.Gw) {
-			err = c.NetlinkAdapter.RouteDel(&route)
+		if c.LocalSubnetV6 != nil && lease.OverlaySubnetV6 != "" && !isSingleIPLease(lease) {
+			route, neigh, err := c.ipv6(lease.OverlaySubnetV6, remoteMac)
 			if err != nil {
-				return fmt.Errorf("del route: %s", err)
+				return nil, nil, err
 			}
-		}
-	}
-
-	neighsForDeletion := getDeletedNeighs(previousNeighs, currentNeighs)
-	for _, neigh := range neighsForDeletion {
-		if neigh.LinkIndex == c.LocalVTEP.Index {
-			err = c.NetlinkAdapter.NeighDel(&neigh)
-			if err != nil {
-				return fmt.Errorf("del neigh with ip/hwaddr %s: %s", &neigh, err)
+			if route != nil {
+				route.Encap = tunnel
+				currentRoutes = append(currentRoutes, *route)
+				currentNeighs = append(currentNeighs, *neigh)
 			}
 		}
 	}
@@ -96,48 +263,42 @@ func (c *Converger) Converge(leases []controller.Lease) error {
 		c.Logger.Info("converger", lager.Data{"non-routable-lease-count": nonRoutableLeaseCount})
 	}
 
-	return nil
+	return currentRoutes, currentNeighs, nil
+}
+
+func (c *Converger) sendCounts(counts convergeCounts) {
+	c.MetricSender.SendValue("routesAdded", float64(counts.routesAdded), "")
+	c.MetricSender.SendValue("routesRemoved", float64(counts.routesRemoved), "")
+	c.MetricSender.SendValue("routesUnchanged", float64(counts.routesUnchanged), "")
+	c.MetricSender.SendValue("neighsAdded", float64(counts.neighsAdded), "")
+	c.MetricSender.SendValue("neighsRemoved", float64(counts.neighsRemoved), "")
+	c.MetricSender.SendValue("neighsUnchanged", float64(counts.neighsUnchanged), "")
 }
 
 func (c *Converger) isLocal(destNet *net.IPNet) bool {
 	return destNet.String() == c.LocalSubnet.String()
 }
 
+func (c *Converger) isOverlayV6(ip net.IP) bool {
+	return c.LocalSubnetV6 != nil && ip != nil && c.OverlayNetworkV6.Contains(ip)
+}
+
 func isSingleIPLease(lease controller.Lease) bool {
 	return strings.Contains(lease.OverlaySubnet, "/32")
 }
 
-func getDeletedRoutes(previous, current []netlink.Route) []netlink.Route {
-	var deletedRoutes []netlink.Route
-	for _, previousRoute := range previous {
-		isRemoved := true
-		for _, currentRoute := range current {
-			isRemoved = isRemoved && !routeEqual(previousRoute, currentRoute)
-		}
-
-		if isRemoved {
-			deletedRoutes = append(deletedRoutes, previousRoute)
-		}
-	}
-
-	return deletedRoutes
-
+// routeKey identifies a route on the vtep by its destination.
+func routeKey(route netlink.Route) string {
+	return route.Dst.String()
 }
 
-func getDeletedNeighs(previous, current []netlink.Neigh) []netlink.Neigh {
-	var deletedNeighs []netlink.Neigh
-	for _, previousNeigh := range previous {
-		isRemoved := true
-		for _, currentNeigh := range current {
-			isRemoved = isRemoved && !neighEqual(previousNeigh, currentNeigh)
-		}
-
-		if isRemoved {
-			deletedNeighs = append(deletedNeighs, previousNeigh)
-		}
+// neighKey identifies an ARP or NDP entry by its IP, and an FDB entry by its
+// MAC and remote vtep IP.
+func neighKey(neigh netlink.Neigh) string {
+	if neigh.Family == syscall.AF_BRIDGE {
+		return fmt.Sprintf("fdb/%s/%s", neigh.HardwareAddr, neigh.IP)
 	}
-
-	return deletedNeighs
+	return fmt.Sprintf("ip/%s", neigh.IP)
 }
 
 func (c *Converger) getPreviousState(index int) ([]netlink.Route, []netlink.Neigh, error) {
@@ -155,6 +316,10 @@ func (c *Converger) getPreviousState(index int) ([]netlink.Route, []netlink.Neig
 	if err != nil {
 		return nil, nil, fmt.Errorf("list fdb: %s", err)
 	}
+	// neighbours are keyed by family, so make sure every FDB entry has it
+	for i := range previousFDBNeighs {
+		previousFDBNeighs[i].Family = syscall.AF_BRIDGE
+	}
 
 	previousARPNeighs, err := c.NetlinkAdapter.ARPList(c.LocalVTEP.Index)
 	if err != nil {
@@ -163,10 +328,30 @@ func (c *Converger) getPreviousState(index int) ([]netlink.Route, []netlink.Neig
 
 	previousNeighs := append(previousARPNeighs, previousFDBNeighs...)
 
+	if c.LocalSubnetV6 != nil {
+		previousRoutesV6, err := c.NetlinkAdapter.RouteList(link, netlink.FAMILY_V6)
+		if err != nil {
+			return nil, nil, fmt.Errorf("list ipv6 routes: %s", err)
+		}
+		previousRoutes = append(previousRoutes, previousRoutesV6...)
+
+		previousNDPNeighs, err := c.NetlinkAdapter.NDPList(c.LocalVTEP.Index)
+		if err != nil {
+			return nil, nil, fmt.Errorf("list ndp: %s", err)
+		}
+		// the kernel maintains link-local and multicast neighbours on the
+		// vtep itself, only the ones programmed for remote leases are ours
+		for _, neigh := range previousNDPNeighs {
+			if c.OverlayNetworkV6.Contains(neigh.IP) {
+				previousNeighs = append(previousNeighs, neigh)
+			}
+		}
+	}
+
 	return previousRoutes, previousNeighs, nil
 }
 
-func (c *Converger) addRoute(destNet *net.IPNet, destAddr net.IP) (netlink.Route, error) {
+func (c *Converger) route(destNet *net.IPNet, destAddr net.IP) (netlink.Route, error) {
 
 	route := netlink.Route{
 		LinkIndex: c.LocalVTEP.Index,
@@ -193,44 +378,53 @@ func (c *Converger) addRoute(destNet *net.IPNet, destAddr net.IP) (netlink.Route
 		route.Src = overlayNetwork.IP
 	}
 
-	err := c.NetlinkAdapter.RouteReplace(&route)
-	if err != nil {
-		return netlink.Route{}, fmt.Errorf("add route: %s", err)
-	}
-
 	return route, nil
 }
 
-func (c *Converger) addNeighs(underlayIP, destAddr net.IP, remoteMac net.HardwareAddr) ([]netlink.Neigh, error) {
-	neighs := []*netlink.Neigh{
-		{ // ARP
-			LinkIndex:    c.LocalVTEP.Index,
-			State:        netlink.NUD_PERMANENT,
-			Type:         syscall.RTN_UNICAST,
-			IP:           destAddr,
-			HardwareAddr: remoteMac,
-		},
-		{ // FDB
-			LinkIndex:    c.LocalVTEP.Index,
-			State:        netlink.NUD_PERMANENT,
-			Family:       syscall.AF_BRIDGE,
-			Flags:        netlink.NTF_SELF,
-			IP:           underlayIP,
-			HardwareAddr: remoteMac,
-		},
+// ipv6 routes a remote lease's IPv6 subnet through the vtep. The remote
+// vtep holds the first address of the subnet, and since it is not on-link
+// from the point of view of the local addressing the route is marked onlink
+// and the neighbour entry is programmed statically, just like ARP for IPv4.
+func (c *Converger) ipv6(overlaySubnetV6 string, remoteMac net.HardwareAddr) (*netlink.Route, *netlink.Neigh, error) {
+	destAddr, destNet, err := net.ParseCIDR(overlaySubnetV6)
+	if err != nil {
+		return nil, nil, fmt.Errorf("parse ipv6 lease: %s", err)
 	}
 
-	var currentNeighs []netlink.Neigh
-	for _, neigh := range neighs {
-		err := c.NetlinkAdapter.NeighSet(neigh)
-		if err != nil {
-			return nil, fmt.Errorf("set neigh: %s", err)
-		}
+	if destNet.String() == c.LocalSubnetV6.String() || !c.OverlayNetworkV6.Contains(destAddr) {
+		return nil, nil, nil
+	}
 
-		currentNeighs = append(currentNeighs, *neigh)
+	route := &netlink.Route{
+		LinkIndex: c.LocalVTEP.Index,
+		Scope:     netlink.SCOPE_UNIVERSE,
+		Dst:       destNet,
+		Gw:        destAddr,
+		Src:       c.LocalSubnetV6.IP,
+		Flags:     unix.RTNH_F_ONLINK,
+	}
+
+	neigh := &netlink.Neigh{ // NDP
+		LinkIndex:    c.LocalVTEP.Index,
+		State:        netlink.NUD_PERMANENT,
+		Type:         syscall.RTN_UNICAST,
+		Family:       syscall.AF_INET6,
+		IP:           destAddr,
+		HardwareAddr: remoteMac,
 	}
 
-	return currentNeighs, nil
+	return route, neigh, nil
+}
+
+func (c *Converger) neighs(underlayIP, destAddr net.IP, remoteMac net.HardwareAddr) []netlink.Neigh {
+	arp := netlink.Neigh{
+		LinkIndex:    c.LocalVTEP.Index,
+		State:        netlink.NUD_PERMANENT,
+		Type:         syscall.RTN_UNICAST,
+		IP:           destAddr,
+		HardwareAddr: remoteMac,
+	}
+	return append([]netlink.Neigh{arp}, c.Encapsulation.FDB(c.LocalVTEP.Index, underlayIP, remoteMac)...)
 }
 
 func routeEqual(r1, r2 netlink.Route) bool {
@@ -238,7 +432,8 @@ func routeEqual(r1, r2 netlink.Route) bool {
 		r1.Scope == r2.Scope &&
 		r1.Dst.String() == r2.Dst.String() &&
 		r1.Gw.String() == r2.Gw.String() &&
-		r1.Src.String() == r2.Src.String()
+		r1.Src.String() == r2.Src.String() &&
+		r1.Flags&unix.RTNH_F_ONLINK == r2.Flags&unix.RTNH_F_ONLINK
 }
 
 func neighEqual(n1, n2 netlink.Neigh) bool {
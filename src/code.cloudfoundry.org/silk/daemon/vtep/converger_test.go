@@ -21,7 +21,9 @@ import (
 var _ = Describe("Converger", func() {
 	var (
 		fakeNetlink                                                  *fakes.NetlinkAdapter
+		fakeMetricSender                                             *fakes.MetricSender
 		converger                                                    *vtep.Converger
+		encapsulation                                                vtep.Encapsulation
 		leases                                                       []controller.Lease
 		overlayNetworks                                              mcn.MultipleCIDRNetwork
 		logger                                                       *lagertest.TestLogger
@@ -39,10 +41,14 @@ var _ = Describe("Converger", func() {
 		BeforeEach(func() {
 			var err error
 			fakeNetlink = &fakes.NetlinkAdapter{}
+			fakeMetricSender = &fakes.MetricSender{}
 
 			overlayNetworks, err = mcn.NewMultipleCIDRNetwork([]string{"10.255.0.0/16", "10.250.0.0/16"})
 			Expect(err).ToNot(HaveOccurred())
 
+			encapsulation, err = vtep.NewEncapsulation(vtep.EncapsulationVXLANGBP)
+			Expect(err).ToNot(HaveOccurred())
+
 			logger = lagertest.NewTestLogger("test")
 			localVTEP = net.Interface{
 				Index: 42,
@@ -94,6 +100,9 @@ var _ = Describe("Converger", func() {
 					NetlinkAdapter: fakeNetlink,
 					Logger:         logger,
 					IsSingleIP:     true,
+					MetricSender:   fakeMetricSender,
+					Encapsulation:  encapsulation,
+					VNI:            99,
 				}
 
 				localLease := controller.Lease{
@@ -156,6 +165,9 @@ var _ = Describe("Converger", func() {
 					NetlinkAdapter: fakeNetlink,
 					Logger:         logger,
 					IsSingleIP:     false,
+					MetricSender:   fakeMetricSender,
+					Encapsulation:  encapsulation,
+					VNI:            99,
 				}
 
 				localLease := controller.Lease{
@@ -286,6 +298,58 @@ var _ = Describe("Converger", func() {
 				Expect(logger.Logs()).To(HaveLen(0))
 			})
 
+			Describe("Repair", func() {
+				It("does nothing before the first converge", func() {
+					repaired, err := converger.Repair()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(repaired).To(BeFalse())
+					Expect(fakeNetlink.LinkByIndexCallCount()).To(Equal(0))
+				})
+
+				It("converges the leases of the last converge again", func() {
+					Expect(converger.Converge(leases)).To(Succeed())
+					Expect(fakeNetlink.RouteReplaceCallCount()).To(Equal(2))
+
+					repaired, err := converger.Repair()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(repaired).To(BeTrue())
+					Expect(fakeNetlink.RouteReplaceCallCount()).To(Equal(4))
+					Expect(fakeNetlink.NeighSetCallCount()).To(Equal(16))
+				})
+
+				It("counts the routes and neighbours it programmed", func() {
+					routes, neighs := converger.Programmed()
+					Expect(routes).To(Equal(0))
+					Expect(neighs).To(Equal(0))
+
+					Expect(converger.Converge(leases)).To(Succeed())
+					routes, neighs = converger.Programmed()
+					Expect(routes).To(Equal(2))
+					Expect(neighs).To(Equal(8))
+				})
+
+				It("converges the vtep it was pointed at", func() {
+					Expect(converger.Converge(leases)).To(Succeed())
+					converger.SetVTEPIndex(43)
+
+					_, err := converger.Repair()
+					Expect(err).NotTo(HaveOccurred())
+					Expect(fakeNetlink.LinkByIndexArgsForCall(1)).To(Equal(43))
+					Expect(fakeNetlink.RouteReplaceArgsForCall(2).LinkIndex).To(Equal(43))
+				})
+
+				Context("when converging fails", func() {
+					It("returns the error", func() {
+						Expect(converger.Converge(leases)).To(Succeed())
+						fakeNetlink.RouteReplaceReturns(errors.New("apricot"))
+
+						repaired, err := converger.Repair()
+						Expect(err).To(MatchError("add route: apricot"))
+						Expect(repaired).To(BeFalse())
+					})
+				})
+			})
+
 			Context("when a non-single IP remote lease is removed", func() {
 				var (
 					deletedNeighs []netlink.Neigh
@@ -456,6 +520,91 @@ var _ = Describe("Converger", func() {
 						},
 					))
 				})
+
+				It("does not rewrite the routing, ARP, and FDB rules that are unchanged", func() {
+					err := converger.Converge(leases)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeNetlink.RouteReplaceCallCount()).To(Equal(0))
+					Expect(fakeNetlink.NeighSetCallCount()).To(Equal(0))
+				})
+
+				It("sends the number of added, removed and unchanged rules", func() {
+					err := converger.Converge(leases)
+					Expect(err).NotTo(HaveOccurred())
+
+					sent := map[string]float64{}
+					for i := 0; i < fakeMetricSender.SendValueCallCount(); i++ {
+						name, value, _ := fakeMetricSender.SendValueArgsForCall(i)
+						sent[name] = value
+					}
+					Expect(sent).To(Equal(map[string]float64{
+						"routesAdded":     0,
+						"routesRemoved":   1,
+						"routesUnchanged": 2,
+						"neighsAdded":     0,
+						"neighsRemoved":   2,
+						"neighsUnchanged": 8,
+					}))
+				})
+
+				Context("when a remote lease has a new hardware address", func() {
+					var newMac net.HardwareAddr
+
+					BeforeEach(func() {
+						newMac, _ = net.ParseMAC("ee:ee:aa:aa:aa:01")
+						leases[1].OverlayHardwareAddr = newMac.String()
+					})
+
+					It("only replaces its ARP rule and swaps its FDB rule", func() {
+						err := converger.Converge(leases)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(fakeNetlink.RouteReplaceCallCount()).To(Equal(0))
+						Expect(fakeNetlink.NeighSetCallCount()).To(Equal(2))
+						Expect(fakeNetlink.NeighSetArgsForCall(0)).To(Equal(&netlink.Neigh{
+							LinkIndex:    42,
+							State:        netlink.NUD_PERMANENT,
+							Type:         syscall.RTN_UNICAST,
+							IP:           net.ParseIP("10.255.19.0"),
+							HardwareAddr: newMac,
+						}))
+						Expect(fakeNetlink.NeighSetArgsForCall(1)).To(Equal(&netlink.Neigh{
+							LinkIndex:    42,
+							State:        netlink.NUD_PERMANENT,
+							Family:       syscall.AF_BRIDGE,
+							Flags:        netlink.NTF_SELF,
+							IP:           net.ParseIP("10.10.0.5"),
+							HardwareAddr: newMac,
+						}))
+
+						Expect(deletedNeighs).To(ContainElement(netlink.Neigh{
+							LinkIndex:    42,
+							State:        netlink.NUD_PERMANENT,
+							Family:       syscall.AF_BRIDGE,
+							Flags:        netlink.NTF_SELF,
+							IP:           net.ParseIP("10.10.0.5"),
+							HardwareAddr: remoteMac,
+						}))
+						Expect(deletedNeighs).NotTo(ContainElement(HaveField("IP", net.ParseIP("10.255.19.0"))))
+					})
+				})
+
+				Context("when the remote routes have changed", func() {
+					BeforeEach(func() {
+						converger.IsSingleIP = true
+					})
+
+					It("replaces them", func() {
+						err := converger.Converge(leases)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(fakeNetlink.RouteReplaceCallCount()).To(Equal(2))
+						Expect(fakeNetlink.RouteReplaceArgsForCall(0).Flags).To(Equal(unix.RTNH_F_ONLINK))
+						Expect(fakeNetlink.RouteReplaceArgsForCall(1).Flags).To(Equal(unix.RTNH_F_ONLINK))
+						Expect(fakeNetlink.RouteDelCallCount()).To(Equal(1))
+					})
+				})
 			})
 
 			Context("when a single IP remote lease is removed", func() {
@@ -831,6 +980,210 @@ var _ = Describe("Converger", func() {
 					Expect(err).To(MatchError("invalid hardware addr: banana"))
 				})
 			})
+
+			Context("when the local lease has an ipv6 subnet", func() {
+				var (
+					overlayNetworksV6   mcn.MultipleCIDRNetwork
+					localOverlayLeaseV6 *net.IPNet
+				)
+
+				BeforeEach(func() {
+					var err error
+					overlayNetworksV6, err = mcn.NewMultipleCIDRNetwork([]string{"fd00::/48"})
+					Expect(err).NotTo(HaveOccurred())
+
+					_, localOverlayLeaseV6, _ = net.ParseCIDR("fd00:0:0:20::/64")
+					converger.OverlayNetworkV6 = overlayNetworksV6
+					converger.LocalSubnetV6 = localOverlayLeaseV6
+
+					leases[0].OverlaySubnetV6 = "fd00:0:0:20::/64"
+					leases[1].OverlaySubnetV6 = "fd00:0:0:13::/64"
+				})
+
+				It("adds an onlink ipv6 route and an NDP entry for each remote dual-stack lease", func() {
+					err := converger.Converge(leases)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeNetlink.RouteReplaceCallCount()).To(Equal(3))
+					destGW, destNet, _ := net.ParseCIDR("fd00:0:0:13::/64")
+					Expect(fakeNetlink.RouteReplaceArgsForCall(1)).To(Equal(&netlink.Route{
+						LinkIndex: 42,
+						Scope:     netlink.SCOPE_UNIVERSE,
+						Dst:       destNet,
+						Gw:        destGW,
+						Src:       localOverlayLeaseV6.IP,
+						Flags:     unix.RTNH_F_ONLINK,
+					}))
+
+					Expect(fakeNetlink.NeighSetCallCount()).To(Equal(9))
+					Expect(fakeNetlink.NeighSetArgsForCall(2)).To(Equal(&netlink.Neigh{
+						LinkIndex:    42,
+						State:        netlink.NUD_PERMANENT,
+						Type:         syscall.RTN_UNICAST,
+						Family:       syscall.AF_INET6,
+						IP:           destGW,
+						HardwareAddr: remoteMac,
+					}))
+				})
+
+				It("lists the previous ipv6 routes and NDP entries", func() {
+					err := converger.Converge(leases)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeNetlink.RouteListCallCount()).To(Equal(2))
+					_, family := fakeNetlink.RouteListArgsForCall(1)
+					Expect(family).To(Equal(netlink.FAMILY_V6))
+					Expect(fakeNetlink.NDPListCallCount()).To(Equal(1))
+					Expect(fakeNetlink.NDPListArgsForCall(0)).To(Equal(42))
+				})
+
+				Context("when a remote dual-stack lease is removed", func() {
+					BeforeEach(func() {
+						destGW, destNet, _ := net.ParseCIDR("fd00:0:0:99::/64")
+						linkLocal := net.ParseIP("fe80::1")
+						fakeNetlink.RouteListReturnsOnCall(0, nil, nil)
+						fakeNetlink.RouteListReturnsOnCall(1, []netlink.Route{{
+							LinkIndex: 42,
+							Scope:     netlink.SCOPE_UNIVERSE,
+							Dst:       destNet,
+							Gw:        destGW,
+							Src:       localOverlayLeaseV6.IP,
+						}}, nil)
+						fakeNetlink.NDPListReturns([]netlink.Neigh{{
+							LinkIndex:    42,
+							State:        netlink.NUD_PERMANENT,
+							IP:           destGW,
+							HardwareAddr: remoteMac,
+						}, {
+							LinkIndex:    42,
+							State:        netlink.NUD_REACHABLE,
+							IP:           linkLocal,
+							HardwareAddr: remoteMac,
+						}}, nil)
+					})
+
+					It("deletes its ipv6 route and NDP entry, and leaves kernel managed neighbours alone", func() {
+						err := converger.Converge(leases)
+						Expect(err).NotTo(HaveOccurred())
+
+						Expect(fakeNetlink.RouteDelCallCount()).To(Equal(1))
+						Expect(fakeNetlink.RouteDelArgsForCall(0).Dst.String()).To(Equal("fd00:0:0:99::/64"))
+
+						Expect(fakeNetlink.NeighDelCallCount()).To(Equal(1))
+						Expect(fakeNetlink.NeighDelArgsForCall(0).IP.String()).To(Equal("fd00:0:0:99::"))
+					})
+				})
+
+				Context("when previous ndp entries cannot be found", func() {
+					BeforeEach(func() {
+						fakeNetlink.NDPListReturns(nil, errors.New("guava"))
+					})
+
+					It("breaks early and returns a meaningful error", func() {
+						err := converger.Converge(leases)
+						Expect(err).To(MatchError("list ndp: guava"))
+					})
+				})
+
+				Context("when adding the ipv6 route fails", func() {
+					BeforeEach(func() {
+						fakeNetlink.RouteReplaceReturnsOnCall(1, errors.New("lychee"))
+					})
+
+					It("returns a meaningful error", func() {
+						err := converger.Converge(leases)
+						Expect(err).To(MatchError("add ipv6 route: lychee"))
+					})
+				})
+
+				Context("when the remote ipv6 subnet is malformed", func() {
+					BeforeEach(func() {
+						leases[1].OverlaySubnetV6 = "fd00::13::/64"
+					})
+
+					It("returns a meaningful error", func() {
+						err := converger.Converge(leases)
+						Expect(err).To(MatchError(ContainSubstring("parse ipv6 lease")))
+					})
+				})
+			})
+
+			Context("when the encapsulation is geneve", func() {
+				BeforeEach(func() {
+					var err error
+					converger.Encapsulation, err = vtep.NewEncapsulation(vtep.EncapsulationGeneve)
+					Expect(err).NotTo(HaveOccurred())
+				})
+
+				It("adds a route with the tunnel to its cell for every remote lease, including singleIP leases", func() {
+					err := converger.Converge(leases)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeNetlink.RouteReplaceCallCount()).To(Equal(4))
+					destGW, destNet, _ := net.ParseCIDR("10.255.19.0/24")
+					Expect(fakeNetlink.RouteReplaceArgsForCall(0)).To(Equal(&netlink.Route{
+						LinkIndex: 42,
+						Scope:     netlink.SCOPE_UNIVERSE,
+						Dst:       destNet,
+						Gw:        destGW,
+						Src:       localOverlayLease.IP,
+						Encap:     &vtep.TunnelEncap{ID: 99, Dst: net.ParseIP("10.10.0.5")},
+					}))
+
+					destGW, destNet, _ = net.ParseCIDR("10.255.1.11/32")
+					Expect(fakeNetlink.RouteReplaceArgsForCall(2)).To(Equal(&netlink.Route{
+						LinkIndex: 42,
+						Scope:     netlink.SCOPE_UNIVERSE,
+						Dst:       destNet,
+						Gw:        destGW,
+						Src:       localOverlayLease.IP,
+						Flags:     unix.RTNH_F_ONLINK,
+						Encap:     &vtep.TunnelEncap{ID: 99, Dst: net.ParseIP("10.10.0.9")},
+					}))
+				})
+
+				It("only adds ARP rules", func() {
+					err := converger.Converge(leases)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(fakeNetlink.NeighSetCallCount()).To(Equal(4))
+					for i := 0; i < 4; i++ {
+						Expect(fakeNetlink.NeighSetArgsForCall(i).Family).NotTo(Equal(syscall.AF_BRIDGE))
+					}
+				})
+
+				Context("when the routes were programmed before", func() {
+					BeforeEach(func() {
+						Expect(converger.Converge(leases)).To(Succeed())
+
+						// the netlink library does not read back the tunnel of a route
+						var routes []netlink.Route
+						for i := 0; i < fakeNetlink.RouteReplaceCallCount(); i++ {
+							route := *fakeNetlink.RouteReplaceArgsForCall(i)
+							route.Encap = nil
+							routes = append(routes, route)
+						}
+						fakeNetlink.RouteListReturns(routes, nil)
+					})
+
+					It("does not rewrite them", func() {
+						Expect(converger.Converge(leases)).To(Succeed())
+						Expect(fakeNetlink.RouteReplaceCallCount()).To(Equal(4))
+					})
+
+					Context("when a remote lease moved to another cell", func() {
+						BeforeEach(func() {
+							leases[1].UnderlayIP = "10.10.0.99"
+						})
+
+						It("replaces its route with the tunnel to the new cell", func() {
+							Expect(converger.Converge(leases)).To(Succeed())
+							Expect(fakeNetlink.RouteReplaceCallCount()).To(Equal(5))
+							Expect(fakeNetlink.RouteReplaceArgsForCall(4).Encap).To(Equal(&vtep.TunnelEncap{ID: 99, Dst: net.ParseIP("10.10.0.99")}))
+						})
+					})
+				})
+			})
 		})
 	})
 })
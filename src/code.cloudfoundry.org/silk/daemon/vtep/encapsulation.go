@@ -0,0 +1,209 @@
+package vtep
+
+import (
+	"fmt"
+	"net"
+	"syscall"
+
+	"github.com/vishvananda/netlink"
+)
+
+const (
+	EncapsulationVXLANGBP = "vxlan-gbp"
+	EncapsulationGeneve   = "geneve"
+)
+
+// Encapsulation is how the vtep wraps the packets it sends to the vteps of
+// other cells, and how the policy tag of a packet travels with it.
+type Encapsulation interface {
+	Name() string
+
+	// Overhead is the number of bytes the encapsulation adds to a packet
+	// from a container, counting the inner ethernet header.
+	Overhead(underlayIP net.IP) int
+
+	// Link is the vtep device that CreateVTEP adds for cfg.
+	Link(cfg *Config) netlink.Link
+
+	// Drift lists the attributes of an existing vtep that differ from what
+	// Link returns for cfg.
+	Drift(link netlink.Link, cfg *Config) []string
+
+	// TagPrograms are the tc programs that copy the policy tag of a packet
+	// in and out of its encapsulation. They are empty when the device does
+	// that by itself.
+	TagPrograms(vni int, underlayIP net.IP) []TagProgram
+
+	// FDB returns the forwarding entries that send frames for the remote
+	// vtep with the given hardware address to its underlay IP.
+	FDB(vtepIndex int, underlayIP net.IP, hardwareAddr net.HardwareAddr) []netlink.Neigh
+
+	// Tunnel returns the encap that sends the packets of a route to the
+	// given underlay IP, or nil when the FDB entries do that.
+	Tunnel(vni int, underlayIP net.IP) netlink.Encap
+}
+
+func NewEncapsulation(name string) (Encapsulation, error) {
+	switch name {
+	case "", EncapsulationVXLANGBP:
+		return &vxlanGBP{}, nil
+	case EncapsulationGeneve:
+		if !littleEndianHost() {
+			return nil, fmt.Errorf("encapsulation %s is only supported on little-endian hosts", name)
+		}
+		return &geneve{}, nil
+	default:
+		return nil, fmt.Errorf("unknown encapsulation %q, want %s or %s", name, EncapsulationVXLANGBP, EncapsulationGeneve)
+	}
+}
+
+// vxlanGBP is a VXLAN device with the group based policy extension, which
+// carries the policy tag in the packet mark. Remote vteps are found through
+// the FDB of the device.
+type vxlanGBP struct{}
+
+func (*vxlanGBP) Name() string {
+	return EncapsulationVXLANGBP
+}
+
+// Overhead is the size of the outer IP, UDP and VXLAN headers and the
+// inner ethernet header. GBP reuses reserved bits of the VXLAN header, so
+// it adds nothing.
+func (*vxlanGBP) Overhead(underlayIP net.IP) int {
+	if underlayIP.To4() == nil {
+		return 70
+	}
+	return 50
+}
+
+func (*vxlanGBP) Link(cfg *Config) netlink.Link {
+	return &netlink.Vxlan{
+		LinkAttrs:    linkAttrs(cfg),
+		VxlanId:      cfg.VNI,
+		SrcAddr:      cfg.UnderlayIP,
+		Port:         cfg.VTEPPort,
+		VtepDevIndex: cfg.UnderlayInterface.Index,
+		GBP:          true,
+	}
+}
+
+func (*vxlanGBP) Drift(link netlink.Link, cfg *Config) []string {
+	vxlan, ok := link.(*netlink.Vxlan)
+	if !ok {
+		return []string{fmt.Sprintf("type: %s, want vxlan", link.Type())}
+	}
+
+	drift := []string{}
+	if vxlan.VxlanId != cfg.VNI {
+		drift = append(drift, fmt.Sprintf("vni: %d, want %d", vxlan.VxlanId, cfg.VNI))
+	}
+	if vxlan.Port != cfg.VTEPPort {
+		drift = append(drift, fmt.Sprintf("port: %d, want %d", vxlan.Port, cfg.VTEPPort))
+	}
+	if vxlan.VtepDevIndex != cfg.UnderlayInterface.Index {
+		drift = append(drift, fmt.Sprintf("underlay interface index: %d, want %d", vxlan.VtepDevIndex, cfg.UnderlayInterface.Index))
+	}
+	if !vxlan.SrcAddr.Equal(cfg.UnderlayIP) {
+		drift = append(drift, fmt.Sprintf("source address: %s, want %s", vxlan.SrcAddr, cfg.UnderlayIP))
+	}
+	if !vxlan.GBP {
+		drift = append(drift, "gbp: false, want true")
+	}
+	return drift
+}
+
+func (*vxlanGBP) TagPrograms(int, net.IP) []TagProgram {
+	return nil
+}
+
+func (*vxlanGBP) FDB(vtepIndex int, underlayIP net.IP, hardwareAddr net.HardwareAddr) []netlink.Neigh {
+	return []netlink.Neigh{{
+		LinkIndex:    vtepIndex,
+		State:        netlink.NUD_PERMANENT,
+		Family:       syscall.AF_BRIDGE,
+		Flags:        netlink.NTF_SELF,
+		IP:           underlayIP,
+		HardwareAddr: hardwareAddr,
+	}}
+}
+
+func (*vxlanGBP) Tunnel(int, net.IP) netlink.Encap {
+	return nil
+}
+
+// geneve is a Geneve device in external mode, so that it can be offloaded
+// by NICs that do not support VXLAN-GBP. It has no FDB, every route to a
+// remote lease carries the tunnel to its cell instead. The policy tag is
+// carried in a Geneve option by tc programs on the device, which also drop
+// packets for other VNIs.
+type geneve struct{}
+
+func (*geneve) Name() string {
+	return EncapsulationGeneve
+}
+
+// Overhead is the size of the outer IP, UDP and Geneve headers, the policy
+// tag option and the inner ethernet header.
+func (*geneve) Overhead(underlayIP net.IP) int {
+	if underlayIP.To4() == nil {
+		return 78
+	}
+	return 58
+}
+
+func (*geneve) Link(cfg *Config) netlink.Link {
+	return &netlink.Geneve{
+		LinkAttrs: linkAttrs(cfg),
+		Dport:     uint16(cfg.VTEPPort),
+		FlowBased: true,
+	}
+}
+
+func (*geneve) Drift(link netlink.Link, cfg *Config) []string {
+	geneve, ok := link.(*netlink.Geneve)
+	if !ok {
+		return []string{fmt.Sprintf("type: %s, want geneve", link.Type())}
+	}
+
+	drift := []string{}
+	if int(geneve.Dport) != cfg.VTEPPort {
+		drift = append(drift, fmt.Sprintf("port: %d, want %d", geneve.Dport, cfg.VTEPPort))
+	}
+	if !geneve.FlowBased {
+		drift = append(drift, "external: false, want true")
+	}
+	return drift
+}
+
+func (*geneve) TagPrograms(vni int, underlayIP net.IP) []TagProgram {
+	return geneveTagPrograms(vni, underlayIP.To4() == nil)
+}
+
+func (*geneve) FDB(int, net.IP, net.HardwareAddr) []netlink.Neigh {
+	return nil
+}
+
+func (*geneve) Tunnel(vni int, underlayIP net.IP) netlink.Encap {
+	return &TunnelEncap{ID: uint64(vni), Dst: underlayIP}
+}
+
+func linkAttrs(cfg *Config) netlink.LinkAttrs {
+	return netlink.LinkAttrs{
+		Name: cfg.VTEPName,
+		// Starting with Ubuntu 22.04 (jammy), we encountered cases where interfaces were
+		// not actually getting the hardware addr being set when we called LinkSetHardwareAddr below.
+		//
+		// https://wiki.archlinux.org/title/Systemd-networkd#%5BLink%5D  includes the following tip
+		// which is likely what's occurring:
+		//
+		// > Tip: systemd-networkd assigns a MAC address generated based on the interface name and
+		// > the machine ID to the bridge. This may cause connection issues, for example in case of
+		// > routing based on MAC filtering. To circumvent such problems you may assign a MAC address
+		// > to your bridge, probably the same as your physical device, adding the line
+		// > MACAddress=xx:xx:xx:xx:xx:xx in the NetDev section above.
+		//
+		// So, to workaround this, we're now setting HardwareAddr upon link creation
+		HardwareAddr: cfg.OverlayHardwareAddr,
+		MTU:          cfg.MTU,
+	}
+}
@@ -7,6 +7,7 @@ import (
 
 	"code.cloudfoundry.org/lager/v3"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 )
 
 //go:generate counterfeiter -o fakes/netlinkAdapter.go --fake-name NetlinkAdapter . netlinkAdapter
@@ -16,7 +17,9 @@ type netlinkAdapter interface {
 	LinkByName(string) (netlink.Link, error)
 	LinkByIndex(int) (netlink.Link, error)
 	LinkSetHardwareAddr(netlink.Link, net.HardwareAddr) error
+	LinkSetMTU(netlink.Link, int) error
 	AddrAddScopeLink(link netlink.Link, addr *netlink.Addr) error
+	AddrAdd(link netlink.Link, addr *netlink.Addr) error
 	AddrList(link netlink.Link, family int) ([]netlink.Addr, error)
 	RouteAdd(*netlink.Route) error
 	RouteReplace(*netlink.Route) error
@@ -26,48 +29,44 @@ type netlinkAdapter interface {
 	NeighSet(*netlink.Neigh) error
 	ARPList(index int) ([]netlink.Neigh, error)
 	FDBList(index int) ([]netlink.Neigh, error)
+	NDPList(index int) ([]netlink.Neigh, error)
 	NeighDel(*netlink.Neigh) error
+	RouteSubscribe(chan<- netlink.RouteUpdate, <-chan struct{}) error
+	NeighSubscribe(chan<- netlink.NeighUpdate, <-chan struct{}) error
+	LinkSubscribe(chan<- netlink.LinkUpdate, <-chan struct{}) error
+	QdiscAdd(netlink.Qdisc) error
+	FilterAdd(netlink.Filter) error
+	FilterList(netlink.Link, uint32) ([]netlink.Filter, error)
+}
+
+//go:generate counterfeiter -o fakes/bpfAdapter.go --fake-name BPFAdapter . bpfAdapter
+type bpfAdapter interface {
+	LoadSchedCLS(name string, instructions []byte) (int, error)
+	Close(fd int) error
 }
 
 type Factory struct {
 	NetlinkAdapter netlinkAdapter
+	BPFAdapter     bpfAdapter
 	Logger         lager.Logger
 }
 
 func (f *Factory) CreateVTEP(cfg *Config) error {
-	vxlan := &netlink.Vxlan{
-		LinkAttrs: netlink.LinkAttrs{
-			Name: cfg.VTEPName,
-			// Starting with Ubuntu 22.04 (jammy), we encountered cases where interfaces were
-			// not actually getting the hardware addr being set when we called LinkSetHardwareAddr below.
-			//
-			// https://wiki.archlinux.org/title/Systemd-networkd#%5BLink%5D  includes the following tip
-			// which is likely what's occurring:
-			//
-			// > Tip: systemd-networkd assigns a MAC address generated based on the interface name and
-			// > the machine ID to the bridge. This may cause connection issues, for example in case of
-			// > routing based on MAC filtering. To circumvent such problems you may assign a MAC address
-			// > to your bridge, probably the same as your physical device, adding the line
-			// > MACAddress=xx:xx:xx:xx:xx:xx in the NetDev section above.
-			//
-			// So, to workaround this, we're now setting HardwareAddr upon link creation
-			HardwareAddr: cfg.OverlayHardwareAddr,
-		},
-		VxlanId:      cfg.VNI,
-		SrcAddr:      cfg.UnderlayIP,
-		Port:         cfg.VTEPPort,
-		VtepDevIndex: cfg.UnderlayInterface.Index,
-		GBP:          true,
-	}
-	err := f.NetlinkAdapter.LinkAdd(vxlan)
+	vtep := cfg.Encapsulation.Link(cfg)
+	err := f.NetlinkAdapter.LinkAdd(vtep)
 	if err != nil {
 		return fmt.Errorf("create link %s: %s", cfg.VTEPName, err)
 	}
-	err = f.NetlinkAdapter.LinkSetUp(vxlan)
+	err = f.NetlinkAdapter.LinkSetUp(vtep)
 	if err != nil {
 		return fmt.Errorf("up link: %s", err)
 	}
 
+	err = f.attachTagPrograms(vtep, cfg.Encapsulation.TagPrograms(cfg.VNI, cfg.UnderlayIP))
+	if err != nil {
+		return err
+	}
+
 	if !cfg.OverlayNetworks.Contains(cfg.LeaseIP) {
 		return fmt.Errorf("lease IP '%s' is not in any of the overlay networks: %s", cfg.LeaseIP, cfg.OverlayNetworks.Networks)
 	}
@@ -78,7 +77,7 @@ func (f *Factory) CreateVTEP(cfg *Config) error {
 			ip = cfg.LeaseIP
 		}
 
-		err = f.NetlinkAdapter.AddrAddScopeLink(vxlan, &netlink.Addr{
+		err = f.NetlinkAdapter.AddrAddScopeLink(vtep, &netlink.Addr{
 			IPNet: &net.IPNet{
 				IP:   ip,
 				Mask: overlayNet.Mask,
@@ -89,6 +88,137 @@ func (f *Factory) CreateVTEP(cfg *Config) error {
 			return fmt.Errorf("add address: %s", err)
 		}
 	}
+
+	if cfg.LeaseIPv6 != nil {
+		overlayNet := cfg.OverlayNetworksV6.WhichNetworkContains(cfg.LeaseIPv6)
+		if overlayNet == nil {
+			return fmt.Errorf("lease IPv6 '%s' is not in any of the ipv6 overlay networks: %s", cfg.LeaseIPv6, cfg.OverlayNetworksV6.Networks)
+		}
+
+		// Routes to other IPv6 overlay networks are added with the onlink
+		// flag, so unlike IPv4 only the lease address itself is needed.
+		// Duplicate address detection is skipped as the controller
+		// guarantees the address is unique.
+		err = f.NetlinkAdapter.AddrAdd(vtep, &netlink.Addr{
+			IPNet: &net.IPNet{
+				IP:   cfg.LeaseIPv6,
+				Mask: overlayNet.Mask,
+			},
+			Flags: unix.IFA_F_NODAD,
+		})
+		if err != nil {
+			return fmt.Errorf("add ipv6 address: %s", err)
+		}
+	}
+	return nil
+}
+
+// Drift lists the attributes of the existing vtep that differ from what
+// CreateVTEP would create from cfg, e.g. after the vni, vtep_port,
+// vxlan_interface_name or encapsulation changed. The vtep must be recreated
+// to apply them. The MTU is left out, SetMTU changes it in place.
+func (f *Factory) Drift(cfg *Config) ([]string, error) {
+	link, err := f.NetlinkAdapter.LinkByName(cfg.VTEPName)
+	if err != nil {
+		return nil, fmt.Errorf("find link %s: %s", cfg.VTEPName, err)
+	}
+
+	drift := cfg.Encapsulation.Drift(link, cfg)
+	if link.Type() != cfg.Encapsulation.Link(cfg).Type() {
+		return drift, nil
+	}
+
+	for _, program := range cfg.Encapsulation.TagPrograms(cfg.VNI, cfg.UnderlayIP) {
+		filters, err := f.NetlinkAdapter.FilterList(link, tagProgramParent(program))
+		if err != nil {
+			return nil, fmt.Errorf("list filters: %s", err)
+		}
+		if !hasBPFFilter(filters, program.FilterName) {
+			drift = append(drift, fmt.Sprintf("tag program: %s is not attached", program.FilterName))
+		}
+	}
+	return drift, nil
+}
+
+func tagProgramParent(program TagProgram) uint32 {
+	if program.Ingress {
+		return netlink.HANDLE_MIN_INGRESS
+	}
+	return netlink.HANDLE_MIN_EGRESS
+}
+
+func hasBPFFilter(filters []netlink.Filter, name string) bool {
+	for _, filter := range filters {
+		if bpf, ok := filter.(*netlink.BpfFilter); ok && bpf.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// SetMTU sets the MTU of the existing vtep to the configured one and returns
+// the MTU it had before. Unless lower is set a configured MTU that is lower
+// than the current one is left unapplied, since running containers may send
+// packets that no longer fit.
+func (f *Factory) SetMTU(cfg *Config, lower bool) (int, error) {
+	link, err := f.NetlinkAdapter.LinkByName(cfg.VTEPName)
+	if err != nil {
+		return 0, fmt.Errorf("find link %s: %s", cfg.VTEPName, err)
+	}
+
+	previous := link.Attrs().MTU
+	if previous == cfg.MTU || (previous > cfg.MTU && !lower) {
+		return previous, nil
+	}
+	err = f.NetlinkAdapter.LinkSetMTU(link, cfg.MTU)
+	if err != nil {
+		return 0, fmt.Errorf("set mtu of %s: %s", cfg.VTEPName, err)
+	}
+	return previous, nil
+}
+
+// attachTagPrograms loads the programs and attaches them to the clsact
+// qdisc of the vtep. They are removed with the vtep.
+func (f *Factory) attachTagPrograms(link netlink.Link, programs []TagProgram) error {
+	if len(programs) == 0 {
+		return nil
+	}
+
+	err := f.NetlinkAdapter.QdiscAdd(&netlink.GenericQdisc{
+		QdiscAttrs: netlink.QdiscAttrs{
+			LinkIndex: link.Attrs().Index,
+			Handle:    netlink.MakeHandle(0xffff, 0),
+			Parent:    netlink.HANDLE_CLSACT,
+		},
+		QdiscType: "clsact",
+	})
+	if err != nil {
+		return fmt.Errorf("add clsact qdisc: %s", err)
+	}
+
+	for _, program := range programs {
+		fd, err := f.BPFAdapter.LoadSchedCLS(program.Name, program.Instructions)
+		if err != nil {
+			return fmt.Errorf("load tag program: %s", err)
+		}
+
+		err = f.NetlinkAdapter.FilterAdd(&netlink.BpfFilter{
+			FilterAttrs: netlink.FilterAttrs{
+				LinkIndex: link.Attrs().Index,
+				Parent:    tagProgramParent(program),
+				Handle:    1,
+				Protocol:  unix.ETH_P_ALL,
+				Priority:  1,
+			},
+			Fd:           fd,
+			Name:         program.FilterName,
+			DirectAction: true,
+		})
+		f.BPFAdapter.Close(fd)
+		if err != nil {
+			return fmt.Errorf("attach tag program %s: %s", program.Name, err)
+		}
+	}
 	return nil
 }
 
@@ -118,3 +248,22 @@ func (f *Factory) GetVTEPState(vtepName string) (net.HardwareAddr, net.IP, int,
 	}
 	return link.Attrs().HardwareAddr, addresses[0].IP, link.Attrs().MTU, nil
 }
+
+// GetVTEPIPv6 returns the IPv6 overlay address of the VTEP, or nil when the
+// VTEP was created from a lease without an IPv6 subnet.
+func (f *Factory) GetVTEPIPv6(vtepName string) (net.IP, error) {
+	link, err := f.NetlinkAdapter.LinkByName(vtepName)
+	if err != nil {
+		return nil, fmt.Errorf("find link: %s", err)
+	}
+	addresses, err := f.NetlinkAdapter.AddrList(link, netlink.FAMILY_V6)
+	if err != nil {
+		return nil, fmt.Errorf("list addresses: %s", err)
+	}
+	for _, address := range addresses {
+		if address.IP.IsGlobalUnicast() {
+			return address.IP, nil
+		}
+	}
+	return nil, nil
+}
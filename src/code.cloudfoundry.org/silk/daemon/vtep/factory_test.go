@@ -12,11 +12,13 @@ import (
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
 )
 
 var _ = Describe("Factory", func() {
 	var (
 		fakeNetlinkAdapter *fakes.NetlinkAdapter
+		fakeBPFAdapter     *fakes.BPFAdapter
 		factory            *vtep.Factory
 		vtepConfig         *vtep.Config
 		fakeLogger         *lagertest.TestLogger
@@ -25,9 +27,11 @@ var _ = Describe("Factory", func() {
 
 	BeforeEach(func() {
 		fakeNetlinkAdapter = &fakes.NetlinkAdapter{}
+		fakeBPFAdapter = &fakes.BPFAdapter{}
 		fakeLogger = lagertest.NewTestLogger("test")
 		factory = &vtep.Factory{
 			NetlinkAdapter: fakeNetlinkAdapter,
+			BPFAdapter:     fakeBPFAdapter,
 			Logger:         fakeLogger,
 		}
 
@@ -44,6 +48,9 @@ var _ = Describe("Factory", func() {
 		overlayNetworks, err := mcn.NewMultipleCIDRNetwork([]string{"10.2.0.0/24", "10.240.0.0/16", "10.255.0.0/16"})
 		Expect(err).NotTo(HaveOccurred())
 
+		encapsulation, err := vtep.NewEncapsulation(vtep.EncapsulationVXLANGBP)
+		Expect(err).NotTo(HaveOccurred())
+
 		vtepConfig = &vtep.Config{
 			VTEPName:            "some-device",
 			UnderlayInterface:   underlayInterface,
@@ -53,6 +60,8 @@ var _ = Describe("Factory", func() {
 			OverlayHardwareAddr: overlayMAC,
 			VNI:                 99,
 			VTEPPort:            4913,
+			MTU:                 1400,
+			Encapsulation:       encapsulation,
 		}
 	})
 
@@ -62,7 +71,7 @@ var _ = Describe("Factory", func() {
 			vtepLinkIndex = 2
 			fakeNetlinkAdapter.LinkByNameReturns(&netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Index: vtepLinkIndex}}, nil)
 		})
-		It("creates the link, with the HW address", func() {
+		It("creates the link, with the HW address and MTU", func() {
 			err := factory.CreateVTEP(vtepConfig)
 			Expect(err).NotTo(HaveOccurred())
 
@@ -70,6 +79,7 @@ var _ = Describe("Factory", func() {
 				LinkAttrs: netlink.LinkAttrs{
 					Name:         "some-device",
 					HardwareAddr: overlayMAC,
+					MTU:          1400,
 				},
 				VxlanId:      99,
 				SrcAddr:      net.IP{172, 255, 0, 0},
@@ -156,6 +166,154 @@ var _ = Describe("Factory", func() {
 				Expect(err).To(MatchError(ContainSubstring("lease IP '10.30.0.0' is not in any of the overlay networks")))
 			})
 		})
+
+		It("does not add an ipv6 address", func() {
+			err := factory.CreateVTEP(vtepConfig)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeNetlinkAdapter.AddrAddCallCount()).To(Equal(0))
+		})
+
+		It("does not attach tag programs, as gbp carries the policy tag", func() {
+			err := factory.CreateVTEP(vtepConfig)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(fakeNetlinkAdapter.QdiscAddCallCount()).To(Equal(0))
+			Expect(fakeBPFAdapter.LoadSchedCLSCallCount()).To(Equal(0))
+		})
+
+		Context("when the encapsulation is geneve", func() {
+			BeforeEach(func() {
+				var err error
+				vtepConfig.Encapsulation, err = vtep.NewEncapsulation(vtep.EncapsulationGeneve)
+				Expect(err).NotTo(HaveOccurred())
+
+				fakeNetlinkAdapter.LinkAddStub = func(link netlink.Link) error {
+					link.Attrs().Index = 7
+					return nil
+				}
+				fakeBPFAdapter.LoadSchedCLSReturnsOnCall(0, 11, nil)
+				fakeBPFAdapter.LoadSchedCLSReturnsOnCall(1, 12, nil)
+			})
+
+			It("creates an external geneve link", func() {
+				err := factory.CreateVTEP(vtepConfig)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeNetlinkAdapter.LinkAddArgsForCall(0)).To(Equal(&netlink.Geneve{
+					LinkAttrs: netlink.LinkAttrs{
+						Name:         "some-device",
+						HardwareAddr: overlayMAC,
+						MTU:          1400,
+						Index:        7,
+					},
+					Dport:     4913,
+					FlowBased: true,
+				}))
+			})
+
+			It("attaches the tag programs to the egress and ingress of the link", func() {
+				err := factory.CreateVTEP(vtepConfig)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeNetlinkAdapter.QdiscAddCallCount()).To(Equal(1))
+				qdisc := fakeNetlinkAdapter.QdiscAddArgsForCall(0)
+				Expect(qdisc.Type()).To(Equal("clsact"))
+				Expect(qdisc.Attrs().LinkIndex).To(Equal(7))
+				Expect(qdisc.Attrs().Parent).To(Equal(uint32(netlink.HANDLE_CLSACT)))
+
+				Expect(fakeBPFAdapter.LoadSchedCLSCallCount()).To(Equal(2))
+				name, instructions := fakeBPFAdapter.LoadSchedCLSArgsForCall(0)
+				Expect(name).To(Equal("silk_tag_egress"))
+				Expect(instructions).NotTo(BeEmpty())
+				name, _ = fakeBPFAdapter.LoadSchedCLSArgsForCall(1)
+				Expect(name).To(Equal("silk_tag_ingress"))
+
+				Expect(fakeNetlinkAdapter.FilterAddCallCount()).To(Equal(2))
+				egress := fakeNetlinkAdapter.FilterAddArgsForCall(0).(*netlink.BpfFilter)
+				Expect(egress.LinkIndex).To(Equal(7))
+				Expect(egress.Parent).To(Equal(uint32(netlink.HANDLE_MIN_EGRESS)))
+				Expect(egress.Fd).To(Equal(11))
+				Expect(egress.Name).To(Equal("silk_tag_egress"))
+				Expect(egress.DirectAction).To(BeTrue())
+				ingress := fakeNetlinkAdapter.FilterAddArgsForCall(1).(*netlink.BpfFilter)
+				Expect(ingress.Parent).To(Equal(uint32(netlink.HANDLE_MIN_INGRESS)))
+				Expect(ingress.Fd).To(Equal(12))
+				Expect(ingress.Name).To(Equal("silk_tag_ingress_vni_99"))
+
+				Expect(fakeBPFAdapter.CloseCallCount()).To(Equal(2))
+				Expect(fakeBPFAdapter.CloseArgsForCall(0)).To(Equal(11))
+				Expect(fakeBPFAdapter.CloseArgsForCall(1)).To(Equal(12))
+			})
+
+			Context("when adding the qdisc fails", func() {
+				BeforeEach(func() {
+					fakeNetlinkAdapter.QdiscAddReturns(errors.New("potato"))
+				})
+				It("wraps and returns the error", func() {
+					err := factory.CreateVTEP(vtepConfig)
+					Expect(err).To(MatchError("add clsact qdisc: potato"))
+				})
+			})
+
+			Context("when loading a program fails", func() {
+				BeforeEach(func() {
+					fakeBPFAdapter.LoadSchedCLSReturnsOnCall(0, -1, errors.New("potato"))
+				})
+				It("wraps and returns the error", func() {
+					err := factory.CreateVTEP(vtepConfig)
+					Expect(err).To(MatchError("load tag program: potato"))
+				})
+			})
+
+			Context("when attaching a program fails", func() {
+				BeforeEach(func() {
+					fakeNetlinkAdapter.FilterAddReturns(errors.New("potato"))
+				})
+				It("closes the program and returns the error", func() {
+					err := factory.CreateVTEP(vtepConfig)
+					Expect(err).To(MatchError("attach tag program silk_tag_egress: potato"))
+					Expect(fakeBPFAdapter.CloseCallCount()).To(Equal(1))
+				})
+			})
+		})
+
+		Context("when the config has an ipv6 lease", func() {
+			BeforeEach(func() {
+				overlayNetworksV6, err := mcn.NewMultipleCIDRNetwork([]string{"fd00::/48"})
+				Expect(err).NotTo(HaveOccurred())
+				vtepConfig.OverlayNetworksV6 = overlayNetworksV6
+				vtepConfig.LeaseIPv6 = net.ParseIP("fd00:0:0:2a::")
+			})
+
+			It("adds the ipv6 lease address with the overlay network mask", func() {
+				err := factory.CreateVTEP(vtepConfig)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(fakeNetlinkAdapter.AddrAddScopeLinkCallCount()).To(Equal(3))
+				Expect(fakeNetlinkAdapter.AddrAddCallCount()).To(Equal(1))
+				link, addr := fakeNetlinkAdapter.AddrAddArgsForCall(0)
+				Expect(link.Attrs().Name).To(Equal("some-device"))
+				Expect(addr.IPNet.String()).To(Equal("fd00:0:0:2a::/48"))
+				Expect(addr.Flags).To(Equal(unix.IFA_F_NODAD))
+			})
+
+			Context("when adding the ipv6 address fails", func() {
+				BeforeEach(func() {
+					fakeNetlinkAdapter.AddrAddReturns(errors.New("potato"))
+				})
+				It("wraps and returns the error", func() {
+					err := factory.CreateVTEP(vtepConfig)
+					Expect(err).To(MatchError("add ipv6 address: potato"))
+				})
+			})
+
+			Context("when the ipv6 lease is not in any of the ipv6 overlay networks", func() {
+				It("returns an error", func() {
+					vtepConfig.LeaseIPv6 = net.ParseIP("fd01::")
+					err := factory.CreateVTEP(vtepConfig)
+					Expect(err).To(MatchError(ContainSubstring("lease IPv6 'fd01::' is not in any of the ipv6 overlay networks")))
+				})
+			})
+		})
 	})
 
 	Describe("GetVTEPState", func() {
@@ -228,6 +386,303 @@ var _ = Describe("Factory", func() {
 		})
 	})
 
+	Describe("GetVTEPIPv6", func() {
+		BeforeEach(func() {
+			fakeNetlinkAdapter.LinkByNameReturns(&netlink.Vxlan{
+				LinkAttrs: netlink.LinkAttrs{Name: "some-device"},
+			}, nil)
+			fakeNetlinkAdapter.AddrListReturns([]netlink.Addr{{
+				IPNet: &net.IPNet{
+					IP:   net.ParseIP("fe80::ecee:aff:feff:4200"),
+					Mask: net.CIDRMask(64, 128),
+				},
+			}, {
+				IPNet: &net.IPNet{
+					IP:   net.ParseIP("fd00:0:0:2a::"),
+					Mask: net.CIDRMask(48, 128),
+				},
+			}}, nil)
+		})
+
+		It("returns the global ipv6 overlay address", func() {
+			ip, err := factory.GetVTEPIPv6(vtepConfig.VTEPName)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ip.String()).To(Equal("fd00:0:0:2a::"))
+
+			Expect(fakeNetlinkAdapter.AddrListCallCount()).To(Equal(1))
+			_, family := fakeNetlinkAdapter.AddrListArgsForCall(0)
+			Expect(family).To(Equal(netlink.FAMILY_V6))
+		})
+
+		Context("when there is only a link-local address", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.AddrListReturns([]netlink.Addr{{
+					IPNet: &net.IPNet{
+						IP:   net.ParseIP("fe80::ecee:aff:feff:4200"),
+						Mask: net.CIDRMask(64, 128),
+					},
+				}}, nil)
+			})
+			It("returns nil", func() {
+				ip, err := factory.GetVTEPIPv6(vtepConfig.VTEPName)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ip).To(BeNil())
+			})
+		})
+
+		Context("when finding the link errors", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.LinkByNameReturns(nil, errors.New("potato"))
+			})
+			It("returns an error", func() {
+				_, err := factory.GetVTEPIPv6(vtepConfig.VTEPName)
+				Expect(err).To(MatchError("find link: potato"))
+			})
+		})
+
+		Context("when listing the addresses fails", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.AddrListReturns(nil, errors.New("potato"))
+			})
+			It("returns an error", func() {
+				_, err := factory.GetVTEPIPv6(vtepConfig.VTEPName)
+				Expect(err).To(MatchError("list addresses: potato"))
+			})
+		})
+	})
+
+	Describe("Drift", func() {
+		var existing *netlink.Vxlan
+
+		BeforeEach(func() {
+			existing = &netlink.Vxlan{
+				LinkAttrs: netlink.LinkAttrs{
+					Name: "some-device",
+					MTU:  1400,
+				},
+				VxlanId:      99,
+				SrcAddr:      net.IP{172, 255, 0, 0},
+				Port:         4913,
+				VtepDevIndex: 4,
+				GBP:          true,
+			}
+			fakeNetlinkAdapter.LinkByNameReturns(existing, nil)
+		})
+
+		It("returns nothing when the vtep matches the config", func() {
+			drift, err := factory.Drift(vtepConfig)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drift).To(BeEmpty())
+			Expect(fakeNetlinkAdapter.LinkByNameArgsForCall(0)).To(Equal("some-device"))
+		})
+
+		It("lists every attribute that differs", func() {
+			existing.VxlanId = 1
+			existing.Port = 4789
+			existing.VtepDevIndex = 2
+			existing.SrcAddr = net.IP{172, 255, 0, 1}
+			existing.GBP = false
+
+			drift, err := factory.Drift(vtepConfig)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drift).To(Equal([]string{
+				"vni: 1, want 99",
+				"port: 4789, want 4913",
+				"underlay interface index: 2, want 4",
+				"source address: 172.255.0.1, want 172.255.0.0",
+				"gbp: false, want true",
+			}))
+		})
+
+		It("leaves out the mtu", func() {
+			existing.MTU = 8950
+
+			drift, err := factory.Drift(vtepConfig)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(drift).To(BeEmpty())
+		})
+
+		Context("when the link is not a vxlan device", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.LinkByNameReturns(&netlink.Dummy{LinkAttrs: netlink.LinkAttrs{Name: "some-device", MTU: 1400}}, nil)
+			})
+
+			It("reports the type", func() {
+				drift, err := factory.Drift(vtepConfig)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(drift).To(Equal([]string{"type: dummy, want vxlan"}))
+			})
+		})
+
+		Context("when the encapsulation is geneve", func() {
+			var (
+				existing *netlink.Geneve
+				filters  map[uint32][]netlink.Filter
+			)
+
+			BeforeEach(func() {
+				var err error
+				vtepConfig.Encapsulation, err = vtep.NewEncapsulation(vtep.EncapsulationGeneve)
+				Expect(err).NotTo(HaveOccurred())
+
+				existing = &netlink.Geneve{
+					LinkAttrs: netlink.LinkAttrs{Name: "some-device", MTU: 1400},
+					Dport:     4913,
+					FlowBased: true,
+				}
+				fakeNetlinkAdapter.LinkByNameReturns(existing, nil)
+
+				filters = map[uint32][]netlink.Filter{
+					netlink.HANDLE_MIN_EGRESS:  {&netlink.BpfFilter{Name: "silk_tag_egress"}},
+					netlink.HANDLE_MIN_INGRESS: {&netlink.BpfFilter{Name: "silk_tag_ingress_vni_99"}},
+				}
+				fakeNetlinkAdapter.FilterListStub = func(link netlink.Link, parent uint32) ([]netlink.Filter, error) {
+					return filters[parent], nil
+				}
+			})
+
+			It("returns nothing when the vtep matches the config", func() {
+				drift, err := factory.Drift(vtepConfig)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(drift).To(BeEmpty())
+
+				Expect(fakeNetlinkAdapter.FilterListCallCount()).To(Equal(2))
+				link, _ := fakeNetlinkAdapter.FilterListArgsForCall(0)
+				Expect(link).To(Equal(existing))
+			})
+
+			Context("when the tag programs were attached for another vni", func() {
+				BeforeEach(func() {
+					filters[netlink.HANDLE_MIN_INGRESS] = []netlink.Filter{&netlink.BpfFilter{Name: "silk_tag_ingress_vni_1"}}
+				})
+
+				It("reports the ingress program", func() {
+					drift, err := factory.Drift(vtepConfig)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(drift).To(Equal([]string{"tag program: silk_tag_ingress_vni_99 is not attached"}))
+				})
+			})
+
+			Context("when listing the filters fails", func() {
+				BeforeEach(func() {
+					fakeNetlinkAdapter.FilterListStub = nil
+					fakeNetlinkAdapter.FilterListReturns(nil, errors.New("banana"))
+				})
+
+				It("returns an error", func() {
+					_, err := factory.Drift(vtepConfig)
+					Expect(err).To(MatchError("list filters: banana"))
+				})
+			})
+
+			It("lists every attribute that differs", func() {
+				existing.Dport = 6081
+				existing.FlowBased = false
+
+				drift, err := factory.Drift(vtepConfig)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(drift).To(Equal([]string{
+					"port: 6081, want 4913",
+					"external: false, want true",
+				}))
+			})
+
+			Context("when the existing vtep is a vxlan device", func() {
+				BeforeEach(func() {
+					fakeNetlinkAdapter.LinkByNameReturns(&netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: "some-device", MTU: 1400}}, nil)
+				})
+
+				It("reports the type", func() {
+					drift, err := factory.Drift(vtepConfig)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(drift).To(Equal([]string{"type: vxlan, want geneve"}))
+					Expect(fakeNetlinkAdapter.FilterListCallCount()).To(Equal(0))
+				})
+			})
+		})
+
+		Context("when the link cannot be found", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.LinkByNameReturns(nil, errors.New("banana"))
+			})
+
+			It("returns an error", func() {
+				_, err := factory.Drift(vtepConfig)
+				Expect(err).To(MatchError("find link some-device: banana"))
+			})
+		})
+	})
+
+	Describe("SetMTU", func() {
+		var existing *netlink.Vxlan
+
+		BeforeEach(func() {
+			existing = &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: "some-device", MTU: 8950}}
+			fakeNetlinkAdapter.LinkByNameReturns(existing, nil)
+		})
+
+		It("sets the configured mtu on the existing vtep", func() {
+			previous, err := factory.SetMTU(vtepConfig, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(previous).To(Equal(8950))
+
+			Expect(fakeNetlinkAdapter.LinkByNameArgsForCall(0)).To(Equal("some-device"))
+			Expect(fakeNetlinkAdapter.LinkSetMTUCallCount()).To(Equal(1))
+			link, mtu := fakeNetlinkAdapter.LinkSetMTUArgsForCall(0)
+			Expect(link).To(Equal(existing))
+			Expect(mtu).To(Equal(1400))
+		})
+
+		It("does not lower the mtu unless asked to", func() {
+			previous, err := factory.SetMTU(vtepConfig, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(previous).To(Equal(8950))
+			Expect(fakeNetlinkAdapter.LinkSetMTUCallCount()).To(Equal(0))
+		})
+
+		It("raises the mtu without being asked to lower it", func() {
+			existing.MTU = 1300
+
+			previous, err := factory.SetMTU(vtepConfig, false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(previous).To(Equal(1300))
+			Expect(fakeNetlinkAdapter.LinkSetMTUCallCount()).To(Equal(1))
+			_, mtu := fakeNetlinkAdapter.LinkSetMTUArgsForCall(0)
+			Expect(mtu).To(Equal(1400))
+		})
+
+		It("does nothing when the mtu matches the config", func() {
+			existing.MTU = 1400
+
+			previous, err := factory.SetMTU(vtepConfig, true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(previous).To(Equal(1400))
+			Expect(fakeNetlinkAdapter.LinkSetMTUCallCount()).To(Equal(0))
+		})
+
+		Context("when the link cannot be found", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.LinkByNameReturns(nil, errors.New("banana"))
+			})
+
+			It("returns an error", func() {
+				_, err := factory.SetMTU(vtepConfig, true)
+				Expect(err).To(MatchError("find link some-device: banana"))
+			})
+		})
+
+		Context("when setting the mtu fails", func() {
+			BeforeEach(func() {
+				fakeNetlinkAdapter.LinkSetMTUReturns(errors.New("banana"))
+			})
+
+			It("returns an error", func() {
+				_, err := factory.SetMTU(vtepConfig, true)
+				Expect(err).To(MatchError("set mtu of some-device: banana"))
+			})
+		})
+	})
+
 	Describe("DeleteVTEP", func() {
 		BeforeEach(func() {
 			fakeNetlinkAdapter.LinkByNameReturns(&netlink.Vxlan{
@@ -0,0 +1,193 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+)
+
+type BPFAdapter struct {
+	CloseStub        func(int) error
+	closeMutex       sync.RWMutex
+	closeArgsForCall []struct {
+		arg1 int
+	}
+	closeReturns struct {
+		result1 error
+	}
+	closeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	LoadSchedCLSStub        func(string, []byte) (int, error)
+	loadSchedCLSMutex       sync.RWMutex
+	loadSchedCLSArgsForCall []struct {
+		arg1 string
+		arg2 []byte
+	}
+	loadSchedCLSReturns struct {
+		result1 int
+		result2 error
+	}
+	loadSchedCLSReturnsOnCall map[int]struct {
+		result1 int
+		result2 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *BPFAdapter) Close(arg1 int) error {
+	fake.closeMutex.Lock()
+	ret, specificReturn := fake.closeReturnsOnCall[len(fake.closeArgsForCall)]
+	fake.closeArgsForCall = append(fake.closeArgsForCall, struct {
+		arg1 int
+	}{arg1})
+	stub := fake.CloseStub
+	fakeReturns := fake.closeReturns
+	fake.recordInvocation("Close", []interface{}{arg1})
+	fake.closeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *BPFAdapter) CloseCallCount() int {
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	return len(fake.closeArgsForCall)
+}
+
+func (fake *BPFAdapter) CloseCalls(stub func(int) error) {
+	fake.closeMutex.Lock()
+	defer fake.closeMutex.Unlock()
+	fake.CloseStub = stub
+}
+
+func (fake *BPFAdapter) CloseArgsForCall(i int) int {
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	argsForCall := fake.closeArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *BPFAdapter) CloseReturns(result1 error) {
+	fake.closeMutex.Lock()
+	defer fake.closeMutex.Unlock()
+	fake.CloseStub = nil
+	fake.closeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *BPFAdapter) CloseReturnsOnCall(i int, result1 error) {
+	fake.closeMutex.Lock()
+	defer fake.closeMutex.Unlock()
+	fake.CloseStub = nil
+	if fake.closeReturnsOnCall == nil {
+		fake.closeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.closeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *BPFAdapter) LoadSchedCLS(arg1 string, arg2 []byte) (int, error) {
+	var arg2Copy []byte
+	if arg2 != nil {
+		arg2Copy = make([]byte, len(arg2))
+		copy(arg2Copy, arg2)
+	}
+	fake.loadSchedCLSMutex.Lock()
+	ret, specificReturn := fake.loadSchedCLSReturnsOnCall[len(fake.loadSchedCLSArgsForCall)]
+	fake.loadSchedCLSArgsForCall = append(fake.loadSchedCLSArgsForCall, struct {
+		arg1 string
+		arg2 []byte
+	}{arg1, arg2Copy})
+	stub := fake.LoadSchedCLSStub
+	fakeReturns := fake.loadSchedCLSReturns
+	fake.recordInvocation("LoadSchedCLS", []interface{}{arg1, arg2Copy})
+	fake.loadSchedCLSMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *BPFAdapter) LoadSchedCLSCallCount() int {
+	fake.loadSchedCLSMutex.RLock()
+	defer fake.loadSchedCLSMutex.RUnlock()
+	return len(fake.loadSchedCLSArgsForCall)
+}
+
+func (fake *BPFAdapter) LoadSchedCLSCalls(stub func(string, []byte) (int, error)) {
+	fake.loadSchedCLSMutex.Lock()
+	defer fake.loadSchedCLSMutex.Unlock()
+	fake.LoadSchedCLSStub = stub
+}
+
+func (fake *BPFAdapter) LoadSchedCLSArgsForCall(i int) (string, []byte) {
+	fake.loadSchedCLSMutex.RLock()
+	defer fake.loadSchedCLSMutex.RUnlock()
+	argsForCall := fake.loadSchedCLSArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *BPFAdapter) LoadSchedCLSReturns(result1 int, result2 error) {
+	fake.loadSchedCLSMutex.Lock()
+	defer fake.loadSchedCLSMutex.Unlock()
+	fake.LoadSchedCLSStub = nil
+	fake.loadSchedCLSReturns = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *BPFAdapter) LoadSchedCLSReturnsOnCall(i int, result1 int, result2 error) {
+	fake.loadSchedCLSMutex.Lock()
+	defer fake.loadSchedCLSMutex.Unlock()
+	fake.LoadSchedCLSStub = nil
+	if fake.loadSchedCLSReturnsOnCall == nil {
+		fake.loadSchedCLSReturnsOnCall = make(map[int]struct {
+			result1 int
+			result2 error
+		})
+	}
+	fake.loadSchedCLSReturnsOnCall[i] = struct {
+		result1 int
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *BPFAdapter) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.closeMutex.RLock()
+	defer fake.closeMutex.RUnlock()
+	fake.loadSchedCLSMutex.RLock()
+	defer fake.loadSchedCLSMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *BPFAdapter) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
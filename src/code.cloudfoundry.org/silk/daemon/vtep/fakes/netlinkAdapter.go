@@ -22,6 +22,18 @@ type NetlinkAdapter struct {
 		result1 []netlink.Neigh
 		result2 error
 	}
+	AddrAddStub        func(netlink.Link, *netlink.Addr) error
+	addrAddMutex       sync.RWMutex
+	addrAddArgsForCall []struct {
+		arg1 netlink.Link
+		arg2 *netlink.Addr
+	}
+	addrAddReturns struct {
+		result1 error
+	}
+	addrAddReturnsOnCall map[int]struct {
+		result1 error
+	}
 	AddrAddScopeLinkStub        func(netlink.Link, *netlink.Addr) error
 	addrAddScopeLinkMutex       sync.RWMutex
 	addrAddScopeLinkArgsForCall []struct {
@@ -61,6 +73,31 @@ type NetlinkAdapter struct {
 		result1 []netlink.Neigh
 		result2 error
 	}
+	FilterAddStub        func(netlink.Filter) error
+	filterAddMutex       sync.RWMutex
+	filterAddArgsForCall []struct {
+		arg1 netlink.Filter
+	}
+	filterAddReturns struct {
+		result1 error
+	}
+	filterAddReturnsOnCall map[int]struct {
+		result1 error
+	}
+	FilterListStub        func(netlink.Link, uint32) ([]netlink.Filter, error)
+	filterListMutex       sync.RWMutex
+	filterListArgsForCall []struct {
+		arg1 netlink.Link
+		arg2 uint32
+	}
+	filterListReturns struct {
+		result1 []netlink.Filter
+		result2 error
+	}
+	filterListReturnsOnCall map[int]struct {
+		result1 []netlink.Filter
+		result2 error
+	}
 	LinkAddStub        func(netlink.Link) error
 	linkAddMutex       sync.RWMutex
 	linkAddArgsForCall []struct {
@@ -121,6 +158,18 @@ type NetlinkAdapter struct {
 	linkSetHardwareAddrReturnsOnCall map[int]struct {
 		result1 error
 	}
+	LinkSetMTUStub        func(netlink.Link, int) error
+	linkSetMTUMutex       sync.RWMutex
+	linkSetMTUArgsForCall []struct {
+		arg1 netlink.Link
+		arg2 int
+	}
+	linkSetMTUReturns struct {
+		result1 error
+	}
+	linkSetMTUReturnsOnCall map[int]struct {
+		result1 error
+	}
 	LinkSetUpStub        func(netlink.Link) error
 	linkSetUpMutex       sync.RWMutex
 	linkSetUpArgsForCall []struct {
@@ -132,6 +181,31 @@ type NetlinkAdapter struct {
 	linkSetUpReturnsOnCall map[int]struct {
 		result1 error
 	}
+	LinkSubscribeStub        func(chan<- netlink.LinkUpdate, <-chan struct{}) error
+	linkSubscribeMutex       sync.RWMutex
+	linkSubscribeArgsForCall []struct {
+		arg1 chan<- netlink.LinkUpdate
+		arg2 <-chan struct{}
+	}
+	linkSubscribeReturns struct {
+		result1 error
+	}
+	linkSubscribeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	NDPListStub        func(int) ([]netlink.Neigh, error)
+	nDPListMutex       sync.RWMutex
+	nDPListArgsForCall []struct {
+		arg1 int
+	}
+	nDPListReturns struct {
+		result1 []netlink.Neigh
+		result2 error
+	}
+	nDPListReturnsOnCall map[int]struct {
+		result1 []netlink.Neigh
+		result2 error
+	}
 	NeighDelStub        func(*netlink.Neigh) error
 	neighDelMutex       sync.RWMutex
 	neighDelArgsForCall []struct {
@@ -154,6 +228,29 @@ type NetlinkAdapter struct {
 	neighSetReturnsOnCall map[int]struct {
 		result1 error
 	}
+	NeighSubscribeStub        func(chan<- netlink.NeighUpdate, <-chan struct{}) error
+	neighSubscribeMutex       sync.RWMutex
+	neighSubscribeArgsForCall []struct {
+		arg1 chan<- netlink.NeighUpdate
+		arg2 <-chan struct{}
+	}
+	neighSubscribeReturns struct {
+		result1 error
+	}
+	neighSubscribeReturnsOnCall map[int]struct {
+		result1 error
+	}
+	QdiscAddStub        func(netlink.Qdisc) error
+	qdiscAddMutex       sync.RWMutex
+	qdiscAddArgsForCall []struct {
+		arg1 netlink.Qdisc
+	}
+	qdiscAddReturns struct {
+		result1 error
+	}
+	qdiscAddReturnsOnCall map[int]struct {
+		result1 error
+	}
 	RouteAddStub        func(*netlink.Route) error
 	routeAddMutex       sync.RWMutex
 	routeAddArgsForCall []struct {
@@ -201,6 +298,18 @@ type NetlinkAdapter struct {
 	routeReplaceReturnsOnCall map[int]struct {
 		result1 error
 	}
+	RouteSubscribeStub        func(chan<- netlink.RouteUpdate, <-chan struct{}) error
+	routeSubscribeMutex       sync.RWMutex
+	routeSubscribeArgsForCall []struct {
+		arg1 chan<- netlink.RouteUpdate
+		arg2 <-chan struct{}
+	}
+	routeSubscribeReturns struct {
+		result1 error
+	}
+	routeSubscribeReturnsOnCall map[int]struct {
+		result1 error
+	}
 	invocations      map[string][][]interface{}
 	invocationsMutex sync.RWMutex
 }
@@ -269,6 +378,68 @@ func (fake *NetlinkAdapter) ARPListReturnsOnCall(i int, result1 []netlink.Neigh,
 	}{result1, result2}
 }
 
+func (fake *NetlinkAdapter) AddrAdd(arg1 netlink.Link, arg2 *netlink.Addr) error {
+	fake.addrAddMutex.Lock()
+	ret, specificReturn := fake.addrAddReturnsOnCall[len(fake.addrAddArgsForCall)]
+	fake.addrAddArgsForCall = append(fake.addrAddArgsForCall, struct {
+		arg1 netlink.Link
+		arg2 *netlink.Addr
+	}{arg1, arg2})
+	stub := fake.AddrAddStub
+	fakeReturns := fake.addrAddReturns
+	fake.recordInvocation("AddrAdd", []interface{}{arg1, arg2})
+	fake.addrAddMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *NetlinkAdapter) AddrAddCallCount() int {
+	fake.addrAddMutex.RLock()
+	defer fake.addrAddMutex.RUnlock()
+	return len(fake.addrAddArgsForCall)
+}
+
+func (fake *NetlinkAdapter) AddrAddCalls(stub func(netlink.Link, *netlink.Addr) error) {
+	fake.addrAddMutex.Lock()
+	defer fake.addrAddMutex.Unlock()
+	fake.AddrAddStub = stub
+}
+
+func (fake *NetlinkAdapter) AddrAddArgsForCall(i int) (netlink.Link, *netlink.Addr) {
+	fake.addrAddMutex.RLock()
+	defer fake.addrAddMutex.RUnlock()
+	argsForCall := fake.addrAddArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *NetlinkAdapter) AddrAddReturns(result1 error) {
+	fake.addrAddMutex.Lock()
+	defer fake.addrAddMutex.Unlock()
+	fake.AddrAddStub = nil
+	fake.addrAddReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *NetlinkAdapter) AddrAddReturnsOnCall(i int, result1 error) {
+	fake.addrAddMutex.Lock()
+	defer fake.addrAddMutex.Unlock()
+	fake.AddrAddStub = nil
+	if fake.addrAddReturnsOnCall == nil {
+		fake.addrAddReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.addrAddReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *NetlinkAdapter) AddrAddScopeLink(arg1 netlink.Link, arg2 *netlink.Addr) error {
 	fake.addrAddScopeLinkMutex.Lock()
 	ret, specificReturn := fake.addrAddScopeLinkReturnsOnCall[len(fake.addrAddScopeLinkArgsForCall)]
@@ -460,6 +631,132 @@ func (fake *NetlinkAdapter) FDBListReturnsOnCall(i int, result1 []netlink.Neigh,
 	}{result1, result2}
 }
 
+func (fake *NetlinkAdapter) FilterAdd(arg1 netlink.Filter) error {
+	fake.filterAddMutex.Lock()
+	ret, specificReturn := fake.filterAddReturnsOnCall[len(fake.filterAddArgsForCall)]
+	fake.filterAddArgsForCall = append(fake.filterAddArgsForCall, struct {
+		arg1 netlink.Filter
+	}{arg1})
+	stub := fake.FilterAddStub
+	fakeReturns := fake.filterAddReturns
+	fake.recordInvocation("FilterAdd", []interface{}{arg1})
+	fake.filterAddMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *NetlinkAdapter) FilterAddCallCount() int {
+	fake.filterAddMutex.RLock()
+	defer fake.filterAddMutex.RUnlock()
+	return len(fake.filterAddArgsForCall)
+}
+
+func (fake *NetlinkAdapter) FilterAddCalls(stub func(netlink.Filter) error) {
+	fake.filterAddMutex.Lock()
+	defer fake.filterAddMutex.Unlock()
+	fake.FilterAddStub = stub
+}
+
+func (fake *NetlinkAdapter) FilterAddArgsForCall(i int) netlink.Filter {
+	fake.filterAddMutex.RLock()
+	defer fake.filterAddMutex.RUnlock()
+	argsForCall := fake.filterAddArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *NetlinkAdapter) FilterAddReturns(result1 error) {
+	fake.filterAddMutex.Lock()
+	defer fake.filterAddMutex.Unlock()
+	fake.FilterAddStub = nil
+	fake.filterAddReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *NetlinkAdapter) FilterAddReturnsOnCall(i int, result1 error) {
+	fake.filterAddMutex.Lock()
+	defer fake.filterAddMutex.Unlock()
+	fake.FilterAddStub = nil
+	if fake.filterAddReturnsOnCall == nil {
+		fake.filterAddReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.filterAddReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *NetlinkAdapter) FilterList(arg1 netlink.Link, arg2 uint32) ([]netlink.Filter, error) {
+	fake.filterListMutex.Lock()
+	ret, specificReturn := fake.filterListReturnsOnCall[len(fake.filterListArgsForCall)]
+	fake.filterListArgsForCall = append(fake.filterListArgsForCall, struct {
+		arg1 netlink.Link
+		arg2 uint32
+	}{arg1, arg2})
+	stub := fake.FilterListStub
+	fakeReturns := fake.filterListReturns
+	fake.recordInvocation("FilterList", []interface{}{arg1, arg2})
+	fake.filterListMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *NetlinkAdapter) FilterListCallCount() int {
+	fake.filterListMutex.RLock()
+	defer fake.filterListMutex.RUnlock()
+	return len(fake.filterListArgsForCall)
+}
+
+func (fake *NetlinkAdapter) FilterListCalls(stub func(netlink.Link, uint32) ([]netlink.Filter, error)) {
+	fake.filterListMutex.Lock()
+	defer fake.filterListMutex.Unlock()
+	fake.FilterListStub = stub
+}
+
+func (fake *NetlinkAdapter) FilterListArgsForCall(i int) (netlink.Link, uint32) {
+	fake.filterListMutex.RLock()
+	defer fake.filterListMutex.RUnlock()
+	argsForCall := fake.filterListArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *NetlinkAdapter) FilterListReturns(result1 []netlink.Filter, result2 error) {
+	fake.filterListMutex.Lock()
+	defer fake.filterListMutex.Unlock()
+	fake.FilterListStub = nil
+	fake.filterListReturns = struct {
+		result1 []netlink.Filter
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *NetlinkAdapter) FilterListReturnsOnCall(i int, result1 []netlink.Filter, result2 error) {
+	fake.filterListMutex.Lock()
+	defer fake.filterListMutex.Unlock()
+	fake.FilterListStub = nil
+	if fake.filterListReturnsOnCall == nil {
+		fake.filterListReturnsOnCall = make(map[int]struct {
+			result1 []netlink.Filter
+			result2 error
+		})
+	}
+	fake.filterListReturnsOnCall[i] = struct {
+		result1 []netlink.Filter
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *NetlinkAdapter) LinkAdd(arg1 netlink.Link) error {
 	fake.linkAddMutex.Lock()
 	ret, specificReturn := fake.linkAddReturnsOnCall[len(fake.linkAddArgsForCall)]
@@ -772,6 +1069,68 @@ func (fake *NetlinkAdapter) LinkSetHardwareAddrReturnsOnCall(i int, result1 erro
 	}{result1}
 }
 
+func (fake *NetlinkAdapter) LinkSetMTU(arg1 netlink.Link, arg2 int) error {
+	fake.linkSetMTUMutex.Lock()
+	ret, specificReturn := fake.linkSetMTUReturnsOnCall[len(fake.linkSetMTUArgsForCall)]
+	fake.linkSetMTUArgsForCall = append(fake.linkSetMTUArgsForCall, struct {
+		arg1 netlink.Link
+		arg2 int
+	}{arg1, arg2})
+	stub := fake.LinkSetMTUStub
+	fakeReturns := fake.linkSetMTUReturns
+	fake.recordInvocation("LinkSetMTU", []interface{}{arg1, arg2})
+	fake.linkSetMTUMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *NetlinkAdapter) LinkSetMTUCallCount() int {
+	fake.linkSetMTUMutex.RLock()
+	defer fake.linkSetMTUMutex.RUnlock()
+	return len(fake.linkSetMTUArgsForCall)
+}
+
+func (fake *NetlinkAdapter) LinkSetMTUCalls(stub func(netlink.Link, int) error) {
+	fake.linkSetMTUMutex.Lock()
+	defer fake.linkSetMTUMutex.Unlock()
+	fake.LinkSetMTUStub = stub
+}
+
+func (fake *NetlinkAdapter) LinkSetMTUArgsForCall(i int) (netlink.Link, int) {
+	fake.linkSetMTUMutex.RLock()
+	defer fake.linkSetMTUMutex.RUnlock()
+	argsForCall := fake.linkSetMTUArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *NetlinkAdapter) LinkSetMTUReturns(result1 error) {
+	fake.linkSetMTUMutex.Lock()
+	defer fake.linkSetMTUMutex.Unlock()
+	fake.LinkSetMTUStub = nil
+	fake.linkSetMTUReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *NetlinkAdapter) LinkSetMTUReturnsOnCall(i int, result1 error) {
+	fake.linkSetMTUMutex.Lock()
+	defer fake.linkSetMTUMutex.Unlock()
+	fake.LinkSetMTUStub = nil
+	if fake.linkSetMTUReturnsOnCall == nil {
+		fake.linkSetMTUReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.linkSetMTUReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *NetlinkAdapter) LinkSetUp(arg1 netlink.Link) error {
 	fake.linkSetUpMutex.Lock()
 	ret, specificReturn := fake.linkSetUpReturnsOnCall[len(fake.linkSetUpArgsForCall)]
@@ -833,6 +1192,132 @@ func (fake *NetlinkAdapter) LinkSetUpReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *NetlinkAdapter) LinkSubscribe(arg1 chan<- netlink.LinkUpdate, arg2 <-chan struct{}) error {
+	fake.linkSubscribeMutex.Lock()
+	ret, specificReturn := fake.linkSubscribeReturnsOnCall[len(fake.linkSubscribeArgsForCall)]
+	fake.linkSubscribeArgsForCall = append(fake.linkSubscribeArgsForCall, struct {
+		arg1 chan<- netlink.LinkUpdate
+		arg2 <-chan struct{}
+	}{arg1, arg2})
+	stub := fake.LinkSubscribeStub
+	fakeReturns := fake.linkSubscribeReturns
+	fake.recordInvocation("LinkSubscribe", []interface{}{arg1, arg2})
+	fake.linkSubscribeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *NetlinkAdapter) LinkSubscribeCallCount() int {
+	fake.linkSubscribeMutex.RLock()
+	defer fake.linkSubscribeMutex.RUnlock()
+	return len(fake.linkSubscribeArgsForCall)
+}
+
+func (fake *NetlinkAdapter) LinkSubscribeCalls(stub func(chan<- netlink.LinkUpdate, <-chan struct{}) error) {
+	fake.linkSubscribeMutex.Lock()
+	defer fake.linkSubscribeMutex.Unlock()
+	fake.LinkSubscribeStub = stub
+}
+
+func (fake *NetlinkAdapter) LinkSubscribeArgsForCall(i int) (chan<- netlink.LinkUpdate, <-chan struct{}) {
+	fake.linkSubscribeMutex.RLock()
+	defer fake.linkSubscribeMutex.RUnlock()
+	argsForCall := fake.linkSubscribeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *NetlinkAdapter) LinkSubscribeReturns(result1 error) {
+	fake.linkSubscribeMutex.Lock()
+	defer fake.linkSubscribeMutex.Unlock()
+	fake.LinkSubscribeStub = nil
+	fake.linkSubscribeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *NetlinkAdapter) LinkSubscribeReturnsOnCall(i int, result1 error) {
+	fake.linkSubscribeMutex.Lock()
+	defer fake.linkSubscribeMutex.Unlock()
+	fake.LinkSubscribeStub = nil
+	if fake.linkSubscribeReturnsOnCall == nil {
+		fake.linkSubscribeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.linkSubscribeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *NetlinkAdapter) NDPList(arg1 int) ([]netlink.Neigh, error) {
+	fake.nDPListMutex.Lock()
+	ret, specificReturn := fake.nDPListReturnsOnCall[len(fake.nDPListArgsForCall)]
+	fake.nDPListArgsForCall = append(fake.nDPListArgsForCall, struct {
+		arg1 int
+	}{arg1})
+	stub := fake.NDPListStub
+	fakeReturns := fake.nDPListReturns
+	fake.recordInvocation("NDPList", []interface{}{arg1})
+	fake.nDPListMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *NetlinkAdapter) NDPListCallCount() int {
+	fake.nDPListMutex.RLock()
+	defer fake.nDPListMutex.RUnlock()
+	return len(fake.nDPListArgsForCall)
+}
+
+func (fake *NetlinkAdapter) NDPListCalls(stub func(int) ([]netlink.Neigh, error)) {
+	fake.nDPListMutex.Lock()
+	defer fake.nDPListMutex.Unlock()
+	fake.NDPListStub = stub
+}
+
+func (fake *NetlinkAdapter) NDPListArgsForCall(i int) int {
+	fake.nDPListMutex.RLock()
+	defer fake.nDPListMutex.RUnlock()
+	argsForCall := fake.nDPListArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *NetlinkAdapter) NDPListReturns(result1 []netlink.Neigh, result2 error) {
+	fake.nDPListMutex.Lock()
+	defer fake.nDPListMutex.Unlock()
+	fake.NDPListStub = nil
+	fake.nDPListReturns = struct {
+		result1 []netlink.Neigh
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *NetlinkAdapter) NDPListReturnsOnCall(i int, result1 []netlink.Neigh, result2 error) {
+	fake.nDPListMutex.Lock()
+	defer fake.nDPListMutex.Unlock()
+	fake.NDPListStub = nil
+	if fake.nDPListReturnsOnCall == nil {
+		fake.nDPListReturnsOnCall = make(map[int]struct {
+			result1 []netlink.Neigh
+			result2 error
+		})
+	}
+	fake.nDPListReturnsOnCall[i] = struct {
+		result1 []netlink.Neigh
+		result2 error
+	}{result1, result2}
+}
+
 func (fake *NetlinkAdapter) NeighDel(arg1 *netlink.Neigh) error {
 	fake.neighDelMutex.Lock()
 	ret, specificReturn := fake.neighDelReturnsOnCall[len(fake.neighDelArgsForCall)]
@@ -955,6 +1440,129 @@ func (fake *NetlinkAdapter) NeighSetReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *NetlinkAdapter) NeighSubscribe(arg1 chan<- netlink.NeighUpdate, arg2 <-chan struct{}) error {
+	fake.neighSubscribeMutex.Lock()
+	ret, specificReturn := fake.neighSubscribeReturnsOnCall[len(fake.neighSubscribeArgsForCall)]
+	fake.neighSubscribeArgsForCall = append(fake.neighSubscribeArgsForCall, struct {
+		arg1 chan<- netlink.NeighUpdate
+		arg2 <-chan struct{}
+	}{arg1, arg2})
+	stub := fake.NeighSubscribeStub
+	fakeReturns := fake.neighSubscribeReturns
+	fake.recordInvocation("NeighSubscribe", []interface{}{arg1, arg2})
+	fake.neighSubscribeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *NetlinkAdapter) NeighSubscribeCallCount() int {
+	fake.neighSubscribeMutex.RLock()
+	defer fake.neighSubscribeMutex.RUnlock()
+	return len(fake.neighSubscribeArgsForCall)
+}
+
+func (fake *NetlinkAdapter) NeighSubscribeCalls(stub func(chan<- netlink.NeighUpdate, <-chan struct{}) error) {
+	fake.neighSubscribeMutex.Lock()
+	defer fake.neighSubscribeMutex.Unlock()
+	fake.NeighSubscribeStub = stub
+}
+
+func (fake *NetlinkAdapter) NeighSubscribeArgsForCall(i int) (chan<- netlink.NeighUpdate, <-chan struct{}) {
+	fake.neighSubscribeMutex.RLock()
+	defer fake.neighSubscribeMutex.RUnlock()
+	argsForCall := fake.neighSubscribeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *NetlinkAdapter) NeighSubscribeReturns(result1 error) {
+	fake.neighSubscribeMutex.Lock()
+	defer fake.neighSubscribeMutex.Unlock()
+	fake.NeighSubscribeStub = nil
+	fake.neighSubscribeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *NetlinkAdapter) NeighSubscribeReturnsOnCall(i int, result1 error) {
+	fake.neighSubscribeMutex.Lock()
+	defer fake.neighSubscribeMutex.Unlock()
+	fake.NeighSubscribeStub = nil
+	if fake.neighSubscribeReturnsOnCall == nil {
+		fake.neighSubscribeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.neighSubscribeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *NetlinkAdapter) QdiscAdd(arg1 netlink.Qdisc) error {
+	fake.qdiscAddMutex.Lock()
+	ret, specificReturn := fake.qdiscAddReturnsOnCall[len(fake.qdiscAddArgsForCall)]
+	fake.qdiscAddArgsForCall = append(fake.qdiscAddArgsForCall, struct {
+		arg1 netlink.Qdisc
+	}{arg1})
+	stub := fake.QdiscAddStub
+	fakeReturns := fake.qdiscAddReturns
+	fake.recordInvocation("QdiscAdd", []interface{}{arg1})
+	fake.qdiscAddMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *NetlinkAdapter) QdiscAddCallCount() int {
+	fake.qdiscAddMutex.RLock()
+	defer fake.qdiscAddMutex.RUnlock()
+	return len(fake.qdiscAddArgsForCall)
+}
+
+func (fake *NetlinkAdapter) QdiscAddCalls(stub func(netlink.Qdisc) error) {
+	fake.qdiscAddMutex.Lock()
+	defer fake.qdiscAddMutex.Unlock()
+	fake.QdiscAddStub = stub
+}
+
+func (fake *NetlinkAdapter) QdiscAddArgsForCall(i int) netlink.Qdisc {
+	fake.qdiscAddMutex.RLock()
+	defer fake.qdiscAddMutex.RUnlock()
+	argsForCall := fake.qdiscAddArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *NetlinkAdapter) QdiscAddReturns(result1 error) {
+	fake.qdiscAddMutex.Lock()
+	defer fake.qdiscAddMutex.Unlock()
+	fake.QdiscAddStub = nil
+	fake.qdiscAddReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *NetlinkAdapter) QdiscAddReturnsOnCall(i int, result1 error) {
+	fake.qdiscAddMutex.Lock()
+	defer fake.qdiscAddMutex.Unlock()
+	fake.QdiscAddStub = nil
+	if fake.qdiscAddReturnsOnCall == nil {
+		fake.qdiscAddReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.qdiscAddReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *NetlinkAdapter) RouteAdd(arg1 *netlink.Route) error {
 	fake.routeAddMutex.Lock()
 	ret, specificReturn := fake.routeAddReturnsOnCall[len(fake.routeAddArgsForCall)]
@@ -1203,17 +1811,85 @@ func (fake *NetlinkAdapter) RouteReplaceReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *NetlinkAdapter) RouteSubscribe(arg1 chan<- netlink.RouteUpdate, arg2 <-chan struct{}) error {
+	fake.routeSubscribeMutex.Lock()
+	ret, specificReturn := fake.routeSubscribeReturnsOnCall[len(fake.routeSubscribeArgsForCall)]
+	fake.routeSubscribeArgsForCall = append(fake.routeSubscribeArgsForCall, struct {
+		arg1 chan<- netlink.RouteUpdate
+		arg2 <-chan struct{}
+	}{arg1, arg2})
+	stub := fake.RouteSubscribeStub
+	fakeReturns := fake.routeSubscribeReturns
+	fake.recordInvocation("RouteSubscribe", []interface{}{arg1, arg2})
+	fake.routeSubscribeMutex.Unlock()
+	if stub != nil {
+		return stub(arg1, arg2)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *NetlinkAdapter) RouteSubscribeCallCount() int {
+	fake.routeSubscribeMutex.RLock()
+	defer fake.routeSubscribeMutex.RUnlock()
+	return len(fake.routeSubscribeArgsForCall)
+}
+
+func (fake *NetlinkAdapter) RouteSubscribeCalls(stub func(chan<- netlink.RouteUpdate, <-chan struct{}) error) {
+	fake.routeSubscribeMutex.Lock()
+	defer fake.routeSubscribeMutex.Unlock()
+	fake.RouteSubscribeStub = stub
+}
+
+func (fake *NetlinkAdapter) RouteSubscribeArgsForCall(i int) (chan<- netlink.RouteUpdate, <-chan struct{}) {
+	fake.routeSubscribeMutex.RLock()
+	defer fake.routeSubscribeMutex.RUnlock()
+	argsForCall := fake.routeSubscribeArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2
+}
+
+func (fake *NetlinkAdapter) RouteSubscribeReturns(result1 error) {
+	fake.routeSubscribeMutex.Lock()
+	defer fake.routeSubscribeMutex.Unlock()
+	fake.RouteSubscribeStub = nil
+	fake.routeSubscribeReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *NetlinkAdapter) RouteSubscribeReturnsOnCall(i int, result1 error) {
+	fake.routeSubscribeMutex.Lock()
+	defer fake.routeSubscribeMutex.Unlock()
+	fake.RouteSubscribeStub = nil
+	if fake.routeSubscribeReturnsOnCall == nil {
+		fake.routeSubscribeReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.routeSubscribeReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *NetlinkAdapter) Invocations() map[string][][]interface{} {
 	fake.invocationsMutex.RLock()
 	defer fake.invocationsMutex.RUnlock()
 	fake.aRPListMutex.RLock()
 	defer fake.aRPListMutex.RUnlock()
+	fake.addrAddMutex.RLock()
+	defer fake.addrAddMutex.RUnlock()
 	fake.addrAddScopeLinkMutex.RLock()
 	defer fake.addrAddScopeLinkMutex.RUnlock()
 	fake.addrListMutex.RLock()
 	defer fake.addrListMutex.RUnlock()
 	fake.fDBListMutex.RLock()
 	defer fake.fDBListMutex.RUnlock()
+	fake.filterAddMutex.RLock()
+	defer fake.filterAddMutex.RUnlock()
+	fake.filterListMutex.RLock()
+	defer fake.filterListMutex.RUnlock()
 	fake.linkAddMutex.RLock()
 	defer fake.linkAddMutex.RUnlock()
 	fake.linkByIndexMutex.RLock()
@@ -1224,12 +1900,22 @@ func (fake *NetlinkAdapter) Invocations() map[string][][]interface{} {
 	defer fake.linkDelMutex.RUnlock()
 	fake.linkSetHardwareAddrMutex.RLock()
 	defer fake.linkSetHardwareAddrMutex.RUnlock()
+	fake.linkSetMTUMutex.RLock()
+	defer fake.linkSetMTUMutex.RUnlock()
 	fake.linkSetUpMutex.RLock()
 	defer fake.linkSetUpMutex.RUnlock()
+	fake.linkSubscribeMutex.RLock()
+	defer fake.linkSubscribeMutex.RUnlock()
+	fake.nDPListMutex.RLock()
+	defer fake.nDPListMutex.RUnlock()
 	fake.neighDelMutex.RLock()
 	defer fake.neighDelMutex.RUnlock()
 	fake.neighSetMutex.RLock()
 	defer fake.neighSetMutex.RUnlock()
+	fake.neighSubscribeMutex.RLock()
+	defer fake.neighSubscribeMutex.RUnlock()
+	fake.qdiscAddMutex.RLock()
+	defer fake.qdiscAddMutex.RUnlock()
 	fake.routeAddMutex.RLock()
 	defer fake.routeAddMutex.RUnlock()
 	fake.routeDelMutex.RLock()
@@ -1238,6 +1924,8 @@ func (fake *NetlinkAdapter) Invocations() map[string][][]interface{} {
 	defer fake.routeListMutex.RUnlock()
 	fake.routeReplaceMutex.RLock()
 	defer fake.routeReplaceMutex.RUnlock()
+	fake.routeSubscribeMutex.RLock()
+	defer fake.routeSubscribeMutex.RUnlock()
 	copiedInvocations := map[string][][]interface{}{}
 	for key, value := range fake.invocations {
 		copiedInvocations[key] = value
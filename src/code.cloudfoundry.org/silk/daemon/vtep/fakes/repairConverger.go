@@ -0,0 +1,142 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+)
+
+type RepairConverger struct {
+	RepairStub        func() (bool, error)
+	repairMutex       sync.RWMutex
+	repairArgsForCall []struct {
+	}
+	repairReturns struct {
+		result1 bool
+		result2 error
+	}
+	repairReturnsOnCall map[int]struct {
+		result1 bool
+		result2 error
+	}
+	SetVTEPIndexStub        func(int)
+	setVTEPIndexMutex       sync.RWMutex
+	setVTEPIndexArgsForCall []struct {
+		arg1 int
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *RepairConverger) Repair() (bool, error) {
+	fake.repairMutex.Lock()
+	ret, specificReturn := fake.repairReturnsOnCall[len(fake.repairArgsForCall)]
+	fake.repairArgsForCall = append(fake.repairArgsForCall, struct {
+	}{})
+	stub := fake.RepairStub
+	fakeReturns := fake.repairReturns
+	fake.recordInvocation("Repair", []interface{}{})
+	fake.repairMutex.Unlock()
+	if stub != nil {
+		return stub()
+	}
+	if specificReturn {
+		return ret.result1, ret.result2
+	}
+	return fakeReturns.result1, fakeReturns.result2
+}
+
+func (fake *RepairConverger) RepairCallCount() int {
+	fake.repairMutex.RLock()
+	defer fake.repairMutex.RUnlock()
+	return len(fake.repairArgsForCall)
+}
+
+func (fake *RepairConverger) RepairCalls(stub func() (bool, error)) {
+	fake.repairMutex.Lock()
+	defer fake.repairMutex.Unlock()
+	fake.RepairStub = stub
+}
+
+func (fake *RepairConverger) RepairReturns(result1 bool, result2 error) {
+	fake.repairMutex.Lock()
+	defer fake.repairMutex.Unlock()
+	fake.RepairStub = nil
+	fake.repairReturns = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *RepairConverger) RepairReturnsOnCall(i int, result1 bool, result2 error) {
+	fake.repairMutex.Lock()
+	defer fake.repairMutex.Unlock()
+	fake.RepairStub = nil
+	if fake.repairReturnsOnCall == nil {
+		fake.repairReturnsOnCall = make(map[int]struct {
+			result1 bool
+			result2 error
+		})
+	}
+	fake.repairReturnsOnCall[i] = struct {
+		result1 bool
+		result2 error
+	}{result1, result2}
+}
+
+func (fake *RepairConverger) SetVTEPIndex(arg1 int) {
+	fake.setVTEPIndexMutex.Lock()
+	fake.setVTEPIndexArgsForCall = append(fake.setVTEPIndexArgsForCall, struct {
+		arg1 int
+	}{arg1})
+	stub := fake.SetVTEPIndexStub
+	fake.recordInvocation("SetVTEPIndex", []interface{}{arg1})
+	fake.setVTEPIndexMutex.Unlock()
+	if stub != nil {
+		fake.SetVTEPIndexStub(arg1)
+	}
+}
+
+func (fake *RepairConverger) SetVTEPIndexCallCount() int {
+	fake.setVTEPIndexMutex.RLock()
+	defer fake.setVTEPIndexMutex.RUnlock()
+	return len(fake.setVTEPIndexArgsForCall)
+}
+
+func (fake *RepairConverger) SetVTEPIndexCalls(stub func(int)) {
+	fake.setVTEPIndexMutex.Lock()
+	defer fake.setVTEPIndexMutex.Unlock()
+	fake.SetVTEPIndexStub = stub
+}
+
+func (fake *RepairConverger) SetVTEPIndexArgsForCall(i int) int {
+	fake.setVTEPIndexMutex.RLock()
+	defer fake.setVTEPIndexMutex.RUnlock()
+	argsForCall := fake.setVTEPIndexArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *RepairConverger) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.repairMutex.RLock()
+	defer fake.repairMutex.RUnlock()
+	fake.setVTEPIndexMutex.RLock()
+	defer fake.setVTEPIndexMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *RepairConverger) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -0,0 +1,109 @@
+// Code generated by counterfeiter. DO NOT EDIT.
+package fakes
+
+import (
+	"sync"
+
+	"code.cloudfoundry.org/silk/daemon/vtep"
+)
+
+type VTEPCreator struct {
+	CreateVTEPStub        func(*vtep.Config) error
+	createVTEPMutex       sync.RWMutex
+	createVTEPArgsForCall []struct {
+		arg1 *vtep.Config
+	}
+	createVTEPReturns struct {
+		result1 error
+	}
+	createVTEPReturnsOnCall map[int]struct {
+		result1 error
+	}
+	invocations      map[string][][]interface{}
+	invocationsMutex sync.RWMutex
+}
+
+func (fake *VTEPCreator) CreateVTEP(arg1 *vtep.Config) error {
+	fake.createVTEPMutex.Lock()
+	ret, specificReturn := fake.createVTEPReturnsOnCall[len(fake.createVTEPArgsForCall)]
+	fake.createVTEPArgsForCall = append(fake.createVTEPArgsForCall, struct {
+		arg1 *vtep.Config
+	}{arg1})
+	stub := fake.CreateVTEPStub
+	fakeReturns := fake.createVTEPReturns
+	fake.recordInvocation("CreateVTEP", []interface{}{arg1})
+	fake.createVTEPMutex.Unlock()
+	if stub != nil {
+		return stub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	return fakeReturns.result1
+}
+
+func (fake *VTEPCreator) CreateVTEPCallCount() int {
+	fake.createVTEPMutex.RLock()
+	defer fake.createVTEPMutex.RUnlock()
+	return len(fake.createVTEPArgsForCall)
+}
+
+func (fake *VTEPCreator) CreateVTEPCalls(stub func(*vtep.Config) error) {
+	fake.createVTEPMutex.Lock()
+	defer fake.createVTEPMutex.Unlock()
+	fake.CreateVTEPStub = stub
+}
+
+func (fake *VTEPCreator) CreateVTEPArgsForCall(i int) *vtep.Config {
+	fake.createVTEPMutex.RLock()
+	defer fake.createVTEPMutex.RUnlock()
+	argsForCall := fake.createVTEPArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *VTEPCreator) CreateVTEPReturns(result1 error) {
+	fake.createVTEPMutex.Lock()
+	defer fake.createVTEPMutex.Unlock()
+	fake.CreateVTEPStub = nil
+	fake.createVTEPReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *VTEPCreator) CreateVTEPReturnsOnCall(i int, result1 error) {
+	fake.createVTEPMutex.Lock()
+	defer fake.createVTEPMutex.Unlock()
+	fake.CreateVTEPStub = nil
+	if fake.createVTEPReturnsOnCall == nil {
+		fake.createVTEPReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.createVTEPReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *VTEPCreator) Invocations() map[string][][]interface{} {
+	fake.invocationsMutex.RLock()
+	defer fake.invocationsMutex.RUnlock()
+	fake.createVTEPMutex.RLock()
+	defer fake.createVTEPMutex.RUnlock()
+	copiedInvocations := map[string][][]interface{}{}
+	for key, value := range fake.invocations {
+		copiedInvocations[key] = value
+	}
+	return copiedInvocations
+}
+
+func (fake *VTEPCreator) recordInvocation(key string, args []interface{}) {
+	fake.invocationsMutex.Lock()
+	defer fake.invocationsMutex.Unlock()
+	if fake.invocations == nil {
+		fake.invocations = map[string][][]interface{}{}
+	}
+	if fake.invocations[key] == nil {
+		fake.invocations[key] = [][]interface{}{}
+	}
+	fake.invocations[key] = append(fake.invocations[key], args)
+}
@@ -0,0 +1,202 @@
+package vtep
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// The policy tag is carried in a Geneve option from the experimental
+// option class range. The option is not critical, so a receiver that does
+// not know it still decapsulates the packet, it just loses the tag and
+// with it any policy that allows the packet.
+const (
+	policyTagOptionClass = 0xff01
+	policyTagOptionType  = 0x01
+
+	// VXLAN-GBP carries the low 16 bits of the mark
+	policyTagMask = 0xffff
+)
+
+// TagProgram is a tc program that is attached to the vtep. FilterName
+// includes the settings that are built into the program, so that Drift
+// notices when they change.
+type TagProgram struct {
+	Name         string
+	FilterName   string
+	Ingress      bool
+	Instructions []byte
+}
+
+// bpf helpers, see include/uapi/linux/bpf.h
+const (
+	bpfFuncSkbGetTunnelKey = 20
+	bpfFuncSkbSetTunnelKey = 21
+	bpfFuncSkbGetTunnelOpt = 29
+	bpfFuncSkbSetTunnelOpt = 30
+
+	bpfFTunInfoIPv6 = 1
+
+	tcActOK   = 0
+	tcActShot = 2
+
+	// offsetof(struct __sk_buff, mark)
+	skbMarkOffset = 8
+	// sizeof(struct bpf_tunnel_key)
+	tunnelKeySize = 44
+)
+
+// geneveTagPrograms copy the mark of a packet into a Geneve option when it
+// leaves the vtep, and the option back into the mark when it arrives, like
+// the kernel does with the GBP extension of VXLAN.
+//
+// On egress the tunnel key that the route of the packet set is read and
+// set again, since options can only be added to a key that was set by a
+// program. The egress program passes every packet on.
+//
+// The vtep is in external mode and accepts every VNI, so on ingress packets
+// with another VNI than the configured one are dropped, as a VXLAN device
+// with that VNI would.
+func geneveTagPrograms(vni int, ipv6Underlay bool) []TagProgram {
+	var keyFlags int32
+	if ipv6Underlay {
+		keyFlags = bpfFTunInfoIPv6
+	}
+	optionHeader := int32(binary.LittleEndian.Uint32([]byte{
+		policyTagOptionClass >> 8, policyTagOptionClass & 0xff,
+		policyTagOptionType,
+		1, // length of the data in multiples of 4 bytes
+	}))
+
+	egress := []bpfInstruction{
+		movReg(6, 1),
+		movReg(2, 10),
+		addImm(2, -48),
+		movImm(3, tunnelKeySize),
+		movImm(4, keyFlags),
+		call(bpfFuncSkbGetTunnelKey),
+		jneImm(0, 0, 17),
+		movReg(1, 6),
+		movReg(2, 10),
+		addImm(2, -48),
+		movImm(3, tunnelKeySize),
+		movImm(4, keyFlags),
+		call(bpfFuncSkbSetTunnelKey),
+		jneImm(0, 0, 10),
+		loadWord(2, 6, skbMarkOffset),
+		andImm(2, policyTagMask),
+		toBigEndian32(2),
+		storeWordReg(10, -52, 2),
+		storeWordImm(10, -56, optionHeader),
+		movReg(1, 6),
+		movReg(2, 10),
+		addImm(2, -56),
+		movImm(3, 8),
+		call(bpfFuncSkbSetTunnelOpt),
+		movImm(0, tcActOK),
+		exit(),
+	}
+
+	ingress := []bpfInstruction{
+		movReg(6, 1),
+		movReg(2, 10),
+		addImm(2, -48),
+		movImm(3, tunnelKeySize),
+		movImm(4, keyFlags),
+		call(bpfFuncSkbGetTunnelKey),
+		jneImm(0, 0, 15),
+		loadWord(2, 10, -48),
+		jne32Imm(2, int32(vni), 13),
+		movReg(1, 6),
+		movReg(2, 10),
+		addImm(2, -8),
+		movImm(3, 8),
+		call(bpfFuncSkbGetTunnelOpt),
+		jneImm(0, 8, 5),
+		loadWord(2, 10, -8),
+		jne32Imm(2, optionHeader, 3),
+		loadWord(2, 10, -4),
+		toBigEndian32(2),
+		storeWordReg(6, skbMarkOffset, 2),
+		movImm(0, tcActOK),
+		exit(),
+		movImm(0, tcActShot),
+		exit(),
+	}
+
+	return []TagProgram{
+		{Name: "silk_tag_egress", FilterName: "silk_tag_egress", Instructions: assemble(egress)},
+		{Name: "silk_tag_ingress", FilterName: fmt.Sprintf("silk_tag_ingress_vni_%d", vni), Ingress: true, Instructions: assemble(ingress)},
+	}
+}
+
+// bpfInstruction is a struct bpf_insn. The programs are only built for
+// little-endian hosts, NewEncapsulation refuses geneve on other hosts.
+type bpfInstruction struct {
+	code   uint8
+	dst    uint8
+	src    uint8
+	offset int16
+	imm    int32
+}
+
+func littleEndianHost() bool {
+	return binary.NativeEndian.Uint16([]byte{1, 0}) == 1
+}
+
+func assemble(instructions []bpfInstruction) []byte {
+	buf := make([]byte, 0, 8*len(instructions))
+	for _, i := range instructions {
+		buf = append(buf, i.code, i.src<<4|i.dst)
+		buf = binary.LittleEndian.AppendUint16(buf, uint16(i.offset))
+		buf = binary.LittleEndian.AppendUint32(buf, uint32(i.imm))
+	}
+	return buf
+}
+
+func movReg(dst, src uint8) bpfInstruction {
+	return bpfInstruction{code: 0xbf, dst: dst, src: src}
+}
+
+func movImm(dst uint8, imm int32) bpfInstruction {
+	return bpfInstruction{code: 0xb7, dst: dst, imm: imm}
+}
+
+func addImm(dst uint8, imm int32) bpfInstruction {
+	return bpfInstruction{code: 0x07, dst: dst, imm: imm}
+}
+
+func andImm(dst uint8, imm int32) bpfInstruction {
+	return bpfInstruction{code: 0x57, dst: dst, imm: imm}
+}
+
+func toBigEndian32(dst uint8) bpfInstruction {
+	return bpfInstruction{code: 0xdc, dst: dst, imm: 32}
+}
+
+func loadWord(dst, src uint8, offset int16) bpfInstruction {
+	return bpfInstruction{code: 0x61, dst: dst, src: src, offset: offset}
+}
+
+func storeWordReg(dst uint8, offset int16, src uint8) bpfInstruction {
+	return bpfInstruction{code: 0x63, dst: dst, src: src, offset: offset}
+}
+
+func storeWordImm(dst uint8, offset int16, imm int32) bpfInstruction {
+	return bpfInstruction{code: 0x62, dst: dst, offset: offset, imm: imm}
+}
+
+func jneImm(dst uint8, imm int32, offset int16) bpfInstruction {
+	return bpfInstruction{code: 0x55, dst: dst, offset: offset, imm: imm}
+}
+
+func jne32Imm(dst uint8, imm int32, offset int16) bpfInstruction {
+	return bpfInstruction{code: 0x56, dst: dst, offset: offset, imm: imm}
+}
+
+func call(helper int32) bpfInstruction {
+	return bpfInstruction{code: 0x85, imm: helper}
+}
+
+func exit() bpfInstruction {
+	return bpfInstruction{code: 0x95}
+}
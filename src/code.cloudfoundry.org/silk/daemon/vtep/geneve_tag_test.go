@@ -0,0 +1,281 @@
+package vtep_test
+
+import (
+	"errors"
+	"net"
+	"os"
+	"runtime"
+
+	mcn "code.cloudfoundry.org/lib/multiple-cidr-network"
+	"code.cloudfoundry.org/silk/daemon/vtep"
+	"code.cloudfoundry.org/silk/lib/adapter"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netns"
+	"golang.org/x/sys/unix"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Geneve tag programs", func() {
+	var (
+		bpfAdapter    *adapter.BPFAdapter
+		encapsulation vtep.Encapsulation
+	)
+
+	BeforeEach(func() {
+		bpfAdapter = &adapter.BPFAdapter{}
+
+		var err error
+		encapsulation, err = vtep.NewEncapsulation(vtep.EncapsulationGeneve)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	DescribeTable("are accepted by the verifier",
+		func(underlayIP net.IP) {
+			programs := encapsulation.TagPrograms(99, underlayIP)
+			Expect(programs).To(HaveLen(2))
+
+			for _, program := range programs {
+				fd, err := bpfAdapter.LoadSchedCLS(program.Name, program.Instructions)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(bpfAdapter.Close(fd)).To(Succeed())
+			}
+		},
+		Entry("with an ipv4 underlay", net.IP{10, 0, 0, 1}),
+		Entry("with an ipv6 underlay", net.ParseIP("fd00::1")),
+	)
+
+	It("names the ingress filter after the vni, so that a vni change is noticed", func() {
+		programs := encapsulation.TagPrograms(4096, net.IP{10, 0, 0, 1})
+		Expect(programs[1].Ingress).To(BeTrue())
+		Expect(programs[1].FilterName).To(Equal("silk_tag_ingress_vni_4096"))
+	})
+
+	Describe("between two vteps", func() {
+		const (
+			underlayA = "10.99.0.1"
+			underlayB = "10.99.0.2"
+			overlayA  = "10.255.1.0"
+			overlayB  = "10.255.2.0"
+			vni       = 99
+			tag       = 0x1234
+			tagTable  = 100
+		)
+
+		var (
+			nsA, nsB netns.NsHandle
+			macA     = net.HardwareAddr{0xee, 0xee, 0x0a, 0xff, 0x01, 0x00}
+			macB     = net.HardwareAddr{0xee, 0xee, 0x0a, 0xff, 0x02, 0x00}
+		)
+
+		inNS := func(ns netns.NsHandle, f func()) {
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+
+			original, err := netns.Get()
+			Expect(err).NotTo(HaveOccurred())
+			defer original.Close()
+
+			Expect(netns.Set(ns)).To(Succeed())
+			defer func() { Expect(netns.Set(original)).To(Succeed()) }()
+			f()
+		}
+
+		newNS := func() netns.NsHandle {
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+
+			original, err := netns.Get()
+			Expect(err).NotTo(HaveOccurred())
+			defer original.Close()
+
+			ns, err := netns.New()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netns.Set(original)).To(Succeed())
+			return ns
+		}
+
+		setUp := func(name string) {
+			link, err := netlink.LinkByName(name)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(netlink.LinkSetUp(link)).To(Succeed())
+		}
+
+		createVTEP := func(underlayIP, leaseIP string, mac net.HardwareAddr) netlink.Link {
+			overlayNetworks, err := mcn.NewMultipleCIDRNetwork([]string{"10.255.0.0/16"})
+			Expect(err).NotTo(HaveOccurred())
+
+			factory := &vtep.Factory{NetlinkAdapter: &adapter.NetlinkAdapter{}, BPFAdapter: bpfAdapter}
+			Expect(factory.CreateVTEP(&vtep.Config{
+				VTEPName:            "silk-vtep",
+				UnderlayIP:          net.ParseIP(underlayIP),
+				LeaseIP:             net.ParseIP(leaseIP),
+				OverlayHardwareAddr: mac,
+				VNI:                 vni,
+				VTEPPort:            6081,
+				MTU:                 1400,
+				Encapsulation:       encapsulation,
+				OverlayNetworks:     overlayNetworks,
+			})).To(Succeed())
+
+			link, err := netlink.LinkByName("silk-vtep")
+			Expect(err).NotTo(HaveOccurred())
+			return link
+		}
+
+		// routeTo sends the packets for the remote overlay address through the
+		// tunnel with the given vni, like the converger does
+		routeTo := func(link netlink.Link, overlayIP, underlayIP string, mac net.HardwareAddr, vni int, table int) {
+			Expect(netlink.RouteAdd(&netlink.Route{
+				LinkIndex: link.Attrs().Index,
+				Dst:       &net.IPNet{IP: net.ParseIP(overlayIP).To4(), Mask: net.CIDRMask(32, 32)},
+				Encap:     encapsulation.Tunnel(vni, net.ParseIP(underlayIP)),
+				Table:     table,
+			})).To(Succeed())
+			Expect(netlink.NeighSet(&netlink.Neigh{
+				LinkIndex:    link.Attrs().Index,
+				State:        netlink.NUD_PERMANENT,
+				IP:           net.ParseIP(overlayIP),
+				HardwareAddr: mac,
+			})).To(Succeed())
+		}
+
+		// ping sends an echo request from A to B with the given mark and
+		// reports whether the reply came back
+		ping := func(mark int) bool {
+			var replied bool
+			inNS(nsA, func() {
+				fd, err := unix.Socket(unix.AF_INET, unix.SOCK_RAW, unix.IPPROTO_ICMP)
+				Expect(err).NotTo(HaveOccurred())
+				defer unix.Close(fd)
+
+				Expect(unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_MARK, mark)).To(Succeed())
+				Expect(unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &unix.Timeval{Sec: 1})).To(Succeed())
+
+				request := []byte{8, 0, 0, 0, 0x51, 0x50, 0, 1}
+				checksum := icmpChecksum(request)
+				request[2], request[3] = byte(checksum>>8), byte(checksum)
+
+				to := &unix.SockaddrInet4{}
+				copy(to.Addr[:], net.ParseIP(overlayB).To4())
+				Expect(unix.Sendto(fd, request, 0, to)).To(Succeed())
+
+				buf := make([]byte, 1500)
+				for {
+					n, from, err := unix.Recvfrom(fd, buf, 0)
+					if err != nil {
+						Expect(err).To(Equal(unix.EAGAIN))
+						return
+					}
+					header := int(buf[0]&0x0f) * 4
+					reply := buf[header:n]
+					if from.(*unix.SockaddrInet4).Addr == to.Addr && reply[0] == 0 && reply[4] == 0x51 && reply[5] == 0x50 {
+						replied = true
+						return
+					}
+				}
+			})
+			return replied
+		}
+
+		BeforeEach(func() {
+			nsA = newNS()
+			nsB = newNS()
+
+			geneveSupported := true
+			inNS(nsA, func() {
+				probe := &netlink.Geneve{LinkAttrs: netlink.LinkAttrs{Name: "probe"}, FlowBased: true}
+				err := netlink.LinkAdd(probe)
+				if errors.Is(err, unix.EOPNOTSUPP) {
+					geneveSupported = false
+					return
+				}
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.LinkDel(probe)).To(Succeed())
+			})
+			if !geneveSupported {
+				Skip("the kernel has no geneve support")
+			}
+
+			inNS(nsA, func() {
+				setUp("lo")
+				Expect(netlink.LinkAdd(&netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "underlay"}, PeerName: "underlay-b"})).To(Succeed())
+				peer, err := netlink.LinkByName("underlay-b")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.LinkSetNsFd(peer, int(nsB))).To(Succeed())
+
+				underlay, err := netlink.LinkByName("underlay")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.AddrAdd(underlay, &netlink.Addr{IPNet: &net.IPNet{IP: net.ParseIP(underlayA), Mask: net.CIDRMask(24, 32)}})).To(Succeed())
+				setUp("underlay")
+			})
+
+			inNS(nsB, func() {
+				setUp("lo")
+				underlay, err := netlink.LinkByName("underlay-b")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(netlink.AddrAdd(underlay, &netlink.Addr{IPNet: &net.IPNet{IP: net.ParseIP(underlayB), Mask: net.CIDRMask(24, 32)}})).To(Succeed())
+				setUp("underlay-b")
+
+				// B only has a route back to A for packets that carry the
+				// tag, and replies to a ping with the mark of the request
+				link := createVTEP(underlayB, overlayB, macB)
+				routeTo(link, overlayA, underlayA, macA, vni, tagTable)
+				rule := netlink.NewRule()
+				rule.Mark = tag
+				mask := uint32(0xffff)
+				rule.Mask = &mask
+				rule.Table = tagTable
+				Expect(netlink.RuleAdd(rule)).To(Succeed())
+				Expect(os.WriteFile("/proc/sys/net/ipv4/fwmark_reflect", []byte("1"), 0644)).To(Succeed())
+			})
+		})
+
+		AfterEach(func() {
+			Expect(nsA.Close()).To(Succeed())
+			Expect(nsB.Close()).To(Succeed())
+		})
+
+		Context("when the vteps use the same vni", func() {
+			BeforeEach(func() {
+				inNS(nsA, func() {
+					link := createVTEP(underlayA, overlayA, macA)
+					routeTo(link, overlayB, underlayB, macB, vni, 0)
+				})
+			})
+
+			It("carries the mark in the option and back into the mark", func() {
+				Expect(ping(0xabc0000 | tag)).To(BeTrue())
+			})
+
+			It("does not tag packets without a mark", func() {
+				Expect(ping(0)).To(BeFalse())
+			})
+		})
+
+		Context("when a packet arrives with another vni", func() {
+			BeforeEach(func() {
+				inNS(nsA, func() {
+					link := createVTEP(underlayA, overlayA, macA)
+					routeTo(link, overlayB, underlayB, macB, vni+1, 0)
+				})
+			})
+
+			It("drops it", func() {
+				Expect(ping(tag)).To(BeFalse())
+			})
+		})
+	})
+})
+
+func icmpChecksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	for sum > 0xffff {
+		sum = sum>>16 + sum&0xffff
+	}
+	return ^uint16(sum)
+}
@@ -0,0 +1,220 @@
+package vtep
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+//go:generate counterfeiter -o fakes/repairConverger.go --fake-name RepairConverger . repairConverger
+type repairConverger interface {
+	Repair() (bool, error)
+	SetVTEPIndex(index int)
+}
+
+//go:generate counterfeiter -o fakes/vtepCreator.go --fake-name VTEPCreator . vtepCreator
+type vtepCreator interface {
+	CreateVTEP(cfg *Config) error
+}
+
+// Repairer watches the routes, neighbours and links of the vtep, and puts
+// back what was changed outside of silk instead of waiting for the next
+// poll. Changes come in bursts, so they are collected for RepairDelay
+// before the converger repairs them all at once. When the vtep itself is
+// deleted it is created again from VTEPConfig.
+type Repairer struct {
+	Converger      repairConverger
+	Factory        vtepCreator
+	VTEPConfig     *Config
+	NetlinkAdapter netlinkAdapter
+	Logger         lager.Logger
+	MetricSender   metricSender
+	RepairDelay    time.Duration
+	RetryInterval  time.Duration
+
+	index int
+}
+
+type subscription struct {
+	routes chan netlink.RouteUpdate
+	neighs chan netlink.NeighUpdate
+	links  chan netlink.LinkUpdate
+	done   chan struct{}
+}
+
+func (r *Repairer) Run(signals <-chan os.Signal, ready chan<- struct{}) error {
+	logger := r.Logger.Session("vtep-repairer")
+
+	link, err := r.NetlinkAdapter.LinkByName(r.VTEPConfig.VTEPName)
+	if err != nil {
+		return fmt.Errorf("find vtep: %s", err)
+	}
+	r.index = link.Attrs().Index
+
+	sub, err := r.subscribe()
+	if err != nil {
+		return err
+	}
+
+	close(ready)
+
+	var (
+		repair   <-chan time.Time
+		recreate bool
+	)
+	schedule := func(delay time.Duration) {
+		if repair == nil {
+			repair = time.After(delay)
+		}
+	}
+
+	for {
+		select {
+		case <-signals:
+			sub.stop()
+			return nil
+		case update, ok := <-sub.routes:
+			if !ok {
+				sub, err = r.resubscribe(logger, sub)
+				if err != nil {
+					return err
+				}
+				schedule(r.RepairDelay)
+				continue
+			}
+			if update.LinkIndex == r.index {
+				schedule(r.RepairDelay)
+			}
+		case update, ok := <-sub.neighs:
+			if !ok {
+				sub, err = r.resubscribe(logger, sub)
+				if err != nil {
+					return err
+				}
+				schedule(r.RepairDelay)
+				continue
+			}
+			if update.LinkIndex == r.index {
+				schedule(r.RepairDelay)
+			}
+		case update, ok := <-sub.links:
+			if !ok {
+				sub, err = r.resubscribe(logger, sub)
+				if err != nil {
+					return err
+				}
+				schedule(r.RepairDelay)
+				continue
+			}
+			if update.Header.Type == unix.RTM_DELLINK && update.Attrs().Index == r.index {
+				logger.Info("vtep-deleted", lager.Data{"index": r.index})
+				recreate = true
+				schedule(r.RepairDelay)
+			}
+		case <-repair:
+			repair = nil
+			recreated := false
+			if recreate {
+				err := r.recreate()
+				if err != nil {
+					logger.Error("recreate-vtep", err)
+					schedule(r.RetryInterval)
+					continue
+				}
+				recreate = false
+				recreated = true
+				logger.Info("recreated-vtep", lager.Data{"index": r.index})
+			}
+
+			// a recreated vtep is repaired as well, both count as one repair
+			repaired, err := r.Converger.Repair()
+			if err != nil {
+				logger.Error("repair-vtep", err)
+			} else if repaired {
+				logger.Info("repaired-vtep")
+			}
+			if recreated || repaired {
+				r.MetricSender.IncrementCounter("vtepRepair")
+			}
+		}
+	}
+}
+
+func (r *Repairer) subscribe() (subscription, error) {
+	sub := subscription{done: make(chan struct{})}
+
+	routes := make(chan netlink.RouteUpdate)
+	err := r.NetlinkAdapter.RouteSubscribe(routes, sub.done)
+	if err != nil {
+		sub.stop()
+		return sub, fmt.Errorf("subscribe to routes: %s", err)
+	}
+	sub.routes = routes
+
+	neighs := make(chan netlink.NeighUpdate)
+	err = r.NetlinkAdapter.NeighSubscribe(neighs, sub.done)
+	if err != nil {
+		sub.stop()
+		return sub, fmt.Errorf("subscribe to neighbours: %s", err)
+	}
+	sub.neighs = neighs
+
+	links := make(chan netlink.LinkUpdate)
+	err = r.NetlinkAdapter.LinkSubscribe(links, sub.done)
+	if err != nil {
+		sub.stop()
+		return sub, fmt.Errorf("subscribe to links: %s", err)
+	}
+	sub.links = links
+	return sub, nil
+}
+
+// resubscribe replaces a subscription that the kernel closed, for example
+// after its receive buffer overflowed. Updates may have been lost in the
+// meantime, so the caller should repair the vtep.
+func (r *Repairer) resubscribe(logger lager.Logger, sub subscription) (subscription, error) {
+	logger.Info("netlink-subscription-closed")
+	sub.stop()
+	return r.subscribe()
+}
+
+// stop ends the subscription. Netlink sends updates without watching done
+// and only closes a channel once its socket is closed, so the channels of
+// the subscriptions that were made are drained until then. Otherwise the
+// netlink goroutines would block on a send forever.
+func (s subscription) stop() {
+	close(s.done)
+	if s.routes != nil {
+		go drain(s.routes)
+	}
+	if s.neighs != nil {
+		go drain(s.neighs)
+	}
+	if s.links != nil {
+		go drain(s.links)
+	}
+}
+
+func drain[T any](ch <-chan T) {
+	for range ch {
+	}
+}
+
+func (r *Repairer) recreate() error {
+	err := r.Factory.CreateVTEP(r.VTEPConfig)
+	if err != nil {
+		return fmt.Errorf("create vtep: %s", err)
+	}
+
+	link, err := r.NetlinkAdapter.LinkByName(r.VTEPConfig.VTEPName)
+	if err != nil {
+		return fmt.Errorf("find vtep: %s", err)
+	}
+	r.index = link.Attrs().Index
+	r.Converger.SetVTEPIndex(r.index)
+	return nil
+}
@@ -0,0 +1,280 @@
+package vtep_test
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"code.cloudfoundry.org/lager/v3/lagertest"
+	"code.cloudfoundry.org/silk/daemon/vtep"
+	"code.cloudfoundry.org/silk/daemon/vtep/fakes"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/tedsuo/ifrit"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+var _ = Describe("Repairer", func() {
+	var (
+		fakeNetlink      *fakes.NetlinkAdapter
+		fakeConverger    *fakes.RepairConverger
+		fakeFactory      *fakes.VTEPCreator
+		fakeMetricSender *fakes.MetricSender
+		logger           *lagertest.TestLogger
+		vtepConfig       *vtep.Config
+		repairer         *vtep.Repairer
+
+		subscriptionsLock sync.Mutex
+		routeUpdates      chan<- netlink.RouteUpdate
+		neighUpdates      chan<- netlink.NeighUpdate
+		linkUpdates       chan<- netlink.LinkUpdate
+		subscriptionDone  <-chan struct{}
+	)
+
+	vtepLink := func(index int) netlink.Link {
+		return &netlink.Vxlan{LinkAttrs: netlink.LinkAttrs{Name: "silk-vtep", Index: index}}
+	}
+
+	subscribed := func() (chan<- netlink.RouteUpdate, chan<- netlink.NeighUpdate, chan<- netlink.LinkUpdate) {
+		subscriptionsLock.Lock()
+		defer subscriptionsLock.Unlock()
+		return routeUpdates, neighUpdates, linkUpdates
+	}
+
+	BeforeEach(func() {
+		fakeNetlink = &fakes.NetlinkAdapter{}
+		fakeConverger = &fakes.RepairConverger{}
+		fakeFactory = &fakes.VTEPCreator{}
+		fakeMetricSender = &fakes.MetricSender{}
+		logger = lagertest.NewTestLogger("test")
+		vtepConfig = &vtep.Config{VTEPName: "silk-vtep"}
+
+		fakeNetlink.LinkByNameReturns(vtepLink(42), nil)
+		fakeNetlink.RouteSubscribeStub = func(ch chan<- netlink.RouteUpdate, done <-chan struct{}) error {
+			subscriptionsLock.Lock()
+			defer subscriptionsLock.Unlock()
+			routeUpdates = ch
+			subscriptionDone = done
+			return nil
+		}
+		fakeNetlink.NeighSubscribeStub = func(ch chan<- netlink.NeighUpdate, _ <-chan struct{}) error {
+			subscriptionsLock.Lock()
+			defer subscriptionsLock.Unlock()
+			neighUpdates = ch
+			return nil
+		}
+		fakeNetlink.LinkSubscribeStub = func(ch chan<- netlink.LinkUpdate, _ <-chan struct{}) error {
+			subscriptionsLock.Lock()
+			defer subscriptionsLock.Unlock()
+			linkUpdates = ch
+			return nil
+		}
+		fakeConverger.RepairReturns(true, nil)
+
+		repairer = &vtep.Repairer{
+			Converger:      fakeConverger,
+			Factory:        fakeFactory,
+			VTEPConfig:     vtepConfig,
+			NetlinkAdapter: fakeNetlink,
+			Logger:         logger,
+			MetricSender:   fakeMetricSender,
+			RepairDelay:    10 * time.Millisecond,
+			RetryInterval:  10 * time.Millisecond,
+		}
+	})
+
+	Context("when it is running", func() {
+		var process ifrit.Process
+
+		BeforeEach(func() {
+			process = ifrit.Invoke(repairer)
+		})
+
+		AfterEach(func() {
+			process.Signal(os.Interrupt)
+			Eventually(process.Wait()).Should(Receive(BeNil()))
+		})
+
+		It("repairs the vtep when one of its routes changes", func() {
+			routes, _, _ := subscribed()
+			routes <- netlink.RouteUpdate{Type: unix.RTM_DELROUTE, Route: netlink.Route{LinkIndex: 42}}
+
+			Eventually(fakeConverger.RepairCallCount).Should(Equal(1))
+			Eventually(fakeMetricSender.IncrementCounterCallCount).Should(Equal(1))
+			Expect(fakeMetricSender.IncrementCounterArgsForCall(0)).To(Equal("vtepRepair"))
+			Expect(logger).To(gbytes.Say("repaired-vtep"))
+		})
+
+		It("repairs the vtep once for a burst of neighbour changes", func() {
+			_, neighs, _ := subscribed()
+			neighs <- netlink.NeighUpdate{Type: unix.RTM_DELNEIGH, Neigh: netlink.Neigh{LinkIndex: 42}}
+			neighs <- netlink.NeighUpdate{Type: unix.RTM_DELNEIGH, Neigh: netlink.Neigh{LinkIndex: 42}}
+
+			Eventually(fakeConverger.RepairCallCount).Should(Equal(1))
+			Consistently(fakeConverger.RepairCallCount, 50*time.Millisecond).Should(Equal(1))
+		})
+
+		It("ignores changes to other links", func() {
+			routes, neighs, _ := subscribed()
+			routes <- netlink.RouteUpdate{Type: unix.RTM_DELROUTE, Route: netlink.Route{LinkIndex: 7}}
+			neighs <- netlink.NeighUpdate{Type: unix.RTM_DELNEIGH, Neigh: netlink.Neigh{LinkIndex: 7}}
+
+			Consistently(fakeConverger.RepairCallCount, 50*time.Millisecond).Should(Equal(0))
+		})
+
+		Context("when nothing had to be repaired", func() {
+			BeforeEach(func() {
+				fakeConverger.RepairReturns(false, nil)
+			})
+
+			It("does not count a repair", func() {
+				routes, _, _ := subscribed()
+				routes <- netlink.RouteUpdate{Type: unix.RTM_NEWROUTE, Route: netlink.Route{LinkIndex: 42}}
+
+				Eventually(fakeConverger.RepairCallCount).Should(Equal(1))
+				Consistently(fakeMetricSender.IncrementCounterCallCount, 50*time.Millisecond).Should(Equal(0))
+			})
+		})
+
+		Context("when repairing fails", func() {
+			BeforeEach(func() {
+				fakeConverger.RepairReturns(false, errors.New("banana"))
+			})
+
+			It("logs the error and keeps running", func() {
+				routes, _, _ := subscribed()
+				routes <- netlink.RouteUpdate{Type: unix.RTM_DELROUTE, Route: netlink.Route{LinkIndex: 42}}
+
+				Eventually(logger).Should(gbytes.Say("repair-vtep.*banana"))
+				Consistently(process.Wait()).ShouldNot(Receive())
+			})
+		})
+
+		Context("when the vtep is deleted", func() {
+			BeforeEach(func() {
+				fakeNetlink.LinkByNameReturns(vtepLink(43), nil)
+			})
+
+			It("recreates it and repairs it", func() {
+				fakeConverger.RepairReturns(true, nil)
+				_, _, links := subscribed()
+				links <- netlink.LinkUpdate{Header: unix.NlMsghdr{Type: unix.RTM_DELLINK}, Link: vtepLink(42)}
+
+				Eventually(fakeConverger.RepairCallCount).Should(Equal(1))
+				Expect(fakeFactory.CreateVTEPCallCount()).To(Equal(1))
+				Expect(fakeFactory.CreateVTEPArgsForCall(0)).To(Equal(vtepConfig))
+				Expect(fakeConverger.SetVTEPIndexCallCount()).To(Equal(1))
+				Expect(fakeConverger.SetVTEPIndexArgsForCall(0)).To(Equal(43))
+				Eventually(fakeMetricSender.IncrementCounterCallCount).Should(Equal(1))
+				Consistently(fakeMetricSender.IncrementCounterCallCount, 50*time.Millisecond).Should(Equal(1))
+				Expect(fakeMetricSender.IncrementCounterArgsForCall(0)).To(Equal("vtepRepair"))
+
+				By("following the recreated vtep")
+				routes, _, _ := subscribed()
+				routes <- netlink.RouteUpdate{Type: unix.RTM_DELROUTE, Route: netlink.Route{LinkIndex: 43}}
+				Eventually(fakeConverger.RepairCallCount).Should(Equal(2))
+			})
+
+			Context("when creating the vtep fails", func() {
+				BeforeEach(func() {
+					fakeFactory.CreateVTEPReturnsOnCall(0, errors.New("kiwi"))
+				})
+
+				It("logs the error and tries again", func() {
+					_, _, links := subscribed()
+					links <- netlink.LinkUpdate{Header: unix.NlMsghdr{Type: unix.RTM_DELLINK}, Link: vtepLink(42)}
+
+					Eventually(logger).Should(gbytes.Say("recreate-vtep.*create vtep: kiwi"))
+					Eventually(fakeFactory.CreateVTEPCallCount).Should(Equal(2))
+					Eventually(fakeConverger.RepairCallCount).Should(Equal(1))
+					Eventually(fakeMetricSender.IncrementCounterCallCount).Should(Equal(1))
+				})
+			})
+
+			Context("when repairing the recreated vtep fails", func() {
+				BeforeEach(func() {
+					fakeConverger.RepairReturns(false, errors.New("banana"))
+				})
+
+				It("still counts the recreate as a repair", func() {
+					_, _, links := subscribed()
+					links <- netlink.LinkUpdate{Header: unix.NlMsghdr{Type: unix.RTM_DELLINK}, Link: vtepLink(42)}
+
+					Eventually(logger).Should(gbytes.Say("repair-vtep.*banana"))
+					Eventually(fakeMetricSender.IncrementCounterCallCount).Should(Equal(1))
+				})
+			})
+		})
+
+		It("does not recreate the vtep when another link is deleted", func() {
+			_, _, links := subscribed()
+			links <- netlink.LinkUpdate{Header: unix.NlMsghdr{Type: unix.RTM_DELLINK}, Link: vtepLink(7)}
+
+			Consistently(fakeFactory.CreateVTEPCallCount, 50*time.Millisecond).Should(Equal(0))
+		})
+
+		Context("when a subscription is closed", func() {
+			It("subscribes again and repairs the vtep", func() {
+				routes, _, _ := subscribed()
+				close(routes)
+
+				Eventually(fakeNetlink.RouteSubscribeCallCount).Should(Equal(2))
+				Expect(fakeNetlink.NeighSubscribeCallCount()).To(Equal(2))
+				Expect(fakeNetlink.LinkSubscribeCallCount()).To(Equal(2))
+				Eventually(fakeConverger.RepairCallCount).Should(Equal(1))
+			})
+
+			It("stops the old subscriptions and drains their updates until they are closed", func() {
+				routes, neighs, links := subscribed()
+				subscriptionsLock.Lock()
+				done := subscriptionDone
+				subscriptionsLock.Unlock()
+				close(routes)
+
+				Eventually(done).Should(BeClosed())
+				for i := 0; i < 3; i++ {
+					Eventually(neighs).Should(BeSent(netlink.NeighUpdate{Neigh: netlink.Neigh{LinkIndex: 42}}))
+					Eventually(links).Should(BeSent(netlink.LinkUpdate{Header: unix.NlMsghdr{Type: unix.RTM_DELLINK}, Link: vtepLink(42)}))
+				}
+				close(neighs)
+				close(links)
+
+				Consistently(fakeFactory.CreateVTEPCallCount, 50*time.Millisecond).Should(Equal(0))
+			})
+		})
+	})
+
+	Context("when the vtep cannot be found", func() {
+		BeforeEach(func() {
+			fakeNetlink.LinkByNameReturns(nil, errors.New("apple"))
+		})
+
+		It("returns an error", func() {
+			process := ifrit.Background(repairer)
+			Eventually(process.Wait()).Should(Receive(MatchError("find vtep: apple")))
+		})
+	})
+
+	DescribeTable("when subscribing fails",
+		func(setup func(), expectedError string) {
+			setup()
+			process := ifrit.Background(repairer)
+			Eventually(process.Wait()).Should(Receive(MatchError(expectedError)))
+		},
+		Entry("routes", func() {
+			fakeNetlink.RouteSubscribeStub = nil
+			fakeNetlink.RouteSubscribeReturns(errors.New("pear"))
+		}, "subscribe to routes: pear"),
+		Entry("neighbours", func() {
+			fakeNetlink.NeighSubscribeStub = nil
+			fakeNetlink.NeighSubscribeReturns(errors.New("pear"))
+		}, "subscribe to neighbours: pear"),
+		Entry("links", func() {
+			fakeNetlink.LinkSubscribeStub = nil
+			fakeNetlink.LinkSubscribeReturns(errors.New("pear"))
+		}, "subscribe to links: pear"),
+	)
+})
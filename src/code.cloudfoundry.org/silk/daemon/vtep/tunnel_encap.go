@@ -0,0 +1,89 @@
+package vtep
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+)
+
+// attributes of LWTUNNEL_ENCAP_IP and LWTUNNEL_ENCAP_IP6, which share
+// their numbering
+const (
+	lwtunnelIPID    = 1
+	lwtunnelIPDst   = 2
+	lwtunnelIPFlags = 6
+
+	tunnelKeyFlag = 0x04
+)
+
+// TunnelEncap sends the packets of a route to the vtep at Dst, like
+// "ip route ... encap ip id ID dst DST" does. The netlink library only
+// has it for IPv6 underlays.
+type TunnelEncap struct {
+	ID  uint64
+	Dst net.IP
+}
+
+func (e *TunnelEncap) Type() int {
+	if e.Dst.To4() == nil {
+		return nl.LWTUNNEL_ENCAP_IP6
+	}
+	return nl.LWTUNNEL_ENCAP_IP
+}
+
+func (e *TunnelEncap) Decode(buf []byte) error {
+	attrs, err := nl.ParseRouteAttr(buf)
+	if err != nil {
+		return err
+	}
+	for _, attr := range attrs {
+		switch attr.Attr.Type {
+		case lwtunnelIPID:
+			if len(attr.Value) != 8 {
+				return fmt.Errorf("invalid tunnel id length %d", len(attr.Value))
+			}
+			e.ID = binary.BigEndian.Uint64(attr.Value)
+		case lwtunnelIPDst:
+			e.Dst = net.IP(attr.Value)
+		}
+	}
+	return nil
+}
+
+func (e *TunnelEncap) Encode() ([]byte, error) {
+	dst := e.Dst.To4()
+	if dst == nil {
+		dst = e.Dst.To16()
+	}
+	if dst == nil {
+		return nil, fmt.Errorf("invalid tunnel destination %s", e.Dst)
+	}
+
+	id := make([]byte, 8)
+	binary.BigEndian.PutUint64(id, e.ID)
+	flags := make([]byte, 2)
+	binary.BigEndian.PutUint16(flags, tunnelKeyFlag)
+
+	buf := nl.NewRtAttr(lwtunnelIPID, id).Serialize()
+	buf = append(buf, nl.NewRtAttr(lwtunnelIPDst, dst).Serialize()...)
+	buf = append(buf, nl.NewRtAttr(lwtunnelIPFlags, flags).Serialize()...)
+	return buf, nil
+}
+
+func (e *TunnelEncap) String() string {
+	return fmt.Sprintf("id %d dst %s", e.ID, e.Dst)
+}
+
+func (e *TunnelEncap) Equal(x netlink.Encap) bool {
+	o, ok := x.(*TunnelEncap)
+	if !ok {
+		return false
+	}
+	if e == nil || o == nil {
+		return e == o
+	}
+	return e.ID == o.ID && e.Dst.Equal(o.Dst)
+}
@@ -0,0 +1,54 @@
+package vtep_test
+
+import (
+	"net"
+
+	"code.cloudfoundry.org/silk/daemon/vtep"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+)
+
+var _ = Describe("TunnelEncap", func() {
+	It("round trips through its netlink encoding", func() {
+		encap := &vtep.TunnelEncap{ID: 99, Dst: net.ParseIP("10.10.0.5")}
+		Expect(encap.Type()).To(Equal(nl.LWTUNNEL_ENCAP_IP))
+
+		buf, err := encap.Encode()
+		Expect(err).NotTo(HaveOccurred())
+
+		decoded := &vtep.TunnelEncap{}
+		Expect(decoded.Decode(buf)).To(Succeed())
+		Expect(decoded.Equal(encap)).To(BeTrue())
+		Expect(decoded.String()).To(Equal("id 99 dst 10.10.0.5"))
+	})
+
+	Context("when the underlay is ipv6", func() {
+		It("is an ip6 encap", func() {
+			encap := &vtep.TunnelEncap{ID: 99, Dst: net.ParseIP("fd00::5")}
+			Expect(encap.Type()).To(Equal(nl.LWTUNNEL_ENCAP_IP6))
+
+			buf, err := encap.Encode()
+			Expect(err).NotTo(HaveOccurred())
+
+			decoded := &vtep.TunnelEncap{}
+			Expect(decoded.Decode(buf)).To(Succeed())
+			Expect(decoded.Dst.String()).To(Equal("fd00::5"))
+		})
+	})
+
+	It("is not equal to another encap", func() {
+		encap := &vtep.TunnelEncap{ID: 99, Dst: net.ParseIP("10.10.0.5")}
+		Expect(encap.Equal(&vtep.TunnelEncap{ID: 99, Dst: net.ParseIP("10.10.0.6")})).To(BeFalse())
+		Expect(encap.Equal(&netlink.MPLSEncap{})).To(BeFalse())
+	})
+
+	Context("when the destination is invalid", func() {
+		It("returns an error", func() {
+			_, err := (&vtep.TunnelEncap{ID: 99}).Encode()
+			Expect(err).To(MatchError("invalid tunnel destination <nil>"))
+		})
+	})
+})
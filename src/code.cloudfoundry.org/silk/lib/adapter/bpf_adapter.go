@@ -0,0 +1,60 @@
+package adapter
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+const bpfLogSize = 64 * 1024
+
+type BPFAdapter struct{}
+
+// the start of union bpf_attr for BPF_PROG_LOAD
+type bpfProgLoadAttr struct {
+	progType    uint32
+	insnCnt     uint32
+	insns       uint64
+	license     uint64
+	logLevel    uint32
+	logSize     uint32
+	logBuf      uint64
+	kernVersion uint32
+	progFlags   uint32
+	progName    [unix.BPF_OBJ_NAME_LEN]byte
+}
+
+// LoadSchedCLS loads a tc classifier program and returns its file
+// descriptor. When the verifier rejects the program its log is part of the
+// error.
+func (*BPFAdapter) LoadSchedCLS(name string, instructions []byte) (int, error) {
+	license := []byte("Apache-2.0\x00")
+	log := make([]byte, bpfLogSize)
+
+	attr := bpfProgLoadAttr{
+		progType: unix.BPF_PROG_TYPE_SCHED_CLS,
+		insnCnt:  uint32(len(instructions) / 8),
+		insns:    uint64(uintptr(unsafe.Pointer(&instructions[0]))),
+		license:  uint64(uintptr(unsafe.Pointer(&license[0]))),
+		logLevel: 1,
+		logSize:  uint32(len(log)),
+		logBuf:   uint64(uintptr(unsafe.Pointer(&log[0]))),
+	}
+	copy(attr.progName[:unix.BPF_OBJ_NAME_LEN-1], name)
+
+	fd, _, errno := unix.Syscall(unix.SYS_BPF, unix.BPF_PROG_LOAD, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr))
+	runtime.KeepAlive(instructions)
+	runtime.KeepAlive(license)
+	runtime.KeepAlive(log)
+	if errno != 0 {
+		return -1, fmt.Errorf("load %s: %s: %s", name, errno, bytes.TrimRight(log, "\x00\n"))
+	}
+	return int(fd), nil
+}
+
+func (*BPFAdapter) Close(fd int) error {
+	return unix.Close(fd)
+}
@@ -26,6 +26,10 @@ func (*NetlinkAdapter) AddrAddScopeLink(link netlink.Link, addr *netlink.Addr) e
 	return netlink.AddrAdd(link, addr)
 }
 
+func (*NetlinkAdapter) AddrAdd(link netlink.Link, addr *netlink.Addr) error {
+	return netlink.AddrAdd(link, addr)
+}
+
 func (*NetlinkAdapter) AddrList(link netlink.Link, family int) ([]netlink.Addr, error) {
 	return netlink.AddrList(link, family)
 }
@@ -34,6 +38,10 @@ func (*NetlinkAdapter) LinkSetHardwareAddr(link netlink.Link, hwaddr net.Hardwar
 	return netlink.LinkSetHardwareAddr(link, hwaddr)
 }
 
+func (*NetlinkAdapter) LinkSetMTU(link netlink.Link, mtu int) error {
+	return netlink.LinkSetMTU(link, mtu)
+}
+
 func (*NetlinkAdapter) NeighAddPermanentIPv4(index int, destIP net.IP, hwAddr net.HardwareAddr) error {
 	return netlink.NeighAdd(&netlink.Neigh{
 		LinkIndex:    index,
@@ -52,6 +60,10 @@ func (*NetlinkAdapter) ARPList(linkIndex int) ([]netlink.Neigh, error) {
 	return netlink.NeighList(linkIndex, netlink.FAMILY_V4)
 }
 
+func (*NetlinkAdapter) NDPList(linkIndex int) ([]netlink.Neigh, error) {
+	return netlink.NeighList(linkIndex, netlink.FAMILY_V6)
+}
+
 func (*NetlinkAdapter) FDBList(linkIndex int) ([]netlink.Neigh, error) {
 	return netlink.NeighList(linkIndex, syscall.AF_BRIDGE)
 }
@@ -64,6 +76,10 @@ func (*NetlinkAdapter) LinkSetARPOff(link netlink.Link) error {
 	return netlink.LinkSetARPOff(link)
 }
 
+func (*NetlinkAdapter) LinkSetTxQLen(link netlink.Link, qlen int) error {
+	return netlink.LinkSetTxQLen(link, qlen)
+}
+
 func (*NetlinkAdapter) LinkSetName(link netlink.Link, newName string) error {
 	return netlink.LinkSetName(link, newName)
 }
@@ -104,10 +120,34 @@ func (*NetlinkAdapter) RouteDel(route *netlink.Route) error {
 	return netlink.RouteDel(route)
 }
 
+func (*NetlinkAdapter) RouteGet(destination net.IP) ([]netlink.Route, error) {
+	return netlink.RouteGet(destination)
+}
+
+func (*NetlinkAdapter) RouteListFiltered(family int, filter *netlink.Route, filterMask uint64) ([]netlink.Route, error) {
+	return netlink.RouteListFiltered(family, filter, filterMask)
+}
+
+func (*NetlinkAdapter) RuleAdd(rule *netlink.Rule) error {
+	return netlink.RuleAdd(rule)
+}
+
+func (*NetlinkAdapter) RuleDel(rule *netlink.Rule) error {
+	return netlink.RuleDel(rule)
+}
+
+func (*NetlinkAdapter) RuleListFiltered(family int, filter *netlink.Rule, filterMask uint64) ([]netlink.Rule, error) {
+	return netlink.RuleListFiltered(family, filter, filterMask)
+}
+
 func (*NetlinkAdapter) QdiscAdd(qdisc netlink.Qdisc) error {
 	return netlink.QdiscAdd(qdisc)
 }
 
+func (*NetlinkAdapter) FilterList(link netlink.Link, parent uint32) ([]netlink.Filter, error) {
+	return netlink.FilterList(link, parent)
+}
+
 func (*NetlinkAdapter) FilterAdd(filter netlink.Filter) error {
 	return netlink.FilterAdd(filter)
 }
@@ -115,3 +155,15 @@ func (*NetlinkAdapter) FilterAdd(filter netlink.Filter) error {
 func (*NetlinkAdapter) TickInUsec() float64 {
 	return netlink.TickInUsec()
 }
+
+func (*NetlinkAdapter) RouteSubscribe(ch chan<- netlink.RouteUpdate, done <-chan struct{}) error {
+	return netlink.RouteSubscribe(ch, done)
+}
+
+func (*NetlinkAdapter) NeighSubscribe(ch chan<- netlink.NeighUpdate, done <-chan struct{}) error {
+	return netlink.NeighSubscribe(ch, done)
+}
+
+func (*NetlinkAdapter) LinkSubscribe(ch chan<- netlink.LinkUpdate, done <-chan struct{}) error {
+	return netlink.LinkSubscribe(ch, done)
+}
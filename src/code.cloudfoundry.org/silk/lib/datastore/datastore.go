@@ -124,3 +124,22 @@ func (c *Store) ReadAll(filePath string) (map[string]Container, error) {
 	}
 	return pool, nil
 }
+
+// ReadAllLocked reads the containers and calls f with them while holding the
+// lock, so that no container is added or deleted until f returns. f must not
+// use the store, since the lock is not reentrant.
+func (c *Store) ReadAllLocked(filePath string, f func(map[string]Container) error) error {
+	locker := c.LockerNew(filePath)
+	file, err := locker.Open()
+	if err != nil {
+		return fmt.Errorf("open lock: %s", err)
+	}
+	defer file.Close()
+
+	pool := make(map[string]Container)
+	err = c.Serializer.DecodeAll(file, &pool)
+	if err != nil {
+		return fmt.Errorf("decoding file: %s", err)
+	}
+	return f(pool)
+}
@@ -3,6 +3,7 @@ package datastore_test
 import (
 	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
 
@@ -245,4 +246,67 @@ var _ = Describe("Datastore", func() {
 			})
 		})
 	})
+
+	Context("when reading from datastore while holding the lock", func() {
+		var lockedFile *libfakes.LockedFile
+
+		BeforeEach(func() {
+			lockedFile = &libfakes.LockedFile{}
+			locker.OpenReturns(lockedFile, nil)
+			serializer.DecodeAllStub = func(file io.ReadSeeker, pool interface{}) error {
+				(*pool.(*map[string]datastore.Container))[handle] = datastore.Container{Handle: handle, IP: ip}
+				return nil
+			}
+		})
+
+		It("calls the function with the containers before releasing the lock", func() {
+			var containers map[string]datastore.Container
+			err := store.ReadAllLocked(filePath, func(c map[string]datastore.Container) error {
+				containers = c
+				Expect(lockedFile.CloseCallCount()).To(Equal(0))
+				return nil
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(containers).To(Equal(map[string]datastore.Container{handle: {Handle: handle, IP: ip}}))
+			Expect(lockerNewFilePath).To(Equal(filePath))
+			Expect(lockedFile.CloseCallCount()).To(Equal(1))
+			Expect(serializer.EncodeAndOverwriteCallCount()).To(Equal(0))
+		})
+
+		It("returns the error of the function", func() {
+			err := store.ReadAllLocked(filePath, func(map[string]datastore.Container) error {
+				return errors.New("potato")
+			})
+			Expect(err).To(MatchError("potato"))
+			Expect(lockedFile.CloseCallCount()).To(Equal(1))
+		})
+
+		Context("when file locker fails to open", func() {
+			BeforeEach(func() {
+				locker.OpenReturns(nil, errors.New("potato"))
+			})
+			It("wraps and returns the error without calling the function", func() {
+				err := store.ReadAllLocked(filePath, func(map[string]datastore.Container) error {
+					Fail("called the function")
+					return nil
+				})
+				Expect(err).To(MatchError("open lock: potato"))
+			})
+		})
+
+		Context("when serializer fails to decode", func() {
+			BeforeEach(func() {
+				serializer.DecodeAllStub = nil
+				serializer.DecodeAllReturns(errors.New("potato"))
+			})
+			It("wraps and returns the error without calling the function", func() {
+				err := store.ReadAllLocked(filePath, func(map[string]datastore.Container) error {
+					Fail("called the function")
+					return nil
+				})
+				Expect(err).To(MatchError("decoding file: potato"))
+			})
+		})
+	})
 })
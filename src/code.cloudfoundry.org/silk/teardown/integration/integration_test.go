@@ -44,6 +44,8 @@ var _ = BeforeEach(func() {
 		VNI:                 GinkgoParallelProcess(),
 		OverlayNetworks:     overlayNetworks,
 	}
+	vtepConfig.Encapsulation, err = vtep.NewEncapsulation(vtep.EncapsulationVXLANGBP)
+	Expect(err).NotTo(HaveOccurred())
 
 	serverListenAddr = fmt.Sprintf("127.0.0.1:%d", 40000+GinkgoParallelProcess())
 	datastoreFile, _ := os.CreateTemp("", "-datastore")